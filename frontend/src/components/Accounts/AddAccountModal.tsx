@@ -93,7 +93,7 @@ export default function AddAccountModal({ isOpen, onClose }: AddAccountModalProp
                 className="input w-full"
                 placeholder="1234"
                 required
-                maxLength={4}
+                maxLength={6}
               />
             </div>
           </>
@@ -81,6 +81,7 @@ export default function TransactionFilters({
             <option value="fee">Frais</option>
             <option value="deposit">Dépôt</option>
             <option value="withdrawal">Retrait</option>
+            <option value="corporate_action">Opération sur titre</option>
             <option value="other">Autre</option>
           </select>
         </div>
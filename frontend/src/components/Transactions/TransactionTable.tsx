@@ -27,6 +27,7 @@ const TRANSACTION_TYPES = [
   { value: 'deposit', label: 'Dépôt' },
   { value: 'withdrawal', label: 'Retrait' },
   { value: 'fee', label: 'Frais' },
+  { value: 'corporate_action', label: 'Opération sur titre' },
   { value: 'other', label: 'Autre' },
 ]
 
@@ -95,6 +96,7 @@ export default function TransactionTable({
       deposit: 'Dépôt',
       withdrawal: 'Retrait',
       interest: 'Intérêts',
+      corporate_action: 'Opération sur titre',
       other: 'Autre',
     }
     return labels[type] || type
@@ -109,6 +111,7 @@ export default function TransactionTable({
       deposit: 'text-success',
       withdrawal: 'text-error',
       interest: 'text-success',
+      corporate_action: 'text-accent-primary',
       other: 'text-text-muted',
     }
     return colors[type] || 'text-text-secondary'
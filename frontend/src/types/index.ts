@@ -22,6 +22,8 @@ export interface Transaction {
   fees: string | number // Can be string from API
   transaction_type: string
   status: string
+  tags: string[]
+  note: string
 }
 
 export interface Asset {
@@ -55,6 +57,7 @@ export interface AssetPosition {
   unrealized_gain_pct: number
   total_fees: number
   currency: string
+  allocation_pct: number
   purchases?: Array<{
     date: string
     quantity: number
@@ -70,6 +73,7 @@ export interface Performance {
   realized_gains: number
   unrealized_gains: number
   performance_pct: number
+  unpriced_assets?: string[]
   time_series: PerformancePoint[]
 }
 
@@ -0,0 +1,120 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"valhafin/internal/repository/database"
+)
+
+// APIError describes a stable error code returned by the API with its HTTP status
+type APIError struct {
+	Code    string
+	Status  int
+	Message string
+}
+
+// WithMessage returns a copy of the error with a request-specific message
+func (e APIError) WithMessage(message string) APIError {
+	e.Message = message
+	return e
+}
+
+// Error implements the error interface
+func (e APIError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+// Client errors
+var (
+	ErrInvalidRequest      = APIError{Code: "INVALID_REQUEST", Status: http.StatusBadRequest, Message: "Invalid request"}
+	ErrValidation          = APIError{Code: "VALIDATION_ERROR", Status: http.StatusBadRequest, Message: "Validation failed"}
+	ErrInvalidCredentials  = APIError{Code: "INVALID_CREDENTIALS", Status: http.StatusBadRequest, Message: "Invalid credentials"}
+	ErrInvalidCode         = APIError{Code: "INVALID_CODE", Status: http.StatusBadRequest, Message: "Invalid verification code"}
+	ErrInvalidPlatform     = APIError{Code: "INVALID_PLATFORM", Status: http.StatusBadRequest, Message: "Invalid platform"}
+	ErrInvalidDate         = APIError{Code: "INVALID_DATE", Status: http.StatusBadRequest, Message: "Invalid date"}
+	ErrInvalidDateRange    = APIError{Code: "INVALID_DATE_RANGE", Status: http.StatusBadRequest, Message: "Invalid date range"}
+	ErrInvalidSort         = APIError{Code: "INVALID_SORT", Status: http.StatusBadRequest, Message: "Invalid sort parameters"}
+	ErrInvalidPeriod       = APIError{Code: "INVALID_PERIOD", Status: http.StatusBadRequest, Message: "Invalid period"}
+	ErrInvalidQuery        = APIError{Code: "INVALID_QUERY", Status: http.StatusBadRequest, Message: "Invalid query"}
+	ErrInvalidISIN         = APIError{Code: "INVALID_ISIN", Status: http.StatusBadRequest, Message: "Invalid ISIN"}
+	ErrMissingISIN         = APIError{Code: "MISSING_ISIN", Status: http.StatusBadRequest, Message: "ISIN is required"}
+	ErrInvalidFile         = APIError{Code: "INVALID_FILE", Status: http.StatusBadRequest, Message: "Invalid file"}
+	ErrCSVParse            = APIError{Code: "CSV_PARSE_ERROR", Status: http.StatusBadRequest, Message: "Failed to parse CSV file"}
+	ErrTooManyQueries      = APIError{Code: "TOO_MANY_QUERIES", Status: http.StatusBadRequest, Message: "Too many queries"}
+	ErrSearch              = APIError{Code: "SEARCH_ERROR", Status: http.StatusBadRequest, Message: "Search failed"}
+	ErrBenchmark           = APIError{Code: "BENCHMARK_ERROR", Status: http.StatusBadRequest, Message: "Failed to compute benchmark"}
+	ErrNotFound            = APIError{Code: "NOT_FOUND", Status: http.StatusNotFound, Message: "Resource not found"}
+	ErrAssetNotFound       = APIError{Code: "ASSET_NOT_FOUND", Status: http.StatusNotFound, Message: "Asset not found"}
+	ErrSyncInProgress      = APIError{Code: "SYNC_IN_PROGRESS", Status: http.StatusConflict, Message: "A synchronization is already running for this account"}
+	ErrUnsupportedPlatform = APIError{Code: "UNSUPPORTED_PLATFORM", Status: http.StatusBadRequest, Message: "Unsupported platform"}
+	ErrImportProfileExists = APIError{Code: "IMPORT_PROFILE_EXISTS", Status: http.StatusConflict, Message: "An import profile with this name already exists"}
+	ErrBackupConflict      = APIError{Code: "BACKUP_CONFLICT", Status: http.StatusConflict, Message: "The backup conflicts with existing transactions"}
+	ErrMoveConflict        = APIError{Code: "MOVE_CONFLICT", Status: http.StatusConflict, Message: "The moved transactions already exist on the target platform"}
+	ErrPayloadTooLarge     = APIError{Code: "PAYLOAD_TOO_LARGE", Status: http.StatusRequestEntityTooLarge, Message: "Request body is too large"}
+)
+
+// Server errors
+var (
+	ErrInternal            = APIError{Code: "INTERNAL_ERROR", Status: http.StatusInternalServerError, Message: "Internal error"}
+	ErrDatabase            = APIError{Code: "DATABASE_ERROR", Status: http.StatusInternalServerError, Message: "Database error"}
+	ErrEncryption          = APIError{Code: "ENCRYPTION_ERROR", Status: http.StatusInternalServerError, Message: "Failed to encrypt credentials"}
+	ErrDecryption          = APIError{Code: "DECRYPTION_ERROR", Status: http.StatusInternalServerError, Message: "Failed to decrypt credentials"}
+	ErrParsing             = APIError{Code: "PARSING_ERROR", Status: http.StatusInternalServerError, Message: "Failed to parse data"}
+	ErrAuth                = APIError{Code: "AUTH_ERROR", Status: http.StatusInternalServerError, Message: "Authentication failed"}
+	ErrScraper             = APIError{Code: "SCRAPER_ERROR", Status: http.StatusInternalServerError, Message: "Scraper error"}
+	ErrSync                = APIError{Code: "SYNC_ERROR", Status: http.StatusInternalServerError, Message: "Synchronization failed"}
+	ErrPlatformUnavailable = APIError{Code: "PLATFORM_UNAVAILABLE", Status: http.StatusBadGateway, Message: "The platform could not be reached"}
+	ErrService             = APIError{Code: "SERVICE_ERROR", Status: http.StatusInternalServerError, Message: "Service unavailable"}
+	ErrPrice               = APIError{Code: "PRICE_ERROR", Status: http.StatusInternalServerError, Message: "Failed to retrieve prices"}
+	ErrPerformance         = APIError{Code: "PERFORMANCE_ERROR", Status: http.StatusInternalServerError, Message: "Failed to calculate performance"}
+	ErrFees                = APIError{Code: "FEES_ERROR", Status: http.StatusInternalServerError, Message: "Failed to calculate fees"}
+	ErrUpdate              = APIError{Code: "UPDATE_ERROR", Status: http.StatusInternalServerError, Message: "Update failed"}
+	ErrUpdateFailed        = APIError{Code: "UPDATE_FAILED", Status: http.StatusInternalServerError, Message: "Update failed"}
+)
+
+// apiErrorRegistry indexes every known error by code
+var apiErrorRegistry = registerAPIErrors(
+	ErrInvalidRequest, ErrValidation, ErrInvalidCredentials, ErrInvalidCode, ErrInvalidPlatform,
+	ErrInvalidDate, ErrInvalidDateRange, ErrInvalidSort, ErrInvalidPeriod, ErrInvalidQuery,
+	ErrInvalidISIN, ErrMissingISIN, ErrInvalidFile, ErrCSVParse, ErrTooManyQueries, ErrSearch,
+	ErrBenchmark, ErrNotFound, ErrAssetNotFound, ErrSyncInProgress, ErrUnsupportedPlatform, ErrImportProfileExists,
+	ErrBackupConflict, ErrMoveConflict, ErrPayloadTooLarge,
+	ErrInternal, ErrDatabase, ErrEncryption, ErrDecryption, ErrParsing, ErrAuth, ErrScraper,
+	ErrSync, ErrPlatformUnavailable, ErrService, ErrPrice, ErrPerformance, ErrFees, ErrUpdate, ErrUpdateFailed,
+)
+
+// registerAPIErrors builds the registry and panics on duplicated codes
+func registerAPIErrors(errs ...APIError) map[string]APIError {
+	registry := make(map[string]APIError, len(errs))
+	for _, e := range errs {
+		if _, exists := registry[e.Code]; exists {
+			panic(fmt.Sprintf("duplicate API error code: %s", e.Code))
+		}
+		registry[e.Code] = e
+	}
+	return registry
+}
+
+// LookupAPIError returns the registered error for a code
+func LookupAPIError(code string) (APIError, bool) {
+	e, ok := apiErrorRegistry[code]
+	return e, ok
+}
+
+// writeAPIError sends an error response using the status mapped to the error code
+func writeAPIError(w http.ResponseWriter, apiErr APIError, details interface{}) {
+	respondError(w, apiErr.Status, apiErr.Code, apiErr.Message, details)
+}
+
+// writePlatformError writes a 400 UNSUPPORTED_PLATFORM response when err reports a
+// platform without a transactions table, and returns whether it did
+func writePlatformError(w http.ResponseWriter, err error, platform string) bool {
+	if !errors.Is(err, database.ErrUnsupportedPlatform) {
+		return false
+	}
+	writeAPIError(w, ErrUnsupportedPlatform.WithMessage(fmt.Sprintf("Platform %q is not supported", platform)), map[string]string{
+		"platform": platform,
+	})
+	return true
+}
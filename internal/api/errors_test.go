@@ -0,0 +1,129 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"valhafin/internal/repository/database"
+	"valhafin/internal/service/scraper/types"
+	"valhafin/internal/service/sync"
+)
+
+func TestAPIErrorRegistry_StatusMapping(t *testing.T) {
+	tests := []struct {
+		code   string
+		status int
+	}{
+		{"INVALID_REQUEST", http.StatusBadRequest},
+		{"VALIDATION_ERROR", http.StatusBadRequest},
+		{"NOT_FOUND", http.StatusNotFound},
+		{"DATABASE_ERROR", http.StatusInternalServerError},
+		{"SYNC_ERROR", http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			apiErr, ok := LookupAPIError(tt.code)
+			if !ok {
+				t.Fatalf("Code %s is not registered", tt.code)
+			}
+			if apiErr.Status != tt.status {
+				t.Errorf("Status = %d, want %d", apiErr.Status, tt.status)
+			}
+		})
+	}
+
+	if _, ok := LookupAPIError("UNKNOWN_CODE"); ok {
+		t.Error("Unknown code should not be registered")
+	}
+}
+
+func TestAPIErrorRegistry_ValidEntries(t *testing.T) {
+	for code, apiErr := range apiErrorRegistry {
+		if apiErr.Code != code {
+			t.Errorf("Registry key %s does not match code %s", code, apiErr.Code)
+		}
+		if apiErr.Status < 400 || apiErr.Status > 599 {
+			t.Errorf("Code %s has non-error status %d", code, apiErr.Status)
+		}
+		if apiErr.Message == "" {
+			t.Errorf("Code %s has no default message", code)
+		}
+	}
+}
+
+func TestWriteAPIError(t *testing.T) {
+	rec := httptest.NewRecorder()
+	writeAPIError(rec, ErrNotFound.WithMessage("Account not found"), map[string]string{"id": "abc"})
+
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+
+	var resp ErrorResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if resp.Error.Code != "NOT_FOUND" {
+		t.Errorf("Code = %s, want NOT_FOUND", resp.Error.Code)
+	}
+	if resp.Error.Message != "Account not found" {
+		t.Errorf("Message = %s, want 'Account not found'", resp.Error.Message)
+	}
+	if resp.Error.Details == nil {
+		t.Error("Details should be included")
+	}
+
+	// WithMessage must not alter the registered error
+	if ErrNotFound.Message != "Resource not found" {
+		t.Errorf("Registered message was modified: %s", ErrNotFound.Message)
+	}
+}
+
+func TestWritePlatformError(t *testing.T) {
+	rec := httptest.NewRecorder()
+	if writePlatformError(rec, errors.New("connection refused"), "traderepublic") {
+		t.Error("other errors must be left to the caller")
+	}
+
+	rec = httptest.NewRecorder()
+	if !writePlatformError(rec, database.ValidateTransactionPlatform("degiro"), "degiro") {
+		t.Fatal("expected an unknown platform to be reported")
+	}
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+
+	var resp ErrorResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if resp.Error.Code != "UNSUPPORTED_PLATFORM" {
+		t.Errorf("Code = %s, want UNSUPPORTED_PLATFORM", resp.Error.Code)
+	}
+}
+
+func TestSyncAPIError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"sync in progress", sync.ErrSyncInProgress, "SYNC_IN_PROGRESS"},
+		{"unsupported platform", fmt.Errorf("%w: kraken", sync.ErrUnsupportedPlatform), "UNSUPPORTED_PLATFORM"},
+		{"authentication", fmt.Errorf("failed to fetch transactions: %w", types.NewAuthError("binance", "bad key", nil)), "INVALID_CREDENTIALS"},
+		{"platform unreachable", fmt.Errorf("failed to fetch transactions: %w", types.NewNetworkError("binance", "timeout", nil)), "PLATFORM_UNAVAILABLE"},
+		{"storage", errors.New("failed to store transactions"), "SYNC_ERROR"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := syncAPIError(tt.err); got.Code != tt.want {
+				t.Errorf("syncAPIError() = %s, want %s", got.Code, tt.want)
+			}
+		})
+	}
+}
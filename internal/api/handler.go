@@ -35,6 +35,10 @@ type Handler struct {
 	FeesService        fees.Service
 	Version            string
 	StartTime          time.Time
+	// SymbolResolution runs the background symbol resolution jobs
+	SymbolResolution *SymbolResolutionJobs
+	// PerformanceCache is the cache wrapping PerformanceService (nil when disabled)
+	PerformanceCache *performance.CachedService
 }
 
 // NewHandler creates a new Handler with dependencies
@@ -49,6 +53,7 @@ func NewHandler(db *database.DB, encryptionService *encryptionsvc.EncryptionServ
 		FeesService:        feesService,
 		Version:            "dev",
 		StartTime:          time.Now(),
+		SymbolResolution:   NewSymbolResolutionJobs(),
 	}
 }
 
@@ -3,6 +3,8 @@ package api
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"strings"
 	"valhafin/internal/domain/models"
@@ -15,6 +17,11 @@ type CreateAccountRequest struct {
 	Name        string                 `json:"name"`
 	Platform    string                 `json:"platform"`
 	Credentials map[string]interface{} `json:"credentials"`
+	// Currency is the ISO 4217 default currency of the account, EUR when omitted
+	Currency string `json:"currency,omitempty"`
+	// DecimalStyle tells how the amounts of the CSV imports are written: auto (default), dot
+	// ("1,234.56") or comma ("1.234,56"). It decides how "1,234" is read.
+	DecimalStyle string `json:"decimal_style,omitempty"`
 }
 
 // CreateAccountHandler creates a new account with encrypted credentials
@@ -31,64 +38,50 @@ type CreateAccountRequest struct {
 func (h *Handler) CreateAccountHandler(w http.ResponseWriter, r *http.Request) {
 	var req CreateAccountRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body", nil)
+		writeAPIError(w, ErrInvalidRequest.WithMessage("Invalid request body"), nil)
 		return
 	}
 
-	// Validate required fields
-	if req.Name == "" {
-		respondError(w, http.StatusBadRequest, "VALIDATION_ERROR", "Account name is required", map[string]string{
-			"field": "name",
-		})
-		return
+	req.Currency = strings.ToUpper(strings.TrimSpace(req.Currency))
+	if req.Currency == "" {
+		req.Currency = models.DefaultCurrency
 	}
-
-	if req.Platform == "" {
-		respondError(w, http.StatusBadRequest, "VALIDATION_ERROR", "Platform is required", map[string]string{
-			"field": "platform",
-		})
-		return
+	req.DecimalStyle = strings.ToLower(strings.TrimSpace(req.DecimalStyle))
+	if req.DecimalStyle == "" {
+		req.DecimalStyle = models.DecimalStyleAuto
 	}
 
-	if req.Credentials == nil || len(req.Credentials) == 0 {
-		respondError(w, http.StatusBadRequest, "VALIDATION_ERROR", "Credentials are required", map[string]string{
-			"field": "credentials",
-		})
-		return
-	}
-
-	// Validate platform-specific credentials
-	if err := h.Validator.ValidateCredentials(req.Platform, req.Credentials); err != nil {
-		respondError(w, http.StatusBadRequest, "INVALID_CREDENTIALS", err.Error(), map[string]string{
-			"platform": req.Platform,
-		})
+	if errs := h.validateCreateAccountRequest(req); len(errs) > 0 {
+		writeValidationErrors(w, errs)
 		return
 	}
 
 	// Convert credentials to JSON string
 	credentialsJSON, err := json.Marshal(req.Credentials)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to process credentials", nil)
+		writeAPIError(w, ErrInternal.WithMessage("Failed to process credentials"), nil)
 		return
 	}
 
 	// Encrypt credentials
 	encryptedCredentials, err := h.Encryption.Encrypt(string(credentialsJSON))
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "ENCRYPTION_ERROR", "Failed to encrypt credentials", nil)
+		writeAPIError(w, ErrEncryption.WithMessage("Failed to encrypt credentials"), nil)
 		return
 	}
 
 	// Create account model
 	account := &models.Account{
-		Name:        req.Name,
-		Platform:    req.Platform,
-		Credentials: encryptedCredentials,
+		Name:         req.Name,
+		Platform:     req.Platform,
+		Credentials:  encryptedCredentials,
+		Currency:     req.Currency,
+		DecimalStyle: req.DecimalStyle,
 	}
 
 	// Save to database
 	if err := h.DB.CreateAccount(account); err != nil {
-		respondError(w, http.StatusInternalServerError, "DATABASE_ERROR", "Failed to create account", nil)
+		writeAPIError(w, ErrDatabase.WithMessage("Failed to create account"), nil)
 		return
 	}
 
@@ -96,6 +89,63 @@ func (h *Handler) CreateAccountHandler(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, http.StatusCreated, account)
 }
 
+// validateCreateAccountRequest collects every problem in an account creation
+// request so the client can fix them all in one round trip
+func (h *Handler) validateCreateAccountRequest(req CreateAccountRequest) ValidationErrors {
+	var errs ValidationErrors
+
+	if req.Name == "" {
+		errs.Add("name", "Account name is required")
+	}
+	if req.Platform == "" {
+		errs.Add("platform", "Platform is required")
+	}
+	if len(req.Credentials) == 0 {
+		errs.Add("credentials", "Credentials are required")
+	}
+	if req.Currency != "" && !models.IsCurrencyCode(req.Currency) {
+		errs.Add("currency", "Currency must be an ISO 4217 code (e.g. EUR, USD)")
+	}
+	if req.DecimalStyle != "" && !models.IsDecimalStyle(req.DecimalStyle) {
+		errs.Add("decimal_style", "Decimal style must be one of: auto, dot, comma")
+	}
+
+	// Platform-specific checks only make sense once a platform is known
+	if req.Platform != "" {
+		if err := h.Validator.ValidateCredentials(req.Platform, req.Credentials); err != nil {
+			var credentialErrs ValidationErrors
+			if errors.As(err, &credentialErrs) {
+				errs = append(errs, credentialErrs...)
+			} else {
+				errs.Add("credentials", err.Error())
+			}
+		}
+	}
+
+	return errs
+}
+
+// writeValidationErrors responds with a single 400 listing every field error.
+// Requests whose only problems are in the credentials are reported as
+// INVALID_CREDENTIALS, anything else as VALIDATION_ERROR.
+func writeValidationErrors(w http.ResponseWriter, errs ValidationErrors) {
+	apiErr := ErrInvalidCredentials
+	for _, fieldErr := range errs {
+		if fieldErr.Field == "name" || fieldErr.Field == "platform" || fieldErr.Field == "credentials" ||
+			fieldErr.Field == "currency" || fieldErr.Field == "decimal_style" {
+			apiErr = ErrValidation
+			break
+		}
+	}
+
+	message := errs[0].Message
+	if len(errs) > 1 {
+		message = fmt.Sprintf("%d validation errors", len(errs))
+	}
+
+	writeAPIError(w, apiErr.WithMessage(message), []FieldError(errs))
+}
+
 // GetAccountsHandler lists all accounts
 // @Summary Lister tous les comptes
 // @Description Récupère la liste de tous les comptes financiers
@@ -107,7 +157,7 @@ func (h *Handler) CreateAccountHandler(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) GetAccountsHandler(w http.ResponseWriter, r *http.Request) {
 	accounts, err := h.DB.GetAllAccounts()
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "DATABASE_ERROR", "Failed to retrieve accounts", nil)
+		writeAPIError(w, ErrDatabase.WithMessage("Failed to retrieve accounts"), nil)
 		return
 	}
 
@@ -130,17 +180,17 @@ func (h *Handler) GetAccountHandler(w http.ResponseWriter, r *http.Request) {
 	accountID := vars["id"]
 
 	if accountID == "" {
-		respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "Account ID is required", nil)
+		writeAPIError(w, ErrInvalidRequest.WithMessage("Account ID is required"), nil)
 		return
 	}
 
 	account, err := h.DB.GetAccountByID(accountID)
 	if err != nil {
 		if err == sql.ErrNoRows || (err != nil && strings.Contains(err.Error(), "no rows")) {
-			respondError(w, http.StatusNotFound, "NOT_FOUND", "Account not found", nil)
+			writeAPIError(w, ErrNotFound.WithMessage("Account not found"), nil)
 			return
 		}
-		respondError(w, http.StatusInternalServerError, "DATABASE_ERROR", "Failed to retrieve account", nil)
+		writeAPIError(w, ErrDatabase.WithMessage("Failed to retrieve account"), nil)
 		return
 	}
 
@@ -163,7 +213,7 @@ func (h *Handler) DeleteAccountHandler(w http.ResponseWriter, r *http.Request) {
 	accountID := vars["id"]
 
 	if accountID == "" {
-		respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "Account ID is required", nil)
+		writeAPIError(w, ErrInvalidRequest.WithMessage("Account ID is required"), nil)
 		return
 	}
 
@@ -171,16 +221,16 @@ func (h *Handler) DeleteAccountHandler(w http.ResponseWriter, r *http.Request) {
 	_, err := h.DB.GetAccountByID(accountID)
 	if err != nil {
 		if err == sql.ErrNoRows || (err != nil && strings.Contains(err.Error(), "no rows")) {
-			respondError(w, http.StatusNotFound, "NOT_FOUND", "Account not found", nil)
+			writeAPIError(w, ErrNotFound.WithMessage("Account not found"), nil)
 			return
 		}
-		respondError(w, http.StatusInternalServerError, "DATABASE_ERROR", "Failed to retrieve account", nil)
+		writeAPIError(w, ErrDatabase.WithMessage("Failed to retrieve account"), nil)
 		return
 	}
 
 	// Delete account (cascade will handle associated data)
 	if err := h.DB.DeleteAccount(accountID); err != nil {
-		respondError(w, http.StatusInternalServerError, "DATABASE_ERROR", "Failed to delete account", nil)
+		writeAPIError(w, ErrDatabase.WithMessage("Failed to delete account"), nil)
 		return
 	}
 
@@ -188,3 +238,76 @@ func (h *Handler) DeleteAccountHandler(w http.ResponseWriter, r *http.Request) {
 		"message": "Account deleted successfully",
 	})
 }
+
+// RotateCredentialsHandler re-encrypts the credentials of an account under the current key
+// @Summary Rechiffrer les credentials d'un compte
+// @Description Déchiffre les credentials avec la clé actuelle ou une ancienne clé et les rechiffre avec la clé actuelle
+// @Tags accounts
+// @Produce json
+// @Param id path string true "ID du compte"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/accounts/{id}/rotate-credentials [post]
+func (h *Handler) RotateCredentialsHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	accountID := vars["id"]
+
+	if accountID == "" {
+		writeAPIError(w, ErrInvalidRequest.WithMessage("Account ID is required"), nil)
+		return
+	}
+
+	account, err := h.DB.GetAccountByID(accountID)
+	if err != nil {
+		if err == sql.ErrNoRows || (err != nil && strings.Contains(err.Error(), "no rows")) {
+			writeAPIError(w, ErrNotFound.WithMessage("Account not found"), nil)
+			return
+		}
+		writeAPIError(w, ErrDatabase.WithMessage("Failed to retrieve account"), nil)
+		return
+	}
+
+	// Decrypt with the current key first, then with previous keys
+	credentialsJSON, usedPreviousKey, err := h.Encryption.DecryptWithFallback(account.Credentials)
+	if err != nil {
+		writeAPIError(w, ErrDecryption.WithMessage("Failed to decrypt credentials with current or previous keys"), nil)
+		return
+	}
+
+	if !usedPreviousKey {
+		respondJSON(w, http.StatusOK, map[string]interface{}{
+			"rotated": false,
+			"message": "Credentials are already encrypted with the current key",
+		})
+		return
+	}
+
+	encryptedCredentials, err := h.Encryption.Encrypt(credentialsJSON)
+	if err != nil {
+		writeAPIError(w, ErrEncryption.WithMessage("Failed to encrypt credentials"), nil)
+		return
+	}
+
+	if err := h.DB.UpdateAccountCredentials(accountID, encryptedCredentials); err != nil {
+		writeAPIError(w, ErrDatabase.WithMessage("Failed to update credentials"), nil)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"rotated": true,
+		"message": "Credentials re-encrypted with the current key",
+	})
+}
+
+// GetPlatformsHandler lists the supported platforms and their capabilities
+// @Summary Lister les plateformes supportées
+// @Description Récupère les plateformes supportées et leurs capacités (2FA, synchronisation incrémentale, identification des actifs)
+// @Tags accounts
+// @Produce json
+// @Success 200 {array} models.Platform
+// @Router /api/platforms [get]
+func (h *Handler) GetPlatformsHandler(w http.ResponseWriter, r *http.Request) {
+	respondJSON(w, http.StatusOK, models.Platforms())
+}
@@ -0,0 +1,119 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"valhafin/internal/domain/models"
+	"valhafin/internal/repository/database"
+)
+
+// GetMigrationsHandler reports the database migration status
+// @Summary État des migrations
+// @Description Retourne les migrations appliquées, la version actuelle du schéma et les migrations en attente ou manquantes
+// @Tags admin
+// @Produce json
+// @Success 200 {object} database.MigrationStatus
+// @Failure 500 {object} ErrorResponse
+// @Router /api/admin/migrations [get]
+func (h *Handler) GetMigrationsHandler(w http.ResponseWriter, r *http.Request) {
+	status, err := h.DB.GetMigrationStatus()
+	if err != nil {
+		writeAPIError(w, ErrDatabase.WithMessage("Failed to retrieve migration status"), map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, status)
+}
+
+// GetTransactionTypeMappingsHandler lists the active keyword to transaction type mappings
+// @Summary Correspondances des types de transaction
+// @Description Liste les mots-clés actifs (personnalisés puis intégrés) utilisés pour déterminer le type des transactions
+// @Tags admin
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Router /api/admin/transaction-types [get]
+func (h *Handler) GetTransactionTypeMappingsHandler(w http.ResponseWriter, r *http.Request) {
+	mappings := models.ActiveTypeMappings()
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"mappings": mappings,
+		"count":    len(mappings),
+	})
+}
+
+// ReclassifyTransactionsHandler infers again the type of the stored Trade Republic transactions
+// @Summary Reclasser les transactions
+// @Description Recalcule le type des transactions Trade Republic enregistrées (éventuellement d'un seul compte) avec les correspondances de mots-clés actuelles et met à jour celles dont le type change (idempotent). Seule une correspondance de mot-clé change un type ; dry_run=true retourne les changements sans les écrire
+// @Tags admin
+// @Produce json
+// @Param platform query string false "Plateforme (seule traderepublic est acceptée) ; requise sans account_id"
+// @Param account_id query string false "Limiter le reclassement à un compte"
+// @Param dry_run query bool false "Retourner les changements sans les écrire"
+// @Success 200 {object} database.ReclassifyResult
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/admin/reclassify [post]
+func (h *Handler) ReclassifyTransactionsHandler(w http.ResponseWriter, r *http.Request) {
+	opts := database.ReclassifyOptions{
+		Platform:  strings.TrimSpace(r.URL.Query().Get("platform")),
+		AccountID: strings.TrimSpace(r.URL.Query().Get("account_id")),
+	}
+
+	if value := r.URL.Query().Get("dry_run"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			writeAPIError(w, ErrValidation.WithMessage("Invalid dry_run value (use true or false)"), map[string]string{
+				"field": "dry_run",
+			})
+			return
+		}
+		opts.DryRun = parsed
+	}
+
+	if opts.AccountID != "" {
+		account, err := h.DB.GetAccountByIDContext(r.Context(), opts.AccountID)
+		if err != nil {
+			writeAPIError(w, ErrNotFound.WithMessage("Account not found"), nil)
+			return
+		}
+		if opts.Platform != "" && opts.Platform != account.Platform {
+			writeAPIError(w, ErrValidation.WithMessage("platform does not match the account platform"), map[string]string{
+				"field": "platform",
+			})
+			return
+		}
+		opts.Platform = account.Platform
+	}
+
+	if opts.Platform == "" {
+		writeAPIError(w, ErrInvalidRequest.WithMessage("platform or account_id is required"), nil)
+		return
+	}
+	if err := database.ValidateTransactionPlatform(opts.Platform); err != nil {
+		writePlatformError(w, err, opts.Platform)
+		return
+	}
+
+	result, err := h.DB.ReclassifyTransactions(r.Context(), opts)
+	if err != nil {
+		if errors.Is(err, database.ErrReclassifyUnsupported) {
+			writeAPIError(w, ErrUnsupportedPlatform.WithMessage(err.Error()), map[string]string{
+				"platform": opts.Platform,
+			})
+			return
+		}
+		writeAPIError(w, ErrDatabase.WithMessage("Failed to reclassify transactions"), map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	log.Printf("INFO: Reclassified %d of %d %s transactions (dry run: %v)", result.Updated, result.Examined, opts.Platform, opts.DryRun)
+	respondJSON(w, http.StatusOK, result)
+}
@@ -0,0 +1,82 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"valhafin/internal/domain/models"
+)
+
+// CreateAlertRequest represents the request body for creating an alert
+type CreateAlertRequest struct {
+	Type      string  `json:"type"`
+	ISIN      *string `json:"isin,omitempty"`
+	Threshold float64 `json:"threshold"`
+	Enabled   *bool   `json:"enabled,omitempty"`
+}
+
+// CreateAlertHandler creates a new alert rule
+// @Summary Créer une alerte
+// @Description Crée une règle d'alerte (unrealized_loss: perte latente en % d'un actif ou du portefeuille, negative_cash: solde espèces sous le seuil)
+// @Tags alerts
+// @Accept json
+// @Produce json
+// @Param alert body CreateAlertRequest true "Règle d'alerte"
+// @Success 201 {object} models.Alert
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/alerts [post]
+func (h *Handler) CreateAlertHandler(w http.ResponseWriter, r *http.Request) {
+	var req CreateAlertRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body", nil)
+		return
+	}
+
+	alert := &models.Alert{
+		Type:      req.Type,
+		Threshold: req.Threshold,
+		Enabled:   true,
+	}
+	if req.ISIN != nil && *req.ISIN != "" {
+		isin := strings.ToUpper(strings.TrimSpace(*req.ISIN))
+		alert.ISIN = &isin
+	}
+	if req.Enabled != nil {
+		alert.Enabled = *req.Enabled
+	}
+
+	if err := alert.Validate(); err != nil {
+		respondError(w, http.StatusBadRequest, "VALIDATION_ERROR", err.Error(), nil)
+		return
+	}
+
+	if err := h.DB.CreateAlert(alert); err != nil {
+		respondError(w, http.StatusInternalServerError, "DATABASE_ERROR", "Failed to create alert", nil)
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, alert)
+}
+
+// GetAlertsHandler lists all alert rules
+// @Summary Lister les alertes
+// @Description Récupère toutes les règles d'alerte et leur état de déclenchement
+// @Tags alerts
+// @Produce json
+// @Success 200 {array} models.Alert
+// @Failure 500 {object} ErrorResponse
+// @Router /api/alerts [get]
+func (h *Handler) GetAlertsHandler(w http.ResponseWriter, r *http.Request) {
+	alerts, err := h.DB.GetAllAlerts()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "DATABASE_ERROR", "Failed to retrieve alerts", nil)
+		return
+	}
+
+	if alerts == nil {
+		alerts = []models.Alert{}
+	}
+
+	respondJSON(w, http.StatusOK, alerts)
+}
@@ -0,0 +1,94 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"valhafin/internal/service/price"
+	"valhafin/internal/utils"
+)
+
+// AssetNameBackfillResponse reports the naming of the assets still named "Unknown"
+type AssetNameBackfillResponse struct {
+	Total   int `json:"total"`
+	Updated int `json:"updated"`
+	// NotFound counts the assets for which the provider returned no name
+	NotFound int `json:"not_found"`
+	Failed   int `json:"failed"`
+	// Names maps the ISIN of each renamed asset to its new name
+	Names map[string]string `json:"names"`
+}
+
+// BackfillAssetNamesHandler names the assets still named "Unknown" from the provider search results
+// @Summary Compléter les noms d'actifs inconnus
+// @Description Recherche sur Yahoo Finance (par ISIN puis par symbole) le nom des actifs encore nommés "Unknown" et l'enregistre. Un nom existant n'est jamais remplacé.
+// @Tags assets
+// @Produce json
+// @Success 200 {object} AssetNameBackfillResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/assets/backfill-names [post]
+func (h *Handler) BackfillAssetNamesHandler(w http.ResponseWriter, r *http.Request) {
+	yahooService, ok := price.YahooService(h.PriceService)
+	if !ok {
+		writeAPIError(w, ErrService.WithMessage("Price service is not Yahoo Finance"), nil)
+		return
+	}
+
+	assets, err := h.DB.GetAssetsWithUnknownName(r.Context())
+	if err != nil {
+		utils.Logf(r.Context(), "ERROR: Failed to get assets with unknown name: %v", err)
+		writeAPIError(w, ErrDatabase.WithMessage("Failed to retrieve assets"), nil)
+		return
+	}
+
+	response := AssetNameBackfillResponse{Total: len(assets), Names: map[string]string{}}
+	for _, asset := range assets {
+		// Stop searching once the client is gone or the server shuts down
+		if r.Context().Err() != nil {
+			break
+		}
+
+		symbol := ""
+		if asset.Symbol != nil {
+			symbol = *asset.Symbol
+		}
+		name, outcome := h.backfillAssetName(r.Context(), yahooService, asset.ISIN, symbol)
+		switch outcome {
+		case symbolResolved:
+			response.Updated++
+			response.Names[asset.ISIN] = name
+		case symbolSkipped:
+			response.NotFound++
+		default:
+			response.Failed++
+		}
+	}
+
+	utils.Logf(r.Context(), "INFO: Asset name backfill: %d of %d assets named", response.Updated, response.Total)
+	respondJSON(w, http.StatusOK, response)
+}
+
+// backfillAssetName names an asset still named "Unknown" from the provider search results.
+// The outcome is symbolSkipped when the provider knows no name for the asset.
+func (h *Handler) backfillAssetName(ctx context.Context, yahooService *price.YahooFinanceService, isin, symbol string) (string, symbolOutcome) {
+	name, err := yahooService.LookupAssetName(isin, symbol)
+	if err != nil {
+		utils.Logf(ctx, "WARNING: Failed to look up the name of %s: %v", isin, err)
+		return "", symbolFailed
+	}
+	if name == "" {
+		return "", symbolSkipped
+	}
+
+	updated, err := h.DB.SetUnknownAssetName(ctx, isin, name)
+	if err != nil {
+		utils.Logf(ctx, "ERROR: Failed to update the name of %s: %v", isin, err)
+		return "", symbolFailed
+	}
+	if !updated {
+		// The asset was named meanwhile: its name is kept
+		return "", symbolSkipped
+	}
+
+	utils.Logf(ctx, "INFO: Named asset %s: %s", isin, name)
+	return name, symbolResolved
+}
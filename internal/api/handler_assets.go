@@ -1,36 +1,44 @@
 package api
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"math"
 	"net/http"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 	"valhafin/internal/domain/models"
 	"valhafin/internal/repository/database"
+	"valhafin/internal/service/performance"
 	"valhafin/internal/service/price"
+	"valhafin/internal/utils"
 
 	"github.com/gorilla/mux"
 )
 
 // AssetPosition represents a user's position in an asset
 type AssetPosition struct {
-	ISIN              string     `json:"isin"`
-	Name              string     `json:"name"`
-	Symbol            string     `json:"symbol,omitempty"`
-	SymbolVerified    bool       `json:"symbol_verified"`
-	Quantity          float64    `json:"quantity"`
-	AverageBuyPrice   float64    `json:"average_buy_price"`
-	CurrentPrice      float64    `json:"current_price"`
-	CurrentValue      float64    `json:"current_value"`
-	TotalInvested     float64    `json:"total_invested"`
-	UnrealizedGain    float64    `json:"unrealized_gain"`
-	UnrealizedGainPct float64    `json:"unrealized_gain_pct"`
-	Currency          string     `json:"currency"`
-	Purchases         []Purchase `json:"purchases"`
+	ISIN              string  `json:"isin"`
+	Name              string  `json:"name"`
+	Symbol            string  `json:"symbol,omitempty"`
+	SymbolVerified    bool    `json:"symbol_verified"`
+	Quantity          float64 `json:"quantity"`
+	AverageBuyPrice   float64 `json:"average_buy_price"`
+	CurrentPrice      float64 `json:"current_price"`
+	CurrentValue      float64 `json:"current_value"`
+	TotalInvested     float64 `json:"total_invested"`
+	UnrealizedGain    float64 `json:"unrealized_gain"`
+	UnrealizedGainPct float64 `json:"unrealized_gain_pct"`
+	Currency          string  `json:"currency"`
+	// AllocationPct is the share of the position in the total current value of the positions
+	AllocationPct float64    `json:"allocation_pct"`
+	Purchases     []Purchase `json:"purchases"`
 }
 
 // Purchase represents a buy transaction
@@ -84,6 +92,8 @@ func (h *Handler) GetAssetPriceHandler(w http.ResponseWriter, r *http.Request) {
 // @Param isin path string true "Code ISIN de l'actif"
 // @Param start_date query string false "Date de début (YYYY-MM-DD)"
 // @Param end_date query string false "Date de fin (YYYY-MM-DD)"
+// @Param interval query string false "Intervalle : auto (défaut, hebdomadaire au-delà d'un mois) ou 1d (journalier quelle que soit la période)"
+// @Param fill query bool false "Complète les jours sans cotation avec le dernier prix connu (défaut : false)"
 // @Success 200 {array} models.AssetPrice
 // @Failure 400 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
@@ -94,7 +104,7 @@ func (h *Handler) GetAssetPriceHistoryHandler(w http.ResponseWriter, r *http.Req
 	isin := vars["isin"]
 
 	if isin == "" {
-		respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "ISIN is required", nil)
+		writeAPIError(w, ErrInvalidRequest.WithMessage("ISIN is required"), nil)
 		return
 	}
 
@@ -109,7 +119,7 @@ func (h *Handler) GetAssetPriceHistoryHandler(w http.ResponseWriter, r *http.Req
 	if startDateStr != "" {
 		parsed, err := time.Parse("2006-01-02", startDateStr)
 		if err != nil {
-			respondError(w, http.StatusBadRequest, "INVALID_DATE", "Invalid start_date format (use YYYY-MM-DD)", nil)
+			writeAPIError(w, ErrInvalidDate.WithMessage("Invalid start_date format (use YYYY-MM-DD)"), nil)
 			return
 		}
 		startDate = parsed
@@ -118,7 +128,7 @@ func (h *Handler) GetAssetPriceHistoryHandler(w http.ResponseWriter, r *http.Req
 	if endDateStr != "" {
 		parsed, err := time.Parse("2006-01-02", endDateStr)
 		if err != nil {
-			respondError(w, http.StatusBadRequest, "INVALID_DATE", "Invalid end_date format (use YYYY-MM-DD)", nil)
+			writeAPIError(w, ErrInvalidDate.WithMessage("Invalid end_date format (use YYYY-MM-DD)"), nil)
 			return
 		}
 		endDate = parsed
@@ -126,26 +136,180 @@ func (h *Handler) GetAssetPriceHistoryHandler(w http.ResponseWriter, r *http.Req
 
 	// Validate date range
 	if startDate.After(endDate) {
-		respondError(w, http.StatusBadRequest, "INVALID_DATE_RANGE", "start_date must be before end_date", nil)
+		writeAPIError(w, ErrInvalidDateRange.WithMessage("start_date must be before end_date"), nil)
 		return
 	}
 
-	// Get price history from price service
-	prices, err := h.PriceService.GetPriceHistory(isin, startDate, endDate)
+	interval := price.HistoryIntervalAuto
+	if value := r.URL.Query().Get("interval"); value != "" {
+		if !price.IsHistoryInterval(value) {
+			writeAPIError(w, ErrValidation.WithMessage("Invalid interval (use auto or 1d)"), map[string]string{"field": "interval"})
+			return
+		}
+		interval = value
+	}
+
+	fill := false
+	if value := r.URL.Query().Get("fill"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			writeAPIError(w, ErrValidation.WithMessage("Invalid fill value (use true or false)"), map[string]string{"field": "fill"})
+			return
+		}
+		fill = parsed
+	}
+
+	// Get price history from price service; only Yahoo Finance can page daily history
+	var prices []models.AssetPrice
+	var err error
+	if yahooService, ok := price.YahooService(h.PriceService); ok {
+		prices, err = yahooService.GetPriceHistoryWithInterval(r.Context(), isin, startDate, endDate, interval)
+	} else {
+		prices, err = h.PriceService.GetPriceHistory(isin, startDate, endDate)
+	}
 	if err != nil {
-		if strings.Contains(err.Error(), "not found") {
-			respondError(w, http.StatusNotFound, "NOT_FOUND", "Asset not found", nil)
+		if errors.Is(err, sql.ErrNoRows) {
+			writeAPIError(w, ErrNotFound.WithMessage("Asset not found"), nil)
 			return
 		}
-		respondError(w, http.StatusInternalServerError, "PRICE_ERROR", "Failed to retrieve price history", map[string]string{
+		writeAPIError(w, ErrPrice.WithMessage("Failed to retrieve price history"), map[string]string{
 			"error": err.Error(),
 		})
 		return
 	}
 
+	if fill {
+		prices = price.ForwardFillDaily(prices, endDate)
+	}
+
 	respondJSON(w, http.StatusOK, prices)
 }
 
+// Number of prices returned by the recent prices endpoint
+const (
+	defaultRecentPricesLimit = 30
+	maxRecentPricesLimit     = 1000
+)
+
+// GetRecentAssetPricesHandler returns the last stored prices of an asset
+// @Summary Derniers prix d'un actif
+// @Description Récupère les derniers prix stockés d'un actif, du plus récent au plus ancien, sans appeler le fournisseur (pour les sparklines). Renvoie une liste vide si aucun prix n'est stocké.
+// @Tags assets
+// @Produce json
+// @Param isin path string true "Code ISIN de l'actif"
+// @Param limit query int false "Nombre de prix (défaut : 30, maximum : 1000)"
+// @Success 200 {array} models.AssetPrice
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/assets/{isin}/prices/recent [get]
+func (h *Handler) GetRecentAssetPricesHandler(w http.ResponseWriter, r *http.Request) {
+	isin := mux.Vars(r)["isin"]
+	if isin == "" {
+		writeAPIError(w, ErrInvalidRequest.WithMessage("ISIN is required"), nil)
+		return
+	}
+
+	limit := defaultRecentPricesLimit
+	if value := r.URL.Query().Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			writeAPIError(w, ErrValidation.WithMessage("limit must be a positive integer"), map[string]string{
+				"field": "limit",
+			})
+			return
+		}
+		limit = min(parsed, maxRecentPricesLimit)
+	}
+
+	prices, err := h.DB.GetRecentAssetPrices(r.Context(), isin, limit)
+	if err != nil {
+		utils.Logf(r.Context(), "ERROR: Failed to get recent prices of %s: %v", isin, err)
+		writeAPIError(w, ErrDatabase.WithMessage("Failed to retrieve prices"), nil)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, prices)
+}
+
+// BackfillAssetPricesHandler fetches and stores daily prices for an asset over a date range
+// @Summary Importer l'historique des prix d'un actif
+// @Description Récupère et stocke les prix journaliers d'un actif sur une période, par tranches pour respecter les limites du fournisseur
+// @Tags assets
+// @Produce json
+// @Param isin path string true "Code ISIN de l'actif"
+// @Param start_date query string true "Date de début (YYYY-MM-DD)"
+// @Param end_date query string false "Date de fin (YYYY-MM-DD, défaut : aujourd'hui)"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/assets/{isin}/backfill [post]
+func (h *Handler) BackfillAssetPricesHandler(w http.ResponseWriter, r *http.Request) {
+	isin := mux.Vars(r)["isin"]
+	if isin == "" {
+		writeAPIError(w, ErrInvalidRequest.WithMessage("ISIN is required"), nil)
+		return
+	}
+
+	startDateStr := r.URL.Query().Get("start_date")
+	if startDateStr == "" {
+		writeAPIError(w, ErrInvalidDate.WithMessage("start_date is required (use YYYY-MM-DD)"), nil)
+		return
+	}
+
+	startDate, err := time.Parse("2006-01-02", startDateStr)
+	if err != nil {
+		writeAPIError(w, ErrInvalidDate.WithMessage("Invalid start_date format (use YYYY-MM-DD)"), nil)
+		return
+	}
+
+	endDate := time.Now().UTC()
+	if endDateStr := r.URL.Query().Get("end_date"); endDateStr != "" {
+		endDate, err = time.Parse("2006-01-02", endDateStr)
+		if err != nil {
+			writeAPIError(w, ErrInvalidDate.WithMessage("Invalid end_date format (use YYYY-MM-DD)"), nil)
+			return
+		}
+	}
+
+	if startDate.After(endDate) {
+		writeAPIError(w, ErrInvalidDateRange.WithMessage("start_date must be before end_date"), nil)
+		return
+	}
+
+	yahooService, ok := price.YahooService(h.PriceService)
+	if !ok {
+		writeAPIError(w, ErrService.WithMessage("Price service is not Yahoo Finance"), nil)
+		return
+	}
+
+	log.Printf("INFO: Backfilling prices for %s from %s to %s", isin, startDate.Format("2006-01-02"), endDate.Format("2006-01-02"))
+
+	stored, chunks, err := yahooService.BackfillPriceHistory(r.Context(), isin, startDate, endDate)
+	if err != nil {
+		if strings.Contains(err.Error(), "asset not found") {
+			writeAPIError(w, ErrAssetNotFound.WithMessage("Asset not found"), nil)
+			return
+		}
+		log.Printf("ERROR: Failed to backfill prices for %s: %v", isin, err)
+		writeAPIError(w, ErrPrice.WithMessage("Failed to backfill prices"), map[string]interface{}{
+			"error":  err.Error(),
+			"stored": stored,
+		})
+		return
+	}
+
+	log.Printf("INFO: Stored %d prices for %s in %d chunk(s)", stored, isin, chunks)
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"isin":       isin,
+		"start_date": startDate.Format("2006-01-02"),
+		"end_date":   endDate.Format("2006-01-02"),
+		"chunks":     chunks,
+		"stored":     stored,
+	})
+}
+
 // RefreshAssetPricesHandler forces a refresh of all historical prices for an asset
 // @Summary Rafraîchir les prix d'un actif
 // @Description Supprime le cache et récupère l'historique complet des prix
@@ -181,7 +345,7 @@ func (h *Handler) RefreshAssetPricesHandler(w http.ResponseWriter, r *http.Reque
 	log.Printf("INFO: Cleared %d cached prices for %s", rowsDeleted, isin)
 
 	// Fetch complete price history
-	if err := h.fetchCompleteAssetPriceHistory(isin); err != nil {
+	if err := h.fetchCompleteAssetPriceHistory(r.Context(), isin); err != nil {
 		log.Printf("ERROR: Failed to fetch price history for %s: %v", isin, err)
 		respondError(w, http.StatusInternalServerError, "PRICE_ERROR", "Failed to fetch prices", map[string]string{
 			"error": err.Error(),
@@ -261,15 +425,105 @@ func (h *Handler) UpdateSingleAssetPrice(w http.ResponseWriter, r *http.Request)
 	})
 }
 
+// priceCacheService is implemented by price services with an in-memory cache
+type priceCacheService interface {
+	InvalidateCache(isin string) int
+	CacheStats() price.CacheStats
+}
+
+// InvalidatePriceCacheHandler clears the in-memory price cache
+// @Summary Vider le cache des prix
+// @Description Supprime toutes les entrées du cache des prix, ou seulement celle d'un ISIN
+// @Tags assets
+// @Produce json
+// @Param isin query string false "Code ISIN à retirer du cache (tout le cache si absent)"
+// @Success 200 {object} map[string]interface{}
+// @Failure 500 {object} ErrorResponse
+// @Router /api/prices/cache/invalidate [post]
+func (h *Handler) InvalidatePriceCacheHandler(w http.ResponseWriter, r *http.Request) {
+	cache, ok := h.PriceService.(priceCacheService)
+	if !ok {
+		writeAPIError(w, ErrService.WithMessage("Price service has no cache"), nil)
+		return
+	}
+
+	isin := r.URL.Query().Get("isin")
+	removed := cache.InvalidateCache(isin)
+
+	if isin == "" {
+		log.Printf("INFO: Price cache cleared (%d entries)", removed)
+	} else {
+		log.Printf("INFO: Price cache entry for %s invalidated (%d removed)", isin, removed)
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"isin":    isin,
+		"removed": removed,
+	})
+}
+
+// GetPriceCacheStatsHandler returns the size and hit/miss counters of the price cache
+// @Summary Statistiques du cache des prix
+// @Description Retourne la taille du cache des prix et ses compteurs de hits et de misses
+// @Tags assets
+// @Produce json
+// @Success 200 {object} price.CacheStats
+// @Failure 500 {object} ErrorResponse
+// @Router /api/prices/cache/stats [get]
+func (h *Handler) GetPriceCacheStatsHandler(w http.ResponseWriter, r *http.Request) {
+	cache, ok := h.PriceService.(priceCacheService)
+	if !ok {
+		writeAPIError(w, ErrService.WithMessage("Price service has no cache"), nil)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, cache.CacheStats())
+}
+
 // GetAssetsHandler returns all assets with user positions
 // @Summary Lister les actifs avec positions
 // @Description Retourne tous les actifs avec les positions de l'utilisateur
 // @Tags assets
 // @Produce json
+// @Param include_hidden query bool false "Inclure les transactions masquées" default(false)
+// @Param min_value query number false "Valeur actuelle minimale des positions listées" default(0)
+// @Param strict query bool false "Exclure aussi du total de répartition les positions sous min_value" default(false)
+// @Param as_of query string false "Date de valorisation (YYYY-MM-DD) : positions à cette date, aux prix historiques"
 // @Success 200 {array} AssetPosition
+// @Failure 400 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /api/assets [get]
 func (h *Handler) GetAssetsHandler(w http.ResponseWriter, r *http.Request) {
+	minValue := 0.0
+	if value := r.URL.Query().Get("min_value"); value != "" {
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil || parsed < 0 || math.IsNaN(parsed) || math.IsInf(parsed, 0) {
+			writeAPIError(w, ErrValidation.WithMessage("min_value must be a non-negative number"), map[string]string{
+				"field": "min_value",
+			})
+			return
+		}
+		minValue = parsed
+	}
+
+	strict := false
+	if value := r.URL.Query().Get("strict"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			writeAPIError(w, ErrValidation.WithMessage("Invalid strict value (use true or false)"), map[string]string{
+				"field": "strict",
+			})
+			return
+		}
+		strict = parsed
+	}
+
+	asOf, apiErr := parseAsOf(r)
+	if apiErr != nil {
+		writeAPIError(w, *apiErr, map[string]string{"field": "as_of"})
+		return
+	}
+
 	// Get all accounts
 	accounts, err := h.DB.GetAllAccounts()
 	if err != nil {
@@ -279,97 +533,205 @@ func (h *Handler) GetAssetsHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Map to store positions by ISIN
-	positionsByISIN := make(map[string]*AssetPosition)
-
 	// Collect all transactions from all accounts
+	var transactions []models.Transaction
+	filter := positionsFilter(asOf, includeHidden(r))
 	for _, account := range accounts {
-		filter := database.TransactionFilter{}
-		transactions, err := h.DB.GetTransactionsByAccount(account.ID, account.Platform, filter)
+		accountTransactions, err := h.DB.GetTransactionsByAccountContext(r.Context(), account.ID, account.Platform, filter)
 		if err != nil {
 			log.Printf("Warning: failed to get transactions for account %s: %v", account.ID, err)
 			continue
 		}
+		transactions = append(transactions, accountTransactions...)
+	}
 
-		// Process transactions
-		for _, tx := range transactions {
-			if tx.ISIN == nil || *tx.ISIN == "" {
-				continue
-			}
+	respondJSON(w, http.StatusOK, excludeDustPositions(h.valuePositions(computePositions(transactions), asOf), minValue, strict))
+}
 
-			isin := *tx.ISIN
-
-			// Initialize position if not exists
-			if _, exists := positionsByISIN[isin]; !exists {
-				// Get asset info
-				asset, err := h.DB.GetAssetByISIN(isin)
-				assetName := "Unknown"
-				currency := "EUR"
-				symbol := ""
-				symbolVerified := false
-				if err == nil {
-					assetName = asset.Name
-					currency = asset.Currency
-					if asset.Symbol != nil {
-						symbol = *asset.Symbol
-					}
-					symbolVerified = asset.SymbolVerified
-				}
-
-				positionsByISIN[isin] = &AssetPosition{
-					ISIN:           isin,
-					Name:           assetName,
-					Symbol:         symbol,
-					SymbolVerified: symbolVerified,
-					Currency:       currency,
-					Purchases:      []Purchase{},
-				}
-			}
+// GetAccountPositionsHandler returns the positions held in a single account
+// @Summary Positions d'un compte
+// @Description Retourne les actifs détenus dans un compte avec leur prix actuel et leur plus-value latente
+// @Tags accounts
+// @Produce json
+// @Param id path string true "ID du compte"
+// @Param include_hidden query bool false "Inclure les transactions masquées" default(false)
+// @Param as_of query string false "Date de valorisation (YYYY-MM-DD) : positions à cette date, aux prix historiques"
+// @Success 200 {array} AssetPosition
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/accounts/{id}/positions [get]
+func (h *Handler) GetAccountPositionsHandler(w http.ResponseWriter, r *http.Request) {
+	accountID := mux.Vars(r)["id"]
+
+	asOf, apiErr := parseAsOf(r)
+	if apiErr != nil {
+		writeAPIError(w, *apiErr, map[string]string{"field": "as_of"})
+		return
+	}
+
+	account, err := h.DB.GetAccountByIDContext(r.Context(), accountID)
+	if err != nil {
+		if err == sql.ErrNoRows || strings.Contains(err.Error(), "no rows") {
+			writeAPIError(w, ErrNotFound.WithMessage("Account not found"), nil)
+			return
+		}
+		writeAPIError(w, ErrDatabase.WithMessage("Failed to retrieve account"), nil)
+		return
+	}
+
+	transactions, err := h.DB.GetTransactionsByAccountContext(r.Context(), account.ID, account.Platform, positionsFilter(asOf, includeHidden(r)))
+	if err != nil {
+		if writePlatformError(w, err, account.Platform) {
+			return
+		}
+		writeAPIError(w, ErrDatabase.WithMessage("Failed to retrieve transactions"), map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
 
-			position := positionsByISIN[isin]
-
-			// Process based on transaction type
-			switch tx.TransactionType {
-			case "buy":
-				position.Quantity += tx.Quantity
-				investedAmount := -tx.AmountValue // AmountValue is negative for buys
-				position.TotalInvested += investedAmount
-
-				// Add to purchases list
-				position.Purchases = append(position.Purchases, Purchase{
-					Date:     tx.Timestamp[:10], // Extract date part
-					Quantity: tx.Quantity,
-					Price:    investedAmount / tx.Quantity,
-				})
-
-			case "sell":
-				position.Quantity -= tx.Quantity
-				// Reduce invested amount proportionally
-				if position.Quantity > 0 {
-					avgCost := position.TotalInvested / (position.Quantity + tx.Quantity)
-					position.TotalInvested -= avgCost * tx.Quantity
-				} else {
-					position.TotalInvested = 0
-				}
+	respondJSON(w, http.StatusOK, h.valuePositions(computePositions(transactions), asOf))
+}
+
+// GetAccountTradedAssetsHandler returns the distinct assets traded in an account
+// @Summary Actifs négociés dans un compte
+// @Description Retourne les actifs distincts présents dans les transactions d'un compte, positions soldées comprises, avec leur nom et s'ils sont encore détenus (par exemple pour les listes de filtres)
+// @Tags accounts
+// @Produce json
+// @Param id path string true "ID du compte"
+// @Param include_hidden query bool false "Inclure les transactions masquées" default(false)
+// @Success 200 {array} database.TradedAsset
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/accounts/{id}/assets [get]
+func (h *Handler) GetAccountTradedAssetsHandler(w http.ResponseWriter, r *http.Request) {
+	accountID := mux.Vars(r)["id"]
+
+	account, err := h.DB.GetAccountByIDContext(r.Context(), accountID)
+	if err != nil {
+		if err == sql.ErrNoRows || strings.Contains(err.Error(), "no rows") {
+			writeAPIError(w, ErrNotFound.WithMessage("Account not found"), nil)
+			return
+		}
+		writeAPIError(w, ErrDatabase.WithMessage("Failed to retrieve account"), nil)
+		return
+	}
+
+	h.respondTradedAssets(w, r, []string{account.ID})
+}
+
+// GetTradedAssetsHandler returns the distinct assets traded across accounts
+// @Summary Actifs négociés
+// @Description Retourne les actifs distincts présents dans les transactions de tous les comptes (ou des comptes indiqués), positions soldées comprises, avec leur nom et s'ils sont encore détenus
+// @Tags assets
+// @Produce json
+// @Param account_ids query string false "IDs des comptes séparés par des virgules"
+// @Param include_hidden query bool false "Inclure les transactions masquées" default(false)
+// @Success 200 {array} database.TradedAsset
+// @Failure 500 {object} ErrorResponse
+// @Router /api/assets/traded [get]
+func (h *Handler) GetTradedAssetsHandler(w http.ResponseWriter, r *http.Request) {
+	h.respondTradedAssets(w, r, parseAccountIDs(r.URL.Query().Get("account_ids")))
+}
+
+// respondTradedAssets writes the assets traded in the given accounts (all accounts when empty)
+func (h *Handler) respondTradedAssets(w http.ResponseWriter, r *http.Request, accountIDs []string) {
+	assets, err := h.DB.GetTradedAssets(r.Context(), database.TransactionFilter{AccountIDs: accountIDs, IncludeHidden: includeHidden(r)})
+	if err != nil {
+		writeAPIError(w, ErrDatabase.WithMessage("Failed to retrieve traded assets"), map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	if assets == nil {
+		assets = []database.TradedAsset{}
+	}
+	respondJSON(w, http.StatusOK, assets)
+}
+
+// computePositions replays buys and sells in chronological order and returns the positions by ISIN.
+// Asset details and prices are left to valuePositions.
+func computePositions(transactions []models.Transaction) map[string]*AssetPosition {
+	// Reinvested dividends (DRIP) must not add to the invested amount
+	drip := performance.DetectDRIP(transactions)
+
+	ordered := make([]models.Transaction, len(transactions))
+	copy(ordered, transactions)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].Timestamp < ordered[j].Timestamp
+	})
+
+	positionsByISIN := make(map[string]*AssetPosition)
+	for _, tx := range ordered {
+		if tx.ISIN == nil || *tx.ISIN == "" {
+			continue
+		}
+
+		isin := *tx.ISIN
+		position, exists := positionsByISIN[isin]
+		if !exists {
+			position = &AssetPosition{
+				ISIN:      isin,
+				Name:      models.UnknownAssetName,
+				Currency:  tx.AmountCurrency,
+				Purchases: []Purchase{},
 			}
+			if position.Currency == "" {
+				position.Currency = models.DefaultCurrency
+			}
+			positionsByISIN[isin] = position
 		}
+
+		applyPositionTransaction(position, tx, drip[tx.ID])
+	}
+
+	return positionsByISIN
+}
+
+// positionsFilter selects the transactions replayed into positions: all of them, or those up
+// to asOf when set; hidden transactions are only kept with includeHidden
+func positionsFilter(asOf *time.Time, includeHidden bool) database.TransactionFilter {
+	filter := database.TransactionFilter{IncludeHidden: includeHidden}
+	if asOf != nil {
+		filter.EndDate = asOf.Format(time.RFC3339)
 	}
+	return filter
+}
 
-	// Calculate current values and get current prices
-	var assets []AssetPosition
+// valuePositions completes the open positions with asset details, current prices (or the historical
+// prices of asOf when set) and unrealized gains, sorted by current value (descending). Sold positions
+// are left out; the result is never nil.
+func (h *Handler) valuePositions(positionsByISIN map[string]*AssetPosition, asOf *time.Time) []AssetPosition {
+	assets := []AssetPosition{}
 	for _, position := range positionsByISIN {
 		if position.Quantity <= 0 {
 			continue // Skip sold positions
 		}
 
-		// Calculate average buy price
-		if position.Quantity > 0 {
-			position.AverageBuyPrice = position.TotalInvested / position.Quantity
+		// Get asset info
+		if asset, err := h.DB.GetAssetByISIN(position.ISIN); err == nil {
+			position.Name = asset.Name
+			position.Currency = asset.Currency
+			if asset.Symbol != nil {
+				position.Symbol = *asset.Symbol
+			}
+			position.SymbolVerified = asset.SymbolVerified
 		}
 
-		// Get current price
-		currentPrice, err := h.PriceService.GetCurrentPrice(position.ISIN)
-		if err != nil {
+		position.AverageBuyPrice = averageBuyPrice(position.TotalInvested, position.Quantity)
+
+		// Get current price, or the price at the valuation date
+		if asOf != nil {
+			historicalPrice, err := h.PerformanceService.HistoricalPrice(position.ISIN, *asOf)
+			if err != nil {
+				log.Printf("Warning: failed to get price for %s at %s: %v", position.ISIN, asOf.Format("2006-01-02"), err)
+				position.CurrentPrice = position.AverageBuyPrice
+			} else {
+				position.CurrentPrice = historicalPrice
+			}
+		} else if currentPrice, err := h.PriceService.GetCurrentPrice(position.ISIN); err != nil {
 			log.Printf("Warning: failed to get current price for %s: %v", position.ISIN, err)
 			// Use average buy price as fallback
 			position.CurrentPrice = position.AverageBuyPrice
@@ -392,7 +754,91 @@ func (h *Handler) GetAssetsHandler(w http.ResponseWriter, r *http.Request) {
 		return assets[i].CurrentValue > assets[j].CurrentValue
 	})
 
-	respondJSON(w, http.StatusOK, assets)
+	allocatePositions(assets)
+	return assets
+}
+
+// allocatePositions sets the share of each position in the total current value of positions
+func allocatePositions(positions []AssetPosition) {
+	total := 0.0
+	for _, position := range positions {
+		total += position.CurrentValue
+	}
+	for i := range positions {
+		positions[i].AllocationPct = 0
+		if total > 0 {
+			positions[i].AllocationPct = positions[i].CurrentValue / total * 100
+		}
+	}
+}
+
+// excludeDustPositions leaves out the positions worth less than minValue. Their value still
+// counts in the allocation of the remaining positions, unless strict is set, in which case
+// the allocation is computed on the remaining positions only.
+func excludeDustPositions(positions []AssetPosition, minValue float64, strict bool) []AssetPosition {
+	if minValue <= 0 {
+		return positions
+	}
+
+	kept := []AssetPosition{}
+	for _, position := range positions {
+		if position.CurrentValue >= minValue {
+			kept = append(kept, position)
+		}
+	}
+	if strict {
+		allocatePositions(kept)
+	}
+	return kept
+}
+
+// applyPositionTransaction updates a position with a buy, sell or corporate action transaction.
+// Buys without a quantity (the Trade Republic timeline often leaves it at 0) still count
+// towards the invested amount but are not listed as purchases since they have no per-share price.
+func applyPositionTransaction(position *AssetPosition, tx models.Transaction, isDRIP bool) {
+	switch tx.TransactionType {
+	case "buy":
+		position.Quantity += tx.Quantity
+		investedAmount := -tx.ExactAmount() // The amount is negative for buys
+		if isDRIP {
+			// Paid with the dividend: shares enter the position at zero cost
+			investedAmount = 0
+		}
+		position.TotalInvested += investedAmount
+
+		if tx.Quantity <= 0 {
+			return
+		}
+
+		position.Purchases = append(position.Purchases, Purchase{
+			Date:     tx.Timestamp[:min(len(tx.Timestamp), 10)], // Extract date part
+			Quantity: tx.Quantity,
+			Price:    investedAmount / tx.Quantity,
+		})
+
+	case "sell":
+		// Reduce invested amount proportionally, and start over once fully sold
+		performance.SellAtAverageCost(&position.Quantity, &position.TotalInvested, tx.Quantity)
+		if position.Quantity <= 0 {
+			// Positions bought without quantity are cleared by any sale
+			position.TotalInvested = 0
+		}
+
+	case models.TransactionTypeCorporateAction:
+		performance.ApplyQuantityDelta(&position.Quantity, &position.TotalInvested, tx.CorporateActionQuantity())
+	}
+}
+
+// averageBuyPrice returns the average cost per share, or 0 when no shares are held
+func averageBuyPrice(totalInvested, quantity float64) float64 {
+	if quantity <= 0 {
+		return 0
+	}
+	avg := totalInvested / quantity
+	if math.IsNaN(avg) || math.IsInf(avg, 0) {
+		return 0
+	}
+	return avg
 }
 
 // SymbolSearchHandler searches for symbols on Yahoo Finance
@@ -413,7 +859,7 @@ func (h *Handler) SymbolSearchHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Call Yahoo Finance search API
-	yahooService, ok := h.PriceService.(*price.YahooFinanceService)
+	yahooService, ok := price.YahooService(h.PriceService)
 	if !ok {
 		respondError(w, http.StatusInternalServerError, "SERVICE_ERROR", "Price service is not Yahoo Finance", nil)
 		return
@@ -431,9 +877,196 @@ func (h *Handler) SymbolSearchHandler(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// maxLocalSearchResults caps the number of local assets returned by the combined search
+const maxLocalSearchResults = 20
+
+// SearchResult is an asset found locally or on the price provider
+type SearchResult struct {
+	// Source is "local" for assets stored in the database, "provider" for Yahoo Finance results
+	Source      string `json:"source"`
+	ISIN        string `json:"isin,omitempty"`
+	Symbol      string `json:"symbol,omitempty"`
+	Name        string `json:"name"`
+	Type        string `json:"type,omitempty"`
+	Currency    string `json:"currency,omitempty"`
+	Exchange    string `json:"exchange,omitempty"`
+	InPortfolio bool   `json:"in_portfolio"`
+}
+
+// SearchHandler searches assets in the local database and on the price provider
+// @Summary Rechercher un actif
+// @Description Recherche dans les actifs locaux (nom, symbole, ISIN) puis sur Yahoo Finance. Les actifs détenus sont renvoyés en premier.
+// @Tags assets
+// @Produce json
+// @Param q query string true "Terme de recherche"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/search [get]
+func (h *Handler) SearchHandler(w http.ResponseWriter, r *http.Request) {
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if query == "" {
+		writeAPIError(w, ErrInvalidQuery.WithMessage("Query parameter q is required"), nil)
+		return
+	}
+
+	local, err := h.DB.SearchAssets(r.Context(), query, maxLocalSearchResults)
+	if err != nil {
+		writeAPIError(w, ErrDatabase.WithMessage("Failed to search assets"), map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	var providerResults []price.YahooSearchResult
+	var providerError string
+	if yahooService, ok := price.YahooService(h.PriceService); ok {
+		providerResults, err = yahooService.SearchSymbol(query)
+		if err != nil {
+			// Local results are still useful when the provider is unavailable
+			log.Printf("WARNING: Yahoo Finance search failed for %q: %v", query, err)
+			providerError = err.Error()
+		}
+	}
+
+	response := map[string]interface{}{
+		"query":   query,
+		"results": mergeSearchResults(local, providerResults),
+	}
+	if providerError != "" {
+		response["provider_error"] = providerError
+	}
+
+	respondJSON(w, http.StatusOK, response)
+}
+
+// mergeSearchResults lists held local assets first, then the other local assets, then provider
+// results. Provider results whose symbol matches a local asset are merged into the local hit.
+func mergeSearchResults(local []database.AssetSearchResult, provider []price.YahooSearchResult) []SearchResult {
+	results := make([]SearchResult, 0, len(local)+len(provider))
+	localSymbols := make(map[string]bool)
+
+	sortedLocal := make([]database.AssetSearchResult, len(local))
+	copy(sortedLocal, local)
+	sort.SliceStable(sortedLocal, func(i, j int) bool {
+		return sortedLocal[i].Held && !sortedLocal[j].Held
+	})
+
+	for _, asset := range sortedLocal {
+		result := SearchResult{
+			Source:      "local",
+			ISIN:        asset.ISIN,
+			Name:        asset.Name,
+			Type:        asset.Type,
+			Currency:    asset.Currency,
+			InPortfolio: asset.Held,
+		}
+		if asset.Symbol != nil {
+			result.Symbol = *asset.Symbol
+			localSymbols[strings.ToUpper(*asset.Symbol)] = true
+		}
+		results = append(results, result)
+	}
+
+	for _, hit := range provider {
+		if hit.Symbol == "" || localSymbols[strings.ToUpper(hit.Symbol)] {
+			continue
+		}
+		name := hit.Name
+		if name == "" {
+			name = hit.ShortName
+		}
+		results = append(results, SearchResult{
+			Source:   "provider",
+			Symbol:   hit.Symbol,
+			Name:     name,
+			Type:     strings.ToLower(hit.Type),
+			Exchange: hit.ExchDisp,
+		})
+	}
+
+	return results
+}
+
+// maxBatchSymbolQueries caps the number of queries accepted by the batch symbol search
+const maxBatchSymbolQueries = 20
+
+// BatchSymbolSearchRequest represents the request body for a batch symbol search
+type BatchSymbolSearchRequest struct {
+	Queries []string `json:"queries"`
+}
+
+// BatchSymbolSearchHandler searches several symbols on Yahoo Finance in one request
+// @Summary Rechercher plusieurs symboles boursiers
+// @Description Recherche une liste de symboles sur Yahoo Finance (dédupliquée, limitée à 20 requêtes)
+// @Tags symbols
+// @Accept json
+// @Produce json
+// @Param request body BatchSymbolSearchRequest true "Termes de recherche"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/symbols/search [post]
+func (h *Handler) BatchSymbolSearchHandler(w http.ResponseWriter, r *http.Request) {
+	var req BatchSymbolSearchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body", nil)
+		return
+	}
+
+	// Deduplicate queries while keeping their order
+	queries := []string{}
+	seen := make(map[string]bool)
+	for _, query := range req.Queries {
+		query = strings.TrimSpace(query)
+		if query == "" || seen[query] {
+			continue
+		}
+		seen[query] = true
+		queries = append(queries, query)
+	}
+
+	if len(queries) == 0 {
+		respondError(w, http.StatusBadRequest, "INVALID_QUERY", "At least one query is required", nil)
+		return
+	}
+
+	if len(queries) > maxBatchSymbolQueries {
+		respondError(w, http.StatusBadRequest, "TOO_MANY_QUERIES",
+			fmt.Sprintf("At most %d queries are allowed per request", maxBatchSymbolQueries),
+			map[string]int{"max": maxBatchSymbolQueries, "received": len(queries)})
+		return
+	}
+
+	yahooService, ok := price.YahooService(h.PriceService)
+	if !ok {
+		respondError(w, http.StatusInternalServerError, "SERVICE_ERROR", "Price service is not Yahoo Finance", nil)
+		return
+	}
+
+	results := make(map[string][]price.YahooSearchResult, len(queries))
+	searchErrors := make(map[string]string)
+
+	// Requests are throttled by the price service rate limiter
+	for _, query := range queries {
+		queryResults, err := yahooService.SearchSymbol(query)
+		if err != nil {
+			log.Printf("WARNING: Yahoo Finance search failed for %q: %v", query, err)
+			searchErrors[query] = err.Error()
+			continue
+		}
+		results[query] = queryResults
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"results": results,
+		"errors":  searchErrors,
+	})
+}
+
 // fetchCompleteAssetPriceHistory fetches all price granularities for an asset
 // This ensures we have daily data for 1M, weekly for 5Y, and max historical data
-func (h *Handler) fetchCompleteAssetPriceHistory(isin string) error {
+func (h *Handler) fetchCompleteAssetPriceHistory(ctx context.Context, isin string) error {
 	// Get asset to retrieve symbol
 	asset, err := h.DB.GetAssetByISIN(isin)
 	if err != nil {
@@ -450,28 +1083,28 @@ func (h *Handler) fetchCompleteAssetPriceHistory(isin string) error {
 	}
 
 	// Cast to Yahoo Finance service to access FetchHistoricalPrices
-	yahooService, ok := h.PriceService.(*price.YahooFinanceService)
+	yahooService, ok := price.YahooService(h.PriceService)
 	if !ok {
 		return fmt.Errorf("price service is not Yahoo Finance")
 	}
 
 	// Fetch data in multiple periods with specific granularity
 	// 1. Last month with daily data (1d interval)
-	prices1m, err := yahooService.FetchHistoricalPrices(symbol, isin, asset.Currency, "1mo", "1d")
+	prices1m, err := yahooService.FetchHistoricalPrices(ctx, symbol, isin, asset.Currency, "1mo", "1d")
 	if err != nil {
 		return fmt.Errorf("failed to fetch 1m daily prices: %w", err)
 	}
 
 	// 2. 5 years with weekly data (1wk interval)
-	prices5y, err := yahooService.FetchHistoricalPrices(symbol, isin, asset.Currency, "5y", "1wk")
+	prices5y, err := yahooService.FetchHistoricalPrices(ctx, symbol, isin, asset.Currency, "5y", "1wk")
 	if err != nil {
-		log.Printf("WARNING: Failed to fetch 5y weekly prices for %s: %v", isin, err)
+		utils.Logf(ctx, "WARNING: Failed to fetch 5y weekly prices for %s: %v", isin, err)
 	}
 
 	// 3. Max range with weekly data (1wk interval)
-	pricesMax, err := yahooService.FetchHistoricalPrices(symbol, isin, asset.Currency, "max", "1wk")
+	pricesMax, err := yahooService.FetchHistoricalPrices(ctx, symbol, isin, asset.Currency, "max", "1wk")
 	if err != nil {
-		log.Printf("WARNING: Failed to fetch max weekly prices for %s: %v", isin, err)
+		utils.Logf(ctx, "WARNING: Failed to fetch max weekly prices for %s: %v", isin, err)
 	}
 
 	// Combine all prices and remove duplicates (keep daily over weekly for overlapping dates)
@@ -513,141 +1146,215 @@ func (h *Handler) fetchCompleteAssetPriceHistory(isin string) error {
 		}
 	}
 
-	log.Printf("INFO: Stored %d price points for %s (1m daily: %d, 5y weekly: %d, max weekly: %d)",
+	utils.Logf(ctx, "INFO: Stored %d price points for %s (1m daily: %d, 5y weekly: %d, max weekly: %d)",
 		len(allPrices), isin, len(prices1m), len(prices5y), len(pricesMax))
 
 	return nil
 }
 
-// resolveAssetSymbols resolves Yahoo Finance symbols for assets that don't have verified symbols
-func (h *Handler) resolveAssetSymbols() int {
-	yahooService, ok := h.PriceService.(*price.YahooFinanceService)
-	if !ok {
-		log.Printf("WARNING: Price service is not Yahoo Finance, skipping symbol resolution")
-		return 0
+// AssetMetadataResponse is the metadata used to resolve the symbol of an asset
+type AssetMetadataResponse struct {
+	ISIN     string                      `json:"isin"`
+	Metadata *models.TransactionMetadata `json:"metadata"`
+}
+
+// GetAssetMetadataHandler returns the parsed transaction metadata used for symbol resolution
+// @Summary Métadonnées d'un actif
+// @Description Retourne les métadonnées de transaction (symbole, nom, places de cotation) utilisées pour résoudre le symbole de l'actif
+// @Tags assets
+// @Produce json
+// @Param isin path string true "Code ISIN de l'actif"
+// @Success 200 {object} AssetMetadataResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/assets/{isin}/metadata [get]
+func (h *Handler) GetAssetMetadataHandler(w http.ResponseWriter, r *http.Request) {
+	isin := mux.Vars(r)["isin"]
+
+	if _, err := h.DB.GetAssetByISIN(isin); err != nil {
+		if err == sql.ErrNoRows || strings.Contains(err.Error(), "no rows") {
+			writeAPIError(w, ErrAssetNotFound, map[string]string{"isin": isin})
+			return
+		}
+		writeAPIError(w, ErrDatabase.WithMessage("Failed to retrieve asset"), nil)
+		return
 	}
 
-	// Get all assets without verified symbols
-	query := `
-		SELECT isin, name, symbol 
-		FROM assets 
-		WHERE (symbol_verified = false OR symbol_verified IS NULL)
-		AND isin IS NOT NULL
-	`
+	metadata, err := h.DB.GetAssetMetadata(isin)
+	if err != nil {
+		log.Printf("ERROR: Failed to get metadata for ISIN %s: %v", isin, err)
+		writeAPIError(w, ErrDatabase.WithMessage("Failed to retrieve asset metadata"), nil)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, AssetMetadataResponse{ISIN: isin, Metadata: metadata})
+}
+
+// MergeAssetsRequest represents the request body for merging two assets
+type MergeAssetsRequest struct {
+	FromISIN string `json:"from_isin"`
+	ToISIN   string `json:"to_isin"`
+}
 
-	type AssetInfo struct {
-		ISIN   string  `db:"isin"`
-		Name   string  `db:"name"`
-		Symbol *string `db:"symbol"`
+// MergeAssetsHandler merges a duplicate asset into another one
+// @Summary Fusionner deux actifs
+// @Description Rattache les transactions, prix et alertes de from_isin à to_isin (les prix en double sont ignorés) puis supprime from_isin, dans une seule transaction
+// @Tags assets
+// @Accept json
+// @Produce json
+// @Param body body MergeAssetsRequest true "Actif source et actif cible"
+// @Success 200 {object} database.AssetMergeResult
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/assets/merge [post]
+func (h *Handler) MergeAssetsHandler(w http.ResponseWriter, r *http.Request) {
+	var req MergeAssetsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeAPIError(w, ErrInvalidRequest.WithMessage("Invalid request body"), nil)
+		return
 	}
 
-	var assets []AssetInfo
-	if err := h.DB.Select(&assets, query); err != nil {
-		log.Printf("ERROR: Failed to get assets for symbol resolution: %v", err)
-		return 0
+	fromISIN := strings.ToUpper(strings.TrimSpace(req.FromISIN))
+	toISIN := strings.ToUpper(strings.TrimSpace(req.ToISIN))
+	if fromISIN == "" || toISIN == "" {
+		writeAPIError(w, ErrValidation.WithMessage("from_isin and to_isin are required"), nil)
+		return
+	}
+	if fromISIN == toISIN {
+		writeAPIError(w, ErrValidation.WithMessage("from_isin and to_isin must be different"), nil)
+		return
 	}
 
-	log.Printf("INFO: Found %d assets to resolve symbols for", len(assets))
-
-	resolved := 0
-	for _, asset := range assets {
-		// Get metadata from transactions to extract exchange info
-		var metadata struct {
-			Symbol    string   `json:"symbol"`
-			Exchanges []string `json:"exchanges"`
-			Name      string   `json:"name"`
-		}
-
-		// Try to get metadata from a transaction with this ISIN
-		metadataQuery := `
-			SELECT metadata 
-			FROM transactions_traderepublic 
-			WHERE isin = $1 AND metadata IS NOT NULL 
-			LIMIT 1
-		`
-		var metadataJSON *string
-		err := h.DB.Get(&metadataJSON, metadataQuery, asset.ISIN)
-		if err == nil && metadataJSON != nil {
-			if err := json.Unmarshal([]byte(*metadataJSON), &metadata); err != nil {
-				log.Printf("WARNING: Failed to parse metadata for ISIN %s: %v", asset.ISIN, err)
-			}
+	result, err := h.DB.MergeAssets(r.Context(), fromISIN, toISIN)
+	if err != nil {
+		if errors.Is(err, database.ErrMergeAssetNotFound) {
+			writeAPIError(w, ErrAssetNotFound.WithMessage(err.Error()), map[string]string{
+				"from_isin": fromISIN,
+				"to_isin":   toISIN,
+			})
+			return
 		}
+		log.Printf("ERROR: Failed to merge asset %s into %s: %v", fromISIN, toISIN, err)
+		writeAPIError(w, ErrDatabase.WithMessage("Failed to merge assets"), nil)
+		return
+	}
 
-		// Use symbol from metadata or from asset
-		symbolToResolve := metadata.Symbol
-		if symbolToResolve == "" && asset.Symbol != nil {
-			symbolToResolve = *asset.Symbol
-		}
+	// The merged asset must not be served from the price cache anymore
+	if cache, ok := h.PriceService.(priceCacheService); ok {
+		cache.InvalidateCache(fromISIN)
+		cache.InvalidateCache(toISIN)
+	}
 
-		if symbolToResolve == "" {
-			log.Printf("WARNING: No symbol found for ISIN %s, skipping", asset.ISIN)
-			continue
-		}
+	log.Printf("INFO: Merged asset %s into %s (%d transactions, %d prices moved)", fromISIN, toISIN, result.TransactionsMoved, result.PricesMoved)
+	respondJSON(w, http.StatusOK, result)
+}
 
-		// Use asset name from metadata or database
-		assetName := metadata.Name
-		if assetName == "" {
-			assetName = asset.Name
-		}
+// maxSymbolUpdates bounds the items of a bulk symbol update
+const maxSymbolUpdates = 500
 
-		// Resolve symbol with Yahoo Finance
-		resolvedSymbol, verified, err := yahooService.ResolveSymbolWithExchange(
-			symbolToResolve,
-			metadata.Exchanges,
-			assetName,
-		)
+// UpdateAssetSymbolsResponse reports the outcome of a bulk symbol update
+type UpdateAssetSymbolsResponse struct {
+	Updated int                                `json:"updated"`
+	Failed  int                                `json:"failed"`
+	Results []database.AssetSymbolUpdateResult `json:"results"`
+}
 
-		if err != nil {
-			log.Printf("WARNING: Failed to resolve symbol for ISIN %s (%s): %v", asset.ISIN, symbolToResolve, err)
-			continue
-		}
+// UpdateAssetSymbolsHandler updates the symbols of several assets at once
+// @Summary Mettre à jour les symboles de plusieurs actifs
+// @Description Applique une liste de symboles Yahoo Finance dans une seule transaction et retourne le résultat de chaque élément ; un ISIN inconnu est signalé en échec sans empêcher les autres mises à jour
+// @Tags assets
+// @Accept json
+// @Produce json
+// @Param body body []database.AssetSymbolUpdate true "Symboles à appliquer (500 au maximum)"
+// @Success 200 {object} UpdateAssetSymbolsResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/assets/symbols [put]
+func (h *Handler) UpdateAssetSymbolsHandler(w http.ResponseWriter, r *http.Request) {
+	var updates []database.AssetSymbolUpdate
+	if err := json.NewDecoder(r.Body).Decode(&updates); err != nil {
+		writeAPIError(w, ErrInvalidRequest.WithMessage("Invalid request body"), nil)
+		return
+	}
 
-		// Update asset with resolved symbol
-		updateQuery := `
-			UPDATE assets 
-			SET symbol = $1, symbol_verified = $2, last_updated = NOW()
-			WHERE isin = $3
-		`
-		if _, err := h.DB.Exec(updateQuery, resolvedSymbol, verified, asset.ISIN); err != nil {
-			log.Printf("ERROR: Failed to update symbol for ISIN %s: %v", asset.ISIN, err)
-			continue
+	if len(updates) == 0 {
+		writeAPIError(w, ErrValidation.WithMessage("at least one symbol update is required"), nil)
+		return
+	}
+	if len(updates) > maxSymbolUpdates {
+		writeAPIError(w, ErrValidation.WithMessage(fmt.Sprintf("at most %d symbol updates are accepted", maxSymbolUpdates)), nil)
+		return
+	}
+	for i := range updates {
+		updates[i].ISIN = strings.ToUpper(strings.TrimSpace(updates[i].ISIN))
+		updates[i].Symbol = strings.TrimSpace(updates[i].Symbol)
+		if updates[i].ISIN == "" {
+			writeAPIError(w, ErrValidation.WithMessage(fmt.Sprintf("item %d: isin is required", i)), map[string]interface{}{
+				"field": "isin",
+				"index": i,
+			})
+			return
 		}
+	}
 
-		log.Printf("INFO: Resolved symbol for %s: %s → %s (verified: %v)", asset.ISIN, symbolToResolve, resolvedSymbol, verified)
-		resolved++
+	results, err := h.DB.UpdateAssetSymbols(r.Context(), updates)
+	if err != nil {
+		log.Printf("ERROR: Failed to update asset symbols: %v", err)
+		writeAPIError(w, ErrDatabase.WithMessage("Failed to update asset symbols"), nil)
+		return
+	}
 
-		// Fetch complete price history for this asset
-		if err := h.fetchCompleteAssetPriceHistory(asset.ISIN); err != nil {
-			log.Printf("WARNING: Failed to fetch price history for %s: %v", asset.ISIN, err)
+	response := UpdateAssetSymbolsResponse{Results: results}
+	for _, result := range results {
+		if result.Success {
+			response.Updated++
 		} else {
-			log.Printf("INFO: Fetched complete price history for %s", asset.ISIN)
+			response.Failed++
 		}
-
-		// Small delay to be respectful to Yahoo Finance
-		time.Sleep(200 * time.Millisecond)
 	}
 
-	return resolved
+	log.Printf("INFO: Updated %d asset symbols (%d failed)", response.Updated, response.Failed)
+	respondJSON(w, http.StatusOK, response)
 }
 
 // ResolveAllSymbolsHandler manually triggers symbol resolution for all assets
 // @Summary Résoudre tous les symboles manquants
-// @Description Déclenche la résolution des symboles Yahoo Finance pour tous les actifs
+// @Description Démarre en arrière-plan la résolution des symboles Yahoo Finance des actifs sans symbole vérifié et retourne le job ; si une résolution est déjà en cours, un job est mis en attente (queued) et démarre à sa fin pour prendre en compte les actifs ajoutés entre-temps
 // @Tags assets
 // @Produce json
-// @Success 200 {object} map[string]interface{}
+// @Success 202 {object} SymbolResolutionStatus
 // @Failure 500 {object} ErrorResponse
 // @Router /api/assets/symbols/resolve [post]
 func (h *Handler) ResolveAllSymbolsHandler(w http.ResponseWriter, r *http.Request) {
 	log.Printf("INFO: Manual symbol resolution triggered")
 
-	resolved := h.resolveAssetSymbols()
+	job := h.resolveAssetSymbolsAsync(r.Context())
 
-	respondJSON(w, http.StatusOK, map[string]interface{}{
-		"success":          true,
-		"symbols_resolved": resolved,
-		"message":          fmt.Sprintf("Successfully resolved %d symbols", resolved),
-	})
+	respondJSON(w, http.StatusAccepted, job.Status())
+}
+
+// GetSymbolResolutionJobHandler returns the progress of a symbol resolution job
+// @Summary Suivre une résolution de symboles
+// @Description Retourne l'avancement d'un job de résolution des symboles (queued, running, completed ou timed_out)
+// @Tags assets
+// @Produce json
+// @Param job_id path string true "ID du job"
+// @Success 200 {object} SymbolResolutionStatus
+// @Failure 404 {object} ErrorResponse
+// @Router /api/assets/symbols/resolve/{job_id} [get]
+func (h *Handler) GetSymbolResolutionJobHandler(w http.ResponseWriter, r *http.Request) {
+	jobID := mux.Vars(r)["job_id"]
+
+	job, ok := h.SymbolResolution.Get(jobID)
+	if !ok {
+		writeAPIError(w, ErrNotFound.WithMessage("Symbol resolution job not found"), map[string]string{
+			"job_id": jobID,
+		})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, job.Status())
 }
 
 // UpdateAssetSymbolHandler updates the symbol for an asset
@@ -0,0 +1,346 @@
+package api
+
+import (
+	"archive/zip"
+	"bytes"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"valhafin/internal/domain/models"
+	"valhafin/internal/repository/database"
+
+	"github.com/gorilla/mux"
+)
+
+// BackupFormatVersion is the version of the account backup layout, increased when
+// the files of the archive change in an incompatible way
+const BackupFormatVersion = 1
+
+// maxBackupSize bounds the size of an uploaded backup and of each file it contains
+const maxBackupSize = 100 << 20
+
+// Files of an account backup archive
+const (
+	backupManifestFile     = "manifest.json"
+	backupAccountFile      = "account.json"
+	backupTransactionsFile = "transactions.json"
+	backupAssetsFile       = "assets.json"
+	backupPricesFile       = "prices.csv"
+)
+
+// backupPricesHeader is the header of the prices file of a backup
+var backupPricesHeader = []string{"isin", "price", "currency", "timestamp"}
+
+// BackupManifest describes an account backup archive
+type BackupManifest struct {
+	FormatVersion int       `json:"format_version"`
+	ExportedAt    time.Time `json:"exported_at"`
+	AccountID     string    `json:"account_id"`
+	Platform      string    `json:"platform"`
+	Transactions  int       `json:"transactions"`
+	Assets        int       `json:"assets"`
+	Prices        int       `json:"prices"`
+}
+
+// BackupImportResponse reports the outcome of a backup import
+type BackupImportResponse struct {
+	AccountID    string `json:"account_id"`
+	Transactions int    `json:"transactions"`
+	Assets       int    `json:"assets"`
+	Prices       int    `json:"prices"`
+}
+
+// ExportAccountZipHandler downloads a ZIP backup of an account
+// @Summary Exporter un compte en ZIP
+// @Description Télécharge une archive ZIP contenant le compte (sans ses credentials), toutes ses transactions, les actifs associés et leurs prix en cache
+// @Tags accounts
+// @Produce application/zip
+// @Param id path string true "ID du compte"
+// @Success 200 {file} file
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/accounts/{id}/export.zip [get]
+func (h *Handler) ExportAccountZipHandler(w http.ResponseWriter, r *http.Request) {
+	accountID := mux.Vars(r)["id"]
+	if accountID == "" {
+		writeAPIError(w, ErrInvalidRequest.WithMessage("Account ID is required"), nil)
+		return
+	}
+
+	backup, err := h.DB.GetAccountBackup(r.Context(), accountID)
+	if err != nil {
+		if err == sql.ErrNoRows || strings.Contains(err.Error(), "no rows") {
+			writeAPIError(w, ErrNotFound.WithMessage("Account not found"), nil)
+			return
+		}
+		log.Printf("ERROR: Failed to export account %s: %v", accountID, err)
+		writeAPIError(w, ErrDatabase.WithMessage("Failed to export account"), nil)
+		return
+	}
+
+	// The archive is built in memory so that an error can still be reported as JSON
+	var buf bytes.Buffer
+	if err := writeAccountBackup(&buf, backup, time.Now().UTC()); err != nil {
+		log.Printf("ERROR: Failed to build backup of account %s: %v", accountID, err)
+		writeAPIError(w, ErrInternal.WithMessage("Failed to build backup archive"), nil)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="valhafin-%s.zip"`, accountID))
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf.Bytes())
+}
+
+// ImportAccountZipHandler restores a ZIP backup into an account
+// @Summary Importer une sauvegarde ZIP
+// @Description Restaure les transactions, actifs et prix d'une archive produite par l'export ZIP dans un compte existant sans transactions, de la même plateforme
+// @Tags accounts
+// @Accept multipart/form-data
+// @Produce json
+// @Param account_id formData string true "ID du compte cible"
+// @Param file formData file true "Archive ZIP"
+// @Success 201 {object} BackupImportResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/import.zip [post]
+func (h *Handler) ImportAccountZipHandler(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxBackupSize)
+	if err := r.ParseMultipartForm(32 << 20); err != nil {
+		writeAPIError(w, ErrInvalidRequest.WithMessage("Failed to parse form data"), nil)
+		return
+	}
+
+	accountID := r.FormValue("account_id")
+	if accountID == "" {
+		writeAPIError(w, ErrValidation.WithMessage("account_id is required"), map[string]string{
+			"field": "account_id",
+		})
+		return
+	}
+
+	account, err := h.DB.GetAccountByID(accountID)
+	if err != nil {
+		if err == sql.ErrNoRows || strings.Contains(err.Error(), "no rows") {
+			writeAPIError(w, ErrNotFound.WithMessage("Account not found"), nil)
+			return
+		}
+		writeAPIError(w, ErrDatabase.WithMessage("Failed to retrieve account"), nil)
+		return
+	}
+
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		writeAPIError(w, ErrValidation.WithMessage("ZIP file is required"), map[string]string{
+			"field": "file",
+		})
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		writeAPIError(w, ErrInvalidFile.WithMessage("Failed to read the uploaded file"), nil)
+		return
+	}
+
+	manifest, backup, err := readAccountBackup(data)
+	if err != nil {
+		writeAPIError(w, ErrInvalidFile.WithMessage(err.Error()), nil)
+		return
+	}
+	if manifest.Platform != account.Platform {
+		writeAPIError(w, ErrValidation.WithMessage("The backup was exported from another platform"), map[string]string{
+			"backup_platform":  manifest.Platform,
+			"account_platform": account.Platform,
+		})
+		return
+	}
+
+	if err := h.DB.RestoreAccountBackup(r.Context(), accountID, backup); err != nil {
+		if errors.Is(err, database.ErrRestoreConflict) {
+			writeAPIError(w, ErrBackupConflict.WithMessage("The account must have no transactions and the backup transactions must not exist yet"), nil)
+			return
+		}
+		if writePlatformError(w, err, account.Platform) {
+			return
+		}
+		log.Printf("ERROR: Failed to restore backup into account %s: %v", accountID, err)
+		writeAPIError(w, ErrDatabase.WithMessage("Failed to restore backup"), nil)
+		return
+	}
+
+	log.Printf("INFO: Restored backup of account %s into account %s (%d transactions, %d assets, %d prices)",
+		manifest.AccountID, accountID, len(backup.Transactions), len(backup.Assets), len(backup.Prices))
+
+	respondJSON(w, http.StatusCreated, BackupImportResponse{
+		AccountID:    accountID,
+		Transactions: len(backup.Transactions),
+		Assets:       len(backup.Assets),
+		Prices:       len(backup.Prices),
+	})
+}
+
+// writeAccountBackup writes the backup archive: a manifest, the account, transactions
+// and assets as JSON, and the prices as CSV
+func writeAccountBackup(w io.Writer, backup *database.AccountBackup, exportedAt time.Time) error {
+	account := backup.Account
+	account.Credentials = ""
+
+	manifest := BackupManifest{
+		FormatVersion: BackupFormatVersion,
+		ExportedAt:    exportedAt,
+		AccountID:     account.ID,
+		Platform:      account.Platform,
+		Transactions:  len(backup.Transactions),
+		Assets:        len(backup.Assets),
+		Prices:        len(backup.Prices),
+	}
+
+	transactions := backup.Transactions
+	if transactions == nil {
+		transactions = []models.Transaction{}
+	}
+	assets := backup.Assets
+	if assets == nil {
+		assets = []models.Asset{}
+	}
+
+	archive := zip.NewWriter(w)
+	files := []struct {
+		name  string
+		value interface{}
+	}{
+		{backupManifestFile, manifest},
+		{backupAccountFile, account},
+		{backupTransactionsFile, transactions},
+		{backupAssetsFile, assets},
+	}
+	for _, file := range files {
+		entry, err := archive.Create(file.name)
+		if err != nil {
+			return fmt.Errorf("failed to create %s: %w", file.name, err)
+		}
+		encoder := json.NewEncoder(entry)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(file.value); err != nil {
+			return fmt.Errorf("failed to write %s: %w", file.name, err)
+		}
+	}
+
+	entry, err := archive.Create(backupPricesFile)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", backupPricesFile, err)
+	}
+	writer := csv.NewWriter(entry)
+	writer.Write(backupPricesHeader)
+	for _, price := range backup.Prices {
+		writer.Write([]string{
+			price.ISIN,
+			strconv.FormatFloat(price.Price, 'f', -1, 64),
+			price.Currency,
+			price.Timestamp.UTC().Format(time.RFC3339Nano),
+		})
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", backupPricesFile, err)
+	}
+
+	return archive.Close()
+}
+
+// readAccountBackup reads a backup archive written by writeAccountBackup
+func readAccountBackup(data []byte) (*BackupManifest, *database.AccountBackup, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, nil, fmt.Errorf("file is not a valid ZIP archive")
+	}
+
+	entries := make(map[string]*zip.File, len(archive.File))
+	for _, file := range archive.File {
+		entries[file.Name] = file
+	}
+	open := func(name string) (io.ReadCloser, error) {
+		file, ok := entries[name]
+		if !ok {
+			return nil, fmt.Errorf("backup is missing %s", name)
+		}
+		return file.Open()
+	}
+	decode := func(name string, value interface{}) error {
+		reader, err := open(name)
+		if err != nil {
+			return err
+		}
+		defer reader.Close()
+		if err := json.NewDecoder(io.LimitReader(reader, maxBackupSize)).Decode(value); err != nil {
+			return fmt.Errorf("invalid %s: %v", name, err)
+		}
+		return nil
+	}
+
+	var manifest BackupManifest
+	if err := decode(backupManifestFile, &manifest); err != nil {
+		return nil, nil, err
+	}
+	if manifest.FormatVersion != BackupFormatVersion {
+		return nil, nil, fmt.Errorf("unsupported backup format version %d", manifest.FormatVersion)
+	}
+
+	backup := &database.AccountBackup{}
+	if err := decode(backupAccountFile, &backup.Account); err != nil {
+		return nil, nil, err
+	}
+	if err := decode(backupTransactionsFile, &backup.Transactions); err != nil {
+		return nil, nil, err
+	}
+	if err := decode(backupAssetsFile, &backup.Assets); err != nil {
+		return nil, nil, err
+	}
+
+	reader, err := open(backupPricesFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer reader.Close()
+	records, err := csv.NewReader(io.LimitReader(reader, maxBackupSize)).ReadAll()
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid %s: %v", backupPricesFile, err)
+	}
+	for i, record := range records {
+		if i == 0 {
+			continue
+		}
+		if len(record) != len(backupPricesHeader) {
+			return nil, nil, fmt.Errorf("invalid %s: line %d has %d columns", backupPricesFile, i+1, len(record))
+		}
+		value, err := strconv.ParseFloat(record[1], 64)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid %s: line %d: invalid price %q", backupPricesFile, i+1, record[1])
+		}
+		timestamp, err := time.Parse(time.RFC3339Nano, record[3])
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid %s: line %d: invalid timestamp %q", backupPricesFile, i+1, record[3])
+		}
+		backup.Prices = append(backup.Prices, models.AssetPrice{
+			ISIN:      record[0],
+			Price:     value,
+			Currency:  record[2],
+			Timestamp: timestamp,
+		})
+	}
+
+	return &manifest, backup, nil
+}
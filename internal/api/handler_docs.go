@@ -0,0 +1,58 @@
+package api
+
+import (
+	"log"
+	"net/http"
+)
+
+// OpenAPIHandler returns the OpenAPI 3 description of the API
+// @Summary Spécification OpenAPI
+// @Description Retourne la description OpenAPI 3.0 de l'API, convertie depuis la documentation Swagger générée à partir des annotations des handlers
+// @Tags docs
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 500 {object} ErrorResponse
+// @Router /api/openapi.json [get]
+func (h *Handler) OpenAPIHandler(w http.ResponseWriter, r *http.Request) {
+	document, err := OpenAPIDocument()
+	if err != nil {
+		log.Printf("ERROR: Failed to build the OpenAPI document: %v", err)
+		writeAPIError(w, ErrInternal.WithMessage("Failed to build the OpenAPI document"), nil)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, document)
+}
+
+// apiDocsPage displays /api/openapi.json with the Swagger UI assets served under /swagger/
+const apiDocsPage = `<!DOCTYPE html>
+<html lang="fr">
+<head>
+  <meta charset="utf-8">
+  <title>Valhafin API</title>
+  <link rel="stylesheet" href="/swagger/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="/swagger/swagger-ui-bundle.js"></script>
+  <script>
+    window.onload = function () {
+      SwaggerUIBundle({ url: "/api/openapi.json", dom_id: "#swagger-ui" });
+    };
+  </script>
+</body>
+</html>
+`
+
+// APIDocsHandler serves a viewer for the OpenAPI description
+// @Summary Documentation de l'API
+// @Description Affiche la spécification OpenAPI (/api/openapi.json) dans Swagger UI
+// @Tags docs
+// @Produce html
+// @Success 200 {string} string "Page HTML"
+// @Router /api/docs [get]
+func (h *Handler) APIDocsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(apiDocsPage))
+}
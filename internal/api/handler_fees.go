@@ -4,7 +4,6 @@ import (
 	"database/sql"
 	"net/http"
 	"strings"
-	"time"
 
 	"github.com/gorilla/mux"
 )
@@ -17,6 +16,7 @@ import (
 // @Param id path string true "ID du compte"
 // @Param start_date query string false "Date de début (YYYY-MM-DD)"
 // @Param end_date query string false "Date de fin (YYYY-MM-DD)"
+// @Param include_hidden query bool false "Inclure les transactions masquées" default(false)
 // @Success 200 {object} fees.FeesMetrics
 // @Failure 400 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
@@ -42,27 +42,15 @@ func (h *Handler) GetAccountFeesHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	// Parse date filters
-	startDate := r.URL.Query().Get("start_date")
-	endDate := r.URL.Query().Get("end_date")
-
-	// Validate date formats if provided
-	if startDate != "" {
-		if _, err := time.Parse("2006-01-02", startDate); err != nil {
-			respondError(w, http.StatusBadRequest, "INVALID_DATE", "Invalid start_date format (use YYYY-MM-DD)", nil)
-			return
-		}
-	}
-
-	if endDate != "" {
-		if _, err := time.Parse("2006-01-02", endDate); err != nil {
-			respondError(w, http.StatusBadRequest, "INVALID_DATE", "Invalid end_date format (use YYYY-MM-DD)", nil)
-			return
-		}
+	// Parse and validate the date range
+	dates, apiErr := parseDateRangeQuery(r)
+	if apiErr != nil {
+		writeAPIError(w, *apiErr, nil)
+		return
 	}
 
 	// Calculate fees
-	feesMetrics, err := h.FeesService.CalculateAccountFees(accountID, startDate, endDate)
+	feesMetrics, err := h.FeesService.CalculateAccountFeesContext(r.Context(), accountID, dates.StartDate, dates.EndDate, includeHidden(r))
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, "FEES_ERROR", "Failed to calculate fees", map[string]string{
 			"error": err.Error(),
@@ -80,32 +68,21 @@ func (h *Handler) GetAccountFeesHandler(w http.ResponseWriter, r *http.Request)
 // @Produce json
 // @Param start_date query string false "Date de début (YYYY-MM-DD)"
 // @Param end_date query string false "Date de fin (YYYY-MM-DD)"
+// @Param include_hidden query bool false "Inclure les transactions masquées" default(false)
 // @Success 200 {object} fees.FeesMetrics
 // @Failure 400 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /api/fees [get]
 func (h *Handler) GetGlobalFeesHandler(w http.ResponseWriter, r *http.Request) {
-	// Parse date filters
-	startDate := r.URL.Query().Get("start_date")
-	endDate := r.URL.Query().Get("end_date")
-
-	// Validate date formats if provided
-	if startDate != "" {
-		if _, err := time.Parse("2006-01-02", startDate); err != nil {
-			respondError(w, http.StatusBadRequest, "INVALID_DATE", "Invalid start_date format (use YYYY-MM-DD)", nil)
-			return
-		}
-	}
-
-	if endDate != "" {
-		if _, err := time.Parse("2006-01-02", endDate); err != nil {
-			respondError(w, http.StatusBadRequest, "INVALID_DATE", "Invalid end_date format (use YYYY-MM-DD)", nil)
-			return
-		}
+	// Parse and validate the date range
+	dates, apiErr := parseDateRangeQuery(r)
+	if apiErr != nil {
+		writeAPIError(w, *apiErr, nil)
+		return
 	}
 
 	// Calculate global fees
-	feesMetrics, err := h.FeesService.CalculateGlobalFees(startDate, endDate)
+	feesMetrics, err := h.FeesService.CalculateGlobalFeesContext(r.Context(), dates.StartDate, dates.EndDate, includeHidden(r))
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, "FEES_ERROR", "Failed to calculate global fees", map[string]string{
 			"error": err.Error(),
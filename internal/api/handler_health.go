@@ -1,13 +1,20 @@
 package api
 
 import (
+	"context"
 	"net/http"
 	"time"
+	"valhafin/internal/service/price"
 )
 
+// providerHealthService is implemented by price services able to probe their provider
+type providerHealthService interface {
+	ProviderHealth(ctx context.Context) price.ProviderHealth
+}
+
 // HealthCheckHandler handles health check requests
 // @Summary Vérifier l'état de santé de l'application
-// @Description Retourne le statut de l'application et de la base de données
+// @Description Retourne le statut de l'application, de la base de données et du fournisseur de prix (up, degraded ou down). Seule la base de données conditionne le statut global : un fournisseur de prix dégradé est signalé sans rendre l'application indisponible.
 // @Tags monitoring
 // @Produce json
 // @Success 200 {object} map[string]interface{} "Application healthy"
@@ -15,20 +22,33 @@ import (
 // @Router /health [get]
 func (h *Handler) HealthCheckHandler(w http.ResponseWriter, r *http.Request) {
 	// Check database connection
-	if err := h.DB.Ping(); err != nil {
+	if err := h.DB.Ready(r.Context()); err != nil {
 		respondJSON(w, http.StatusServiceUnavailable, map[string]interface{}{
-			"status":   "unhealthy",
-			"database": "down",
-			"error":    err.Error(),
+			"status":          "unhealthy",
+			"database":        "down",
+			"circuit_breaker": h.DB.CircuitState(),
+			"error":           err.Error(),
 		})
 		return
 	}
 
 	uptime := time.Since(h.StartTime)
-	respondJSON(w, http.StatusOK, map[string]interface{}{
-		"status":   "healthy",
-		"version":  h.Version,
-		"uptime":   uptime.String(),
-		"database": "up",
-	})
+	response := map[string]interface{}{
+		"status":          "healthy",
+		"version":         h.Version,
+		"uptime":          uptime.String(),
+		"database":        "up",
+		"circuit_breaker": h.DB.CircuitState(),
+	}
+
+	// The price provider is reported but does not affect readiness
+	if provider, ok := h.PriceService.(providerHealthService); ok {
+		health := provider.ProviderHealth(r.Context())
+		response["price_provider"] = health.Status
+		if health.Error != "" {
+			response["price_provider_error"] = health.Error
+		}
+	}
+
+	respondJSON(w, http.StatusOK, response)
 }
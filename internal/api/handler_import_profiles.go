@@ -0,0 +1,85 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"valhafin/internal/domain/models"
+	"valhafin/internal/repository/database"
+)
+
+// CreateImportProfileRequest represents the request body for saving an import profile
+type CreateImportProfileRequest struct {
+	Name         string            `json:"name"`
+	Columns      map[string]string `json:"columns"`
+	DateFormat   string            `json:"date_format,omitempty"`
+	DecimalStyle string            `json:"decimal_style,omitempty"`
+}
+
+// CreateImportProfileHandler saves a named CSV column mapping
+// @Summary Créer un profil d'import
+// @Description Enregistre un profil de correspondance des colonnes CSV (colonne source → champ interne, format de date, séparateur décimal) réutilisable avec /api/transactions/import?profile=
+// @Tags transactions
+// @Accept json
+// @Produce json
+// @Param profile body CreateImportProfileRequest true "Profil d'import"
+// @Success 201 {object} models.ImportProfile
+// @Failure 400 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/import/profiles [post]
+func (h *Handler) CreateImportProfileHandler(w http.ResponseWriter, r *http.Request) {
+	var req CreateImportProfileRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeAPIError(w, ErrInvalidRequest.WithMessage("Invalid request body"), nil)
+		return
+	}
+
+	profile := &models.ImportProfile{
+		Name:         req.Name,
+		Columns:      req.Columns,
+		DateFormat:   req.DateFormat,
+		DecimalStyle: strings.ToLower(strings.TrimSpace(req.DecimalStyle)),
+	}
+
+	if err := profile.Validate(); err != nil {
+		writeAPIError(w, ErrValidation.WithMessage(err.Error()), nil)
+		return
+	}
+
+	if err := h.DB.CreateImportProfile(profile); err != nil {
+		if errors.Is(err, database.ErrImportProfileExists) {
+			writeAPIError(w, ErrImportProfileExists, map[string]string{
+				"name": profile.Name,
+			})
+			return
+		}
+		writeAPIError(w, ErrDatabase.WithMessage("Failed to create import profile"), nil)
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, profile)
+}
+
+// GetImportProfilesHandler lists the saved import profiles
+// @Summary Lister les profils d'import
+// @Description Récupère les profils de correspondance des colonnes CSV enregistrés
+// @Tags transactions
+// @Produce json
+// @Success 200 {array} models.ImportProfile
+// @Failure 500 {object} ErrorResponse
+// @Router /api/import/profiles [get]
+func (h *Handler) GetImportProfilesHandler(w http.ResponseWriter, r *http.Request) {
+	profiles, err := h.DB.GetAllImportProfiles()
+	if err != nil {
+		writeAPIError(w, ErrDatabase.WithMessage("Failed to retrieve import profiles"), nil)
+		return
+	}
+
+	if profiles == nil {
+		profiles = []models.ImportProfile{}
+	}
+
+	respondJSON(w, http.StatusOK, profiles)
+}
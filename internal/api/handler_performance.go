@@ -1,13 +1,203 @@
 package api
 
 import (
+	"context"
 	"database/sql"
+	"errors"
+	"fmt"
 	"net/http"
+	"regexp"
 	"strings"
+	"time"
+	"valhafin/internal/domain/models"
+	"valhafin/internal/repository/database"
+	"valhafin/internal/service/performance"
+	"valhafin/internal/service/price"
 
 	"github.com/gorilla/mux"
 )
 
+// DefaultPerformanceCacheTTL is how long performance results are cached by default
+const DefaultPerformanceCacheTTL = 30 * time.Second
+
+// performanceCacheTTL is replaced from the configuration with SetPerformanceCacheTTL
+var performanceCacheTTL = DefaultPerformanceCacheTTL
+
+// SetPerformanceCacheTTL sets how long the routes set up afterwards cache the portfolio and
+// account performance (0 disables the cache)
+func SetPerformanceCacheTTL(ttl time.Duration) {
+	performanceCacheTTL = ttl
+}
+
+// validPeriods are the preset performance periods
+var validPeriods = map[string]bool{"1m": true, "3m": true, "1y": true, "all": true}
+
+// dateRangeQuery is the start_date/end_date range (YYYY-MM-DD, both inclusive) accepted by
+// the performance and fees endpoints
+type dateRangeQuery struct {
+	StartDate string
+	EndDate   string
+	start     time.Time
+	end       time.Time
+}
+
+// IsSet reports whether start_date or end_date was given
+func (q dateRangeQuery) IsSet() bool {
+	return q.StartDate != "" || q.EndDate != ""
+}
+
+// parseDateRangeQuery reads start_date and end_date. The start date begins at 00:00 and the
+// end date ends at 23:59:59 in the filter time zone; a missing end date means now. The start
+// must be before the end.
+func parseDateRangeQuery(r *http.Request) (dateRangeQuery, *APIError) {
+	q := dateRangeQuery{
+		StartDate: r.URL.Query().Get("start_date"),
+		EndDate:   r.URL.Query().Get("end_date"),
+		end:       time.Now(),
+	}
+
+	bound := func(name, value string, isEnd bool) (time.Time, *APIError) {
+		if _, err := time.Parse("2006-01-02", value); err != nil {
+			apiErr := ErrInvalidDate.WithMessage(fmt.Sprintf("Invalid %s format (use YYYY-MM-DD)", name))
+			return time.Time{}, &apiErr
+		}
+		normalized, _ := database.NormalizeDateBound(value, isEnd)
+		t, _ := time.Parse(time.RFC3339, normalized)
+		return t, nil
+	}
+
+	var apiErr *APIError
+	if q.StartDate != "" {
+		if q.start, apiErr = bound("start_date", q.StartDate, false); apiErr != nil {
+			return q, apiErr
+		}
+	}
+	if q.EndDate != "" {
+		if q.end, apiErr = bound("end_date", q.EndDate, true); apiErr != nil {
+			return q, apiErr
+		}
+	}
+
+	if q.StartDate != "" && !q.start.Before(q.end) {
+		rangeErr := ErrInvalidDateRange.WithMessage("start_date must be before end_date")
+		return q, &rangeErr
+	}
+	return q, nil
+}
+
+// parseAsOf reads the optional as_of date (YYYY-MM-DD) at which a portfolio is valued. The
+// valuation happens at the end of that day in the filter time zone; today is valued now, like
+// without as_of, so nil is returned. Future dates are rejected, and as_of cannot be combined
+// with start_date/end_date.
+func parseAsOf(r *http.Request) (*time.Time, *APIError) {
+	value := r.URL.Query().Get("as_of")
+	if value == "" {
+		return nil, nil
+	}
+	if r.URL.Query().Get("start_date") != "" || r.URL.Query().Get("end_date") != "" {
+		apiErr := ErrInvalidDate.WithMessage("as_of cannot be combined with start_date or end_date")
+		return nil, &apiErr
+	}
+	if _, err := time.Parse("2006-01-02", value); err != nil {
+		apiErr := ErrInvalidDate.WithMessage("Invalid as_of format (use YYYY-MM-DD)")
+		return nil, &apiErr
+	}
+
+	now := time.Now()
+	startOfDay, _ := database.NormalizeDateBound(value, false)
+	if start, _ := time.Parse(time.RFC3339, startOfDay); start.After(now) {
+		apiErr := ErrInvalidDate.WithMessage("as_of cannot be in the future")
+		return nil, &apiErr
+	}
+	endOfDay, _ := database.NormalizeDateBound(value, true)
+	asOf, _ := time.Parse(time.RFC3339, endOfDay)
+	if asOf.After(now) {
+		// Today: the default valuation at the current prices
+		return nil, nil
+	}
+	return &asOf, nil
+}
+
+// performanceRange is either a preset period or an explicit date range
+type performanceRange struct {
+	Period string
+	Start  time.Time
+	End    time.Time
+}
+
+// IsCustom reports whether the range was given with start_date/end_date
+func (p performanceRange) IsCustom() bool {
+	return p.Period == ""
+}
+
+// parsePerformanceRange reads the period (default 1y) or the start_date/end_date range of a
+// performance request; both cannot be combined, and start_date is required for a range
+func parsePerformanceRange(r *http.Request) (performanceRange, *APIError) {
+	period := r.URL.Query().Get("period")
+
+	dates, apiErr := parseDateRangeQuery(r)
+	if apiErr != nil {
+		return performanceRange{}, apiErr
+	}
+	if dates.IsSet() {
+		if period != "" {
+			periodErr := ErrInvalidPeriod.WithMessage("period cannot be combined with start_date or end_date")
+			return performanceRange{}, &periodErr
+		}
+		if dates.StartDate == "" {
+			dateErr := ErrInvalidDate.WithMessage("start_date is required with end_date (use YYYY-MM-DD)")
+			return performanceRange{}, &dateErr
+		}
+		return performanceRange{Start: dates.start, End: dates.end}, nil
+	}
+
+	if period == "" {
+		period = "1y"
+	}
+	if !validPeriods[period] {
+		periodErr := ErrInvalidPeriod.WithMessage("Period must be one of: 1m, 3m, 1y, all")
+		return performanceRange{}, &periodErr
+	}
+	return performanceRange{Period: period}, nil
+}
+
+// aggregationOptions returns the calculation options of a request, in which hidden transactions
+// are kept when the request sets include_hidden=true
+func aggregationOptions(r *http.Request) performance.Options {
+	return performance.Options{IncludeHidden: includeHidden(r)}
+}
+
+// performanceOptions returns the calculation options of a performance request with the
+// sampling interval of its time series (auto by default)
+func performanceOptions(r *http.Request) (performance.Options, *APIError) {
+	opts := aggregationOptions(r)
+	interval := r.URL.Query().Get("interval")
+	if interval == "" {
+		return opts, nil
+	}
+	if !performance.IsSamplingInterval(interval) {
+		apiErr := ErrValidation.WithMessage("interval must be one of: auto, daily, weekly, monthly")
+		return opts, &apiErr
+	}
+	opts.Interval = interval
+	return opts, nil
+}
+
+// writePerformanceError responds to a failed performance calculation; a time series with too
+// many points is an invalid interval, and an account deleted meanwhile is not found
+func writePerformanceError(w http.ResponseWriter, message string, err error) {
+	switch {
+	case errors.Is(err, performance.ErrTooManyPoints):
+		writeAPIError(w, ErrValidation.WithMessage(err.Error()), map[string]string{"field": "interval"})
+	case errors.Is(err, sql.ErrNoRows):
+		writeAPIError(w, ErrNotFound.WithMessage("Account not found"), nil)
+	default:
+		writeAPIError(w, ErrDatabase.WithMessage(message), map[string]string{
+			"error": err.Error(),
+		})
+	}
+}
+
 // GetAccountPerformanceHandler retrieves performance metrics for a specific account
 // @Summary Performance d'un compte
 // @Description Calcule les métriques de performance pour un compte spécifique
@@ -15,6 +205,11 @@ import (
 // @Produce json
 // @Param id path string true "ID du compte"
 // @Param period query string false "Période (1m, 3m, 1y, all)" default(1y)
+// @Param start_date query string false "Date de début (YYYY-MM-DD), à la place de period"
+// @Param end_date query string false "Date de fin (YYYY-MM-DD, aujourd'hui par défaut), avec start_date"
+// @Param as_of query string false "Date de valorisation (YYYY-MM-DD) : transactions rejouées jusqu'à cette date et prix historiques, avec period"
+// @Param interval query string false "Intervalle de la série temporelle : auto (défaut), daily, weekly ou monthly"
+// @Param include_hidden query bool false "Inclure les transactions masquées" default(false)
 // @Success 200 {object} performance.Performance
 // @Failure 400 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
@@ -25,80 +220,174 @@ func (h *Handler) GetAccountPerformanceHandler(w http.ResponseWriter, r *http.Re
 	accountID := vars["id"]
 
 	if accountID == "" {
-		respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "Account ID is required", nil)
+		writeAPIError(w, ErrInvalidRequest.WithMessage("Account ID is required"), nil)
 		return
 	}
 
 	// Check if account exists
-	_, err := h.DB.GetAccountByID(accountID)
+	_, err := h.DB.GetAccountByIDContext(r.Context(), accountID)
 	if err != nil {
-		if err == sql.ErrNoRows || (err != nil && strings.Contains(err.Error(), "no rows")) {
-			respondError(w, http.StatusNotFound, "NOT_FOUND", "Account not found", nil)
+		if errors.Is(err, sql.ErrNoRows) {
+			writeAPIError(w, ErrNotFound.WithMessage("Account not found"), nil)
 			return
 		}
-		respondError(w, http.StatusInternalServerError, "DATABASE_ERROR", "Failed to retrieve account", nil)
+		writeAPIError(w, ErrDatabase.WithMessage("Failed to retrieve account"), nil)
 		return
 	}
 
-	// Get period from query parameter (default: 1y)
-	period := r.URL.Query().Get("period")
-	if period == "" {
-		period = "1y"
+	// Get the period (default: 1y) or the explicit date range
+	dateRange, apiErr := parsePerformanceRange(r)
+	if apiErr != nil {
+		writeAPIError(w, *apiErr, nil)
+		return
 	}
-
-	// Validate period
-	validPeriods := map[string]bool{"1m": true, "3m": true, "1y": true, "all": true}
-	if !validPeriods[period] {
-		respondError(w, http.StatusBadRequest, "INVALID_PERIOD", "Period must be one of: 1m, 3m, 1y, all", nil)
+	asOf, apiErr := parseAsOf(r)
+	if apiErr != nil {
+		writeAPIError(w, *apiErr, map[string]string{"field": "as_of"})
+		return
+	}
+	opts, apiErr := performanceOptions(r)
+	if apiErr != nil {
+		writeAPIError(w, *apiErr, map[string]string{"field": "interval"})
 		return
 	}
 
 	// Calculate performance
-	performance, err := h.PerformanceService.CalculateAccountPerformance(accountID, period)
+	var performance *performance.Performance
+	if asOf != nil {
+		performance, err = h.PerformanceService.CalculateAccountPerformanceAsOfContext(r.Context(), accountID, dateRange.Period, *asOf, opts)
+	} else if dateRange.IsCustom() {
+		performance, err = h.PerformanceService.CalculateAccountPerformanceRangeContext(r.Context(), accountID, dateRange.Start, dateRange.End, opts)
+	} else {
+		performance, err = h.PerformanceService.CalculateAccountPerformanceContext(r.Context(), accountID, dateRange.Period, opts)
+	}
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "PERFORMANCE_ERROR", "Failed to calculate performance", map[string]string{
-			"error": err.Error(),
-		})
+		writePerformanceError(w, "Failed to calculate performance", err)
 		return
 	}
 
 	respondJSON(w, http.StatusOK, performance)
 }
 
+// GetAccountSummaryHandler returns a compact all-time overview of an account
+// @Summary Résumé d'un compte
+// @Description Retourne le solde espèces, les dépôts, les intérêts et la valeur totale d'un compte, y compris pour les comptes sans titres
+// @Tags performance
+// @Produce json
+// @Param id path string true "ID du compte"
+// @Param include_hidden query bool false "Inclure les transactions masquées" default(false)
+// @Success 200 {object} performance.AccountSummary
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/accounts/{id}/summary [get]
+func (h *Handler) GetAccountSummaryHandler(w http.ResponseWriter, r *http.Request) {
+	accountID := mux.Vars(r)["id"]
+
+	account, err := h.DB.GetAccountByIDContext(r.Context(), accountID)
+	if err != nil {
+		if err == sql.ErrNoRows || strings.Contains(err.Error(), "no rows") {
+			writeAPIError(w, ErrNotFound.WithMessage("Account not found"), nil)
+			return
+		}
+		writeAPIError(w, ErrDatabase.WithMessage("Failed to retrieve account"), nil)
+		return
+	}
+
+	perf, err := h.PerformanceService.CalculateAccountPerformanceContext(r.Context(), accountID, "all", aggregationOptions(r))
+	if err != nil {
+		writeAPIError(w, ErrPerformance, map[string]string{"error": err.Error()})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, performance.Summarize(account, perf))
+}
+
 // GetGlobalPerformanceHandler retrieves performance metrics across all accounts
 // @Summary Performance globale
 // @Description Calcule les métriques de performance pour tous les comptes
 // @Tags performance
 // @Produce json
 // @Param period query string false "Période (1m, 3m, 1y, all)" default(1y)
+// @Param start_date query string false "Date de début (YYYY-MM-DD), à la place de period"
+// @Param end_date query string false "Date de fin (YYYY-MM-DD, aujourd'hui par défaut), avec start_date"
+// @Param as_of query string false "Date de valorisation (YYYY-MM-DD) : transactions rejouées jusqu'à cette date et prix historiques, avec period"
+// @Param benchmark query string false "Indice ou ISIN de comparaison (ex: ^GSPC), séries normalisées à 100"
+// @Param interval query string false "Intervalle de la série temporelle : auto (défaut), daily, weekly ou monthly"
+// @Param include_hidden query bool false "Inclure les transactions masquées" default(false)
 // @Success 200 {object} performance.Performance
 // @Failure 400 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /api/performance [get]
 func (h *Handler) GetGlobalPerformanceHandler(w http.ResponseWriter, r *http.Request) {
-	// Get period from query parameter (default: 1y)
-	period := r.URL.Query().Get("period")
-	if period == "" {
-		period = "1y"
+	// Get the period (default: 1y) or the explicit date range
+	dateRange, apiErr := parsePerformanceRange(r)
+	if apiErr != nil {
+		writeAPIError(w, *apiErr, nil)
+		return
 	}
 
-	// Validate period
-	validPeriods := map[string]bool{"1m": true, "3m": true, "1y": true, "all": true}
-	if !validPeriods[period] {
-		respondError(w, http.StatusBadRequest, "INVALID_PERIOD", "Period must be one of: 1m, 3m, 1y, all", nil)
+	asOf, apiErr := parseAsOf(r)
+	if apiErr != nil {
+		writeAPIError(w, *apiErr, map[string]string{"field": "as_of"})
+		return
+	}
+	opts, apiErr := performanceOptions(r)
+	if apiErr != nil {
+		writeAPIError(w, *apiErr, map[string]string{"field": "interval"})
 		return
 	}
 
 	// Calculate global performance
-	performance, err := h.PerformanceService.CalculateGlobalPerformance(period)
+	var perf *performance.Performance
+	var err error
+	if asOf != nil {
+		perf, err = h.PerformanceService.CalculateGlobalPerformanceAsOfContext(r.Context(), dateRange.Period, *asOf, opts)
+	} else if dateRange.IsCustom() {
+		perf, err = h.PerformanceService.CalculateGlobalPerformanceRangeContext(r.Context(), dateRange.Start, dateRange.End, opts)
+	} else {
+		perf, err = h.PerformanceService.CalculateGlobalPerformanceContext(r.Context(), dateRange.Period, opts)
+	}
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "PERFORMANCE_ERROR", "Failed to calculate global performance", map[string]string{
-			"error": err.Error(),
-		})
+		writePerformanceError(w, "Failed to calculate global performance", err)
 		return
 	}
 
-	respondJSON(w, http.StatusOK, performance)
+	// Optional benchmark overlay (symbol such as ^GSPC or ISIN of a tracked asset)
+	if benchmark := strings.TrimSpace(r.URL.Query().Get("benchmark")); benchmark != "" && len(perf.TimeSeries) > 0 {
+		startDate := perf.TimeSeries[0].Date
+		endDate := perf.TimeSeries[len(perf.TimeSeries)-1].Date
+
+		prices, err := h.getBenchmarkPrices(r.Context(), benchmark, startDate, endDate)
+		if err != nil {
+			writeAPIError(w, ErrBenchmark.WithMessage("Failed to fetch benchmark history"), map[string]string{
+				"benchmark": benchmark,
+				"error":     err.Error(),
+			})
+			return
+		}
+
+		perf.Benchmark = performance.CompareWithBenchmark(benchmark, perf.TimeSeries, prices)
+	}
+
+	respondJSON(w, http.StatusOK, perf)
+}
+
+// getBenchmarkPrices returns the price history of a benchmark
+// Tracked assets (ISIN) go through GetPriceHistory, other values are treated as Yahoo symbols
+func (h *Handler) getBenchmarkPrices(ctx context.Context, benchmark string, startDate, endDate time.Time) ([]models.AssetPrice, error) {
+	isinRegex := regexp.MustCompile(`^[A-Z]{2}[A-Z0-9]{10}$`)
+	if isinRegex.MatchString(benchmark) {
+		if _, err := h.DB.GetAssetByISIN(benchmark); err == nil {
+			return h.PriceService.GetPriceHistory(benchmark, startDate, endDate)
+		}
+	}
+
+	yahooService, ok := price.YahooService(h.PriceService)
+	if !ok {
+		return nil, fmt.Errorf("price service is not Yahoo Finance")
+	}
+
+	return yahooService.GetSymbolPriceHistory(ctx, benchmark, startDate, endDate)
 }
 
 // GetAssetPerformanceHandler retrieves performance metrics for a specific asset
@@ -108,6 +397,9 @@ func (h *Handler) GetGlobalPerformanceHandler(w http.ResponseWriter, r *http.Req
 // @Produce json
 // @Param isin path string true "Code ISIN de l'actif"
 // @Param period query string false "Période (1m, 3m, 1y, all)" default(1y)
+// @Param start_date query string false "Date de début (YYYY-MM-DD), à la place de period"
+// @Param end_date query string false "Date de fin (YYYY-MM-DD, aujourd'hui par défaut), avec start_date"
+// @Param include_hidden query bool false "Inclure les transactions masquées" default(false)
 // @Success 200 {object} performance.AssetPerformance
 // @Failure 400 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
@@ -122,21 +414,21 @@ func (h *Handler) GetAssetPerformanceHandler(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	// Get period from query parameter (default: 1y)
-	period := r.URL.Query().Get("period")
-	if period == "" {
-		period = "1y"
-	}
-
-	// Validate period
-	validPeriods := map[string]bool{"1m": true, "3m": true, "1y": true, "all": true}
-	if !validPeriods[period] {
-		respondError(w, http.StatusBadRequest, "INVALID_PERIOD", "Period must be one of: 1m, 3m, 1y, all", nil)
+	// Get the period (default: 1y) or the explicit date range
+	dateRange, apiErr := parsePerformanceRange(r)
+	if apiErr != nil {
+		writeAPIError(w, *apiErr, nil)
 		return
 	}
 
 	// Calculate asset performance
-	performance, err := h.PerformanceService.CalculateAssetPerformance(isin, period)
+	var performance *performance.AssetPerformance
+	var err error
+	if dateRange.IsCustom() {
+		performance, err = h.PerformanceService.CalculateAssetPerformanceRangeContext(r.Context(), isin, dateRange.Start, dateRange.End, aggregationOptions(r))
+	} else {
+		performance, err = h.PerformanceService.CalculateAssetPerformanceContext(r.Context(), isin, dateRange.Period, aggregationOptions(r))
+	}
 	if err != nil {
 		if strings.Contains(err.Error(), "not found") {
 			respondError(w, http.StatusNotFound, "NOT_FOUND", "Asset not found", nil)
@@ -150,3 +442,125 @@ func (h *Handler) GetAssetPerformanceHandler(w http.ResponseWriter, r *http.Requ
 
 	respondJSON(w, http.StatusOK, performance)
 }
+
+// GetAssetHoldingsHistoryHandler retrieves the quantity held of an asset over time
+// @Summary Historique des quantités détenues
+// @Description Retourne la quantité détenue d'un actif à chaque point de la période, calculée en rejouant les transactions (sans prix)
+// @Tags performance
+// @Produce json
+// @Param isin path string true "Code ISIN de l'actif"
+// @Param period query string false "Période (1m, 3m, 1y, all)" default(1y)
+// @Param start_date query string false "Date de début (YYYY-MM-DD), à la place de period"
+// @Param end_date query string false "Date de fin (YYYY-MM-DD, aujourd'hui par défaut), avec start_date"
+// @Param include_hidden query bool false "Inclure les transactions masquées" default(false)
+// @Success 200 {object} performance.HoldingsHistory
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/assets/{isin}/holdings-history [get]
+func (h *Handler) GetAssetHoldingsHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	isin := vars["isin"]
+
+	if isin == "" {
+		writeAPIError(w, ErrValidation.WithMessage("ISIN is required"), map[string]string{"field": "isin"})
+		return
+	}
+
+	// Get the period (default: 1y) or the explicit date range
+	dateRange, apiErr := parsePerformanceRange(r)
+	if apiErr != nil {
+		writeAPIError(w, *apiErr, nil)
+		return
+	}
+
+	var history *performance.HoldingsHistory
+	var err error
+	if dateRange.IsCustom() {
+		history, err = h.PerformanceService.CalculateAssetHoldingsHistoryRangeContext(r.Context(), isin, dateRange.Start, dateRange.End, aggregationOptions(r))
+	} else {
+		history, err = h.PerformanceService.CalculateAssetHoldingsHistoryContext(r.Context(), isin, dateRange.Period, aggregationOptions(r))
+	}
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeAPIError(w, ErrNotFound.WithMessage("Asset not found"), nil)
+			return
+		}
+		writeAPIError(w, ErrDatabase.WithMessage("Failed to calculate holdings history"), map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, history)
+}
+
+// GetAssetLotsHandler retrieves the open purchase lots of an asset
+// @Summary Lots d'achat ouverts d'un actif
+// @Description Retourne chaque lot d'achat encore détenu (date, quantité, coût, valeur actuelle, plus-value latente au prix actuel). Les ventes consomment les lots les plus anciens de leur compte (FIFO).
+// @Tags performance
+// @Produce json
+// @Param isin path string true "Code ISIN de l'actif"
+// @Param include_hidden query bool false "Inclure les transactions masquées" default(false)
+// @Success 200 {object} performance.AssetLots
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/assets/{isin}/lots [get]
+func (h *Handler) GetAssetLotsHandler(w http.ResponseWriter, r *http.Request) {
+	isin := mux.Vars(r)["isin"]
+
+	if isin == "" {
+		writeAPIError(w, ErrValidation.WithMessage("ISIN is required"), map[string]string{"field": "isin"})
+		return
+	}
+
+	lots, err := h.PerformanceService.CalculateAssetLotsContext(r.Context(), isin, aggregationOptions(r))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeAPIError(w, ErrNotFound.WithMessage("Asset not found"), nil)
+			return
+		}
+		writeAPIError(w, ErrDatabase.WithMessage("Failed to calculate asset lots"), map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, lots)
+}
+
+// GetAccountCashFlowHandler retrieves the cash ledger of an account
+// @Summary Relevé de trésorerie d'un compte
+// @Description Retourne l'effet de chaque transaction sur les liquidités, par ordre chronologique, avec le solde courant après chacune (dépôt +, achat −, vente +, intérêts +, frais −). Le dernier solde est le cash_balance de la performance du compte.
+// @Tags performance
+// @Produce json
+// @Param id path string true "ID du compte"
+// @Param include_hidden query bool false "Inclure les transactions masquées" default(false)
+// @Success 200 {object} performance.CashFlowStatement
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/accounts/{id}/cashflow [get]
+func (h *Handler) GetAccountCashFlowHandler(w http.ResponseWriter, r *http.Request) {
+	accountID := mux.Vars(r)["id"]
+
+	if accountID == "" {
+		writeAPIError(w, ErrValidation.WithMessage("Account ID is required"), map[string]string{"field": "id"})
+		return
+	}
+
+	statement, err := h.PerformanceService.CalculateAccountCashFlowContext(r.Context(), accountID, aggregationOptions(r))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeAPIError(w, ErrNotFound.WithMessage("Account not found"), nil)
+			return
+		}
+		writeAPIError(w, ErrDatabase.WithMessage("Failed to calculate cash flow"), map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, statement)
+}
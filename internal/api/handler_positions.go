@@ -0,0 +1,257 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+	"valhafin/internal/domain/models"
+	"valhafin/internal/repository/database"
+	"valhafin/internal/service/scraper/traderepublic"
+	"valhafin/internal/utils"
+
+	"github.com/gorilla/mux"
+)
+
+// positionQuantityTolerance absorbs rounding of fractional shares between the broker and the replay
+const positionQuantityTolerance = 1e-6
+
+// PositionDifference is a position whose replayed quantity does not match the broker
+type PositionDifference struct {
+	ISIN                 string  `json:"isin"`
+	BrokerQuantity       float64 `json:"broker_quantity"`
+	ComputedQuantity     float64 `json:"computed_quantity"`
+	QuantityDifference   float64 `json:"quantity_difference"` // Broker minus computed
+	BrokerAveragePrice   float64 `json:"broker_average_price"`
+	ComputedAveragePrice float64 `json:"computed_average_price"`
+}
+
+// PositionReconciliation compares the positions replayed from transactions with the latest broker snapshot
+type PositionReconciliation struct {
+	AccountID   string               `json:"account_id"`
+	SnapshotID  string               `json:"snapshot_id"`
+	TakenAt     time.Time            `json:"taken_at"`
+	InSync      bool                 `json:"in_sync"`
+	Differences []PositionDifference `json:"differences"`
+}
+
+// reconcilePositions lists the ISINs whose quantity differs between the broker snapshot
+// (the truth, including corporate actions) and the positions computed from transactions
+func reconcilePositions(snapshot *models.PositionSnapshot, computed map[string]*AssetPosition) PositionReconciliation {
+	differences := []PositionDifference{}
+	broker := make(map[string]models.BrokerPosition, len(snapshot.Positions))
+
+	for _, position := range snapshot.Positions {
+		broker[position.ISIN] = position
+
+		var quantity, averagePrice float64
+		if replayed, ok := computed[position.ISIN]; ok {
+			quantity = replayed.Quantity
+			averagePrice = averageBuyPrice(replayed.TotalInvested, replayed.Quantity)
+		}
+		if math.Abs(position.Quantity-quantity) > positionQuantityTolerance {
+			differences = append(differences, PositionDifference{
+				ISIN:                 position.ISIN,
+				BrokerQuantity:       position.Quantity,
+				ComputedQuantity:     quantity,
+				QuantityDifference:   position.Quantity - quantity,
+				BrokerAveragePrice:   position.AveragePrice,
+				ComputedAveragePrice: averagePrice,
+			})
+		}
+	}
+
+	// Positions the replay still holds but the broker no longer reports
+	for isin, replayed := range computed {
+		if _, ok := broker[isin]; ok || math.Abs(replayed.Quantity) <= positionQuantityTolerance {
+			continue
+		}
+		differences = append(differences, PositionDifference{
+			ISIN:                 isin,
+			ComputedQuantity:     replayed.Quantity,
+			QuantityDifference:   -replayed.Quantity,
+			ComputedAveragePrice: averageBuyPrice(replayed.TotalInvested, replayed.Quantity),
+		})
+	}
+
+	sort.Slice(differences, func(i, j int) bool {
+		return differences[i].ISIN < differences[j].ISIN
+	})
+
+	return PositionReconciliation{
+		AccountID:   snapshot.AccountID,
+		SnapshotID:  snapshot.ID,
+		TakenAt:     snapshot.TakenAt,
+		InSync:      len(differences) == 0,
+		Differences: differences,
+	}
+}
+
+// storePortfolioSnapshot fetches the Trade Republic portfolio with a session token and
+// stores it as the broker snapshot of the account
+func (h *Handler) storePortfolioSnapshot(r *http.Request, trScraper *traderepublic.Scraper, sessionToken, accountID string) (*models.PositionSnapshot, error) {
+	positions, err := trScraper.FetchPortfolio(sessionToken)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := &models.PositionSnapshot{
+		AccountID: accountID,
+		TakenAt:   time.Now().UTC(),
+		Positions: positions,
+	}
+	if err := h.DB.CreatePositionSnapshot(r.Context(), snapshot); err != nil {
+		return nil, err
+	}
+
+	return snapshot, nil
+}
+
+// reconcileAccountPositions compares a snapshot with the positions replayed from the account transactions
+func (h *Handler) reconcileAccountPositions(r *http.Request, account *models.Account, snapshot *models.PositionSnapshot) (PositionReconciliation, error) {
+	transactions, err := h.DB.GetTransactionsByAccountContext(r.Context(), account.ID, account.Platform, database.TransactionFilter{IncludeHidden: includeHidden(r)})
+	if err != nil {
+		return PositionReconciliation{}, err
+	}
+	return reconcilePositions(snapshot, computePositions(transactions)), nil
+}
+
+// SyncPositionsHandler fetches the current Trade Republic positions and stores them as a snapshot
+// @Summary Synchroniser les positions Trade Republic
+// @Description Récupère les positions actuelles chez Trade Republic (quantité et prix moyen par ISIN) avec le code 2FA, les enregistre comme instantané et les rapproche des positions calculées à partir des transactions
+// @Tags positions
+// @Accept json
+// @Produce json
+// @Param id path string true "ID du compte"
+// @Param body body CompleteSyncRequest true "Process ID et code 2FA"
+// @Param include_hidden query bool false "Inclure les transactions masquées" default(false)
+// @Success 200 {object} PositionReconciliation
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/accounts/{id}/positions/sync [post]
+func (h *Handler) SyncPositionsHandler(w http.ResponseWriter, r *http.Request) {
+	accountID := mux.Vars(r)["id"]
+
+	var req CompleteSyncRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeAPIError(w, ErrInvalidRequest.WithMessage("Invalid request body"), nil)
+		return
+	}
+
+	if req.ProcessID == "" || req.Code == "" {
+		writeAPIError(w, ErrValidation.WithMessage("Process ID and code are required"), nil)
+		return
+	}
+
+	account, err := h.DB.GetAccountByIDContext(r.Context(), accountID)
+	if err != nil {
+		if err == sql.ErrNoRows || strings.Contains(err.Error(), "no rows") {
+			writeAPIError(w, ErrNotFound.WithMessage("Account not found"), nil)
+			return
+		}
+		writeAPIError(w, ErrDatabase.WithMessage("Failed to retrieve account"), nil)
+		return
+	}
+
+	// Only Trade Republic exposes a portfolio snapshot
+	if platform, ok := models.GetPlatform(account.Platform); !ok || !platform.PortfolioSnapshot {
+		writeAPIError(w, ErrInvalidPlatform.WithMessage("This endpoint is only for Trade Republic accounts"), nil)
+		return
+	}
+
+	trScraper, ok := h.SyncService.GetScraper("traderepublic").(*traderepublic.Scraper)
+	if !ok {
+		writeAPIError(w, ErrScraper.WithMessage("Trade Republic scraper not available"), nil)
+		return
+	}
+
+	// Only one synchronization per account at a time
+	release, err := h.SyncService.LockAccount(accountID)
+	if err != nil {
+		writeAPIError(w, ErrSyncInProgress, nil)
+		return
+	}
+	defer release()
+
+	sessionToken, err := trScraper.Authenticate2FA(req.ProcessID, req.Code)
+	if err != nil {
+		log.Printf("ERROR: 2FA verification failed for account %s: %s", accountID, utils.RedactText(err.Error()))
+		writeAPIError(w, ErrInvalidCode.WithMessage("Failed to verify code"), map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	h.saveAccountSession(r, account.ID, sessionToken)
+
+	snapshot, err := h.storePortfolioSnapshot(r, trScraper, sessionToken, account.ID)
+	if err != nil {
+		log.Printf("ERROR: Failed to sync positions for account %s: %s", accountID, utils.RedactText(err.Error()))
+		writeAPIError(w, ErrSync.WithMessage("Failed to fetch positions"), map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	log.Printf("INFO: Stored %d broker positions for account %s", len(snapshot.Positions), accountID)
+
+	reconciliation, err := h.reconcileAccountPositions(r, account, snapshot)
+	if err != nil {
+		writeAPIError(w, ErrDatabase.WithMessage("Failed to retrieve transactions"), nil)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, reconciliation)
+}
+
+// ReconcilePositionsHandler compares the replayed positions with the latest broker snapshot
+// @Summary Rapprocher les positions avec le courtier
+// @Description Compare les positions calculées à partir des transactions avec le dernier instantané des positions du courtier et liste les écarts (opérations sur titres manquantes, transactions absentes...)
+// @Tags positions
+// @Produce json
+// @Param id path string true "ID du compte"
+// @Param include_hidden query bool false "Inclure les transactions masquées" default(false)
+// @Success 200 {object} PositionReconciliation
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/accounts/{id}/positions/reconcile [get]
+func (h *Handler) ReconcilePositionsHandler(w http.ResponseWriter, r *http.Request) {
+	accountID := mux.Vars(r)["id"]
+
+	account, err := h.DB.GetAccountByIDContext(r.Context(), accountID)
+	if err != nil {
+		if err == sql.ErrNoRows || strings.Contains(err.Error(), "no rows") {
+			writeAPIError(w, ErrNotFound.WithMessage("Account not found"), nil)
+			return
+		}
+		writeAPIError(w, ErrDatabase.WithMessage("Failed to retrieve account"), nil)
+		return
+	}
+
+	snapshot, err := h.DB.GetLatestPositionSnapshot(r.Context(), account.ID)
+	if err != nil {
+		if err == sql.ErrNoRows || strings.Contains(err.Error(), "no rows") {
+			writeAPIError(w, ErrNotFound.WithMessage("No broker positions snapshot for this account"), nil)
+			return
+		}
+		writeAPIError(w, ErrDatabase.WithMessage("Failed to retrieve positions snapshot"), nil)
+		return
+	}
+
+	reconciliation, err := h.reconcileAccountPositions(r, account, snapshot)
+	if err != nil {
+		if writePlatformError(w, err, account.Platform) {
+			return
+		}
+		writeAPIError(w, ErrDatabase.WithMessage("Failed to retrieve transactions"), nil)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, reconciliation)
+}
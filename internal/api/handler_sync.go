@@ -3,12 +3,16 @@ package api
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
 	"net/http"
 	"strings"
 	"time"
+	"valhafin/internal/domain/models"
 	"valhafin/internal/service/scraper/traderepublic"
+	"valhafin/internal/service/scraper/types"
+	"valhafin/internal/service/sync"
+	"valhafin/internal/utils"
 
 	"github.com/gorilla/mux"
 )
@@ -25,6 +29,14 @@ type InitSyncResponse struct {
 	Message           string `json:"message"`
 }
 
+// AccountSessionResponse reports whether an account has a session that a sync can reuse
+type AccountSessionResponse struct {
+	AccountID string `json:"account_id"`
+	// Reusable is true when a sync can run without a new 2FA code
+	Reusable  bool       `json:"reusable"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
 // CompleteSyncRequest represents the request to complete sync with 2FA code
 type CompleteSyncRequest struct {
 	ProcessID string `json:"process_id"`
@@ -33,21 +45,23 @@ type CompleteSyncRequest struct {
 
 // SyncAccountHandler triggers synchronization for an account
 // @Summary Synchroniser un compte
-// @Description Déclenche la synchronisation des transactions pour un compte (Binance, Bourse Direct)
+// @Description Déclenche la synchronisation des transactions pour un compte (Binance, Bourse Direct). Un compte Trade Republic est synchronisé avec la session de sa dernière authentification 2FA tant qu'elle est valide ; sinon la 2FA est requise.
 // @Tags sync
 // @Produce json
 // @Param id path string true "ID du compte"
-// @Success 200 {object} map[string]interface{}
+// @Success 200 {object} types.SyncResult
 // @Failure 400 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
+// @Failure 502 {object} ErrorResponse
 // @Router /api/accounts/{id}/sync [post]
 func (h *Handler) SyncAccountHandler(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	accountID := vars["id"]
 
 	if accountID == "" {
-		respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "Account ID is required", nil)
+		writeAPIError(w, ErrInvalidRequest.WithMessage("Account ID is required"), nil)
 		return
 	}
 
@@ -55,30 +69,73 @@ func (h *Handler) SyncAccountHandler(w http.ResponseWriter, r *http.Request) {
 	_, err := h.DB.GetAccountByID(accountID)
 	if err != nil {
 		if err == sql.ErrNoRows || (err != nil && strings.Contains(err.Error(), "no rows")) {
-			respondError(w, http.StatusNotFound, "NOT_FOUND", "Account not found", nil)
+			writeAPIError(w, ErrNotFound.WithMessage("Account not found"), nil)
 			return
 		}
-		respondError(w, http.StatusInternalServerError, "DATABASE_ERROR", "Failed to retrieve account", nil)
+		writeAPIError(w, ErrDatabase.WithMessage("Failed to retrieve account"), nil)
 		return
 	}
 
 	// Trigger synchronization
-	result, err := h.SyncService.SyncAccount(accountID)
+	result, err := h.SyncService.SyncAccountContext(r.Context(), accountID)
 	if err != nil {
-		// Return the result even if there was an error, as it contains useful information
-		if result != nil {
-			respondJSON(w, http.StatusOK, result)
-			return
-		}
-		respondError(w, http.StatusInternalServerError, "SYNC_ERROR", "Failed to synchronize account", map[string]string{
-			"error": err.Error(),
-		})
+		// The failed result is returned as details so that clients know where the sync stopped
+		writeAPIError(w, syncAPIError(err), result)
 		return
 	}
 
+	// Full and partial successes: the status and warnings tell them apart
 	respondJSON(w, http.StatusOK, result)
 }
 
+// SyncAllAccountsHandler synchronizes every account that can be synchronized without 2FA
+// @Summary Synchroniser tous les comptes
+// @Description Synchronise tous les comptes dont la plateforme ne nécessite pas de 2FA, quelques comptes à la fois. L'échec d'un compte n'interrompt pas les autres ; les comptes 2FA, ceux déjà en cours de synchronisation et ceux non atteints avant l'arrêt du serveur sont signalés comme ignorés (skipped).
+// @Tags sync
+// @Produce json
+// @Success 200 {object} sync.BulkSyncSummary
+// @Failure 500 {object} ErrorResponse
+// @Router /api/sync/all [post]
+func (h *Handler) SyncAllAccountsHandler(w http.ResponseWriter, r *http.Request) {
+	// The request context is canceled on shutdown (and when the client goes away)
+	summary, err := h.SyncService.SyncAccountsContext(r.Context(), sync.BulkSyncOptions{
+		Workers:  sync.BulkSyncWorkers,
+		Interval: sync.BulkSyncInterval,
+	})
+	if err != nil {
+		utils.Logf(r.Context(), "ERROR: Bulk sync failed: %v", err)
+		writeAPIError(w, ErrDatabase.WithMessage("Failed to retrieve accounts"), nil)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, summary)
+}
+
+// syncAPIError maps a hard synchronization failure to an API error
+func syncAPIError(err error) APIError {
+	if errors.Is(err, sync.ErrSyncInProgress) {
+		return ErrSyncInProgress
+	}
+	if errors.Is(err, sync.ErrUnsupportedPlatform) {
+		return ErrUnsupportedPlatform.WithMessage("No scraper is available for this platform")
+	}
+
+	var scraperErr *types.ScraperError
+	if errors.As(err, &scraperErr) {
+		switch scraperErr.Type {
+		case "auth":
+			return ErrInvalidCredentials.WithMessage("Authentication with the platform failed")
+		case "validation":
+			return ErrValidation.WithMessage(scraperErr.Message)
+		case "network":
+			return ErrPlatformUnavailable
+		}
+		return ErrScraper.WithMessage("Failed to fetch transactions")
+	}
+
+	return ErrSync.WithMessage("Failed to synchronize account")
+}
+
 // InitSyncHandler initiates synchronization for Trade Republic (triggers 2FA)
 // @Summary Initier la synchronisation Trade Republic
 // @Description Déclenche l'authentification 2FA pour Trade Republic
@@ -95,7 +152,7 @@ func (h *Handler) InitSyncHandler(w http.ResponseWriter, r *http.Request) {
 	accountID := vars["id"]
 
 	if accountID == "" {
-		respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "Account ID is required", nil)
+		writeAPIError(w, ErrInvalidRequest.WithMessage("Account ID is required"), nil)
 		return
 	}
 
@@ -103,43 +160,43 @@ func (h *Handler) InitSyncHandler(w http.ResponseWriter, r *http.Request) {
 	account, err := h.DB.GetAccountByID(accountID)
 	if err != nil {
 		if err == sql.ErrNoRows || (err != nil && strings.Contains(err.Error(), "no rows")) {
-			respondError(w, http.StatusNotFound, "NOT_FOUND", "Account not found", nil)
+			writeAPIError(w, ErrNotFound.WithMessage("Account not found"), nil)
 			return
 		}
-		respondError(w, http.StatusInternalServerError, "DATABASE_ERROR", "Failed to retrieve account", nil)
+		writeAPIError(w, ErrDatabase.WithMessage("Failed to retrieve account"), nil)
 		return
 	}
 
-	// Only Trade Republic requires 2FA init
-	if account.Platform != "traderepublic" {
-		respondError(w, http.StatusBadRequest, "INVALID_PLATFORM", "This endpoint is only for Trade Republic accounts", nil)
+	// Only platforms requiring 2FA are synchronized through this flow
+	if platform, ok := models.GetPlatform(account.Platform); !ok || !platform.RequiresTwoFactor {
+		writeAPIError(w, ErrInvalidPlatform.WithMessage("This endpoint is only for platforms requiring two-factor authentication"), nil)
 		return
 	}
 
 	// Decrypt credentials
-	credentialsJSON, err := h.Encryption.Decrypt(account.Credentials)
+	credentialsJSON, _, err := h.Encryption.DecryptWithFallback(account.Credentials)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "DECRYPTION_ERROR", "Failed to decrypt credentials", nil)
+		writeAPIError(w, ErrDecryption.WithMessage("Failed to decrypt credentials"), nil)
 		return
 	}
 
 	var credentials map[string]interface{}
 	if err := json.Unmarshal([]byte(credentialsJSON), &credentials); err != nil {
-		respondError(w, http.StatusInternalServerError, "PARSING_ERROR", "Failed to parse credentials", nil)
+		writeAPIError(w, ErrParsing.WithMessage("Failed to parse credentials"), nil)
 		return
 	}
 
 	// Get scraper
 	scraper := h.SyncService.GetScraper("traderepublic")
 	if scraper == nil {
-		respondError(w, http.StatusInternalServerError, "SCRAPER_ERROR", "Trade Republic scraper not available", nil)
+		writeAPIError(w, ErrScraper.WithMessage("Trade Republic scraper not available"), nil)
 		return
 	}
 
 	// Cast to Trade Republic scraper to access Authenticate2FA method
 	trScraper, ok := scraper.(*traderepublic.Scraper)
 	if !ok {
-		respondError(w, http.StatusInternalServerError, "SCRAPER_ERROR", "Invalid scraper type", nil)
+		writeAPIError(w, ErrScraper.WithMessage("Invalid scraper type"), nil)
 		return
 	}
 
@@ -169,13 +226,13 @@ func (h *Handler) InitSyncHandler(w http.ResponseWriter, r *http.Request) {
 
 		// If it's a login error, it means the credentials are wrong
 		if strings.Contains(errMsg, "Login failed") {
-			log.Printf("[SYNC] InitSync failed for account %s: %s", accountID, errMsg)
-			respondError(w, http.StatusBadRequest, "INVALID_CREDENTIALS", errMsg, nil)
+			utils.Logf(r.Context(), "[SYNC] InitSync failed for account %s: %s", accountID, utils.RedactText(errMsg))
+			writeAPIError(w, ErrInvalidCredentials.WithMessage(errMsg), nil)
 			return
 		}
 
-		log.Printf("[SYNC] InitSync failed for account %s: %s", accountID, authErr.Error())
-		respondError(w, http.StatusInternalServerError, "AUTH_ERROR", authErr.Error(), nil)
+		utils.Logf(r.Context(), "[SYNC] InitSync failed for account %s: %s", accountID, utils.RedactText(authErr.Error()))
+		writeAPIError(w, ErrAuth.WithMessage(authErr.Error()), nil)
 		return
 	}
 
@@ -197,6 +254,7 @@ func (h *Handler) InitSyncHandler(w http.ResponseWriter, r *http.Request) {
 // @Success 200 {object} map[string]interface{}
 // @Failure 400 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /api/accounts/{id}/sync/complete [post]
 func (h *Handler) CompleteSyncHandler(w http.ResponseWriter, r *http.Request) {
@@ -204,18 +262,18 @@ func (h *Handler) CompleteSyncHandler(w http.ResponseWriter, r *http.Request) {
 	accountID := vars["id"]
 
 	if accountID == "" {
-		respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "Account ID is required", nil)
+		writeAPIError(w, ErrInvalidRequest.WithMessage("Account ID is required"), nil)
 		return
 	}
 
 	var req CompleteSyncRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body", nil)
+		writeAPIError(w, ErrInvalidRequest.WithMessage("Invalid request body"), nil)
 		return
 	}
 
 	if req.ProcessID == "" || req.Code == "" {
-		respondError(w, http.StatusBadRequest, "VALIDATION_ERROR", "Process ID and code are required", nil)
+		writeAPIError(w, ErrValidation.WithMessage("Process ID and code are required"), nil)
 		return
 	}
 
@@ -223,97 +281,176 @@ func (h *Handler) CompleteSyncHandler(w http.ResponseWriter, r *http.Request) {
 	account, err := h.DB.GetAccountByID(accountID)
 	if err != nil {
 		if err == sql.ErrNoRows || (err != nil && strings.Contains(err.Error(), "no rows")) {
-			respondError(w, http.StatusNotFound, "NOT_FOUND", "Account not found", nil)
+			writeAPIError(w, ErrNotFound.WithMessage("Account not found"), nil)
 			return
 		}
-		respondError(w, http.StatusInternalServerError, "DATABASE_ERROR", "Failed to retrieve account", nil)
+		writeAPIError(w, ErrDatabase.WithMessage("Failed to retrieve account"), nil)
 		return
 	}
 
-	// Only Trade Republic requires 2FA
-	if account.Platform != "traderepublic" {
-		respondError(w, http.StatusBadRequest, "INVALID_PLATFORM", "This endpoint is only for Trade Republic accounts", nil)
+	// Only platforms requiring 2FA are synchronized through this flow
+	if platform, ok := models.GetPlatform(account.Platform); !ok || !platform.RequiresTwoFactor {
+		writeAPIError(w, ErrInvalidPlatform.WithMessage("This endpoint is only for platforms requiring two-factor authentication"), nil)
 		return
 	}
 
 	// Get scraper
 	scraper := h.SyncService.GetScraper("traderepublic")
 	if scraper == nil {
-		respondError(w, http.StatusInternalServerError, "SCRAPER_ERROR", "Trade Republic scraper not available", nil)
+		writeAPIError(w, ErrScraper.WithMessage("Trade Republic scraper not available"), nil)
 		return
 	}
 
 	// Cast to Trade Republic scraper
 	trScraper, ok := scraper.(*traderepublic.Scraper)
 	if !ok {
-		respondError(w, http.StatusInternalServerError, "SCRAPER_ERROR", "Invalid scraper type", nil)
+		writeAPIError(w, ErrScraper.WithMessage("Invalid scraper type"), nil)
+		return
+	}
+
+	// Only one synchronization per account at a time
+	release, err := h.SyncService.LockAccount(accountID)
+	if err != nil {
+		writeAPIError(w, ErrSyncInProgress, nil)
 		return
 	}
+	defer release()
 
 	// Complete 2FA authentication
-	log.Printf("INFO: Completing 2FA for account %s with process ID %s", accountID, req.ProcessID)
+	utils.Logf(r.Context(), "INFO: Completing 2FA for account %s with process ID %s", accountID, req.ProcessID)
 	sessionToken, err := trScraper.Authenticate2FA(req.ProcessID, req.Code)
 	if err != nil {
-		log.Printf("ERROR: 2FA verification failed for account %s: %v", accountID, err)
-		respondError(w, http.StatusBadRequest, "AUTH_ERROR", "Failed to verify code", map[string]string{
+		utils.Logf(r.Context(), "ERROR: 2FA verification failed for account %s: %s", accountID, utils.RedactText(err.Error()))
+		writeAPIError(w, ErrInvalidCode.WithMessage("Failed to verify code"), map[string]string{
 			"error": err.Error(),
 		})
 		return
 	}
 
 	if sessionToken == "" {
-		log.Printf("ERROR: Empty session token for account %s", accountID)
-		respondError(w, http.StatusInternalServerError, "AUTH_ERROR", "Failed to obtain session token", nil)
+		utils.Logf(r.Context(), "ERROR: Empty session token for account %s", accountID)
+		writeAPIError(w, ErrAuth.WithMessage("Failed to obtain session token"), nil)
 		return
 	}
+	h.saveAccountSession(r, account.ID, sessionToken)
 
-	log.Printf("INFO: Successfully authenticated, fetching transactions for account %s", accountID)
+	utils.Logf(r.Context(), "INFO: Successfully authenticated, fetching transactions for account %s", accountID)
 	// Now fetch transactions using the session token
 	// For Trade Republic, always fetch all transactions (don't use lastSync filter)
 	// because the WebSocket API returns all transactions anyway
 	transactions, err := trScraper.FetchTransactionsWithToken(sessionToken, nil)
 	if err != nil {
-		log.Printf("ERROR: Failed to fetch transactions for account %s: %v", accountID, err)
-		respondError(w, http.StatusInternalServerError, "SYNC_ERROR", "Failed to fetch transactions", map[string]string{
+		utils.Logf(r.Context(), "ERROR: Failed to fetch transactions for account %s: %s", accountID, utils.RedactText(err.Error()))
+		writeAPIError(w, ErrSync.WithMessage("Failed to fetch transactions"), map[string]string{
 			"error": err.Error(),
 		})
 		return
 	}
 
-	log.Printf("INFO: Fetched %d transactions for account %s", len(transactions), accountID)
+	utils.Logf(r.Context(), "INFO: Fetched %d transactions for account %s", len(transactions), accountID)
 
 	// Set account ID for all transactions
 	for i := range transactions {
 		transactions[i].AccountID = account.ID
 	}
 
-	// Store transactions in database
+	// Store transactions in database; a malformed event is reported instead of failing the sync
 	transactionsStored := 0
+	changes := &models.TransactionChanges{}
+	failures := []models.TransactionFailure{}
 	if len(transactions) > 0 {
-		if err := h.DB.CreateTransactionsBatch(transactions, account.Platform); err != nil {
-			respondError(w, http.StatusInternalServerError, "DATABASE_ERROR", "Failed to store transactions", map[string]string{
+		if changes, failures, err = h.DB.UpsertTransactionsBatchPartial(transactions, account.Platform); err != nil {
+			writeAPIError(w, ErrDatabase.WithMessage("Failed to store transactions"), map[string]string{
 				"error": err.Error(),
 			})
 			return
 		}
-		transactionsStored = len(transactions)
+		transactionsStored = len(transactions) - len(failures)
+		for _, failure := range failures {
+			utils.Logf(r.Context(), "WARNING: Transaction %s of account %s not stored: %s", failure.ID, accountID, utils.RedactText(failure.Error))
+		}
+	}
+
+	// The broker positions are the reference of the reconciliation; a failure must not fail the sync
+	positionsSynced := 0
+	if snapshot, err := h.storePortfolioSnapshot(r, trScraper, sessionToken, account.ID); err != nil {
+		utils.Logf(r.Context(), "WARNING: Failed to sync positions for account %s: %s", accountID, utils.RedactText(err.Error()))
+	} else {
+		positionsSynced = len(snapshot.Positions)
 	}
 
-	// Resolve symbols for assets with Yahoo Finance
-	log.Printf("INFO: Resolving symbols for assets...")
-	symbolsResolved := h.resolveAssetSymbols()
-	log.Printf("INFO: Resolved %d symbols", symbolsResolved)
+	// Resolve symbols for assets with Yahoo Finance in the background
+	symbolJob := h.resolveAssetSymbolsAsync(r.Context())
 
 	// Update last sync timestamp
 	now := time.Now()
 	if err := h.DB.UpdateAccountLastSync(account.ID, now); err != nil {
-		log.Printf("WARNING: Failed to update last sync timestamp for account %s: %v", account.ID, err)
+		utils.Logf(r.Context(), "WARNING: Failed to update last sync timestamp for account %s: %v", account.ID, err)
 	}
 
 	respondJSON(w, http.StatusOK, map[string]interface{}{
-		"success":            true,
-		"transactions_added": transactionsStored,
-		"symbols_resolved":   symbolsResolved,
-		"message":            fmt.Sprintf("Successfully synchronized %d transactions and resolved %d symbols", transactionsStored, symbolsResolved),
+		"success":               true,
+		"transactions_added":    transactionsStored,
+		"changes":               changes,
+		"failed_transactions":   failures,
+		"positions_synced":      positionsSynced,
+		"symbol_resolution_job": symbolJob.Status().ID,
+		"message":               fmt.Sprintf("Successfully synchronized %d transactions", transactionsStored),
 	})
 }
+
+// saveAccountSession keeps the session obtained by a 2FA authentication so that the next
+// synchronizations of the account can reuse it. A failure only costs a new 2FA.
+func (h *Handler) saveAccountSession(r *http.Request, accountID, sessionToken string) {
+	encrypted, err := h.Encryption.Encrypt(sessionToken)
+	if err != nil {
+		utils.Logf(r.Context(), "WARNING: Failed to encrypt the session of account %s: %v", accountID, err)
+		return
+	}
+
+	session := &models.AccountSession{
+		AccountID: accountID,
+		Token:     encrypted,
+		ExpiresAt: traderepublic.SessionExpiry(sessionToken, time.Now()).UTC(),
+	}
+	if err := h.DB.SaveAccountSession(r.Context(), session); err != nil {
+		utils.Logf(r.Context(), "WARNING: %v", err)
+	}
+}
+
+// GetAccountSessionHandler reports whether an account has a session that a sync can reuse
+// @Summary Session réutilisable d'un compte
+// @Description Indique si la session obtenue lors de la dernière authentification 2FA du compte est encore valide, auquel cas POST /api/accounts/{id}/sync la réutilise sans nouveau code
+// @Tags sync
+// @Produce json
+// @Param id path string true "ID du compte"
+// @Success 200 {object} AccountSessionResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/accounts/{id}/session [get]
+func (h *Handler) GetAccountSessionHandler(w http.ResponseWriter, r *http.Request) {
+	accountID := mux.Vars(r)["id"]
+
+	if _, err := h.DB.GetAccountByIDContext(r.Context(), accountID); err != nil {
+		if err == sql.ErrNoRows || strings.Contains(err.Error(), "no rows") {
+			writeAPIError(w, ErrNotFound.WithMessage("Account not found"), nil)
+			return
+		}
+		writeAPIError(w, ErrDatabase.WithMessage("Failed to retrieve account"), nil)
+		return
+	}
+
+	response := AccountSessionResponse{AccountID: accountID}
+	session, err := h.DB.GetAccountSession(r.Context(), accountID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		utils.Logf(r.Context(), "ERROR: Failed to get the session of account %s: %v", accountID, err)
+		writeAPIError(w, ErrDatabase.WithMessage("Failed to retrieve session"), nil)
+		return
+	}
+	if session != nil {
+		response.Reusable = session.ValidAt(time.Now())
+		response.ExpiresAt = &session.ExpiresAt
+	}
+
+	respondJSON(w, http.StatusOK, response)
+}
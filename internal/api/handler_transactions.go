@@ -1,12 +1,16 @@
 package api
 
 import (
+	"crypto/sha256"
 	"database/sql"
 	"encoding/csv"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
+	"math"
 	"net/http"
 	"sort"
 	"strconv"
@@ -25,9 +29,12 @@ type TransactionResponse struct {
 	Page         int                  `json:"page"`
 	Limit        int                  `json:"limit"`
 	TotalPages   int                  `json:"total_pages"`
+	// NextPage and PrevPage are null at the boundaries
+	NextPage *int `json:"next_page"`
+	PrevPage *int `json:"prev_page"`
 }
 
-// ImportSummary represents the result of a CSV import operation
+// ImportSummary represents the result of a CSV or JSON import operation
 type ImportSummary struct {
 	Imported int      `json:"imported"`
 	Ignored  int      `json:"ignored"`
@@ -37,18 +44,22 @@ type ImportSummary struct {
 
 // GetAccountTransactionsHandler retrieves transactions for a specific account with filters
 // @Summary Récupérer les transactions d'un compte
-// @Description Retourne les transactions paginées et filtrées d'un compte
+// @Description Retourne les transactions paginées et filtrées d'un compte, en CSV avec l'en-tête Accept: text/csv
 // @Tags transactions
 // @Produce json
+// @Produce text/csv
 // @Param id path string true "ID du compte"
 // @Param start_date query string false "Date de début (YYYY-MM-DD)"
 // @Param end_date query string false "Date de fin (YYYY-MM-DD)"
 // @Param asset query string false "Filtrer par ISIN"
 // @Param type query string false "Filtrer par type (buy, sell, dividend, fee)"
+// @Param tag query string false "Filtrer par étiquette (ex: rebalance)"
 // @Param page query int false "Numéro de page" default(1)
 // @Param limit query int false "Nombre de résultats par page" default(50)
 // @Param sort_by query string false "Trier par champ (timestamp, amount)"
 // @Param sort_order query string false "Ordre de tri (asc, desc)"
+// @Param include_deleted query bool false "Inclure les transactions supprimées" default(false)
+// @Param include_hidden query bool false "Inclure les transactions masquées" default(false)
 // @Success 200 {object} TransactionResponse
 // @Failure 400 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
@@ -59,23 +70,27 @@ func (h *Handler) GetAccountTransactionsHandler(w http.ResponseWriter, r *http.R
 	accountID := vars["id"]
 
 	if accountID == "" {
-		respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "Account ID is required", nil)
+		writeAPIError(w, ErrInvalidRequest.WithMessage("Account ID is required"), nil)
 		return
 	}
 
 	// Check if account exists and get platform
-	account, err := h.DB.GetAccountByID(accountID)
+	account, err := h.DB.GetAccountByIDContext(r.Context(), accountID)
 	if err != nil {
 		if err == sql.ErrNoRows || (err != nil && strings.Contains(err.Error(), "no rows")) {
-			respondError(w, http.StatusNotFound, "NOT_FOUND", "Account not found", nil)
+			writeAPIError(w, ErrNotFound.WithMessage("Account not found"), nil)
 			return
 		}
-		respondError(w, http.StatusInternalServerError, "DATABASE_ERROR", "Failed to retrieve account", nil)
+		writeAPIError(w, ErrDatabase.WithMessage("Failed to retrieve account"), nil)
 		return
 	}
 
 	// Parse query parameters
-	filter := h.parseTransactionFilters(r)
+	filter, err := h.parseTransactionFilters(r)
+	if err != nil {
+		writeFilterError(w, err)
+		return
+	}
 	filter.AccountID = accountID
 
 	// Get sort parameters
@@ -84,67 +99,68 @@ func (h *Handler) GetAccountTransactionsHandler(w http.ResponseWriter, r *http.R
 
 	// Validate sort parameters
 	if sortBy != "" && sortBy != "timestamp" && sortBy != "amount" {
-		respondError(w, http.StatusBadRequest, "INVALID_SORT", "sort_by must be 'timestamp' or 'amount'", nil)
+		writeAPIError(w, ErrInvalidSort.WithMessage("sort_by must be 'timestamp' or 'amount'"), nil)
 		return
 	}
 	if sortOrder != "" && sortOrder != "asc" && sortOrder != "desc" {
-		respondError(w, http.StatusBadRequest, "INVALID_SORT", "sort_order must be 'asc' or 'desc'", nil)
+		writeAPIError(w, ErrInvalidSort.WithMessage("sort_order must be 'asc' or 'desc'"), nil)
 		return
 	}
 
 	// Get transactions with filters
-	transactions, err := h.DB.GetTransactionsByAccountWithSort(accountID, account.Platform, filter, sortBy, sortOrder)
+	transactions, err := h.DB.GetTransactionsByAccountWithSortContext(r.Context(), accountID, account.Platform, filter, sortBy, sortOrder)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "DATABASE_ERROR", "Failed to retrieve transactions", map[string]string{
+		if writePlatformError(w, err, account.Platform) {
+			return
+		}
+		writeAPIError(w, ErrDatabase.WithMessage("Failed to retrieve transactions"), map[string]string{
 			"error": err.Error(),
 		})
 		return
 	}
 
 	// Get total count for pagination
-	total, err := h.DB.CountTransactions(account.Platform, filter)
+	total, err := h.DB.CountTransactionsContext(r.Context(), account.Platform, filter)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "DATABASE_ERROR", "Failed to count transactions", nil)
+		writeAPIError(w, ErrDatabase.WithMessage("Failed to count transactions"), nil)
 		return
 	}
 
-	// Calculate total pages
-	totalPages := 0
-	if filter.Limit > 0 {
-		totalPages = (total + filter.Limit - 1) / filter.Limit
-	}
-
-	response := TransactionResponse{
-		Transactions: transactions,
-		Total:        total,
-		Page:         filter.Page,
-		Limit:        filter.Limit,
-		TotalPages:   totalPages,
-	}
+	response := newTransactionResponse(w, r, transactions, total, filter)
 
-	respondJSON(w, http.StatusOK, response)
+	respondTransactions(w, r, response)
 }
 
 // GetAllTransactionsHandler retrieves all transactions across all accounts with filters
 // @Summary Récupérer toutes les transactions
-// @Description Retourne les transactions paginées de tous les comptes
+// @Description Retourne les transactions paginées de tous les comptes, en CSV avec l'en-tête Accept: text/csv
 // @Tags transactions
 // @Produce json
+// @Produce text/csv
 // @Param start_date query string false "Date de début (YYYY-MM-DD)"
 // @Param end_date query string false "Date de fin (YYYY-MM-DD)"
 // @Param asset query string false "Filtrer par ISIN"
 // @Param type query string false "Filtrer par type (buy, sell, dividend, fee)"
+// @Param tag query string false "Filtrer par étiquette (ex: rebalance)"
+// @Param account_ids query string false "Restreindre à une liste de comptes (IDs séparés par des virgules)"
 // @Param page query int false "Numéro de page" default(1)
 // @Param limit query int false "Nombre de résultats par page" default(50)
 // @Param sort_by query string false "Trier par champ (timestamp, amount)"
 // @Param sort_order query string false "Ordre de tri (asc, desc)"
+// @Param include_deleted query bool false "Inclure les transactions supprimées" default(false)
+// @Param include_hidden query bool false "Inclure les transactions masquées" default(false)
 // @Success 200 {object} TransactionResponse
 // @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /api/transactions [get]
 func (h *Handler) GetAllTransactionsHandler(w http.ResponseWriter, r *http.Request) {
 	// Parse query parameters
-	filter := h.parseTransactionFilters(r)
+	filter, err := h.parseTransactionFilters(r)
+	if err != nil {
+		writeFilterError(w, err)
+		return
+	}
 
 	// Get sort parameters
 	sortBy := r.URL.Query().Get("sort_by")
@@ -152,24 +168,35 @@ func (h *Handler) GetAllTransactionsHandler(w http.ResponseWriter, r *http.Reque
 
 	// Validate sort parameters
 	if sortBy != "" && sortBy != "timestamp" && sortBy != "amount" {
-		respondError(w, http.StatusBadRequest, "INVALID_SORT", "sort_by must be 'timestamp' or 'amount'", nil)
+		writeAPIError(w, ErrInvalidSort.WithMessage("sort_by must be 'timestamp' or 'amount'"), nil)
 		return
 	}
 	if sortOrder != "" && sortOrder != "asc" && sortOrder != "desc" {
-		respondError(w, http.StatusBadRequest, "INVALID_SORT", "sort_order must be 'asc' or 'desc'", nil)
+		writeAPIError(w, ErrInvalidSort.WithMessage("sort_order must be 'asc' or 'desc'"), nil)
 		return
 	}
 
 	// Get all accounts to query all platforms
-	accounts, err := h.DB.GetAllAccounts()
+	accounts, err := h.DB.GetAllAccountsContext(r.Context())
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "DATABASE_ERROR", "Failed to retrieve accounts", nil)
+		writeAPIError(w, ErrDatabase.WithMessage("Failed to retrieve accounts"), nil)
 		return
 	}
 
+	// Only query the platforms of the selected accounts
+	if len(filter.AccountIDs) > 0 {
+		selected, missing := selectAccounts(accounts, filter.AccountIDs)
+		if len(missing) > 0 {
+			writeAPIError(w, ErrNotFound.WithMessage("Account not found"), map[string]interface{}{
+				"account_ids": missing,
+			})
+			return
+		}
+		accounts = selected
+	}
+
 	// Collect transactions from all platforms
 	allTransactions := []models.Transaction{}
-	totalCount := 0
 
 	// Get unique platforms
 	platforms := make(map[string]bool)
@@ -179,70 +206,312 @@ func (h *Handler) GetAllTransactionsHandler(w http.ResponseWriter, r *http.Reque
 
 	// Query each platform
 	for platform := range platforms {
-		transactions, err := h.DB.GetAllTransactionsWithSort(platform, filter, sortBy, sortOrder)
+		transactions, err := h.DB.GetAllTransactionsWithSortContext(r.Context(), platform, filter, sortBy, sortOrder)
 		if err != nil {
 			// Log error but continue with other platforms
 			log.Printf("ERROR: Failed to get transactions for platform %s: %v", platform, err)
 			continue
 		}
-		log.Printf("DEBUG: Found %d transactions for platform %s", len(transactions), platform)
 		allTransactions = append(allTransactions, transactions...)
+	}
+
+	// Merge platforms in the same order as a single table query
+	h.sortTransactions(allTransactions, sortBy, sortOrder)
 
-		count, err := h.DB.CountTransactions(platform, filter)
-		if err == nil {
-			totalCount += count
+	// The merged rows are the single source of truth for the total, so that
+	// paging through every page returns exactly Total transactions
+	response := newTransactionResponse(w, r, paginateTransactions(allTransactions, filter), len(allTransactions), filter)
+
+	respondTransactions(w, r, response)
+}
+
+// Page sizes of the transactions stream
+const (
+	defaultStreamLimit = 100
+	maxStreamLimit     = 1000
+)
+
+// TransactionStreamResponse is a page of the transactions stream
+type TransactionStreamResponse struct {
+	Transactions []models.Transaction `json:"transactions"`
+	// NextCursor is passed as cursor to fetch the following changes; it is returned
+	// even when the page is empty so that clients can keep polling from it
+	NextCursor string `json:"next_cursor"`
+	// HasMore is true when more changes are available right away
+	HasMore bool `json:"has_more"`
+}
+
+// GetTransactionsStreamHandler returns the transactions inserted or modified after a cursor
+// @Summary Flux des transactions modifiées
+// @Description Renvoie, par ordre croissant de modification, les transactions créées ou modifiées après un curseur. Pensé pour le rafraîchissement incrémental des tableaux de bord, sans pagination par offset.
+// @Tags transactions
+// @Produce json
+// @Param since query string false "Date de départ (RFC3339 ou YYYY-MM-DD), ignorée si cursor est fourni"
+// @Param cursor query string false "Curseur next_cursor renvoyé par l'appel précédent"
+// @Param account_ids query string false "Restreindre à une liste de comptes (IDs séparés par des virgules)"
+// @Param limit query int false "Nombre maximal de transactions (max 1000)" default(100)
+// @Success 200 {object} TransactionStreamResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/transactions/stream [get]
+func (h *Handler) GetTransactionsStreamHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	var cursor database.TransactionCursor
+	if value := query.Get("cursor"); value != "" {
+		parsed, err := database.ParseTransactionCursor(value)
+		if err != nil {
+			writeAPIError(w, ErrValidation.WithMessage("cursor must be a next_cursor returned by the stream"), map[string]string{
+				"field": "cursor",
+			})
+			return
 		}
+		cursor = parsed
+	} else if since := query.Get("since"); since != "" {
+		parsed, err := time.Parse(time.RFC3339, since)
+		if err != nil {
+			parsed, err = time.Parse("2006-01-02", since)
+		}
+		if err != nil {
+			writeAPIError(w, ErrInvalidDate.WithMessage("since must be an RFC3339 timestamp or a YYYY-MM-DD date"), map[string]string{
+				"field": "since",
+			})
+			return
+		}
+		cursor.Since = parsed
 	}
 
-	// Sort combined results if needed
-	if sortBy != "" {
-		h.sortTransactions(allTransactions, sortBy, sortOrder)
+	limit := defaultStreamLimit
+	if value := query.Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			writeAPIError(w, ErrValidation.WithMessage("limit must be a positive integer"), map[string]string{
+				"field": "limit",
+			})
+			return
+		}
+		limit = min(parsed, maxStreamLimit)
 	}
 
-	// Apply pagination to combined results
-	start := 0
-	end := len(allTransactions)
-	if filter.Limit > 0 && filter.Page > 0 {
-		start = (filter.Page - 1) * filter.Limit
-		end = start + filter.Limit
-		if start > len(allTransactions) {
-			start = len(allTransactions)
-		}
-		if end > len(allTransactions) {
-			end = len(allTransactions)
-		}
+	// Fetch one extra row to know whether more changes are waiting
+	transactions, err := h.DB.GetTransactionsChangedSince(r.Context(), cursor, parseAccountIDs(query.Get("account_ids")), limit+1)
+	if err != nil {
+		writeAPIError(w, ErrDatabase.WithMessage("Failed to retrieve transactions"), nil)
+		return
+	}
+
+	response := TransactionStreamResponse{Transactions: transactions}
+	if len(transactions) > limit {
+		response.Transactions = transactions[:limit]
+		response.HasMore = true
+	}
+	if len(response.Transactions) > 0 {
+		last := response.Transactions[len(response.Transactions)-1]
+		cursor = database.TransactionCursor{ChangeXID: last.ChangeXID, ID: last.ID, Since: cursor.Since}
+	}
+	response.NextCursor = cursor.Encode()
+	if response.Transactions == nil {
+		response.Transactions = []models.Transaction{}
 	}
 
-	paginatedTransactions := allTransactions[start:end]
+	respondJSON(w, http.StatusOK, response)
+}
 
+// newTransactionResponse builds the paginated response and sets the RFC 5988 Link header
+func newTransactionResponse(w http.ResponseWriter, r *http.Request, transactions []models.Transaction, total int, filter database.TransactionFilter) TransactionResponse {
 	// Calculate total pages
 	totalPages := 0
 	if filter.Limit > 0 {
-		totalPages = (totalCount + filter.Limit - 1) / filter.Limit
+		totalPages = (total + filter.Limit - 1) / filter.Limit
 	}
 
 	response := TransactionResponse{
-		Transactions: paginatedTransactions,
-		Total:        totalCount,
+		Transactions: transactions,
+		Total:        total,
 		Page:         filter.Page,
 		Limit:        filter.Limit,
 		TotalPages:   totalPages,
 	}
 
+	if filter.Page > 1 {
+		prev := filter.Page - 1
+		if prev > totalPages && totalPages > 0 {
+			// Out of range page: point back to the last existing page
+			prev = totalPages
+		}
+		response.PrevPage = &prev
+	}
+	if filter.Page < totalPages {
+		next := filter.Page + 1
+		response.NextPage = &next
+	}
+
+	if link := paginationLinkHeader(r, response); link != "" {
+		w.Header().Set("Link", link)
+	}
+
+	return response
+}
+
+// respondTransactions sends a page of transactions as CSV when the Accept header asks for it,
+// as JSON otherwise. The pagination is reported by the Link and X-Total-Count headers in CSV.
+func respondTransactions(w http.ResponseWriter, r *http.Request, response TransactionResponse) {
+	w.Header().Add("Vary", "Accept")
+	if wantsCSV(r) {
+		w.Header().Set("X-Total-Count", strconv.Itoa(response.Total))
+		respondTransactionsCSV(w, response.Transactions)
+		return
+	}
 	respondJSON(w, http.StatusOK, response)
 }
 
+// paginationLinkHeader builds a Link header with first, prev, next and last relations
+// Links keep the current query parameters and only change the page
+func paginationLinkHeader(r *http.Request, response TransactionResponse) string {
+	if response.TotalPages == 0 {
+		return ""
+	}
+
+	pageURL := func(page int) string {
+		query := r.URL.Query()
+		query.Set("page", strconv.Itoa(page))
+		query.Set("limit", strconv.Itoa(response.Limit))
+		return r.URL.Path + "?" + query.Encode()
+	}
+
+	links := []string{fmt.Sprintf(`<%s>; rel="first"`, pageURL(1))}
+	if response.PrevPage != nil {
+		links = append(links, fmt.Sprintf(`<%s>; rel="prev"`, pageURL(*response.PrevPage)))
+	}
+	if response.NextPage != nil {
+		links = append(links, fmt.Sprintf(`<%s>; rel="next"`, pageURL(*response.NextPage)))
+	}
+	links = append(links, fmt.Sprintf(`<%s>; rel="last"`, pageURL(response.TotalPages)))
+
+	return strings.Join(links, ", ")
+}
+
+// Values of the fill parameter of GetAccountMonthlyTransactionsHandler
+const (
+	monthlyFillNone = "none"
+	monthlyFillZero = "zero"
+)
+
+// MonthlyTransactionsResponse lists the transactions of an account grouped by month
+type MonthlyTransactionsResponse struct {
+	AccountID string                         `json:"account_id"`
+	Fill      string                         `json:"fill"`
+	Months    []database.MonthlyTransactions `json:"months"`
+}
+
+// GetAccountMonthlyTransactionsHandler groups the transactions of an account by month
+// @Summary Transactions d'un compte par mois
+// @Description Retourne, pour chaque mois (fuseau TIMEZONE), le nombre de transactions et le flux de trésorerie net (dépôts - retraits - achats + ventes). Avec fill=zero, les mois sans activité sont retournés à zéro ; avec fill=none (défaut), ils sont absents
+// @Tags transactions
+// @Produce json
+// @Param id path string true "ID du compte"
+// @Param start_date query string false "Date de début (YYYY-MM-DD)"
+// @Param end_date query string false "Date de fin (YYYY-MM-DD)"
+// @Param asset query string false "Filtrer par ISIN"
+// @Param type query string false "Filtrer par type (buy, sell, dividend, fee)"
+// @Param tag query string false "Filtrer par étiquette (ex: rebalance)"
+// @Param fill query string false "Mois sans activité : none (absents) ou zero" default(none)
+// @Param include_deleted query bool false "Inclure les transactions supprimées" default(false)
+// @Param include_hidden query bool false "Inclure les transactions masquées" default(false)
+// @Success 200 {object} MonthlyTransactionsResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/accounts/{id}/transactions/monthly [get]
+func (h *Handler) GetAccountMonthlyTransactionsHandler(w http.ResponseWriter, r *http.Request) {
+	accountID := mux.Vars(r)["id"]
+
+	fill := r.URL.Query().Get("fill")
+	if fill == "" {
+		fill = monthlyFillNone
+	}
+	if fill != monthlyFillNone && fill != monthlyFillZero {
+		writeAPIError(w, ErrValidation.WithMessage("fill must be 'none' or 'zero'"), map[string]string{
+			"field": "fill",
+		})
+		return
+	}
+
+	filter, err := h.parseTransactionFilters(r)
+	if err != nil {
+		writeFilterError(w, err)
+		return
+	}
+	filter.AccountID = accountID
+
+	account, err := h.DB.GetAccountByIDContext(r.Context(), accountID)
+	if err != nil {
+		if err == sql.ErrNoRows || strings.Contains(err.Error(), "no rows") {
+			writeAPIError(w, ErrNotFound.WithMessage("Account not found"), nil)
+			return
+		}
+		writeAPIError(w, ErrDatabase.WithMessage("Failed to retrieve account"), nil)
+		return
+	}
+
+	months, err := h.DB.GetMonthlyTransactions(r.Context(), account.Platform, filter)
+	if err != nil {
+		if writePlatformError(w, err, account.Platform) {
+			return
+		}
+		writeAPIError(w, ErrDatabase.WithMessage("Failed to group transactions by month"), nil)
+		return
+	}
+
+	if fill == monthlyFillZero {
+		// The requested period is covered even where it has no transactions
+		from, to := monthlyFillBounds(filter)
+		if months, err = database.FillMonthGaps(months, from, to); err != nil {
+			writeAPIError(w, ErrDatabase.WithMessage("Failed to group transactions by month"), nil)
+			return
+		}
+	}
+	if months == nil {
+		months = []database.MonthlyTransactions{}
+	}
+
+	respondJSON(w, http.StatusOK, MonthlyTransactionsResponse{
+		AccountID: accountID,
+		Fill:      fill,
+		Months:    months,
+	})
+}
+
+// monthlyFillBounds returns the normalized date bounds of the filter in the filter time zone,
+// zero when not set
+func monthlyFillBounds(filter database.TransactionFilter) (time.Time, time.Time) {
+	var bounds [2]time.Time
+	for i, value := range []string{filter.StartDate, filter.EndDate} {
+		if parsed, err := time.Parse(time.RFC3339, value); err == nil {
+			bounds[i] = parsed.In(database.FilterLocation())
+		}
+	}
+	return bounds[0], bounds[1]
+}
+
 // parseTransactionFilters parses query parameters into a TransactionFilter
-func (h *Handler) parseTransactionFilters(r *http.Request) database.TransactionFilter {
+// Date filters are validated and normalized to inclusive RFC3339 bounds
+func (h *Handler) parseTransactionFilters(r *http.Request) (database.TransactionFilter, error) {
 	filter := database.TransactionFilter{
 		StartDate:       r.URL.Query().Get("start_date"),
 		EndDate:         r.URL.Query().Get("end_date"),
 		ISIN:            r.URL.Query().Get("asset"),
-		TransactionType: r.URL.Query().Get("type"),
+		TransactionType: strings.ToLower(strings.TrimSpace(r.URL.Query().Get("type"))),
+		Tag:             strings.ToLower(strings.TrimSpace(r.URL.Query().Get("tag"))),
+		IncludeDeleted:  r.URL.Query().Get("include_deleted") == "true",
+		IncludeHidden:   includeHidden(r),
 		Page:            1,
 		Limit:           50, // Default limit
 	}
 
+	// Parse the account selection (comma-separated IDs)
+	filter.AccountIDs = parseAccountIDs(r.URL.Query().Get("account_ids"))
+
 	// Parse page
 	if pageStr := r.URL.Query().Get("page"); pageStr != "" {
 		if page, err := strconv.Atoi(pageStr); err == nil && page > 0 {
@@ -257,41 +526,178 @@ func (h *Handler) parseTransactionFilters(r *http.Request) database.TransactionF
 		}
 	}
 
-	return filter
+	if filter.TransactionType != "" {
+		if err := models.ValidateTransactionType(filter.TransactionType); err != nil {
+			return filter, err
+		}
+	}
+
+	startDate, endDate, err := filter.DateBounds()
+	if err != nil {
+		return filter, err
+	}
+	filter.StartDate = startDate
+	filter.EndDate = endDate
+
+	return filter, nil
 }
 
-// sortTransactions sorts a slice of transactions
-func (h *Handler) sortTransactions(transactions []models.Transaction, sortBy, sortOrder string) {
-	if sortBy == "" {
+// parseAccountIDs parses a comma-separated list of account IDs, dropping blanks and duplicates
+func parseAccountIDs(value string) []string {
+	var accountIDs []string
+	seen := make(map[string]bool)
+	for _, accountID := range strings.Split(value, ",") {
+		accountID = strings.TrimSpace(accountID)
+		if accountID != "" && !seen[accountID] {
+			seen[accountID] = true
+			accountIDs = append(accountIDs, accountID)
+		}
+	}
+	return accountIDs
+}
+
+// includeHidden reports whether the request sets include_hidden=true, to keep hidden transactions
+// in lists and in the performance, fees and positions calculations
+func includeHidden(r *http.Request) bool {
+	return r.URL.Query().Get("include_hidden") == "true"
+}
+
+// writeFilterError responds to an invalid list filter: an unknown type or invalid dates
+func writeFilterError(w http.ResponseWriter, err error) {
+	if errors.Is(err, models.ErrUnknownTransactionType) {
+		writeAPIError(w, ErrValidation.WithMessage(err.Error()), map[string]string{
+			"field": "type",
+		})
 		return
 	}
+	writeAPIError(w, ErrInvalidDate.WithMessage(err.Error()), nil)
+}
 
-	sort.Slice(transactions, func(i, j int) bool {
-		var less bool
-		switch sortBy {
-		case "timestamp":
-			less = transactions[i].Timestamp < transactions[j].Timestamp
-		case "amount":
-			less = transactions[i].AmountValue < transactions[j].AmountValue
-		default:
-			return false
+// selectAccounts returns the accounts whose ID is in ids, and the IDs matching no account
+func selectAccounts(accounts []models.Account, ids []string) ([]models.Account, []string) {
+	byID := make(map[string]models.Account, len(accounts))
+	for _, account := range accounts {
+		byID[account.ID] = account
+	}
+
+	selected := make([]models.Account, 0, len(ids))
+	missing := []string{}
+	for _, id := range ids {
+		account, ok := byID[id]
+		if !ok {
+			missing = append(missing, id)
+			continue
 		}
+		selected = append(selected, account)
+	}
+	return selected, missing
+}
 
-		if sortOrder == "desc" {
-			return !less
+// sortTransactions sorts a slice of transactions like the database listing queries:
+// by timestamp (default) or amount, descending unless sortOrder is "asc", ties broken by ID
+func (h *Handler) sortTransactions(transactions []models.Transaction, sortBy, sortOrder string) {
+	ascending := sortOrder == "asc"
+
+	sort.SliceStable(transactions, func(i, j int) bool {
+		a, b := transactions[i], transactions[j]
+		if sortBy == "amount" {
+			if a.AmountValue != b.AmountValue {
+				return (a.AmountValue < b.AmountValue) == ascending
+			}
+		} else if a.Timestamp != b.Timestamp {
+			return (a.Timestamp < b.Timestamp) == (ascending && sortBy == "timestamp")
 		}
-		return less
+		return a.ID < b.ID
 	})
 }
 
+// paginateTransactions returns the requested page of already sorted transactions
+func paginateTransactions(transactions []models.Transaction, filter database.TransactionFilter) []models.Transaction {
+	if filter.Limit <= 0 || filter.Page <= 0 {
+		return transactions
+	}
+
+	start := (filter.Page - 1) * filter.Limit
+	if start > len(transactions) {
+		start = len(transactions)
+	}
+	end := start + filter.Limit
+	if end > len(transactions) {
+		end = len(transactions)
+	}
+
+	return transactions[start:end]
+}
+
+// GetTransactionHandler retrieves a single transaction by ID
+// @Summary Récupérer une transaction par ID
+// @Description Retourne une transaction. Si account_id est fourni, la plateforme du compte est utilisée, sinon toutes les plateformes sont parcourues
+// @Tags transactions
+// @Produce json
+// @Param id path string true "ID de la transaction"
+// @Param account_id query string false "ID du compte"
+// @Success 200 {object} models.Transaction
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/transactions/{id} [get]
+func (h *Handler) GetTransactionHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	transactionID := vars["id"]
+
+	if transactionID == "" {
+		writeAPIError(w, ErrInvalidRequest.WithMessage("Transaction ID is required"), nil)
+		return
+	}
+
+	var transaction *models.Transaction
+	var err error
+
+	if accountID := r.URL.Query().Get("account_id"); accountID != "" {
+		// Resolve platform from the account
+		account, accErr := h.DB.GetAccountByIDContext(r.Context(), accountID)
+		if accErr != nil {
+			if accErr == sql.ErrNoRows || strings.Contains(accErr.Error(), "no rows") {
+				writeAPIError(w, ErrNotFound.WithMessage("Account not found"), nil)
+				return
+			}
+			writeAPIError(w, ErrDatabase.WithMessage("Failed to retrieve account"), nil)
+			return
+		}
+
+		transaction, err = h.DB.GetTransactionByIDContext(r.Context(), transactionID, account.Platform)
+		if writePlatformError(w, err, account.Platform) {
+			return
+		}
+		if err == nil && transaction.AccountID != accountID {
+			writeAPIError(w, ErrNotFound.WithMessage("Transaction not found"), nil)
+			return
+		}
+	} else {
+		// Search across all platform tables
+		transaction, _, err = h.DB.FindTransactionByIDContext(r.Context(), transactionID)
+	}
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeAPIError(w, ErrNotFound.WithMessage("Transaction not found"), nil)
+			return
+		}
+		writeAPIError(w, ErrDatabase.WithMessage("Failed to retrieve transaction"), nil)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, transaction)
+}
+
 // UpdateTransactionHandler updates an existing transaction
 // @Summary Modifier une transaction
-// @Description Met à jour une transaction existante
+// @Description Met à jour les champs fournis d'une transaction ; les champs absents sont conservés. Si account_id est fourni, la plateforme du compte est utilisée, sinon toutes les plateformes sont parcourues
 // @Tags transactions
 // @Accept json
 // @Produce json
 // @Param id path string true "ID de la transaction"
-// @Param transaction body models.Transaction true "Données de la transaction"
+// @Param transaction body models.TransactionUpdate true "Champs à modifier"
 // @Success 200 {object} models.Transaction
 // @Failure 400 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
@@ -302,34 +708,90 @@ func (h *Handler) UpdateTransactionHandler(w http.ResponseWriter, r *http.Reques
 	transactionID := vars["id"]
 
 	if transactionID == "" {
-		respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "Transaction ID is required", nil)
+		writeAPIError(w, ErrInvalidRequest.WithMessage("Transaction ID is required"), nil)
 		return
 	}
 
-	// Parse request body
-	var transaction models.Transaction
-	if err := json.NewDecoder(r.Body).Decode(&transaction); err != nil {
-		respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body", nil)
+	// Parse request body: omitted fields are left unchanged
+	var update models.TransactionUpdate
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		writeAPIError(w, ErrInvalidRequest.WithMessage("Invalid request body"), nil)
 		return
 	}
 
-	// Set the ID from URL
-	transaction.ID = transactionID
+	if update.TransactionType != nil && *update.TransactionType != "" {
+		if err := models.ValidateTransactionType(*update.TransactionType); err != nil {
+			writeAPIError(w, ErrValidation.WithMessage(err.Error()), map[string]string{
+				"field": "transaction_type",
+			})
+			return
+		}
+	}
 
-	// Get account to determine platform
-	account, err := h.DB.GetAccountByID(transaction.AccountID)
-	if err != nil {
-		respondError(w, http.StatusNotFound, "NOT_FOUND", "Account not found", nil)
-		return
+	if update.AmountCurrency != nil {
+		if err := models.ValidateCurrencyCode(*update.AmountCurrency); err != nil {
+			writeAPIError(w, ErrValidation.WithMessage(err.Error()), map[string]string{
+				"field": "amount_currency",
+			})
+			return
+		}
+	}
+
+	if update.Tags != nil {
+		if err := models.ValidateTags(models.NormalizeTags(*update.Tags)); err != nil {
+			writeAPIError(w, ErrValidation.WithMessage(err.Error()), map[string]string{
+				"field": "tags",
+			})
+			return
+		}
+	}
+
+	if update.Note != nil {
+		if err := models.ValidateNote(*update.Note); err != nil {
+			writeAPIError(w, ErrValidation.WithMessage(err.Error()), map[string]string{
+				"field": "note",
+			})
+			return
+		}
+	}
+
+	// Resolve the platform from the account, or from the transaction itself
+	var platform string
+	if update.AccountID != "" {
+		account, err := h.DB.GetAccountByIDContext(r.Context(), update.AccountID)
+		if err != nil {
+			writeAPIError(w, ErrNotFound.WithMessage("Account not found"), nil)
+			return
+		}
+		platform = account.Platform
+	} else {
+		_, found, err := h.DB.FindTransactionByIDContext(r.Context(), transactionID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				writeAPIError(w, ErrNotFound.WithMessage("Transaction not found"), nil)
+				return
+			}
+			writeAPIError(w, ErrDatabase.WithMessage("Failed to retrieve transaction"), nil)
+			return
+		}
+		platform = found
 	}
 
 	// Update transaction
-	if err := h.DB.UpdateTransaction(&transaction, account.Platform); err != nil {
+	transaction, err := h.DB.UpdateTransaction(r.Context(), transactionID, platform, update)
+	if err != nil {
+		if writePlatformError(w, err, platform) {
+			return
+		}
 		if strings.Contains(err.Error(), "not found") {
-			respondError(w, http.StatusNotFound, "NOT_FOUND", "Transaction not found", nil)
+			writeAPIError(w, ErrNotFound.WithMessage("Transaction not found"), nil)
 			return
 		}
-		respondError(w, http.StatusInternalServerError, "DATABASE_ERROR", "Failed to update transaction", map[string]string{
+		if strings.Contains(err.Error(), "validation failed") {
+			writeAPIError(w, ErrValidation.WithMessage(err.Error()), nil)
+			return
+		}
+		writeAPIError(w, ErrDatabase.WithMessage("Failed to update transaction"), map[string]string{
 			"error": err.Error(),
 		})
 		return
@@ -338,6 +800,94 @@ func (h *Handler) UpdateTransactionHandler(w http.ResponseWriter, r *http.Reques
 	respondJSON(w, http.StatusOK, transaction)
 }
 
+// maxMovedTransactions bounds the transactions moved by one request
+const maxMovedTransactions = 10000
+
+// MoveTransactionsRequest represents the request body for moving transactions to another account
+type MoveTransactionsRequest struct {
+	TransactionIDs []string `json:"transaction_ids"`
+	FromAccountID  string   `json:"from_account_id"`
+	ToAccountID    string   `json:"to_account_id"`
+}
+
+// MoveTransactionsHandler moves transactions created under the wrong account
+// @Summary Déplacer des transactions vers un autre compte
+// @Description Rattache des transactions du compte source au compte cible, dans une seule transaction. Entre deux plateformes, les transactions sont validées à nouveau et copiées dans la table de la plateforme cible ; les plateformes doivent identifier les actifs de la même façon (ISIN ou symbole)
+// @Tags transactions
+// @Accept json
+// @Produce json
+// @Param body body MoveTransactionsRequest true "Transactions, compte source et compte cible"
+// @Success 200 {object} database.TransactionMoveResult
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/transactions/move [post]
+func (h *Handler) MoveTransactionsHandler(w http.ResponseWriter, r *http.Request) {
+	var req MoveTransactionsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeAPIError(w, ErrInvalidRequest.WithMessage("Invalid request body"), nil)
+		return
+	}
+
+	fromAccountID := strings.TrimSpace(req.FromAccountID)
+	toAccountID := strings.TrimSpace(req.ToAccountID)
+	if fromAccountID == "" || toAccountID == "" {
+		writeAPIError(w, ErrValidation.WithMessage("from_account_id and to_account_id are required"), nil)
+		return
+	}
+	if fromAccountID == toAccountID {
+		writeAPIError(w, ErrValidation.WithMessage("from_account_id and to_account_id must be different"), nil)
+		return
+	}
+
+	// Repeated IDs are moved once
+	var ids []string
+	seen := make(map[string]bool)
+	for _, id := range req.TransactionIDs {
+		id = strings.TrimSpace(id)
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+		ids = append(ids, id)
+	}
+	if len(ids) == 0 {
+		writeAPIError(w, ErrValidation.WithMessage("at least one transaction ID is required"), map[string]string{
+			"field": "transaction_ids",
+		})
+		return
+	}
+	if len(ids) > maxMovedTransactions {
+		writeAPIError(w, ErrValidation.WithMessage(fmt.Sprintf("at most %d transactions can be moved at once", maxMovedTransactions)), map[string]string{
+			"field": "transaction_ids",
+		})
+		return
+	}
+
+	result, err := h.DB.MoveTransactions(r.Context(), ids, fromAccountID, toAccountID)
+	if err != nil {
+		switch {
+		case errors.Is(err, database.ErrMoveAccountNotFound):
+			writeAPIError(w, ErrNotFound.WithMessage("Account not found"), map[string]string{"error": err.Error()})
+		case errors.Is(err, database.ErrMoveTransactionNotFound):
+			writeAPIError(w, ErrNotFound.WithMessage("Transaction not found in the source account"), map[string]string{"error": err.Error()})
+		case errors.Is(err, database.ErrMoveIncompatiblePlatforms):
+			writeAPIError(w, ErrValidation.WithMessage(err.Error()), nil)
+		case errors.Is(err, database.ErrMoveConflict):
+			writeAPIError(w, ErrMoveConflict.WithMessage(err.Error()), nil)
+		default:
+			log.Printf("ERROR: Failed to move transactions from %s to %s: %v", fromAccountID, toAccountID, err)
+			writeAPIError(w, ErrDatabase.WithMessage("Failed to move transactions"), nil)
+		}
+		return
+	}
+
+	log.Printf("INFO: Moved %d transactions from account %s (%s) to %s (%s)",
+		result.Moved, fromAccountID, result.FromPlatform, toAccountID, result.ToPlatform)
+	respondJSON(w, http.StatusOK, result)
+}
+
 // ImportCSVHandler imports transactions from a CSV file
 // @Summary Importer des transactions depuis un CSV
 // @Description Importe des transactions à partir d'un fichier CSV avec déduplication
@@ -346,22 +896,36 @@ func (h *Handler) UpdateTransactionHandler(w http.ResponseWriter, r *http.Reques
 // @Produce json
 // @Param account_id formData string true "ID du compte"
 // @Param file formData file true "Fichier CSV"
+// @Param reject_future formData bool false "Rejeter les transactions datées dans le futur"
+// @Param future_tolerance formData string false "Tolérance pour les dates futures (défaut: 24h)"
+// @Param aggregate_fills formData bool false "Fusionner les exécutions partielles d'un même ordre"
+// @Param fill_window formData string false "Écart maximal entre exécutions partielles (défaut: 5s)"
+// @Param profile query string false "Nom du profil d'import à appliquer (colonnes, format de date, séparateur décimal)"
 // @Success 200 {object} ImportSummary
 // @Failure 400 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
+// @Failure 413 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /api/transactions/import [post]
 func (h *Handler) ImportCSVHandler(w http.ResponseWriter, r *http.Request) {
-	// Parse multipart form (max 10MB)
-	if err := r.ParseMultipartForm(10 << 20); err != nil {
-		respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "Failed to parse form data", nil)
+	maxSize, maxRows := CSVImportLimits()
+
+	// The body is bounded before anything is read, the file itself is checked below
+	r.Body = http.MaxBytesReader(w, r.Body, maxSize+csvImportFormOverhead)
+	if err := r.ParseMultipartForm(maxSize); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeCSVTooLarge(w, maxSize)
+			return
+		}
+		writeAPIError(w, ErrInvalidRequest.WithMessage("Failed to parse form data"), nil)
 		return
 	}
 
 	// Get account_id from form
 	accountID := r.FormValue("account_id")
 	if accountID == "" {
-		respondError(w, http.StatusBadRequest, "VALIDATION_ERROR", "account_id is required", map[string]string{
+		writeAPIError(w, ErrValidation.WithMessage("account_id is required"), map[string]string{
 			"field": "account_id",
 		})
 		return
@@ -371,17 +935,20 @@ func (h *Handler) ImportCSVHandler(w http.ResponseWriter, r *http.Request) {
 	account, err := h.DB.GetAccountByID(accountID)
 	if err != nil {
 		if err == sql.ErrNoRows || (err != nil && strings.Contains(err.Error(), "no rows")) {
-			respondError(w, http.StatusNotFound, "NOT_FOUND", "Account not found", nil)
+			writeAPIError(w, ErrNotFound.WithMessage("Account not found"), nil)
 			return
 		}
-		respondError(w, http.StatusInternalServerError, "DATABASE_ERROR", "Failed to retrieve account", nil)
+		writeAPIError(w, ErrDatabase.WithMessage("Failed to retrieve account"), nil)
+		return
+	}
+	if writePlatformError(w, database.ValidateTransactionPlatform(account.Platform), account.Platform) {
 		return
 	}
 
 	// Get the file from form
 	file, header, err := r.FormFile("file")
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "VALIDATION_ERROR", "CSV file is required", map[string]string{
+		writeAPIError(w, ErrValidation.WithMessage("CSV file is required"), map[string]string{
 			"field": "file",
 		})
 		return
@@ -390,18 +957,53 @@ func (h *Handler) ImportCSVHandler(w http.ResponseWriter, r *http.Request) {
 
 	// Validate file extension
 	if !strings.HasSuffix(strings.ToLower(header.Filename), ".csv") {
-		respondError(w, http.StatusBadRequest, "INVALID_FILE", "File must be a CSV file", map[string]string{
+		writeAPIError(w, ErrInvalidFile.WithMessage("File must be a CSV file"), map[string]string{
 			"filename": header.Filename,
 		})
 		return
 	}
+	if header.Size > maxSize {
+		writeCSVTooLarge(w, maxSize)
+		return
+	}
+
+	opts, err := parseCSVImportOptions(r)
+	if err != nil {
+		writeAPIError(w, ErrValidation.WithMessage(err.Error()), nil)
+		return
+	}
+
+	if profileName := strings.TrimSpace(r.FormValue("profile")); profileName != "" {
+		profile, err := h.DB.GetImportProfileByName(profileName)
+		if err != nil {
+			if err == sql.ErrNoRows || strings.Contains(err.Error(), "no rows") {
+				writeAPIError(w, ErrNotFound.WithMessage("Import profile not found"), map[string]string{
+					"profile": profileName,
+				})
+				return
+			}
+			writeAPIError(w, ErrDatabase.WithMessage("Failed to retrieve import profile"), nil)
+			return
+		}
+		opts.Profile = profile
+	}
+	opts.Currency = account.CurrencyOrDefault()
+	opts.DecimalStyle = account.DecimalStyleOrDefault()
+	opts.MaxRows = maxRows
 
 	// Parse CSV
-	transactions, errors := h.parseCSV(file, accountID)
+	transactions, errors, err := h.parseCSVWithOptions(file, accountID, opts)
+	if err == errCSVTooManyRows {
+		writeAPIError(w, ErrValidation.WithMessage(fmt.Sprintf("CSV file has more than %d rows", maxRows)), map[string]interface{}{
+			"field":    "file",
+			"max_rows": maxRows,
+		})
+		return
+	}
 
 	// If there are critical parsing errors and no transactions, reject the import
 	if len(transactions) == 0 && len(errors) > 0 {
-		respondError(w, http.StatusBadRequest, "CSV_PARSE_ERROR", "Failed to parse CSV file", map[string]interface{}{
+		writeAPIError(w, ErrCSVParse.WithMessage("Failed to parse CSV file"), map[string]interface{}{
 			"errors": errors,
 		})
 		return
@@ -413,16 +1015,7 @@ func (h *Handler) ImportCSVHandler(w http.ResponseWriter, r *http.Request) {
 	importErrors := []string{}
 
 	// Get existing transaction IDs to detect duplicates
-	existingIDs := make(map[string]bool)
-	existingTransactions, err := h.DB.GetTransactionsByAccount(accountID, account.Platform, database.TransactionFilter{
-		AccountID: accountID,
-		Limit:     10000, // Get all existing transactions
-	})
-	if err == nil {
-		for _, t := range existingTransactions {
-			existingIDs[t.ID] = true
-		}
-	}
+	existingIDs := h.existingTransactionIDs(accountID, account.Platform)
 
 	for _, transaction := range transactions {
 		// Validate transaction
@@ -431,8 +1024,9 @@ func (h *Handler) ImportCSVHandler(w http.ResponseWriter, r *http.Request) {
 			continue
 		}
 
-		// Check if transaction already exists
-		if existingIDs[transaction.ID] {
+		// Check if transaction already exists, also under the ID given before content hashes
+		if existingIDs[transaction.ID] ||
+			(strings.HasPrefix(transaction.ID, csvIDPrefix) && existingIDs[legacyCSVTransactionID(transaction)]) {
 			ignored++
 			continue
 		}
@@ -461,19 +1055,273 @@ func (h *Handler) ImportCSVHandler(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, http.StatusOK, summary)
 }
 
+// CSV import limits, replaced from the configuration with SetCSVImportLimits
+var (
+	csvImportMaxSize int64 = 10 << 20
+	csvImportMaxRows       = 50000
+)
+
+// csvImportFormOverhead is the room left in the request body for the multipart envelope and
+// the other form fields of the CSV import
+const csvImportFormOverhead = 1 << 20
+
+// SetCSVImportLimits sets the largest CSV file, in bytes, and the largest number of data rows
+// accepted by the CSV import
+func SetCSVImportLimits(maxSize int64, maxRows int) {
+	csvImportMaxSize = maxSize
+	csvImportMaxRows = maxRows
+}
+
+// CSVImportLimits returns the largest CSV file, in bytes, and number of data rows accepted by
+// the CSV import
+func CSVImportLimits() (int64, int) {
+	return csvImportMaxSize, csvImportMaxRows
+}
+
+// writeCSVTooLarge writes the 413 response of a CSV file over the size limit
+func writeCSVTooLarge(w http.ResponseWriter, maxSize int64) {
+	writeAPIError(w, ErrPayloadTooLarge.WithMessage(fmt.Sprintf("CSV file is larger than %d bytes", maxSize)), map[string]interface{}{
+		"field":    "file",
+		"max_size": maxSize,
+	})
+}
+
+// maxJSONImportSize caps the request body accepted by the JSON import
+const maxJSONImportSize = 10 << 20
+
+// ImportJSONHandler imports transactions from a JSON array
+// @Summary Importer des transactions JSON
+// @Description Importe un tableau JSON de transactions dans un compte avec déduplication
+// @Tags transactions
+// @Accept json
+// @Produce json
+// @Param id path string true "ID du compte"
+// @Param transactions body []models.Transaction true "Transactions à importer"
+// @Success 200 {object} ImportSummary
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/accounts/{id}/transactions/import-json [post]
+func (h *Handler) ImportJSONHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	accountID := vars["id"]
+
+	if accountID == "" {
+		writeAPIError(w, ErrInvalidRequest.WithMessage("Account ID is required"), nil)
+		return
+	}
+
+	// Check if account exists and get platform
+	account, err := h.DB.GetAccountByID(accountID)
+	if err != nil {
+		if err == sql.ErrNoRows || strings.Contains(err.Error(), "no rows") {
+			writeAPIError(w, ErrNotFound.WithMessage("Account not found"), nil)
+			return
+		}
+		writeAPIError(w, ErrDatabase.WithMessage("Failed to retrieve account"), nil)
+		return
+	}
+	if writePlatformError(w, database.ValidateTransactionPlatform(account.Platform), account.Platform) {
+		return
+	}
+
+	var transactions []models.Transaction
+	r.Body = http.MaxBytesReader(w, r.Body, maxJSONImportSize)
+	if err := json.NewDecoder(r.Body).Decode(&transactions); err != nil {
+		writeAPIError(w, ErrInvalidRequest.WithMessage("Request body must be a JSON array of transactions"), map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	if len(transactions) == 0 {
+		writeAPIError(w, ErrValidation.WithMessage("At least one transaction is required"), nil)
+		return
+	}
+
+	existingIDs := h.existingTransactionIDs(accountID, account.Platform)
+
+	ignored := 0
+	importErrors := []string{}
+	toImport := make([]models.Transaction, 0, len(transactions))
+
+	for i, transaction := range transactions {
+		// Transactions always belong to the account from the URL
+		transaction.AccountID = accountID
+		if transaction.AmountCurrency == "" {
+			transaction.AmountCurrency = account.CurrencyOrDefault()
+		}
+
+		if err := transaction.Validate(); err != nil {
+			importErrors = append(importErrors, fmt.Sprintf("Transaction %d (%s): %s", i+1, transaction.ID, err.Error()))
+			continue
+		}
+
+		// Skip transactions already stored or repeated in the same payload
+		if existingIDs[transaction.ID] {
+			ignored++
+			continue
+		}
+		existingIDs[transaction.ID] = true
+
+		toImport = append(toImport, transaction)
+	}
+
+	if err := h.DB.CreateTransactionsBatch(toImport, account.Platform); err != nil {
+		writeAPIError(w, ErrDatabase.WithMessage("Failed to import transactions"), map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	summary := ImportSummary{
+		Imported: len(toImport),
+		Ignored:  ignored,
+		Errors:   len(importErrors),
+		Details:  importErrors,
+	}
+
+	respondJSON(w, http.StatusOK, summary)
+}
+
+// existingTransactionIDs returns the IDs already stored for an account, used for deduplication
+func (h *Handler) existingTransactionIDs(accountID, platform string) map[string]bool {
+	existingIDs := make(map[string]bool)
+	existingTransactions, err := h.DB.GetTransactionsByAccount(accountID, platform, database.TransactionFilter{
+		AccountID:      accountID,
+		IncludeDeleted: true,  // Deleted transactions must not be imported again
+		IncludeHidden:  true,  // Hidden transactions neither
+		Limit:          10000, // Get all existing transactions
+	})
+	if err == nil {
+		for _, t := range existingTransactions {
+			existingIDs[t.ID] = true
+		}
+	}
+	return existingIDs
+}
+
+// csvImportOptions holds the optional behaviours of a CSV import
+type csvImportOptions struct {
+	// RejectFuture reports rows dated after now + FutureTolerance as errors
+	RejectFuture    bool
+	FutureTolerance time.Duration
+	// AggregateFills merges partial fills of the same order into one transaction
+	AggregateFills bool
+	FillWindow     time.Duration
+	// Profile maps the columns, dates and decimals of a broker export (nil for the native format)
+	Profile *models.ImportProfile
+	// Currency is used for rows without amount_currency (the account currency, EUR when empty)
+	Currency string
+	// DecimalStyle reads the numbers a profile decimal style did not normalize (the account style, auto when empty)
+	DecimalStyle string
+	// MaxRows stops the parsing with errCSVTooManyRows past this many data rows (0 for no limit)
+	MaxRows int
+}
+
+// decimalStyle returns the style the numbers of the rows are read with. A profile decimal style
+// takes precedence: its values are already normalized to plain numbers, that auto reads as is.
+func (opts csvImportOptions) decimalStyle() string {
+	if opts.Profile != nil && opts.Profile.DecimalStyle != "" && opts.Profile.DecimalStyle != models.DecimalStyleAuto {
+		return models.DecimalStyleAuto
+	}
+	if opts.DecimalStyle == "" {
+		return models.DecimalStyleAuto
+	}
+	return opts.DecimalStyle
+}
+
+// errCSVTooManyRows is returned by parseCSVWithOptions when the file has more than MaxRows rows
+var errCSVTooManyRows = errors.New("too many CSV rows")
+
+// defaultFillWindow is the maximum time between partial fills of the same order
+const defaultFillWindow = 5 * time.Second
+
+// normalizeProfileRow converts the values of a row read with an import profile
+// to the native CSV format. header holds the internal field of each column.
+func normalizeProfileRow(profile *models.ImportProfile, header, row []string) ([]string, error) {
+	normalized := make([]string, len(row))
+	for i, value := range row {
+		if i >= len(header) || header[i] == "" {
+			normalized[i] = value
+			continue
+		}
+		converted, err := profile.NormalizeValue(header[i], value)
+		if err != nil {
+			return nil, err
+		}
+		normalized[i] = converted
+	}
+	return normalized, nil
+}
+
+// parseCSVImportOptions reads the optional import flags from the form
+func parseCSVImportOptions(r *http.Request) (csvImportOptions, error) {
+	opts := csvImportOptions{
+		FutureTolerance: models.DefaultFutureTolerance,
+		FillWindow:      defaultFillWindow,
+	}
+
+	if rejectFuture := r.FormValue("reject_future"); rejectFuture != "" {
+		value, err := strconv.ParseBool(rejectFuture)
+		if err != nil {
+			return opts, fmt.Errorf("reject_future must be a boolean")
+		}
+		opts.RejectFuture = value
+	}
+
+	if tolerance := r.FormValue("future_tolerance"); tolerance != "" {
+		value, err := time.ParseDuration(tolerance)
+		if err != nil || value < 0 {
+			return opts, fmt.Errorf("future_tolerance must be a positive duration (e.g. 48h)")
+		}
+		opts.FutureTolerance = value
+	}
+
+	if aggregateFills := r.FormValue("aggregate_fills"); aggregateFills != "" {
+		value, err := strconv.ParseBool(aggregateFills)
+		if err != nil {
+			return opts, fmt.Errorf("aggregate_fills must be a boolean")
+		}
+		opts.AggregateFills = value
+	}
+
+	if window := r.FormValue("fill_window"); window != "" {
+		value, err := time.ParseDuration(window)
+		if err != nil || value < 0 {
+			return opts, fmt.Errorf("fill_window must be a positive duration (e.g. 10s)")
+		}
+		opts.FillWindow = value
+	}
+
+	return opts, nil
+}
+
 // parseCSV parses a CSV file and returns transactions and errors
 func (h *Handler) parseCSV(file io.Reader, accountID string) ([]models.Transaction, []string) {
+	transactions, errors, _ := h.parseCSVWithOptions(file, accountID, csvImportOptions{})
+	return transactions, errors
+}
+
+// parseCSVWithOptions parses a CSV file applying the given import options. Rows are read one
+// at a time: the error is errCSVTooManyRows as soon as the file goes past opts.MaxRows.
+func (h *Handler) parseCSVWithOptions(file io.Reader, accountID string, opts csvImportOptions) ([]models.Transaction, []string, error) {
 	reader := csv.NewReader(file)
 	reader.TrimLeadingSpace = true
 
 	// Read header
 	header, err := reader.Read()
 	if err != nil {
-		return nil, []string{fmt.Sprintf("Failed to read CSV header: %s", err.Error())}
+		return nil, []string{fmt.Sprintf("Failed to read CSV header: %s", err.Error())}, nil
 	}
 
 	// Validate required columns
 	requiredColumns := []string{"timestamp", "isin", "amount_value", "fees"}
+	if opts.Profile != nil {
+		// Broker exports are renamed to internal fields; fees default to 0 when not mapped
+		header = opts.Profile.MapHeader(header)
+		requiredColumns = models.ImportProfileRequiredFields
+	}
 	columnIndices := make(map[string]int)
 	errors := []string{}
 
@@ -493,24 +1341,31 @@ func (h *Handler) parseCSV(file io.Reader, accountID string) ([]models.Transacti
 
 	// If required columns are missing, return error
 	if len(errors) > 0 {
-		return nil, errors
+		return nil, errors, nil
 	}
 
 	// Map all columns for flexible parsing
 	allColumnIndices := make(map[string]int)
 	for i, col := range header {
-		allColumnIndices[strings.TrimSpace(strings.ToLower(col))] = i
+		if name := strings.TrimSpace(strings.ToLower(col)); name != "" {
+			allColumnIndices[name] = i
+		}
 	}
 
 	// Parse rows
 	transactions := []models.Transaction{}
 	rowNum := 1 // Start at 1 (header is row 0)
+	now := time.Now()
+	contentIDs := make(map[string]int)
 
 	for {
 		row, err := reader.Read()
 		if err == io.EOF {
 			break
 		}
+		if opts.MaxRows > 0 && rowNum > opts.MaxRows {
+			return nil, nil, errCSVTooManyRows
+		}
 		if err != nil {
 			errors = append(errors, fmt.Sprintf("Row %d: Failed to read row: %s", rowNum, err.Error()))
 			rowNum++
@@ -519,21 +1374,125 @@ func (h *Handler) parseCSV(file io.Reader, accountID string) ([]models.Transacti
 
 		rowNum++
 
+		if opts.Profile != nil {
+			if row, err = normalizeProfileRow(opts.Profile, header, row); err != nil {
+				errors = append(errors, fmt.Sprintf("Row %d: %s", rowNum, err.Error()))
+				continue
+			}
+		}
+
 		// Parse transaction from row
-		transaction, err := h.parseCSVRow(row, allColumnIndices, accountID, rowNum)
+		transaction, err := h.parseCSVRow(row, allColumnIndices, accountID, opts.Currency, opts.decimalStyle(), rowNum)
 		if err != nil {
 			errors = append(errors, fmt.Sprintf("Row %d: %s", rowNum, err.Error()))
 			continue
 		}
 
+		// Identical rows without an ID are numbered in file order (csv-<hash>-2, -3, ...)
+		if strings.HasPrefix(transaction.ID, csvIDPrefix) {
+			contentIDs[transaction.ID]++
+			if n := contentIDs[transaction.ID]; n > 1 {
+				transaction.ID = fmt.Sprintf("%s-%d", transaction.ID, n)
+			}
+		}
+
+		if opts.RejectFuture {
+			if err := transaction.ValidateNotFuture(now, opts.FutureTolerance); err != nil {
+				errors = append(errors, fmt.Sprintf("Row %d: %s", rowNum, err.Error()))
+				continue
+			}
+		}
+
 		transactions = append(transactions, *transaction)
 	}
 
-	return transactions, errors
+	if opts.AggregateFills {
+		transactions = aggregateFills(transactions, opts.FillWindow)
+	}
+
+	return transactions, errors, nil
+}
+
+// aggregateFills merges partial fills of the same order: buys or sells of the same ISIN
+// whose timestamps are within window of the first fill. Quantity, amount and fees are summed
+// and the merged transaction keeps the ID and timestamp of the first fill.
+func aggregateFills(transactions []models.Transaction, window time.Duration) []models.Transaction {
+	type fillGroup struct {
+		index int // position of the merged transaction in the result
+		start time.Time
+		fees  float64
+	}
+
+	// Process fills chronologically so each group starts at its first fill
+	order := make([]int, len(transactions))
+	times := make([]time.Time, len(transactions))
+	for i := range transactions {
+		order[i] = i
+		times[i] = parseImportTimestamp(transactions[i].Timestamp)
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return times[order[a]].Before(times[order[b]])
+	})
+
+	merged := make([]bool, len(transactions))
+	result := make([]models.Transaction, len(transactions))
+	copy(result, transactions)
+
+	openGroups := make(map[string]*fillGroup)
+	for _, i := range order {
+		tx := transactions[i]
+		if (tx.TransactionType != "buy" && tx.TransactionType != "sell") || tx.ISIN == nil || times[i].IsZero() {
+			continue
+		}
+
+		key := tx.AccountID + "|" + *tx.ISIN + "|" + tx.TransactionType
+		group, ok := openGroups[key]
+		if !ok || times[i].Sub(group.start) > window {
+			openGroups[key] = &fillGroup{index: i, start: times[i], fees: parseFillFees(tx.Fees)}
+			continue
+		}
+
+		// Merge this fill into the first fill of the group
+		target := &result[group.index]
+		target.Quantity += tx.Quantity
+		target.AmountValue = target.ExactAmount() + tx.ExactAmount()
+		group.fees += parseFillFees(tx.Fees)
+		target.Fees = strconv.FormatFloat(group.fees, 'f', -1, 64)
+		merged[i] = true
+	}
+
+	aggregated := make([]models.Transaction, 0, len(transactions))
+	for i := range result {
+		if !merged[i] {
+			aggregated = append(aggregated, result[i])
+		}
+	}
+	return aggregated
+}
+
+// parseImportTimestamp parses the timestamp formats accepted by the CSV import
+func parseImportTimestamp(value string) time.Time {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t
+	}
+	if t, err := time.Parse("2006-01-02T15:04:05", value); err == nil {
+		return t
+	}
+	return time.Time{}
+}
+
+// parseFillFees parses the fees of a fill, treating unparseable values as zero
+func parseFillFees(value string) float64 {
+	fees, err := parseDecimal(value)
+	if err != nil {
+		return 0
+	}
+	return fees
 }
 
-// parseCSVRow parses a single CSV row into a Transaction
-func (h *Handler) parseCSVRow(row []string, columnIndices map[string]int, accountID string, rowNum int) (*models.Transaction, error) {
+// parseCSVRow parses a single CSV row into a Transaction. Numbers are read with decimalStyle
+// (see models.ParseDecimalStyle).
+func (h *Handler) parseCSVRow(row []string, columnIndices map[string]int, accountID, defaultCurrency, decimalStyle string, rowNum int) (*models.Transaction, error) {
 	transaction := &models.Transaction{
 		AccountID: accountID,
 	}
@@ -573,7 +1532,7 @@ func (h *Handler) parseCSVRow(row []string, columnIndices map[string]int, accoun
 	if amountStr == "" {
 		return nil, fmt.Errorf("amount_value is required")
 	}
-	amount, err := strconv.ParseFloat(amountStr, 64)
+	amount, err := models.ParseDecimalStyle(amountStr, decimalStyle)
 	if err != nil {
 		return nil, fmt.Errorf("invalid amount_value: %s", amountStr)
 	}
@@ -584,30 +1543,35 @@ func (h *Handler) parseCSVRow(row []string, columnIndices map[string]int, accoun
 	if feesStr == "" {
 		feesStr = "0"
 	}
-	transaction.Fees = feesStr
+	if transaction.Fees, err = canonicalAmount(feesStr, decimalStyle); err != nil {
+		return nil, fmt.Errorf("invalid fees: %s", feesStr)
+	}
 
 	// Parse optional fields
 	transaction.ID = getColumn("id")
-	if transaction.ID == "" {
-		// Generate ID from timestamp + isin + amount if not provided
-		transaction.ID = fmt.Sprintf("%s_%s_%.2f", transaction.Timestamp, isinStr, transaction.AmountValue)
-	}
 
 	transaction.Title = getColumn("title")
 	transaction.Icon = getColumn("icon")
 	transaction.Avatar = getColumn("avatar")
 	transaction.Subtitle = getColumn("subtitle")
-	transaction.AmountCurrency = getColumn("amount_currency")
+	transaction.AmountCurrency = strings.ToUpper(getColumn("amount_currency"))
+	if transaction.AmountCurrency == "" {
+		transaction.AmountCurrency = defaultCurrency
+	}
 	if transaction.AmountCurrency == "" {
-		transaction.AmountCurrency = "EUR" // Default currency
+		transaction.AmountCurrency = models.DefaultCurrency
+	}
+	if err := models.ValidateCurrencyCode(transaction.AmountCurrency); err != nil {
+		return nil, fmt.Errorf("invalid amount_currency: %w", err)
 	}
 
 	amountFractionStr := getColumn("amount_fraction")
 	if amountFractionStr != "" {
-		fraction, err := strconv.Atoi(amountFractionStr)
-		if err == nil {
-			transaction.AmountFraction = fraction
+		fraction, err := models.ParseDecimalStyle(amountFractionStr, decimalStyle)
+		if err != nil || fraction != math.Trunc(fraction) {
+			return nil, fmt.Errorf("invalid amount_fraction: %s", amountFractionStr)
 		}
+		transaction.AmountFraction = int(fraction)
 	}
 
 	transaction.Status = getColumn("status")
@@ -624,7 +1588,9 @@ func (h *Handler) parseCSVRow(row []string, columnIndices map[string]int, accoun
 	// Parse detail fields
 	transaction.Actions = getColumn("actions")
 	transaction.DividendPerShare = getColumn("dividend_per_share")
-	transaction.Taxes = getColumn("taxes")
+	if transaction.Taxes, err = canonicalAmount(getColumn("taxes"), decimalStyle); err != nil {
+		return nil, fmt.Errorf("invalid taxes: %s", getColumn("taxes"))
+	}
 	transaction.Total = getColumn("total")
 	transaction.Shares = getColumn("shares")
 	transaction.SharePrice = getColumn("share_price")
@@ -633,13 +1599,20 @@ func (h *Handler) parseCSVRow(row []string, columnIndices map[string]int, accoun
 	// Parse quantity
 	quantityStr := getColumn("quantity")
 	if quantityStr != "" {
-		quantity, err := strconv.ParseFloat(quantityStr, 64)
-		if err == nil {
-			transaction.Quantity = quantity
+		quantity, err := models.ParseDecimalStyle(quantityStr, decimalStyle)
+		if err != nil {
+			return nil, fmt.Errorf("invalid quantity: %s", quantityStr)
 		}
+		transaction.Quantity = quantity
 	}
 
-	transaction.TransactionType = getColumn("transaction_type")
+	// Map localized or custom type labels (e.g. "Kauf", "Achat") to known types
+	transaction.TransactionType = models.NormalizeTransactionType(getColumn("transaction_type"))
+	if transaction.TransactionType != "" {
+		if err := models.ValidateTransactionType(transaction.TransactionType); err != nil {
+			return nil, err
+		}
+	}
 
 	// Parse metadata - must be valid JSON or empty
 	metadata := getColumn("metadata")
@@ -656,5 +1629,63 @@ func (h *Handler) parseCSVRow(row []string, columnIndices map[string]int, accoun
 		}
 	}
 
+	// Rows without an ID are identified by their content
+	if transaction.ID == "" {
+		transaction.ID = csvContentID(transaction)
+	}
+
 	return transaction, nil
 }
+
+// csvIDPrefix starts the IDs synthesized for CSV rows without an id column
+const csvIDPrefix = "csv-"
+
+// csvContentID returns the ID of a CSV row without an id column: a hash of its timestamp,
+// ISIN, amount, title, quantity, type and fees. Re-importing the same row gives the same ID,
+// while distinct trades of the same day and amount get different ones.
+func csvContentID(tx *models.Transaction) string {
+	isin := ""
+	if tx.ISIN != nil {
+		isin = *tx.ISIN
+	}
+	content := strings.Join([]string{
+		tx.Timestamp,
+		isin,
+		strconv.FormatFloat(tx.AmountValue, 'f', -1, 64),
+		tx.Title,
+		strconv.FormatFloat(tx.Quantity, 'f', -1, 64),
+		tx.TransactionType,
+		tx.Fees,
+	}, "\x1f")
+	sum := sha256.Sum256([]byte(content))
+	return csvIDPrefix + hex.EncodeToString(sum[:8])
+}
+
+// legacyCSVTransactionID returns the ID given to CSV rows without an id column before content
+// hashes were used (timestamp_isin_amount), so that rows imported that way are not imported again
+func legacyCSVTransactionID(tx models.Transaction) string {
+	isin := ""
+	if tx.ISIN != nil {
+		isin = *tx.ISIN
+	}
+	return fmt.Sprintf("%s_%s_%.2f", tx.Timestamp, isin, tx.AmountValue)
+}
+
+// canonicalAmount rewrites an amount written with a known decimal style as a plain number
+// ("1.234,50 €" is "1234.5" with the comma style), so that the fees and taxes stored as text
+// are not guessed again by the calculations. Empty values and the auto style are kept as is.
+func canonicalAmount(value, decimalStyle string) (string, error) {
+	if value == "" || decimalStyle == "" || decimalStyle == models.DecimalStyleAuto {
+		return value, nil
+	}
+	amount, err := models.ParseMoneyStyle(value, decimalStyle)
+	if err != nil {
+		return "", err
+	}
+	return strconv.FormatFloat(amount, 'f', -1, 64), nil
+}
+
+// parseDecimal parses a number of a CSV column (see models.ParseDecimal)
+func parseDecimal(value string) (float64, error) {
+	return models.ParseDecimal(value)
+}
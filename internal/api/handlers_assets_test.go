@@ -0,0 +1,624 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"valhafin/internal/domain/models"
+	"valhafin/internal/repository/database"
+	"valhafin/internal/service/price"
+
+	"github.com/gorilla/mux"
+)
+
+func TestApplyPositionTransaction_ZeroQuantityBuy(t *testing.T) {
+	isin := "IE00B4L5Y983"
+	transactions := []models.Transaction{
+		{ID: "buy-1", ISIN: &isin, TransactionType: "buy", Timestamp: "2024-01-10T10:00:00Z", AmountValue: -1000, Quantity: 10},
+		// Timeline buy without quantity
+		{ID: "buy-2", ISIN: &isin, TransactionType: "buy", Timestamp: "2024-02-10T10:00:00Z", AmountValue: -50, Quantity: 0},
+	}
+
+	position := &AssetPosition{ISIN: isin, Purchases: []Purchase{}}
+	for _, tx := range transactions {
+		applyPositionTransaction(position, tx, false)
+	}
+	position.AverageBuyPrice = averageBuyPrice(position.TotalInvested, position.Quantity)
+
+	if position.Quantity != 10 {
+		t.Errorf("Quantity = %v, want 10", position.Quantity)
+	}
+	if position.TotalInvested != 1050 {
+		t.Errorf("TotalInvested = %v, want 1050", position.TotalInvested)
+	}
+	if len(position.Purchases) != 1 {
+		t.Fatalf("expected the zero-quantity buy to be left out of purchases, got %+v", position.Purchases)
+	}
+	for _, purchase := range position.Purchases {
+		if math.IsNaN(purchase.Price) || math.IsInf(purchase.Price, 0) {
+			t.Errorf("purchase price is not finite: %v", purchase.Price)
+		}
+	}
+	if position.AverageBuyPrice != 105 {
+		t.Errorf("AverageBuyPrice = %v, want 105", position.AverageBuyPrice)
+	}
+}
+
+func TestApplyPositionTransaction_OnlyZeroQuantityBuys(t *testing.T) {
+	isin := "IE00B4L5Y983"
+	position := &AssetPosition{ISIN: isin, Purchases: []Purchase{}}
+
+	applyPositionTransaction(position, models.Transaction{
+		ID: "buy-1", ISIN: &isin, TransactionType: "buy", Timestamp: "2024-01-10T10:00:00Z", AmountValue: -100,
+	}, false)
+	applyPositionTransaction(position, models.Transaction{
+		ID: "sell-1", ISIN: &isin, TransactionType: "sell", Timestamp: "2024-01-11T10:00:00Z", AmountValue: 100,
+	}, false)
+
+	avg := averageBuyPrice(position.TotalInvested, position.Quantity)
+	if math.IsNaN(avg) || math.IsInf(avg, 0) || avg != 0 {
+		t.Errorf("AverageBuyPrice = %v, want 0", avg)
+	}
+	if math.IsNaN(position.TotalInvested) || math.IsInf(position.TotalInvested, 0) {
+		t.Errorf("TotalInvested is not finite: %v", position.TotalInvested)
+	}
+	if len(position.Purchases) != 0 {
+		t.Errorf("expected no purchases, got %+v", position.Purchases)
+	}
+}
+
+func TestApplyPositionTransaction_ReentryAfterFullSale(t *testing.T) {
+	isin := "IE00B4L5Y983"
+	position := &AssetPosition{ISIN: isin, Purchases: []Purchase{}}
+	for _, tx := range []models.Transaction{
+		{ID: "buy-1", ISIN: &isin, TransactionType: "buy", Timestamp: "2024-01-10T10:00:00Z", AmountValue: -10, Quantity: 0.1},
+		{ID: "buy-2", ISIN: &isin, TransactionType: "buy", Timestamp: "2024-01-11T10:00:00Z", AmountValue: -20, Quantity: 0.2},
+		{ID: "sell-1", ISIN: &isin, TransactionType: "sell", Timestamp: "2024-02-10T10:00:00Z", AmountValue: 45, Quantity: 0.3},
+		{ID: "buy-3", ISIN: &isin, TransactionType: "buy", Timestamp: "2024-03-10T10:00:00Z", AmountValue: -300, Quantity: 2},
+	} {
+		applyPositionTransaction(position, tx, false)
+	}
+
+	if position.Quantity != 2 || averageBuyPrice(position.TotalInvested, position.Quantity) != 150 {
+		t.Errorf("expected 2 shares at the second buy price (150), got %v at %v",
+			position.Quantity, averageBuyPrice(position.TotalInvested, position.Quantity))
+	}
+}
+
+func TestMergeSearchResults(t *testing.T) {
+	appleSymbol := "AAPL"
+	local := []database.AssetSearchResult{
+		{Asset: models.Asset{ISIN: "US0378331005", Name: "Apple Inc.", Symbol: &appleSymbol, Type: "stock", Currency: "USD"}, Held: false},
+		{Asset: models.Asset{ISIN: "IE00B4L5Y983", Name: "iShares Core MSCI World", Type: "etf", Currency: "EUR"}, Held: true},
+	}
+	provider := []price.YahooSearchResult{
+		{Symbol: "aapl", Name: "Apple Inc.", Type: "EQUITY", ExchDisp: "NASDAQ"},
+		{Symbol: "APC.DE", ShortName: "APPLE INC", Type: "EQUITY", ExchDisp: "XETRA"},
+		{Symbol: "", Name: "No symbol"},
+	}
+
+	results := mergeSearchResults(local, provider)
+
+	if len(results) != 3 {
+		t.Fatalf("expected 3 results, got %d: %+v", len(results), results)
+	}
+
+	// Held asset first
+	if results[0].ISIN != "IE00B4L5Y983" || !results[0].InPortfolio || results[0].Source != "local" {
+		t.Errorf("first result should be the held local asset, got %+v", results[0])
+	}
+	if results[1].ISIN != "US0378331005" || results[1].InPortfolio || results[1].Symbol != "AAPL" {
+		t.Errorf("second result should be the other local asset, got %+v", results[1])
+	}
+
+	// Provider duplicate of AAPL is dropped, other listing kept with its short name
+	if results[2].Source != "provider" || results[2].Symbol != "APC.DE" || results[2].Name != "APPLE INC" {
+		t.Errorf("third result should be the provider listing, got %+v", results[2])
+	}
+	if results[2].Exchange != "XETRA" || results[2].Type != "equity" {
+		t.Errorf("provider fields not mapped: %+v", results[2])
+	}
+}
+
+func TestComputePositions(t *testing.T) {
+	world := "IE00B4L5Y983"
+	apple := "US0378331005"
+
+	// Listed newest first, as returned by the database
+	transactions := []models.Transaction{
+		{ID: "sell-apple", ISIN: &apple, TransactionType: "sell", Timestamp: "2024-03-01T10:00:00Z", AmountValue: 600, Quantity: 4},
+		{ID: "buy-world-2", ISIN: &world, TransactionType: "buy", Timestamp: "2024-02-01T10:00:00Z", AmountValue: -300, Quantity: 2},
+		{ID: "deposit", TransactionType: "deposit", Timestamp: "2024-01-15T10:00:00Z", AmountValue: 5000},
+		{ID: "buy-apple", ISIN: &apple, TransactionType: "buy", Timestamp: "2024-01-10T10:00:00Z", AmountValue: -400, Quantity: 4},
+		{ID: "buy-world-1", ISIN: &world, TransactionType: "buy", Timestamp: "2024-01-05T10:00:00Z", AmountValue: -1000, Quantity: 10},
+	}
+
+	positions := computePositions(transactions)
+
+	if len(positions) != 2 {
+		t.Fatalf("expected 2 positions, got %d", len(positions))
+	}
+
+	worldPosition := positions[world]
+	if worldPosition.Quantity != 12 || worldPosition.TotalInvested != 1300 {
+		t.Errorf("world position = %v shares / %v invested, want 12 / 1300", worldPosition.Quantity, worldPosition.TotalInvested)
+	}
+	if len(worldPosition.Purchases) != 2 || worldPosition.Purchases[0].Date != "2024-01-05" {
+		t.Errorf("purchases should be listed chronologically, got %+v", worldPosition.Purchases)
+	}
+
+	// The sell is replayed after the buy even though it is listed first
+	applePosition := positions[apple]
+	if applePosition.Quantity != 0 || applePosition.TotalInvested != 0 {
+		t.Errorf("apple position should be closed, got %v shares / %v invested", applePosition.Quantity, applePosition.TotalInvested)
+	}
+}
+
+func TestComputePositions_NoTransactions(t *testing.T) {
+	if positions := computePositions(nil); len(positions) != 0 {
+		t.Errorf("expected no positions, got %v", positions)
+	}
+}
+
+func TestMergeAssetsHandler_Validation(t *testing.T) {
+	handler := &Handler{}
+
+	for _, body := range []string{`{"from_isin":"","to_isin":"IE00B4L5Y983"}`, `{"from_isin":"ie00b4l5y983","to_isin":"IE00B4L5Y983"}`, `not json`} {
+		req := httptest.NewRequest("POST", "/api/assets/merge", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		handler.MergeAssetsHandler(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("body %s: expected status 400, got %d", body, w.Code)
+		}
+	}
+}
+
+func TestMergeAssetsHandler_Integration(t *testing.T) {
+	handler, db := setupTestHandler(t)
+	if handler == nil {
+		return
+	}
+	defer db.Close()
+
+	fromISIN, toISIN := "IE00B4L5Y98X", "IE00B4L5Y983"
+	for _, isin := range []string{fromISIN, toISIN} {
+		if err := db.CreateAsset(&models.Asset{ISIN: isin, Name: "iShares Core MSCI World", Type: "etf", Currency: "EUR"}); err != nil {
+			t.Fatalf("Failed to create asset: %v", err)
+		}
+	}
+
+	account := &models.Account{Name: "Test Merge", Platform: "traderepublic", Credentials: "encrypted"}
+	if err := db.CreateAccount(account); err != nil {
+		t.Fatalf("Failed to create account: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		tx := models.Transaction{
+			ID:              fmt.Sprintf("tx-merge-%d", i),
+			AccountID:       account.ID,
+			Timestamp:       time.Date(2024, 1, i+1, 10, 0, 0, 0, time.UTC).Format(time.RFC3339),
+			AmountValue:     -100,
+			AmountCurrency:  "EUR",
+			TransactionType: "buy",
+			ISIN:            stringPtr(fromISIN),
+			Quantity:        1,
+		}
+		if err := db.CreateTransaction(&tx, account.Platform); err != nil {
+			t.Fatalf("Failed to create transaction: %v", err)
+		}
+	}
+
+	// The second day is known for both assets: the target price is kept
+	day1 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	day2 := day1.AddDate(0, 0, 1)
+	prices := []models.AssetPrice{
+		{ISIN: fromISIN, Price: 80, Currency: "EUR", Timestamp: day1},
+		{ISIN: fromISIN, Price: 81, Currency: "EUR", Timestamp: day2},
+		{ISIN: toISIN, Price: 90, Currency: "EUR", Timestamp: day2},
+	}
+	if err := db.CreateAssetPricesBatch(prices); err != nil {
+		t.Fatalf("Failed to create prices: %v", err)
+	}
+
+	// Merging into an unknown asset is refused and changes nothing
+	req := httptest.NewRequest("POST", "/api/assets/merge", strings.NewReader(`{"from_isin":"`+fromISIN+`","to_isin":"US0000000000"}`))
+	w := httptest.NewRecorder()
+	handler.MergeAssetsHandler(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected status 404 for an unknown target, got %d: %s", w.Code, w.Body.String())
+	}
+
+	req = httptest.NewRequest("POST", "/api/assets/merge", strings.NewReader(`{"from_isin":"`+fromISIN+`","to_isin":"`+toISIN+`"}`))
+	w = httptest.NewRecorder()
+	handler.MergeAssetsHandler(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var result database.AssetMergeResult
+	if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if result.TransactionsMoved != 2 || result.PricesMoved != 1 || result.DuplicatePrices != 1 {
+		t.Errorf("unexpected merge result: %+v", result)
+	}
+
+	if _, err := db.GetAssetByISIN(fromISIN); err == nil {
+		t.Error("merged asset should have been deleted")
+	}
+	history, err := db.GetAssetPriceHistory(toISIN, day1, day2)
+	if err != nil {
+		t.Fatalf("Failed to get price history: %v", err)
+	}
+	if len(history) != 2 {
+		t.Errorf("expected 2 prices for the target, got %d", len(history))
+	}
+	for _, p := range history {
+		if p.Timestamp.Equal(day2) && p.Price != 90 {
+			t.Errorf("target price of the duplicated day should be kept, got %v", p.Price)
+		}
+	}
+}
+
+func TestUpdateAssetSymbolsHandler_Validation(t *testing.T) {
+	handler := &Handler{}
+
+	for _, body := range []string{`[]`, `[{"isin":" ","symbol":"AAPL"}]`, `{"isin":"US0378331005"}`, `not json`} {
+		req := httptest.NewRequest("PUT", "/api/assets/symbols", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		handler.UpdateAssetSymbolsHandler(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("body %s: expected status 400, got %d", body, w.Code)
+		}
+	}
+}
+
+func TestUpdateAssetSymbolsHandler_Integration(t *testing.T) {
+	handler, db := setupTestHandler(t)
+	if handler == nil {
+		return
+	}
+	defer db.Close()
+
+	for _, isin := range []string{"US0378331005", "IE00B4L5Y983"} {
+		if err := db.CreateAsset(&models.Asset{ISIN: isin, Name: "Asset " + isin, Type: "stock", Currency: "EUR"}); err != nil {
+			t.Fatalf("Failed to create asset: %v", err)
+		}
+	}
+
+	body := `[
+		{"isin":"US0378331005","symbol":"AAPL","symbol_verified":true},
+		{"isin":"US0000000000","symbol":"NOPE","symbol_verified":true},
+		{"isin":"ie00b4l5y983","symbol":"IWDA.AS","symbol_verified":false}
+	]`
+	req := httptest.NewRequest("PUT", "/api/assets/symbols", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	handler.UpdateAssetSymbolsHandler(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var response UpdateAssetSymbolsResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if response.Updated != 2 || response.Failed != 1 || len(response.Results) != 3 {
+		t.Fatalf("unexpected response: %+v", response)
+	}
+	if failed := response.Results[1]; failed.ISIN != "US0000000000" || failed.Success || failed.Error == "" {
+		t.Errorf("expected the unknown ISIN to be reported as failed, got %+v", failed)
+	}
+
+	apple, err := db.GetAssetByISIN("US0378331005")
+	if err != nil {
+		t.Fatalf("Failed to get asset: %v", err)
+	}
+	if apple.Symbol == nil || *apple.Symbol != "AAPL" || !apple.SymbolVerified {
+		t.Errorf("expected the verified AAPL symbol, got %+v", apple)
+	}
+	world, err := db.GetAssetByISIN("IE00B4L5Y983")
+	if err != nil {
+		t.Fatalf("Failed to get asset: %v", err)
+	}
+	if world.Symbol == nil || *world.Symbol != "IWDA.AS" || world.SymbolVerified {
+		t.Errorf("expected the unverified IWDA.AS symbol, got %+v", world)
+	}
+}
+
+func TestReconcilePositions(t *testing.T) {
+	snapshot := &models.PositionSnapshot{
+		ID:        "snapshot-1",
+		AccountID: "account-1",
+		TakenAt:   time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC),
+		Positions: []models.BrokerPosition{
+			{ISIN: "US0378331005", Quantity: 10, AveragePrice: 100},
+			{ISIN: "IE00B4L5Y983", Quantity: 5, AveragePrice: 80},
+			{ISIN: "US5949181045", Quantity: 1, AveragePrice: 300},
+		},
+	}
+	computed := map[string]*AssetPosition{
+		// A 2:1 split the transactions do not show
+		"US0378331005": {ISIN: "US0378331005", Quantity: 5, TotalInvested: 1000},
+		"IE00B4L5Y983": {ISIN: "IE00B4L5Y983", Quantity: 5.0000000001, TotalInvested: 400},
+		"DE0007164600": {ISIN: "DE0007164600", Quantity: 3, TotalInvested: 300},
+		"FR0000120271": {ISIN: "FR0000120271", Quantity: 0},
+	}
+
+	result := reconcilePositions(snapshot, computed)
+
+	if result.InSync || result.SnapshotID != "snapshot-1" {
+		t.Fatalf("unexpected reconciliation: %+v", result)
+	}
+	want := map[string]float64{"DE0007164600": -3, "US0378331005": 5, "US5949181045": 1}
+	if len(result.Differences) != len(want) {
+		t.Fatalf("expected %d differences, got %+v", len(want), result.Differences)
+	}
+	for i, diff := range result.Differences {
+		if i > 0 && result.Differences[i-1].ISIN > diff.ISIN {
+			t.Errorf("differences not sorted by ISIN: %+v", result.Differences)
+		}
+		if want[diff.ISIN] != diff.QuantityDifference {
+			t.Errorf("%s: expected difference %v, got %v", diff.ISIN, want[diff.ISIN], diff.QuantityDifference)
+		}
+	}
+	if result.Differences[1].ComputedAveragePrice != 200 || result.Differences[1].BrokerAveragePrice != 100 {
+		t.Errorf("expected average prices 100 (broker) and 200 (computed), got %+v", result.Differences[1])
+	}
+
+	inSync := reconcilePositions(&models.PositionSnapshot{AccountID: "account-1"}, map[string]*AssetPosition{})
+	if !inSync.InSync || inSync.Differences == nil {
+		t.Errorf("expected an empty reconciliation to be in sync with an empty list, got %+v", inSync)
+	}
+}
+
+func TestGetAssetPriceHistoryHandler_InvalidOptions(t *testing.T) {
+	handler := &Handler{}
+
+	for _, query := range []string{"interval=1wk", "fill=maybe"} {
+		req := httptest.NewRequest("GET", "/api/assets/IE00B4L5Y983/history?"+query, nil)
+		req = mux.SetURLVars(req, map[string]string{"isin": "IE00B4L5Y983"})
+		w := httptest.NewRecorder()
+		handler.GetAssetPriceHistoryHandler(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("query %s: expected status 400, got %d", query, w.Code)
+		}
+	}
+}
+
+// historyPriceService fails every price history request with err
+type historyPriceService struct {
+	price.Service
+	err error
+}
+
+func (s historyPriceService) GetPriceHistory(isin string, startDate, endDate time.Time) ([]models.AssetPrice, error) {
+	return nil, s.err
+}
+
+func TestGetAssetPriceHistoryHandler_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want APIError
+	}{
+		{"unknown asset", fmt.Errorf("asset not found: %w", sql.ErrNoRows), ErrNotFound},
+		{"provider failure", errors.New("symbol not found on Yahoo Finance"), ErrPrice},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/api/assets/IE00B4L5Y983/history", nil)
+		req = mux.SetURLVars(req, map[string]string{"isin": "IE00B4L5Y983"})
+		w := httptest.NewRecorder()
+		(&Handler{PriceService: historyPriceService{err: tt.err}}).GetAssetPriceHistoryHandler(w, req)
+
+		var response ErrorResponse
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("%s: failed to decode response: %v", tt.name, err)
+		}
+		if w.Code != tt.want.Status || response.Error.Code != tt.want.Code {
+			t.Errorf("%s: got %d %s, want %d %s", tt.name, w.Code, response.Error.Code, tt.want.Status, tt.want.Code)
+		}
+	}
+}
+
+func TestGetRecentAssetPricesHandler_InvalidLimit(t *testing.T) {
+	handler := &Handler{}
+
+	for _, limit := range []string{"0", "-5", "ten"} {
+		req := httptest.NewRequest("GET", "/api/assets/IE00B4L5Y983/prices/recent?limit="+limit, nil)
+		req = mux.SetURLVars(req, map[string]string{"isin": "IE00B4L5Y983"})
+		w := httptest.NewRecorder()
+		handler.GetRecentAssetPricesHandler(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("limit %s: expected status 400, got %d", limit, w.Code)
+		}
+	}
+}
+
+func TestExcludeDustPositions(t *testing.T) {
+	positions := []AssetPosition{
+		{ISIN: "IE00B4L5Y983", CurrentValue: 900},
+		{ISIN: "US0378331005", CurrentValue: 99.97},
+		{ISIN: "IE00B4ND3602", CurrentValue: 0.03},
+	}
+	allocatePositions(positions)
+
+	kept := excludeDustPositions(append([]AssetPosition(nil), positions...), 1, false)
+	if len(kept) != 2 || kept[1].ISIN != "US0378331005" {
+		t.Fatalf("expected the dust position to be left out, got %+v", kept)
+	}
+	if math.Abs(kept[0].AllocationPct-90) > 1e-9 {
+		t.Errorf("expected the dust position to count in the allocation total, got %v", kept[0].AllocationPct)
+	}
+
+	kept = excludeDustPositions(append([]AssetPosition(nil), positions...), 1, true)
+	if len(kept) != 2 || math.Abs(kept[0].AllocationPct+kept[1].AllocationPct-100) > 1e-9 {
+		t.Errorf("expected a strict allocation over the kept positions, got %+v", kept)
+	}
+
+	if kept := excludeDustPositions(positions, 0, false); len(kept) != 3 {
+		t.Errorf("expected every position without min_value, got %d", len(kept))
+	}
+}
+
+func TestGetAssetsHandler_InvalidOptions(t *testing.T) {
+	handler := &Handler{}
+
+	for _, query := range []string{"min_value=-1", "min_value=abc", "strict=maybe"} {
+		req := httptest.NewRequest("GET", "/api/assets?"+query, nil)
+		w := httptest.NewRecorder()
+		handler.GetAssetsHandler(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("query %s: expected status 400, got %d", query, w.Code)
+		}
+	}
+}
+
+func TestTradedAssetsHandlers_Integration(t *testing.T) {
+	handler, db := setupTestHandler(t)
+	if handler == nil {
+		return
+	}
+	defer db.Close()
+
+	heldISIN, closedISIN := "IE00B4L5Y983", "US0378331005"
+	if err := db.CreateAsset(&models.Asset{ISIN: heldISIN, Name: "iShares Core MSCI World", Type: "etf", Currency: "EUR"}); err != nil {
+		t.Fatalf("Failed to create asset: %v", err)
+	}
+	if err := db.CreateAsset(&models.Asset{ISIN: closedISIN, Name: "Apple", Type: "stock", Currency: "USD"}); err != nil {
+		t.Fatalf("Failed to create asset: %v", err)
+	}
+
+	account := &models.Account{Name: "Test Traded", Platform: "traderepublic", Credentials: "encrypted"}
+	other := &models.Account{Name: "Test Other", Platform: "traderepublic", Credentials: "encrypted"}
+	for _, a := range []*models.Account{account, other} {
+		if err := db.CreateAccount(a); err != nil {
+			t.Fatalf("Failed to create account: %v", err)
+		}
+	}
+
+	trades := []struct {
+		accountID string
+		isin      string
+		txType    string
+		quantity  float64
+	}{
+		{account.ID, heldISIN, "buy", 3},
+		{account.ID, heldISIN, "sell", 1},
+		{account.ID, closedISIN, "buy", 2},
+		{account.ID, closedISIN, "sell", 2},
+		{other.ID, closedISIN, "buy", 1},
+	}
+	for i, trade := range trades {
+		tx := models.Transaction{
+			ID:              fmt.Sprintf("tx-traded-%d", i),
+			AccountID:       trade.accountID,
+			Timestamp:       time.Date(2024, 1, i+1, 10, 0, 0, 0, time.UTC).Format(time.RFC3339),
+			AmountValue:     -100,
+			AmountCurrency:  "EUR",
+			TransactionType: trade.txType,
+			ISIN:            stringPtr(trade.isin),
+			Quantity:        trade.quantity,
+		}
+		if err := db.CreateTransaction(&tx, "traderepublic"); err != nil {
+			t.Fatalf("Failed to create transaction: %v", err)
+		}
+	}
+
+	req := httptest.NewRequest("GET", "/api/accounts/"+account.ID+"/assets", nil)
+	req = mux.SetURLVars(req, map[string]string{"id": account.ID})
+	w := httptest.NewRecorder()
+	handler.GetAccountTradedAssetsHandler(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var assets []database.TradedAsset
+	if err := json.NewDecoder(w.Body).Decode(&assets); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(assets) != 2 {
+		t.Fatalf("expected 2 traded assets, got %+v", assets)
+	}
+	// Sorted by name: Apple (closed) then iShares (held)
+	if assets[0].ISIN != closedISIN || assets[0].Held || assets[0].Name != "Apple" {
+		t.Errorf("expected the closed Apple position first, got %+v", assets[0])
+	}
+	if assets[1].ISIN != heldISIN || !assets[1].Held || assets[1].Quantity != 2 {
+		t.Errorf("expected 2 iShares shares held, got %+v", assets[1])
+	}
+
+	// Across accounts, the other account still holds Apple
+	req = httptest.NewRequest("GET", "/api/assets/traded", nil)
+	w = httptest.NewRecorder()
+	handler.GetTradedAssetsHandler(w, req)
+	if err := json.NewDecoder(w.Body).Decode(&assets); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(assets) != 2 || !assets[0].Held || assets[0].Quantity != 1 {
+		t.Errorf("expected Apple to be held across accounts, got %+v", assets)
+	}
+
+	req = httptest.NewRequest("GET", "/api/accounts/unknown/assets", nil)
+	req = mux.SetURLVars(req, map[string]string{"id": "00000000-0000-0000-0000-000000000000"})
+	w = httptest.NewRecorder()
+	handler.GetAccountTradedAssetsHandler(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status 404 for an unknown account, got %d", w.Code)
+	}
+}
+
+func TestSetUnknownAssetName_Integration(t *testing.T) {
+	handler, db := setupTestHandler(t)
+	if handler == nil {
+		return
+	}
+	defer db.Close()
+
+	unknownISIN, namedISIN := "US0378331005", "IE00B4L5Y983"
+	if err := db.CreateAsset(&models.Asset{ISIN: unknownISIN, Name: models.UnknownAssetName, Type: "stock", Currency: "USD"}); err != nil {
+		t.Fatalf("Failed to create asset: %v", err)
+	}
+	if err := db.CreateAsset(&models.Asset{ISIN: namedISIN, Name: "iShares Core MSCI World", Type: "etf", Currency: "EUR"}); err != nil {
+		t.Fatalf("Failed to create asset: %v", err)
+	}
+
+	// Creating the asset again without name keeps its real name
+	if err := db.CreateAsset(&models.Asset{ISIN: namedISIN, Name: models.UnknownAssetName, Type: "etf", Currency: "EUR"}); err != nil {
+		t.Fatalf("Failed to upsert asset: %v", err)
+	}
+
+	assets, err := db.GetAssetsWithUnknownName(context.Background())
+	if err != nil {
+		t.Fatalf("GetAssetsWithUnknownName failed: %v", err)
+	}
+	if len(assets) != 1 || assets[0].ISIN != unknownISIN {
+		t.Fatalf("expected only %s to be unknown, got %+v", unknownISIN, assets)
+	}
+
+	if updated, err := db.SetUnknownAssetName(context.Background(), unknownISIN, "Apple Inc."); err != nil || !updated {
+		t.Errorf("expected the unknown asset to be named, got %v, %v", updated, err)
+	}
+	if updated, err := db.SetUnknownAssetName(context.Background(), namedISIN, "Other name"); err != nil || updated {
+		t.Errorf("expected the real name to be kept, got %v, %v", updated, err)
+	}
+	if _, err := db.SetUnknownAssetName(context.Background(), unknownISIN, models.UnknownAssetName); err == nil {
+		t.Error("expected Unknown to be rejected as a name")
+	}
+
+	for isin, expected := range map[string]string{unknownISIN: "Apple Inc.", namedISIN: "iShares Core MSCI World"} {
+		asset, err := db.GetAssetByISIN(isin)
+		if err != nil {
+			t.Fatalf("Failed to get asset: %v", err)
+		}
+		if asset.Name != expected {
+			t.Errorf("%s: expected name %q, got %q", isin, expected, asset.Name)
+		}
+	}
+}
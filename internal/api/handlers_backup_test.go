@@ -0,0 +1,196 @@
+package api
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"valhafin/internal/domain/models"
+	"valhafin/internal/repository/database"
+
+	"github.com/gorilla/mux"
+)
+
+func TestAccountBackupArchive_RoundTrip(t *testing.T) {
+	isin := "US0378331005"
+	symbol := "AAPL"
+	metadata := `{"symbol":"AAPL"}`
+	backup := &database.AccountBackup{
+		Account: models.Account{ID: "acc-1", Name: "Main", Platform: "traderepublic", Credentials: "secret-credentials", Currency: "EUR"},
+		Transactions: []models.Transaction{
+			{ID: "tx-1", AccountID: "acc-1", Timestamp: "2024-01-15T10:00:00Z", Title: "Apple", AmountValue: -150.5, AmountCurrency: "EUR", ISIN: &isin, Quantity: 1, TransactionType: "buy", Metadata: &metadata},
+			{ID: "tx-2", AccountID: "acc-1", Timestamp: "2024-02-01T10:00:00Z", Title: "Dividend", AmountValue: 1.2, AmountCurrency: "EUR", ISIN: &isin, TransactionType: "dividend", Hidden: true},
+		},
+		Assets: []models.Asset{{ISIN: isin, Name: "Apple Inc.", Symbol: &symbol, SymbolVerified: true, Type: "stock", Currency: "USD"}},
+		Prices: []models.AssetPrice{
+			{ISIN: isin, Price: 185.64, Currency: "USD", Timestamp: time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)},
+			{ISIN: isin, Price: 186.1, Currency: "USD", Timestamp: time.Date(2024, 1, 16, 21, 30, 0, 0, time.UTC)},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := writeAccountBackup(&buf, backup, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatalf("writeAccountBackup failed: %v", err)
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("Export is not a valid ZIP archive: %v", err)
+	}
+	names := make(map[string]bool)
+	for _, file := range archive.File {
+		names[file.Name] = true
+		reader, err := file.Open()
+		if err != nil {
+			t.Fatalf("Failed to open %s: %v", file.Name, err)
+		}
+		content, _ := io.ReadAll(reader)
+		reader.Close()
+		if strings.Contains(string(content), "secret-credentials") {
+			t.Errorf("%s contains the account credentials", file.Name)
+		}
+	}
+	for _, name := range []string{backupManifestFile, backupAccountFile, backupTransactionsFile, backupAssetsFile, backupPricesFile} {
+		if !names[name] {
+			t.Errorf("Archive is missing %s", name)
+		}
+	}
+
+	manifest, restored, err := readAccountBackup(buf.Bytes())
+	if err != nil {
+		t.Fatalf("readAccountBackup failed: %v", err)
+	}
+	if manifest.FormatVersion != BackupFormatVersion || manifest.Platform != "traderepublic" || manifest.AccountID != "acc-1" || manifest.Transactions != 2 {
+		t.Errorf("Unexpected manifest: %+v", manifest)
+	}
+	if restored.Account.Name != "Main" || restored.Account.Credentials != "" {
+		t.Errorf("Unexpected account: %+v", restored.Account)
+	}
+	if len(restored.Transactions) != 2 || restored.Transactions[0].ID != "tx-1" || *restored.Transactions[0].Metadata != metadata || !restored.Transactions[1].Hidden {
+		t.Errorf("Unexpected transactions: %+v", restored.Transactions)
+	}
+	if len(restored.Assets) != 1 || *restored.Assets[0].Symbol != "AAPL" || !restored.Assets[0].SymbolVerified {
+		t.Errorf("Unexpected assets: %+v", restored.Assets)
+	}
+	if len(restored.Prices) != 2 {
+		t.Fatalf("Expected 2 prices, got %d", len(restored.Prices))
+	}
+	for i, price := range restored.Prices {
+		if price.Price != backup.Prices[i].Price || !price.Timestamp.Equal(backup.Prices[i].Timestamp) || price.Currency != "USD" {
+			t.Errorf("Price %d: expected %+v, got %+v", i, backup.Prices[i], price)
+		}
+	}
+}
+
+func TestReadAccountBackup_Invalid(t *testing.T) {
+	if _, _, err := readAccountBackup([]byte("not a zip")); err == nil {
+		t.Error("Expected an error for a non-ZIP file")
+	}
+
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	entry, _ := archive.Create(backupManifestFile)
+	entry.Write([]byte(`{"format_version": 99}`))
+	archive.Close()
+	if _, _, err := readAccountBackup(buf.Bytes()); err == nil || !strings.Contains(err.Error(), "format version") {
+		t.Errorf("Expected an unsupported format version error, got %v", err)
+	}
+}
+
+// createBackupMultipartRequest creates a multipart import.zip request
+func createBackupMultipartRequest(t *testing.T, accountID string, archive []byte) *http.Request {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	writer.WriteField("account_id", accountID)
+	part, err := writer.CreateFormFile("file", "backup.zip")
+	if err != nil {
+		t.Fatalf("Failed to create form file: %v", err)
+	}
+	part.Write(archive)
+	writer.Close()
+
+	req := httptest.NewRequest("POST", "/api/import.zip", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	return req
+}
+
+func TestAccountZipExportImport_RoundTrip(t *testing.T) {
+	handler, db := setupTestHandler(t)
+	if handler == nil {
+		return
+	}
+	defer db.Close()
+
+	sourceID := createTestAccount(t, db, "traderepublic")
+	isin := "US0378331005"
+	transactions := []models.Transaction{
+		{ID: "backup-tx-1", AccountID: sourceID, Timestamp: "2024-01-15T10:00:00Z", Title: "Apple", AmountValue: -150, AmountCurrency: "EUR", ISIN: &isin, Quantity: 1, TransactionType: "buy"},
+		{ID: "backup-tx-2", AccountID: sourceID, Timestamp: "2024-02-01T10:00:00Z", Title: "Apple", AmountValue: 2, AmountCurrency: "EUR", ISIN: &isin, TransactionType: "dividend", Deleted: true},
+	}
+	if err := db.CreateTransactionsBatch(transactions, "traderepublic"); err != nil {
+		t.Fatalf("Failed to create transactions: %v", err)
+	}
+	if err := db.CreateAssetPrice(&models.AssetPrice{ISIN: isin, Price: 185.5, Currency: "EUR", Timestamp: time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)}); err != nil {
+		t.Fatalf("Failed to create price: %v", err)
+	}
+
+	req := httptest.NewRequest("GET", "/api/accounts/"+sourceID+"/export.zip", nil)
+	req = mux.SetURLVars(req, map[string]string{"id": sourceID})
+	rec := httptest.NewRecorder()
+	handler.ExportAccountZipHandler(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Export: expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/zip" {
+		t.Errorf("Export: expected application/zip, got %s", ct)
+	}
+	archive := rec.Body.Bytes()
+
+	// Transaction IDs are unique per platform: the source account is removed before restoring
+	if err := db.DeleteAccount(sourceID); err != nil {
+		t.Fatalf("Failed to delete source account: %v", err)
+	}
+	targetID := createTestAccount(t, db, "traderepublic")
+
+	rec = httptest.NewRecorder()
+	handler.ImportAccountZipHandler(rec, createBackupMultipartRequest(t, targetID, archive))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Import: expected status 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var result BackupImportResponse
+	if err := json.NewDecoder(rec.Body).Decode(&result); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if result.Transactions != 2 || result.Assets != 1 || result.Prices != 1 {
+		t.Errorf("Unexpected import result: %+v", result)
+	}
+
+	restored, err := db.GetTransactionsByAccount(targetID, "traderepublic", database.TransactionFilter{IncludeDeleted: true})
+	if err != nil {
+		t.Fatalf("Failed to get restored transactions: %v", err)
+	}
+	if len(restored) != 2 {
+		t.Fatalf("Expected 2 restored transactions, got %d", len(restored))
+	}
+	for _, transaction := range restored {
+		if transaction.AccountID != targetID {
+			t.Errorf("Transaction %s restored into account %s", transaction.ID, transaction.AccountID)
+		}
+		if transaction.ID == "backup-tx-2" && !transaction.Deleted {
+			t.Error("Expected the deleted flag to be restored")
+		}
+	}
+
+	// Importing again would overwrite the restored transactions
+	rec = httptest.NewRecorder()
+	handler.ImportAccountZipHandler(rec, createBackupMultipartRequest(t, targetID, archive))
+	if rec.Code != http.StatusConflict {
+		t.Errorf("Second import: expected status 409, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
@@ -2,11 +2,12 @@ package api
 
 import (
 	"bytes"
-	"crypto/rand"
+	"context"
 	"encoding/csv"
 	"encoding/json"
 	"fmt"
 	"io"
+	"math"
 	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
@@ -15,12 +16,8 @@ import (
 	"time"
 	"valhafin/internal/domain/models"
 	"valhafin/internal/repository/database"
-	encryptionsvc "valhafin/internal/service/encryption"
-	"valhafin/internal/service/fees"
-	"valhafin/internal/service/performance"
-	"valhafin/internal/service/price"
-	"valhafin/internal/service/sync"
 
+	"github.com/gorilla/mux"
 	"github.com/leanovate/gopter"
 	"github.com/leanovate/gopter/gen"
 	"github.com/leanovate/gopter/prop"
@@ -33,68 +30,7 @@ func stringPtr(s string) *string {
 
 // setupTestHandlerForCSV creates a test handler with dependencies for CSV tests
 func setupTestHandlerForCSV(t *testing.T) (*Handler, *database.DB) {
-	cfg := database.Config{
-		Host:     "localhost",
-		Port:     5432,
-		User:     "valhafin",
-		Password: "valhafin",
-		DBName:   "valhafin_test",
-		SSLMode:  "disable",
-	}
-
-	db, err := database.Connect(cfg)
-	if err != nil {
-		t.Skipf("Skipping test: database not available: %v", err)
-		return nil, nil
-	}
-
-	// Run migrations
-	if err := db.RunMigrations(); err != nil {
-		t.Fatalf("Failed to run migrations: %v", err)
-	}
-
-	// Create encryption service with test key
-	key := make([]byte, 32)
-	_, err = rand.Read(key)
-	if err != nil {
-		t.Fatalf("Failed to generate encryption key: %v", err)
-	}
-
-	encryptionService, err := encryptionsvc.NewEncryptionService(key)
-	if err != nil {
-		t.Fatalf("Failed to create encryption service: %v", err)
-	}
-
-	// Create scraper factory and sync service
-	scraperFactory := sync.NewScraperFactory()
-	syncService := sync.NewService(db, scraperFactory, encryptionService)
-
-	// Create price service
-	priceService := price.NewYahooFinanceService(db)
-
-	// Create performance service
-	performanceService := performance.NewPerformanceService(db, priceService)
-
-	// Create fees service
-	feesService := fees.NewFeesService(db)
-
-	handler := NewHandler(db, encryptionService, syncService, priceService, performanceService, feesService)
-	return handler, db
-}
-
-// cleanupTestDBForCSV cleans up test data
-func cleanupTestDBForCSV(t *testing.T, db *database.DB) {
-	if db == nil {
-		return
-	}
-
-	// Clean up all test data
-	_, _ = db.Exec("DELETE FROM transactions_traderepublic")
-	_, _ = db.Exec("DELETE FROM transactions_binance")
-	_, _ = db.Exec("DELETE FROM transactions_boursedirect")
-	_, _ = db.Exec("DELETE FROM asset_prices")
-	_, _ = db.Exec("DELETE FROM assets")
-	_, _ = db.Exec("DELETE FROM accounts")
+	return setupTestHandler(t)
 }
 
 // createTestAccount creates a test account and returns its ID
@@ -189,7 +125,6 @@ func TestProperty20_CSVParsingAndValidation(t *testing.T) {
 	if handler == nil {
 		return
 	}
-	defer cleanupTestDBForCSV(t, db)
 	defer db.Close()
 
 	parameters := gopter.DefaultTestParameters()
@@ -346,7 +281,6 @@ func TestProperty21_CSVImportWithDeduplication(t *testing.T) {
 	if handler == nil {
 		return
 	}
-	defer cleanupTestDBForCSV(t, db)
 	defer db.Close()
 
 	parameters := gopter.DefaultTestParameters()
@@ -545,3 +479,543 @@ func TestProperty21_CSVImportWithDeduplication(t *testing.T) {
 
 	properties.TestingRun(t)
 }
+
+// TestParseDecimal tests parsing of numbers written with either decimal convention
+func TestParseDecimal(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    float64
+		wantErr bool
+	}{
+		{"1234.56", 1234.56, false},
+		{"1234,56", 1234.56, false},
+		{"1,234.56", 1234.56, false},
+		{"1.234,56", 1234.56, false},
+		{"1,234,567", 1234567, false},
+		{"1.234.567", 1234567, false},
+		{"1 234,56", 1234.56, false},
+		{"1 234,56", 1234.56, false},
+		{"1'234.56", 1234.56, false},
+		{"-1.234,56", -1234.56, false},
+		{"-42", -42, false},
+		// Ambiguous: a single separator is read as the decimal separator
+		{"1,234", 1.234, false},
+		{"1.234", 1.234, false},
+		{"0,5", 0.5, false},
+		{"", 0, true},
+		{"abc", 0, true},
+		{"1,2,3", 0, true},
+		{"1.234.56", 0, true},
+		{"1,23.456,78", 0, true},
+		{"1.234,56.78", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseDecimal(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseDecimal(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("parseDecimal(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestParseCSV_DecimalComma tests that European-formatted rows import and only unparseable rows fail
+func TestParseCSV_DecimalComma(t *testing.T) {
+	handler := &Handler{}
+	csvContent := "timestamp,isin,amount_value,fees,quantity\n" +
+		"2024-01-15T10:00:00Z,US0378331005,\"1.234,56\",1,\"2,5\"\n" +
+		"2024-01-16T10:00:00Z,US0378331005,\"1,234.56\",1,3\n" +
+		"2024-01-17T10:00:00Z,US0378331005,12x,1,1\n"
+
+	transactions, errs := handler.parseCSV(strings.NewReader(csvContent), "account-1")
+
+	if len(transactions) != 2 {
+		t.Fatalf("expected 2 parsed transactions, got %d (errors: %v)", len(transactions), errs)
+	}
+	if transactions[0].AmountValue != 1234.56 || transactions[0].Quantity != 2.5 {
+		t.Errorf("row 2 parsed as amount=%v quantity=%v", transactions[0].AmountValue, transactions[0].Quantity)
+	}
+	if transactions[1].AmountValue != 1234.56 {
+		t.Errorf("row 3 parsed as amount=%v", transactions[1].AmountValue)
+	}
+	if len(errs) != 1 || !strings.HasPrefix(errs[0], "Row 4:") {
+		t.Errorf("expected a single Row 4 error, got %v", errs)
+	}
+}
+
+// TestParseCSV_AccountDecimalStyle tests that the account decimal style reads "1,234"
+// deterministically and stores the fees as plain numbers
+func TestParseCSV_AccountDecimalStyle(t *testing.T) {
+	handler := &Handler{}
+	csvContent := "timestamp,isin,amount_value,fees,taxes\n" +
+		"2024-01-15T10:00:00Z,US0378331005,\"1,234\",\"1,50 €\",\"0,30\"\n"
+
+	tests := []struct {
+		style  string
+		amount float64
+		fees   string
+		taxes  string
+	}{
+		{models.DecimalStyleComma, 1.234, "1.5", "0.3"},
+		{models.DecimalStyleAuto, 1.234, "1,50 €", "0,30"},
+	}
+	for _, tt := range tests {
+		transactions, errs, _ := handler.parseCSVWithOptions(strings.NewReader(csvContent), "account-1", csvImportOptions{DecimalStyle: tt.style})
+		if len(transactions) != 1 {
+			t.Fatalf("%s: expected 1 transaction, got %d (errors: %v)", tt.style, len(transactions), errs)
+		}
+		tx := transactions[0]
+		if tx.AmountValue != tt.amount || tx.Fees != tt.fees || tx.Taxes != tt.taxes {
+			t.Errorf("%s: parsed amount=%v fees=%q taxes=%q", tt.style, tx.AmountValue, tx.Fees, tx.Taxes)
+		}
+	}
+
+	// With the dot style "1,234" is a thousands group, and "1,50 €" is not a number
+	transactions, errs, _ := handler.parseCSVWithOptions(strings.NewReader(csvContent), "account-1", csvImportOptions{DecimalStyle: models.DecimalStyleDot})
+	if len(transactions) != 0 || len(errs) != 1 || !strings.Contains(errs[0], "invalid fees") {
+		t.Errorf("expected the fees to be rejected with the dot style, got %v (errors: %v)", transactions, errs)
+	}
+	usContent := "timestamp,isin,amount_value,fees\n2024-01-15T10:00:00Z,US0378331005,\"1,234\",\"1,234.50\"\n"
+	transactions, errs, _ = handler.parseCSVWithOptions(strings.NewReader(usContent), "account-1", csvImportOptions{DecimalStyle: models.DecimalStyleDot})
+	if len(transactions) != 1 || transactions[0].AmountValue != 1234 || transactions[0].Fees != "1234.5" {
+		t.Errorf("expected 1234 and fees 1234.5 with the dot style, got %+v (errors: %v)", transactions, errs)
+	}
+}
+
+// TestImportCSVHandler_DecimalStyleFeesRoundTrip tests that the fees imported for a comma
+// account are read back with the same values by the fees calculation
+func TestImportCSVHandler_DecimalStyleFeesRoundTrip(t *testing.T) {
+	handler, db := setupTestHandlerForCSV(t)
+	if handler == nil {
+		return
+	}
+	defer db.Close()
+
+	account := &models.Account{Name: "Comma Account", Platform: "boursedirect", Credentials: "encrypted_test_credentials", DecimalStyle: models.DecimalStyleComma}
+	if err := db.CreateAccount(account); err != nil {
+		t.Fatalf("Failed to create account: %v", err)
+	}
+
+	csvContent := "timestamp,isin,amount_value,fees,taxes,quantity,title,transaction_type\n" +
+		"2024-01-15T10:00:00Z,US0378331005,\"-1.234,50\",\"1,50 €\",\"0,30\",1,Apple,buy\n" +
+		"2024-01-16T10:00:00Z,US0378331005,-100,\"1.234,50\",,1,Apple,buy\n" +
+		"2024-01-17T10:00:00Z,US0378331005,-100,\"1,234\",,1,Apple,buy\n"
+	req, err := createCSVMultipartRequest(account.ID, csvContent)
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	rec := httptest.NewRecorder()
+	handler.ImportCSVHandler(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	metrics, err := handler.FeesService.CalculateAccountFeesContext(context.Background(), account.ID, "", "", false)
+	if err != nil {
+		t.Fatalf("Failed to calculate fees: %v", err)
+	}
+	if math.Abs(metrics.TotalFees-1237.234) > 0.0001 || math.Abs(metrics.Breakdown.Taxes-0.3) > 0.0001 {
+		t.Errorf("expected fees 1.5 + 1234.5 + 1.234 and taxes 0.3, got fees %v and taxes %v", metrics.TotalFees, metrics.Breakdown.Taxes)
+	}
+}
+
+func TestImportJSONHandler_Deduplication(t *testing.T) {
+	handler, db := setupTestHandlerForCSV(t)
+	if handler == nil {
+		return
+	}
+	defer db.Close()
+
+	accountID := createTestAccount(t, db, "traderepublic")
+
+	payload := `[
+		{"id": "json-1", "timestamp": "2024-01-15T10:30:00Z", "amount_currency": "EUR", "amount_value": -100, "transaction_type": "buy"},
+		{"id": "json-2", "timestamp": "2024-01-16T10:30:00Z", "amount_currency": "EUR", "amount_value": 5, "transaction_type": "dividend"},
+		{"id": "json-2", "timestamp": "2024-01-16T10:30:00Z", "amount_currency": "EUR", "amount_value": 5, "transaction_type": "dividend"},
+		{"id": "json-3", "timestamp": "not-a-date", "amount_currency": "EUR"}
+	]`
+
+	importJSON := func() ImportSummary {
+		req := httptest.NewRequest("POST", "/api/accounts/"+accountID+"/transactions/import-json", strings.NewReader(payload))
+		req = mux.SetURLVars(req, map[string]string{"id": accountID})
+		rec := httptest.NewRecorder()
+
+		handler.ImportJSONHandler(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+
+		var summary ImportSummary
+		if err := json.NewDecoder(rec.Body).Decode(&summary); err != nil {
+			t.Fatalf("Failed to decode summary: %v", err)
+		}
+		return summary
+	}
+
+	first := importJSON()
+	if first.Imported != 2 || first.Ignored != 1 || first.Errors != 1 {
+		t.Errorf("First import: expected 2 imported, 1 ignored, 1 error, got %+v", first)
+	}
+
+	second := importJSON()
+	if second.Imported != 0 || second.Ignored != 3 {
+		t.Errorf("Second import: expected 0 imported, 3 ignored, got %+v", second)
+	}
+}
+
+// TestParseCSV_AggregateFills tests that partial fills of the same order are merged only on demand
+func TestParseCSV_AggregateFills(t *testing.T) {
+	handler := &Handler{}
+	csvContent := "id,timestamp,isin,amount_value,fees,quantity,transaction_type\n" +
+		// Three partial fills of the same buy order
+		"f1,2024-01-15T10:00:00Z,US0378331005,-100,1,1,buy\n" +
+		"f2,2024-01-15T10:00:02Z,US0378331005,-200,0.5,2,buy\n" +
+		"f3,2024-01-15T10:00:04Z,US0378331005,-50.5,0,0.5,buy\n" +
+		// Same ISIN but outside the window: separate order
+		"f4,2024-01-15T11:00:00Z,US0378331005,-100,1,1,buy\n" +
+		// Same timestamp but different type or ISIN: never merged
+		"f5,2024-01-15T10:00:01Z,US0378331005,90,1,1,sell\n" +
+		"f6,2024-01-15T10:00:01Z,IE00B4L5Y983,-80,1,1,buy\n" +
+		// Dividends are not fills
+		"f7,2024-01-16T10:00:00Z,US0378331005,2,0,0,dividend\n" +
+		"f8,2024-01-16T10:00:00Z,US0378331005,3,0,0,dividend\n"
+
+	raw, errs := handler.parseCSV(strings.NewReader(csvContent), "account-1")
+	if len(errs) != 0 || len(raw) != 8 {
+		t.Fatalf("raw import should keep every row, got %d transactions (errors: %v)", len(raw), errs)
+	}
+
+	opts := csvImportOptions{AggregateFills: true, FillWindow: defaultFillWindow}
+	aggregated, errs, _ := handler.parseCSVWithOptions(strings.NewReader(csvContent), "account-1", opts)
+	if len(errs) != 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if len(aggregated) != 6 {
+		t.Fatalf("expected 6 transactions after aggregation, got %d", len(aggregated))
+	}
+
+	order := aggregated[0]
+	if order.ID != "f1" || order.Timestamp != "2024-01-15T10:00:00Z" {
+		t.Errorf("merged order should keep the first fill, got id=%s timestamp=%s", order.ID, order.Timestamp)
+	}
+	if order.Quantity != 3.5 || order.AmountValue != -350.5 || order.Fees != "1.5" {
+		t.Errorf("merged order: quantity=%v amount=%v fees=%s, want 3.5, -350.5, 1.5", order.Quantity, order.AmountValue, order.Fees)
+	}
+
+	ids := make([]string, 0, len(aggregated))
+	for _, tx := range aggregated {
+		ids = append(ids, tx.ID)
+	}
+	if strings.Join(ids, ",") != "f1,f4,f5,f6,f7,f8" {
+		t.Errorf("unexpected transactions after aggregation: %v", ids)
+	}
+}
+
+// TestParseCSVImportOptions_AggregateFills tests the aggregate_fills and fill_window form values
+func TestParseCSVImportOptions_AggregateFills(t *testing.T) {
+	req := httptest.NewRequest("POST", "/api/transactions/import?aggregate_fills=true&fill_window=10s", nil)
+	opts, err := parseCSVImportOptions(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !opts.AggregateFills || opts.FillWindow != 10*time.Second {
+		t.Errorf("got AggregateFills=%v FillWindow=%v", opts.AggregateFills, opts.FillWindow)
+	}
+
+	req = httptest.NewRequest("POST", "/api/transactions/import", nil)
+	opts, err = parseCSVImportOptions(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if opts.AggregateFills {
+		t.Error("aggregation must be disabled by default")
+	}
+
+	req = httptest.NewRequest("POST", "/api/transactions/import?aggregate_fills=maybe", nil)
+	if _, err := parseCSVImportOptions(req); err == nil {
+		t.Error("expected error for invalid aggregate_fills")
+	}
+}
+
+// TestParseCSV_ImportProfile tests that a saved profile maps broker columns, dates and decimals
+func TestParseCSV_ImportProfile(t *testing.T) {
+	handler := &Handler{}
+	profile := &models.ImportProfile{
+		Name: "broker-fr",
+		Columns: models.ColumnMapping{
+			"Date":      "timestamp",
+			"Code ISIN": "isin",
+			"Montant":   "amount_value",
+			"Quantité":  "quantity",
+			"Frais":     "fees",
+		},
+		DateFormat:   "02/01/2006",
+		DecimalStyle: models.DecimalStyleComma,
+	}
+	if err := profile.Validate(); err != nil {
+		t.Fatalf("invalid profile: %v", err)
+	}
+
+	csvContent := "Date,Code ISIN,Montant,Quantité,Frais,Commentaire\n" +
+		"15/01/2024,US0378331005,\"-1.234,56\",\"2,5\",\"1,50\",achat\n" +
+		"2024-01-16,US0378331005,\"-10,00\",1,0,format de date invalide\n"
+
+	opts := csvImportOptions{Profile: profile}
+	transactions, errs, _ := handler.parseCSVWithOptions(strings.NewReader(csvContent), "account-1", opts)
+
+	if len(transactions) != 1 {
+		t.Fatalf("expected 1 parsed transaction, got %d (errors: %v)", len(transactions), errs)
+	}
+	tx := transactions[0]
+	if tx.Timestamp != "2024-01-15T00:00:00Z" {
+		t.Errorf("expected timestamp 2024-01-15T00:00:00Z, got %s", tx.Timestamp)
+	}
+	// Fees are stored as a plain number, like with the decimal_style of the native format
+	if tx.AmountValue != -1234.56 || tx.Quantity != 2.5 || tx.Fees != "1.5" {
+		t.Errorf("parsed amount=%v quantity=%v fees=%s, want -1234.56, 2.5, 1.5", tx.AmountValue, tx.Quantity, tx.Fees)
+	}
+	if len(errs) != 1 || !strings.HasPrefix(errs[0], "Row 3:") {
+		t.Errorf("expected a single Row 3 error, got %v", errs)
+	}
+
+	// Without the profile the broker columns are not recognized
+	if _, errs := handler.parseCSV(strings.NewReader(csvContent), "account-1"); len(errs) == 0 {
+		t.Error("expected missing column errors without a profile")
+	}
+}
+
+func TestParseCSV_DefaultCurrency(t *testing.T) {
+	handler := &Handler{}
+
+	csvContent := "timestamp,isin,amount_value,fees,amount_currency\n" +
+		"2024-01-15T10:00:00Z,US0378331005,-100,1,\n" +
+		"2024-01-16T10:00:00Z,US0378331005,-50,1,EUR\n"
+
+	opts := csvImportOptions{Currency: "USD"}
+	transactions, errs, _ := handler.parseCSVWithOptions(strings.NewReader(csvContent), "account-1", opts)
+	if len(errs) != 0 || len(transactions) != 2 {
+		t.Fatalf("expected 2 transactions, got %d (errors: %v)", len(transactions), errs)
+	}
+	if transactions[0].AmountCurrency != "USD" {
+		t.Errorf("expected account currency USD for a row without currency, got %s", transactions[0].AmountCurrency)
+	}
+	if transactions[1].AmountCurrency != "EUR" {
+		t.Errorf("expected explicit currency EUR to be kept, got %s", transactions[1].AmountCurrency)
+	}
+
+	// Without an account currency rows default to EUR
+	transactions, _ = handler.parseCSV(strings.NewReader(csvContent), "account-1")
+	if len(transactions) == 0 || transactions[0].AmountCurrency != models.DefaultCurrency {
+		t.Errorf("expected default currency %s, got %+v", models.DefaultCurrency, transactions)
+	}
+}
+
+func TestParseCSV_ValidatesCurrency(t *testing.T) {
+	handler := &Handler{}
+
+	csvContent := "timestamp,isin,amount_value,fees,amount_currency\n" +
+		"2024-01-15T10:00:00Z,US0378331005,-100,1,USD\n" +
+		"2024-01-16T10:00:00Z,US0378331005,-50,1,US\n" +
+		"2024-01-17T10:00:00Z,US0378331005,-50,1,EURO\n" +
+		"2024-01-18T10:00:00Z,US0378331005,-50,1,\n" +
+		"2024-01-19T10:00:00Z,US0378331005,-50,1,gbp\n"
+
+	transactions, errs := handler.parseCSV(strings.NewReader(csvContent), "account-1")
+	if len(transactions) != 3 {
+		t.Fatalf("expected 3 valid transactions, got %d (errors: %v)", len(transactions), errs)
+	}
+	if len(errs) != 2 || !strings.HasPrefix(errs[0], "Row 3:") || !strings.Contains(errs[0], "ISO 4217") ||
+		!strings.HasPrefix(errs[1], "Row 4:") || !strings.Contains(errs[1], "EURO") {
+		t.Errorf("expected ISO 4217 errors for rows 3 and 4, got %v", errs)
+	}
+
+	expected := []string{"USD", models.DefaultCurrency, "GBP"}
+	for i, currency := range expected {
+		if transactions[i].AmountCurrency != currency {
+			t.Errorf("transaction %d: expected currency %s, got %s", i, currency, transactions[i].AmountCurrency)
+		}
+	}
+}
+
+func TestParseCSV_RejectsUnknownTransactionType(t *testing.T) {
+	handler := &Handler{}
+
+	csvContent := "timestamp,isin,amount_value,fees,transaction_type\n" +
+		"2024-01-15T10:00:00Z,US0378331005,-100,1,buy\n" +
+		"2024-01-16T10:00:00Z,US0378331005,-50,1,buys\n" +
+		"2024-01-17T10:00:00Z,US0378331005,-50,1,Kauf\n"
+
+	transactions, errs := handler.parseCSV(strings.NewReader(csvContent), "account-1")
+	if len(transactions) != 2 {
+		t.Fatalf("expected 2 valid transactions, got %d (errors: %v)", len(transactions), errs)
+	}
+	if len(errs) != 1 || !strings.HasPrefix(errs[0], "Row 3:") || !strings.Contains(errs[0], "buys") {
+		t.Errorf("expected a single Row 3 error about \"buys\", got %v", errs)
+	}
+	if transactions[1].TransactionType != models.TransactionTypeBuy {
+		t.Errorf("expected localized label to map to buy, got %q", transactions[1].TransactionType)
+	}
+}
+
+// TestParseCSV_ContentHashIDs tests the IDs synthesized for rows without an id column
+func TestParseCSV_ContentHashIDs(t *testing.T) {
+	handler := &Handler{}
+
+	csvContent := "timestamp,isin,amount_value,fees,quantity,title,transaction_type\n" +
+		// Two distinct buys of the same day and amount
+		"2024-01-15T00:00:00Z,US0378331005,-100,1,1,Apple,buy\n" +
+		"2024-01-15T00:00:00Z,US0378331005,-100,1,2,Apple,buy\n" +
+		// Two identical rows
+		"2024-01-16T00:00:00Z,US0378331005,2,0,0,Dividende,dividend\n" +
+		"2024-01-16T00:00:00Z,US0378331005,2,0,0,Dividende,dividend\n"
+
+	transactions, errs := handler.parseCSV(strings.NewReader(csvContent), "account-1")
+	if len(errs) != 0 || len(transactions) != 4 {
+		t.Fatalf("expected 4 transactions, got %d (errors: %v)", len(transactions), errs)
+	}
+
+	seen := make(map[string]bool)
+	for _, tx := range transactions {
+		if !strings.HasPrefix(tx.ID, csvIDPrefix) || seen[tx.ID] {
+			t.Errorf("expected distinct content IDs, got %q", tx.ID)
+		}
+		seen[tx.ID] = true
+	}
+	if transactions[3].ID != transactions[2].ID+"-2" {
+		t.Errorf("expected the identical row to be numbered, got %q and %q", transactions[2].ID, transactions[3].ID)
+	}
+
+	// Parsing the same file again gives the same IDs
+	again, _ := handler.parseCSV(strings.NewReader(csvContent), "account-1")
+	for i := range again {
+		if again[i].ID != transactions[i].ID {
+			t.Errorf("row %d: expected a stable ID %q, got %q", i+2, transactions[i].ID, again[i].ID)
+		}
+	}
+}
+
+// TestImportCSVHandler_SameDayTrades tests that distinct trades of the same day and amount are all imported
+func TestImportCSVHandler_SameDayTrades(t *testing.T) {
+	handler, db := setupTestHandlerForCSV(t)
+	if handler == nil {
+		return
+	}
+	defer db.Close()
+
+	accountID := createTestAccount(t, db, "traderepublic")
+	csvContent := "timestamp,isin,amount_value,fees,quantity,title,transaction_type\n" +
+		"2024-01-15T00:00:00Z,US0378331005,-100,1,1,Apple,buy\n" +
+		"2024-01-15T00:00:00Z,US0378331005,-100,1,2,Apple,buy\n"
+
+	importCSV := func() ImportSummary {
+		req, err := createCSVMultipartRequest(accountID, csvContent)
+		if err != nil {
+			t.Fatalf("Failed to create request: %v", err)
+		}
+		rec := httptest.NewRecorder()
+		handler.ImportCSVHandler(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+
+		var summary ImportSummary
+		if err := json.NewDecoder(rec.Body).Decode(&summary); err != nil {
+			t.Fatalf("Failed to decode summary: %v", err)
+		}
+		return summary
+	}
+
+	if first := importCSV(); first.Imported != 2 || first.Ignored != 0 {
+		t.Errorf("First import: expected both trades imported, got %+v", first)
+	}
+	if second := importCSV(); second.Imported != 0 || second.Ignored != 2 {
+		t.Errorf("Second import: expected both trades ignored, got %+v", second)
+	}
+}
+
+func TestParseCSV_MaxRows(t *testing.T) {
+	handler := &Handler{}
+	csvContent := "timestamp,isin,amount_value,fees\n" +
+		"2024-01-15T10:00:00Z,US0378331005,-100,0\n" +
+		"not a date,US0378331005,-100,0\n" +
+		"2024-01-17T10:00:00Z,US0378331005,-100,0\n"
+
+	// Rows in error count towards the limit
+	transactions, errs, err := handler.parseCSVWithOptions(strings.NewReader(csvContent), "account-1", csvImportOptions{MaxRows: 3})
+	if err != nil || len(transactions) != 2 || len(errs) != 1 {
+		t.Fatalf("expected 3 rows at the limit to be parsed, got %d transactions, errors %v, %v", len(transactions), errs, err)
+	}
+
+	if _, _, err := handler.parseCSVWithOptions(strings.NewReader(csvContent), "account-1", csvImportOptions{MaxRows: 2}); err != errCSVTooManyRows {
+		t.Errorf("expected errCSVTooManyRows past the limit, got %v", err)
+	}
+}
+
+func TestImportCSVHandler_RejectsOversizedBody(t *testing.T) {
+	maxSize, maxRows := CSVImportLimits()
+	defer SetCSVImportLimits(maxSize, maxRows)
+	SetCSVImportLimits(1024, maxRows)
+
+	req, err := createCSVMultipartRequest("account-1", strings.Repeat("x", 1024+csvImportFormOverhead))
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	w := httptest.NewRecorder()
+	(&Handler{}).ImportCSVHandler(w, req)
+
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected status 413, got %d: %s", w.Code, w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), "PAYLOAD_TOO_LARGE") {
+		t.Errorf("expected a PAYLOAD_TOO_LARGE error, got %s", w.Body.String())
+	}
+}
+
+func TestImportCSVHandler_FileSizeLimit(t *testing.T) {
+	handler, db := setupTestHandlerForCSV(t)
+	if handler == nil {
+		return
+	}
+	defer db.Close()
+
+	accountID := createTestAccount(t, db, "traderepublic")
+	maxSize, maxRows := CSVImportLimits()
+	defer SetCSVImportLimits(maxSize, maxRows)
+
+	header := "timestamp,isin,amount_value,fees\n"
+	row := "2024-01-15T10:00:00Z,US0378331005,-100,0\n"
+	atLimit := header + row + strings.Repeat(" ", 100-len(header)-len(row))
+	if len(atLimit) != 100 {
+		t.Fatalf("expected a 100-byte file, got %d", len(atLimit))
+	}
+
+	for _, tt := range []struct {
+		name     string
+		content  string
+		maxRows  int
+		expected int
+	}{
+		{"file at the size limit", atLimit, 10, http.StatusOK},
+		{"file one byte over the limit", atLimit + " ", 10, http.StatusRequestEntityTooLarge},
+		{"rows at the limit", header + row, 1, http.StatusOK},
+		{"rows over the limit", header + row + row, 1, http.StatusBadRequest},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			SetCSVImportLimits(100, tt.maxRows)
+			req, err := createCSVMultipartRequest(accountID, tt.content)
+			if err != nil {
+				t.Fatalf("Failed to create request: %v", err)
+			}
+			w := httptest.NewRecorder()
+			handler.ImportCSVHandler(w, req)
+			if w.Code != tt.expected {
+				t.Errorf("expected status %d, got %d: %s", tt.expected, w.Code, w.Body.String())
+			}
+		})
+	}
+}
@@ -2,13 +2,18 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"crypto/rand"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
+	"valhafin/internal/config"
 	"valhafin/internal/domain/models"
 	"valhafin/internal/repository/database"
 	encryptionsvc "valhafin/internal/service/encryption"
@@ -16,6 +21,7 @@ import (
 	"valhafin/internal/service/performance"
 	"valhafin/internal/service/price"
 	"valhafin/internal/service/sync"
+	"valhafin/internal/testutil"
 
 	"github.com/gorilla/mux"
 	"github.com/leanovate/gopter"
@@ -23,32 +29,12 @@ import (
 	"github.com/leanovate/gopter/prop"
 )
 
-// setupTestDB creates a test database connection
+// setupTestDB creates a test database isolated in its own schema
 func setupTestDB(t *testing.T) *database.DB {
-	cfg := database.Config{
-		Host:     "localhost",
-		Port:     5432,
-		User:     "valhafin",
-		Password: "valhafin",
-		DBName:   "valhafin_test",
-		SSLMode:  "disable",
-	}
-
-	db, err := database.Connect(cfg)
-	if err != nil {
-		t.Skipf("Skipping test: database not available: %v", err)
-		return nil
-	}
-
-	// Run migrations
-	if err := db.RunMigrations(); err != nil {
-		t.Fatalf("Failed to run migrations: %v", err)
-	}
-
-	return db
+	return testutil.NewTestDB(t)
 }
 
-// cleanupTestDB cleans up test data
+// cleanupTestDB empties the test schema, e.g. between property test iterations
 func cleanupTestDB(t *testing.T, db *database.DB) {
 	if db == nil {
 		return
@@ -87,7 +73,10 @@ func setupTestHandler(t *testing.T) (*Handler, *database.DB) {
 	syncService := sync.NewService(db, scraperFactory, encryptionService)
 
 	// Create price service
-	priceService := price.NewYahooFinanceService(db)
+	priceService, err := price.NewService(config.PriceConfig{Provider: config.PriceProviderYahoo}, db)
+	if err != nil {
+		t.Fatalf("Failed to create price service: %v", err)
+	}
 
 	// Create performance service
 	performanceService := performance.NewPerformanceService(db, priceService)
@@ -110,7 +99,6 @@ func TestProperty_AccountCreationWithEncryption(t *testing.T) {
 	if handler == nil {
 		return
 	}
-	defer cleanupTestDB(t, db)
 	defer db.Close()
 
 	parameters := gopter.DefaultTestParameters()
@@ -247,7 +235,6 @@ func TestProperty_InvalidCredentialsRejection(t *testing.T) {
 	if handler == nil {
 		return
 	}
-	defer cleanupTestDB(t, db)
 	defer db.Close()
 
 	parameters := gopter.DefaultTestParameters()
@@ -389,7 +376,6 @@ func TestProperty_CascadeDelete(t *testing.T) {
 	if handler == nil {
 		return
 	}
-	defer cleanupTestDB(t, db)
 	defer db.Close()
 
 	parameters := gopter.DefaultTestParameters()
@@ -488,3 +474,350 @@ func TestProperty_CascadeDelete(t *testing.T) {
 
 	properties.TestingRun(t)
 }
+
+func TestGetPlatformsHandler(t *testing.T) {
+	handler := &Handler{}
+
+	req := httptest.NewRequest("GET", "/api/platforms", nil)
+	w := httptest.NewRecorder()
+	handler.GetPlatformsHandler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+
+	var platforms []models.Platform
+	if err := json.NewDecoder(w.Body).Decode(&platforms); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(platforms) != 3 {
+		t.Fatalf("Expected 3 platforms, got %d", len(platforms))
+	}
+	for _, platform := range platforms {
+		if platform.ID == "traderepublic" && !platform.RequiresTwoFactor {
+			t.Error("Expected Trade Republic to require 2FA")
+		}
+		if platform.ID == "binance" && platform.AssetKey != models.AssetKeySymbol {
+			t.Errorf("Expected Binance assets to be keyed by symbol, got %s", platform.AssetKey)
+		}
+	}
+}
+
+func TestReclassifyTransactionsHandler_Validation(t *testing.T) {
+	handler := &Handler{}
+
+	for _, query := range []string{"", "?platform=kraken", "?platform=boursedirect", "?platform=traderepublic&dry_run=maybe"} {
+		req := httptest.NewRequest("POST", "/api/admin/reclassify"+query, nil)
+		w := httptest.NewRecorder()
+		handler.ReclassifyTransactionsHandler(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("query %q: expected status 400, got %d", query, w.Code)
+		}
+	}
+}
+
+func TestParsePerformanceRange(t *testing.T) {
+	req := httptest.NewRequest("GET", "/api/performance", nil)
+	dateRange, apiErr := parsePerformanceRange(req)
+	if apiErr != nil || dateRange.Period != "1y" || dateRange.IsCustom() {
+		t.Fatalf("expected the default 1y period, got %+v (%v)", dateRange, apiErr)
+	}
+
+	req = httptest.NewRequest("GET", "/api/performance?start_date=2024-01-01&end_date=2024-03-31", nil)
+	dateRange, apiErr = parsePerformanceRange(req)
+	if apiErr != nil || !dateRange.IsCustom() {
+		t.Fatalf("expected a custom range, got %+v (%v)", dateRange, apiErr)
+	}
+	if dateRange.Start.Format("2006-01-02 15:04:05") != "2024-01-01 00:00:00" || dateRange.End.Format("2006-01-02 15:04:05") != "2024-03-31 23:59:59" {
+		t.Errorf("expected an inclusive range, got %s - %s", dateRange.Start, dateRange.End)
+	}
+
+	tests := []struct {
+		query string
+		code  string
+	}{
+		{"period=5y", "INVALID_PERIOD"},
+		{"period=1y&start_date=2024-01-01", "INVALID_PERIOD"},
+		{"end_date=2024-03-31", "INVALID_DATE"},
+		{"start_date=01/01/2024", "INVALID_DATE"},
+		{"start_date=2024-03-31&end_date=2024-01-01", "INVALID_DATE_RANGE"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/api/performance?"+tt.query, nil)
+		if _, apiErr := parsePerformanceRange(req); apiErr == nil || apiErr.Code != tt.code {
+			t.Errorf("%s: expected %s, got %v", tt.query, tt.code, apiErr)
+		}
+
+		w := httptest.NewRecorder()
+		(&Handler{}).GetGlobalPerformanceHandler(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d", tt.query, w.Code)
+		}
+	}
+
+	// The fees endpoints share the date validation
+	req = httptest.NewRequest("GET", "/api/fees?start_date=2024-03-31&end_date=2024-01-01", nil)
+	w := httptest.NewRecorder()
+	(&Handler{}).GetGlobalFeesHandler(w, req)
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "INVALID_DATE_RANGE") {
+		t.Errorf("expected 400 INVALID_DATE_RANGE for fees, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+// tooManyPointsService fails like a time series exceeding the maximum number of points
+type tooManyPointsService struct {
+	performance.Service
+}
+
+func (tooManyPointsService) CalculateGlobalPerformanceContext(ctx context.Context, period string, opts performance.Options) (*performance.Performance, error) {
+	return nil, fmt.Errorf("%w: the %s interval exceeds the limit", performance.ErrTooManyPoints, opts.Interval)
+}
+
+func TestGetGlobalPerformanceHandler_Interval(t *testing.T) {
+	req := httptest.NewRequest("GET", "/api/performance?interval=hourly", nil)
+	w := httptest.NewRecorder()
+	(&Handler{}).GetGlobalPerformanceHandler(w, req)
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "VALIDATION_ERROR") {
+		t.Errorf("expected 400 VALIDATION_ERROR for an unknown interval, got %d: %s", w.Code, w.Body.String())
+	}
+
+	req = httptest.NewRequest("GET", "/api/performance?period=all&interval=daily", nil)
+	w = httptest.NewRecorder()
+	(&Handler{PerformanceService: tooManyPointsService{}}).GetGlobalPerformanceHandler(w, req)
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "daily interval exceeds") {
+		t.Errorf("expected 400 for too many points at the requested interval, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+// failingPerformanceService fails every calculation with err
+type failingPerformanceService struct {
+	performance.Service
+	err error
+}
+
+func (s failingPerformanceService) CalculateGlobalPerformanceContext(ctx context.Context, period string, opts performance.Options) (*performance.Performance, error) {
+	return nil, s.err
+}
+
+func (s failingPerformanceService) CalculateAssetHoldingsHistoryContext(ctx context.Context, isin string, period string, opts performance.Options) (*performance.HoldingsHistory, error) {
+	return nil, s.err
+}
+
+func (s failingPerformanceService) CalculateAssetLotsContext(ctx context.Context, isin string, opts performance.Options) (*performance.AssetLots, error) {
+	return nil, s.err
+}
+
+func (s failingPerformanceService) CalculateAccountCashFlowContext(ctx context.Context, accountID string, opts performance.Options) (*performance.CashFlowStatement, error) {
+	return nil, s.err
+}
+
+// serveFailingPerformance runs handle with a performance service failing with err and returns
+// the status and error code of the response
+func serveFailingPerformance(t *testing.T, handle func(*Handler) http.HandlerFunc, vars map[string]string, err error) (int, string) {
+	t.Helper()
+
+	req := mux.SetURLVars(httptest.NewRequest("GET", "/", nil), vars)
+	w := httptest.NewRecorder()
+	handle(&Handler{PerformanceService: failingPerformanceService{err: err}})(w, req)
+
+	var response ErrorResponse
+	if decodeErr := json.NewDecoder(w.Body).Decode(&response); decodeErr != nil {
+		t.Fatalf("Failed to decode error response: %v", decodeErr)
+	}
+	return w.Code, response.Error.Code
+}
+
+func TestWritePerformanceError(t *testing.T) {
+	global := func(h *Handler) http.HandlerFunc { return h.GetGlobalPerformanceHandler }
+
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantCode   string
+	}{
+		{"too many points", fmt.Errorf("%w: daily", performance.ErrTooManyPoints), http.StatusBadRequest, ErrValidation.Code},
+		{"account deleted meanwhile", fmt.Errorf("failed to get account: %w", sql.ErrNoRows), http.StatusNotFound, ErrNotFound.Code},
+		// A message mentioning "not found" is not a missing resource
+		{"database failure", errors.New("relation not found"), http.StatusInternalServerError, ErrDatabase.Code},
+	}
+	for _, tt := range tests {
+		status, code := serveFailingPerformance(t, global, nil, tt.err)
+		if status != tt.wantStatus || code != tt.wantCode {
+			t.Errorf("%s: got %d %s, want %d %s", tt.name, status, code, tt.wantStatus, tt.wantCode)
+		}
+	}
+}
+
+func TestGetAssetHoldingsHistoryHandler_Errors(t *testing.T) {
+	history := func(h *Handler) http.HandlerFunc { return h.GetAssetHoldingsHistoryHandler }
+	notFound := fmt.Errorf("failed to get asset: %w", sql.ErrNoRows)
+
+	tests := []struct {
+		name       string
+		isin       string
+		err        error
+		wantStatus int
+		wantCode   string
+	}{
+		{"missing ISIN", "", nil, http.StatusBadRequest, ErrValidation.Code},
+		{"unknown asset", "US0378331005", notFound, http.StatusNotFound, ErrNotFound.Code},
+		{"database failure", "US0378331005", errors.New("connection reset"), http.StatusInternalServerError, ErrDatabase.Code},
+	}
+	for _, tt := range tests {
+		status, code := serveFailingPerformance(t, history, map[string]string{"isin": tt.isin}, tt.err)
+		if status != tt.wantStatus || code != tt.wantCode {
+			t.Errorf("%s: got %d %s, want %d %s", tt.name, status, code, tt.wantStatus, tt.wantCode)
+		}
+	}
+}
+
+func TestGetAssetLotsHandler_Errors(t *testing.T) {
+	lots := func(h *Handler) http.HandlerFunc { return h.GetAssetLotsHandler }
+	notFound := fmt.Errorf("failed to get asset: %w", sql.ErrNoRows)
+
+	tests := []struct {
+		name       string
+		isin       string
+		err        error
+		wantStatus int
+		wantCode   string
+	}{
+		{"missing ISIN", "", nil, http.StatusBadRequest, ErrValidation.Code},
+		{"unknown asset", "US0378331005", notFound, http.StatusNotFound, ErrNotFound.Code},
+		{"database failure", "US0378331005", errors.New("connection reset"), http.StatusInternalServerError, ErrDatabase.Code},
+	}
+	for _, tt := range tests {
+		status, code := serveFailingPerformance(t, lots, map[string]string{"isin": tt.isin}, tt.err)
+		if status != tt.wantStatus || code != tt.wantCode {
+			t.Errorf("%s: got %d %s, want %d %s", tt.name, status, code, tt.wantStatus, tt.wantCode)
+		}
+	}
+}
+
+func TestGetAccountCashFlowHandler_Errors(t *testing.T) {
+	cashFlow := func(h *Handler) http.HandlerFunc { return h.GetAccountCashFlowHandler }
+	notFound := fmt.Errorf("failed to get account: %w", sql.ErrNoRows)
+
+	tests := []struct {
+		name       string
+		accountID  string
+		err        error
+		wantStatus int
+		wantCode   string
+	}{
+		{"missing account ID", "", nil, http.StatusBadRequest, ErrValidation.Code},
+		{"unknown account", "00000000-0000-0000-0000-000000000000", notFound, http.StatusNotFound, ErrNotFound.Code},
+		{"database failure", "00000000-0000-0000-0000-000000000000", errors.New("connection reset"), http.StatusInternalServerError, ErrDatabase.Code},
+	}
+	for _, tt := range tests {
+		status, code := serveFailingPerformance(t, cashFlow, map[string]string{"id": tt.accountID}, tt.err)
+		if status != tt.wantStatus || code != tt.wantCode {
+			t.Errorf("%s: got %d %s, want %d %s", tt.name, status, code, tt.wantStatus, tt.wantCode)
+		}
+	}
+}
+
+// seriesPerformanceService returns a global performance with a single time series point
+type seriesPerformanceService struct {
+	performance.Service
+}
+
+func (seriesPerformanceService) CalculateGlobalPerformanceContext(ctx context.Context, period string, opts performance.Options) (*performance.Performance, error) {
+	return &performance.Performance{TimeSeries: []performance.PerformancePoint{{Date: time.Now()}}}, nil
+}
+
+func TestGetGlobalPerformanceHandler_BenchmarkError(t *testing.T) {
+	// Without Yahoo Finance, a symbol benchmark cannot be fetched
+	req := httptest.NewRequest("GET", "/api/performance?benchmark=%5EGSPC", nil)
+	w := httptest.NewRecorder()
+	(&Handler{PerformanceService: seriesPerformanceService{}}).GetGlobalPerformanceHandler(w, req)
+
+	var response ErrorResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if w.Code != ErrBenchmark.Status || response.Error.Code != ErrBenchmark.Code {
+		t.Errorf("expected %d %s, got %d %s", ErrBenchmark.Status, ErrBenchmark.Code, w.Code, response.Error.Code)
+	}
+}
+
+func TestGetAccountSessionHandler_Integration(t *testing.T) {
+	handler, db := setupTestHandler(t)
+	if handler == nil {
+		return
+	}
+	defer db.Close()
+
+	accountID := createTestAccount(t, db, "traderepublic")
+
+	getSession := func(id string) (int, AccountSessionResponse) {
+		req := httptest.NewRequest("GET", "/api/accounts/"+id+"/session", nil)
+		req = mux.SetURLVars(req, map[string]string{"id": id})
+		w := httptest.NewRecorder()
+		handler.GetAccountSessionHandler(w, req)
+		var response AccountSessionResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return w.Code, response
+	}
+
+	if code, response := getSession(accountID); code != http.StatusOK || response.Reusable || response.ExpiresAt != nil {
+		t.Errorf("expected no session, got %d %+v", code, response)
+	}
+
+	req := httptest.NewRequest("POST", "/api/accounts/"+accountID+"/sync/complete", nil)
+	handler.saveAccountSession(req, accountID, "session-token")
+	if code, response := getSession(accountID); code != http.StatusOK || !response.Reusable || response.ExpiresAt == nil {
+		t.Errorf("expected a reusable session, got %d %+v", code, response)
+	}
+
+	session, err := db.GetAccountSession(req.Context(), accountID)
+	if err != nil || session.Token == "session-token" {
+		t.Errorf("expected the session token to be stored encrypted, got %+v, %v", session, err)
+	}
+
+	if code, _ := getSession("00000000-0000-0000-0000-000000000000"); code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown account, got %d", code)
+	}
+}
+
+func TestParseAsOf(t *testing.T) {
+	req := httptest.NewRequest("GET", "/api/performance", nil)
+	if asOf, apiErr := parseAsOf(req); asOf != nil || apiErr != nil {
+		t.Fatalf("expected no as_of date, got %v (%v)", asOf, apiErr)
+	}
+
+	req = httptest.NewRequest("GET", "/api/performance?as_of=2024-03-31", nil)
+	asOf, apiErr := parseAsOf(req)
+	if apiErr != nil || asOf == nil || asOf.Format("2006-01-02 15:04:05") != "2024-03-31 23:59:59" {
+		t.Fatalf("expected the end of 2024-03-31, got %v (%v)", asOf, apiErr)
+	}
+
+	req = httptest.NewRequest("GET", "/api/performance?as_of="+time.Now().Format("2006-01-02"), nil)
+	if asOf, apiErr := parseAsOf(req); apiErr != nil || asOf != nil {
+		t.Errorf("expected today to be valued like without as_of, got %v (%v)", asOf, apiErr)
+	}
+
+	for _, query := range []string{
+		"as_of=31/03/2024",
+		"as_of=" + time.Now().AddDate(0, 0, 2).Format("2006-01-02"),
+		"as_of=2024-03-31&start_date=2024-01-01",
+	} {
+		req := httptest.NewRequest("GET", "/api/performance?"+query, nil)
+		if _, apiErr := parseAsOf(req); apiErr == nil || apiErr.Code != "INVALID_DATE" {
+			t.Errorf("%s: expected INVALID_DATE, got %v", query, apiErr)
+		}
+
+		for name, handle := range map[string]http.HandlerFunc{
+			"performance": (&Handler{}).GetGlobalPerformanceHandler,
+			"assets":      (&Handler{}).GetAssetsHandler,
+			"positions":   (&Handler{}).GetAccountPositionsHandler,
+		} {
+			w := httptest.NewRecorder()
+			handle(w, req)
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("%s %s: expected status 400, got %d", name, query, w.Code)
+			}
+		}
+	}
+}
@@ -2,9 +2,12 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 	"valhafin/internal/domain/models"
@@ -529,3 +532,887 @@ func TestGetAccountTransactionsHandler_Integration(t *testing.T) {
 		t.Errorf("Expected total 2, got %d", response.Total)
 	}
 }
+
+func TestNewTransactionResponse_PaginationLinks(t *testing.T) {
+	tests := []struct {
+		name      string
+		page      int
+		total     int
+		wantPrev  *int
+		wantNext  *int
+		wantLinks []string
+	}{
+		{"first page", 1, 120, nil, intPtr(2), []string{`rel="first"`, `rel="next"`, `rel="last"`}},
+		{"middle page", 2, 120, intPtr(1), intPtr(3), []string{`rel="first"`, `rel="prev"`, `rel="next"`, `rel="last"`}},
+		{"last page", 3, 120, intPtr(2), nil, []string{`rel="first"`, `rel="prev"`, `rel="last"`}},
+		{"single page", 1, 10, nil, nil, []string{`rel="first"`, `rel="last"`}},
+		{"beyond last page", 7, 120, intPtr(3), nil, []string{`rel="prev"`}},
+		{"no results", 1, 0, nil, nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", fmt.Sprintf("/api/transactions?type=buy&page=%d&limit=50", tt.page), nil)
+			rec := httptest.NewRecorder()
+			filter := database.TransactionFilter{Page: tt.page, Limit: 50}
+
+			response := newTransactionResponse(rec, req, []models.Transaction{}, tt.total, filter)
+
+			if !equalIntPtr(response.PrevPage, tt.wantPrev) {
+				t.Errorf("PrevPage = %v, want %v", derefInt(response.PrevPage), derefInt(tt.wantPrev))
+			}
+			if !equalIntPtr(response.NextPage, tt.wantNext) {
+				t.Errorf("NextPage = %v, want %v", derefInt(response.NextPage), derefInt(tt.wantNext))
+			}
+
+			link := rec.Header().Get("Link")
+			if tt.wantLinks == nil && link != "" {
+				t.Errorf("Expected no Link header, got %q", link)
+			}
+			for _, rel := range tt.wantLinks {
+				if !strings.Contains(link, rel) {
+					t.Errorf("Link header %q should contain %s", link, rel)
+				}
+			}
+			// Links keep the current filters
+			if link != "" && !strings.Contains(link, "type=buy") {
+				t.Errorf("Link header should keep filters, got %q", link)
+			}
+		})
+	}
+}
+
+func TestTransactionResponse_NullPagesAtBoundaries(t *testing.T) {
+	data, err := json.Marshal(TransactionResponse{Page: 1, Limit: 50})
+	if err != nil {
+		t.Fatalf("Failed to marshal response: %v", err)
+	}
+	if !strings.Contains(string(data), `"next_page":null`) || !strings.Contains(string(data), `"prev_page":null`) {
+		t.Errorf("Expected null next_page and prev_page, got %s", data)
+	}
+}
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func equalIntPtr(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func derefInt(i *int) interface{} {
+	if i == nil {
+		return nil
+	}
+	return *i
+}
+
+func TestPaginateTransactions_PagesCoverAllRows(t *testing.T) {
+	var transactions []models.Transaction
+	for i := 0; i < 7; i++ {
+		transactions = append(transactions, models.Transaction{ID: fmt.Sprintf("tx-%d", i)})
+	}
+
+	seen := make(map[string]bool)
+	for page := 1; page <= 4; page++ {
+		rows := paginateTransactions(transactions, database.TransactionFilter{Page: page, Limit: 3})
+		for _, tx := range rows {
+			if seen[tx.ID] {
+				t.Errorf("transaction %s returned on several pages", tx.ID)
+			}
+			seen[tx.ID] = true
+		}
+		if page == 4 && len(rows) != 0 {
+			t.Errorf("page past the end should be empty, got %d rows", len(rows))
+		}
+	}
+	if len(seen) != len(transactions) {
+		t.Errorf("paging returned %d transactions, want %d", len(seen), len(transactions))
+	}
+}
+
+func TestSortTransactions_MatchesDatabaseOrder(t *testing.T) {
+	h := &Handler{}
+	transactions := []models.Transaction{
+		{ID: "b", Timestamp: "2024-01-02T10:00:00Z", AmountValue: 10},
+		{ID: "c", Timestamp: "2024-01-01T10:00:00Z", AmountValue: 30},
+		{ID: "a", Timestamp: "2024-01-02T10:00:00Z", AmountValue: 20},
+	}
+
+	ids := func() string {
+		var out []string
+		for _, tx := range transactions {
+			out = append(out, tx.ID)
+		}
+		return strings.Join(out, ",")
+	}
+
+	tests := []struct {
+		sortBy, sortOrder, want string
+	}{
+		{"", "", "a,b,c"}, // default: newest first, ties by ID
+		{"timestamp", "asc", "c,a,b"},
+		{"timestamp", "desc", "a,b,c"},
+		{"amount", "asc", "b,a,c"},
+		{"amount", "", "c,a,b"},
+	}
+
+	for _, tt := range tests {
+		h.sortTransactions(transactions, tt.sortBy, tt.sortOrder)
+		if got := ids(); got != tt.want {
+			t.Errorf("sortTransactions(%q, %q) = %s, want %s", tt.sortBy, tt.sortOrder, got, tt.want)
+		}
+	}
+}
+
+// TestGetAllTransactionsHandler_TotalMatchesPagedRows pages through transactions stored on two
+// platforms and checks that Total is exactly the number of rows returned across all pages
+func TestGetAllTransactionsHandler_TotalMatchesPagedRows(t *testing.T) {
+	handler, db := setupTestHandler(t)
+	if handler == nil {
+		return
+	}
+	defer db.Close()
+
+	isin := "TESTPAGE0001"
+	if _, err := db.Exec(`
+		INSERT INTO assets (isin, name, symbol, type, currency, last_updated)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (isin) DO NOTHING
+	`, isin, "Test Paging Asset", "TPA", "stock", "EUR", time.Now()); err != nil {
+		t.Fatalf("Failed to create asset: %v", err)
+	}
+
+	platforms := map[string]int{"traderepublic": 4, "binance": 3}
+	expected := 0
+	for platform, count := range platforms {
+		account := &models.Account{Name: "Test Paging " + platform, Platform: platform, Credentials: "encrypted"}
+		if err := db.CreateAccount(account); err != nil {
+			t.Fatalf("Failed to create account: %v", err)
+		}
+
+		for i := 0; i < count; i++ {
+			tx := models.Transaction{
+				ID:              fmt.Sprintf("tx-page-%s-%d", platform, i),
+				AccountID:       account.ID,
+				Timestamp:       time.Date(2024, 1, i+1, 10, 0, 0, 0, time.UTC).Format(time.RFC3339),
+				Title:           "Paging test",
+				AmountValue:     -10,
+				AmountCurrency:  "EUR",
+				TransactionType: "buy",
+				ISIN:            stringPtr(isin),
+				Quantity:        1,
+			}
+			if err := db.CreateTransaction(&tx, platform); err != nil {
+				t.Fatalf("Failed to create transaction: %v", err)
+			}
+			expected++
+		}
+
+		// Excluded by the listing filters: must not be counted either
+		failed := models.Transaction{
+			ID:              fmt.Sprintf("tx-page-%s-failed", platform),
+			AccountID:       account.ID,
+			Timestamp:       time.Date(2024, 2, 1, 10, 0, 0, 0, time.UTC).Format(time.RFC3339),
+			Title:           "Paging test",
+			Subtitle:        "Échec du plan d'épargne",
+			AmountValue:     -10,
+			AmountCurrency:  "EUR",
+			TransactionType: "buy",
+			ISIN:            stringPtr(isin),
+			Quantity:        1,
+		}
+		if err := db.CreateTransaction(&failed, platform); err != nil {
+			t.Fatalf("Failed to create transaction: %v", err)
+		}
+	}
+
+	seen := make(map[string]bool)
+	total := -1
+	for page := 1; page <= 10; page++ {
+		req := httptest.NewRequest("GET", fmt.Sprintf("/api/transactions?asset=%s&limit=3&page=%d", isin, page), nil)
+		w := httptest.NewRecorder()
+		handler.GetAllTransactionsHandler(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("page %d: expected status 200, got %d: %s", page, w.Code, w.Body.String())
+		}
+
+		var response TransactionResponse
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+
+		if total == -1 {
+			total = response.Total
+		} else if response.Total != total {
+			t.Errorf("page %d: total changed from %d to %d", page, total, response.Total)
+		}
+
+		for _, tx := range response.Transactions {
+			if seen[tx.ID] {
+				t.Errorf("transaction %s returned on several pages", tx.ID)
+			}
+			seen[tx.ID] = true
+		}
+
+		if response.NextPage == nil {
+			break
+		}
+	}
+
+	if total != expected {
+		t.Errorf("Total = %d, want %d", total, expected)
+	}
+	if len(seen) != total {
+		t.Errorf("paging returned %d transactions, Total is %d", len(seen), total)
+	}
+}
+
+func TestGetAllTransactionsHandler_AccountIDsFilter(t *testing.T) {
+	handler, db := setupTestHandler(t)
+	if handler == nil {
+		return
+	}
+	defer db.Close()
+
+	isin := "TESTACCT0001"
+	if _, err := db.Exec(`
+		INSERT INTO assets (isin, name, symbol, type, currency, last_updated)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (isin) DO NOTHING
+	`, isin, "Test Account Filter Asset", "TAF", "stock", "EUR", time.Now()); err != nil {
+		t.Fatalf("Failed to create asset: %v", err)
+	}
+
+	// Two accounts share the Trade Republic table, the third one is on Binance
+	platforms := []string{"traderepublic", "traderepublic", "binance"}
+	accountIDs := make([]string, len(platforms))
+	for i, platform := range platforms {
+		account := &models.Account{Name: fmt.Sprintf("Test Filter %d", i), Platform: platform, Credentials: "encrypted"}
+		if err := db.CreateAccount(account); err != nil {
+			t.Fatalf("Failed to create account: %v", err)
+		}
+		accountIDs[i] = account.ID
+
+		for j := 0; j < 2; j++ {
+			tx := models.Transaction{
+				ID:              fmt.Sprintf("tx-filter-%d-%d", i, j),
+				AccountID:       account.ID,
+				Timestamp:       time.Date(2024, 1, j+1, 10, 0, 0, 0, time.UTC).Format(time.RFC3339),
+				Title:           "Account filter test",
+				AmountValue:     -10,
+				AmountCurrency:  "EUR",
+				TransactionType: "buy",
+				ISIN:            stringPtr(isin),
+				Quantity:        1,
+			}
+			if err := db.CreateTransaction(&tx, platform); err != nil {
+				t.Fatalf("Failed to create transaction: %v", err)
+			}
+		}
+	}
+
+	// Select the first Trade Republic account and the Binance account
+	req := httptest.NewRequest("GET", fmt.Sprintf("/api/transactions?asset=%s&account_ids=%s,%s", isin, accountIDs[0], accountIDs[2]), nil)
+	w := httptest.NewRecorder()
+	handler.GetAllTransactionsHandler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var response TransactionResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	if response.Total != 4 || len(response.Transactions) != 4 {
+		t.Errorf("expected 4 transactions, got total=%d rows=%d", response.Total, len(response.Transactions))
+	}
+	for _, tx := range response.Transactions {
+		if tx.AccountID == accountIDs[1] {
+			t.Errorf("transaction %s belongs to an account that was not selected", tx.ID)
+		}
+	}
+
+	// Unknown accounts are reported
+	req = httptest.NewRequest("GET", "/api/transactions?account_ids="+accountIDs[0]+",unknown-account", nil)
+	w = httptest.NewRecorder()
+	handler.GetAllTransactionsHandler(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status 404 for an unknown account, got %d", w.Code)
+	}
+}
+
+func TestParseTransactionFilters_ValidatesType(t *testing.T) {
+	handler := &Handler{}
+
+	req := httptest.NewRequest("GET", "/api/transactions?type=%20Dividend%20", nil)
+	filter, err := handler.parseTransactionFilters(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if filter.TransactionType != models.TransactionTypeDividend {
+		t.Errorf("expected normalized type dividend, got %q", filter.TransactionType)
+	}
+
+	req = httptest.NewRequest("GET", "/api/transactions?type=buys", nil)
+	w := httptest.NewRecorder()
+	handler.GetAllTransactionsHandler(w, req)
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "VALIDATION_ERROR") {
+		t.Errorf("expected 400 VALIDATION_ERROR for an unknown type, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestGetTransactionsStreamHandler_ValidatesParameters(t *testing.T) {
+	handler := &Handler{}
+
+	for _, query := range []string{"cursor=garbage", "since=yesterday", "limit=0", "limit=abc"} {
+		req := httptest.NewRequest("GET", "/api/transactions/stream?"+query, nil)
+		w := httptest.NewRecorder()
+		handler.GetTransactionsStreamHandler(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d: %s", query, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestGetTransactionsStreamHandler_Integration(t *testing.T) {
+	handler, db := setupTestHandler(t)
+	if handler == nil {
+		return
+	}
+	defer db.Close()
+
+	account := &models.Account{Name: "Test Stream", Platform: "traderepublic", Credentials: "encrypted"}
+	if err := db.CreateAccount(account); err != nil {
+		t.Fatalf("Failed to create account: %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		tx := models.Transaction{
+			ID:             fmt.Sprintf("tx-stream-%d", i),
+			AccountID:      account.ID,
+			Timestamp:      time.Date(2024, 1, i+1, 10, 0, 0, 0, time.UTC).Format(time.RFC3339),
+			Title:          "Stream test",
+			AmountValue:    -10,
+			AmountCurrency: "EUR",
+		}
+		if err := db.CreateTransaction(&tx, "traderepublic"); err != nil {
+			t.Fatalf("Failed to create transaction: %v", err)
+		}
+	}
+
+	fetch := func(query string) TransactionStreamResponse {
+		t.Helper()
+		req := httptest.NewRequest("GET", "/api/transactions/stream?account_ids="+account.ID+"&"+query, nil)
+		w := httptest.NewRecorder()
+		handler.GetTransactionsStreamHandler(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var response TransactionStreamResponse
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		return response
+	}
+
+	first := fetch("limit=2")
+	if len(first.Transactions) != 2 || !first.HasMore || first.NextCursor == "" {
+		t.Fatalf("expected a full first page with more to come, got %+v", first)
+	}
+
+	second := fetch("limit=2&cursor=" + first.NextCursor)
+	if len(second.Transactions) != 1 || second.HasMore {
+		t.Fatalf("expected the last transaction, got %+v", second)
+	}
+
+	// Nothing new: the cursor is kept so that polling can continue from it
+	idle := fetch("cursor=" + second.NextCursor)
+	if len(idle.Transactions) != 0 || idle.NextCursor != second.NextCursor {
+		t.Fatalf("expected an empty page with the same cursor, got %+v", idle)
+	}
+
+	// A modified transaction comes back in the stream
+	if _, err := db.Exec(`UPDATE transactions_traderepublic SET title = 'Edited' WHERE id = 'tx-stream-0'`); err != nil {
+		t.Fatalf("Failed to update transaction: %v", err)
+	}
+	updated := fetch("cursor=" + second.NextCursor)
+	if len(updated.Transactions) != 1 || updated.Transactions[0].ID != "tx-stream-0" || updated.Transactions[0].Title != "Edited" {
+		t.Errorf("expected the edited transaction, got %+v", updated.Transactions)
+	}
+}
+
+func TestUpdateTransactionHandler_KeepsOmittedFields(t *testing.T) {
+	handler, db := setupTestHandler(t)
+	if handler == nil {
+		return
+	}
+	defer db.Close()
+
+	account := &models.Account{Name: "Test Update", Platform: "traderepublic", Credentials: "encrypted"}
+	if err := db.CreateAccount(account); err != nil {
+		t.Fatalf("Failed to create account: %v", err)
+	}
+	isin := "US0378331005"
+	tx := models.Transaction{
+		ID:              "tx-update-1",
+		AccountID:       account.ID,
+		Timestamp:       time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC).Format(time.RFC3339),
+		Title:           "Apple",
+		AmountValue:     -1850.5,
+		AmountCurrency:  "EUR",
+		ISIN:            &isin,
+		Quantity:        10,
+		TransactionType: "buy",
+	}
+	if err := db.CreateTransaction(&tx, "traderepublic"); err != nil {
+		t.Fatalf("Failed to create transaction: %v", err)
+	}
+
+	update := func(id, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("PUT", "/api/transactions/"+id, strings.NewReader(body))
+		req = mux.SetURLVars(req, map[string]string{"id": id})
+		w := httptest.NewRecorder()
+		handler.UpdateTransactionHandler(w, req)
+		return w
+	}
+
+	// Only the title is sent, without account_id: the position must not be zeroed
+	if w := update(tx.ID, `{"title": "Apple Inc."}`); w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	stored, err := db.GetTransactionByID(tx.ID, "traderepublic")
+	if err != nil {
+		t.Fatalf("Failed to get transaction: %v", err)
+	}
+	if stored.Title != "Apple Inc." || stored.Quantity != 10 || stored.AmountValue != -1850.5 ||
+		stored.TransactionType != "buy" || stored.ISIN == nil || *stored.ISIN != isin {
+		t.Errorf("expected only the title to change, got %+v", stored)
+	}
+
+	// An explicit zero is written
+	if w := update(tx.ID, `{"account_id": "`+account.ID+`", "quantity": 0}`); w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if stored, _ := db.GetTransactionByID(tx.ID, "traderepublic"); stored.Quantity != 0 || stored.Title != "Apple Inc." {
+		t.Errorf("expected the quantity to be zeroed, got %+v", stored)
+	}
+
+	if w := update(tx.ID, `{"amount_currency": ""}`); w.Code != http.StatusBadRequest {
+		t.Errorf("expected an empty currency to be rejected, got %d", w.Code)
+	}
+	if w := update("missing", `{"title": "x"}`); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown transaction, got %d", w.Code)
+	}
+}
+
+func TestGetTransactionHandler_Integration(t *testing.T) {
+	handler, db := setupTestHandler(t)
+	if handler == nil {
+		return
+	}
+	defer db.Close()
+
+	account := &models.Account{Name: "Test Get", Platform: "boursedirect", Credentials: "encrypted"}
+	if err := db.CreateAccount(account); err != nil {
+		t.Fatalf("Failed to create account: %v", err)
+	}
+	other := &models.Account{Name: "Test Get Other", Platform: "boursedirect", Credentials: "encrypted"}
+	if err := db.CreateAccount(other); err != nil {
+		t.Fatalf("Failed to create account: %v", err)
+	}
+	isin := "FR0000120271"
+	tx := models.Transaction{
+		ID:              "tx-get-1",
+		AccountID:       account.ID,
+		Timestamp:       time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC).Format(time.RFC3339),
+		Title:           "TotalEnergies",
+		AmountValue:     -500,
+		AmountCurrency:  "EUR",
+		ISIN:            &isin,
+		Quantity:        8,
+		TransactionType: "buy",
+	}
+	if err := db.CreateTransaction(&tx, "boursedirect"); err != nil {
+		t.Fatalf("Failed to create transaction: %v", err)
+	}
+
+	get := func(id, query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/api/transactions/"+id+query, nil)
+		req = mux.SetURLVars(req, map[string]string{"id": id})
+		w := httptest.NewRecorder()
+		handler.GetTransactionHandler(w, req)
+		return w
+	}
+
+	// Found in the last platform table without account_id, and with the account of the transaction
+	for _, query := range []string{"", "?account_id=" + account.ID} {
+		w := get(tx.ID, query)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%q: expected status 200, got %d: %s", query, w.Code, w.Body.String())
+		}
+		var found models.Transaction
+		if err := json.NewDecoder(w.Body).Decode(&found); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if found.ID != tx.ID || found.AccountID != account.ID {
+			t.Errorf("%q: unexpected transaction %+v", query, found)
+		}
+	}
+
+	for _, query := range []string{"", "?account_id=" + other.ID} {
+		w := get("tx-get-missing", query)
+		if w.Code != http.StatusNotFound {
+			t.Errorf("%q: expected 404 for an unknown transaction, got %d", query, w.Code)
+		}
+	}
+	// The transaction of another account is not found
+	if w := get(tx.ID, "?account_id="+other.ID); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for the transaction of another account, got %d", w.Code)
+	}
+}
+
+func TestGetTransactionHandler_MissingID(t *testing.T) {
+	req := httptest.NewRequest("GET", "/api/transactions/", nil)
+	req = mux.SetURLVars(req, map[string]string{"id": ""})
+	w := httptest.NewRecorder()
+	(&Handler{}).GetTransactionHandler(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400, got %d", w.Code)
+	}
+	var errResp ErrorResponse
+	if err := json.NewDecoder(w.Body).Decode(&errResp); err != nil || errResp.Error.Code != ErrInvalidRequest.Code {
+		t.Errorf("expected %s, got %+v (%v)", ErrInvalidRequest.Code, errResp, err)
+	}
+}
+
+func TestUpdateTransactionHandler_InvalidAnnotations(t *testing.T) {
+	handler := &Handler{}
+	for _, body := range []string{
+		`{"tags": ["` + strings.Repeat("x", models.MaxTagLength+1) + `"]}`,
+		`{"note": "` + strings.Repeat("x", models.MaxNoteLength+1) + `"}`,
+	} {
+		req := httptest.NewRequest("PUT", "/api/transactions/tx-1", strings.NewReader(body))
+		req = mux.SetURLVars(req, map[string]string{"id": "tx-1"})
+		w := httptest.NewRecorder()
+		handler.UpdateTransactionHandler(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("expected status 400, got %d", w.Code)
+		}
+	}
+}
+
+func TestTransactionTags_Integration(t *testing.T) {
+	handler, db := setupTestHandler(t)
+	if handler == nil {
+		return
+	}
+	defer db.Close()
+
+	account := &models.Account{Name: "Test Tags", Platform: "traderepublic", Credentials: "encrypted"}
+	if err := db.CreateAccount(account); err != nil {
+		t.Fatalf("Failed to create account: %v", err)
+	}
+	isin := "US0378331005"
+	for i := 0; i < 2; i++ {
+		tx := models.Transaction{
+			ID:              fmt.Sprintf("tx-tags-%d", i),
+			AccountID:       account.ID,
+			Timestamp:       time.Date(2024, 1, 15+i, 10, 0, 0, 0, time.UTC).Format(time.RFC3339),
+			Title:           "Apple",
+			AmountValue:     -100,
+			AmountCurrency:  "EUR",
+			ISIN:            &isin,
+			Quantity:        1,
+			TransactionType: "buy",
+		}
+		if err := db.CreateTransaction(&tx, "traderepublic"); err != nil {
+			t.Fatalf("Failed to create transaction: %v", err)
+		}
+	}
+
+	req := httptest.NewRequest("PUT", "/api/transactions/tx-tags-0", strings.NewReader(`{"tags": ["Rebalance", "tax-loss harvest"], "note": "Q1 rebalance"}`))
+	req = mux.SetURLVars(req, map[string]string{"id": "tx-tags-0"})
+	w := httptest.NewRecorder()
+	handler.UpdateTransactionHandler(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	stored, err := db.GetTransactionByID("tx-tags-0", "traderepublic")
+	if err != nil {
+		t.Fatalf("Failed to get transaction: %v", err)
+	}
+	if len(stored.Tags) != 2 || stored.Tags[0] != "rebalance" || stored.Note != "Q1 rebalance" || stored.AmountValue != -100 {
+		t.Errorf("expected the tags and note to be stored and the amount kept, got %+v", stored)
+	}
+
+	list := func(target string, serve http.HandlerFunc) TransactionResponse {
+		t.Helper()
+		req := httptest.NewRequest("GET", target, nil)
+		req = mux.SetURLVars(req, map[string]string{"id": account.ID})
+		w := httptest.NewRecorder()
+		serve(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected status 200, got %d: %s", target, w.Code, w.Body.String())
+		}
+		var response TransactionResponse
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		return response
+	}
+
+	for _, response := range []TransactionResponse{
+		list("/api/accounts/"+account.ID+"/transactions?tag=REBALANCE", handler.GetAccountTransactionsHandler),
+		list("/api/transactions?account_ids="+account.ID+"&tag=rebalance", handler.GetAllTransactionsHandler),
+	} {
+		if len(response.Transactions) != 1 || response.Transactions[0].ID != "tx-tags-0" || response.Transactions[0].Note != "Q1 rebalance" {
+			t.Errorf("expected only the tagged transaction, got %+v", response.Transactions)
+		}
+	}
+	if response := list("/api/accounts/"+account.ID+"/transactions", handler.GetAccountTransactionsHandler); len(response.Transactions) != 2 {
+		t.Errorf("expected both transactions without tag filter, got %d", len(response.Transactions))
+	}
+
+	// A later sync of the same transaction keeps the annotations
+	synced := *stored
+	synced.Tags, synced.Note = nil, ""
+	if _, err := db.UpsertTransactionsBatch([]models.Transaction{synced}, "traderepublic"); err != nil {
+		t.Fatalf("UpsertTransactionsBatch() error = %v", err)
+	}
+	if stored, _ := db.GetTransactionByID("tx-tags-0", "traderepublic"); len(stored.Tags) != 2 || stored.Note == "" {
+		t.Errorf("expected the sync to keep the annotations, got %+v", stored)
+	}
+}
+
+func TestGetAccountMonthlyTransactionsHandler_InvalidFill(t *testing.T) {
+	handler := &Handler{}
+	req := httptest.NewRequest("GET", "/api/accounts/acc-1/transactions/monthly?fill=gaps", nil)
+	req = mux.SetURLVars(req, map[string]string{"id": "acc-1"})
+	w := httptest.NewRecorder()
+	handler.GetAccountMonthlyTransactionsHandler(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400, got %d", w.Code)
+	}
+}
+
+func TestGetAccountMonthlyTransactionsHandler_Integration(t *testing.T) {
+	handler, db := setupTestHandler(t)
+	if handler == nil {
+		return
+	}
+	defer db.Close()
+
+	account := &models.Account{Name: "Test Monthly", Platform: "traderepublic", Credentials: "encrypted"}
+	if err := db.CreateAccount(account); err != nil {
+		t.Fatalf("Failed to create account: %v", err)
+	}
+
+	// January and April 2024 have activity, February and March have none
+	isin := "US0378331005"
+	for i, tx := range []models.Transaction{
+		{Timestamp: "2024-01-05T10:00:00Z", TransactionType: "deposit", AmountValue: 1000},
+		{Timestamp: "2024-01-10T10:00:00Z", TransactionType: "buy", AmountValue: -600, ISIN: &isin, Quantity: 3},
+		{Timestamp: "2024-01-31T23:30:00Z", TransactionType: "dividend", AmountValue: 5, ISIN: &isin},
+		{Timestamp: "2024-04-02T10:00:00Z", TransactionType: "sell", AmountValue: 250, ISIN: &isin, Quantity: 1},
+		{Timestamp: "2024-04-20T10:00:00Z", TransactionType: "withdrawal", AmountValue: -100},
+	} {
+		tx.ID = fmt.Sprintf("tx-monthly-%d", i)
+		tx.AccountID = account.ID
+		tx.Title = "Monthly test"
+		tx.AmountCurrency = "EUR"
+		if err := db.CreateTransaction(&tx, "traderepublic"); err != nil {
+			t.Fatalf("Failed to create transaction: %v", err)
+		}
+	}
+
+	fetch := func(query string) MonthlyTransactionsResponse {
+		t.Helper()
+		req := httptest.NewRequest("GET", "/api/accounts/"+account.ID+"/transactions/monthly?"+query, nil)
+		req = mux.SetURLVars(req, map[string]string{"id": account.ID})
+		w := httptest.NewRecorder()
+		handler.GetAccountMonthlyTransactionsHandler(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var response MonthlyTransactionsResponse
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		return response
+	}
+
+	gaps := fetch("")
+	if gaps.Fill != "none" || len(gaps.Months) != 2 {
+		t.Fatalf("expected the two active months only, got %+v", gaps)
+	}
+	if january := gaps.Months[0]; january.Month != "2024-01" || january.Count != 3 || math.Abs(january.NetCashFlow-400) > 0.01 {
+		t.Errorf("expected 3 transactions and a net flow of 400 in January, got %+v", january)
+	}
+	if april := gaps.Months[1]; april.Month != "2024-04" || april.Count != 2 || math.Abs(april.NetCashFlow-150) > 0.01 {
+		t.Errorf("expected 2 transactions and a net flow of 150 in April, got %+v", april)
+	}
+
+	zeros := fetch("fill=zero&start_date=2023-12-01&end_date=2024-05-31")
+	var months []string
+	for _, month := range zeros.Months {
+		months = append(months, month.Month)
+		if (month.Month == "2024-02" || month.Month == "2024-03") && month.Count != 0 {
+			t.Errorf("expected an empty month, got %+v", month)
+		}
+	}
+	if strings.Join(months, ",") != "2023-12,2024-01,2024-02,2024-03,2024-04,2024-05" {
+		t.Errorf("expected every month of the period, got %v", months)
+	}
+
+	if filtered := fetch("type=buy"); len(filtered.Months) != 1 || filtered.Months[0].Count != 1 {
+		t.Errorf("expected the type filter to apply, got %+v", filtered.Months)
+	}
+}
+
+func TestWantsCSV(t *testing.T) {
+	tests := map[string]bool{
+		"":                                  false,
+		"*/*":                               false,
+		"application/json":                  false,
+		"text/csv":                          true,
+		"text/csv; charset=utf-8":           true,
+		"application/json, text/csv;q=0.5":  false,
+		"text/csv, application/json;q=0.9":  true,
+		"text/csv;q=0, application/json":    false,
+		"text/html, text/csv, */*;q=0.8":    true,
+		"not a media type, text/csv;q=0.7":  true,
+		"application/json;q=0.2, text/csv ": true,
+	}
+	for accept, expected := range tests {
+		req := httptest.NewRequest("GET", "/api/transactions", nil)
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		if got := wantsCSV(req); got != expected {
+			t.Errorf("Accept %q: expected wantsCSV = %v, got %v", accept, expected, got)
+		}
+	}
+}
+
+func TestRespondTransactions_NegotiatesFormat(t *testing.T) {
+	response := TransactionResponse{
+		Transactions: []models.Transaction{
+			{
+				ID:              "tx-1",
+				AccountID:       "acc-1",
+				Timestamp:       "2024-01-01T10:00:00Z",
+				Title:           "Apple, Inc.",
+				ISIN:            stringPtr("US0378331005"),
+				TransactionType: "buy",
+				Quantity:        2,
+				AmountValue:     -300.5,
+				AmountCurrency:  "EUR",
+				Fees:            "1",
+			},
+			{
+				ID:              "tx-2",
+				AccountID:       "acc-1",
+				Timestamp:       "2024-01-02T10:00:00Z",
+				Title:           "Interest",
+				TransactionType: "interest",
+				AmountValue:     3.2,
+				AmountCurrency:  "EUR",
+			},
+		},
+		Total: 7, Page: 1, Limit: 2, TotalPages: 4,
+	}
+
+	// JSON by default
+	req := httptest.NewRequest("GET", "/api/accounts/acc-1/transactions", nil)
+	w := httptest.NewRecorder()
+	respondTransactions(w, req, response)
+	var decoded TransactionResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &decoded); err != nil || len(decoded.Transactions) != 2 {
+		t.Fatalf("expected a JSON response, got %q (%v)", w.Body.String(), err)
+	}
+	if !strings.HasPrefix(w.Header().Get("Content-Type"), "application/json") || w.Header().Get("Vary") != "Accept" {
+		t.Errorf("unexpected JSON headers %v", w.Header())
+	}
+
+	// CSV on request, with the standard column set
+	req = httptest.NewRequest("GET", "/api/accounts/acc-1/transactions", nil)
+	req.Header.Set("Accept", "text/csv")
+	w = httptest.NewRecorder()
+	respondTransactions(w, req, response)
+	if !strings.HasPrefix(w.Header().Get("Content-Type"), "text/csv") || w.Header().Get("X-Total-Count") != "7" {
+		t.Errorf("unexpected CSV headers %v", w.Header())
+	}
+	lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+	if len(lines) != 3 || lines[0] != strings.Join(transactionCSVColumns, ",") {
+		t.Fatalf("expected a header and 2 rows, got %q", w.Body.String())
+	}
+	if lines[1] != `tx-1,acc-1,2024-01-01T10:00:00Z,"Apple, Inc.",,US0378331005,buy,2,-300.5,EUR,1,` {
+		t.Errorf("unexpected CSV row %q", lines[1])
+	}
+
+	// The rows of securities can be imported back (the import requires an ISIN)
+	imported, errs := (&Handler{}).parseCSV(strings.NewReader(lines[0]+"\n"+lines[1]+"\n"), "acc-1")
+	if len(errs) != 0 || len(imported) != 1 || imported[0].ID != "tx-1" || imported[0].Quantity != 2 || imported[0].ExactAmount() != -300.5 {
+		t.Errorf("expected the CSV to be importable, got %+v (%v)", imported, errs)
+	}
+}
+
+// failingCSVWriter accepts limit bytes and then fails every write
+type failingCSVWriter struct {
+	limit  int
+	writes int
+}
+
+func (f *failingCSVWriter) Write(p []byte) (int, error) {
+	f.writes++
+	if len(p) > f.limit {
+		return 0, errors.New("connection reset")
+	}
+	f.limit -= len(p)
+	return len(p), nil
+}
+
+func TestWriteTransactionsCSV_StopsOnWriteError(t *testing.T) {
+	transactions := make([]models.Transaction, 2000)
+	for i := range transactions {
+		transactions[i] = models.Transaction{ID: fmt.Sprintf("tx-%d", i), AccountID: "acc-1", Status: "EXECUTED"}
+	}
+
+	writer := &failingCSVWriter{}
+	err := writeTransactionsCSV(writer, transactions)
+	if err == nil || !strings.Contains(err.Error(), "connection reset") {
+		t.Fatalf("Expected the write error to be returned, got %v", err)
+	}
+	if writer.writes != 1 {
+		t.Errorf("Expected the stream to stop after the failed write, got %d writes", writer.writes)
+	}
+
+	if err := writeTransactionsCSV(&failingCSVWriter{limit: 1 << 20}, transactions[:1]); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestMoveTransactionsHandler_Validation(t *testing.T) {
+	handler := &Handler{}
+	for _, body := range []string{
+		`not json`,
+		`{"transaction_ids": ["tx-1"], "from_account_id": "a", "to_account_id": ""}`,
+		`{"transaction_ids": ["tx-1"], "from_account_id": "a", "to_account_id": "a"}`,
+		`{"transaction_ids": [" "], "from_account_id": "a", "to_account_id": "b"}`,
+	} {
+		req := httptest.NewRequest("POST", "/api/transactions/move", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		handler.MoveTransactionsHandler(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("body %s: expected status 400, got %d", body, w.Code)
+		}
+	}
+}
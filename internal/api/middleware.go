@@ -2,9 +2,13 @@ package api
 
 import (
 	"encoding/json"
-	"log"
+	"fmt"
 	"net/http"
+	"strings"
 	"time"
+	"valhafin/internal/utils"
+
+	"github.com/gorilla/mux"
 )
 
 // CORSMiddleware handles Cross-Origin Resource Sharing
@@ -13,7 +17,8 @@ func CORSMiddleware(next http.Handler) http.Handler {
 		// Allow requests from frontend during development
 		w.Header().Set("Access-Control-Allow-Origin", "*")
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, "+utils.RequestIDHeader)
+		w.Header().Set("Access-Control-Expose-Headers", utils.RequestIDHeader)
 		w.Header().Set("Access-Control-Max-Age", "3600")
 
 		// Handle preflight requests
@@ -26,7 +31,22 @@ func CORSMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// RequestIDMiddleware propagates the X-Request-ID of the request, or generates one, in the
+// request context and echoes it in the response, so that the logs of a request can be correlated
+func RequestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(utils.RequestIDHeader)
+		if !utils.IsValidRequestID(id) {
+			id = utils.NewRequestID()
+		}
+
+		w.Header().Set(utils.RequestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(utils.WithRequestID(r.Context(), id)))
+	})
+}
+
 // LoggingMiddleware logs all HTTP requests
+// Sensitive query parameters (PIN, password, 2FA code...) are redacted
 func LoggingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
@@ -36,10 +56,11 @@ func LoggingMiddleware(next http.Handler) http.Handler {
 
 		next.ServeHTTP(wrapped, r)
 
-		log.Printf(
+		utils.Logf(
+			r.Context(),
 			"%s %s %d %s",
 			r.Method,
-			r.RequestURI,
+			utils.RedactURI(r.RequestURI),
 			wrapped.statusCode,
 			time.Since(start),
 		)
@@ -68,12 +89,36 @@ func (rw *responseWriter) Write(b []byte) (int, error) {
 	return rw.ResponseWriter.Write(b)
 }
 
+// PerformanceCacheMiddleware drops the cached performance results once a write request
+// (sync, import, transaction update, price refresh...) has succeeded: the results of the
+// account for the /accounts/{id} routes, every result for the others
+func (h *Handler) PerformanceCacheMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if h.PerformanceCache == nil || r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		wrapped := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+		next.ServeHTTP(wrapped, r)
+		if wrapped.statusCode >= http.StatusBadRequest {
+			return
+		}
+
+		if accountID := mux.Vars(r)["id"]; accountID != "" && strings.HasPrefix(r.URL.Path, "/api/accounts/") {
+			h.PerformanceCache.Invalidate(accountID)
+		} else {
+			h.PerformanceCache.InvalidateAll()
+		}
+	})
+}
+
 // RecoveryMiddleware handles panics and returns a 500 error
 func RecoveryMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		defer func() {
 			if err := recover(); err != nil {
-				log.Printf("PANIC: %v", err)
+				utils.Logf(r.Context(), "PANIC: %s", utils.RedactText(fmt.Sprint(err)))
 
 				// Check if headers have already been written
 				if rw, ok := w.(*responseWriter); ok && rw.written {
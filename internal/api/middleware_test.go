@@ -13,6 +13,7 @@ import (
 	"strings"
 	"testing"
 	"time"
+	"valhafin/internal/config"
 	"valhafin/internal/domain/models"
 	"valhafin/internal/repository/database"
 	encryptionsvc "valhafin/internal/service/encryption"
@@ -20,6 +21,7 @@ import (
 	"valhafin/internal/service/performance"
 	"valhafin/internal/service/price"
 	"valhafin/internal/service/sync"
+	"valhafin/internal/utils"
 
 	"github.com/gorilla/mux"
 	"github.com/leanovate/gopter"
@@ -38,7 +40,6 @@ func TestProperty_APIInputValidation(t *testing.T) {
 	if handler == nil {
 		return
 	}
-	defer cleanupTestDB(t, db)
 	defer db.Close()
 
 	parameters := gopter.DefaultTestParameters()
@@ -182,7 +183,6 @@ func TestProperty_RequestAndErrorLogging(t *testing.T) {
 	if handler == nil {
 		return
 	}
-	defer cleanupTestDB(t, db)
 	defer db.Close()
 
 	parameters := gopter.DefaultTestParameters()
@@ -302,7 +302,6 @@ func TestProperty_HealthCheck(t *testing.T) {
 	if handler == nil {
 		return
 	}
-	defer cleanupTestDB(t, db)
 	defer db.Close()
 
 	parameters := gopter.DefaultTestParameters()
@@ -388,13 +387,9 @@ func TestProperty_HealthCheck(t *testing.T) {
 // Test that health check returns 503 when database is down
 func TestHealthCheck_DatabaseDown(t *testing.T) {
 	// Create a handler with a closed database connection
-	cfg := database.Config{
-		Host:     "localhost",
-		Port:     5432,
-		User:     "valhafin",
-		Password: "valhafin",
-		DBName:   "valhafin_test",
-		SSLMode:  "disable",
+	cfg, err := database.TestConfig()
+	if err != nil {
+		t.Fatalf("Invalid test database configuration: %v", err)
 	}
 
 	db, err := database.Connect(cfg)
@@ -421,7 +416,10 @@ func TestHealthCheck_DatabaseDown(t *testing.T) {
 	// Create services
 	scraperFactory := sync.NewScraperFactory()
 	syncService := sync.NewService(db, scraperFactory, encryptionService)
-	priceService := price.NewYahooFinanceService(db)
+	priceService, err := price.NewService(config.PriceConfig{Provider: config.PriceProviderYahoo}, db)
+	if err != nil {
+		t.Fatalf("Failed to create price service: %v", err)
+	}
 	performanceService := performance.NewPerformanceService(db, priceService)
 	feesService := fees.NewFeesService(db)
 
@@ -498,6 +496,44 @@ func TestRecoveryMiddleware_HandlesPanic(t *testing.T) {
 	}
 }
 
+// TestLoggingMiddleware_NeverLogsPIN sends a PIN in the query string and the body and checks
+// that neither the request log, a handler log of the body nor a panic message contains it
+func TestLoggingMiddleware_NeverLogsPIN(t *testing.T) {
+	var logBuf bytes.Buffer
+	log.SetOutput(&logBuf)
+	defer log.SetOutput(os.Stderr)
+
+	router := mux.NewRouter()
+	router.Use(LoggingMiddleware)
+	router.Use(RecoveryMiddleware)
+	router.HandleFunc("/api/accounts/{id}/sync/complete", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		log.Printf("DEBUG: request body %s", utils.RedactJSON(body))
+
+		var payload map[string]interface{}
+		_ = json.Unmarshal(body, &payload)
+		panic(fmt.Sprintf("unexpected payload %v", payload))
+	}).Methods("POST")
+
+	body := `{"process_id":"proc-1","code":"998877","credentials":{"phone_number":"+33612345678","pin":"4821"}}`
+	req := httptest.NewRequest("POST", "/api/accounts/acc-1/sync/complete?pin=4821&code=998877", strings.NewReader(body))
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	logOutput := logBuf.String()
+	if logOutput == "" {
+		t.Fatal("expected log output")
+	}
+	for _, secret := range []string{"4821", "998877"} {
+		if strings.Contains(logOutput, secret) {
+			t.Errorf("log output leaks %q:\n%s", secret, logOutput)
+		}
+	}
+	if !strings.Contains(logOutput, "/api/accounts/acc-1/sync/complete") || !strings.Contains(logOutput, "proc-1") {
+		t.Errorf("non sensitive values should still be logged:\n%s", logOutput)
+	}
+}
+
 // Test CORS middleware sets correct headers
 func TestCORSMiddleware_SetsHeaders(t *testing.T) {
 	// Create a simple handler
@@ -534,13 +570,54 @@ func TestCORSMiddleware_SetsHeaders(t *testing.T) {
 	}
 }
 
+func TestRequestIDMiddleware(t *testing.T) {
+	var logBuf bytes.Buffer
+	log.SetOutput(&logBuf)
+	defer log.SetOutput(os.Stderr)
+
+	router := mux.NewRouter()
+	router.Use(RequestIDMiddleware)
+	router.Use(LoggingMiddleware)
+	var seen string
+	router.HandleFunc("/api/accounts", func(w http.ResponseWriter, r *http.Request) {
+		seen = utils.RequestID(r.Context())
+		utils.Logf(r.Context(), "INFO: handler log")
+		w.WriteHeader(http.StatusOK)
+	})
+
+	// A valid client ID is propagated to the context, the logs and the response
+	req := httptest.NewRequest("GET", "/api/accounts", nil)
+	req.Header.Set(utils.RequestIDHeader, "client-trace-42")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if seen != "client-trace-42" || rr.Header().Get(utils.RequestIDHeader) != "client-trace-42" {
+		t.Errorf("expected the client request ID to be propagated, got %q in context and %q in response", seen, rr.Header().Get(utils.RequestIDHeader))
+	}
+	if strings.Count(logBuf.String(), "[request_id=client-trace-42]") != 2 {
+		t.Errorf("expected the handler and request logs to carry the request ID, got:\n%s", logBuf.String())
+	}
+
+	// Missing or unsafe IDs are replaced with a generated one
+	for _, header := range []string{"", "bad id\nINFO: forged"} {
+		req := httptest.NewRequest("GET", "/api/accounts", nil)
+		req.Header.Set(utils.RequestIDHeader, header)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		generated := rr.Header().Get(utils.RequestIDHeader)
+		if generated == "" || generated == header || seen != generated {
+			t.Errorf("header %q: expected a generated request ID, got %q (context %q)", header, generated, seen)
+		}
+	}
+}
+
 // Test that invalid date formats return 400
 func TestProperty_DateValidation(t *testing.T) {
 	handler, db := setupTestHandler(t)
 	if handler == nil {
 		return
 	}
-	defer cleanupTestDB(t, db)
 	defer db.Close()
 
 	// Create a test account
@@ -0,0 +1,231 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"valhafin/internal/docs"
+)
+
+// The OpenAPI 3 document is converted from the Swagger 2.0 document that swag generates
+// from the handler annotations (see docs/SWAGGER.md), so both stay in sync with the code
+var (
+	openAPIOnce     sync.Once
+	openAPIDocument map[string]interface{}
+	openAPIErr      error
+)
+
+// OpenAPIDocument returns the OpenAPI 3.0 description of the API
+func OpenAPIDocument() (map[string]interface{}, error) {
+	openAPIOnce.Do(func() {
+		var swagger map[string]interface{}
+		if err := json.Unmarshal([]byte(docs.SwaggerInfo.ReadDoc()), &swagger); err != nil {
+			openAPIErr = fmt.Errorf("failed to parse the swagger document: %w", err)
+			return
+		}
+		openAPIDocument = convertSwaggerToOpenAPI(swagger)
+	})
+	return openAPIDocument, openAPIErr
+}
+
+// convertSwaggerToOpenAPI converts a Swagger 2.0 document into an OpenAPI 3.0 document:
+// definitions become components, body and form parameters become request bodies and
+// response schemas are given a media type
+func convertSwaggerToOpenAPI(swagger map[string]interface{}) map[string]interface{} {
+	basePath, _ := swagger["basePath"].(string)
+	if basePath == "" {
+		basePath = "/"
+	}
+
+	document := map[string]interface{}{
+		"openapi": "3.0.3",
+		"info":    swagger["info"],
+		"servers": []interface{}{map[string]interface{}{"url": basePath}},
+	}
+	if tags, ok := swagger["tags"]; ok {
+		document["tags"] = tags
+	}
+
+	consumes := stringList(swagger["consumes"], "application/json")
+	produces := stringList(swagger["produces"], "application/json")
+
+	paths := make(map[string]interface{})
+	swaggerPaths, _ := swagger["paths"].(map[string]interface{})
+	for path, item := range swaggerPaths {
+		operations, _ := item.(map[string]interface{})
+		converted := make(map[string]interface{})
+		for method, operation := range operations {
+			if op, ok := operation.(map[string]interface{}); ok {
+				converted[method] = convertOperation(op, consumes, produces)
+			}
+		}
+		paths[path] = converted
+	}
+	document["paths"] = paths
+
+	schemas := make(map[string]interface{})
+	if definitions, ok := swagger["definitions"].(map[string]interface{}); ok {
+		for name, schema := range definitions {
+			schemas[name] = convertSchema(schema)
+		}
+	}
+	document["components"] = map[string]interface{}{"schemas": schemas}
+
+	return document
+}
+
+// convertOperation converts a Swagger 2.0 operation
+func convertOperation(op map[string]interface{}, consumes, produces []string) map[string]interface{} {
+	converted := make(map[string]interface{})
+	for _, key := range []string{"summary", "description", "tags", "operationId", "deprecated"} {
+		if value, ok := op[key]; ok {
+			converted[key] = value
+		}
+	}
+
+	consumes = stringList(op["consumes"], consumes...)
+	produces = stringList(op["produces"], produces...)
+
+	var parameters []interface{}
+	formSchema := map[string]interface{}{"type": "object", "properties": map[string]interface{}{}}
+	var formRequired []string
+	params, _ := op["parameters"].([]interface{})
+	for _, p := range params {
+		param, ok := p.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _ := param["name"].(string)
+		required, _ := param["required"].(bool)
+
+		switch param["in"] {
+		case "body":
+			body := map[string]interface{}{
+				"content": mediaTypes(consumes, convertSchema(param["schema"])),
+			}
+			if description, ok := param["description"]; ok {
+				body["description"] = description
+			}
+			if required {
+				body["required"] = true
+			}
+			converted["requestBody"] = body
+		case "formData":
+			property := parameterSchema(param)
+			if property["type"] == "file" {
+				property = map[string]interface{}{"type": "string", "format": "binary"}
+			}
+			if description, ok := param["description"]; ok {
+				property["description"] = description
+			}
+			formSchema["properties"].(map[string]interface{})[name] = property
+			if required {
+				formRequired = append(formRequired, name)
+			}
+		default:
+			parameter := map[string]interface{}{
+				"name":   name,
+				"in":     param["in"],
+				"schema": parameterSchema(param),
+			}
+			if description, ok := param["description"]; ok {
+				parameter["description"] = description
+			}
+			if required || param["in"] == "path" {
+				parameter["required"] = true
+			}
+			parameters = append(parameters, parameter)
+		}
+	}
+	if len(parameters) > 0 {
+		converted["parameters"] = parameters
+	}
+	if properties := formSchema["properties"].(map[string]interface{}); len(properties) > 0 {
+		if len(formRequired) > 0 {
+			sort.Strings(formRequired)
+			formSchema["required"] = formRequired
+		}
+		mediaType := "multipart/form-data"
+		if len(consumes) > 0 && consumes[0] == "application/x-www-form-urlencoded" {
+			mediaType = consumes[0]
+		}
+		converted["requestBody"] = map[string]interface{}{
+			"content": mediaTypes([]string{mediaType}, formSchema),
+		}
+	}
+
+	responses := make(map[string]interface{})
+	swaggerResponses, _ := op["responses"].(map[string]interface{})
+	for code, r := range swaggerResponses {
+		response, _ := r.(map[string]interface{})
+		description, _ := response["description"].(string)
+		convertedResponse := map[string]interface{}{"description": description}
+		if schema, ok := response["schema"]; ok {
+			convertedResponse["content"] = mediaTypes(produces, convertSchema(schema))
+		}
+		responses[code] = convertedResponse
+	}
+	converted["responses"] = responses
+
+	return converted
+}
+
+// parameterSchema builds the schema of a non-body Swagger 2.0 parameter from its inline fields
+func parameterSchema(param map[string]interface{}) map[string]interface{} {
+	schema := make(map[string]interface{})
+	for _, key := range []string{"type", "format", "items", "enum", "default", "minimum", "maximum"} {
+		if value, ok := param[key]; ok {
+			schema[key] = convertSchema(value)
+		}
+	}
+	return schema
+}
+
+// mediaTypes returns an OpenAPI content object giving schema to every media type
+func mediaTypes(types []string, schema interface{}) map[string]interface{} {
+	content := make(map[string]interface{}, len(types))
+	for _, mediaType := range types {
+		content[mediaType] = map[string]interface{}{"schema": schema}
+	}
+	return content
+}
+
+// convertSchema rewrites the definition references of a schema to component references
+func convertSchema(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		converted := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			if ref, ok := item.(string); ok && key == "$ref" {
+				converted[key] = strings.Replace(ref, "#/definitions/", "#/components/schemas/", 1)
+				continue
+			}
+			converted[key] = convertSchema(item)
+		}
+		return converted
+	case []interface{}:
+		converted := make([]interface{}, len(v))
+		for i, item := range v {
+			converted[i] = convertSchema(item)
+		}
+		return converted
+	}
+	return value
+}
+
+// stringList returns the strings of a JSON array, or fallback when it is missing or empty
+func stringList(value interface{}, fallback ...string) []string {
+	items, _ := value.([]interface{})
+	var list []string
+	for _, item := range items {
+		if s, ok := item.(string); ok {
+			list = append(list, s)
+		}
+	}
+	if len(list) == 0 {
+		return fallback
+	}
+	return list
+}
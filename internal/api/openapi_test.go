@@ -0,0 +1,101 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+// TestOpenAPIDocument_MatchesRoutes fails when a route and the generated documentation drift:
+// after adding or changing a handler, update its annotations and run
+// swag init --output internal/docs --parseDependency --parseInternal
+func TestOpenAPIDocument_MatchesRoutes(t *testing.T) {
+	document, err := OpenAPIDocument()
+	if err != nil {
+		t.Fatalf("Failed to build the OpenAPI document: %v", err)
+	}
+	paths := document["paths"].(map[string]interface{})
+
+	router, _ := SetupRoutes(nil, nil)
+	routed := make(map[string]bool)
+	router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		path, err := route.GetPathTemplate()
+		if err != nil || (path != "/health" && !strings.HasPrefix(path, "/api/")) {
+			return nil
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			return nil
+		}
+		for _, method := range methods {
+			method = strings.ToLower(method)
+			routed[method+" "+path] = true
+			if operations, ok := paths[path].(map[string]interface{}); !ok || operations[method] == nil {
+				t.Errorf("%s %s is not documented (add its annotations and run swag init)", strings.ToUpper(method), path)
+			}
+		}
+		return nil
+	})
+
+	for path, item := range paths {
+		for method := range item.(map[string]interface{}) {
+			if !routed[method+" "+path] {
+				t.Errorf("%s %s is documented but not routed (run swag init)", strings.ToUpper(method), path)
+			}
+		}
+	}
+}
+
+func TestOpenAPIDocument_Schemas(t *testing.T) {
+	document, err := OpenAPIDocument()
+	if err != nil {
+		t.Fatalf("Failed to build the OpenAPI document: %v", err)
+	}
+	if document["openapi"] != "3.0.3" {
+		t.Errorf("expected an OpenAPI 3 document, got %v", document["openapi"])
+	}
+
+	schemas := document["components"].(map[string]interface{})["schemas"].(map[string]interface{})
+	for _, name := range []string{"api.ErrorResponse", "api.TransactionResponse", "performance.Performance", "api.AssetPosition"} {
+		if schemas[name] == nil {
+			t.Errorf("expected the %s schema in the components", name)
+		}
+	}
+
+	body, _ := json.Marshal(document)
+	if strings.Contains(string(body), "#/definitions/") {
+		t.Error("expected every reference to point to the components")
+	}
+
+	// Body parameters become request bodies, form parameters a multipart request body
+	paths := document["paths"].(map[string]interface{})
+	createAccount := paths["/api/accounts"].(map[string]interface{})["post"].(map[string]interface{})
+	if createAccount["requestBody"] == nil {
+		t.Error("expected POST /api/accounts to have a request body")
+	}
+	importCSV := paths["/api/transactions/import"].(map[string]interface{})["post"].(map[string]interface{})
+	content, _ := importCSV["requestBody"].(map[string]interface{})["content"].(map[string]interface{})
+	if content["multipart/form-data"] == nil {
+		t.Errorf("expected a multipart request body for the CSV import, got %v", importCSV["requestBody"])
+	}
+}
+
+func TestOpenAPIHandler(t *testing.T) {
+	handler := &Handler{}
+
+	w := httptest.NewRecorder()
+	handler.OpenAPIHandler(w, httptest.NewRequest("GET", "/api/openapi.json", nil))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"openapi":"3.0.3"`) {
+		t.Errorf("expected the OpenAPI document, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	handler.APIDocsHandler(w, httptest.NewRequest("GET", "/api/docs", nil))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "/api/openapi.json") {
+		t.Errorf("expected the viewer page, got %d", w.Code)
+	}
+}
@@ -20,15 +20,17 @@ type Services struct {
 	PriceService       price.Service
 	PerformanceService performance.Service
 	FeesService        fees.Service
+	// PerformanceCache is the cache wrapping PerformanceService (nil when disabled)
+	PerformanceCache *performance.CachedService
 }
 
 // SetupRoutes configures all API routes and returns the router and services
 func SetupRoutes(db *database.DB, encryptionService *encryption.EncryptionService) (*mux.Router, *Services) {
-	return SetupRoutesWithVersion(db, encryptionService, "dev", time.Now())
+	return SetupRoutesWithVersion(db, encryptionService, price.NewYahooFinanceService(db), "dev", time.Now())
 }
 
-// SetupRoutesWithVersion configures all API routes with version and start time
-func SetupRoutesWithVersion(db *database.DB, encryptionService *encryption.EncryptionService, version string, startTime time.Time) (*mux.Router, *Services) {
+// SetupRoutesWithVersion configures all API routes with the given price service, version and start time
+func SetupRoutesWithVersion(db *database.DB, encryptionService *encryption.EncryptionService, priceService price.Service, version string, startTime time.Time) (*mux.Router, *Services) {
 	router := mux.NewRouter()
 
 	// Create scraper factory
@@ -37,11 +39,13 @@ func SetupRoutesWithVersion(db *database.DB, encryptionService *encryption.Encry
 	// Create sync service
 	syncService := sync.NewService(db, scraperFactory, encryptionService)
 
-	// Create price service (Yahoo Finance)
-	priceService := price.NewYahooFinanceService(db)
-
-	// Create performance service
-	performanceService := performance.NewPerformanceService(db, priceService)
+	// Create performance service, cached for a short time unless disabled
+	var performanceService performance.Service = performance.NewPerformanceService(db, priceService)
+	var performanceCache *performance.CachedService
+	if performanceCacheTTL > 0 {
+		performanceCache = performance.NewCachedService(performanceService, performanceCacheTTL)
+		performanceService = performanceCache
+	}
 
 	// Create fees service
 	feesService := fees.NewFeesService(db)
@@ -50,9 +54,12 @@ func SetupRoutesWithVersion(db *database.DB, encryptionService *encryption.Encry
 	handler := NewHandler(db, encryptionService, syncService, priceService, performanceService, feesService)
 	handler.Version = version
 	handler.StartTime = startTime
+	handler.PerformanceCache = performanceCache
 
 	// Apply middleware (CORS must be first to handle preflight requests)
+	// The request ID comes next so that every log of the request carries it
 	router.Use(CORSMiddleware)
+	router.Use(RequestIDMiddleware)
 	router.Use(RecoveryMiddleware)
 	router.Use(LoggingMiddleware)
 
@@ -61,12 +68,15 @@ func SetupRoutesWithVersion(db *database.DB, encryptionService *encryption.Encry
 
 	// Apply CORS middleware to API subrouter as well
 	api.Use(CORSMiddleware)
+	api.Use(handler.PerformanceCacheMiddleware)
 
 	// Health check
 	router.HandleFunc("/health", handler.HealthCheckHandler).Methods("GET")
 
 	// Swagger documentation
 	router.PathPrefix("/swagger/").Handler(httpSwagger.WrapHandler)
+	api.HandleFunc("/openapi.json", handler.OpenAPIHandler).Methods("GET")
+	api.HandleFunc("/docs", handler.APIDocsHandler).Methods("GET")
 
 	// Handle OPTIONS requests globally for CORS preflight
 	router.Methods("OPTIONS").HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -74,24 +84,45 @@ func SetupRoutesWithVersion(db *database.DB, encryptionService *encryption.Encry
 	})
 
 	// Account routes
+	api.HandleFunc("/platforms", handler.GetPlatformsHandler).Methods("GET")
 	api.HandleFunc("/accounts", handler.GetAccountsHandler).Methods("GET")
 	api.HandleFunc("/accounts", handler.CreateAccountHandler).Methods("POST")
 	api.HandleFunc("/accounts/{id}", handler.GetAccountHandler).Methods("GET")
 	api.HandleFunc("/accounts/{id}", handler.DeleteAccountHandler).Methods("DELETE")
+	api.HandleFunc("/accounts/{id}/rotate-credentials", handler.RotateCredentialsHandler).Methods("POST")
 	api.HandleFunc("/accounts/{id}/sync", handler.SyncAccountHandler).Methods("POST")
 	api.HandleFunc("/accounts/{id}/sync/init", handler.InitSyncHandler).Methods("POST")
 	api.HandleFunc("/accounts/{id}/sync/complete", handler.CompleteSyncHandler).Methods("POST")
+	api.HandleFunc("/accounts/{id}/session", handler.GetAccountSessionHandler).Methods("GET")
+	api.HandleFunc("/sync/all", handler.SyncAllAccountsHandler).Methods("POST")
+	api.HandleFunc("/accounts/{id}/export.zip", handler.ExportAccountZipHandler).Methods("GET")
+	api.HandleFunc("/import.zip", handler.ImportAccountZipHandler).Methods("POST")
 
 	// Transaction routes
 	api.HandleFunc("/accounts/{id}/transactions", handler.GetAccountTransactionsHandler).Methods("GET")
+	api.HandleFunc("/accounts/{id}/transactions/monthly", handler.GetAccountMonthlyTransactionsHandler).Methods("GET")
+	api.HandleFunc("/accounts/{id}/transactions/import-json", handler.ImportJSONHandler).Methods("POST")
 	api.HandleFunc("/transactions", handler.GetAllTransactionsHandler).Methods("GET")
+	api.HandleFunc("/transactions/stream", handler.GetTransactionsStreamHandler).Methods("GET")
+	api.HandleFunc("/transactions/move", handler.MoveTransactionsHandler).Methods("POST")
+	api.HandleFunc("/transactions/{id}", handler.GetTransactionHandler).Methods("GET")
 	api.HandleFunc("/transactions/{id}", handler.UpdateTransactionHandler).Methods("PUT")
 	api.HandleFunc("/transactions/import", handler.ImportCSVHandler).Methods("POST")
+	api.HandleFunc("/import/profiles", handler.GetImportProfilesHandler).Methods("GET")
+	api.HandleFunc("/import/profiles", handler.CreateImportProfileHandler).Methods("POST")
 
 	// Performance routes
+	api.HandleFunc("/accounts/{id}/positions", handler.GetAccountPositionsHandler).Methods("GET")
+	api.HandleFunc("/accounts/{id}/assets", handler.GetAccountTradedAssetsHandler).Methods("GET")
+	api.HandleFunc("/accounts/{id}/positions/sync", handler.SyncPositionsHandler).Methods("POST")
+	api.HandleFunc("/accounts/{id}/positions/reconcile", handler.ReconcilePositionsHandler).Methods("GET")
 	api.HandleFunc("/accounts/{id}/performance", handler.GetAccountPerformanceHandler).Methods("GET")
+	api.HandleFunc("/accounts/{id}/summary", handler.GetAccountSummaryHandler).Methods("GET")
+	api.HandleFunc("/accounts/{id}/cashflow", handler.GetAccountCashFlowHandler).Methods("GET")
 	api.HandleFunc("/performance", handler.GetGlobalPerformanceHandler).Methods("GET")
 	api.HandleFunc("/assets/{isin}/performance", handler.GetAssetPerformanceHandler).Methods("GET")
+	api.HandleFunc("/assets/{isin}/holdings-history", handler.GetAssetHoldingsHistoryHandler).Methods("GET")
+	api.HandleFunc("/assets/{isin}/lots", handler.GetAssetLotsHandler).Methods("GET")
 
 	// Fees routes
 	api.HandleFunc("/accounts/{id}/fees", handler.GetAccountFeesHandler).Methods("GET")
@@ -99,15 +130,38 @@ func SetupRoutesWithVersion(db *database.DB, encryptionService *encryption.Encry
 
 	// Asset routes
 	api.HandleFunc("/assets", handler.GetAssetsHandler).Methods("GET")
+	api.HandleFunc("/assets/traded", handler.GetTradedAssetsHandler).Methods("GET")
 	api.HandleFunc("/assets/{isin}/price", handler.GetAssetPriceHandler).Methods("GET")
 	api.HandleFunc("/assets/{isin}/history", handler.GetAssetPriceHistoryHandler).Methods("GET")
+	api.HandleFunc("/assets/{isin}/prices/recent", handler.GetRecentAssetPricesHandler).Methods("GET")
 	api.HandleFunc("/assets/{isin}/price/update", handler.UpdateSingleAssetPrice).Methods("POST")
 	api.HandleFunc("/assets/{isin}/price/refresh", handler.RefreshAssetPricesHandler).Methods("POST")
+	api.HandleFunc("/assets/{isin}/backfill", handler.BackfillAssetPricesHandler).Methods("POST")
 	api.HandleFunc("/assets/{isin}/symbol", handler.UpdateAssetSymbolHandler).Methods("PUT")
+	api.HandleFunc("/assets/{isin}/metadata", handler.GetAssetMetadataHandler).Methods("GET")
+	api.HandleFunc("/assets/symbols", handler.UpdateAssetSymbolsHandler).Methods("PUT")
 	api.HandleFunc("/assets/symbols/resolve", handler.ResolveAllSymbolsHandler).Methods("POST")
+	api.HandleFunc("/assets/symbols/resolve/{job_id}", handler.GetSymbolResolutionJobHandler).Methods("GET")
+	api.HandleFunc("/assets/merge", handler.MergeAssetsHandler).Methods("POST")
+	api.HandleFunc("/assets/backfill-names", handler.BackfillAssetNamesHandler).Methods("POST")
+
+	// Price cache routes
+	api.HandleFunc("/prices/cache/invalidate", handler.InvalidatePriceCacheHandler).Methods("POST")
+	api.HandleFunc("/prices/cache/stats", handler.GetPriceCacheStatsHandler).Methods("GET")
 
 	// Symbol search routes
+	api.HandleFunc("/search", handler.SearchHandler).Methods("GET")
 	api.HandleFunc("/symbols/search", handler.SymbolSearchHandler).Methods("GET")
+	api.HandleFunc("/symbols/search", handler.BatchSymbolSearchHandler).Methods("POST")
+
+	// Alert routes
+	api.HandleFunc("/alerts", handler.GetAlertsHandler).Methods("GET")
+	api.HandleFunc("/alerts", handler.CreateAlertHandler).Methods("POST")
+
+	// Admin routes
+	api.HandleFunc("/admin/migrations", handler.GetMigrationsHandler).Methods("GET")
+	api.HandleFunc("/admin/transaction-types", handler.GetTransactionTypeMappingsHandler).Methods("GET")
+	api.HandleFunc("/admin/reclassify", handler.ReclassifyTransactionsHandler).Methods("POST")
 
 	// Return router and services
 	services := &Services{
@@ -115,6 +169,7 @@ func SetupRoutesWithVersion(db *database.DB, encryptionService *encryption.Encry
 		PriceService:       priceService,
 		PerformanceService: performanceService,
 		FeesService:        feesService,
+		PerformanceCache:   performanceCache,
 	}
 
 	return router, services
@@ -0,0 +1,377 @@
+package api
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+	"valhafin/internal/domain/models"
+	"valhafin/internal/service/price"
+	"valhafin/internal/utils"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// SymbolResolutionWorkers bounds the assets resolved concurrently; Yahoo Finance
+	// requests are still spaced by the price service rate limiter
+	SymbolResolutionWorkers = 4
+	// SymbolResolutionTimeout bounds a whole resolution job; the assets not reached
+	// by then are left for the next job
+	SymbolResolutionTimeout = 10 * time.Minute
+	// maxSymbolResolutionJobs is the number of finished jobs kept for status queries
+	maxSymbolResolutionJobs = 20
+)
+
+// Symbol resolution job states
+const (
+	// SymbolJobQueued means the job waits for the running one to finish
+	SymbolJobQueued    = "queued"
+	SymbolJobRunning   = "running"
+	SymbolJobCompleted = "completed"
+	// SymbolJobTimedOut means the deadline was reached before every asset was processed
+	SymbolJobTimedOut = "timed_out"
+)
+
+// symbolOutcome is the result of the resolution of one asset
+type symbolOutcome int
+
+const (
+	symbolResolved symbolOutcome = iota
+	symbolFailed
+	symbolSkipped
+)
+
+// SymbolResolutionStatus reports the progress of a symbol resolution job
+type SymbolResolutionStatus struct {
+	ID         string     `json:"id"`
+	Status     string     `json:"status"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	// Total is the number of assets without verified symbol when the job started
+	Total    int `json:"total"`
+	Resolved int `json:"resolved"`
+	Failed   int `json:"failed"`
+	// Skipped counts the assets without any symbol to resolve
+	Skipped int `json:"skipped"`
+	// Unprocessed counts the assets not reached before the deadline
+	Unprocessed int    `json:"unprocessed"`
+	Error       string `json:"error,omitempty"`
+}
+
+// SymbolResolutionJob is a symbol resolution running in the background
+type SymbolResolutionJob struct {
+	mu     sync.Mutex
+	status SymbolResolutionStatus
+	done   chan struct{}
+}
+
+// Status returns a snapshot of the job progress
+func (j *SymbolResolutionJob) Status() SymbolResolutionStatus {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.status
+}
+
+// Done is closed when the job has finished
+func (j *SymbolResolutionJob) Done() <-chan struct{} {
+	return j.done
+}
+
+func (j *SymbolResolutionJob) setTotal(total int) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.status.Total = total
+}
+
+func (j *SymbolResolutionJob) record(outcome symbolOutcome) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	switch outcome {
+	case symbolResolved:
+		j.status.Resolved++
+	case symbolFailed:
+		j.status.Failed++
+	case symbolSkipped:
+		j.status.Skipped++
+	}
+}
+
+func (j *SymbolResolutionJob) finish(ctxErr error, errMessage string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	now := time.Now()
+	j.status.FinishedAt = &now
+	j.status.Error = errMessage
+	j.status.Unprocessed = j.status.Total - j.status.Resolved - j.status.Failed - j.status.Skipped
+	j.status.Status = SymbolJobCompleted
+	if ctxErr != nil && j.status.Unprocessed > 0 {
+		j.status.Status = SymbolJobTimedOut
+	}
+}
+
+// symbolResolutionRun is the work of a symbol resolution job; it returns an error message
+// when the resolution could not run
+type symbolResolutionRun func(ctx context.Context, job *SymbolResolutionJob) string
+
+// SymbolResolutionJobs runs symbol resolution jobs one at a time and keeps the latest
+// ones for status queries. A job requested while another runs is queued: it starts when the
+// running one finishes, so that it selects the assets created in the meantime.
+type SymbolResolutionJobs struct {
+	mu      sync.Mutex
+	jobs    map[string]*SymbolResolutionJob
+	order   []string
+	running *SymbolResolutionJob
+	// queued is the job started after the running one, with its run
+	queued    *SymbolResolutionJob
+	queuedRun symbolResolutionRun
+
+	// writeMu serializes the symbol updates of the workers
+	writeMu sync.Mutex
+
+	workers int
+	timeout time.Duration
+}
+
+// NewSymbolResolutionJobs creates the symbol resolution job registry
+func NewSymbolResolutionJobs() *SymbolResolutionJobs {
+	return &SymbolResolutionJobs{
+		jobs:    make(map[string]*SymbolResolutionJob),
+		workers: SymbolResolutionWorkers,
+		timeout: SymbolResolutionTimeout,
+	}
+}
+
+// Get returns the job with the given ID
+func (r *SymbolResolutionJobs) Get(id string) (*SymbolResolutionJob, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job, ok := r.jobs[id]
+	return job, ok
+}
+
+// Start runs run in the background with the job deadline. When a job is already running, a
+// follow-up job is queued instead (or the one already queued is returned) and runs once the
+// running job finishes. started reports whether the returned job was started right away.
+func (r *SymbolResolutionJobs) Start(run func(ctx context.Context, job *SymbolResolutionJob) string) (job *SymbolResolutionJob, started bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.running != nil {
+		if r.queued == nil {
+			r.queued = r.register(SymbolJobQueued)
+			r.queuedRun = run
+		}
+		return r.queued, false
+	}
+
+	job = r.register(SymbolJobRunning)
+	r.launch(job, run)
+	return job, true
+}
+
+// register creates a job in the given state and keeps it for status queries; r.mu must be held
+func (r *SymbolResolutionJobs) register(status string) *SymbolResolutionJob {
+	job := &SymbolResolutionJob{
+		status: SymbolResolutionStatus{ID: uuid.New().String(), Status: status, StartedAt: time.Now()},
+		done:   make(chan struct{}),
+	}
+	r.jobs[job.status.ID] = job
+	r.order = append(r.order, job.status.ID)
+	for len(r.order) > maxSymbolResolutionJobs {
+		delete(r.jobs, r.order[0])
+		r.order = r.order[1:]
+	}
+	return job
+}
+
+// launch runs job in the background and then the queued job, if any; r.mu must be held
+func (r *SymbolResolutionJobs) launch(job *SymbolResolutionJob, run symbolResolutionRun) {
+	job.mu.Lock()
+	job.status.Status = SymbolJobRunning
+	job.status.StartedAt = time.Now()
+	job.mu.Unlock()
+	r.running = job
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), r.timeout)
+		defer cancel()
+
+		errMessage := run(ctx, job)
+		job.finish(ctx.Err(), errMessage)
+
+		r.mu.Lock()
+		r.running = nil
+		if next := r.queued; next != nil {
+			r.launch(next, r.queuedRun)
+			r.queued, r.queuedRun = nil, nil
+		}
+		r.mu.Unlock()
+		close(job.done)
+
+		status := job.Status()
+		log.Printf("INFO: Symbol resolution job %s %s: %d resolved, %d failed, %d skipped, %d unprocessed",
+			status.ID, status.Status, status.Resolved, status.Failed, status.Skipped, status.Unprocessed)
+	}()
+}
+
+// symbolCandidate is an asset without verified symbol
+type symbolCandidate struct {
+	ISIN   string  `db:"isin"`
+	Name   string  `db:"name"`
+	Symbol *string `db:"symbol"`
+}
+
+// runSymbolWorkers resolves the candidates with a bounded number of workers. Workers stop
+// taking candidates once ctx is done; the candidates in progress are finished.
+func runSymbolWorkers(ctx context.Context, job *SymbolResolutionJob, candidates []symbolCandidate, workers int, resolve func(symbolCandidate) symbolOutcome) {
+	if workers < 1 {
+		workers = 1
+	}
+
+	queue := make(chan symbolCandidate)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for candidate := range queue {
+				job.record(resolve(candidate))
+			}
+		}()
+	}
+
+feed:
+	for _, candidate := range candidates {
+		select {
+		case <-ctx.Done():
+			break feed
+		case queue <- candidate:
+		}
+	}
+	close(queue)
+	wg.Wait()
+}
+
+// resolveAssetSymbolsAsync starts a background job resolving the Yahoo Finance symbols of
+// the assets without verified symbol, or queues one after the job already running. The job
+// logs with the request ID carried by ctx, but is not canceled with it.
+func (h *Handler) resolveAssetSymbolsAsync(ctx context.Context) *SymbolResolutionJob {
+	requestID := utils.RequestID(ctx)
+	job, started := h.SymbolResolution.Start(func(jobCtx context.Context, job *SymbolResolutionJob) string {
+		if requestID != "" {
+			jobCtx = utils.WithRequestID(jobCtx, requestID)
+		}
+		defer h.PerformanceCache.InvalidateAll()
+		return h.resolveAssetSymbols(jobCtx, job)
+	})
+	if started {
+		utils.Logf(ctx, "INFO: Started symbol resolution job %s", job.Status().ID)
+	} else {
+		utils.Logf(ctx, "INFO: Symbol resolution job %s queued after the running job", job.Status().ID)
+	}
+	return job
+}
+
+// resolveAssetSymbols resolves Yahoo Finance symbols for assets that don't have verified
+// symbols, and returns an error message when the resolution could not run
+func (h *Handler) resolveAssetSymbols(ctx context.Context, job *SymbolResolutionJob) string {
+	yahooService, ok := price.YahooService(h.PriceService)
+	if !ok {
+		utils.Logf(ctx, "WARNING: Price service is not Yahoo Finance, skipping symbol resolution")
+		return "price service is not Yahoo Finance"
+	}
+
+	// Get all assets without verified symbols
+	query := `
+		SELECT isin, name, symbol
+		FROM assets
+		WHERE (symbol_verified = false OR symbol_verified IS NULL)
+		AND isin IS NOT NULL
+	`
+
+	var assets []symbolCandidate
+	if err := h.DB.SelectContext(ctx, &assets, query); err != nil {
+		utils.Logf(ctx, "ERROR: Failed to get assets for symbol resolution: %v", err)
+		return "failed to get assets"
+	}
+
+	utils.Logf(ctx, "INFO: Found %d assets to resolve symbols for", len(assets))
+	job.setTotal(len(assets))
+
+	runSymbolWorkers(ctx, job, assets, h.SymbolResolution.workers, func(asset symbolCandidate) symbolOutcome {
+		return h.resolveAssetSymbol(ctx, yahooService, asset)
+	})
+	return ""
+}
+
+// resolveAssetSymbol resolves the symbol of one asset, stores it and fetches its price history
+func (h *Handler) resolveAssetSymbol(ctx context.Context, yahooService *price.YahooFinanceService, asset symbolCandidate) symbolOutcome {
+	// Get metadata from transactions to extract exchange info
+	metadata := models.TransactionMetadata{}
+	if parsed, err := h.DB.GetAssetMetadata(asset.ISIN); err != nil {
+		utils.Logf(ctx, "WARNING: Failed to get metadata for ISIN %s: %v", asset.ISIN, err)
+	} else if parsed != nil {
+		metadata = *parsed
+	}
+
+	// Use symbol from metadata or from asset
+	symbolToResolve := metadata.Symbol
+	if symbolToResolve == "" && asset.Symbol != nil {
+		symbolToResolve = *asset.Symbol
+	}
+
+	if symbolToResolve == "" {
+		utils.Logf(ctx, "WARNING: No symbol found for ISIN %s, skipping", asset.ISIN)
+		return symbolSkipped
+	}
+
+	// Use asset name from metadata or database
+	assetName := metadata.Name
+	if assetName == "" {
+		assetName = asset.Name
+	}
+
+	// Resolve symbol with Yahoo Finance
+	resolvedSymbol, verified, err := yahooService.ResolveSymbolWithExchange(
+		ctx,
+		symbolToResolve,
+		metadata.Exchanges,
+		assetName,
+	)
+
+	if err != nil {
+		utils.Logf(ctx, "WARNING: Failed to resolve symbol for ISIN %s (%s): %v", asset.ISIN, symbolToResolve, err)
+		return symbolFailed
+	}
+
+	// Update asset with resolved symbol
+	updateQuery := `
+		UPDATE assets
+		SET symbol = $1, symbol_verified = $2, last_updated = NOW()
+		WHERE isin = $3
+	`
+	h.SymbolResolution.writeMu.Lock()
+	_, err = h.DB.Exec(updateQuery, resolvedSymbol, verified, asset.ISIN)
+	h.SymbolResolution.writeMu.Unlock()
+	if err != nil {
+		utils.Logf(ctx, "ERROR: Failed to update symbol for ISIN %s: %v", asset.ISIN, err)
+		return symbolFailed
+	}
+
+	utils.Logf(ctx, "INFO: Resolved symbol for %s: %s → %s (verified: %v)", asset.ISIN, symbolToResolve, resolvedSymbol, verified)
+
+	// Name the asset from the provider when no name was ever known
+	if asset.Name == models.UnknownAssetName && yahooService.NameBackfill() {
+		h.backfillAssetName(ctx, yahooService, asset.ISIN, resolvedSymbol)
+	}
+
+	// Fetch complete price history for this asset (stored in its own transaction)
+	if err := h.fetchCompleteAssetPriceHistory(ctx, asset.ISIN); err != nil {
+		utils.Logf(ctx, "WARNING: Failed to fetch price history for %s: %v", asset.ISIN, err)
+	} else {
+		utils.Logf(ctx, "INFO: Fetched complete price history for %s", asset.ISIN)
+	}
+
+	return symbolResolved
+}
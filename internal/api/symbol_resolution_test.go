@@ -0,0 +1,153 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+func symbolCandidates(n int) []symbolCandidate {
+	candidates := make([]symbolCandidate, n)
+	for i := range candidates {
+		candidates[i] = symbolCandidate{ISIN: fmt.Sprintf("US%010d", i)}
+	}
+	return candidates
+}
+
+func TestRunSymbolWorkers_BoundsConcurrency(t *testing.T) {
+	job := &SymbolResolutionJob{}
+	candidates := symbolCandidates(20)
+	job.setTotal(len(candidates))
+
+	var inFlight, maxInFlight int32
+	runSymbolWorkers(context.Background(), job, candidates, 3, func(candidate symbolCandidate) symbolOutcome {
+		current := atomic.AddInt32(&inFlight, 1)
+		for {
+			max := atomic.LoadInt32(&maxInFlight)
+			if current <= max || atomic.CompareAndSwapInt32(&maxInFlight, max, current) {
+				break
+			}
+		}
+		time.Sleep(2 * time.Millisecond)
+		atomic.AddInt32(&inFlight, -1)
+
+		if candidate.ISIN == "US0000000000" {
+			return symbolSkipped
+		}
+		if candidate.ISIN == "US0000000001" {
+			return symbolFailed
+		}
+		return symbolResolved
+	})
+	job.finish(nil, "")
+
+	if maxInFlight > 3 {
+		t.Errorf("expected at most 3 concurrent resolutions, got %d", maxInFlight)
+	}
+	status := job.Status()
+	if status.Status != SymbolJobCompleted || status.Resolved != 18 || status.Failed != 1 || status.Skipped != 1 || status.Unprocessed != 0 {
+		t.Errorf("unexpected status: %+v", status)
+	}
+}
+
+func TestRunSymbolWorkers_StopsAtDeadline(t *testing.T) {
+	job := &SymbolResolutionJob{}
+	candidates := symbolCandidates(100)
+	job.setTotal(len(candidates))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	runSymbolWorkers(ctx, job, candidates, 2, func(symbolCandidate) symbolOutcome {
+		time.Sleep(5 * time.Millisecond)
+		return symbolResolved
+	})
+	job.finish(ctx.Err(), "")
+
+	status := job.Status()
+	if status.Status != SymbolJobTimedOut || status.Unprocessed == 0 || status.Resolved+status.Unprocessed != 100 {
+		t.Errorf("expected the job to time out with unprocessed assets, got %+v", status)
+	}
+}
+
+func TestSymbolResolutionJobs_RunsOneJobAtATime(t *testing.T) {
+	jobs := NewSymbolResolutionJobs()
+	release := make(chan struct{})
+	var runs int32
+
+	run := func(ctx context.Context, job *SymbolResolutionJob) string {
+		atomic.AddInt32(&runs, 1)
+		job.setTotal(1)
+		<-release
+		job.record(symbolResolved)
+		return ""
+	}
+
+	first, started := jobs.Start(run)
+	if !started || first.Status().Status != SymbolJobRunning {
+		t.Fatalf("expected a running job, got %+v", first.Status())
+	}
+	second, started := jobs.Start(run)
+	if started || second == first || second.Status().Status != SymbolJobQueued {
+		t.Fatalf("expected a follow-up job to be queued, got %+v", second.Status())
+	}
+	if again, started := jobs.Start(run); started || again != second {
+		t.Error("expected the queued job to be returned instead of queuing another one")
+	}
+	if n := atomic.LoadInt32(&runs); n > 1 {
+		t.Errorf("expected the queued job to wait for the running one, got %d runs", n)
+	}
+
+	close(release)
+	<-first.Done()
+	<-second.Done()
+
+	if status := first.Status(); status.Status != SymbolJobCompleted || status.Resolved != 1 || status.FinishedAt == nil {
+		t.Errorf("unexpected status: %+v", status)
+	}
+	if job, ok := jobs.Get(first.Status().ID); !ok || job != first {
+		t.Error("expected the finished job to be kept")
+	}
+	if status := second.Status(); status.Status != SymbolJobCompleted || status.Resolved != 1 {
+		t.Errorf("expected the queued job to run after the first one, got %+v", status)
+	}
+	if n := atomic.LoadInt32(&runs); n != 2 {
+		t.Errorf("expected two runs, got %d", n)
+	}
+
+	// A new job can start once the previous one has finished
+	third, started := jobs.Start(func(ctx context.Context, job *SymbolResolutionJob) string {
+		return "price service is not Yahoo Finance"
+	})
+	<-third.Done()
+	if !started || third == first || third == second || third.Status().Error == "" {
+		t.Errorf("expected a new job reporting its error, got %+v", third.Status())
+	}
+}
+
+func TestGetSymbolResolutionJobHandler(t *testing.T) {
+	handler := &Handler{SymbolResolution: NewSymbolResolutionJobs()}
+	job, _ := handler.SymbolResolution.Start(func(ctx context.Context, job *SymbolResolutionJob) string { return "" })
+	<-job.Done()
+
+	for _, tt := range []struct {
+		id     string
+		status int
+	}{
+		{job.Status().ID, http.StatusOK},
+		{"unknown", http.StatusNotFound},
+	} {
+		req := httptest.NewRequest("GET", "/api/assets/symbols/resolve/"+tt.id, nil)
+		req = mux.SetURLVars(req, map[string]string{"job_id": tt.id})
+		rec := httptest.NewRecorder()
+		handler.GetSymbolResolutionJobHandler(rec, req)
+		if rec.Code != tt.status {
+			t.Errorf("job %s: expected status %d, got %d", tt.id, tt.status, rec.Code)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package api
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"valhafin/internal/domain/models"
+)
+
+// transactionCSVColumns is the standard column set of the transaction lists served as CSV.
+// The files can be imported back with POST /api/transactions/import.
+var transactionCSVColumns = []string{
+	"id", "account_id", "timestamp", "title", "subtitle", "isin", "transaction_type",
+	"quantity", "amount_value", "amount_currency", "fees", "status",
+}
+
+// wantsCSV reports whether the Accept header prefers CSV to JSON. Without Accept header, or
+// with */*, the transaction lists keep answering JSON.
+func wantsCSV(r *http.Request) bool {
+	csvQuality, jsonQuality := 0.0, 0.0
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		quality := 1.0
+		if value, ok := params["q"]; ok {
+			if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+				quality = parsed
+			}
+		}
+		switch mediaType {
+		case "text/csv":
+			csvQuality = max(csvQuality, quality)
+		case "application/json", "*/*":
+			jsonQuality = max(jsonQuality, quality)
+		}
+	}
+	return csvQuality > 0 && csvQuality >= jsonQuality
+}
+
+// respondTransactionsCSV streams the transactions as CSV, one row at a time, with the
+// standard column set. The status is already sent when a write fails, so the stream is
+// cut short and the error logged.
+func respondTransactionsCSV(w http.ResponseWriter, transactions []models.Transaction) {
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+
+	if err := writeTransactionsCSV(w, transactions); err != nil {
+		log.Printf("ERROR: Failed to stream transactions CSV: %v", err)
+	}
+}
+
+// writeTransactionsCSV writes the header and one row per transaction, stopping at the
+// first write error
+func writeTransactionsCSV(w io.Writer, transactions []models.Transaction) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(transactionCSVColumns); err != nil {
+		return fmt.Errorf("failed to write header: %w", err)
+	}
+	for _, tx := range transactions {
+		isin := ""
+		if tx.ISIN != nil {
+			isin = *tx.ISIN
+		}
+		err := writer.Write([]string{
+			tx.ID,
+			tx.AccountID,
+			tx.Timestamp,
+			tx.Title,
+			tx.Subtitle,
+			isin,
+			tx.TransactionType,
+			strconv.FormatFloat(tx.Quantity, 'f', -1, 64),
+			strconv.FormatFloat(tx.ExactAmount(), 'f', -1, 64),
+			tx.AmountCurrency,
+			tx.Fees,
+			tx.Status,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to write transaction %s: %w", tx.ID, err)
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
@@ -3,6 +3,45 @@ package api
 import (
 	"fmt"
 	"regexp"
+	"strings"
+	"valhafin/internal/service/scraper/traderepublic"
+)
+
+// FieldError describes a single validation problem on a request field
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationErrors collects every field-level problem found while validating a request
+type ValidationErrors []FieldError
+
+// Add records a validation problem for the given field
+func (e *ValidationErrors) Add(field, message string) {
+	*e = append(*e, FieldError{Field: field, Message: message})
+}
+
+// Error joins all messages so callers that only need a string still get every problem
+func (e ValidationErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, fieldErr := range e {
+		messages[i] = fieldErr.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+// orNil returns nil when no problem was recorded, so validators can return it as an error
+func (e ValidationErrors) orNil() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+var (
+	phoneRegex    = regexp.MustCompile(`^\+[1-9]\d{1,14}$`)
+	apiKeyRegex   = regexp.MustCompile(`^[A-Za-z0-9]{64}$`)
+	usernameRegex = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
 )
 
 // CredentialsValidator validates credentials for different platforms
@@ -13,7 +52,9 @@ func NewCredentialsValidator() *CredentialsValidator {
 	return &CredentialsValidator{}
 }
 
-// ValidateCredentials validates credentials based on the platform
+// ValidateCredentials validates credentials based on the platform.
+// When the credentials are invalid, the returned error is a ValidationErrors
+// listing every problem found rather than only the first one.
 func (v *CredentialsValidator) ValidateCredentials(platform string, credentials map[string]interface{}) error {
 	switch platform {
 	case "traderepublic":
@@ -23,101 +64,77 @@ func (v *CredentialsValidator) ValidateCredentials(platform string, credentials
 	case "boursedirect":
 		return v.validateBourseDirectCredentials(credentials)
 	default:
-		return fmt.Errorf("unsupported platform: %s", platform)
+		return ValidationErrors{{Field: "platform", Message: fmt.Sprintf("unsupported platform: %s", platform)}}
 	}
 }
 
 // validateTradeRepublicCredentials validates Trade Republic credentials
 func (v *CredentialsValidator) validateTradeRepublicCredentials(credentials map[string]interface{}) error {
+	var errs ValidationErrors
+
 	// Trade Republic requires phone_number and pin
 	phoneNumber, ok := credentials["phone_number"].(string)
 	if !ok || phoneNumber == "" {
-		return fmt.Errorf("phone_number is required for Trade Republic")
-	}
-
-	// Validate phone number format (international format)
-	phoneRegex := regexp.MustCompile(`^\+[1-9]\d{1,14}$`)
-	if !phoneRegex.MatchString(phoneNumber) {
-		return fmt.Errorf("phone_number must be in international format (e.g., +33612345678)")
+		errs.Add("phone_number", "phone_number is required for Trade Republic")
+	} else if !phoneRegex.MatchString(phoneNumber) {
+		// Validate phone number format (international format)
+		errs.Add("phone_number", "phone_number must be in international format (e.g., +33612345678)")
 	}
 
 	pin, ok := credentials["pin"].(string)
 	if !ok || pin == "" {
-		return fmt.Errorf("pin is required for Trade Republic")
-	}
-
-	// Validate PIN format (4 digits)
-	pinRegex := regexp.MustCompile(`^\d{4}$`)
-	if !pinRegex.MatchString(pin) {
-		return fmt.Errorf("pin must be exactly 4 digits")
+		errs.Add("pin", "pin is required for Trade Republic")
+	} else if err := traderepublic.ValidatePIN(pin); err != nil {
+		// Validate PIN format with the same rules as the scraper
+		errs.Add("pin", fmt.Sprintf("invalid pin: %v", err))
 	}
 
-	return nil
+	return errs.orNil()
 }
 
 // validateBinanceCredentials validates Binance credentials
 func (v *CredentialsValidator) validateBinanceCredentials(credentials map[string]interface{}) error {
-	// Binance requires api_key and api_secret
-	apiKey, ok := credentials["api_key"].(string)
-	if !ok || apiKey == "" {
-		return fmt.Errorf("api_key is required for Binance")
-	}
-
-	// Validate API key format (64 characters alphanumeric)
-	if len(apiKey) != 64 {
-		return fmt.Errorf("api_key must be exactly 64 characters")
-	}
-
-	apiKeyRegex := regexp.MustCompile(`^[A-Za-z0-9]{64}$`)
-	if !apiKeyRegex.MatchString(apiKey) {
-		return fmt.Errorf("api_key must contain only alphanumeric characters")
-	}
-
-	apiSecret, ok := credentials["api_secret"].(string)
-	if !ok || apiSecret == "" {
-		return fmt.Errorf("api_secret is required for Binance")
-	}
-
-	// Validate API secret format (64 characters alphanumeric)
-	if len(apiSecret) != 64 {
-		return fmt.Errorf("api_secret must be exactly 64 characters")
-	}
-
-	apiSecretRegex := regexp.MustCompile(`^[A-Za-z0-9]{64}$`)
-	if !apiSecretRegex.MatchString(apiSecret) {
-		return fmt.Errorf("api_secret must contain only alphanumeric characters")
-	}
-
-	return nil
+	var errs ValidationErrors
+
+	// Binance requires api_key and api_secret, both 64 alphanumeric characters
+	for _, field := range []string{"api_key", "api_secret"} {
+		value, ok := credentials[field].(string)
+		switch {
+		case !ok || value == "":
+			errs.Add(field, fmt.Sprintf("%s is required for Binance", field))
+		case len(value) != 64:
+			errs.Add(field, fmt.Sprintf("%s must be exactly 64 characters", field))
+		case !apiKeyRegex.MatchString(value):
+			errs.Add(field, fmt.Sprintf("%s must contain only alphanumeric characters", field))
+		}
+	}
+
+	return errs.orNil()
 }
 
 // validateBourseDirectCredentials validates Bourse Direct credentials
 func (v *CredentialsValidator) validateBourseDirectCredentials(credentials map[string]interface{}) error {
+	var errs ValidationErrors
+
 	// Bourse Direct requires username and password
 	username, ok := credentials["username"].(string)
-	if !ok || username == "" {
-		return fmt.Errorf("username is required for Bourse Direct")
-	}
-
-	// Validate username format (alphanumeric, 3-50 characters)
-	if len(username) < 3 || len(username) > 50 {
-		return fmt.Errorf("username must be between 3 and 50 characters")
-	}
-
-	usernameRegex := regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
-	if !usernameRegex.MatchString(username) {
-		return fmt.Errorf("username must contain only alphanumeric characters, underscores, and hyphens")
+	switch {
+	case !ok || username == "":
+		errs.Add("username", "username is required for Bourse Direct")
+	case len(username) < 3 || len(username) > 50:
+		// Validate username format (alphanumeric, 3-50 characters)
+		errs.Add("username", "username must be between 3 and 50 characters")
+	case !usernameRegex.MatchString(username):
+		errs.Add("username", "username must contain only alphanumeric characters, underscores, and hyphens")
 	}
 
 	password, ok := credentials["password"].(string)
 	if !ok || password == "" {
-		return fmt.Errorf("password is required for Bourse Direct")
-	}
-
-	// Validate password format (minimum 8 characters)
-	if len(password) < 8 {
-		return fmt.Errorf("password must be at least 8 characters")
+		errs.Add("password", "password is required for Bourse Direct")
+	} else if len(password) < 8 {
+		// Validate password format (minimum 8 characters)
+		errs.Add("password", "password must be at least 8 characters")
 	}
 
-	return nil
+	return errs.orNil()
 }
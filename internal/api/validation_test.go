@@ -1,6 +1,11 @@
 package api
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/leanovate/gopter"
@@ -34,13 +39,45 @@ func TestValidateTradeRepublicCredentials(t *testing.T) {
 			wantErr: true,
 		},
 		{
-			name: "invalid PIN - not 4 digits",
+			name: "valid credentials - 5 digit PIN",
+			credentials: map[string]interface{}{
+				"phone_number": "+33612345678",
+				"pin":          "12345",
+			},
+			wantErr: false,
+		},
+		{
+			name: "valid credentials - 6 digit PIN",
+			credentials: map[string]interface{}{
+				"phone_number": "+33612345678",
+				"pin":          "123456",
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid PIN - too short",
 			credentials: map[string]interface{}{
 				"phone_number": "+33612345678",
 				"pin":          "12",
 			},
 			wantErr: true,
 		},
+		{
+			name: "invalid PIN - too long",
+			credentials: map[string]interface{}{
+				"phone_number": "+33612345678",
+				"pin":          "1234567",
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid PIN - not numeric",
+			credentials: map[string]interface{}{
+				"phone_number": "+33612345678",
+				"pin":          "12ab",
+			},
+			wantErr: true,
+		},
 		{
 			name: "missing phone_number",
 			credentials: map[string]interface{}{
@@ -156,6 +193,156 @@ func TestValidateBourseDirectCredentials(t *testing.T) {
 	}
 }
 
+// TestValidateCredentials_ReportsAllErrors checks that every credential problem is reported at once
+func TestValidateCredentials_ReportsAllErrors(t *testing.T) {
+	validator := NewCredentialsValidator()
+
+	tests := []struct {
+		name       string
+		platform   string
+		credential map[string]interface{}
+		wantFields []string
+	}{
+		{
+			name:       "trade republic missing phone and short pin",
+			platform:   "traderepublic",
+			credential: map[string]interface{}{"pin": "12"},
+			wantFields: []string{"phone_number", "pin"},
+		},
+		{
+			name:     "binance short key and missing secret",
+			platform: "binance",
+			credential: map[string]interface{}{
+				"api_key": "short",
+			},
+			wantFields: []string{"api_key", "api_secret"},
+		},
+		{
+			name:     "bourse direct invalid username and short password",
+			platform: "boursedirect",
+			credential: map[string]interface{}{
+				"username": "a!",
+				"password": "short",
+			},
+			wantFields: []string{"username", "password"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validator.ValidateCredentials(tt.platform, tt.credential)
+
+			var errs ValidationErrors
+			if !errors.As(err, &errs) {
+				t.Fatalf("expected ValidationErrors, got %v", err)
+			}
+			if len(errs) != len(tt.wantFields) {
+				t.Fatalf("expected %d errors, got %d: %v", len(tt.wantFields), len(errs), errs)
+			}
+			for i, field := range tt.wantFields {
+				if errs[i].Field != field {
+					t.Errorf("error %d: expected field %q, got %q", i, field, errs[i].Field)
+				}
+			}
+		})
+	}
+}
+
+// TestCreateAccountHandler_ReportsAllErrors checks that the handler returns every problem in a single 400
+func TestCreateAccountHandler_ReportsAllErrors(t *testing.T) {
+	handler := &Handler{Validator: NewCredentialsValidator()}
+
+	tests := []struct {
+		name       string
+		body       map[string]interface{}
+		wantCode   string
+		wantFields []string
+	}{
+		{
+			name: "missing name and invalid credentials",
+			body: map[string]interface{}{
+				"platform":    "traderepublic",
+				"credentials": map[string]interface{}{"pin": "12"},
+			},
+			wantCode:   "VALIDATION_ERROR",
+			wantFields: []string{"name", "phone_number", "pin"},
+		},
+		{
+			name: "credential errors only",
+			body: map[string]interface{}{
+				"name":        "Test Account",
+				"platform":    "traderepublic",
+				"credentials": map[string]interface{}{"pin": "12"},
+			},
+			wantCode:   "INVALID_CREDENTIALS",
+			wantFields: []string{"phone_number", "pin"},
+		},
+		{
+			name:       "everything missing",
+			body:       map[string]interface{}{},
+			wantCode:   "VALIDATION_ERROR",
+			wantFields: []string{"name", "platform", "credentials"},
+		},
+		{
+			name: "invalid currency",
+			body: map[string]interface{}{
+				"name":        "Test Account",
+				"platform":    "traderepublic",
+				"credentials": map[string]interface{}{"phone_number": "+33612345678", "pin": "1234"},
+				"currency":    "euro",
+			},
+			wantCode:   "VALIDATION_ERROR",
+			wantFields: []string{"currency"},
+		},
+		{
+			name: "invalid decimal style",
+			body: map[string]interface{}{
+				"name":          "Test Account",
+				"platform":      "traderepublic",
+				"credentials":   map[string]interface{}{"phone_number": "+33612345678", "pin": "1234"},
+				"decimal_style": "eu",
+			},
+			wantCode:   "VALIDATION_ERROR",
+			wantFields: []string{"decimal_style"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, _ := json.Marshal(tt.body)
+			req := httptest.NewRequest("POST", "/api/accounts", bytes.NewReader(body))
+			w := httptest.NewRecorder()
+
+			handler.CreateAccountHandler(w, req)
+
+			if w.Code != http.StatusBadRequest {
+				t.Fatalf("expected status 400, got %d: %s", w.Code, w.Body.String())
+			}
+
+			var resp struct {
+				Error struct {
+					Code    string       `json:"code"`
+					Details []FieldError `json:"details"`
+				} `json:"error"`
+			}
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if resp.Error.Code != tt.wantCode {
+				t.Errorf("expected code %s, got %s", tt.wantCode, resp.Error.Code)
+			}
+			if len(resp.Error.Details) != len(tt.wantFields) {
+				t.Fatalf("expected %d details, got %d: %+v", len(tt.wantFields), len(resp.Error.Details), resp.Error.Details)
+			}
+			for i, field := range tt.wantFields {
+				if resp.Error.Details[i].Field != field {
+					t.Errorf("detail %d: expected field %q, got %q", i, field, resp.Error.Details[i].Field)
+				}
+			}
+		})
+	}
+}
+
 // **Propriété 2: Rejet des identifiants invalides (Unit Test Version)**
 // **Valide: Exigences 1.4**
 //
@@ -211,7 +398,7 @@ func TestProperty_CredentialValidation(t *testing.T) {
 			err := validator.validateTradeRepublicCredentials(credentials)
 			return err != nil
 		},
-		gen.OneConstOf("12", "123", "12345", "abcd", ""),
+		gen.OneConstOf("12", "123", "1234567", "abcd", "12a4", ""),
 	))
 
 	// Property: Valid Binance credentials should always pass validation
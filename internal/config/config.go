@@ -1,7 +1,9 @@
 package config
 
 import (
+	"crypto/tls"
 	"os"
+	"strconv"
 
 	"github.com/spf13/viper"
 )
@@ -11,6 +13,18 @@ type Config struct {
 	General  GeneralConfig  `mapstructure:"general"`
 	Database DatabaseConfig `mapstructure:"database"`
 	Server   ServerConfig   `mapstructure:"server"`
+	// TLS serves HTTPS when a certificate and its key are set
+	TLS    TLSConfig    `mapstructure:"tls"`
+	Alerts AlertsConfig `mapstructure:"alerts"`
+	Price  PriceConfig  `mapstructure:"price"`
+	// TradeRepublic holds Trade Republic specific settings
+	TradeRepublic TradeRepublicConfig `mapstructure:"traderepublic"`
+	// TransactionTypes extends the keyword to transaction type mappings
+	TransactionTypes TransactionTypesConfig `mapstructure:"transaction_types"`
+	// Logging controls what is redacted from logs
+	Logging LoggingConfig `mapstructure:"logging"`
+	// Import bounds the files accepted by the imports
+	Import ImportConfig `mapstructure:"import"`
 }
 
 type SecretConfig struct {
@@ -22,15 +36,129 @@ type GeneralConfig struct {
 	OutputFormat   string `mapstructure:"output_format"`
 	OutputFolder   string `mapstructure:"output_folder"`
 	ExtractDetails bool   `mapstructure:"extract_details"`
+	// Timezone is the IANA time zone used to interpret YYYY-MM-DD date filters
+	Timezone string `mapstructure:"timezone"`
+	// CurrencyDecimals is the number of decimals kept for amounts in performance responses
+	CurrencyDecimals int `mapstructure:"currency_decimals"`
+	// PerformanceCacheSeconds is how long performance results are cached (0 disables the cache)
+	PerformanceCacheSeconds int `mapstructure:"performance_cache_seconds"`
 }
 
 type DatabaseConfig struct {
 	URL string `mapstructure:"url"`
+	// RetryAttempts is the number of attempts for reads and transactions on transient errors (1 disables retries)
+	RetryAttempts int `mapstructure:"retry_attempts"`
+	// BreakerThreshold is the number of consecutive connection failures that opens the circuit breaker (0 disables it)
+	BreakerThreshold int `mapstructure:"breaker_threshold"`
+	// BreakerCooldownSeconds is how long the breaker fails fast before probing the database again
+	BreakerCooldownSeconds int `mapstructure:"breaker_cooldown_seconds"`
 }
 
 type ServerConfig struct {
 	Port          string `mapstructure:"port"`
 	EncryptionKey string `mapstructure:"encryption_key"`
+	// PreviousEncryptionKeys is a comma-separated list of keys used before rotation
+	PreviousEncryptionKeys string `mapstructure:"previous_encryption_keys"`
+	// EncryptionPassphrase derives the key when EncryptionKey is not a valid 32-byte key
+	EncryptionPassphrase string `mapstructure:"encryption_passphrase"`
+	EncryptionSalt       string `mapstructure:"encryption_salt"`
+	// EncryptionKDFVersion selects the fixed key derivation parameters
+	EncryptionKDFVersion int `mapstructure:"encryption_kdf_version"`
+	// AllowWeakEncryptionKey starts the server with a warning instead of refusing a weak
+	// key or passphrase
+	AllowWeakEncryptionKey bool `mapstructure:"allow_weak_encryption_key"`
+}
+
+type TLSConfig struct {
+	// CertFile and KeyFile are the PEM certificate (with its chain) and private key
+	// served over HTTPS. Plain HTTP is served when both are empty.
+	CertFile string `mapstructure:"cert_file"`
+	KeyFile  string `mapstructure:"key_file"`
+}
+
+// Enabled reports whether the server must serve HTTPS
+func (t TLSConfig) Enabled() bool {
+	return t.CertFile != "" || t.KeyFile != ""
+}
+
+// ServerTLSConfig returns the TLS settings of the HTTPS server: TLS 1.2 at least, with
+// the Go defaults for cipher suites and curves
+func (t TLSConfig) ServerTLSConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+	}
+}
+
+type AlertsConfig struct {
+	// WebhookURL receives alert events as JSON (alerts are only logged when empty)
+	WebhookURL string `mapstructure:"webhook_url"`
+}
+
+// Price providers selectable with PRICE_PROVIDER
+const (
+	PriceProviderYahoo        = "yahoo"
+	PriceProviderAlphaVantage = "alphavantage"
+	// PriceProviderComposite uses Yahoo Finance, then Alpha Vantage when a key is set,
+	// then the stored prices
+	PriceProviderComposite = "composite"
+	// PriceProviderStatic serves stored prices only and never calls a provider
+	PriceProviderStatic = "static"
+)
+
+// Exchange rate providers selectable with EXCHANGE_RATE_PROVIDER
+const (
+	// ExchangeRateProviderAPI fetches the latest rates from exchangerate-api.com
+	ExchangeRateProviderAPI = "exchangerate-api"
+	// ExchangeRateProviderECB uses the daily reference rates of the European Central Bank,
+	// downloaded or read from ECB_RATES_FILE
+	ExchangeRateProviderECB = "ecb"
+)
+
+type PriceConfig struct {
+	// Provider selects the price provider (yahoo by default)
+	Provider string `mapstructure:"provider"`
+	// AlphaVantageKey is the Alpha Vantage API key, required by the alphavantage provider
+	AlphaVantageKey string `mapstructure:"alpha_vantage_key"`
+	// ExchangeRateProvider selects the source of the currency conversion rates
+	// (exchangerate-api by default)
+	ExchangeRateProvider string `mapstructure:"exchange_rate_provider"`
+	// ECBRatesFile is an ECB rates CSV (eurofxref-hist.csv) read instead of downloading the rates
+	ECBRatesFile string `mapstructure:"ecb_rates_file"`
+	// RequestsPerMinute caps requests sent to the price provider (0 disables limiting)
+	RequestsPerMinute int `mapstructure:"requests_per_minute"`
+	// BackfillAssetNames names the assets still unknown from the provider search results
+	// when their symbol is resolved (enabled by default)
+	BackfillAssetNames bool `mapstructure:"backfill_asset_names"`
+	// RetentionDailyMonths keeps every stored price for this many months, older ones are
+	// reduced to one per week (0 keeps every price)
+	RetentionDailyMonths int `mapstructure:"retention_daily_months"`
+	// RetentionWeeklyMonths keeps weekly prices for this many months, older ones are
+	// reduced to one per month (0 keeps weekly prices)
+	RetentionWeeklyMonths int `mapstructure:"retention_weekly_months"`
+}
+
+type TradeRepublicConfig struct {
+	// PINMinLength and PINMaxLength bound the accepted PIN length (digits only)
+	PINMinLength int `mapstructure:"pin_min_length"`
+	PINMaxLength int `mapstructure:"pin_max_length"`
+}
+
+type TransactionTypesConfig struct {
+	// Mappings is a comma-separated list of "[field:]keyword=type" entries added to the built-in ones
+	Mappings string `mapstructure:"mappings"`
+}
+
+type ImportConfig struct {
+	// CSVMaxSizeMB is the largest CSV file accepted by the CSV import, in megabytes
+	CSVMaxSizeMB int `mapstructure:"csv_max_size_mb"`
+	// CSVMaxRows is the largest number of data rows accepted by the CSV import
+	CSVMaxRows int `mapstructure:"csv_max_rows"`
+}
+
+type LoggingConfig struct {
+	// RedactKeys is a comma-separated list of keys redacted from logs in addition to the defaults
+	// (pin, password, api_secret, code, secret, token, session_token)
+	RedactKeys string `mapstructure:"redact_keys"`
 }
 
 func Load() (*Config, error) {
@@ -45,14 +173,55 @@ func Load() (*Config, error) {
 	// Bind environment variables
 	viper.AutomaticEnv()
 	viper.BindEnv("database.url", "DATABASE_URL")
+	viper.BindEnv("database.retry_attempts", "DB_RETRY_ATTEMPTS")
+	viper.BindEnv("database.breaker_threshold", "DB_BREAKER_THRESHOLD")
+	viper.BindEnv("database.breaker_cooldown_seconds", "DB_BREAKER_COOLDOWN_SECONDS")
 	viper.BindEnv("server.port", "PORT")
 	viper.BindEnv("server.encryption_key", "ENCRYPTION_KEY")
+	viper.BindEnv("server.previous_encryption_keys", "ENCRYPTION_PREVIOUS_KEYS")
+	viper.BindEnv("server.encryption_passphrase", "ENCRYPTION_PASSPHRASE")
+	viper.BindEnv("server.encryption_salt", "ENCRYPTION_SALT")
+	viper.BindEnv("server.encryption_kdf_version", "ENCRYPTION_KDF_VERSION")
+	viper.BindEnv("server.allow_weak_encryption_key", "ENCRYPTION_ALLOW_WEAK_KEY")
+	viper.BindEnv("tls.cert_file", "TLS_CERT_FILE")
+	viper.BindEnv("tls.key_file", "TLS_KEY_FILE")
+	viper.BindEnv("general.timezone", "TIMEZONE")
+	viper.BindEnv("general.currency_decimals", "CURRENCY_DECIMALS")
+	viper.BindEnv("general.performance_cache_seconds", "PERFORMANCE_CACHE_SECONDS")
+	viper.BindEnv("alerts.webhook_url", "ALERT_WEBHOOK_URL")
+	viper.BindEnv("price.requests_per_minute", "PRICE_REQUESTS_PER_MINUTE")
+	viper.BindEnv("price.provider", "PRICE_PROVIDER")
+	viper.BindEnv("price.alpha_vantage_key", "ALPHA_VANTAGE_API_KEY")
+	viper.BindEnv("price.backfill_asset_names", "ASSET_NAME_BACKFILL")
+	viper.BindEnv("price.retention_daily_months", "PRICE_RETENTION_DAILY_MONTHS")
+	viper.BindEnv("price.retention_weekly_months", "PRICE_RETENTION_WEEKLY_MONTHS")
+	viper.BindEnv("traderepublic.pin_min_length", "TR_PIN_MIN_LENGTH")
+	viper.BindEnv("traderepublic.pin_max_length", "TR_PIN_MAX_LENGTH")
+	viper.BindEnv("transaction_types.mappings", "TRANSACTION_TYPE_MAPPINGS")
+	viper.BindEnv("logging.redact_keys", "LOG_REDACT_KEYS")
+	viper.BindEnv("import.csv_max_size_mb", "CSV_IMPORT_MAX_SIZE_MB")
+	viper.BindEnv("import.csv_max_rows", "CSV_IMPORT_MAX_ROWS")
 
 	// Set defaults
 	viper.SetDefault("server.port", "8080")
+	viper.SetDefault("database.retry_attempts", 3)
+	viper.SetDefault("database.breaker_threshold", 5)
+	viper.SetDefault("database.breaker_cooldown_seconds", 30)
+	viper.SetDefault("server.encryption_kdf_version", 1)
 	viper.SetDefault("general.output_format", "json")
 	viper.SetDefault("general.output_folder", "out")
 	viper.SetDefault("general.extract_details", false)
+	viper.SetDefault("general.timezone", "UTC")
+	viper.SetDefault("general.currency_decimals", 2)
+	viper.SetDefault("general.performance_cache_seconds", 30)
+	viper.SetDefault("price.provider", PriceProviderYahoo)
+	viper.SetDefault("price.exchange_rate_provider", ExchangeRateProviderAPI)
+	viper.SetDefault("price.requests_per_minute", 600)
+	viper.SetDefault("price.backfill_asset_names", true)
+	viper.SetDefault("traderepublic.pin_min_length", 4)
+	viper.SetDefault("traderepublic.pin_max_length", 6)
+	viper.SetDefault("import.csv_max_size_mb", 10)
+	viper.SetDefault("import.csv_max_rows", 50000)
 
 	var config Config
 	if err := viper.Unmarshal(&config); err != nil {
@@ -63,12 +232,105 @@ func Load() (*Config, error) {
 	if dbURL := os.Getenv("DATABASE_URL"); dbURL != "" {
 		config.Database.URL = dbURL
 	}
+	if attempts := os.Getenv("DB_RETRY_ATTEMPTS"); attempts != "" {
+		if value, err := strconv.Atoi(attempts); err == nil {
+			config.Database.RetryAttempts = value
+		}
+	}
+	if threshold := os.Getenv("DB_BREAKER_THRESHOLD"); threshold != "" {
+		if value, err := strconv.Atoi(threshold); err == nil {
+			config.Database.BreakerThreshold = value
+		}
+	}
+	if cooldown := os.Getenv("DB_BREAKER_COOLDOWN_SECONDS"); cooldown != "" {
+		if value, err := strconv.Atoi(cooldown); err == nil {
+			config.Database.BreakerCooldownSeconds = value
+		}
+	}
 	if port := os.Getenv("PORT"); port != "" {
 		config.Server.Port = port
 	}
 	if encKey := os.Getenv("ENCRYPTION_KEY"); encKey != "" {
 		config.Server.EncryptionKey = encKey
 	}
+	if timezone := os.Getenv("TIMEZONE"); timezone != "" {
+		config.General.Timezone = timezone
+	}
+	if webhookURL := os.Getenv("ALERT_WEBHOOK_URL"); webhookURL != "" {
+		config.Alerts.WebhookURL = webhookURL
+	}
+	if previousKeys := os.Getenv("ENCRYPTION_PREVIOUS_KEYS"); previousKeys != "" {
+		config.Server.PreviousEncryptionKeys = previousKeys
+	}
+	if passphrase := os.Getenv("ENCRYPTION_PASSPHRASE"); passphrase != "" {
+		config.Server.EncryptionPassphrase = passphrase
+	}
+	if salt := os.Getenv("ENCRYPTION_SALT"); salt != "" {
+		config.Server.EncryptionSalt = salt
+	}
+	if kdfVersion := os.Getenv("ENCRYPTION_KDF_VERSION"); kdfVersion != "" {
+		if value, err := strconv.Atoi(kdfVersion); err == nil {
+			config.Server.EncryptionKDFVersion = value
+		}
+	}
+	if allowWeak := os.Getenv("ENCRYPTION_ALLOW_WEAK_KEY"); allowWeak != "" {
+		if value, err := strconv.ParseBool(allowWeak); err == nil {
+			config.Server.AllowWeakEncryptionKey = value
+		}
+	}
+	if decimals := os.Getenv("CURRENCY_DECIMALS"); decimals != "" {
+		if value, err := strconv.Atoi(decimals); err == nil {
+			config.General.CurrencyDecimals = value
+		}
+	}
+	if provider := os.Getenv("PRICE_PROVIDER"); provider != "" {
+		config.Price.Provider = provider
+	}
+	if apiKey := os.Getenv("ALPHA_VANTAGE_API_KEY"); apiKey != "" {
+		config.Price.AlphaVantageKey = apiKey
+	}
+	if provider := os.Getenv("EXCHANGE_RATE_PROVIDER"); provider != "" {
+		config.Price.ExchangeRateProvider = provider
+	}
+	if file := os.Getenv("ECB_RATES_FILE"); file != "" {
+		config.Price.ECBRatesFile = file
+	}
+	if rpm := os.Getenv("PRICE_REQUESTS_PER_MINUTE"); rpm != "" {
+		if value, err := strconv.Atoi(rpm); err == nil {
+			config.Price.RequestsPerMinute = value
+		}
+	}
+	if backfill := os.Getenv("ASSET_NAME_BACKFILL"); backfill != "" {
+		if value, err := strconv.ParseBool(backfill); err == nil {
+			config.Price.BackfillAssetNames = value
+		}
+	}
+	if months := os.Getenv("PRICE_RETENTION_DAILY_MONTHS"); months != "" {
+		if value, err := strconv.Atoi(months); err == nil {
+			config.Price.RetentionDailyMonths = value
+		}
+	}
+	if months := os.Getenv("PRICE_RETENTION_WEEKLY_MONTHS"); months != "" {
+		if value, err := strconv.Atoi(months); err == nil {
+			config.Price.RetentionWeeklyMonths = value
+		}
+	}
+	if minLength := os.Getenv("TR_PIN_MIN_LENGTH"); minLength != "" {
+		if value, err := strconv.Atoi(minLength); err == nil {
+			config.TradeRepublic.PINMinLength = value
+		}
+	}
+	if maxLength := os.Getenv("TR_PIN_MAX_LENGTH"); maxLength != "" {
+		if value, err := strconv.Atoi(maxLength); err == nil {
+			config.TradeRepublic.PINMaxLength = value
+		}
+	}
+	if mappings := os.Getenv("TRANSACTION_TYPE_MAPPINGS"); mappings != "" {
+		config.TransactionTypes.Mappings = mappings
+	}
+	if redactKeys := os.Getenv("LOG_REDACT_KEYS"); redactKeys != "" {
+		config.Logging.RedactKeys = redactKeys
+	}
 
 	return &config, nil
 }
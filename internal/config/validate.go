@@ -0,0 +1,208 @@
+package config
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"valhafin/internal/domain/models"
+	"valhafin/internal/repository/database"
+	"valhafin/internal/service/encryption"
+)
+
+// ValidationError lists every problem found in a configuration
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid configuration:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+// Validate checks the configuration before anything is initialized so that the server
+// does not start half way and fail deep inside. All problems are reported at once.
+func (c *Config) Validate() error {
+	var problems []string
+	add := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	// Database
+	if strings.TrimSpace(c.Database.URL) == "" {
+		add("DATABASE_URL is required")
+	} else if _, err := database.ParseURL(c.Database.URL); err != nil {
+		add("DATABASE_URL: %v", err)
+	}
+	if c.Database.RetryAttempts < 1 {
+		add("DB_RETRY_ATTEMPTS must be at least 1 (got %d)", c.Database.RetryAttempts)
+	}
+	if c.Database.BreakerThreshold < 0 {
+		add("DB_BREAKER_THRESHOLD must not be negative (got %d)", c.Database.BreakerThreshold)
+	}
+	if c.Database.BreakerThreshold > 0 && c.Database.BreakerCooldownSeconds <= 0 {
+		add("DB_BREAKER_COOLDOWN_SECONDS must be positive when the circuit breaker is enabled (got %d)", c.Database.BreakerCooldownSeconds)
+	}
+
+	// Server
+	if c.Server.Port != "" {
+		if port, err := strconv.Atoi(c.Server.Port); err != nil || port <= 0 || port > 65535 {
+			add("PORT must be a number between 1 and 65535 (got %q)", c.Server.Port)
+		}
+	}
+	problems = append(problems, c.Server.encryptionProblems()...)
+	problems = append(problems, c.TLS.problems()...)
+
+	// General
+	if c.General.Timezone != "" {
+		if _, err := time.LoadLocation(c.General.Timezone); err != nil {
+			add("TIMEZONE %q is not a known time zone", c.General.Timezone)
+		}
+	}
+	if c.General.CurrencyDecimals < 0 {
+		add("CURRENCY_DECIMALS must not be negative (got %d)", c.General.CurrencyDecimals)
+	}
+	if c.General.PerformanceCacheSeconds < 0 {
+		add("PERFORMANCE_CACHE_SECONDS must not be negative (got %d)", c.General.PerformanceCacheSeconds)
+	}
+
+	// Alerts
+	if c.Alerts.WebhookURL != "" {
+		if u, err := url.Parse(c.Alerts.WebhookURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			add("ALERT_WEBHOOK_URL must be an http or https URL")
+		}
+	}
+
+	// Price provider
+	switch c.Price.Provider {
+	case PriceProviderYahoo, PriceProviderComposite, PriceProviderStatic:
+	case PriceProviderAlphaVantage:
+		if c.Price.AlphaVantageKey == "" {
+			add("ALPHA_VANTAGE_API_KEY is required when PRICE_PROVIDER is %s", PriceProviderAlphaVantage)
+		}
+	default:
+		add("PRICE_PROVIDER must be one of %s, %s, %s or %s (got %q)", PriceProviderYahoo,
+			PriceProviderAlphaVantage, PriceProviderComposite, PriceProviderStatic, c.Price.Provider)
+	}
+	switch c.Price.ExchangeRateProvider {
+	case "", ExchangeRateProviderAPI, ExchangeRateProviderECB:
+	default:
+		add("EXCHANGE_RATE_PROVIDER must be %s or %s (got %q)", ExchangeRateProviderAPI,
+			ExchangeRateProviderECB, c.Price.ExchangeRateProvider)
+	}
+	if c.Price.ECBRatesFile != "" {
+		if _, err := os.Stat(c.Price.ECBRatesFile); err != nil {
+			add("ECB_RATES_FILE %q cannot be read: %v", c.Price.ECBRatesFile, err)
+		}
+	}
+	if c.Price.RequestsPerMinute < 0 {
+		add("PRICE_REQUESTS_PER_MINUTE must not be negative (got %d)", c.Price.RequestsPerMinute)
+	}
+	if c.Price.RetentionDailyMonths < 0 || c.Price.RetentionWeeklyMonths < 0 {
+		add("PRICE_RETENTION_DAILY_MONTHS and PRICE_RETENTION_WEEKLY_MONTHS must not be negative")
+	} else if c.Price.RetentionDailyMonths > 0 && c.Price.RetentionWeeklyMonths > 0 &&
+		c.Price.RetentionWeeklyMonths < c.Price.RetentionDailyMonths {
+		add("PRICE_RETENTION_WEEKLY_MONTHS (%d) must not be shorter than PRICE_RETENTION_DAILY_MONTHS (%d)",
+			c.Price.RetentionWeeklyMonths, c.Price.RetentionDailyMonths)
+	}
+
+	// Trade Republic
+	if c.TradeRepublic.PINMinLength < 1 || c.TradeRepublic.PINMaxLength < c.TradeRepublic.PINMinLength {
+		add("TR_PIN_MIN_LENGTH and TR_PIN_MAX_LENGTH must define a valid range (got %d-%d)",
+			c.TradeRepublic.PINMinLength, c.TradeRepublic.PINMaxLength)
+	}
+
+	// Imports
+	if c.Import.CSVMaxSizeMB < 1 {
+		add("CSV_IMPORT_MAX_SIZE_MB must be at least 1 (got %d)", c.Import.CSVMaxSizeMB)
+	}
+	if c.Import.CSVMaxRows < 1 {
+		add("CSV_IMPORT_MAX_ROWS must be at least 1 (got %d)", c.Import.CSVMaxRows)
+	}
+
+	// Transaction types
+	if _, err := models.ParseTypeMappings(c.TransactionTypes.Mappings); err != nil {
+		add("TRANSACTION_TYPE_MAPPINGS: %v", err)
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// problems checks that the certificate and its key are set together and can be loaded
+func (t TLSConfig) problems() []string {
+	if !t.Enabled() {
+		return nil
+	}
+	if t.CertFile == "" || t.KeyFile == "" {
+		return []string{"TLS_CERT_FILE and TLS_KEY_FILE must be set together"}
+	}
+	if _, err := tls.LoadX509KeyPair(t.CertFile, t.KeyFile); err != nil {
+		return []string{fmt.Sprintf("TLS_CERT_FILE and TLS_KEY_FILE cannot be loaded: %v", err)}
+	}
+	return nil
+}
+
+// WeakKey returns why the configured key or passphrase is weak, or nil when it is strong
+// enough. It does not check that the key is valid.
+func (s ServerConfig) WeakKey() error {
+	if _, err := encryption.ParseKey(s.EncryptionKey); err == nil {
+		return encryption.CheckKeyStrength(s.EncryptionKey)
+	}
+	if s.EncryptionPassphrase != "" {
+		return encryption.CheckPassphraseStrength(s.EncryptionPassphrase)
+	}
+	return nil
+}
+
+// keyStrength returns the weakness of the key unless weak keys are allowed
+func (s ServerConfig) keyStrength() error {
+	if s.AllowWeakEncryptionKey {
+		return nil
+	}
+	return s.WeakKey()
+}
+
+// encryptionProblems checks that an encryption key can be obtained, either from
+// ENCRYPTION_KEY or derived from ENCRYPTION_PASSPHRASE, and that the previous keys are valid
+func (s ServerConfig) encryptionProblems() []string {
+	var problems []string
+
+	_, keyErr := encryption.ParseKey(s.EncryptionKey)
+	switch {
+	case keyErr == nil:
+		if err := s.keyStrength(); err != nil {
+			problems = append(problems, fmt.Sprintf("ENCRYPTION_KEY: %v (set ENCRYPTION_ALLOW_WEAK_KEY=true to start anyway)", err))
+		}
+	case s.EncryptionPassphrase != "":
+		if err := s.keyStrength(); err != nil {
+			problems = append(problems, fmt.Sprintf("ENCRYPTION_PASSPHRASE: %v (set ENCRYPTION_ALLOW_WEAK_KEY=true to start anyway)", err))
+		}
+		if len(s.EncryptionSalt) < encryption.MinSaltLength {
+			problems = append(problems, fmt.Sprintf("ENCRYPTION_SALT must be at least %d bytes when ENCRYPTION_PASSPHRASE is set", encryption.MinSaltLength))
+		}
+		if !encryption.IsKDFVersion(s.EncryptionKDFVersion) {
+			problems = append(problems, fmt.Sprintf("ENCRYPTION_KDF_VERSION %d is not supported", s.EncryptionKDFVersion))
+		}
+	case s.EncryptionKey == "":
+		problems = append(problems, "ENCRYPTION_KEY is required (or ENCRYPTION_PASSPHRASE and ENCRYPTION_SALT)")
+	default:
+		problems = append(problems, fmt.Sprintf("ENCRYPTION_KEY: %v", keyErr))
+	}
+
+	for i, keyStr := range strings.Split(s.PreviousEncryptionKeys, ",") {
+		keyStr = strings.TrimSpace(keyStr)
+		if keyStr == "" {
+			continue
+		}
+		if _, err := encryption.ParseKey(keyStr); err != nil {
+			problems = append(problems, fmt.Sprintf("ENCRYPTION_PREVIOUS_KEYS entry %d: %v", i+1, err))
+		}
+	}
+
+	return problems
+}
@@ -183,16 +183,16 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/accounts/{id}/fees": {
+        "/api/accounts/{id}/assets": {
             "get": {
-                "description": "Calcule les métriques de frais pour un compte spécifique",
+                "description": "Retourne les actifs distincts présents dans les transactions d'un compte, positions soldées comprises, avec leur nom et s'ils sont encore détenus (par exemple pour les listes de filtres)",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "fees"
+                    "accounts"
                 ],
-                "summary": "Frais d'un compte",
+                "summary": "Actifs négociés dans un compte",
                 "parameters": [
                     {
                         "type": "string",
@@ -202,15 +202,10 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "type": "string",
-                        "description": "Date de début (YYYY-MM-DD)",
-                        "name": "start_date",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Date de fin (YYYY-MM-DD)",
-                        "name": "end_date",
+                        "type": "boolean",
+                        "default": false,
+                        "description": "Inclure les transactions masquées",
+                        "name": "include_hidden",
                         "in": "query"
                     }
                 ],
@@ -218,13 +213,10 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/fees.FeesMetrics"
-                        }
-                    },
-                    "400": {
-                        "description": "Bad Request",
-                        "schema": {
-                            "$ref": "#/definitions/api.ErrorResponse"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/database.TradedAsset"
+                            }
                         }
                     },
                     "404": {
@@ -242,16 +234,16 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/accounts/{id}/performance": {
+        "/api/accounts/{id}/cashflow": {
             "get": {
-                "description": "Calcule les métriques de performance pour un compte spécifique",
+                "description": "Retourne l'effet de chaque transaction sur les liquidités, par ordre chronologique, avec le solde courant après chacune (dépôt +, achat −, vente +, intérêts +, frais −). Le dernier solde est le cash_balance de la performance du compte.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "performance"
                 ],
-                "summary": "Performance d'un compte",
+                "summary": "Relevé de trésorerie d'un compte",
                 "parameters": [
                     {
                         "type": "string",
@@ -261,10 +253,10 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "type": "string",
-                        "default": "1y",
-                        "description": "Période (1m, 3m, 1y, all)",
-                        "name": "period",
+                        "type": "boolean",
+                        "default": false,
+                        "description": "Inclure les transactions masquées",
+                        "name": "include_hidden",
                         "in": "query"
                     }
                 ],
@@ -272,7 +264,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/performance.Performance"
+                            "$ref": "#/definitions/performance.CashFlowStatement"
                         }
                     },
                     "400": {
@@ -296,16 +288,16 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/accounts/{id}/sync": {
-            "post": {
-                "description": "Déclenche la synchronisation des transactions pour un compte (Binance, Bourse Direct)",
+        "/api/accounts/{id}/export.zip": {
+            "get": {
+                "description": "Télécharge une archive ZIP contenant le compte (sans ses credentials), toutes ses transactions, les actifs associés et leurs prix en cache",
                 "produces": [
-                    "application/json"
+                    "application/zip"
                 ],
                 "tags": [
-                    "sync"
+                    "accounts"
                 ],
-                "summary": "Synchroniser un compte",
+                "summary": "Exporter un compte en ZIP",
                 "parameters": [
                     {
                         "type": "string",
@@ -319,8 +311,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "type": "file"
                         }
                     },
                     "400": {
@@ -344,19 +335,16 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/accounts/{id}/sync/complete": {
-            "post": {
-                "description": "Finalise la synchronisation en fournissant le code de vérification",
-                "consumes": [
-                    "application/json"
-                ],
+        "/api/accounts/{id}/fees": {
+            "get": {
+                "description": "Calcule les métriques de frais pour un compte spécifique",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "sync"
+                    "fees"
                 ],
-                "summary": "Compléter la synchronisation Trade Republic avec le code 2FA",
+                "summary": "Frais d'un compte",
                 "parameters": [
                     {
                         "type": "string",
@@ -366,21 +354,30 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "description": "Process ID et code 2FA",
-                        "name": "body",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/api.CompleteSyncRequest"
-                        }
+                        "type": "string",
+                        "description": "Date de début (YYYY-MM-DD)",
+                        "name": "start_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Date de fin (YYYY-MM-DD)",
+                        "name": "end_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "default": false,
+                        "description": "Inclure les transactions masquées",
+                        "name": "include_hidden",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/fees.FeesMetrics"
                         }
                     },
                     "400": {
@@ -404,16 +401,16 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/accounts/{id}/sync/init": {
-            "post": {
-                "description": "Déclenche l'authentification 2FA pour Trade Republic",
+        "/api/accounts/{id}/performance": {
+            "get": {
+                "description": "Calcule les métriques de performance pour un compte spécifique",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "sync"
+                    "performance"
                 ],
-                "summary": "Initier la synchronisation Trade Republic",
+                "summary": "Performance d'un compte",
                 "parameters": [
                     {
                         "type": "string",
@@ -421,13 +418,51 @@ const docTemplate = `{
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "default": "1y",
+                        "description": "Période (1m, 3m, 1y, all)",
+                        "name": "period",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Date de début (YYYY-MM-DD), à la place de period",
+                        "name": "start_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Date de fin (YYYY-MM-DD, aujourd'hui par défaut), avec start_date",
+                        "name": "end_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Date de valorisation (YYYY-MM-DD) : transactions rejouées jusqu'à cette date et prix historiques, avec period",
+                        "name": "as_of",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Intervalle de la série temporelle : auto (défaut), daily, weekly ou monthly",
+                        "name": "interval",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "default": false,
+                        "description": "Inclure les transactions masquées",
+                        "name": "include_hidden",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/api.InitSyncResponse"
+                            "$ref": "#/definitions/performance.Performance"
                         }
                     },
                     "400": {
@@ -451,16 +486,16 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/accounts/{id}/transactions": {
+        "/api/accounts/{id}/positions": {
             "get": {
-                "description": "Retourne les transactions paginées et filtrées d'un compte",
+                "description": "Retourne les actifs détenus dans un compte avec leur prix actuel et leur plus-value latente",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "transactions"
+                    "accounts"
                 ],
-                "summary": "Récupérer les transactions d'un compte",
+                "summary": "Positions d'un compte",
                 "parameters": [
                     {
                         "type": "string",
@@ -470,53 +505,16 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "type": "string",
-                        "description": "Date de début (YYYY-MM-DD)",
-                        "name": "start_date",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Date de fin (YYYY-MM-DD)",
-                        "name": "end_date",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Filtrer par ISIN",
-                        "name": "asset",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Filtrer par type (buy, sell, dividend, fee)",
-                        "name": "type",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "default": 1,
-                        "description": "Numéro de page",
-                        "name": "page",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "default": 50,
-                        "description": "Nombre de résultats par page",
-                        "name": "limit",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Trier par champ (timestamp, amount)",
-                        "name": "sort_by",
+                        "type": "boolean",
+                        "default": false,
+                        "description": "Inclure les transactions masquées",
+                        "name": "include_hidden",
                         "in": "query"
                     },
                     {
                         "type": "string",
-                        "description": "Ordre de tri (asc, desc)",
-                        "name": "sort_order",
+                        "description": "Date de valorisation (YYYY-MM-DD) : positions à cette date, aux prix historiques",
+                        "name": "as_of",
                         "in": "query"
                     }
                 ],
@@ -524,7 +522,10 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/api.TransactionResponse"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/api.AssetPosition"
+                            }
                         }
                     },
                     "400": {
@@ -548,52 +549,44 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/assets": {
+        "/api/accounts/{id}/positions/reconcile": {
             "get": {
-                "description": "Retourne tous les actifs avec les positions de l'utilisateur",
+                "description": "Compare les positions calculées à partir des transactions avec le dernier instantané des positions du courtier et liste les écarts (opérations sur titres manquantes, transactions absentes...)",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "assets"
+                    "positions"
+                ],
+                "summary": "Rapprocher les positions avec le courtier",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID du compte",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "default": false,
+                        "description": "Inclure les transactions masquées",
+                        "name": "include_hidden",
+                        "in": "query"
+                    }
                 ],
-                "summary": "Lister les actifs avec positions",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/api.AssetPosition"
-                            }
+                            "$ref": "#/definitions/api.PositionReconciliation"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "$ref": "#/definitions/api.ErrorResponse"
                         }
-                    }
-                }
-            }
-        },
-        "/api/assets/symbols/resolve": {
-            "post": {
-                "description": "Déclenche la résolution des symboles Yahoo Finance pour tous les actifs",
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "assets"
-                ],
-                "summary": "Résoudre tous les symboles manquants",
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": true
-                        }
                     },
                     "500": {
                         "description": "Internal Server Error",
@@ -604,34 +597,41 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/assets/{isin}/history": {
-            "get": {
-                "description": "Récupère l'historique des prix pour un actif sur une période donnée",
+        "/api/accounts/{id}/positions/sync": {
+            "post": {
+                "description": "Récupère les positions actuelles chez Trade Republic (quantité et prix moyen par ISIN) avec le code 2FA, les enregistre comme instantané et les rapproche des positions calculées à partir des transactions",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "assets"
+                    "positions"
                 ],
-                "summary": "Historique des prix d'un actif",
+                "summary": "Synchroniser les positions Trade Republic",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Code ISIN de l'actif",
-                        "name": "isin",
+                        "description": "ID du compte",
+                        "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "type": "string",
-                        "description": "Date de début (YYYY-MM-DD)",
-                        "name": "start_date",
-                        "in": "query"
+                        "description": "Process ID et code 2FA",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.CompleteSyncRequest"
+                        }
                     },
                     {
-                        "type": "string",
-                        "description": "Date de fin (YYYY-MM-DD)",
-                        "name": "end_date",
+                        "type": "boolean",
+                        "default": false,
+                        "description": "Inclure les transactions masquées",
+                        "name": "include_hidden",
                         "in": "query"
                     }
                 ],
@@ -639,10 +639,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.AssetPrice"
-                            }
+                            "$ref": "#/definitions/api.PositionReconciliation"
                         }
                     },
                     "400": {
@@ -657,6 +654,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/api.ErrorResponse"
                         }
                     },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -666,37 +669,31 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/assets/{isin}/performance": {
-            "get": {
-                "description": "Calcule les métriques de performance pour un actif spécifique",
+        "/api/accounts/{id}/rotate-credentials": {
+            "post": {
+                "description": "Déchiffre les credentials avec la clé actuelle ou une ancienne clé et les rechiffre avec la clé actuelle",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "performance"
+                    "accounts"
                 ],
-                "summary": "Performance d'un actif",
+                "summary": "Rechiffrer les credentials d'un compte",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Code ISIN de l'actif",
-                        "name": "isin",
+                        "description": "ID du compte",
+                        "name": "id",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "type": "string",
-                        "default": "1y",
-                        "description": "Période (1m, 3m, 1y, all)",
-                        "name": "period",
-                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/performance.AssetPerformance"
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     },
                     "400": {
@@ -720,21 +717,21 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/assets/{isin}/price": {
+        "/api/accounts/{id}/session": {
             "get": {
-                "description": "Récupère le prix actuel d'un actif par son code ISIN",
+                "description": "Indique si la session obtenue lors de la dernière authentification 2FA du compte est encore valide, auquel cas POST /api/accounts/{id}/sync la réutilise sans nouveau code",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "assets"
+                    "sync"
                 ],
-                "summary": "Prix actuel d'un actif",
+                "summary": "Session réutilisable d'un compte",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Code ISIN de l'actif",
-                        "name": "isin",
+                        "description": "ID du compte",
+                        "name": "id",
                         "in": "path",
                         "required": true
                     }
@@ -743,13 +740,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.AssetPrice"
-                        }
-                    },
-                    "400": {
-                        "description": "Bad Request",
-                        "schema": {
-                            "$ref": "#/definitions/api.ErrorResponse"
+                            "$ref": "#/definitions/api.AccountSessionResponse"
                         }
                     },
                     "404": {
@@ -767,35 +758,41 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/assets/{isin}/price/refresh": {
-            "post": {
-                "description": "Supprime le cache et récupère l'historique complet des prix",
+        "/api/accounts/{id}/summary": {
+            "get": {
+                "description": "Retourne le solde espèces, les dépôts, les intérêts et la valeur totale d'un compte, y compris pour les comptes sans titres",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "assets"
+                    "performance"
                 ],
-                "summary": "Rafraîchir les prix d'un actif",
+                "summary": "Résumé d'un compte",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Code ISIN de l'actif",
-                        "name": "isin",
+                        "description": "ID du compte",
+                        "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "default": false,
+                        "description": "Inclure les transactions masquées",
+                        "name": "include_hidden",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/performance.AccountSummary"
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "$ref": "#/definitions/api.ErrorResponse"
                         }
@@ -809,21 +806,21 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/assets/{isin}/price/update": {
+        "/api/accounts/{id}/sync": {
             "post": {
-                "description": "Force la mise à jour du prix actuel d'un actif",
+                "description": "Déclenche la synchronisation des transactions pour un compte (Binance, Bourse Direct). Un compte Trade Republic est synchronisé avec la session de sa dernière authentification 2FA tant qu'elle est valide ; sinon la 2FA est requise.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "assets"
+                    "sync"
                 ],
-                "summary": "Mettre à jour le prix d'un actif",
+                "summary": "Synchroniser un compte",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Code ISIN de l'actif",
-                        "name": "isin",
+                        "description": "ID du compte",
+                        "name": "id",
                         "in": "path",
                         "required": true
                     }
@@ -832,8 +829,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/types.SyncResult"
                         }
                     },
                     "400": {
@@ -842,18 +838,36 @@ const docTemplate = `{
                             "$ref": "#/definitions/api.ErrorResponse"
                         }
                     },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
                             "$ref": "#/definitions/api.ErrorResponse"
                         }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
                     }
                 }
             }
         },
-        "/api/assets/{isin}/symbol": {
-            "put": {
-                "description": "Met à jour le symbole Yahoo Finance d'un actif",
+        "/api/accounts/{id}/sync/complete": {
+            "post": {
+                "description": "Finalise la synchronisation en fournissant le code de vérification",
                 "consumes": [
                     "application/json"
                 ],
@@ -861,24 +875,24 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "assets"
+                    "sync"
                 ],
-                "summary": "Mettre à jour le symbole d'un actif",
+                "summary": "Compléter la synchronisation Trade Republic avec le code 2FA",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Code ISIN de l'actif",
-                        "name": "isin",
+                        "description": "ID du compte",
+                        "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Symbole et statut de vérification",
+                        "description": "Process ID et code 2FA",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "type": "object"
+                            "$ref": "#/definitions/api.CompleteSyncRequest"
                         }
                     }
                 ],
@@ -886,7 +900,8 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.Asset"
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     },
                     "400": {
@@ -901,48 +916,8 @@ const docTemplate = `{
                             "$ref": "#/definitions/api.ErrorResponse"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
-                        "schema": {
-                            "$ref": "#/definitions/api.ErrorResponse"
-                        }
-                    }
-                }
-            }
-        },
-        "/api/fees": {
-            "get": {
-                "description": "Calcule les métriques de frais pour tous les comptes",
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "fees"
-                ],
-                "summary": "Frais globaux",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "Date de début (YYYY-MM-DD)",
-                        "name": "start_date",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Date de fin (YYYY-MM-DD)",
-                        "name": "end_date",
-                        "in": "query"
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/fees.FeesMetrics"
-                        }
-                    },
-                    "400": {
-                        "description": "Bad Request",
+                    "409": {
+                        "description": "Conflict",
                         "schema": {
                             "$ref": "#/definitions/api.ErrorResponse"
                         }
@@ -956,30 +931,30 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/performance": {
-            "get": {
-                "description": "Calcule les métriques de performance pour tous les comptes",
+        "/api/accounts/{id}/sync/init": {
+            "post": {
+                "description": "Déclenche l'authentification 2FA pour Trade Republic",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "performance"
+                    "sync"
                 ],
-                "summary": "Performance globale",
+                "summary": "Initier la synchronisation Trade Republic",
                 "parameters": [
                     {
                         "type": "string",
-                        "default": "1y",
-                        "description": "Période (1m, 3m, 1y, all)",
-                        "name": "period",
-                        "in": "query"
+                        "description": "ID du compte",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/performance.Performance"
+                            "$ref": "#/definitions/api.InitSyncResponse"
                         }
                     },
                     "400": {
@@ -988,44 +963,8 @@ const docTemplate = `{
                             "$ref": "#/definitions/api.ErrorResponse"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
-                        "schema": {
-                            "$ref": "#/definitions/api.ErrorResponse"
-                        }
-                    }
-                }
-            }
-        },
-        "/api/symbols/search": {
-            "get": {
-                "description": "Recherche un symbole sur Yahoo Finance",
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "symbols"
-                ],
-                "summary": "Rechercher un symbole boursier",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "Terme de recherche",
-                        "name": "query",
-                        "in": "query",
-                        "required": true
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": true
-                        }
-                    },
-                    "400": {
-                        "description": "Bad Request",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "$ref": "#/definitions/api.ErrorResponse"
                         }
@@ -1039,17 +978,25 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/transactions": {
+        "/api/accounts/{id}/transactions": {
             "get": {
-                "description": "Retourne les transactions paginées de tous les comptes",
+                "description": "Retourne les transactions paginées et filtrées d'un compte, en CSV avec l'en-tête Accept: text/csv",
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "text/csv"
                 ],
                 "tags": [
                     "transactions"
                 ],
-                "summary": "Récupérer toutes les transactions",
+                "summary": "Récupérer les transactions d'un compte",
                 "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID du compte",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
                     {
                         "type": "string",
                         "description": "Date de début (YYYY-MM-DD)",
@@ -1074,6 +1021,12 @@ const docTemplate = `{
                         "name": "type",
                         "in": "query"
                     },
+                    {
+                        "type": "string",
+                        "description": "Filtrer par étiquette (ex: rebalance)",
+                        "name": "tag",
+                        "in": "query"
+                    },
                     {
                         "type": "integer",
                         "default": 1,
@@ -1099,6 +1052,20 @@ const docTemplate = `{
                         "description": "Ordre de tri (asc, desc)",
                         "name": "sort_order",
                         "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "default": false,
+                        "description": "Inclure les transactions supprimées",
+                        "name": "include_deleted",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "default": false,
+                        "description": "Inclure les transactions masquées",
+                        "name": "include_hidden",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -1114,6 +1081,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/api.ErrorResponse"
                         }
                     },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -1123,11 +1096,11 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/transactions/import": {
+        "/api/accounts/{id}/transactions/import-json": {
             "post": {
-                "description": "Importe des transactions à partir d'un fichier CSV avec déduplication",
+                "description": "Importe un tableau JSON de transactions dans un compte avec déduplication",
                 "consumes": [
-                    "multipart/form-data"
+                    "application/json"
                 ],
                 "produces": [
                     "application/json"
@@ -1135,21 +1108,26 @@ const docTemplate = `{
                 "tags": [
                     "transactions"
                 ],
-                "summary": "Importer des transactions depuis un CSV",
+                "summary": "Importer des transactions JSON",
                 "parameters": [
                     {
                         "type": "string",
                         "description": "ID du compte",
-                        "name": "account_id",
-                        "in": "formData",
+                        "name": "id",
+                        "in": "path",
                         "required": true
                     },
                     {
-                        "type": "file",
-                        "description": "Fichier CSV",
-                        "name": "file",
-                        "in": "formData",
-                        "required": true
+                        "description": "Transactions à importer",
+                        "name": "transactions",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Transaction"
+                            }
+                        }
                     }
                 ],
                 "responses": {
@@ -1180,42 +1158,165 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/transactions/{id}": {
-            "put": {
-                "description": "Met à jour une transaction existante",
-                "consumes": [
-                    "application/json"
-                ],
+        "/api/accounts/{id}/transactions/monthly": {
+            "get": {
+                "description": "Retourne, pour chaque mois (fuseau TIMEZONE), le nombre de transactions et le flux de trésorerie net (dépôts - retraits - achats + ventes). Avec fill=zero, les mois sans activité sont retournés à zéro ; avec fill=none (défaut), ils sont absents",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "transactions"
                 ],
-                "summary": "Modifier une transaction",
+                "summary": "Transactions d'un compte par mois",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "ID de la transaction",
+                        "description": "ID du compte",
                         "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Données de la transaction",
-                        "name": "transaction",
-                        "in": "body",
-                        "required": true,
+                        "type": "string",
+                        "description": "Date de début (YYYY-MM-DD)",
+                        "name": "start_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Date de fin (YYYY-MM-DD)",
+                        "name": "end_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filtrer par ISIN",
+                        "name": "asset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filtrer par type (buy, sell, dividend, fee)",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filtrer par étiquette (ex: rebalance)",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "none",
+                        "description": "Mois sans activité : none (absents) ou zero",
+                        "name": "fill",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "default": false,
+                        "description": "Inclure les transactions supprimées",
+                        "name": "include_deleted",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "default": false,
+                        "description": "Inclure les transactions masquées",
+                        "name": "include_hidden",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.Transaction"
+                            "$ref": "#/definitions/api.MonthlyTransactionsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
                         }
                     }
+                }
+            }
+        },
+        "/api/admin/migrations": {
+            "get": {
+                "description": "Retourne les migrations appliquées, la version actuelle du schéma et les migrations en attente ou manquantes",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
                 ],
+                "summary": "État des migrations",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.Transaction"
+                            "$ref": "#/definitions/database.MigrationStatus"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/admin/reclassify": {
+            "post": {
+                "description": "Recalcule le type des transactions Trade Republic enregistrées (éventuellement d'un seul compte) avec les correspondances de mots-clés actuelles et met à jour celles dont le type change (idempotent). Seule une correspondance de mot-clé change un type ; dry_run=true retourne les changements sans les écrire",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Reclasser les transactions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Plateforme (seule traderepublic est acceptée) ; requise sans account_id",
+                        "name": "platform",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Limiter le reclassement à un compte",
+                        "name": "account_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Retourner les changements sans les écrire",
+                        "name": "dry_run",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/database.ReclassifyResult"
                         }
                     },
                     "400": {
@@ -1239,439 +1340,3386 @@ const docTemplate = `{
                 }
             }
         },
-        "/health": {
+        "/api/admin/transaction-types": {
             "get": {
-                "description": "Retourne le statut de l'application et de la base de données",
+                "description": "Liste les mots-clés actifs (personnalisés puis intégrés) utilisés pour déterminer le type des transactions",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "monitoring"
+                    "admin"
                 ],
-                "summary": "Vérifier l'état de santé de l'application",
+                "summary": "Correspondances des types de transaction",
                 "responses": {
                     "200": {
-                        "description": "Application healthy",
+                        "description": "OK",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
+                    }
+                }
+            }
+        },
+        "/api/alerts": {
+            "get": {
+                "description": "Récupère toutes les règles d'alerte et leur état de déclenchement",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "alerts"
+                ],
+                "summary": "Lister les alertes",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Alert"
+                            }
+                        }
                     },
-                    "503": {
-                        "description": "Application unhealthy",
+                    "500": {
+                        "description": "Internal Server Error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Crée une règle d'alerte (unrealized_loss: perte latente en % d'un actif ou du portefeuille, negative_cash: solde espèces sous le seuil)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "alerts"
+                ],
+                "summary": "Créer une alerte",
+                "parameters": [
+                    {
+                        "description": "Règle d'alerte",
+                        "name": "alert",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.CreateAlertRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.Alert"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
                         }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "api.AssetPosition": {
-            "type": "object",
-            "properties": {
-                "average_buy_price": {
-                    "type": "number"
-                },
-                "currency": {
+        },
+        "/api/assets": {
+            "get": {
+                "description": "Retourne tous les actifs avec les positions de l'utilisateur",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "assets"
+                ],
+                "summary": "Lister les actifs avec positions",
+                "parameters": [
+                    {
+                        "type": "boolean",
+                        "default": false,
+                        "description": "Inclure les transactions masquées",
+                        "name": "include_hidden",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "default": 0,
+                        "description": "Valeur actuelle minimale des positions listées",
+                        "name": "min_value",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "default": false,
+                        "description": "Exclure aussi du total de répartition les positions sous min_value",
+                        "name": "strict",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Date de valorisation (YYYY-MM-DD) : positions à cette date, aux prix historiques",
+                        "name": "as_of",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/api.AssetPosition"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/assets/backfill-names": {
+            "post": {
+                "description": "Recherche sur Yahoo Finance (par ISIN puis par symbole) le nom des actifs encore nommés \"Unknown\" et l'enregistre. Un nom existant n'est jamais remplacé.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "assets"
+                ],
+                "summary": "Compléter les noms d'actifs inconnus",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.AssetNameBackfillResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/assets/merge": {
+            "post": {
+                "description": "Rattache les transactions, prix et alertes de from_isin à to_isin (les prix en double sont ignorés) puis supprime from_isin, dans une seule transaction",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "assets"
+                ],
+                "summary": "Fusionner deux actifs",
+                "parameters": [
+                    {
+                        "description": "Actif source et actif cible",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.MergeAssetsRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/database.AssetMergeResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/assets/symbols": {
+            "put": {
+                "description": "Applique une liste de symboles Yahoo Finance dans une seule transaction et retourne le résultat de chaque élément ; un ISIN inconnu est signalé en échec sans empêcher les autres mises à jour",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "assets"
+                ],
+                "summary": "Mettre à jour les symboles de plusieurs actifs",
+                "parameters": [
+                    {
+                        "description": "Symboles à appliquer (500 au maximum)",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/database.AssetSymbolUpdate"
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.UpdateAssetSymbolsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/assets/symbols/resolve": {
+            "post": {
+                "description": "Démarre en arrière-plan la résolution des symboles Yahoo Finance des actifs sans symbole vérifié et retourne le job ; si une résolution est déjà en cours, un job est mis en attente (queued) et démarre à sa fin pour prendre en compte les actifs ajoutés entre-temps",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "assets"
+                ],
+                "summary": "Résoudre tous les symboles manquants",
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/api.SymbolResolutionStatus"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/assets/symbols/resolve/{job_id}": {
+            "get": {
+                "description": "Retourne l'avancement d'un job de résolution des symboles (queued, running, completed ou timed_out)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "assets"
+                ],
+                "summary": "Suivre une résolution de symboles",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID du job",
+                        "name": "job_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.SymbolResolutionStatus"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/assets/traded": {
+            "get": {
+                "description": "Retourne les actifs distincts présents dans les transactions de tous les comptes (ou des comptes indiqués), positions soldées comprises, avec leur nom et s'ils sont encore détenus",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "assets"
+                ],
+                "summary": "Actifs négociés",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "IDs des comptes séparés par des virgules",
+                        "name": "account_ids",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "default": false,
+                        "description": "Inclure les transactions masquées",
+                        "name": "include_hidden",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/database.TradedAsset"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/assets/{isin}/backfill": {
+            "post": {
+                "description": "Récupère et stocke les prix journaliers d'un actif sur une période, par tranches pour respecter les limites du fournisseur",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "assets"
+                ],
+                "summary": "Importer l'historique des prix d'un actif",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Code ISIN de l'actif",
+                        "name": "isin",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Date de début (YYYY-MM-DD)",
+                        "name": "start_date",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Date de fin (YYYY-MM-DD, défaut : aujourd'hui)",
+                        "name": "end_date",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/assets/{isin}/history": {
+            "get": {
+                "description": "Récupère l'historique des prix pour un actif sur une période donnée",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "assets"
+                ],
+                "summary": "Historique des prix d'un actif",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Code ISIN de l'actif",
+                        "name": "isin",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Date de début (YYYY-MM-DD)",
+                        "name": "start_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Date de fin (YYYY-MM-DD)",
+                        "name": "end_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Intervalle : auto (défaut, hebdomadaire au-delà d'un mois) ou 1d (journalier quelle que soit la période)",
+                        "name": "interval",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Complète les jours sans cotation avec le dernier prix connu (défaut : false)",
+                        "name": "fill",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.AssetPrice"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/assets/{isin}/holdings-history": {
+            "get": {
+                "description": "Retourne la quantité détenue d'un actif à chaque point de la période, calculée en rejouant les transactions (sans prix)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "performance"
+                ],
+                "summary": "Historique des quantités détenues",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Code ISIN de l'actif",
+                        "name": "isin",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "default": "1y",
+                        "description": "Période (1m, 3m, 1y, all)",
+                        "name": "period",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Date de début (YYYY-MM-DD), à la place de period",
+                        "name": "start_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Date de fin (YYYY-MM-DD, aujourd'hui par défaut), avec start_date",
+                        "name": "end_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "default": false,
+                        "description": "Inclure les transactions masquées",
+                        "name": "include_hidden",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/performance.HoldingsHistory"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/assets/{isin}/lots": {
+            "get": {
+                "description": "Retourne chaque lot d'achat encore détenu (date, quantité, coût, valeur actuelle, plus-value latente au prix actuel). Les ventes consomment les lots les plus anciens de leur compte (FIFO).",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "performance"
+                ],
+                "summary": "Lots d'achat ouverts d'un actif",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Code ISIN de l'actif",
+                        "name": "isin",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "default": false,
+                        "description": "Inclure les transactions masquées",
+                        "name": "include_hidden",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/performance.AssetLots"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/assets/{isin}/metadata": {
+            "get": {
+                "description": "Retourne les métadonnées de transaction (symbole, nom, places de cotation) utilisées pour résoudre le symbole de l'actif",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "assets"
+                ],
+                "summary": "Métadonnées d'un actif",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Code ISIN de l'actif",
+                        "name": "isin",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.AssetMetadataResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/assets/{isin}/performance": {
+            "get": {
+                "description": "Calcule les métriques de performance pour un actif spécifique",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "performance"
+                ],
+                "summary": "Performance d'un actif",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Code ISIN de l'actif",
+                        "name": "isin",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "default": "1y",
+                        "description": "Période (1m, 3m, 1y, all)",
+                        "name": "period",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Date de début (YYYY-MM-DD), à la place de period",
+                        "name": "start_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Date de fin (YYYY-MM-DD, aujourd'hui par défaut), avec start_date",
+                        "name": "end_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "default": false,
+                        "description": "Inclure les transactions masquées",
+                        "name": "include_hidden",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/performance.AssetPerformance"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/assets/{isin}/price": {
+            "get": {
+                "description": "Récupère le prix actuel d'un actif par son code ISIN",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "assets"
+                ],
+                "summary": "Prix actuel d'un actif",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Code ISIN de l'actif",
+                        "name": "isin",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.AssetPrice"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/assets/{isin}/price/refresh": {
+            "post": {
+                "description": "Supprime le cache et récupère l'historique complet des prix",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "assets"
+                ],
+                "summary": "Rafraîchir les prix d'un actif",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Code ISIN de l'actif",
+                        "name": "isin",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/assets/{isin}/price/update": {
+            "post": {
+                "description": "Force la mise à jour du prix actuel d'un actif",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "assets"
+                ],
+                "summary": "Mettre à jour le prix d'un actif",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Code ISIN de l'actif",
+                        "name": "isin",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/assets/{isin}/prices/recent": {
+            "get": {
+                "description": "Récupère les derniers prix stockés d'un actif, du plus récent au plus ancien, sans appeler le fournisseur (pour les sparklines). Renvoie une liste vide si aucun prix n'est stocké.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "assets"
+                ],
+                "summary": "Derniers prix d'un actif",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Code ISIN de l'actif",
+                        "name": "isin",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Nombre de prix (défaut : 30, maximum : 1000)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.AssetPrice"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/assets/{isin}/symbol": {
+            "put": {
+                "description": "Met à jour le symbole Yahoo Finance d'un actif",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "assets"
+                ],
+                "summary": "Mettre à jour le symbole d'un actif",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Code ISIN de l'actif",
+                        "name": "isin",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Symbole et statut de vérification",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Asset"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/docs": {
+            "get": {
+                "description": "Affiche la spécification OpenAPI (/api/openapi.json) dans Swagger UI",
+                "produces": [
+                    "text/html"
+                ],
+                "tags": [
+                    "docs"
+                ],
+                "summary": "Documentation de l'API",
+                "responses": {
+                    "200": {
+                        "description": "Page HTML",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/fees": {
+            "get": {
+                "description": "Calcule les métriques de frais pour tous les comptes",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "fees"
+                ],
+                "summary": "Frais globaux",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Date de début (YYYY-MM-DD)",
+                        "name": "start_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Date de fin (YYYY-MM-DD)",
+                        "name": "end_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "default": false,
+                        "description": "Inclure les transactions masquées",
+                        "name": "include_hidden",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/fees.FeesMetrics"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/import.zip": {
+            "post": {
+                "description": "Restaure les transactions, actifs et prix d'une archive produite par l'export ZIP dans un compte existant sans transactions, de la même plateforme",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "accounts"
+                ],
+                "summary": "Importer une sauvegarde ZIP",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID du compte cible",
+                        "name": "account_id",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "Archive ZIP",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/api.BackupImportResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/import/profiles": {
+            "get": {
+                "description": "Récupère les profils de correspondance des colonnes CSV enregistrés",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "transactions"
+                ],
+                "summary": "Lister les profils d'import",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.ImportProfile"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Enregistre un profil de correspondance des colonnes CSV (colonne source → champ interne, format de date, séparateur décimal) réutilisable avec /api/transactions/import?profile=",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "transactions"
+                ],
+                "summary": "Créer un profil d'import",
+                "parameters": [
+                    {
+                        "description": "Profil d'import",
+                        "name": "profile",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.CreateImportProfileRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.ImportProfile"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/openapi.json": {
+            "get": {
+                "description": "Retourne la description OpenAPI 3.0 de l'API, convertie depuis la documentation Swagger générée à partir des annotations des handlers",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "docs"
+                ],
+                "summary": "Spécification OpenAPI",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/performance": {
+            "get": {
+                "description": "Calcule les métriques de performance pour tous les comptes",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "performance"
+                ],
+                "summary": "Performance globale",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "1y",
+                        "description": "Période (1m, 3m, 1y, all)",
+                        "name": "period",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Date de début (YYYY-MM-DD), à la place de period",
+                        "name": "start_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Date de fin (YYYY-MM-DD, aujourd'hui par défaut), avec start_date",
+                        "name": "end_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Date de valorisation (YYYY-MM-DD) : transactions rejouées jusqu'à cette date et prix historiques, avec period",
+                        "name": "as_of",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Indice ou ISIN de comparaison (ex: ^GSPC), séries normalisées à 100",
+                        "name": "benchmark",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Intervalle de la série temporelle : auto (défaut), daily, weekly ou monthly",
+                        "name": "interval",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "default": false,
+                        "description": "Inclure les transactions masquées",
+                        "name": "include_hidden",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/performance.Performance"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/platforms": {
+            "get": {
+                "description": "Récupère les plateformes supportées et leurs capacités (2FA, synchronisation incrémentale, identification des actifs)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "accounts"
+                ],
+                "summary": "Lister les plateformes supportées",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Platform"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/prices/cache/invalidate": {
+            "post": {
+                "description": "Supprime toutes les entrées du cache des prix, ou seulement celle d'un ISIN",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "assets"
+                ],
+                "summary": "Vider le cache des prix",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Code ISIN à retirer du cache (tout le cache si absent)",
+                        "name": "isin",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/prices/cache/stats": {
+            "get": {
+                "description": "Retourne la taille du cache des prix et ses compteurs de hits et de misses",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "assets"
+                ],
+                "summary": "Statistiques du cache des prix",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/price.CacheStats"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/search": {
+            "get": {
+                "description": "Recherche dans les actifs locaux (nom, symbole, ISIN) puis sur Yahoo Finance. Les actifs détenus sont renvoyés en premier.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "assets"
+                ],
+                "summary": "Rechercher un actif",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Terme de recherche",
+                        "name": "q",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/symbols/search": {
+            "get": {
+                "description": "Recherche un symbole sur Yahoo Finance",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "symbols"
+                ],
+                "summary": "Rechercher un symbole boursier",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Terme de recherche",
+                        "name": "query",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Recherche une liste de symboles sur Yahoo Finance (dédupliquée, limitée à 20 requêtes)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "symbols"
+                ],
+                "summary": "Rechercher plusieurs symboles boursiers",
+                "parameters": [
+                    {
+                        "description": "Termes de recherche",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.BatchSymbolSearchRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/sync/all": {
+            "post": {
+                "description": "Synchronise tous les comptes dont la plateforme ne nécessite pas de 2FA, quelques comptes à la fois. L'échec d'un compte n'interrompt pas les autres ; les comptes 2FA, ceux déjà en cours de synchronisation et ceux non atteints avant l'arrêt du serveur sont signalés comme ignorés (skipped).",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "sync"
+                ],
+                "summary": "Synchroniser tous les comptes",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/sync.BulkSyncSummary"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/transactions": {
+            "get": {
+                "description": "Retourne les transactions paginées de tous les comptes, en CSV avec l'en-tête Accept: text/csv",
+                "produces": [
+                    "application/json",
+                    "text/csv"
+                ],
+                "tags": [
+                    "transactions"
+                ],
+                "summary": "Récupérer toutes les transactions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Date de début (YYYY-MM-DD)",
+                        "name": "start_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Date de fin (YYYY-MM-DD)",
+                        "name": "end_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filtrer par ISIN",
+                        "name": "asset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filtrer par type (buy, sell, dividend, fee)",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filtrer par étiquette (ex: rebalance)",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Restreindre à une liste de comptes (IDs séparés par des virgules)",
+                        "name": "account_ids",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Numéro de page",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 50,
+                        "description": "Nombre de résultats par page",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Trier par champ (timestamp, amount)",
+                        "name": "sort_by",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Ordre de tri (asc, desc)",
+                        "name": "sort_order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "default": false,
+                        "description": "Inclure les transactions supprimées",
+                        "name": "include_deleted",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "default": false,
+                        "description": "Inclure les transactions masquées",
+                        "name": "include_hidden",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.TransactionResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/transactions/import": {
+            "post": {
+                "description": "Importe des transactions à partir d'un fichier CSV avec déduplication",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "transactions"
+                ],
+                "summary": "Importer des transactions depuis un CSV",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID du compte",
+                        "name": "account_id",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "Fichier CSV",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Rejeter les transactions datées dans le futur",
+                        "name": "reject_future",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Tolérance pour les dates futures (défaut: 24h)",
+                        "name": "future_tolerance",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Fusionner les exécutions partielles d'un même ordre",
+                        "name": "aggregate_fills",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Écart maximal entre exécutions partielles (défaut: 5s)",
+                        "name": "fill_window",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Nom du profil d'import à appliquer (colonnes, format de date, séparateur décimal)",
+                        "name": "profile",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.ImportSummary"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/transactions/move": {
+            "post": {
+                "description": "Rattache des transactions du compte source au compte cible, dans une seule transaction. Entre deux plateformes, les transactions sont validées à nouveau et copiées dans la table de la plateforme cible ; les plateformes doivent identifier les actifs de la même façon (ISIN ou symbole)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "transactions"
+                ],
+                "summary": "Déplacer des transactions vers un autre compte",
+                "parameters": [
+                    {
+                        "description": "Transactions, compte source et compte cible",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.MoveTransactionsRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/database.TransactionMoveResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/transactions/stream": {
+            "get": {
+                "description": "Renvoie, par ordre croissant de modification, les transactions créées ou modifiées après un curseur. Pensé pour le rafraîchissement incrémental des tableaux de bord, sans pagination par offset.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "transactions"
+                ],
+                "summary": "Flux des transactions modifiées",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Date de départ (RFC3339 ou YYYY-MM-DD), ignorée si cursor est fourni",
+                        "name": "since",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Curseur next_cursor renvoyé par l'appel précédent",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Restreindre à une liste de comptes (IDs séparés par des virgules)",
+                        "name": "account_ids",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 100,
+                        "description": "Nombre maximal de transactions (max 1000)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.TransactionStreamResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/transactions/{id}": {
+            "get": {
+                "description": "Retourne une transaction. Si account_id est fourni, la plateforme du compte est utilisée, sinon toutes les plateformes sont parcourues",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "transactions"
+                ],
+                "summary": "Récupérer une transaction par ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID de la transaction",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID du compte",
+                        "name": "account_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Transaction"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Met à jour les champs fournis d'une transaction ; les champs absents sont conservés. Si account_id est fourni, la plateforme du compte est utilisée, sinon toutes les plateformes sont parcourues",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "transactions"
+                ],
+                "summary": "Modifier une transaction",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID de la transaction",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Champs à modifier",
+                        "name": "transaction",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.TransactionUpdate"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Transaction"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/health": {
+            "get": {
+                "description": "Retourne le statut de l'application, de la base de données et du fournisseur de prix (up, degraded ou down). Seule la base de données conditionne le statut global : un fournisseur de prix dégradé est signalé sans rendre l'application indisponible.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "monitoring"
+                ],
+                "summary": "Vérifier l'état de santé de l'application",
+                "responses": {
+                    "200": {
+                        "description": "Application healthy",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "503": {
+                        "description": "Application unhealthy",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "api.AccountSessionResponse": {
+            "type": "object",
+            "properties": {
+                "account_id": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "reusable": {
+                    "description": "Reusable is true when a sync can run without a new 2FA code",
+                    "type": "boolean"
+                }
+            }
+        },
+        "api.AssetMetadataResponse": {
+            "type": "object",
+            "properties": {
+                "isin": {
+                    "type": "string"
+                },
+                "metadata": {
+                    "$ref": "#/definitions/models.TransactionMetadata"
+                }
+            }
+        },
+        "api.AssetNameBackfillResponse": {
+            "type": "object",
+            "properties": {
+                "failed": {
+                    "type": "integer"
+                },
+                "names": {
+                    "description": "Names maps the ISIN of each renamed asset to its new name",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "not_found": {
+                    "description": "NotFound counts the assets for which the provider returned no name",
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "updated": {
+                    "type": "integer"
+                }
+            }
+        },
+        "api.AssetPosition": {
+            "type": "object",
+            "properties": {
+                "allocation_pct": {
+                    "description": "AllocationPct is the share of the position in the total current value of the positions",
+                    "type": "number"
+                },
+                "average_buy_price": {
+                    "type": "number"
+                },
+                "currency": {
+                    "type": "string"
+                },
+                "current_price": {
+                    "type": "number"
+                },
+                "current_value": {
+                    "type": "number"
+                },
+                "isin": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "purchases": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.Purchase"
+                    }
+                },
+                "quantity": {
+                    "type": "number"
+                },
+                "symbol": {
+                    "type": "string"
+                },
+                "symbol_verified": {
+                    "type": "boolean"
+                },
+                "total_invested": {
+                    "type": "number"
+                },
+                "unrealized_gain": {
+                    "type": "number"
+                },
+                "unrealized_gain_pct": {
+                    "type": "number"
+                }
+            }
+        },
+        "api.BackupImportResponse": {
+            "type": "object",
+            "properties": {
+                "account_id": {
+                    "type": "string"
+                },
+                "assets": {
+                    "type": "integer"
+                },
+                "prices": {
+                    "type": "integer"
+                },
+                "transactions": {
+                    "type": "integer"
+                }
+            }
+        },
+        "api.BatchSymbolSearchRequest": {
+            "type": "object",
+            "properties": {
+                "queries": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "api.CompleteSyncRequest": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "process_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.CreateAccountRequest": {
+            "type": "object",
+            "properties": {
+                "credentials": {
+                    "type": "object",
+                    "additionalProperties": true
+                },
+                "currency": {
+                    "description": "Currency is the ISO 4217 default currency of the account, EUR when omitted",
+                    "type": "string"
+                },
+                "decimal_style": {
+                    "description": "DecimalStyle tells how the amounts of the CSV imports are written: auto (default), dot\n(\"1,234.56\") or comma (\"1.234,56\"). It decides how \"1,234\" is read.",
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "platform": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.CreateAlertRequest": {
+            "type": "object",
+            "properties": {
+                "enabled": {
+                    "type": "boolean"
+                },
+                "isin": {
+                    "type": "string"
+                },
+                "threshold": {
+                    "type": "number"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.CreateImportProfileRequest": {
+            "type": "object",
+            "properties": {
+                "columns": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "date_format": {
+                    "type": "string"
+                },
+                "decimal_style": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.ErrorDetail": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "details": {},
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.ErrorResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "$ref": "#/definitions/api.ErrorDetail"
+                }
+            }
+        },
+        "api.ImportSummary": {
+            "type": "object",
+            "properties": {
+                "details": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "errors": {
+                    "type": "integer"
+                },
+                "ignored": {
+                    "type": "integer"
+                },
+                "imported": {
+                    "type": "integer"
+                }
+            }
+        },
+        "api.InitSyncResponse": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string"
+                },
+                "process_id": {
+                    "type": "string"
+                },
+                "requires_two_factor": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "api.MergeAssetsRequest": {
+            "type": "object",
+            "properties": {
+                "from_isin": {
+                    "type": "string"
+                },
+                "to_isin": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.MonthlyTransactionsResponse": {
+            "type": "object",
+            "properties": {
+                "account_id": {
+                    "type": "string"
+                },
+                "fill": {
+                    "type": "string"
+                },
+                "months": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/database.MonthlyTransactions"
+                    }
+                }
+            }
+        },
+        "api.MoveTransactionsRequest": {
+            "type": "object",
+            "properties": {
+                "from_account_id": {
+                    "type": "string"
+                },
+                "to_account_id": {
+                    "type": "string"
+                },
+                "transaction_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "api.PositionDifference": {
+            "type": "object",
+            "properties": {
+                "broker_average_price": {
+                    "type": "number"
+                },
+                "broker_quantity": {
+                    "type": "number"
+                },
+                "computed_average_price": {
+                    "type": "number"
+                },
+                "computed_quantity": {
+                    "type": "number"
+                },
+                "isin": {
+                    "type": "string"
+                },
+                "quantity_difference": {
+                    "description": "Broker minus computed",
+                    "type": "number"
+                }
+            }
+        },
+        "api.PositionReconciliation": {
+            "type": "object",
+            "properties": {
+                "account_id": {
+                    "type": "string"
+                },
+                "differences": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.PositionDifference"
+                    }
+                },
+                "in_sync": {
+                    "type": "boolean"
+                },
+                "snapshot_id": {
+                    "type": "string"
+                },
+                "taken_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.Purchase": {
+            "type": "object",
+            "properties": {
+                "date": {
+                    "type": "string"
+                },
+                "price": {
+                    "type": "number"
+                },
+                "quantity": {
+                    "type": "number"
+                }
+            }
+        },
+        "api.SymbolResolutionStatus": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "failed": {
+                    "type": "integer"
+                },
+                "finished_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "resolved": {
+                    "type": "integer"
+                },
+                "skipped": {
+                    "description": "Skipped counts the assets without any symbol to resolve",
+                    "type": "integer"
+                },
+                "started_at": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "total": {
+                    "description": "Total is the number of assets without verified symbol when the job started",
+                    "type": "integer"
+                },
+                "unprocessed": {
+                    "description": "Unprocessed counts the assets not reached before the deadline",
+                    "type": "integer"
+                }
+            }
+        },
+        "api.TransactionResponse": {
+            "type": "object",
+            "properties": {
+                "limit": {
+                    "type": "integer"
+                },
+                "next_page": {
+                    "description": "NextPage and PrevPage are null at the boundaries",
+                    "type": "integer"
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "prev_page": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "total_pages": {
+                    "type": "integer"
+                },
+                "transactions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Transaction"
+                    }
+                }
+            }
+        },
+        "api.TransactionStreamResponse": {
+            "type": "object",
+            "properties": {
+                "has_more": {
+                    "description": "HasMore is true when more changes are available right away",
+                    "type": "boolean"
+                },
+                "next_cursor": {
+                    "description": "NextCursor is passed as cursor to fetch the following changes; it is returned\neven when the page is empty so that clients can keep polling from it",
+                    "type": "string"
+                },
+                "transactions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Transaction"
+                    }
+                }
+            }
+        },
+        "api.UpdateAssetSymbolsResponse": {
+            "type": "object",
+            "properties": {
+                "failed": {
+                    "type": "integer"
+                },
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/database.AssetSymbolUpdateResult"
+                    }
+                },
+                "updated": {
+                    "type": "integer"
+                }
+            }
+        },
+        "database.AppliedMigration": {
+            "type": "object",
+            "properties": {
+                "applied_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "database.AssetMergeResult": {
+            "type": "object",
+            "properties": {
+                "alerts_moved": {
+                    "type": "integer"
+                },
+                "duplicate_prices_removed": {
+                    "type": "integer"
+                },
+                "from_isin": {
+                    "type": "string"
+                },
+                "prices_moved": {
+                    "type": "integer"
+                },
+                "to_isin": {
+                    "type": "string"
+                },
+                "transactions_moved": {
+                    "type": "integer"
+                }
+            }
+        },
+        "database.AssetSymbolUpdate": {
+            "type": "object",
+            "properties": {
+                "isin": {
+                    "type": "string"
+                },
+                "symbol": {
+                    "type": "string"
+                },
+                "symbol_verified": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "database.AssetSymbolUpdateResult": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "isin": {
+                    "type": "string"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "database.MigrationInfo": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "database.MigrationStatus": {
+            "type": "object",
+            "properties": {
+                "applied": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/database.AppliedMigration"
+                    }
+                },
+                "current_version": {
+                    "type": "integer"
+                },
+                "latest_version": {
+                    "type": "integer"
+                },
+                "missing": {
+                    "description": "Missing are migrations older than the current version that were never recorded (partial apply)",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/database.MigrationInfo"
+                    }
+                },
+                "pending": {
+                    "description": "Pending are migrations newer than the current version",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/database.MigrationInfo"
+                    }
+                },
+                "unknown": {
+                    "description": "Unknown are recorded versions the application does not know (database ahead of the code)",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "up_to_date": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "database.MonthlyTransactions": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "month": {
+                    "description": "Month is the month in the filter time zone, as YYYY-MM",
+                    "type": "string"
+                },
+                "net_cash_flow": {
+                    "description": "NetCashFlow is deposits - withdrawals - buys + sells, whatever the sign of the stored amounts",
+                    "type": "number"
+                }
+            }
+        },
+        "database.ReclassifyResult": {
+            "type": "object",
+            "properties": {
+                "account_id": {
+                    "type": "string"
+                },
+                "changes": {
+                    "description": "Changes counts the updated rows by \"old-\u003enew\" type",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "dry_run": {
+                    "type": "boolean"
+                },
+                "examined": {
+                    "type": "integer"
+                },
+                "platform": {
+                    "type": "string"
+                },
+                "updated": {
+                    "description": "Updated counts the rows whose type changed, or would change in a dry run",
+                    "type": "integer"
+                }
+            }
+        },
+        "database.TradedAsset": {
+            "type": "object",
+            "properties": {
+                "held": {
+                    "type": "boolean"
+                },
+                "isin": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "quantity": {
+                    "type": "number"
+                }
+            }
+        },
+        "database.TransactionMoveResult": {
+            "type": "object",
+            "properties": {
+                "from_account_id": {
+                    "type": "string"
+                },
+                "from_platform": {
+                    "type": "string"
+                },
+                "moved": {
+                    "type": "integer"
+                },
+                "to_account_id": {
+                    "type": "string"
+                },
+                "to_platform": {
+                    "type": "string"
+                }
+            }
+        },
+        "fees.CostBreakdown": {
+            "type": "object",
+            "properties": {
+                "commission": {
+                    "description": "Commission is the explicit broker fee (Fees field)",
+                    "type": "number"
+                },
+                "implicit_costs": {
+                    "description": "ImplicitCosts is the part of a trade total that neither the shares value nor the\ncommission and taxes explain (spread, FX margin), when the trade details are available",
+                    "type": "number"
+                },
+                "taxes": {
+                    "description": "Taxes are the taxes withheld or paid (Taxes field), e.g. on dividends or financial transactions",
+                    "type": "number"
+                },
+                "total_costs": {
+                    "description": "TotalCosts is the sum of the three",
+                    "type": "number"
+                }
+            }
+        },
+        "fees.FeeTimeSeriesPoint": {
+            "type": "object",
+            "properties": {
+                "date": {
+                    "type": "string"
+                },
+                "fees": {
+                    "type": "number"
+                }
+            }
+        },
+        "fees.FeesMetrics": {
+            "type": "object",
+            "properties": {
+                "average_fees": {
+                    "type": "number"
+                },
+                "breakdown": {
+                    "$ref": "#/definitions/fees.CostBreakdown"
+                },
+                "fees_by_type": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "number",
+                        "format": "float64"
+                    }
+                },
+                "time_series": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/fees.FeeTimeSeriesPoint"
+                    }
+                },
+                "total_fees": {
+                    "type": "number"
+                },
+                "transaction_count": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.Account": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "currency": {
+                    "description": "Default currency of transactions and assets without one",
+                    "type": "string"
+                },
+                "decimal_style": {
+                    "description": "Separators of the imported amounts: auto, dot or comma",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "last_sync": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "platform": {
+                    "description": "\"traderepublic\", \"binance\", \"boursedirect\"",
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Alert": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "enabled": {
+                    "type": "boolean"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "isin": {
+                    "type": "string"
+                },
+                "last_triggered_at": {
+                    "type": "string"
+                },
+                "threshold": {
+                    "type": "number"
+                },
+                "triggered": {
+                    "description": "Set while the condition holds, to avoid firing every cycle",
+                    "type": "boolean"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Asset": {
+            "type": "object",
+            "properties": {
+                "currency": {
+                    "type": "string"
+                },
+                "isin": {
+                    "type": "string"
+                },
+                "last_updated": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "symbol": {
+                    "type": "string"
+                },
+                "symbol_verified": {
+                    "type": "boolean"
+                },
+                "type": {
+                    "description": "\"stock\", \"etf\", \"crypto\"",
+                    "type": "string"
+                }
+            }
+        },
+        "models.AssetPrice": {
+            "type": "object",
+            "properties": {
+                "currency": {
+                    "type": "string"
+                },
+                "filled": {
+                    "description": "Filled marks a day without quote carrying the previous price (see forward-fill)",
+                    "type": "boolean"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "isin": {
+                    "type": "string"
+                },
+                "price": {
+                    "type": "number"
+                },
+                "timestamp": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ColumnMapping": {
+            "type": "object",
+            "additionalProperties": {
+                "type": "string"
+            }
+        },
+        "models.ImportProfile": {
+            "type": "object",
+            "properties": {
+                "columns": {
+                    "description": "Columns maps source column names (case-insensitive) to internal fields",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.ColumnMapping"
+                        }
+                    ]
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "date_format": {
+                    "description": "DateFormat is the Go layout of the timestamp column (e.g. \"02/01/2006\"), RFC3339 when empty",
+                    "type": "string"
+                },
+                "decimal_style": {
+                    "description": "DecimalStyle is one of auto, dot or comma",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Platform": {
+            "type": "object",
+            "properties": {
+                "asset_key": {
+                    "description": "AssetKey is AssetKeyISIN or AssetKeySymbol",
+                    "type": "string"
+                },
+                "asset_type": {
+                    "description": "AssetType is the type given to the assets created from the platform transactions",
+                    "type": "string"
+                },
+                "credential_fields": {
+                    "description": "CredentialFields lists the credentials expected when creating an account",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "id": {
+                    "type": "string"
+                },
+                "incremental_sync": {
+                    "description": "IncrementalSync means the scraper only fetches transactions newer than the last sync",
+                    "type": "boolean"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "portfolio_snapshot": {
+                    "description": "PortfolioSnapshot means the current positions can be fetched from the platform",
+                    "type": "boolean"
+                },
+                "requires_two_factor": {
+                    "description": "RequiresTwoFactor means the platform is synchronized through the sync/init and\nsync/complete flow, and is skipped by automatic synchronizations",
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.Transaction": {
+            "type": "object",
+            "properties": {
+                "account_id": {
+                    "description": "New fields for database integration",
+                    "type": "string"
+                },
+                "action_payload": {
+                    "type": "string"
+                },
+                "action_type": {
+                    "type": "string"
+                },
+                "actions": {
+                    "description": "Details (when extract_details is true)",
+                    "type": "string"
+                },
+                "amount": {
+                    "type": "string"
+                },
+                "amount_currency": {
                     "type": "string"
                 },
-                "current_price": {
-                    "type": "number"
+                "amount_fraction": {
+                    "type": "integer"
                 },
-                "current_value": {
+                "amount_value": {
                     "type": "number"
                 },
-                "isin": {
+                "avatar": {
                     "type": "string"
                 },
-                "name": {
+                "cash_account_number": {
                     "type": "string"
                 },
-                "purchases": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/api.Purchase"
-                    }
+                "deleted": {
+                    "type": "boolean"
                 },
-                "quantity": {
-                    "type": "number"
+                "dividend_per_share": {
+                    "type": "string"
                 },
-                "symbol": {
+                "fees": {
                     "type": "string"
                 },
-                "symbol_verified": {
+                "hidden": {
                     "type": "boolean"
                 },
-                "total_invested": {
-                    "type": "number"
+                "icon": {
+                    "type": "string"
                 },
-                "unrealized_gain": {
-                    "type": "number"
+                "id": {
+                    "type": "string"
                 },
-                "unrealized_gain_pct": {
-                    "type": "number"
-                }
-            }
-        },
-        "api.CompleteSyncRequest": {
-            "type": "object",
-            "properties": {
-                "code": {
+                "isin": {
                     "type": "string"
                 },
-                "process_id": {
+                "metadata": {
+                    "description": "JSON string for additional platform-specific data",
                     "type": "string"
-                }
-            }
-        },
-        "api.CreateAccountRequest": {
-            "type": "object",
-            "properties": {
-                "credentials": {
-                    "type": "object",
-                    "additionalProperties": true
                 },
-                "name": {
+                "note": {
                     "type": "string"
                 },
-                "platform": {
+                "quantity": {
+                    "type": "number"
+                },
+                "share_price": {
                     "type": "string"
-                }
-            }
-        },
-        "api.ErrorDetail": {
-            "type": "object",
-            "properties": {
-                "code": {
+                },
+                "shares": {
                     "type": "string"
                 },
-                "details": {},
-                "message": {
+                "status": {
                     "type": "string"
-                }
-            }
-        },
-        "api.ErrorResponse": {
-            "type": "object",
-            "properties": {
-                "error": {
-                    "$ref": "#/definitions/api.ErrorDetail"
-                }
-            }
-        },
-        "api.ImportSummary": {
-            "type": "object",
-            "properties": {
-                "details": {
+                },
+                "subtitle": {
+                    "type": "string"
+                },
+                "tags": {
+                    "description": "Tags and Note are annotations of the user; they never affect the calculations",
                     "type": "array",
                     "items": {
                         "type": "string"
                     }
                 },
-                "errors": {
-                    "type": "integer"
-                },
-                "ignored": {
-                    "type": "integer"
+                "taxes": {
+                    "type": "string"
                 },
-                "imported": {
-                    "type": "integer"
-                }
-            }
-        },
-        "api.InitSyncResponse": {
-            "type": "object",
-            "properties": {
-                "message": {
+                "timestamp": {
                     "type": "string"
                 },
-                "process_id": {
+                "title": {
                     "type": "string"
                 },
-                "requires_two_factor": {
-                    "type": "boolean"
-                }
-            }
-        },
-        "api.Purchase": {
-            "type": "object",
-            "properties": {
-                "date": {
+                "total": {
                     "type": "string"
                 },
-                "price": {
-                    "type": "number"
+                "transaction_type": {
+                    "description": "\"buy\", \"sell\", \"dividend\", \"fee\"",
+                    "type": "string"
                 },
-                "quantity": {
-                    "type": "number"
+                "updated_at": {
+                    "description": "UpdatedAt is when the row was last inserted or modified; only loaded by the transactions stream",
+                    "type": "string"
                 }
             }
         },
-        "api.TransactionResponse": {
+        "models.TransactionChanges": {
             "type": "object",
             "properties": {
-                "limit": {
-                    "type": "integer"
-                },
-                "page": {
-                    "type": "integer"
-                },
-                "total": {
-                    "type": "integer"
+                "inserted": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 },
-                "total_pages": {
-                    "type": "integer"
+                "unchanged": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 },
-                "transactions": {
+                "updated": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/models.Transaction"
+                        "type": "string"
                     }
                 }
             }
         },
-        "fees.FeeTimeSeriesPoint": {
+        "models.TransactionMetadata": {
             "type": "object",
             "properties": {
-                "date": {
+                "exchanges": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "name": {
                     "type": "string"
                 },
-                "fees": {
+                "quantity_delta": {
+                    "description": "QuantityDelta is the number of shares a corporate action adds (spin-off, bonus shares)\nor removes (reverse split compensation) from the position of its asset",
                     "type": "number"
+                },
+                "raw": {
+                    "type": "string"
+                },
+                "symbol": {
+                    "type": "string"
                 }
             }
         },
-        "fees.FeesMetrics": {
+        "models.TransactionUpdate": {
             "type": "object",
             "properties": {
-                "average_fees": {
-                    "type": "number"
-                },
-                "fees_by_type": {
-                    "type": "object",
-                    "additionalProperties": {
-                        "type": "number",
-                        "format": "float64"
-                    }
+                "account_id": {
+                    "description": "AccountID, when set, must be the account of the transaction; it is not updated",
+                    "type": "string"
                 },
-                "time_series": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/fees.FeeTimeSeriesPoint"
-                    }
+                "amount_currency": {
+                    "type": "string"
                 },
-                "total_fees": {
+                "amount_value": {
                     "type": "number"
                 },
-                "transaction_count": {
-                    "type": "integer"
-                }
-            }
-        },
-        "models.Account": {
-            "type": "object",
-            "properties": {
-                "created_at": {
+                "fees": {
                     "type": "string"
                 },
-                "id": {
+                "isin": {
+                    "description": "ISIN set to an empty string removes the asset of the transaction",
                     "type": "string"
                 },
-                "last_sync": {
+                "note": {
                     "type": "string"
                 },
-                "name": {
+                "quantity": {
+                    "type": "number"
+                },
+                "subtitle": {
                     "type": "string"
                 },
-                "platform": {
-                    "description": "\"traderepublic\", \"binance\", \"boursedirect\"",
+                "tags": {
+                    "description": "Tags replace the tags of the transaction; an empty list removes them",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "title": {
                     "type": "string"
                 },
-                "updated_at": {
+                "transaction_type": {
                     "type": "string"
                 }
             }
         },
-        "models.Asset": {
+        "performance.AccountSummary": {
             "type": "object",
             "properties": {
-                "currency": {
+                "account_id": {
                     "type": "string"
                 },
-                "isin": {
-                    "type": "string"
+                "assets_value": {
+                    "type": "number"
                 },
-                "last_updated": {
-                    "type": "string"
+                "cash_balance": {
+                    "type": "number"
+                },
+                "cash_only": {
+                    "type": "boolean"
                 },
                 "name": {
                     "type": "string"
                 },
-                "symbol": {
+                "performance_pct": {
+                    "type": "number"
+                },
+                "platform": {
                     "type": "string"
                 },
-                "symbol_verified": {
-                    "type": "boolean"
+                "total_deposits": {
+                    "type": "number"
                 },
-                "type": {
-                    "description": "\"stock\", \"etf\", \"crypto\"",
-                    "type": "string"
+                "total_fees": {
+                    "type": "number"
+                },
+                "total_interest": {
+                    "type": "number"
+                },
+                "total_invested": {
+                    "type": "number"
+                },
+                "total_value": {
+                    "description": "Assets value plus cash balance",
+                    "type": "number"
                 }
             }
         },
-        "models.AssetPrice": {
+        "performance.AssetLots": {
             "type": "object",
             "properties": {
-                "currency": {
-                    "type": "string"
-                },
-                "id": {
-                    "type": "integer"
+                "current_price": {
+                    "type": "number"
                 },
                 "isin": {
                     "type": "string"
                 },
-                "price": {
-                    "type": "number"
+                "lots": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/performance.Lot"
+                    }
                 },
-                "timestamp": {
+                "name": {
                     "type": "string"
                 }
             }
         },
-        "models.Transaction": {
+        "performance.AssetPerformance": {
             "type": "object",
             "properties": {
-                "account_id": {
-                    "description": "New fields for database integration",
-                    "type": "string"
-                },
-                "action_payload": {
-                    "type": "string"
-                },
-                "action_type": {
-                    "type": "string"
-                },
-                "actions": {
-                    "description": "Details (when extract_details is true)",
-                    "type": "string"
+                "current_price": {
+                    "type": "number"
                 },
-                "amount": {
+                "isin": {
                     "type": "string"
                 },
-                "amount_currency": {
+                "name": {
                     "type": "string"
                 },
-                "amount_fraction": {
-                    "type": "integer"
-                },
-                "amount_value": {
+                "performance_pct": {
                     "type": "number"
                 },
-                "avatar": {
-                    "type": "string"
+                "realized_gains": {
+                    "type": "number"
                 },
-                "cash_account_number": {
-                    "type": "string"
+                "time_series": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/performance.PerformancePoint"
+                    }
                 },
-                "deleted": {
-                    "type": "boolean"
+                "total_fees": {
+                    "type": "number"
                 },
-                "dividend_per_share": {
-                    "type": "string"
+                "total_invested": {
+                    "type": "number"
                 },
-                "fees": {
-                    "type": "string"
+                "total_quantity": {
+                    "type": "number"
                 },
-                "hidden": {
-                    "type": "boolean"
+                "total_value": {
+                    "type": "number"
                 },
-                "icon": {
+                "unrealized_gains": {
+                    "type": "number"
+                }
+            }
+        },
+        "performance.BenchmarkComparison": {
+            "type": "object",
+            "properties": {
+                "benchmark": {
                     "type": "string"
                 },
-                "id": {
-                    "type": "string"
+                "portfolio": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/performance.NormalizedPoint"
+                    }
                 },
-                "isin": {
-                    "type": "string"
+                "series": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/performance.NormalizedPoint"
+                    }
+                }
+            }
+        },
+        "performance.CashFlowEntry": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "description": "Amount is the cash moved by the transaction, fees included: positive for deposits,\nsales, interests and dividends, negative for withdrawals, buys and fees",
+                    "type": "number"
                 },
-                "metadata": {
-                    "description": "JSON string for additional platform-specific data",
+                "date": {
                     "type": "string"
                 },
-                "quantity": {
+                "running_balance": {
+                    "description": "RunningBalance is the cash balance after the transaction",
                     "type": "number"
                 },
-                "share_price": {
-                    "type": "string"
-                },
-                "shares": {
+                "transaction_id": {
                     "type": "string"
                 },
-                "status": {
+                "type": {
                     "type": "string"
-                },
-                "subtitle": {
+                }
+            }
+        },
+        "performance.CashFlowStatement": {
+            "type": "object",
+            "properties": {
+                "account_id": {
                     "type": "string"
                 },
-                "taxes": {
-                    "type": "string"
+                "balance": {
+                    "description": "Balance is the running balance after the last entry, the cash balance of the account",
+                    "type": "number"
                 },
-                "timestamp": {
+                "currency": {
                     "type": "string"
                 },
-                "title": {
-                    "type": "string"
+                "entries": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/performance.CashFlowEntry"
+                    }
+                }
+            }
+        },
+        "performance.HoldingsHistory": {
+            "type": "object",
+            "properties": {
+                "holdings": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/performance.HoldingsPoint"
+                    }
                 },
-                "total": {
+                "isin": {
                     "type": "string"
                 },
-                "transaction_type": {
-                    "description": "\"buy\", \"sell\", \"dividend\", \"fee\"",
+                "period": {
                     "type": "string"
                 }
             }
         },
-        "performance.AssetPerformance": {
+        "performance.HoldingsPoint": {
             "type": "object",
             "properties": {
-                "current_price": {
-                    "type": "number"
-                },
-                "isin": {
+                "date": {
                     "type": "string"
                 },
-                "name": {
+                "quantity": {
+                    "type": "number"
+                }
+            }
+        },
+        "performance.Lot": {
+            "type": "object",
+            "properties": {
+                "account_id": {
                     "type": "string"
                 },
-                "performance_pct": {
+                "bought_quantity": {
                     "type": "number"
                 },
-                "realized_gains": {
+                "cost": {
+                    "description": "Cost is the cost of the quantity still held",
                     "type": "number"
                 },
-                "time_series": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/performance.PerformancePoint"
-                    }
+                "current_value": {
+                    "type": "number"
                 },
-                "total_fees": {
+                "date": {
+                    "type": "string"
+                },
+                "quantity": {
+                    "description": "Quantity is the quantity still held from the buy, out of BoughtQuantity",
                     "type": "number"
                 },
-                "total_invested": {
+                "transaction_id": {
+                    "type": "string"
+                },
+                "unit_cost": {
                     "type": "number"
                 },
-                "total_quantity": {
+                "unrealized_gain": {
                     "type": "number"
                 },
-                "total_value": {
+                "unrealized_gain_pct": {
                     "type": "number"
+                }
+            }
+        },
+        "performance.NormalizedPoint": {
+            "type": "object",
+            "properties": {
+                "date": {
+                    "type": "string"
                 },
-                "unrealized_gains": {
+                "value": {
                     "type": "number"
                 }
             }
@@ -1679,9 +4727,25 @@ const docTemplate = `{
         "performance.Performance": {
             "type": "object",
             "properties": {
+                "benchmark": {
+                    "description": "Benchmark is only set when a benchmark comparison is requested",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/performance.BenchmarkComparison"
+                        }
+                    ]
+                },
                 "cash_balance": {
                     "type": "number"
                 },
+                "cash_only": {
+                    "description": "No security was ever traded (e.g. a savings account)",
+                    "type": "boolean"
+                },
+                "dividend_income": {
+                    "description": "Dividends, part of the investment return",
+                    "type": "number"
+                },
                 "performance_pct": {
                     "type": "number"
                 },
@@ -1694,15 +4758,30 @@ const docTemplate = `{
                         "$ref": "#/definitions/performance.PerformancePoint"
                     }
                 },
+                "total_deposits": {
+                    "description": "Deposits net of withdrawals",
+                    "type": "number"
+                },
                 "total_fees": {
                     "type": "number"
                 },
+                "total_interest": {
+                    "description": "Interest paid on cash, not an investment return",
+                    "type": "number"
+                },
                 "total_invested": {
                     "type": "number"
                 },
                 "total_value": {
                     "type": "number"
                 },
+                "unpriced_assets": {
+                    "description": "UnpricedAssets lists the ISINs of the held assets without price, valued at their\ninvested amount (so without gain or loss), usually because their symbol is not resolved",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
                 "unrealized_gains": {
                     "type": "number"
                 }
@@ -1723,6 +4802,109 @@ const docTemplate = `{
                     "type": "number"
                 }
             }
+        },
+        "price.CacheStats": {
+            "type": "object",
+            "properties": {
+                "expired": {
+                    "description": "Entries past their TTL",
+                    "type": "integer"
+                },
+                "hit_rate": {
+                    "description": "Hits over lookups, 0 without lookups",
+                    "type": "number"
+                },
+                "hits": {
+                    "type": "integer"
+                },
+                "misses": {
+                    "type": "integer"
+                },
+                "size": {
+                    "description": "Entries held, including expired ones not yet replaced",
+                    "type": "integer"
+                },
+                "ttl_seconds": {
+                    "type": "integer"
+                }
+            }
+        },
+        "sync.BulkSyncSummary": {
+            "type": "object",
+            "properties": {
+                "canceled": {
+                    "type": "boolean"
+                },
+                "failed": {
+                    "type": "integer"
+                },
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/types.SyncResult"
+                    }
+                },
+                "skipped": {
+                    "type": "integer"
+                },
+                "succeeded": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "types.SyncResult": {
+            "type": "object",
+            "properties": {
+                "account_id": {
+                    "type": "string"
+                },
+                "changes": {
+                    "description": "Changes reports which stored transactions were new, updated or unchanged",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.TransactionChanges"
+                        }
+                    ]
+                },
+                "duration": {
+                    "type": "string"
+                },
+                "end_time": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "platform": {
+                    "type": "string"
+                },
+                "start_time": {
+                    "type": "string"
+                },
+                "status": {
+                    "description": "\"success\", \"partial\", \"failed\" or \"skipped\"",
+                    "type": "string"
+                },
+                "sync_type": {
+                    "description": "\"full\" or \"incremental\"",
+                    "type": "string"
+                },
+                "transactions_added": {
+                    "type": "integer"
+                },
+                "transactions_fetched": {
+                    "type": "integer"
+                },
+                "warnings": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
         }
     }
 }`
@@ -1732,7 +4914,7 @@ var SwaggerInfo = &swag.Spec{
 	Version:          "1.0",
 	Host:             "localhost:8080",
 	BasePath:         "/",
-	Schemes:          []string{"http"},
+	Schemes:          []string{"http", "https"},
 	Title:            "Valhafin API",
 	Description:      "API de gestion de portefeuille financier - synchronisation de comptes, suivi de performance et analyse des frais.",
 	InfoInstanceName: "swagger",
@@ -2,18 +2,21 @@ package models
 
 import (
 	"errors"
+	"strings"
 	"time"
 )
 
 // Account represents a financial account on a trading platform
 type Account struct {
-	ID          string     `json:"id" db:"id"`
-	Name        string     `json:"name" db:"name"`
-	Platform    string     `json:"platform" db:"platform"` // "traderepublic", "binance", "boursedirect"
-	Credentials string     `json:"-" db:"credentials"`     // Encrypted credentials
-	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
-	UpdatedAt   time.Time  `json:"updated_at" db:"updated_at"`
-	LastSync    *time.Time `json:"last_sync,omitempty" db:"last_sync"`
+	ID           string     `json:"id" db:"id"`
+	Name         string     `json:"name" db:"name"`
+	Platform     string     `json:"platform" db:"platform"`           // "traderepublic", "binance", "boursedirect"
+	Credentials  string     `json:"-" db:"credentials"`               // Encrypted credentials
+	Currency     string     `json:"currency" db:"currency"`           // Default currency of transactions and assets without one
+	DecimalStyle string     `json:"decimal_style" db:"decimal_style"` // Separators of the imported amounts: auto, dot or comma
+	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at" db:"updated_at"`
+	LastSync     *time.Time `json:"last_sync,omitempty" db:"last_sync"`
 }
 
 // Validate validates the Account model
@@ -27,19 +30,51 @@ func (a *Account) Validate() error {
 	}
 
 	// Validate platform is one of the supported platforms
-	validPlatforms := map[string]bool{
-		"traderepublic": true,
-		"binance":       true,
-		"boursedirect":  true,
-	}
-
-	if !validPlatforms[a.Platform] {
-		return errors.New("platform must be one of: traderepublic, binance, boursedirect")
+	if !IsPlatform(a.Platform) {
+		return errors.New("platform must be one of: " + strings.Join(PlatformIDs(), ", "))
 	}
 
 	if a.Credentials == "" {
 		return errors.New("credentials are required")
 	}
 
+	if a.Currency != "" && !IsCurrencyCode(a.Currency) {
+		return errors.New("currency must be an ISO 4217 code (e.g. EUR, USD)")
+	}
+
+	if a.DecimalStyle != "" && !IsDecimalStyle(a.DecimalStyle) {
+		return errors.New("decimal_style must be one of: auto, dot, comma")
+	}
+
 	return nil
 }
+
+// DecimalStyleOrDefault returns the account decimal style, auto for accounts created before styles existed
+func (a *Account) DecimalStyleOrDefault() string {
+	if a.DecimalStyle == "" {
+		return DecimalStyleAuto
+	}
+	return a.DecimalStyle
+}
+
+// CurrencyOrDefault returns the account currency, EUR for accounts created before currencies existed
+func (a *Account) CurrencyOrDefault() string {
+	if a.Currency == "" {
+		return DefaultCurrency
+	}
+	return a.Currency
+}
+
+// AccountSession is a platform session kept after a two-factor authentication, so that the
+// account can be synchronized again without a new code until the session expires
+type AccountSession struct {
+	AccountID string    `json:"account_id" db:"account_id"`
+	Token     string    `json:"-" db:"token"` // Encrypted
+	ExpiresAt time.Time `json:"expires_at" db:"expires_at"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// ValidAt reports whether the session can still be used at now
+func (s *AccountSession) ValidAt(now time.Time) bool {
+	return s.Token != "" && now.Before(s.ExpiresAt)
+}
@@ -0,0 +1,55 @@
+package models
+
+import (
+	"errors"
+	"regexp"
+	"time"
+)
+
+// Alert types
+const (
+	// AlertTypeUnrealizedLoss fires when the unrealized loss of an asset (or of the whole
+	// portfolio when no ISIN is set) exceeds Threshold percent
+	AlertTypeUnrealizedLoss = "unrealized_loss"
+	// AlertTypeNegativeCash fires when the cash balance drops below Threshold (usually 0)
+	AlertTypeNegativeCash = "negative_cash"
+)
+
+// Alert represents a user-defined alert rule on the portfolio
+type Alert struct {
+	ID              string     `json:"id" db:"id"`
+	Type            string     `json:"type" db:"type"`
+	ISIN            *string    `json:"isin,omitempty" db:"isin"`
+	Threshold       float64    `json:"threshold" db:"threshold"`
+	Enabled         bool       `json:"enabled" db:"enabled"`
+	Triggered       bool       `json:"triggered" db:"triggered"` // Set while the condition holds, to avoid firing every cycle
+	LastTriggeredAt *time.Time `json:"last_triggered_at,omitempty" db:"last_triggered_at"`
+	CreatedAt       time.Time  `json:"created_at" db:"created_at"`
+}
+
+// Validate validates the Alert model
+func (a *Alert) Validate() error {
+	switch a.Type {
+	case AlertTypeUnrealizedLoss:
+		if a.Threshold <= 0 || a.Threshold > 100 {
+			return errors.New("threshold must be a percentage between 0 and 100")
+		}
+	case AlertTypeNegativeCash:
+		if a.ISIN != nil && *a.ISIN != "" {
+			return errors.New("isin is not allowed for negative_cash alerts")
+		}
+	case "":
+		return errors.New("alert type is required")
+	default:
+		return errors.New("alert type must be one of: unrealized_loss, negative_cash")
+	}
+
+	if a.ISIN != nil && *a.ISIN != "" {
+		isinRegex := regexp.MustCompile(`^[A-Z]{2}[A-Z0-9]{10}$`)
+		if !isinRegex.MatchString(*a.ISIN) {
+			return errors.New("ISIN must be 12 characters: 2 letters followed by 10 alphanumeric characters")
+		}
+	}
+
+	return nil
+}
@@ -6,6 +6,9 @@ import (
 	"time"
 )
 
+// UnknownAssetName is the name of assets created without any name, until a real one is known
+const UnknownAssetName = "Unknown"
+
 // Asset represents a financial asset (stock, ETF, crypto)
 type Asset struct {
 	ISIN           string    `json:"isin" db:"isin"`
@@ -60,3 +63,38 @@ func (a *Asset) Validate() error {
 
 	return nil
 }
+
+// isinFormat is the format of an ISIN: a country code, 9 alphanumeric characters and a check digit
+var isinFormat = regexp.MustCompile(`^[A-Z]{2}[A-Z0-9]{9}[0-9]$`)
+
+// IsValidISIN reports whether isin is a well-formed ISIN with a valid check digit (ISO 6166:
+// letters are expanded to two digits, then the Luhn algorithm applies)
+func IsValidISIN(isin string) bool {
+	if !isinFormat.MatchString(isin) {
+		return false
+	}
+
+	digits := make([]int, 0, 2*len(isin))
+	for _, c := range isin {
+		if c >= 'A' && c <= 'Z' {
+			value := int(c-'A') + 10
+			digits = append(digits, value/10, value%10)
+		} else {
+			digits = append(digits, int(c-'0'))
+		}
+	}
+
+	sum := 0
+	for i := len(digits) - 1; i >= 0; i-- {
+		digit := digits[i]
+		// Every second digit from the right, the check digit excluded, is doubled
+		if (len(digits)-1-i)%2 == 1 {
+			digit *= 2
+			if digit > 9 {
+				digit -= 9
+			}
+		}
+		sum += digit
+	}
+	return sum%10 == 0
+}
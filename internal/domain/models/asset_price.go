@@ -2,6 +2,7 @@ package models
 
 import (
 	"errors"
+	"math"
 	"time"
 )
 
@@ -12,6 +13,8 @@ type AssetPrice struct {
 	Price     float64   `json:"price" db:"price"`
 	Currency  string    `json:"currency" db:"currency"`
 	Timestamp time.Time `json:"timestamp" db:"timestamp"`
+	// Filled marks a day without quote carrying the previous price (see forward-fill)
+	Filled bool `json:"filled,omitempty" db:"-"`
 }
 
 // Validate validates the AssetPrice model
@@ -20,8 +23,8 @@ func (ap *AssetPrice) Validate() error {
 		return errors.New("ISIN is required")
 	}
 
-	if ap.Price <= 0 {
-		return errors.New("price must be greater than 0")
+	if !(ap.Price > 0) || math.IsInf(ap.Price, 0) {
+		return errors.New("price must be a finite number greater than 0")
 	}
 
 	if ap.Currency == "" {
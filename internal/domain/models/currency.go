@@ -0,0 +1,44 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultCurrency is the currency of accounts created without one
+const DefaultCurrency = "EUR"
+
+// iso4217Codes is the set of active ISO 4217 alphabetic currency codes, with the
+// precious metals (XAU, XAG, XPT, XPD) that brokers quote like currencies
+var iso4217Codes = func() map[string]bool {
+	codes := strings.Fields(`
+		AED AFN ALL AMD ANG AOA ARS AUD AWG AZN BAM BBD BDT BGN BHD BIF BMD BND BOB BRL
+		BSD BTN BWP BYN BZD CAD CDF CHF CLP CNY COP CRC CUP CVE CZK DJF DKK DOP DZD EGP
+		ERN ETB EUR FJD FKP GBP GEL GHS GIP GMD GNF GTQ GYD HKD HNL HTG HUF IDR ILS INR
+		IQD IRR ISK JMD JOD JPY KES KGS KHR KMF KPW KRW KWD KYD KZT LAK LBP LKR LRD LSL
+		LYD MAD MDL MGA MKD MMK MNT MOP MRU MUR MVR MWK MXN MYR MZN NAD NGN NIO NOK NPR
+		NZD OMR PAB PEN PGK PHP PKR PLN PYG QAR RON RSD RUB RWF SAR SBD SCR SDG SEK SGD
+		SHP SLE SOS SRD SSP STN SVC SYP SZL THB TJS TMT TND TOP TRY TTD TWD TZS UAH UGX
+		USD UYU UZS VES VND VUV WST XAF XCD XCG XOF XPF YER ZAR ZMW ZWG
+		XAU XAG XPT XPD
+	`)
+	set := make(map[string]bool, len(codes))
+	for _, code := range codes {
+		set[code] = true
+	}
+	return set
+}()
+
+// IsCurrencyCode reports whether code is an active ISO 4217 currency code (e.g. EUR, USD)
+func IsCurrencyCode(code string) bool {
+	return iso4217Codes[code]
+}
+
+// ValidateCurrencyCode returns an error when code is not an ISO 4217 currency code.
+// Codes are expected in upper case.
+func ValidateCurrencyCode(code string) error {
+	if !IsCurrencyCode(code) {
+		return fmt.Errorf("currency %q is not an ISO 4217 code (e.g. EUR, USD)", code)
+	}
+	return nil
+}
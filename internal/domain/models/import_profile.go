@@ -0,0 +1,189 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Decimal styles of an import profile
+const (
+	// DecimalStyleAuto guesses the separators of each value (default)
+	DecimalStyleAuto = "auto"
+	// DecimalStyleDot reads "1,234.56": dot decimals, comma thousands
+	DecimalStyleDot = "dot"
+	// DecimalStyleComma reads "1.234,56": comma decimals, dot thousands
+	DecimalStyleComma = "comma"
+)
+
+// ImportFields are the internal fields a CSV column can be mapped to
+var ImportFields = []string{
+	"id", "timestamp", "title", "icon", "avatar", "subtitle",
+	"amount_currency", "amount_value", "amount_fraction", "status",
+	"action_type", "action_payload", "cash_account_number", "hidden", "deleted",
+	"actions", "dividend_per_share", "taxes", "total", "shares", "share_price",
+	"fees", "amount", "isin", "quantity", "transaction_type", "metadata",
+}
+
+// ImportProfileRequiredFields must be mapped by every import profile
+var ImportProfileRequiredFields = []string{"timestamp", "isin", "amount_value"}
+
+// importDecimalFields are the numeric fields normalized with the profile decimal style
+var importDecimalFields = map[string]bool{
+	"amount_value":    true,
+	"amount_fraction": true,
+	"quantity":        true,
+	"fees":            true,
+}
+
+// ColumnMapping maps a source CSV column name to an internal field
+type ColumnMapping map[string]string
+
+// Value stores the mapping as JSON
+func (m ColumnMapping) Value() (driver.Value, error) {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// Scan reads the mapping from its JSON representation
+func (m *ColumnMapping) Scan(src interface{}) error {
+	var data []byte
+	switch v := src.(type) {
+	case nil:
+		*m = nil
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("unsupported column mapping type %T", src)
+	}
+	return json.Unmarshal(data, m)
+}
+
+// ImportProfile is a named CSV column mapping for a broker export
+type ImportProfile struct {
+	ID   string `json:"id" db:"id"`
+	Name string `json:"name" db:"name"`
+	// Columns maps source column names (case-insensitive) to internal fields
+	Columns ColumnMapping `json:"columns" db:"columns"`
+	// DateFormat is the Go layout of the timestamp column (e.g. "02/01/2006"), RFC3339 when empty
+	DateFormat string `json:"date_format,omitempty" db:"date_format"`
+	// DecimalStyle is one of auto, dot or comma
+	DecimalStyle string    `json:"decimal_style" db:"decimal_style"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+}
+
+// Validate validates the ImportProfile model and normalizes its column names
+func (p *ImportProfile) Validate() error {
+	p.Name = strings.TrimSpace(p.Name)
+	if p.Name == "" {
+		return errors.New("name is required")
+	}
+	if len(p.Columns) == 0 {
+		return errors.New("columns are required")
+	}
+
+	known := make(map[string]bool, len(ImportFields))
+	for _, field := range ImportFields {
+		known[field] = true
+	}
+
+	normalized := make(ColumnMapping, len(p.Columns))
+	mapped := make(map[string]string, len(p.Columns))
+	for source, field := range p.Columns {
+		source = strings.ToLower(strings.TrimSpace(source))
+		field = strings.ToLower(strings.TrimSpace(field))
+		if source == "" {
+			return errors.New("column names must not be empty")
+		}
+		if !known[field] {
+			return fmt.Errorf("unknown field %q for column %q", field, source)
+		}
+		if previous, ok := mapped[field]; ok && previous != source {
+			return fmt.Errorf("field %q is mapped by both %q and %q", field, previous, source)
+		}
+		mapped[field] = source
+		normalized[source] = field
+	}
+	p.Columns = normalized
+
+	var missing []string
+	for _, field := range ImportProfileRequiredFields {
+		if _, ok := mapped[field]; !ok {
+			missing = append(missing, field)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return fmt.Errorf("columns must map the required fields: %s", strings.Join(missing, ", "))
+	}
+
+	if p.DateFormat != "" {
+		reference := time.Date(2024, 12, 31, 15, 4, 5, 0, time.UTC)
+		parsed, err := time.Parse(p.DateFormat, reference.Format(p.DateFormat))
+		if err != nil || parsed.Year() != 2024 || parsed.Month() != time.December || parsed.Day() != 31 {
+			return fmt.Errorf("date_format must be a Go layout with year, month and day (e.g. 02/01/2006)")
+		}
+	}
+
+	if p.DecimalStyle == "" {
+		p.DecimalStyle = DecimalStyleAuto
+	}
+	if !IsDecimalStyle(p.DecimalStyle) {
+		return errors.New("decimal_style must be one of: auto, dot, comma")
+	}
+
+	return nil
+}
+
+// MapHeader returns the internal field of each source column. Columns the
+// profile does not map are returned as empty strings so that they are ignored.
+func (p *ImportProfile) MapHeader(header []string) []string {
+	mapped := make([]string, len(header))
+	for i, column := range header {
+		mapped[i] = p.Columns[strings.ToLower(strings.TrimSpace(column))]
+	}
+	return mapped
+}
+
+// NormalizeValue converts a value of the given internal field to the format expected
+// by the CSV import: timestamps to RFC3339 and numbers to dot decimals.
+func (p *ImportProfile) NormalizeValue(field, value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return value, nil
+	}
+
+	if field == "timestamp" && p.DateFormat != "" {
+		parsed, err := time.Parse(p.DateFormat, value)
+		if err != nil {
+			return "", fmt.Errorf("invalid timestamp %q (expected format %s)", value, p.DateFormat)
+		}
+		return parsed.Format(time.RFC3339), nil
+	}
+
+	if importDecimalFields[field] && p.DecimalStyle != DecimalStyleAuto && p.DecimalStyle != "" {
+		// The value is read once with the profile style, then left unambiguous to the import
+		parse := ParseDecimalStyle
+		if field == "fees" {
+			parse = ParseMoneyStyle // Fees may carry a currency, like in the native format
+		}
+		number, err := parse(value, p.DecimalStyle)
+		if err != nil {
+			return "", fmt.Errorf("invalid %s %q: %w", field, value, err)
+		}
+		return strconv.FormatFloat(number, 'f', -1, 64), nil
+	}
+
+	return value, nil
+}
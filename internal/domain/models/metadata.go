@@ -0,0 +1,171 @@
+package models
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// TransactionMetadata holds the platform-specific data attached to a transaction.
+// Symbol, Name and Exchanges are used to resolve the Yahoo Finance symbol of the asset;
+// Raw keeps metadata that could not be read as such.
+type TransactionMetadata struct {
+	Symbol    string   `json:"symbol,omitempty"`
+	Name      string   `json:"name,omitempty"`
+	Exchanges []string `json:"exchanges,omitempty"`
+	Raw       string   `json:"raw,omitempty"`
+	// QuantityDelta is the number of shares a corporate action adds (spin-off, bonus shares)
+	// or removes (reverse split compensation) from the position of its asset
+	QuantityDelta float64 `json:"quantity_delta,omitempty"`
+}
+
+// ParseTransactionMetadata reads stored metadata. It never fails: metadata that is
+// not a JSON object with the expected field types is returned under Raw.
+func ParseTransactionMetadata(metadata string) TransactionMetadata {
+	fields, ok := normalizeMetadataFields(metadata)
+	if !ok {
+		return TransactionMetadata{Raw: metadata}
+	}
+
+	parsed := TransactionMetadata{}
+	parsed.Symbol, _ = fields["symbol"].(string)
+	parsed.Name, _ = fields["name"].(string)
+	parsed.Raw, _ = fields["raw"].(string)
+	if exchanges, ok := fields["exchanges"].([]string); ok {
+		parsed.Exchanges = exchanges
+	}
+	parsed.QuantityDelta, _ = fields["quantity_delta"].(float64)
+	return parsed
+}
+
+// CorporateActionQuantity returns the shares added to (or removed from, when negative) the
+// position by a corporate action, 0 for any other transaction
+func (t *Transaction) CorporateActionQuantity() float64 {
+	if t.TransactionType != TransactionTypeCorporateAction {
+		return 0
+	}
+	return t.ParsedMetadata().QuantityDelta
+}
+
+// NormalizeMetadata validates metadata before it is stored. Known fields are trimmed
+// and a single exchange is turned into a list; anything that is not a JSON object
+// with the expected field types is wrapped as {"raw": "..."}. Empty metadata stays empty.
+func NormalizeMetadata(metadata string) string {
+	if strings.TrimSpace(metadata) == "" {
+		return ""
+	}
+
+	fields, ok := normalizeMetadataFields(metadata)
+	if !ok {
+		wrapped, _ := json.Marshal(map[string]string{"raw": metadata})
+		return string(wrapped)
+	}
+
+	normalized, err := json.Marshal(fields)
+	if err != nil {
+		wrapped, _ := json.Marshal(map[string]string{"raw": metadata})
+		return string(wrapped)
+	}
+	return string(normalized)
+}
+
+// NormalizeMetadata normalizes the transaction metadata in place, clearing it when empty
+func (t *Transaction) NormalizeMetadata() {
+	if t.Metadata == nil {
+		return
+	}
+
+	normalized := NormalizeMetadata(*t.Metadata)
+	if normalized == "" {
+		t.Metadata = nil
+		return
+	}
+	t.Metadata = &normalized
+}
+
+// ParsedMetadata returns the typed metadata of the transaction
+func (t *Transaction) ParsedMetadata() TransactionMetadata {
+	if t.Metadata == nil {
+		return TransactionMetadata{}
+	}
+	return ParseTransactionMetadata(*t.Metadata)
+}
+
+// normalizeMetadataFields decodes a metadata object and normalizes its known fields.
+// Unknown fields are kept as is. It reports false when the metadata has an unknown shape.
+func normalizeMetadataFields(metadata string) (map[string]interface{}, bool) {
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(metadata), &fields); err != nil || fields == nil {
+		return nil, false
+	}
+
+	for _, key := range []string{"symbol", "name", "raw"} {
+		value, exists := fields[key]
+		if !exists || value == nil {
+			delete(fields, key)
+			continue
+		}
+		str, ok := value.(string)
+		if !ok {
+			return nil, false
+		}
+		if str = strings.TrimSpace(str); str == "" {
+			delete(fields, key)
+		} else {
+			fields[key] = str
+		}
+	}
+
+	if value, exists := fields["quantity_delta"]; exists {
+		switch delta := value.(type) {
+		case nil:
+			delete(fields, "quantity_delta")
+		case float64:
+			if delta == 0 {
+				delete(fields, "quantity_delta")
+			}
+		default:
+			return nil, false
+		}
+	}
+
+	if value, exists := fields["exchanges"]; exists {
+		exchanges, ok := normalizeExchanges(value)
+		if !ok {
+			return nil, false
+		}
+		if len(exchanges) == 0 {
+			delete(fields, "exchanges")
+		} else {
+			fields["exchanges"] = exchanges
+		}
+	}
+
+	return fields, true
+}
+
+// normalizeExchanges accepts a list of exchange codes or a single code
+func normalizeExchanges(value interface{}) ([]string, bool) {
+	switch v := value.(type) {
+	case nil:
+		return nil, true
+	case string:
+		if v = strings.TrimSpace(v); v == "" {
+			return nil, true
+		}
+		return []string{v}, true
+	case []interface{}:
+		exchanges := make([]string, 0, len(v))
+		for _, item := range v {
+			str, ok := item.(string)
+			if !ok {
+				return nil, false
+			}
+			if str = strings.TrimSpace(str); str != "" {
+				exchanges = append(exchanges, str)
+			}
+		}
+		return exchanges, true
+	default:
+		return nil, false
+	}
+}
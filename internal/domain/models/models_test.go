@@ -1,6 +1,9 @@
 package models
 
 import (
+	"encoding/json"
+	"math"
+	"strings"
 	"testing"
 	"time"
 )
@@ -50,6 +53,26 @@ func TestAccountValidation(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "valid currency",
+			account: Account{
+				Name:        "My Account",
+				Platform:    "binance",
+				Credentials: "encrypted_credentials",
+				Currency:    "USD",
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid currency",
+			account: Account{
+				Name:        "My Account",
+				Platform:    "binance",
+				Credentials: "encrypted_credentials",
+				Currency:    "dollar",
+			},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -161,6 +184,25 @@ func TestAssetPriceValidation(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "zero price",
+			price: AssetPrice{
+				ISIN:      "US0378331005",
+				Currency:  "USD",
+				Timestamp: now,
+			},
+			wantErr: true,
+		},
+		{
+			name: "NaN price",
+			price: AssetPrice{
+				ISIN:      "US0378331005",
+				Price:     math.NaN(),
+				Currency:  "USD",
+				Timestamp: now,
+			},
+			wantErr: true,
+		},
 		{
 			name: "missing timestamp",
 			price: AssetPrice{
@@ -226,6 +268,55 @@ func TestTransactionValidation(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "USD currency",
+			transaction: Transaction{
+				ID:             "txn_123",
+				AccountID:      "acc_123",
+				Timestamp:      time.Now().Format(time.RFC3339),
+				AmountCurrency: "USD",
+			},
+			wantErr: false,
+		},
+		{
+			name: "two-letter currency",
+			transaction: Transaction{
+				ID:             "txn_123",
+				AccountID:      "acc_123",
+				Timestamp:      time.Now().Format(time.RFC3339),
+				AmountCurrency: "US",
+			},
+			wantErr: true,
+		},
+		{
+			name: "four-letter currency",
+			transaction: Transaction{
+				ID:             "txn_123",
+				AccountID:      "acc_123",
+				Timestamp:      time.Now().Format(time.RFC3339),
+				AmountCurrency: "EURO",
+			},
+			wantErr: true,
+		},
+		{
+			name: "unknown three-letter currency",
+			transaction: Transaction{
+				ID:             "txn_123",
+				AccountID:      "acc_123",
+				Timestamp:      time.Now().Format(time.RFC3339),
+				AmountCurrency: "ABC",
+			},
+			wantErr: true,
+		},
+		{
+			name: "missing currency",
+			transaction: Transaction{
+				ID:        "txn_123",
+				AccountID: "acc_123",
+				Timestamp: time.Now().Format(time.RFC3339),
+			},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -237,3 +328,404 @@ func TestTransactionValidation(t *testing.T) {
 		})
 	}
 }
+
+func TestIsCurrencyCode(t *testing.T) {
+	for _, code := range []string{"EUR", "USD", "GBP", "CHF", "JPY", "XAU"} {
+		if !IsCurrencyCode(code) {
+			t.Errorf("expected %s to be an ISO 4217 code", code)
+		}
+	}
+	for _, code := range []string{"", "US", "EURO", "usd", "ABC", "BTC"} {
+		if IsCurrencyCode(code) {
+			t.Errorf("expected %q not to be an ISO 4217 code", code)
+		}
+	}
+}
+
+func TestTransactionValidateNotFuture(t *testing.T) {
+	now := time.Date(2024, 6, 15, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		timestamp string
+		tolerance time.Duration
+		wantErr   bool
+	}{
+		{"past timestamp", "2024-06-01T10:00:00Z", DefaultFutureTolerance, false},
+		{"now", "2024-06-15T12:00:00Z", 0, false},
+		{"within tolerance", "2024-06-16T11:00:00Z", DefaultFutureTolerance, false},
+		{"beyond tolerance", "2024-06-16T13:00:00Z", DefaultFutureTolerance, true},
+		{"far future", "2026-01-01T00:00:00Z", DefaultFutureTolerance, true},
+		{"offset taken into account", "2024-06-16T14:00:00+02:00", DefaultFutureTolerance, false},
+		{"unparseable timestamp left to Validate", "invalid", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tx := Transaction{Timestamp: tt.timestamp}
+			err := tx.ValidateNotFuture(now, tt.tolerance)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Transaction.ValidateNotFuture() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTransactionInferQuantity(t *testing.T) {
+	tests := []struct {
+		name         string
+		transaction  Transaction
+		wantQuantity float64
+		wantInferred bool
+	}{
+		{
+			name:         "shares with zero quantity",
+			transaction:  Transaction{TransactionType: "buy", Shares: "2,5", Quantity: 0},
+			wantQuantity: 2.5,
+			wantInferred: true,
+		},
+		{
+			name:         "shares with thousands separator and unit",
+			transaction:  Transaction{TransactionType: "buy", Shares: "1 234,5 titres"},
+			wantQuantity: 1234.5,
+			wantInferred: true,
+		},
+		{
+			name:         "sell with negative shares",
+			transaction:  Transaction{TransactionType: "sell", Shares: "-3"},
+			wantQuantity: 3,
+			wantInferred: true,
+		},
+		{
+			name:         "existing quantity is kept",
+			transaction:  Transaction{TransactionType: "buy", Shares: "2,5", Quantity: 4},
+			wantQuantity: 4,
+		},
+		{
+			name:         "no shares",
+			transaction:  Transaction{TransactionType: "buy"},
+			wantQuantity: 0,
+		},
+		{
+			name:         "unparsable shares",
+			transaction:  Transaction{TransactionType: "buy", Shares: "n/a"},
+			wantQuantity: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inferred := tt.transaction.InferQuantity()
+			if inferred != tt.wantInferred {
+				t.Errorf("InferQuantity() = %v, want %v", inferred, tt.wantInferred)
+			}
+			if tt.transaction.Quantity != tt.wantQuantity {
+				t.Errorf("Quantity = %v, want %v", tt.transaction.Quantity, tt.wantQuantity)
+			}
+		})
+	}
+}
+
+func TestParseShares(t *testing.T) {
+	tests := map[string]float64{
+		"0,5":      0.5,
+		"12.5":     12.5,
+		"1 234,56": 1234.56,
+		"1.234,56": 1234.56,
+		"1,234.56": 1234.56,
+		"3 titres": 3,
+		"0,000123": 0.000123,
+		" 10,5":    10.5,
+		// Read like the other numbers (ParseDecimal)
+		"1,234,567": 1234567,
+		"1e3":       1000,
+	}
+
+	for input, want := range tests {
+		got, err := ParseShares(input)
+		if err != nil {
+			t.Errorf("ParseShares(%q) error = %v", input, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("ParseShares(%q) = %v, want %v", input, got, want)
+		}
+	}
+
+	if _, err := ParseShares("abc"); err == nil {
+		t.Error("ParseShares(\"abc\") should fail")
+	}
+}
+
+func TestNormalizeMetadata(t *testing.T) {
+	tests := []struct {
+		name     string
+		metadata string
+		want     string
+	}{
+		{"empty", "  ", ""},
+		{"valid metadata is kept", `{"symbol":"AAPL","exchanges":["LSX"],"name":"Apple"}`, `{"exchanges":["LSX"],"name":"Apple","symbol":"AAPL"}`},
+		{"unknown fields are kept", `{"symbol":"AAPL","source":"timeline"}`, `{"source":"timeline","symbol":"AAPL"}`},
+		{"values are trimmed", `{"symbol":" AAPL ","name":""}`, `{"symbol":"AAPL"}`},
+		{"single exchange becomes a list", `{"symbol":"AAPL","exchanges":"LSX"}`, `{"exchanges":["LSX"],"symbol":"AAPL"}`},
+		{"invalid JSON is wrapped", `not json`, `{"raw":"not json"}`},
+		{"non object is wrapped", `["AAPL"]`, `{"raw":"[\"AAPL\"]"}`},
+		{"wrong symbol type is wrapped", `{"symbol":42}`, `{"raw":"{\"symbol\":42}"}`},
+		{"wrong exchanges type is wrapped", `{"exchanges":[1,2]}`, `{"raw":"{\"exchanges\":[1,2]}"}`},
+		{"quantity delta is kept", `{"quantity_delta":-2.5}`, `{"quantity_delta":-2.5}`},
+		{"zero quantity delta is dropped", `{"symbol":"AAPL","quantity_delta":0}`, `{"symbol":"AAPL"}`},
+		{"wrong quantity delta type is wrapped", `{"quantity_delta":"3"}`, `{"raw":"{\"quantity_delta\":\"3\"}"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NormalizeMetadata(tt.metadata); got != tt.want {
+				t.Errorf("NormalizeMetadata(%q) = %q, want %q", tt.metadata, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTransactionMetadata(t *testing.T) {
+	parsed := ParseTransactionMetadata(`{"symbol":"IGLN","exchanges":"LSX","name":"iShares Physical Gold"}`)
+	if parsed.Symbol != "IGLN" || parsed.Name != "iShares Physical Gold" {
+		t.Errorf("unexpected metadata: %+v", parsed)
+	}
+	if len(parsed.Exchanges) != 1 || parsed.Exchanges[0] != "LSX" {
+		t.Errorf("expected exchanges [LSX], got %v", parsed.Exchanges)
+	}
+
+	malformed := ParseTransactionMetadata(`{"symbol":["IGLN"]}`)
+	if malformed.Symbol != "" || malformed.Raw != `{"symbol":["IGLN"]}` {
+		t.Errorf("expected malformed metadata under raw, got %+v", malformed)
+	}
+
+	action := Transaction{TransactionType: TransactionTypeCorporateAction, Metadata: stringPtr(`{"quantity_delta":3}`)}
+	if delta := action.CorporateActionQuantity(); delta != 3 {
+		t.Errorf("expected a quantity delta of 3, got %v", delta)
+	}
+	action.TransactionType = TransactionTypeOther
+	if delta := action.CorporateActionQuantity(); delta != 0 {
+		t.Errorf("expected no quantity delta outside corporate actions, got %v", delta)
+	}
+
+	tx := Transaction{Metadata: stringPtr("")}
+	tx.NormalizeMetadata()
+	if tx.Metadata != nil {
+		t.Errorf("expected empty metadata to be cleared, got %q", *tx.Metadata)
+	}
+}
+
+func TestImportProfileValidate(t *testing.T) {
+	profile := ImportProfile{
+		Name:    " broker ",
+		Columns: ColumnMapping{" Date ": "Timestamp", "ISIN": "isin", "Amount": "amount_value"},
+	}
+	if err := profile.Validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if profile.Name != "broker" || profile.DecimalStyle != DecimalStyleAuto {
+		t.Errorf("expected trimmed name and auto decimal style, got %q %q", profile.Name, profile.DecimalStyle)
+	}
+	if profile.Columns["date"] != "timestamp" {
+		t.Errorf("expected normalized column names, got %v", profile.Columns)
+	}
+
+	invalid := []ImportProfile{
+		{Name: "", Columns: ColumnMapping{"a": "timestamp"}},
+		{Name: "p", Columns: ColumnMapping{"date": "timestamp", "isin": "isin"}},
+		{Name: "p", Columns: ColumnMapping{"date": "timestamp", "isin": "isin", "amount": "amount_value", "x": "unknown"}},
+		{Name: "p", Columns: ColumnMapping{"date": "timestamp", "isin": "isin", "amount": "amount_value", "total": "amount_value"}},
+		{Name: "p", Columns: ColumnMapping{"date": "timestamp", "isin": "isin", "amount": "amount_value"}, DateFormat: "15:04"},
+		{Name: "p", Columns: ColumnMapping{"date": "timestamp", "isin": "isin", "amount": "amount_value"}, DecimalStyle: "space"},
+	}
+	for i, p := range invalid {
+		if err := p.Validate(); err == nil {
+			t.Errorf("profile %d: expected validation error", i)
+		}
+	}
+}
+
+func TestReconstructAmount(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    float64
+		digits   int
+		expected float64
+	}{
+		// fractionDigits is the precision of the value, never cents to add
+		{"euro amount", -38.85, 2, -38.85},
+		{"float noise removed", 38.849999999, 2, 38.85},
+		{"whole amount", 100, 2, 100},
+		{"crypto precision", 0.123456789, 8, 0.12345679},
+		{"unknown precision keeps value", 12.3456, 0, 12.3456},
+		{"out of range precision keeps value", 12.3456, 12, 12.3456},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ReconstructAmount(tt.value, tt.digits); got != tt.expected {
+				t.Errorf("ReconstructAmount(%v, %d) = %v, want %v", tt.value, tt.digits, got, tt.expected)
+			}
+		})
+	}
+
+	tx := Transaction{AmountValue: -0.1 - 0.2, AmountFraction: 2}
+	tx.NormalizeAmount()
+	if tx.AmountValue != -0.3 || tx.ExactAmount() != -0.3 {
+		t.Errorf("expected normalized amount -0.3, got %v", tx.AmountValue)
+	}
+}
+
+func TestPlatformRegistry(t *testing.T) {
+	platform, ok := GetPlatform("traderepublic")
+	if !ok || !platform.RequiresTwoFactor || platform.AssetKey != AssetKeyISIN {
+		t.Errorf("unexpected Trade Republic platform: %+v", platform)
+	}
+
+	if _, ok := GetPlatform("kraken"); ok {
+		t.Error("expected an unknown platform not to be found")
+	}
+
+	for _, platform := range Platforms() {
+		if !IsPlatform(platform.ID) {
+			t.Errorf("expected %s to be a supported platform", platform.ID)
+		}
+		if platform.AssetKey != AssetKeyISIN && platform.AssetKey != AssetKeySymbol {
+			t.Errorf("unexpected asset key %q for %s", platform.AssetKey, platform.ID)
+		}
+	}
+
+	// The registry cannot be modified through the returned slice
+	Platforms()[0].RequiresTwoFactor = false
+	if platform, _ := GetPlatform(Platforms()[0].ID); !platform.RequiresTwoFactor {
+		t.Error("expected the registry to be left unchanged")
+	}
+}
+
+func TestTransactionUpdate_Apply(t *testing.T) {
+	isin := "US0378331005"
+	tx := Transaction{Title: "Apple", AmountValue: -1850.5, Quantity: 10, TransactionType: "buy", ISIN: &isin}
+
+	title := "Apple Inc."
+	TransactionUpdate{Title: &title}.Apply(&tx)
+	if tx.Title != title || tx.Quantity != 10 || tx.AmountValue != -1850.5 || tx.TransactionType != "buy" || tx.ISIN == nil {
+		t.Errorf("expected only the title to change, got %+v", tx)
+	}
+
+	zero, empty := 0.0, ""
+	TransactionUpdate{Quantity: &zero, ISIN: &empty}.Apply(&tx)
+	if tx.Quantity != 0 || tx.ISIN != nil {
+		t.Errorf("expected an explicit zero quantity and a removed ISIN, got %+v", tx)
+	}
+}
+
+func TestTransactionChanges(t *testing.T) {
+	changes := &TransactionChanges{}
+	changes.Record("tx-1", TransactionInserted)
+	changes.Record("tx-2", TransactionUnchanged)
+	// A transaction repeated in the batch keeps its first status
+	changes.Record("tx-1", TransactionUpdated)
+
+	if changes.Status("tx-1") != TransactionInserted || changes.Status("tx-2") != TransactionUnchanged || changes.Status("tx-3") != "" {
+		t.Errorf("unexpected statuses: %+v", changes)
+	}
+	if changes.Written() != 2 {
+		t.Errorf("expected 2 written transactions, got %d", changes.Written())
+	}
+
+	data, err := json.Marshal(changes)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	if string(data) != `{"inserted":["tx-1"],"updated":[],"unchanged":["tx-2"]}` {
+		t.Errorf("expected empty statuses as empty lists, got %s", data)
+	}
+}
+
+func TestIsValidISIN(t *testing.T) {
+	tests := []struct {
+		isin  string
+		valid bool
+	}{
+		{"US0378331005", true},
+		{"IE00B4L5Y983", true},
+		{"DE0007164600", true},
+		{"US0378331006", false}, // wrong check digit
+		{"us0378331005", false},
+		{"US037833100", false},
+		{"US037833100X", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := IsValidISIN(tt.isin); got != tt.valid {
+			t.Errorf("IsValidISIN(%q) = %v, want %v", tt.isin, got, tt.valid)
+		}
+	}
+}
+
+func TestAccountSession_ValidAt(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	session := AccountSession{AccountID: "acc-1", Token: "encrypted", ExpiresAt: now.Add(time.Minute)}
+
+	if !session.ValidAt(now) {
+		t.Error("expected the session to be valid before its expiry")
+	}
+	if session.ValidAt(now.Add(time.Minute)) {
+		t.Error("expected the session to be expired at its expiry")
+	}
+	if (&AccountSession{ExpiresAt: now.Add(time.Minute)}).ValidAt(now) {
+		t.Error("expected a session without token to be invalid")
+	}
+}
+
+func TestNormalizeTags(t *testing.T) {
+	tags := NormalizeTags([]string{" Rebalance ", "tax-loss harvest", "", "rebalance"})
+	if len(tags) != 2 || tags[0] != "rebalance" || tags[1] != "tax-loss harvest" {
+		t.Errorf("expected trimmed, lowercased and unique tags, got %q", tags)
+	}
+
+	if err := ValidateTags(tags); err != nil {
+		t.Errorf("ValidateTags() error = %v", err)
+	}
+	tooMany := make([]string, MaxTransactionTags+1)
+	for i := range tooMany {
+		tooMany[i] = string(rune('a' + i))
+	}
+	if err := ValidateTags(tooMany); err == nil {
+		t.Error("expected an error for too many tags")
+	}
+	if err := ValidateTags([]string{strings.Repeat("x", MaxTagLength+1)}); err == nil {
+		t.Error("expected an error for a too long tag")
+	}
+	if err := ValidateNote(strings.Repeat("é", MaxNoteLength)); err != nil {
+		t.Errorf("expected a note at the limit to be valid, got %v", err)
+	}
+	if err := ValidateNote(strings.Repeat("x", MaxNoteLength+1)); err == nil {
+		t.Error("expected an error for a too long note")
+	}
+}
+
+func TestTags_JSONAndUpdate(t *testing.T) {
+	data, err := json.Marshal(Transaction{ID: "tx-1"})
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if !strings.Contains(string(data), `"tags":[]`) || !strings.Contains(string(data), `"note":""`) {
+		t.Errorf("expected empty tags and note in the response, got %s", data)
+	}
+
+	tx := Transaction{Title: "Apple", Tags: Tags{"rebalance"}, Note: "kept"}
+	tags := []string{"Tax-Loss Harvest"}
+	TransactionUpdate{Tags: &tags}.Apply(&tx)
+	if len(tx.Tags) != 1 || tx.Tags[0] != "tax-loss harvest" || tx.Note != "kept" {
+		t.Errorf("expected the tags to be replaced and the note kept, got %+v", tx)
+	}
+
+	none := []string{}
+	note := "  sold to rebalance  "
+	TransactionUpdate{Tags: &none, Note: &note}.Apply(&tx)
+	if len(tx.Tags) != 0 || tx.Note != "sold to rebalance" {
+		t.Errorf("expected the tags to be removed and the note trimmed, got %+v", tx)
+	}
+}
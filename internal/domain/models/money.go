@@ -0,0 +1,145 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ParseDecimal parses a number written with either decimal convention.
+// Spaces and apostrophes are treated as thousands separators. When both '.' and ','
+// appear, the last one is the decimal separator ("1,234.56" and "1.234,56").
+// A separator repeated several times is a thousands separator ("1,234,567");
+// a single one is the decimal separator ("1234,56", and the ambiguous "1,234" reads as 1.234).
+func ParseDecimal(value string) (float64, error) {
+	cleaned := removeGroupSpaces(value)
+
+	if cleaned == "" {
+		return 0, fmt.Errorf("empty number")
+	}
+
+	lastDot := strings.LastIndex(cleaned, ".")
+	lastComma := strings.LastIndex(cleaned, ",")
+
+	switch {
+	case lastDot >= 0 && lastComma >= 0:
+		// Both separators: the last one is the decimal separator
+		thousands, decimal := ",", "."
+		if lastComma > lastDot {
+			thousands, decimal = ".", ","
+		}
+		if strings.Count(cleaned, decimal) > 1 {
+			return 0, fmt.Errorf("invalid number: %s", value)
+		}
+		intPart := cleaned[:strings.LastIndex(cleaned, decimal)]
+		if !validThousandsGroups(intPart, thousands) {
+			return 0, fmt.Errorf("invalid number: %s", value)
+		}
+		cleaned = strings.ReplaceAll(intPart, thousands, "") + "." + cleaned[strings.LastIndex(cleaned, decimal)+1:]
+	case lastComma >= 0 || lastDot >= 0:
+		separator := ","
+		if lastDot >= 0 {
+			separator = "."
+		}
+		if strings.Count(cleaned, separator) > 1 {
+			// Repeated separator: thousands grouping
+			if !validThousandsGroups(cleaned, separator) {
+				return 0, fmt.Errorf("invalid number: %s", value)
+			}
+			cleaned = strings.ReplaceAll(cleaned, separator, "")
+		} else {
+			cleaned = strings.Replace(cleaned, separator, ".", 1)
+		}
+	}
+
+	number, err := strconv.ParseFloat(cleaned, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number: %s", value)
+	}
+	return number, nil
+}
+
+// ParseDecimalStyle parses a number like ParseDecimal, but reads a lone '.' or ',' with
+// the given decimal style instead of guessing: with DecimalStyleComma "1,234" is 1.234 and
+// "1.234" is 1234, with DecimalStyleDot the reverse. Numbers with both separators or a
+// repeated one are not ambiguous and read as by ParseDecimal. DecimalStyleAuto is ParseDecimal.
+func ParseDecimalStyle(value, style string) (float64, error) {
+	var thousands string
+	switch style {
+	case DecimalStyleDot:
+		thousands = ","
+	case DecimalStyleComma:
+		thousands = "."
+	default:
+		return ParseDecimal(value)
+	}
+
+	cleaned := removeGroupSpaces(value)
+	if strings.Count(cleaned, ".")+strings.Count(cleaned, ",") == 1 && strings.Contains(cleaned, thousands) {
+		// Lone thousands separator of the style
+		if !validThousandsGroups(cleaned, thousands) {
+			return 0, fmt.Errorf("invalid number: %s", value)
+		}
+		return ParseDecimal(strings.Replace(cleaned, thousands, "", 1))
+	}
+	return ParseDecimal(value)
+}
+
+// IsDecimalStyle reports whether style is one of auto, dot or comma
+func IsDecimalStyle(style string) bool {
+	switch style {
+	case DecimalStyleAuto, DecimalStyleDot, DecimalStyleComma:
+		return true
+	}
+	return false
+}
+
+// removeGroupSpaces drops the spaces and apostrophes used as thousands separators
+func removeGroupSpaces(value string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '\'', '\u00a0', '\u202f':
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(value))
+}
+
+// validThousandsGroups checks that every group after the first has exactly 3 digits
+func validThousandsGroups(value string, separator string) bool {
+	groups := strings.Split(strings.TrimLeft(value, "+-"), separator)
+	if len(groups[0]) == 0 || (len(groups[0]) > 3 && len(groups) > 1) {
+		return false
+	}
+	for _, group := range groups[1:] {
+		if len(group) != 3 {
+			return false
+		}
+	}
+	return true
+}
+
+// ParseMoney parses an amount as displayed by the platforms or written in a CSV file,
+// e.g. "1,50 €", "-2.75 USD" or "€1,234.56". The currency symbol or code is ignored and
+// the number is read with ParseDecimal. An empty value is zero.
+func ParseMoney(value string) (float64, error) {
+	return ParseMoneyStyle(value, DecimalStyleAuto)
+}
+
+// ParseMoneyStyle is like ParseMoney but reads the number with ParseDecimalStyle
+func ParseMoneyStyle(value, style string) (float64, error) {
+	cleaned := strings.TrimSpace(strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.Is(unicode.Sc, r) {
+			return -1
+		}
+		return r
+	}, value))
+	if cleaned == "" {
+		if strings.TrimSpace(value) == "" {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("invalid amount: %s", value)
+	}
+	return ParseDecimalStyle(cleaned, style)
+}
@@ -0,0 +1,101 @@
+package models
+
+import "testing"
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    float64
+		wantErr bool
+	}{
+		{"", 0, false},
+		{"1,50 €", 1.50, false},
+		{"-2.75 USD", -2.75, false},
+		{"€1,234.56", 1234.56, false},
+		{"-€0,99", -0.99, false},
+		{"1 234,56 EUR", 1234.56, false},
+		{"12.5 titres", 12.5, false},
+		{"EUR", 0, true},
+		{"1,2,3 €", 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseMoney(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("ParseMoney(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+		}
+		if !tt.wantErr && got != tt.want {
+			t.Errorf("ParseMoney(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+// decimalStyleTests are shared by the parser and the import profile normalization, which
+// must read every value the same way
+var decimalStyleTests = []struct {
+	value   string
+	style   string
+	want    float64
+	wantErr bool
+}{
+	// EU: comma decimals, dot thousands
+	{"1,234", DecimalStyleComma, 1.234, false},
+	{"1.234", DecimalStyleComma, 1234, false},
+	{"1,234.56", DecimalStyleComma, 1234.56, false},
+	{"1.234,56", DecimalStyleComma, 1234.56, false},
+	{"1.23", DecimalStyleComma, 0, true},
+	// US: dot decimals, comma thousands
+	{"1,234", DecimalStyleDot, 1234, false},
+	{"1.234", DecimalStyleDot, 1.234, false},
+	{"1,234.56", DecimalStyleDot, 1234.56, false},
+	{"1.234,56", DecimalStyleDot, 1234.56, false},
+	{"1,23", DecimalStyleDot, 0, true},
+	{"-12,345", DecimalStyleDot, -12345, false},
+	// Auto keeps the heuristic: a lone separator is the decimal one
+	{"1,234", DecimalStyleAuto, 1.234, false},
+	{"1.234", DecimalStyleAuto, 1.234, false},
+	{"1,234", "", 1.234, false},
+}
+
+func TestParseDecimalStyle(t *testing.T) {
+	for _, tt := range decimalStyleTests {
+		got, err := ParseDecimalStyle(tt.value, tt.style)
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("ParseDecimalStyle(%q, %q) error = %v, wantErr %v", tt.value, tt.style, err, tt.wantErr)
+		}
+		if !tt.wantErr && got != tt.want {
+			t.Errorf("ParseDecimalStyle(%q, %q) = %v, want %v", tt.value, tt.style, got, tt.want)
+		}
+	}
+
+	if got, err := ParseMoneyStyle("1.234 €", DecimalStyleComma); err != nil || got != 1234 {
+		t.Errorf("ParseMoneyStyle() = %v, %v; expected 1234", got, err)
+	}
+}
+
+func TestImportProfileNormalizeValue_MatchesParseDecimalStyle(t *testing.T) {
+	for _, tt := range decimalStyleTests {
+		profile := ImportProfile{DecimalStyle: tt.style}
+		normalized, err := profile.NormalizeValue("amount_value", tt.value)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("NormalizeValue(%q) with style %q = %q, want an error", tt.value, tt.style, normalized)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("NormalizeValue(%q) with style %q error = %v", tt.value, tt.style, err)
+		}
+
+		// The import reads the normalized value without style
+		got, err := ParseDecimal(normalized)
+		if err != nil || got != tt.want {
+			t.Errorf("NormalizeValue(%q) with style %q = %q (%v, %v), want %v", tt.value, tt.style, normalized, got, err, tt.want)
+		}
+	}
+
+	profile := ImportProfile{DecimalStyle: DecimalStyleComma}
+	if fees, err := profile.NormalizeValue("fees", "1.234,50 €"); err != nil || fees != "1234.5" {
+		t.Errorf("NormalizeValue(fees) = %q, %v; expected 1234.5", fees, err)
+	}
+}
@@ -0,0 +1,88 @@
+package models
+
+// Asset keys: how a platform identifies the assets of its transactions
+const (
+	// AssetKeyISIN means transactions carry the ISIN of the asset
+	AssetKeyISIN = "isin"
+	// AssetKeySymbol means transactions carry a ticker symbol (e.g. BTC) instead of an ISIN
+	AssetKeySymbol = "symbol"
+)
+
+// Platform describes a supported trading platform and what its synchronization can do
+type Platform struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// RequiresTwoFactor means the platform is synchronized through the sync/init and
+	// sync/complete flow, and is skipped by automatic synchronizations
+	RequiresTwoFactor bool `json:"requires_two_factor"`
+	// IncrementalSync means the scraper only fetches transactions newer than the last sync
+	IncrementalSync bool `json:"incremental_sync"`
+	// PortfolioSnapshot means the current positions can be fetched from the platform
+	PortfolioSnapshot bool `json:"portfolio_snapshot"`
+	// AssetKey is AssetKeyISIN or AssetKeySymbol
+	AssetKey string `json:"asset_key"`
+	// AssetType is the type given to the assets created from the platform transactions
+	AssetType string `json:"asset_type"`
+	// CredentialFields lists the credentials expected when creating an account
+	CredentialFields []string `json:"credential_fields"`
+}
+
+// platforms is the registry of supported platforms, in display order
+var platforms = []Platform{
+	{
+		ID:                "traderepublic",
+		Name:              "Trade Republic",
+		RequiresTwoFactor: true,
+		IncrementalSync:   true,
+		PortfolioSnapshot: true,
+		AssetKey:          AssetKeyISIN,
+		AssetType:         "stock",
+		CredentialFields:  []string{"phone_number", "pin"},
+	},
+	{
+		ID:               "binance",
+		Name:             "Binance",
+		AssetKey:         AssetKeySymbol,
+		AssetType:        "crypto",
+		CredentialFields: []string{"api_key", "api_secret"},
+	},
+	{
+		ID:               "boursedirect",
+		Name:             "Bourse Direct",
+		AssetKey:         AssetKeyISIN,
+		AssetType:        "stock",
+		CredentialFields: []string{"username", "password"},
+	},
+}
+
+// Platforms returns the supported platforms
+func Platforms() []Platform {
+	result := make([]Platform, len(platforms))
+	copy(result, platforms)
+	return result
+}
+
+// GetPlatform returns the platform with the given ID
+func GetPlatform(id string) (Platform, bool) {
+	for _, platform := range platforms {
+		if platform.ID == id {
+			return platform, true
+		}
+	}
+	return Platform{}, false
+}
+
+// IsPlatform reports whether id is a supported platform
+func IsPlatform(id string) bool {
+	_, ok := GetPlatform(id)
+	return ok
+}
+
+// PlatformIDs returns the IDs of the supported platforms, e.g. for error messages
+func PlatformIDs() []string {
+	ids := make([]string, len(platforms))
+	for i, platform := range platforms {
+		ids[i] = platform.ID
+	}
+	return ids
+}
@@ -0,0 +1,49 @@
+package models
+
+import (
+	"errors"
+	"regexp"
+	"time"
+)
+
+// BrokerPosition is a position as reported by the broker itself
+type BrokerPosition struct {
+	ISIN         string  `json:"isin" db:"isin"`
+	Quantity     float64 `json:"quantity" db:"quantity"`
+	AveragePrice float64 `json:"average_price" db:"average_price"`
+}
+
+// PositionSnapshot holds the positions reported by the broker at a point in time.
+// It is the reference the positions replayed from transactions are reconciled against.
+type PositionSnapshot struct {
+	ID        string           `json:"id"`
+	AccountID string           `json:"account_id"`
+	TakenAt   time.Time        `json:"taken_at"`
+	Positions []BrokerPosition `json:"positions"`
+}
+
+// Validate validates the PositionSnapshot model
+func (s *PositionSnapshot) Validate() error {
+	if s.AccountID == "" {
+		return errors.New("account_id is required")
+	}
+	if s.TakenAt.IsZero() {
+		return errors.New("taken_at is required")
+	}
+
+	isinRegex := regexp.MustCompile(`^[A-Z]{2}[A-Z0-9]{10}$`)
+	seen := make(map[string]bool, len(s.Positions))
+	for _, position := range s.Positions {
+		if !isinRegex.MatchString(position.ISIN) {
+			return errors.New("invalid ISIN format in position " + position.ISIN)
+		}
+		if seen[position.ISIN] {
+			return errors.New("duplicate position " + position.ISIN)
+		}
+		seen[position.ISIN] = true
+		if position.Quantity < 0 {
+			return errors.New("position quantity must not be negative for " + position.ISIN)
+		}
+	}
+	return nil
+}
@@ -1,8 +1,13 @@
 package models
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
+	"math"
+	"strings"
 	"time"
+	"unicode"
 )
 
 type Transaction struct {
@@ -38,6 +43,149 @@ type Transaction struct {
 	Quantity        float64 `json:"quantity,omitempty" db:"quantity"`
 	TransactionType string  `json:"transaction_type,omitempty" db:"transaction_type"` // "buy", "sell", "dividend", "fee"
 	Metadata        *string `json:"metadata,omitempty" db:"metadata"`                 // JSON string for additional platform-specific data
+
+	// Tags and Note are annotations of the user; they never affect the calculations
+	Tags Tags   `json:"tags" db:"tags"`
+	Note string `json:"note" db:"note"`
+
+	// UpdatedAt is when the row was last inserted or modified; only loaded by the transactions stream
+	UpdatedAt *time.Time `json:"updated_at,omitempty" db:"updated_at"`
+	// ChangeXID is the database transaction that last wrote the row; only loaded by the
+	// transactions stream, which uses it as its cursor
+	ChangeXID uint64 `json:"-" db:"change_xid"`
+}
+
+// TransactionUpdate holds the fields changed by a transaction update: omitted (nil) fields
+// are left unchanged, so that an update never zeroes a quantity or amount it did not mention
+type TransactionUpdate struct {
+	// AccountID, when set, must be the account of the transaction; it is not updated
+	AccountID       string   `json:"account_id,omitempty"`
+	Title           *string  `json:"title,omitempty"`
+	Subtitle        *string  `json:"subtitle,omitempty"`
+	AmountValue     *float64 `json:"amount_value,omitempty"`
+	AmountCurrency  *string  `json:"amount_currency,omitempty"`
+	Fees            *string  `json:"fees,omitempty"`
+	Quantity        *float64 `json:"quantity,omitempty"`
+	TransactionType *string  `json:"transaction_type,omitempty"`
+	// ISIN set to an empty string removes the asset of the transaction
+	ISIN *string `json:"isin,omitempty"`
+	// Tags replace the tags of the transaction; an empty list removes them
+	Tags *[]string `json:"tags,omitempty"`
+	Note *string   `json:"note,omitempty"`
+}
+
+// Apply sets the fields of the update on t
+func (u TransactionUpdate) Apply(t *Transaction) {
+	if u.Title != nil {
+		t.Title = *u.Title
+	}
+	if u.Subtitle != nil {
+		t.Subtitle = *u.Subtitle
+	}
+	if u.AmountValue != nil {
+		t.AmountValue = *u.AmountValue
+	}
+	if u.AmountCurrency != nil {
+		t.AmountCurrency = *u.AmountCurrency
+	}
+	if u.Fees != nil {
+		t.Fees = *u.Fees
+	}
+	if u.Quantity != nil {
+		t.Quantity = *u.Quantity
+	}
+	if u.TransactionType != nil {
+		t.TransactionType = *u.TransactionType
+	}
+	if u.ISIN != nil {
+		if *u.ISIN == "" {
+			t.ISIN = nil
+		} else {
+			isin := *u.ISIN
+			t.ISIN = &isin
+		}
+	}
+	if u.Tags != nil {
+		t.Tags = NormalizeTags(*u.Tags)
+	}
+	if u.Note != nil {
+		t.Note = strings.TrimSpace(*u.Note)
+	}
+}
+
+// Statuses of a transaction written by a batch upsert
+const (
+	TransactionInserted  = "inserted"
+	TransactionUpdated   = "updated"
+	TransactionUnchanged = "unchanged"
+)
+
+// TransactionChanges reports, by status, the IDs of the transactions written by a batch
+// upsert. A transaction repeated in the batch is reported once, with its first status.
+type TransactionChanges struct {
+	Inserted  []string `json:"inserted"`
+	Updated   []string `json:"updated"`
+	Unchanged []string `json:"unchanged"`
+
+	seen map[string]bool
+}
+
+// TransactionFailure is a transaction that a partial batch could not write
+type TransactionFailure struct {
+	ID    string `json:"id"`
+	Error string `json:"error"`
+}
+
+// Record adds the status of the transaction id
+func (c *TransactionChanges) Record(id, status string) {
+	if c.seen == nil {
+		c.seen = make(map[string]bool)
+	}
+	if c.seen[id] {
+		return
+	}
+	c.seen[id] = true
+
+	switch status {
+	case TransactionInserted:
+		c.Inserted = append(c.Inserted, id)
+	case TransactionUpdated:
+		c.Updated = append(c.Updated, id)
+	default:
+		c.Unchanged = append(c.Unchanged, id)
+	}
+}
+
+// Status returns the status recorded for id, empty when the transaction was not written
+func (c *TransactionChanges) Status(id string) string {
+	for status, ids := range map[string][]string{
+		TransactionInserted:  c.Inserted,
+		TransactionUpdated:   c.Updated,
+		TransactionUnchanged: c.Unchanged,
+	} {
+		for _, changed := range ids {
+			if changed == id {
+				return status
+			}
+		}
+	}
+	return ""
+}
+
+// MarshalJSON encodes empty statuses as empty lists
+func (c TransactionChanges) MarshalJSON() ([]byte, error) {
+	type changes TransactionChanges
+	for _, ids := range []*[]string{&c.Inserted, &c.Updated, &c.Unchanged} {
+		if *ids == nil {
+			*ids = []string{}
+		}
+	}
+	return json.Marshal(changes(c))
+}
+
+// Written returns the number of transactions written, whatever their status
+func (c *TransactionChanges) Written() int {
+	return len(c.Inserted) + len(c.Updated) + len(c.Unchanged)
 }
 
 // Validate validates the Transaction model
@@ -63,10 +211,105 @@ func (t *Transaction) Validate() error {
 	if t.AmountCurrency == "" {
 		return errors.New("amount currency is required")
 	}
+	if err := ValidateCurrencyCode(t.AmountCurrency); err != nil {
+		return fmt.Errorf("invalid amount currency: %w", err)
+	}
+
+	if t.TransactionType != "" {
+		if err := ValidateTransactionType(t.TransactionType); err != nil {
+			return err
+		}
+	}
+
+	if err := ValidateTags(t.Tags); err != nil {
+		return err
+	}
+	if err := ValidateNote(t.Note); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// DefaultFutureTolerance is the margin allowed for timestamps ahead of now
+// (settlement dates, clock skew between the platform and the server)
+const DefaultFutureTolerance = 24 * time.Hour
+
+// ValidateNotFuture checks that the timestamp is not later than now + tolerance
+// Timestamps that cannot be parsed are left to Validate
+func (t *Transaction) ValidateNotFuture(now time.Time, tolerance time.Duration) error {
+	timestamp, err := time.Parse(time.RFC3339, t.Timestamp)
+	if err != nil {
+		return nil
+	}
+
+	if timestamp.After(now.Add(tolerance)) {
+		return fmt.Errorf("timestamp %s is in the future (tolerance %s)", t.Timestamp, tolerance)
+	}
 
 	return nil
 }
 
+// maxFractionDigits bounds the precision read from AmountFraction (crypto amounts use up to 8 decimals)
+const maxFractionDigits = 8
+
+// ReconstructAmount returns the monetary amount of a platform amount {value, fractionDigits}.
+// Trade Republic sends amounts as {"value": -38.85, "currency": "EUR", "fractionDigits": 2}:
+// value already is the full amount and fractionDigits is the number of decimals of the currency,
+// not a number of cents to add. The amount is therefore value rounded to fractionDigits decimals,
+// which removes float noise (e.g. 38.849999999). A precision of 0 is treated as unknown and the
+// value is returned as is, as are out of range precisions.
+func ReconstructAmount(value float64, fractionDigits int) float64 {
+	if fractionDigits <= 0 || fractionDigits > maxFractionDigits || math.IsNaN(value) || math.IsInf(value, 0) {
+		return value
+	}
+
+	scale := math.Pow(10, float64(fractionDigits))
+	return math.Round(value*scale) / scale
+}
+
+// ExactAmount returns the monetary amount of the transaction (see ReconstructAmount).
+// Ingest and every aggregation must read amounts through it.
+func (t *Transaction) ExactAmount() float64 {
+	return ReconstructAmount(t.AmountValue, t.AmountFraction)
+}
+
+// NormalizeAmount stores the reconstructed amount in AmountValue
+func (t *Transaction) NormalizeAmount() {
+	t.AmountValue = t.ExactAmount()
+}
+
+// InferQuantity sets Quantity from the Shares detail when no quantity is set
+// (Trade Republic timeline transactions come with a zero quantity).
+// Returns true when the quantity was inferred.
+func (t *Transaction) InferQuantity() bool {
+	if t.Quantity != 0 || t.Shares == "" {
+		return false
+	}
+
+	shares, err := ParseShares(t.Shares)
+	if err != nil || shares == 0 {
+		return false
+	}
+
+	t.Quantity = math.Abs(shares)
+	return true
+}
+
+// ParseShares parses a number of shares as displayed by the platforms, e.g. "0,5", "1 234,56" or "12.5 titres".
+// The unit after the number is dropped and the number is read with ParseDecimal.
+func ParseShares(shares string) (float64, error) {
+	number := strings.TrimRightFunc(shares, func(r rune) bool {
+		return !unicode.IsDigit(r)
+	})
+
+	value, err := ParseDecimal(number)
+	if err != nil {
+		return 0, fmt.Errorf("invalid shares value %q", shares)
+	}
+	return value, nil
+}
+
 type ProfileCash struct {
 	Currency       string  `json:"currency" csv:"currency"`
 	Value          float64 `json:"value" csv:"value"`
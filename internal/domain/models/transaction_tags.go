@@ -0,0 +1,91 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/lib/pq"
+)
+
+// Limits of the annotations of a transaction
+const (
+	MaxTransactionTags = 20
+	MaxTagLength       = 50
+	MaxNoteLength      = 1000
+)
+
+// Tags are the labels set by the user on a transaction (e.g. "rebalance"). They are stored
+// as a text array and never affect the calculations.
+type Tags []string
+
+// NormalizeTags trims and lowercases the tags, and drops the blank and repeated ones,
+// keeping the first occurrence order
+func NormalizeTags(tags []string) Tags {
+	normalized := Tags{}
+	seen := make(map[string]bool)
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	return normalized
+}
+
+// NormalizeAnnotations normalizes the tags and trims the note of t
+func (t *Transaction) NormalizeAnnotations() {
+	t.Tags = NormalizeTags(t.Tags)
+	t.Note = strings.TrimSpace(t.Note)
+}
+
+// ValidateTags checks the number and the length of the tags
+func ValidateTags(tags []string) error {
+	if len(tags) > MaxTransactionTags {
+		return fmt.Errorf("a transaction has at most %d tags", MaxTransactionTags)
+	}
+	for _, tag := range tags {
+		if utf8.RuneCountInString(tag) > MaxTagLength {
+			return fmt.Errorf("tag %q is longer than %d characters", tag, MaxTagLength)
+		}
+	}
+	return nil
+}
+
+// ValidateNote checks the length of a transaction note
+func ValidateNote(note string) error {
+	if utf8.RuneCountInString(note) > MaxNoteLength {
+		return fmt.Errorf("note is longer than %d characters", MaxNoteLength)
+	}
+	return nil
+}
+
+// Value stores the tags as a text array, empty rather than NULL
+func (t Tags) Value() (driver.Value, error) {
+	if t == nil {
+		return pq.StringArray{}.Value()
+	}
+	return pq.StringArray(t).Value()
+}
+
+// Scan reads a text array
+func (t *Tags) Scan(src interface{}) error {
+	var array pq.StringArray
+	if err := array.Scan(src); err != nil {
+		return err
+	}
+	*t = Tags(array)
+	return nil
+}
+
+// MarshalJSON encodes missing tags as an empty list
+func (t Tags) MarshalJSON() ([]byte, error) {
+	if t == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal([]string(t))
+}
@@ -0,0 +1,366 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Transaction types produced by the scrapers and the imports
+const (
+	TransactionTypeBuy        = "buy"
+	TransactionTypeSell       = "sell"
+	TransactionTypeDividend   = "dividend"
+	TransactionTypeInterest   = "interest"
+	TransactionTypeDeposit    = "deposit"
+	TransactionTypeWithdrawal = "withdrawal"
+	TransactionTypeFee        = "fee"
+	// TransactionTypeCorporateAction is a cash payment or share change from a corporate
+	// action other than a dividend (rights, spin-off, merger compensation...)
+	TransactionTypeCorporateAction = "corporate_action"
+	TransactionTypeOther           = "other"
+)
+
+// TransactionTypes lists every valid transaction type, in display order
+var TransactionTypes = []string{
+	TransactionTypeBuy,
+	TransactionTypeSell,
+	TransactionTypeDividend,
+	TransactionTypeDeposit,
+	TransactionTypeWithdrawal,
+	TransactionTypeInterest,
+	TransactionTypeFee,
+	TransactionTypeCorporateAction,
+	TransactionTypeOther,
+}
+
+// ErrUnknownTransactionType is returned for a type outside TransactionTypes
+var ErrUnknownTransactionType = errors.New("unknown transaction type")
+
+// IsTransactionType reports whether value is one of TransactionTypes
+func IsTransactionType(value string) bool {
+	return knownTransactionTypes[value]
+}
+
+// ValidateTransactionType rejects types outside TransactionTypes, so that typos
+// such as "buys" do not silently end up in the computations as another type
+func ValidateTransactionType(value string) error {
+	if !knownTransactionTypes[value] {
+		return fmt.Errorf("%w %q (expected one of: %s)", ErrUnknownTransactionType, value, strings.Join(TransactionTypes, ", "))
+	}
+	return nil
+}
+
+// Fields a type mapping keyword can be matched against
+const (
+	MappingFieldTitle    = "title"
+	MappingFieldSubtitle = "subtitle"
+	MappingFieldIcon     = "icon"
+	MappingFieldAny      = "any"
+)
+
+// knownTransactionTypes lists the types a mapping can resolve to
+var knownTransactionTypes = map[string]bool{
+	TransactionTypeBuy:             true,
+	TransactionTypeSell:            true,
+	TransactionTypeDividend:        true,
+	TransactionTypeInterest:        true,
+	TransactionTypeDeposit:         true,
+	TransactionTypeWithdrawal:      true,
+	TransactionTypeFee:             true,
+	TransactionTypeCorporateAction: true,
+	TransactionTypeOther:           true,
+}
+
+// TypeMapping maps a keyword found in a transaction field to a transaction type
+type TypeMapping struct {
+	Keyword string `json:"keyword"`
+	Field   string `json:"field"`
+	Type    string `json:"type"`
+	// BuiltIn is false for mappings added through configuration
+	BuiltIn bool `json:"built_in"`
+}
+
+// Validate validates the TypeMapping model
+func (m *TypeMapping) Validate() error {
+	if strings.TrimSpace(m.Keyword) == "" {
+		return fmt.Errorf("keyword is required")
+	}
+	if !knownTransactionTypes[m.Type] {
+		return fmt.Errorf("unknown transaction type: %s", m.Type)
+	}
+	switch m.Field {
+	case MappingFieldTitle, MappingFieldSubtitle, MappingFieldIcon, MappingFieldAny:
+	default:
+		return fmt.Errorf("field must be one of: title, subtitle, icon, any")
+	}
+	return nil
+}
+
+// matches reports whether the keyword appears in the mapped field (case-insensitive)
+func (m *TypeMapping) matches(title, subtitle, icon string) bool {
+	keyword := strings.ToLower(m.Keyword)
+	switch m.Field {
+	case MappingFieldTitle:
+		return strings.Contains(strings.ToLower(title), keyword)
+	case MappingFieldSubtitle:
+		return strings.Contains(strings.ToLower(subtitle), keyword)
+	case MappingFieldIcon:
+		return strings.Contains(strings.ToLower(icon), keyword)
+	default:
+		return strings.Contains(strings.ToLower(title), keyword) ||
+			strings.Contains(strings.ToLower(subtitle), keyword) ||
+			strings.Contains(strings.ToLower(icon), keyword)
+	}
+}
+
+// builtinTypeMappings holds the German/French/English keywords used by Trade Republic
+var builtinTypeMappings = []TypeMapping{
+	{Keyword: "dividende en espèces", Field: MappingFieldSubtitle, Type: TransactionTypeDividend},
+	{Keyword: "dividende", Field: MappingFieldSubtitle, Type: TransactionTypeDividend},
+	{Keyword: "dividend", Field: MappingFieldSubtitle, Type: TransactionTypeDividend},
+	{Keyword: "dividend", Field: MappingFieldIcon, Type: TransactionTypeDividend},
+
+	{Keyword: "intérêts", Field: MappingFieldTitle, Type: TransactionTypeInterest},
+	{Keyword: "intérêt", Field: MappingFieldTitle, Type: TransactionTypeInterest},
+	{Keyword: "interest", Field: MappingFieldTitle, Type: TransactionTypeInterest},
+
+	{Keyword: "plan d'épargne exécuté", Field: MappingFieldSubtitle, Type: TransactionTypeBuy},
+	{Keyword: "sparplan ausgeführt", Field: MappingFieldSubtitle, Type: TransactionTypeBuy},
+	{Keyword: "ordre d'achat", Field: MappingFieldSubtitle, Type: TransactionTypeBuy},
+	{Keyword: "échec du plan d'épargne", Field: MappingFieldSubtitle, Type: TransactionTypeBuy},
+	{Keyword: "buy order", Field: MappingFieldSubtitle, Type: TransactionTypeBuy},
+	{Keyword: "arrow-right", Field: MappingFieldIcon, Type: TransactionTypeBuy},
+	{Keyword: "kauf", Field: MappingFieldTitle, Type: TransactionTypeBuy},
+	{Keyword: "sparplan", Field: MappingFieldTitle, Type: TransactionTypeBuy},
+
+	{Keyword: "ordre de vente", Field: MappingFieldSubtitle, Type: TransactionTypeSell},
+	{Keyword: "sell order", Field: MappingFieldSubtitle, Type: TransactionTypeSell},
+	{Keyword: "arrow-left", Field: MappingFieldIcon, Type: TransactionTypeSell},
+	{Keyword: "verkauf", Field: MappingFieldTitle, Type: TransactionTypeSell},
+	{Keyword: "vente", Field: MappingFieldTitle, Type: TransactionTypeSell},
+
+	{Keyword: "terminé", Field: MappingFieldSubtitle, Type: TransactionTypeDeposit},
+	{Keyword: "einzahlung", Field: MappingFieldTitle, Type: TransactionTypeDeposit},
+	{Keyword: "dépôt", Field: MappingFieldTitle, Type: TransactionTypeDeposit},
+	{Keyword: "versement", Field: MappingFieldTitle, Type: TransactionTypeDeposit},
+	{Keyword: "deposit", Field: MappingFieldTitle, Type: TransactionTypeDeposit},
+
+	{Keyword: "auszahlung", Field: MappingFieldTitle, Type: TransactionTypeWithdrawal},
+	{Keyword: "retrait", Field: MappingFieldTitle, Type: TransactionTypeWithdrawal},
+	{Keyword: "withdrawal", Field: MappingFieldTitle, Type: TransactionTypeWithdrawal},
+
+	{Keyword: "gebühr", Field: MappingFieldTitle, Type: TransactionTypeFee},
+	{Keyword: "frais", Field: MappingFieldTitle, Type: TransactionTypeFee},
+	{Keyword: "fee", Field: MappingFieldTitle, Type: TransactionTypeFee},
+}
+
+var (
+	typeMappingsMu     sync.RWMutex
+	customTypeMappings []TypeMapping
+)
+
+// SetCustomTypeMappings registers additional keyword mappings on top of the built-in ones
+// Custom mappings are consulted before the built-in keywords of the same type
+func SetCustomTypeMappings(mappings []TypeMapping) error {
+	custom := make([]TypeMapping, 0, len(mappings))
+	for i, m := range mappings {
+		if m.Field == "" {
+			m.Field = MappingFieldAny
+		}
+		m.Type = strings.ToLower(strings.TrimSpace(m.Type))
+		m.BuiltIn = false
+		if err := m.Validate(); err != nil {
+			return fmt.Errorf("type mapping %d: %w", i+1, err)
+		}
+		custom = append(custom, m)
+	}
+
+	typeMappingsMu.Lock()
+	defer typeMappingsMu.Unlock()
+	customTypeMappings = custom
+	return nil
+}
+
+// ActiveTypeMappings returns the custom mappings followed by the built-in ones
+func ActiveTypeMappings() []TypeMapping {
+	typeMappingsMu.RLock()
+	defer typeMappingsMu.RUnlock()
+
+	active := make([]TypeMapping, 0, len(customTypeMappings)+len(builtinTypeMappings))
+	active = append(active, customTypeMappings...)
+	for _, m := range builtinTypeMappings {
+		m.BuiltIn = true
+		active = append(active, m)
+	}
+	return active
+}
+
+// MatchesTransactionType reports whether any keyword mapped to txType appears in the transaction fields
+func MatchesTransactionType(txType, title, subtitle, icon string) bool {
+	for _, m := range ActiveTypeMappings() {
+		if m.Type == txType && m.matches(title, subtitle, icon) {
+			return true
+		}
+	}
+	return false
+}
+
+// DetermineTransactionType infers the type of a Trade Republic transaction from its icon, title,
+// subtitle and amount. Keywords come from the configurable type mappings (see ActiveTypeMappings).
+func DetermineTransactionType(icon, title, subtitle string, amountValue float64) string {
+	titleLower := strings.ToLower(title)
+	subtitleLower := strings.ToLower(subtitle)
+
+	matches := func(txType string) bool {
+		return MatchesTransactionType(txType, title, subtitle, icon)
+	}
+
+	// Dividends - check subtitle for "dividende" or "dividend"
+	if matches(TransactionTypeDividend) {
+		return TransactionTypeDividend
+	}
+
+	// Interest
+	if matches(TransactionTypeInterest) {
+		return TransactionTypeInterest
+	}
+
+	// Buy transactions - execution confirmation in the subtitle or buy keywords in the title
+	if matches(TransactionTypeBuy) {
+		return TransactionTypeBuy
+	}
+
+	// If amount is negative and title contains an asset name (not "intérêt", "versement", etc.)
+	// it's likely a buy transaction
+	if amountValue < 0 &&
+		!strings.Contains(titleLower, "intérêt") &&
+		!strings.Contains(titleLower, "versement") &&
+		!strings.Contains(titleLower, "dépôt") &&
+		titleLower != "" &&
+		// Check if it looks like an asset name (contains letters and possibly numbers)
+		len(titleLower) > 3 {
+		return TransactionTypeBuy
+	}
+
+	// Sell transactions
+	if matches(TransactionTypeSell) {
+		return TransactionTypeSell
+	}
+
+	// Deposits - positive amount with specific keywords or "terminé" subtitle
+	if matches(TransactionTypeDeposit) {
+		// But not if it's a dividend
+		if !strings.Contains(subtitleLower, "dividende") {
+			return TransactionTypeDeposit
+		}
+	}
+
+	// If amount is positive and title is a person's name (contains spaces and capital letters)
+	// it's likely a deposit
+	if amountValue > 0 &&
+		strings.Contains(title, " ") &&
+		title == strings.Title(strings.ToLower(title)) {
+		return TransactionTypeDeposit
+	}
+
+	// Withdrawals
+	if matches(TransactionTypeWithdrawal) {
+		return TransactionTypeWithdrawal
+	}
+
+	// Fees
+	if matches(TransactionTypeFee) {
+		return TransactionTypeFee
+	}
+
+	return TransactionTypeOther
+}
+
+// keywordTypeOrder is the order in which the keyword mappings of each type are tried
+var keywordTypeOrder = []string{
+	TransactionTypeDividend, TransactionTypeInterest, TransactionTypeBuy, TransactionTypeSell,
+	TransactionTypeDeposit, TransactionTypeWithdrawal, TransactionTypeFee,
+}
+
+// KeywordTransactionType returns the type whose keywords appear in the icon, title or subtitle
+// of a Trade Republic transaction, or "" when no keyword matches. Unlike DetermineTransactionType
+// it never guesses a type from the amount alone.
+func KeywordTransactionType(icon, title, subtitle string) string {
+	for _, txType := range keywordTypeOrder {
+		if !MatchesTransactionType(txType, title, subtitle, icon) {
+			continue
+		}
+		// A dividend paid to the cash account is not a deposit
+		if txType == TransactionTypeDeposit && strings.Contains(strings.ToLower(subtitle), "dividende") {
+			continue
+		}
+		return txType
+	}
+	return ""
+}
+
+// NormalizeTransactionType maps a free-form type (e.g. from a CSV export) to a known type
+// Known types are returned lowercased, other values are matched against the keyword mappings
+// and kept unchanged when nothing matches
+func NormalizeTransactionType(value string) string {
+	trimmed := strings.TrimSpace(value)
+	if trimmed == "" {
+		return ""
+	}
+
+	lower := strings.ToLower(trimmed)
+	if knownTransactionTypes[lower] {
+		return lower
+	}
+
+	// The longest keyword wins so that "verkauf" is not read as "kauf"
+	best := ""
+	bestLength := 0
+	for _, m := range ActiveTypeMappings() {
+		keyword := strings.ToLower(m.Keyword)
+		if len(keyword) > bestLength && strings.Contains(lower, keyword) {
+			best = m.Type
+			bestLength = len(keyword)
+		}
+	}
+	if best != "" {
+		return best
+	}
+
+	return trimmed
+}
+
+// ParseTypeMappings parses a comma-separated list of "[field:]keyword=type" entries
+// e.g. "achat=buy,subtitle:verkoop=sell"; the field defaults to any
+func ParseTypeMappings(spec string) ([]TypeMapping, error) {
+	var mappings []TypeMapping
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		keyword, txType, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid type mapping %q: expected keyword=type", entry)
+		}
+
+		field := MappingFieldAny
+		if prefix, rest, found := strings.Cut(keyword, ":"); found {
+			switch strings.ToLower(strings.TrimSpace(prefix)) {
+			case MappingFieldTitle, MappingFieldSubtitle, MappingFieldIcon, MappingFieldAny:
+				field = strings.ToLower(strings.TrimSpace(prefix))
+				keyword = rest
+			}
+		}
+
+		mappings = append(mappings, TypeMapping{
+			Keyword: strings.TrimSpace(keyword),
+			Field:   field,
+			Type:    strings.ToLower(strings.TrimSpace(txType)),
+		})
+	}
+	return mappings, nil
+}
@@ -0,0 +1,109 @@
+package models
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNormalizeTransactionType(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected string
+	}{
+		{"buy", "buy"},
+		{"SELL", "sell"},
+		{" Dividend ", "dividend"},
+		{"Kauf", "buy"},
+		{"Verkauf", "sell"},
+		{"Frais de garde", "fee"},
+		{"Auszahlung", "withdrawal"},
+		{"", ""},
+		{"Spin-off", "Spin-off"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			if got := NormalizeTransactionType(tt.value); got != tt.expected {
+				t.Errorf("NormalizeTransactionType(%q) = %q, want %q", tt.value, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSetCustomTypeMappings(t *testing.T) {
+	defer SetCustomTypeMappings(nil)
+
+	if err := SetCustomTypeMappings([]TypeMapping{{Keyword: "achat", Type: "buy"}}); err != nil {
+		t.Fatalf("SetCustomTypeMappings failed: %v", err)
+	}
+
+	if got := NormalizeTransactionType("Achat"); got != "buy" {
+		t.Errorf("Custom mapping not applied, got %q", got)
+	}
+	if !MatchesTransactionType("buy", "Achat Apple", "", "") {
+		t.Error("Custom mapping should match the title")
+	}
+
+	active := ActiveTypeMappings()
+	if len(active) == 0 || active[0].Keyword != "achat" || active[0].BuiltIn {
+		t.Errorf("Custom mappings should come first, got %+v", active[0])
+	}
+	if !active[len(active)-1].BuiltIn {
+		t.Error("Built-in mappings should be flagged as such")
+	}
+
+	invalid := [][]TypeMapping{
+		{{Keyword: "", Type: "buy"}},
+		{{Keyword: "achat", Type: "purchase"}},
+		{{Keyword: "achat", Type: "buy", Field: "isin"}},
+	}
+	for _, mappings := range invalid {
+		if err := SetCustomTypeMappings(mappings); err == nil {
+			t.Errorf("Expected error for %+v", mappings)
+		}
+	}
+}
+
+func TestParseTypeMappings(t *testing.T) {
+	mappings, err := ParseTypeMappings("achat=buy, subtitle:verkoop=SELL,,")
+	if err != nil {
+		t.Fatalf("ParseTypeMappings failed: %v", err)
+	}
+	if len(mappings) != 2 {
+		t.Fatalf("Expected 2 mappings, got %d", len(mappings))
+	}
+	if mappings[0] != (TypeMapping{Keyword: "achat", Field: MappingFieldAny, Type: "buy"}) {
+		t.Errorf("Unexpected first mapping: %+v", mappings[0])
+	}
+	if mappings[1] != (TypeMapping{Keyword: "verkoop", Field: MappingFieldSubtitle, Type: "sell"}) {
+		t.Errorf("Unexpected second mapping: %+v", mappings[1])
+	}
+
+	if _, err := ParseTypeMappings("achat"); err == nil {
+		t.Error("Expected error for entry without type")
+	}
+}
+
+func TestValidateTransactionType(t *testing.T) {
+	for _, value := range TransactionTypes {
+		if err := ValidateTransactionType(value); err != nil {
+			t.Errorf("ValidateTransactionType(%q) error = %v", value, err)
+		}
+	}
+
+	for _, value := range []string{"buys", "Buy", "", "achat", "split"} {
+		err := ValidateTransactionType(value)
+		if !errors.Is(err, ErrUnknownTransactionType) {
+			t.Errorf("ValidateTransactionType(%q) error = %v, want ErrUnknownTransactionType", value, err)
+		}
+	}
+
+	tx := Transaction{ID: "txn_1", AccountID: "acc_1", Timestamp: "2024-01-15T10:00:00Z", AmountCurrency: "EUR", TransactionType: "buys"}
+	if err := tx.Validate(); !errors.Is(err, ErrUnknownTransactionType) {
+		t.Errorf("expected Validate to reject type %q, got %v", tx.TransactionType, err)
+	}
+	tx.TransactionType = ""
+	if err := tx.Validate(); err != nil {
+		t.Errorf("expected a transaction without type to be valid, got %v", err)
+	}
+}
@@ -0,0 +1,56 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"valhafin/internal/domain/models"
+)
+
+// SaveAccountSession stores the session of an account, replacing the previous one.
+// The token must already be encrypted.
+func (db *DB) SaveAccountSession(ctx context.Context, session *models.AccountSession) error {
+	ctx, cancel := db.withQueryTimeout(ctx)
+	defer cancel()
+
+	_, err := db.ExecContext(ctx, `
+		INSERT INTO account_sessions (account_id, token, expires_at, created_at)
+		VALUES ($1, $2, $3, NOW())
+		ON CONFLICT (account_id) DO UPDATE SET
+			token = EXCLUDED.token,
+			expires_at = EXCLUDED.expires_at,
+			created_at = EXCLUDED.created_at
+	`, session.AccountID, session.Token, session.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to save account session: %w", err)
+	}
+	return nil
+}
+
+// GetAccountSession returns the stored session of an account, expired or not.
+// It returns sql.ErrNoRows when the account has no session.
+func (db *DB) GetAccountSession(ctx context.Context, accountID string) (*models.AccountSession, error) {
+	ctx, cancel := db.withQueryTimeout(ctx)
+	defer cancel()
+
+	var session models.AccountSession
+	err := db.GetContext(ctx, &session, `
+		SELECT account_id, token, expires_at, created_at
+		FROM account_sessions
+		WHERE account_id = $1
+	`, accountID)
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// DeleteAccountSession forgets the session of an account
+func (db *DB) DeleteAccountSession(ctx context.Context, accountID string) error {
+	ctx, cancel := db.withQueryTimeout(ctx)
+	defer cancel()
+
+	if _, err := db.ExecContext(ctx, `DELETE FROM account_sessions WHERE account_id = $1`, accountID); err != nil {
+		return fmt.Errorf("failed to delete account session: %w", err)
+	}
+	return nil
+}
@@ -1,6 +1,7 @@
 package database
 
 import (
+	"context"
 	"fmt"
 	"time"
 	"valhafin/internal/domain/models"
@@ -20,14 +21,22 @@ func (db *DB) CreateAccount(account *models.Account) error {
 	account.CreatedAt = now
 	account.UpdatedAt = now
 
+	// Default currency for backward compatibility
+	if account.Currency == "" {
+		account.Currency = models.DefaultCurrency
+	}
+	if account.DecimalStyle == "" {
+		account.DecimalStyle = models.DecimalStyleAuto
+	}
+
 	// Validate account
 	if err := account.Validate(); err != nil {
 		return fmt.Errorf("validation failed: %w", err)
 	}
 
 	query := `
-		INSERT INTO accounts (id, name, platform, credentials, created_at, updated_at, last_sync)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		INSERT INTO accounts (id, name, platform, credentials, currency, decimal_style, created_at, updated_at, last_sync)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 	`
 
 	_, err := db.Exec(
@@ -36,6 +45,8 @@ func (db *DB) CreateAccount(account *models.Account) error {
 		account.Name,
 		account.Platform,
 		account.Credentials,
+		account.Currency,
+		account.DecimalStyle,
 		account.CreatedAt,
 		account.UpdatedAt,
 		account.LastSync,
@@ -50,15 +61,23 @@ func (db *DB) CreateAccount(account *models.Account) error {
 
 // GetAccountByID retrieves an account by its ID
 func (db *DB) GetAccountByID(id string) (*models.Account, error) {
+	return db.GetAccountByIDContext(context.Background(), id)
+}
+
+// GetAccountByIDContext is like GetAccountByID but is canceled with ctx and bounded by the query timeout
+func (db *DB) GetAccountByIDContext(ctx context.Context, id string) (*models.Account, error) {
+	ctx, cancel := db.withQueryTimeout(ctx)
+	defer cancel()
+
 	var account models.Account
 
 	query := `
-		SELECT id, name, platform, credentials, created_at, updated_at, last_sync
+		SELECT id, name, platform, credentials, currency, decimal_style, created_at, updated_at, last_sync
 		FROM accounts
 		WHERE id = $1
 	`
 
-	err := db.Get(&account, query, id)
+	err := db.GetContext(ctx, &account, query, id)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get account: %w", err)
 	}
@@ -68,15 +87,23 @@ func (db *DB) GetAccountByID(id string) (*models.Account, error) {
 
 // GetAllAccounts retrieves all accounts
 func (db *DB) GetAllAccounts() ([]models.Account, error) {
+	return db.GetAllAccountsContext(context.Background())
+}
+
+// GetAllAccountsContext is like GetAllAccounts but is canceled with ctx and bounded by the query timeout
+func (db *DB) GetAllAccountsContext(ctx context.Context) ([]models.Account, error) {
+	ctx, cancel := db.withQueryTimeout(ctx)
+	defer cancel()
+
 	var accounts []models.Account
 
 	query := `
-		SELECT id, name, platform, credentials, created_at, updated_at, last_sync
+		SELECT id, name, platform, credentials, currency, decimal_style, created_at, updated_at, last_sync
 		FROM accounts
 		ORDER BY created_at DESC
 	`
 
-	err := db.Select(&accounts, query)
+	err := db.SelectContext(ctx, &accounts, query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get accounts: %w", err)
 	}
@@ -89,7 +116,7 @@ func (db *DB) GetAccountsByPlatform(platform string) ([]models.Account, error) {
 	var accounts []models.Account
 
 	query := `
-		SELECT id, name, platform, credentials, created_at, updated_at, last_sync
+		SELECT id, name, platform, credentials, currency, decimal_style, created_at, updated_at, last_sync
 		FROM accounts
 		WHERE platform = $1
 		ORDER BY created_at DESC
@@ -115,8 +142,8 @@ func (db *DB) UpdateAccount(account *models.Account) error {
 
 	query := `
 		UPDATE accounts
-		SET name = $1, platform = $2, credentials = $3, updated_at = $4, last_sync = $5
-		WHERE id = $6
+		SET name = $1, platform = $2, credentials = $3, currency = $4, decimal_style = $5, updated_at = $6, last_sync = $7
+		WHERE id = $8
 	`
 
 	result, err := db.Exec(
@@ -124,6 +151,8 @@ func (db *DB) UpdateAccount(account *models.Account) error {
 		account.Name,
 		account.Platform,
 		account.Credentials,
+		account.CurrencyOrDefault(),
+		account.DecimalStyleOrDefault(),
 		account.UpdatedAt,
 		account.LastSync,
 		account.ID,
@@ -170,6 +199,31 @@ func (db *DB) UpdateAccountLastSync(accountID string, lastSync time.Time) error
 	return nil
 }
 
+// UpdateAccountCredentials replaces the encrypted credentials of an account
+func (db *DB) UpdateAccountCredentials(accountID string, credentials string) error {
+	query := `
+		UPDATE accounts
+		SET credentials = $1, updated_at = $2
+		WHERE id = $3
+	`
+
+	result, err := db.Exec(query, credentials, time.Now(), accountID)
+	if err != nil {
+		return fmt.Errorf("failed to update credentials: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("account not found")
+	}
+
+	return nil
+}
+
 // DeleteAccount deletes an account and all associated transactions (cascade)
 func (db *DB) DeleteAccount(id string) error {
 	query := `DELETE FROM accounts WHERE id = $1`
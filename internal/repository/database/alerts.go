@@ -0,0 +1,110 @@
+package database
+
+import (
+	"fmt"
+	"time"
+	"valhafin/internal/domain/models"
+
+	"github.com/google/uuid"
+)
+
+// CreateAlert creates a new alert rule in the database
+func (db *DB) CreateAlert(alert *models.Alert) error {
+	// Generate UUID if not provided
+	if alert.ID == "" {
+		alert.ID = uuid.New().String()
+	}
+
+	alert.CreatedAt = time.Now()
+
+	// Validate alert
+	if err := alert.Validate(); err != nil {
+		return fmt.Errorf("validation failed: %w", err)
+	}
+
+	query := `
+		INSERT INTO alerts (id, type, isin, threshold, enabled, triggered, last_triggered_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+
+	_, err := db.Exec(
+		query,
+		alert.ID,
+		alert.Type,
+		alert.ISIN,
+		alert.Threshold,
+		alert.Enabled,
+		alert.Triggered,
+		alert.LastTriggeredAt,
+		alert.CreatedAt,
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to create alert: %w", err)
+	}
+
+	return nil
+}
+
+// GetAllAlerts retrieves all alert rules
+func (db *DB) GetAllAlerts() ([]models.Alert, error) {
+	var alerts []models.Alert
+
+	query := `
+		SELECT id, type, isin, threshold, enabled, triggered, last_triggered_at, created_at
+		FROM alerts
+		ORDER BY created_at DESC
+	`
+
+	err := db.Select(&alerts, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get alerts: %w", err)
+	}
+
+	return alerts, nil
+}
+
+// GetEnabledAlerts retrieves the alert rules to evaluate
+func (db *DB) GetEnabledAlerts() ([]models.Alert, error) {
+	var alerts []models.Alert
+
+	query := `
+		SELECT id, type, isin, threshold, enabled, triggered, last_triggered_at, created_at
+		FROM alerts
+		WHERE enabled = TRUE
+		ORDER BY created_at
+	`
+
+	err := db.Select(&alerts, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get enabled alerts: %w", err)
+	}
+
+	return alerts, nil
+}
+
+// UpdateAlertTriggerState records whether an alert condition currently holds
+// lastTriggeredAt is only updated when a non-nil value is given
+func (db *DB) UpdateAlertTriggerState(alertID string, triggered bool, lastTriggeredAt *time.Time) error {
+	query := `
+		UPDATE alerts
+		SET triggered = $1, last_triggered_at = COALESCE($2, last_triggered_at)
+		WHERE id = $3
+	`
+
+	result, err := db.Exec(query, triggered, lastTriggeredAt, alertID)
+	if err != nil {
+		return fmt.Errorf("failed to update alert: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("alert not found")
+	}
+
+	return nil
+}
@@ -0,0 +1,157 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"valhafin/internal/domain/models"
+
+	"github.com/lib/pq"
+)
+
+// ErrRestoreConflict is returned when a backup cannot be restored without overwriting
+// existing data: the target account already has transactions, or transactions of the
+// backup are stored under another account
+var ErrRestoreConflict = errors.New("backup conflicts with existing transactions")
+
+// AccountBackup holds the data of an account exported to a backup: the account without
+// its credentials, all its transactions, the assets they refer to and the stored prices
+// of these assets
+type AccountBackup struct {
+	Account      models.Account
+	Transactions []models.Transaction
+	Assets       []models.Asset
+	Prices       []models.AssetPrice
+}
+
+// GetAccountBackup collects the data of an account for a backup. Hidden and deleted
+// transactions are included so that a restore gives back the account as it was.
+func (db *DB) GetAccountBackup(ctx context.Context, accountID string) (*AccountBackup, error) {
+	account, err := db.GetAccountByIDContext(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	account.Credentials = ""
+
+	transactions, err := db.GetTransactionsByAccountContext(ctx, accountID, account.Platform, TransactionFilter{
+		IncludeDeleted: true,
+		IncludeHidden:  true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var isins []string
+	for _, transaction := range transactions {
+		if transaction.ISIN != nil && *transaction.ISIN != "" && !seen[*transaction.ISIN] {
+			seen[*transaction.ISIN] = true
+			isins = append(isins, *transaction.ISIN)
+		}
+	}
+	sort.Strings(isins)
+
+	backup := &AccountBackup{
+		Account:      *account,
+		Transactions: transactions,
+		Assets:       []models.Asset{},
+		Prices:       []models.AssetPrice{},
+	}
+	if len(isins) == 0 {
+		return backup, nil
+	}
+
+	queryCtx, cancel := db.withQueryTimeout(ctx)
+	defer cancel()
+
+	err = db.SelectContext(queryCtx, &backup.Assets, `
+		SELECT isin, name, symbol, symbol_verified, type, currency, last_updated
+		FROM assets
+		WHERE isin = ANY($1)
+		ORDER BY isin
+	`, pq.Array(isins))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get assets: %w", err)
+	}
+
+	err = db.SelectContext(queryCtx, &backup.Prices, `
+		SELECT id, isin, price, currency, timestamp
+		FROM asset_prices
+		WHERE isin = ANY($1)
+		ORDER BY isin, timestamp
+	`, pq.Array(isins))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get asset prices: %w", err)
+	}
+
+	return backup, nil
+}
+
+// RestoreAccountBackup restores the transactions, assets and prices of a backup into an
+// existing account, in a single transaction. The transactions are attached to accountID
+// whatever account they were exported from. ErrRestoreConflict is returned when the
+// account already has transactions or when a transaction of the backup already exists.
+func (db *DB) RestoreAccountBackup(ctx context.Context, accountID string, backup *AccountBackup) error {
+	account, err := db.GetAccountByIDContext(ctx, accountID)
+	if err != nil {
+		return err
+	}
+	tableName, err := getTransactionTableName(account.Platform)
+	if err != nil {
+		return err
+	}
+
+	transactions := make([]models.Transaction, len(backup.Transactions))
+	ids := make([]string, len(backup.Transactions))
+	for i, transaction := range backup.Transactions {
+		transaction.AccountID = accountID
+		transaction.UpdatedAt = nil
+		transactions[i] = transaction
+		ids[i] = transaction.ID
+	}
+
+	return db.InTransaction(ctx, func(tx *sql.Tx) error {
+		var existing int
+		err := tx.QueryRowContext(ctx, fmt.Sprintf(`
+			SELECT COUNT(*) FROM %s WHERE account_id = $1 OR id = ANY($2)
+		`, tableName), accountID, pq.Array(ids)).Scan(&existing)
+		if err != nil {
+			return fmt.Errorf("failed to check existing transactions: %w", err)
+		}
+		if existing > 0 {
+			return ErrRestoreConflict
+		}
+
+		if len(transactions) > 0 {
+			if err := insertTransactionsBatch(tx, transactions, account.Platform, &models.TransactionChanges{}); err != nil {
+				return err
+			}
+		}
+
+		// Assets are upserted after the transactions so that the resolved names and
+		// symbols of the backup replace the placeholders created from the transactions,
+		// without replacing a symbol already verified on this instance
+		for _, asset := range backup.Assets {
+			_, err := tx.ExecContext(ctx, `
+				INSERT INTO assets (isin, name, symbol, symbol_verified, type, currency)
+				VALUES ($1, $2, $3, $4, $5, $6)
+				ON CONFLICT (isin) DO UPDATE
+				SET name = CASE WHEN assets.name = 'Unknown' THEN EXCLUDED.name ELSE assets.name END,
+				    symbol = CASE WHEN assets.symbol_verified THEN assets.symbol ELSE COALESCE(EXCLUDED.symbol, assets.symbol) END,
+				    symbol_verified = assets.symbol_verified OR EXCLUDED.symbol_verified
+			`, asset.ISIN, asset.Name, asset.Symbol, asset.SymbolVerified, asset.Type, asset.Currency)
+			if err != nil {
+				return fmt.Errorf("failed to restore asset %s: %w", asset.ISIN, err)
+			}
+		}
+
+		if len(backup.Prices) > 0 {
+			if err := insertAssetPricesBatch(tx, backup.Prices); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,61 @@
+package database
+
+import (
+	"fmt"
+	"time"
+)
+
+// dateOnlyLayout is the layout accepted for day-granularity date filters
+const dateOnlyLayout = "2006-01-02"
+
+// filterLocation is the time zone used to interpret YYYY-MM-DD date filters
+var filterLocation = time.UTC
+
+// SetFilterLocation sets the time zone used to interpret YYYY-MM-DD date filters (default UTC)
+func SetFilterLocation(loc *time.Location) {
+	if loc != nil {
+		filterLocation = loc
+	}
+}
+
+// FilterLocation returns the time zone used to interpret YYYY-MM-DD date filters
+func FilterLocation() *time.Location {
+	return filterLocation
+}
+
+// NormalizeDateBound converts a date filter value into an RFC3339 UTC bound.
+// A YYYY-MM-DD start date becomes 00:00:00 and an end date 23:59:59 of that day in the
+// filter time zone, so the range is inclusive. RFC3339 values are kept as instants.
+func NormalizeDateBound(value string, isEnd bool) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+
+	if t, err := time.ParseInLocation(dateOnlyLayout, value, filterLocation); err == nil {
+		if isEnd {
+			t = t.AddDate(0, 0, 1).Add(-time.Second)
+		}
+		return t.UTC().Format(time.RFC3339), nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.UTC().Format(time.RFC3339), nil
+	}
+
+	return "", fmt.Errorf("invalid date %q (use YYYY-MM-DD or RFC3339)", value)
+}
+
+// DateBounds returns the normalized inclusive start and end bounds of the filter
+func (f TransactionFilter) DateBounds() (string, string, error) {
+	startDate, err := NormalizeDateBound(f.StartDate, false)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid start date: %w", err)
+	}
+
+	endDate, err := NormalizeDateBound(f.EndDate, true)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid end date: %w", err)
+	}
+
+	return startDate, endDate, nil
+}
@@ -0,0 +1,92 @@
+package database
+
+import (
+	"testing"
+	"time"
+)
+
+// TestNormalizeDateBound tests conversion of date filters into inclusive RFC3339 bounds
+func TestNormalizeDateBound(t *testing.T) {
+	paris, err := time.LoadLocation("Europe/Paris")
+	if err != nil {
+		t.Skipf("time zone database not available: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		loc     *time.Location
+		value   string
+		isEnd   bool
+		want    string
+		wantErr bool
+	}{
+		{"empty", time.UTC, "", false, "", false},
+		{"start date UTC", time.UTC, "2024-01-01", false, "2024-01-01T00:00:00Z", false},
+		{"end date UTC", time.UTC, "2024-01-31", true, "2024-01-31T23:59:59Z", false},
+		{"start date Paris", paris, "2024-01-01", false, "2023-12-31T23:00:00Z", false},
+		{"end date Paris", paris, "2024-07-31", true, "2024-07-31T21:59:59Z", false},
+		{"RFC3339 with offset", time.UTC, "2024-01-01T01:30:00+02:00", false, "2023-12-31T23:30:00Z", false},
+		{"invalid date", time.UTC, "01/01/2024", false, "", true},
+	}
+
+	defer SetFilterLocation(time.UTC)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetFilterLocation(tt.loc)
+			got, err := NormalizeDateBound(tt.value, tt.isEnd)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NormalizeDateBound() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("NormalizeDateBound() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestDateBounds_DayBoundaryTransactions tests that transactions at the edges of a day
+// are included or excluded consistently regardless of their stored offset
+func TestDateBounds_DayBoundaryTransactions(t *testing.T) {
+	defer SetFilterLocation(time.UTC)
+	SetFilterLocation(time.UTC)
+
+	filter := TransactionFilter{StartDate: "2024-01-01", EndDate: "2024-01-01"}
+	start, end, err := filter.DateBounds()
+	if err != nil {
+		t.Fatalf("DateBounds() error = %v", err)
+	}
+	startTime, _ := time.Parse(time.RFC3339, start)
+	endTime, _ := time.Parse(time.RFC3339, end)
+
+	tests := []struct {
+		timestamp string
+		included  bool
+	}{
+		{"2024-01-01T00:00:00Z", true},
+		{"2024-01-01T23:59:59Z", true},
+		{"2024-01-02T00:00:00Z", false},
+		{"2023-12-31T23:59:59Z", false},
+		// Same instant as 2023-12-31T23:30:00Z: outside the UTC day even though the date reads 2024-01-01
+		{"2024-01-01T00:30:00+01:00", false},
+		// Same instant as 2024-01-01T23:30:00Z: inside the UTC day even though the date reads 2024-01-02
+		{"2024-01-02T00:30:00+01:00", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.timestamp, func(t *testing.T) {
+			ts, err := time.Parse(time.RFC3339, tt.timestamp)
+			if err != nil {
+				t.Fatalf("invalid test timestamp: %v", err)
+			}
+			included := !ts.Before(startTime) && !ts.After(endTime)
+			if included != tt.included {
+				t.Errorf("timestamp %s included = %v, want %v (bounds %s - %s)", tt.timestamp, included, tt.included, start, end)
+			}
+		})
+	}
+
+	if _, _, err := (TransactionFilter{EndDate: "2024-13-01"}).DateBounds(); err == nil {
+		t.Error("DateBounds() should reject an invalid end date")
+	}
+}
@@ -1,16 +1,29 @@
 package database
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 	_ "github.com/lib/pq"
 )
 
+// DefaultQueryTimeout bounds read queries whose context has no deadline
+const DefaultQueryTimeout = 30 * time.Second
+
+// DefaultStatementTimeout is the server-side statement_timeout set on every connection
+const DefaultStatementTimeout = 60 * time.Second
+
 // DB wraps the sqlx database connection
 type DB struct {
 	*sqlx.DB
+	// QueryTimeout overrides DefaultQueryTimeout when set
+	QueryTimeout time.Duration
+
+	retryPolicy RetryPolicy
+	breaker     *CircuitBreaker
 }
 
 // Config holds database configuration
@@ -21,14 +34,20 @@ type Config struct {
 	Password string
 	DBName   string
 	SSLMode  string
+	// Params holds extra connection parameters (e.g. application_name, connect_timeout)
+	Params map[string]string
+	// StatementTimeout overrides DefaultStatementTimeout when set
+	StatementTimeout time.Duration
 }
 
 // Connect establishes a connection to the PostgreSQL database
 func Connect(cfg Config) (*DB, error) {
-	dsn := fmt.Sprintf(
-		"host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
-		cfg.Host, cfg.Port, cfg.User, cfg.Password, cfg.DBName, cfg.SSLMode,
-	)
+	statementTimeout := cfg.StatementTimeout
+	if statementTimeout <= 0 {
+		statementTimeout = DefaultStatementTimeout
+	}
+
+	dsn := cfg.DSN(statementTimeout.Milliseconds())
 
 	db, err := sqlx.Connect("postgres", dsn)
 	if err != nil {
@@ -42,10 +61,25 @@ func Connect(cfg Config) (*DB, error) {
 
 	log.Println("✅ Successfully connected to PostgreSQL database")
 
-	return &DB{db}, nil
+	return &DB{DB: db, retryPolicy: DefaultRetryPolicy}, nil
 }
 
 // Close closes the database connection
 func (db *DB) Close() error {
 	return db.DB.Close()
 }
+
+// withQueryTimeout derives a context bounded by the query timeout
+// Contexts that already carry a deadline keep it
+func (db *DB) withQueryTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok {
+		return context.WithCancel(ctx)
+	}
+
+	timeout := db.QueryTimeout
+	if timeout <= 0 {
+		timeout = DefaultQueryTimeout
+	}
+
+	return context.WithTimeout(ctx, timeout)
+}
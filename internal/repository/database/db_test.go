@@ -0,0 +1,49 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestWithQueryTimeout(t *testing.T) {
+	db := &DB{QueryTimeout: 50 * time.Millisecond}
+
+	// No deadline: the query timeout applies
+	ctx, cancel := db.withQueryTimeout(context.Background())
+	defer cancel()
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		t.Fatal("expected a deadline on a background context")
+	}
+	if remaining := time.Until(deadline); remaining > 50*time.Millisecond {
+		t.Errorf("deadline too far: %s", remaining)
+	}
+
+	// Existing deadline is kept
+	parent, parentCancel := context.WithTimeout(context.Background(), time.Hour)
+	defer parentCancel()
+	ctx, cancel = db.withQueryTimeout(parent)
+	defer cancel()
+	if deadline, _ := ctx.Deadline(); time.Until(deadline) < time.Minute {
+		t.Error("existing deadline should be preserved")
+	}
+
+	// Canceling the request cancels the query context
+	request, requestCancel := context.WithCancel(context.Background())
+	ctx, cancel = db.withQueryTimeout(request)
+	defer cancel()
+	requestCancel()
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Error("query context should be canceled with the request")
+	}
+
+	// Zero value uses the default timeout
+	ctx, cancel = (&DB{}).withQueryTimeout(context.Background())
+	defer cancel()
+	if deadline, _ := ctx.Deadline(); time.Until(deadline) > DefaultQueryTimeout {
+		t.Error("default timeout should apply when QueryTimeout is not set")
+	}
+}
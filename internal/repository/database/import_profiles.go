@@ -0,0 +1,93 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"time"
+	"valhafin/internal/domain/models"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// ErrImportProfileExists is returned when an import profile with the same name already exists
+var ErrImportProfileExists = errors.New("import profile already exists")
+
+// uniqueViolation is the PostgreSQL error code raised by a unique constraint
+const uniqueViolation = "23505"
+
+// CreateImportProfile saves a new CSV import profile
+func (db *DB) CreateImportProfile(profile *models.ImportProfile) error {
+	// Generate UUID if not provided
+	if profile.ID == "" {
+		profile.ID = uuid.New().String()
+	}
+
+	profile.CreatedAt = time.Now()
+
+	// Validate profile
+	if err := profile.Validate(); err != nil {
+		return fmt.Errorf("validation failed: %w", err)
+	}
+
+	query := `
+		INSERT INTO import_profiles (id, name, columns, date_format, decimal_style, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+
+	_, err := db.Exec(
+		query,
+		profile.ID,
+		profile.Name,
+		profile.Columns,
+		profile.DateFormat,
+		profile.DecimalStyle,
+		profile.CreatedAt,
+	)
+
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+			return fmt.Errorf("%w: %s", ErrImportProfileExists, profile.Name)
+		}
+		return fmt.Errorf("failed to create import profile: %w", err)
+	}
+
+	return nil
+}
+
+// GetImportProfileByName retrieves an import profile by its name
+func (db *DB) GetImportProfileByName(name string) (*models.ImportProfile, error) {
+	var profile models.ImportProfile
+
+	query := `
+		SELECT id, name, columns, date_format, decimal_style, created_at
+		FROM import_profiles
+		WHERE name = $1
+	`
+
+	err := db.Get(&profile, query, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get import profile: %w", err)
+	}
+
+	return &profile, nil
+}
+
+// GetAllImportProfiles retrieves all import profiles ordered by name
+func (db *DB) GetAllImportProfiles() ([]models.ImportProfile, error) {
+	var profiles []models.ImportProfile
+
+	query := `
+		SELECT id, name, columns, date_format, decimal_style, created_at
+		FROM import_profiles
+		ORDER BY name
+	`
+
+	err := db.Select(&profiles, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get import profiles: %w", err)
+	}
+
+	return profiles, nil
+}
@@ -0,0 +1,27 @@
+package database
+
+import (
+	"errors"
+	"testing"
+	"valhafin/internal/domain/models"
+)
+
+func TestCreateImportProfile_Duplicate(t *testing.T) {
+	db := NewTestDB(t)
+
+	newProfile := func() *models.ImportProfile {
+		return &models.ImportProfile{
+			Name:    "broker",
+			Columns: models.ColumnMapping{"Date": "timestamp", "ISIN": "isin", "Montant": "amount_value"},
+		}
+	}
+
+	if err := db.CreateImportProfile(newProfile()); err != nil {
+		t.Fatalf("Failed to create import profile: %v", err)
+	}
+
+	err := db.CreateImportProfile(newProfile())
+	if !errors.Is(err, ErrImportProfileExists) {
+		t.Fatalf("Expected ErrImportProfileExists, got %v", err)
+	}
+}
@@ -3,6 +3,7 @@ package database
 import (
 	"fmt"
 	"log"
+	"time"
 )
 
 // Migration represents a database migration
@@ -232,6 +233,221 @@ var migrations = []Migration{
 			ALTER TABLE assets DROP COLUMN IF EXISTS symbol_verified;
 		`,
 	},
+	{
+		Version: 9,
+		Name:    "create_alerts_table",
+		Up: `
+			CREATE TABLE IF NOT EXISTS alerts (
+				id VARCHAR(36) PRIMARY KEY,
+				type VARCHAR(50) NOT NULL,
+				isin VARCHAR(12),
+				threshold DECIMAL(20, 8) NOT NULL DEFAULT 0,
+				enabled BOOLEAN NOT NULL DEFAULT TRUE,
+				triggered BOOLEAN NOT NULL DEFAULT FALSE,
+				last_triggered_at TIMESTAMP,
+				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+			);
+			CREATE INDEX IF NOT EXISTS idx_alerts_enabled ON alerts(enabled);
+		`,
+		Down: `
+			DROP TABLE IF EXISTS alerts CASCADE;
+		`,
+	},
+	{
+		Version: 10,
+		Name:    "create_import_profiles_table",
+		Up: `
+			CREATE TABLE IF NOT EXISTS import_profiles (
+				id VARCHAR(36) PRIMARY KEY,
+				name VARCHAR(255) NOT NULL UNIQUE,
+				columns JSONB NOT NULL,
+				date_format VARCHAR(100) NOT NULL DEFAULT '',
+				decimal_style VARCHAR(10) NOT NULL DEFAULT 'auto',
+				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+			);
+		`,
+		Down: `
+			DROP TABLE IF EXISTS import_profiles CASCADE;
+		`,
+	},
+	{
+		Version: 11,
+		Name:    "add_account_currency",
+		Up: `
+			ALTER TABLE accounts ADD COLUMN IF NOT EXISTS currency VARCHAR(3) NOT NULL DEFAULT 'EUR';
+		`,
+		Down: `
+			ALTER TABLE accounts DROP COLUMN IF EXISTS currency;
+		`,
+	},
+	{
+		Version: 12,
+		Name:    "create_position_snapshots_table",
+		Up: `
+			CREATE TABLE IF NOT EXISTS position_snapshots (
+				id VARCHAR(36) PRIMARY KEY,
+				account_id UUID NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+				taken_at TIMESTAMP NOT NULL
+			);
+
+			CREATE INDEX IF NOT EXISTS idx_position_snapshots_account ON position_snapshots(account_id, taken_at DESC);
+
+			CREATE TABLE IF NOT EXISTS position_snapshot_items (
+				snapshot_id VARCHAR(36) NOT NULL REFERENCES position_snapshots(id) ON DELETE CASCADE,
+				isin VARCHAR(12) NOT NULL,
+				quantity DECIMAL(20, 8) NOT NULL,
+				average_price DECIMAL(20, 8) NOT NULL DEFAULT 0,
+				PRIMARY KEY (snapshot_id, isin)
+			);
+		`,
+		Down: `
+			DROP TABLE IF EXISTS position_snapshot_items CASCADE;
+			DROP TABLE IF EXISTS position_snapshots CASCADE;
+		`,
+	},
+	{
+		Version: 13,
+		Name:    "add_transactions_updated_at",
+		Up: `
+			CREATE OR REPLACE FUNCTION set_transaction_updated_at() RETURNS TRIGGER AS $$
+			BEGIN
+				NEW.updated_at = NOW();
+				RETURN NEW;
+			END;
+			$$ LANGUAGE plpgsql;
+
+			ALTER TABLE transactions_traderepublic ADD COLUMN IF NOT EXISTS updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW();
+			CREATE INDEX IF NOT EXISTS idx_transactions_tr_updated_at ON transactions_traderepublic(updated_at, id);
+			DROP TRIGGER IF EXISTS trg_transactions_tr_updated_at ON transactions_traderepublic;
+			CREATE TRIGGER trg_transactions_tr_updated_at BEFORE UPDATE ON transactions_traderepublic
+				FOR EACH ROW EXECUTE FUNCTION set_transaction_updated_at();
+
+			ALTER TABLE transactions_binance ADD COLUMN IF NOT EXISTS updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW();
+			CREATE INDEX IF NOT EXISTS idx_transactions_bn_updated_at ON transactions_binance(updated_at, id);
+			DROP TRIGGER IF EXISTS trg_transactions_bn_updated_at ON transactions_binance;
+			CREATE TRIGGER trg_transactions_bn_updated_at BEFORE UPDATE ON transactions_binance
+				FOR EACH ROW EXECUTE FUNCTION set_transaction_updated_at();
+
+			ALTER TABLE transactions_boursedirect ADD COLUMN IF NOT EXISTS updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW();
+			CREATE INDEX IF NOT EXISTS idx_transactions_bd_updated_at ON transactions_boursedirect(updated_at, id);
+			DROP TRIGGER IF EXISTS trg_transactions_bd_updated_at ON transactions_boursedirect;
+			CREATE TRIGGER trg_transactions_bd_updated_at BEFORE UPDATE ON transactions_boursedirect
+				FOR EACH ROW EXECUTE FUNCTION set_transaction_updated_at();
+		`,
+		Down: `
+			DROP TRIGGER IF EXISTS trg_transactions_tr_updated_at ON transactions_traderepublic;
+			ALTER TABLE transactions_traderepublic DROP COLUMN IF EXISTS updated_at;
+			DROP TRIGGER IF EXISTS trg_transactions_bn_updated_at ON transactions_binance;
+			ALTER TABLE transactions_binance DROP COLUMN IF EXISTS updated_at;
+			DROP TRIGGER IF EXISTS trg_transactions_bd_updated_at ON transactions_boursedirect;
+			ALTER TABLE transactions_boursedirect DROP COLUMN IF EXISTS updated_at;
+			DROP FUNCTION IF EXISTS set_transaction_updated_at();
+		`,
+	},
+	{
+		Version: 14,
+		Name:    "create_account_sessions_table",
+		Up: `
+			CREATE TABLE IF NOT EXISTS account_sessions (
+				account_id UUID PRIMARY KEY REFERENCES accounts(id) ON DELETE CASCADE,
+				token TEXT NOT NULL,
+				expires_at TIMESTAMP NOT NULL,
+				created_at TIMESTAMP NOT NULL DEFAULT NOW()
+			);
+		`,
+		Down: `
+			DROP TABLE IF EXISTS account_sessions CASCADE;
+		`,
+	},
+	{
+		Version: 15,
+		Name:    "add_transactions_tags_and_note",
+		Up: `
+			ALTER TABLE transactions_traderepublic ADD COLUMN IF NOT EXISTS tags TEXT[] NOT NULL DEFAULT '{}';
+			ALTER TABLE transactions_traderepublic ADD COLUMN IF NOT EXISTS note TEXT NOT NULL DEFAULT '';
+			CREATE INDEX IF NOT EXISTS idx_transactions_tr_tags ON transactions_traderepublic USING GIN (tags);
+
+			ALTER TABLE transactions_binance ADD COLUMN IF NOT EXISTS tags TEXT[] NOT NULL DEFAULT '{}';
+			ALTER TABLE transactions_binance ADD COLUMN IF NOT EXISTS note TEXT NOT NULL DEFAULT '';
+			CREATE INDEX IF NOT EXISTS idx_transactions_bn_tags ON transactions_binance USING GIN (tags);
+
+			ALTER TABLE transactions_boursedirect ADD COLUMN IF NOT EXISTS tags TEXT[] NOT NULL DEFAULT '{}';
+			ALTER TABLE transactions_boursedirect ADD COLUMN IF NOT EXISTS note TEXT NOT NULL DEFAULT '';
+			CREATE INDEX IF NOT EXISTS idx_transactions_bd_tags ON transactions_boursedirect USING GIN (tags);
+		`,
+		Down: `
+			ALTER TABLE transactions_traderepublic DROP COLUMN IF EXISTS tags, DROP COLUMN IF EXISTS note;
+			ALTER TABLE transactions_binance DROP COLUMN IF EXISTS tags, DROP COLUMN IF EXISTS note;
+			ALTER TABLE transactions_boursedirect DROP COLUMN IF EXISTS tags, DROP COLUMN IF EXISTS note;
+		`,
+	},
+	{
+		Version: 16,
+		Name:    "add_account_decimal_style",
+		Up: `
+			ALTER TABLE accounts ADD COLUMN IF NOT EXISTS decimal_style VARCHAR(10) NOT NULL DEFAULT 'auto';
+		`,
+		Down: `
+			ALTER TABLE accounts DROP COLUMN IF EXISTS decimal_style;
+		`,
+	},
+	{
+		Version: 17,
+		Name:    "add_transactions_change_xid",
+		Up: `
+			CREATE OR REPLACE FUNCTION set_transaction_updated_at() RETURNS TRIGGER AS $$
+			BEGIN
+				NEW.updated_at = NOW();
+				NEW.change_xid = pg_current_xact_id();
+				RETURN NEW;
+			END;
+			$$ LANGUAGE plpgsql;
+
+			ALTER TABLE transactions_traderepublic ADD COLUMN IF NOT EXISTS change_xid xid8 NOT NULL DEFAULT pg_current_xact_id();
+			CREATE INDEX IF NOT EXISTS idx_transactions_tr_change_xid ON transactions_traderepublic(change_xid, id);
+
+			ALTER TABLE transactions_binance ADD COLUMN IF NOT EXISTS change_xid xid8 NOT NULL DEFAULT pg_current_xact_id();
+			CREATE INDEX IF NOT EXISTS idx_transactions_bn_change_xid ON transactions_binance(change_xid, id);
+
+			ALTER TABLE transactions_boursedirect ADD COLUMN IF NOT EXISTS change_xid xid8 NOT NULL DEFAULT pg_current_xact_id();
+			CREATE INDEX IF NOT EXISTS idx_transactions_bd_change_xid ON transactions_boursedirect(change_xid, id);
+		`,
+		Down: `
+			CREATE OR REPLACE FUNCTION set_transaction_updated_at() RETURNS TRIGGER AS $$
+			BEGIN
+				NEW.updated_at = NOW();
+				RETURN NEW;
+			END;
+			$$ LANGUAGE plpgsql;
+
+			ALTER TABLE transactions_traderepublic DROP COLUMN IF EXISTS change_xid;
+			ALTER TABLE transactions_binance DROP COLUMN IF EXISTS change_xid;
+			ALTER TABLE transactions_boursedirect DROP COLUMN IF EXISTS change_xid;
+		`,
+	},
+	{
+		Version: 18,
+		Name:    "unique_asset_price_per_trading_day",
+		Up: `
+			-- Keep the last price of each asset and day, the one the date lookups already returned
+			DELETE FROM asset_prices p
+			USING (
+				SELECT id, ROW_NUMBER() OVER (
+					PARTITION BY isin, timestamp::date
+					ORDER BY timestamp DESC, id DESC
+				) AS rn
+				FROM asset_prices
+			) duplicate
+			WHERE p.id = duplicate.id AND duplicate.rn > 1;
+
+			ALTER TABLE asset_prices DROP CONSTRAINT IF EXISTS asset_prices_isin_timestamp_key;
+			CREATE UNIQUE INDEX IF NOT EXISTS idx_asset_prices_isin_day ON asset_prices(isin, (timestamp::date));
+		`,
+		Down: `
+			DROP INDEX IF EXISTS idx_asset_prices_isin_day;
+			ALTER TABLE asset_prices ADD CONSTRAINT asset_prices_isin_timestamp_key UNIQUE (isin, timestamp);
+		`,
+	},
 }
 
 // RunMigrations executes all pending migrations
@@ -331,3 +547,132 @@ func (db *DB) RollbackMigration() error {
 	log.Printf("✅ Migration %d rolled back successfully", currentVersion)
 	return nil
 }
+
+// AppliedMigration represents a migration recorded in schema_migrations
+type AppliedMigration struct {
+	Version   int       `db:"version" json:"version"`
+	Name      string    `db:"name" json:"name"`
+	AppliedAt time.Time `db:"applied_at" json:"applied_at"`
+}
+
+// MigrationInfo identifies a migration known by the application
+type MigrationInfo struct {
+	Version int    `json:"version"`
+	Name    string `json:"name"`
+}
+
+// MigrationStatus compares the applied migrations with the ones known by the application
+type MigrationStatus struct {
+	CurrentVersion int                `json:"current_version"`
+	LatestVersion  int                `json:"latest_version"`
+	UpToDate       bool               `json:"up_to_date"`
+	Applied        []AppliedMigration `json:"applied"`
+	// Pending are migrations newer than the current version
+	Pending []MigrationInfo `json:"pending"`
+	// Missing are migrations older than the current version that were never recorded (partial apply)
+	Missing []MigrationInfo `json:"missing"`
+	// Unknown are recorded versions the application does not know (database ahead of the code)
+	Unknown []int `json:"unknown"`
+}
+
+// LatestMigrationVersion returns the version of the most recent known migration
+func LatestMigrationVersion() int {
+	latest := 0
+	for _, migration := range migrations {
+		if migration.Version > latest {
+			latest = migration.Version
+		}
+	}
+	return latest
+}
+
+// GetAppliedMigrations returns the migrations recorded in schema_migrations ordered by version
+func (db *DB) GetAppliedMigrations() ([]AppliedMigration, error) {
+	var applied []AppliedMigration
+	err := db.Select(&applied, "SELECT version, name, applied_at FROM schema_migrations ORDER BY version")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get applied migrations: %w", err)
+	}
+	return applied, nil
+}
+
+// GetMigrationStatus reports applied, pending and missing migrations
+func (db *DB) GetMigrationStatus() (*MigrationStatus, error) {
+	applied, err := db.GetAppliedMigrations()
+	if err != nil {
+		return nil, err
+	}
+	return buildMigrationStatus(migrations, applied), nil
+}
+
+// VerifyMigrations returns an error if any known migration is not recorded as applied
+// It is meant to run right after RunMigrations so that a partially migrated schema fails startup
+func (db *DB) VerifyMigrations() error {
+	status, err := db.GetMigrationStatus()
+	if err != nil {
+		return err
+	}
+
+	if len(status.Missing) > 0 || len(status.Pending) > 0 {
+		notApplied := append(append([]MigrationInfo{}, status.Missing...), status.Pending...)
+		versions := make([]string, 0, len(notApplied))
+		for _, m := range notApplied {
+			versions = append(versions, fmt.Sprintf("%d (%s)", m.Version, m.Name))
+		}
+		return fmt.Errorf("schema at version %d is missing migrations: %v", status.CurrentVersion, versions)
+	}
+
+	if len(status.Unknown) > 0 {
+		log.Printf("WARNING: Database has migrations unknown to this version of the application: %v", status.Unknown)
+	}
+
+	return nil
+}
+
+// buildMigrationStatus compares the known migrations with the applied ones
+func buildMigrationStatus(known []Migration, applied []AppliedMigration) *MigrationStatus {
+	status := &MigrationStatus{
+		Applied: applied,
+		Pending: []MigrationInfo{},
+		Missing: []MigrationInfo{},
+		Unknown: []int{},
+	}
+	if status.Applied == nil {
+		status.Applied = []AppliedMigration{}
+	}
+
+	appliedVersions := make(map[int]bool, len(applied))
+	for _, m := range applied {
+		appliedVersions[m.Version] = true
+		if m.Version > status.CurrentVersion {
+			status.CurrentVersion = m.Version
+		}
+	}
+
+	knownVersions := make(map[int]bool, len(known))
+	for _, m := range known {
+		knownVersions[m.Version] = true
+		if m.Version > status.LatestVersion {
+			status.LatestVersion = m.Version
+		}
+		if appliedVersions[m.Version] {
+			continue
+		}
+
+		info := MigrationInfo{Version: m.Version, Name: m.Name}
+		if m.Version < status.CurrentVersion {
+			status.Missing = append(status.Missing, info)
+		} else {
+			status.Pending = append(status.Pending, info)
+		}
+	}
+
+	for _, m := range applied {
+		if !knownVersions[m.Version] {
+			status.Unknown = append(status.Unknown, m.Version)
+		}
+	}
+
+	status.UpToDate = len(status.Pending) == 0 && len(status.Missing) == 0
+	return status
+}
@@ -0,0 +1,91 @@
+package database
+
+import (
+	"testing"
+)
+
+func TestMigrations_OrderedAndUnique(t *testing.T) {
+	seen := make(map[int]bool)
+	previous := 0
+	for _, m := range migrations {
+		if seen[m.Version] {
+			t.Errorf("Duplicate migration version %d", m.Version)
+		}
+		seen[m.Version] = true
+		if m.Version <= previous {
+			t.Errorf("Migration %d is out of order (after %d)", m.Version, previous)
+		}
+		previous = m.Version
+		if m.Name == "" || m.Up == "" {
+			t.Errorf("Migration %d must have a name and an Up script", m.Version)
+		}
+	}
+
+	if LatestMigrationVersion() != previous {
+		t.Errorf("LatestMigrationVersion() = %d, want %d", LatestMigrationVersion(), previous)
+	}
+}
+
+func TestBuildMigrationStatus(t *testing.T) {
+	known := []Migration{
+		{Version: 1, Name: "one"},
+		{Version: 2, Name: "two"},
+		{Version: 3, Name: "three"},
+		{Version: 4, Name: "four"},
+	}
+
+	tests := []struct {
+		name     string
+		applied  []int
+		current  int
+		upToDate bool
+		pending  []int
+		missing  []int
+		unknown  []int
+	}{
+		{"fresh database", nil, 0, false, []int{1, 2, 3, 4}, nil, nil},
+		{"up to date", []int{1, 2, 3, 4}, 4, true, nil, nil, nil},
+		{"pending migrations", []int{1, 2}, 2, false, []int{3, 4}, nil, nil},
+		{"partially applied", []int{1, 3, 4}, 4, false, nil, []int{2}, nil},
+		{"database ahead of code", []int{1, 2, 3, 4, 5}, 5, true, nil, nil, []int{5}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var applied []AppliedMigration
+			for _, v := range tt.applied {
+				applied = append(applied, AppliedMigration{Version: v})
+			}
+
+			status := buildMigrationStatus(known, applied)
+
+			if status.CurrentVersion != tt.current {
+				t.Errorf("CurrentVersion = %d, want %d", status.CurrentVersion, tt.current)
+			}
+			if status.LatestVersion != 4 {
+				t.Errorf("LatestVersion = %d, want 4", status.LatestVersion)
+			}
+			if status.UpToDate != tt.upToDate {
+				t.Errorf("UpToDate = %v, want %v", status.UpToDate, tt.upToDate)
+			}
+			assertVersions(t, "Pending", status.Pending, tt.pending)
+			assertVersions(t, "Missing", status.Missing, tt.missing)
+			if len(status.Unknown) != len(tt.unknown) {
+				t.Errorf("Unknown = %v, want %v", status.Unknown, tt.unknown)
+			}
+		})
+	}
+}
+
+func assertVersions(t *testing.T, label string, got []MigrationInfo, want []int) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Errorf("%s = %v, want %v", label, got, want)
+		return
+	}
+	for i := range want {
+		if got[i].Version != want[i] {
+			t.Errorf("%s[%d] = %d, want %d", label, i, got[i].Version, want[i])
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+	"valhafin/internal/domain/models"
+
+	"github.com/google/uuid"
+)
+
+// CreatePositionSnapshot stores the positions reported by the broker for an account.
+// A snapshot without positions is kept too: it records that the account holds nothing.
+func (db *DB) CreatePositionSnapshot(ctx context.Context, snapshot *models.PositionSnapshot) error {
+	if snapshot.ID == "" {
+		snapshot.ID = uuid.New().String()
+	}
+	if snapshot.TakenAt.IsZero() {
+		snapshot.TakenAt = time.Now().UTC()
+	}
+
+	if err := snapshot.Validate(); err != nil {
+		return fmt.Errorf("validation failed: %w", err)
+	}
+
+	err := db.InTransaction(ctx, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO position_snapshots (id, account_id, taken_at)
+			VALUES ($1, $2, $3)
+		`, snapshot.ID, snapshot.AccountID, snapshot.TakenAt)
+		if err != nil {
+			return err
+		}
+
+		for _, position := range snapshot.Positions {
+			_, err := tx.ExecContext(ctx, `
+				INSERT INTO position_snapshot_items (snapshot_id, isin, quantity, average_price)
+				VALUES ($1, $2, $3, $4)
+			`, snapshot.ID, position.ISIN, position.Quantity, position.AveragePrice)
+			if err != nil {
+				return fmt.Errorf("failed to store position %s: %w", position.ISIN, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create position snapshot: %w", err)
+	}
+
+	return nil
+}
+
+// GetLatestPositionSnapshot returns the most recent broker snapshot of an account.
+// It returns sql.ErrNoRows when the account has no snapshot.
+func (db *DB) GetLatestPositionSnapshot(ctx context.Context, accountID string) (*models.PositionSnapshot, error) {
+	ctx, cancel := db.withQueryTimeout(ctx)
+	defer cancel()
+
+	var header struct {
+		ID      string    `db:"id"`
+		TakenAt time.Time `db:"taken_at"`
+	}
+	err := db.GetContext(ctx, &header, `
+		SELECT id, taken_at
+		FROM position_snapshots
+		WHERE account_id = $1
+		ORDER BY taken_at DESC
+		LIMIT 1
+	`, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	positions := []models.BrokerPosition{}
+	err = db.SelectContext(ctx, &positions, `
+		SELECT isin, quantity, average_price
+		FROM position_snapshot_items
+		WHERE snapshot_id = $1
+		ORDER BY isin
+	`, header.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get position snapshot items: %w", err)
+	}
+
+	return &models.PositionSnapshot{
+		ID:        header.ID,
+		AccountID: accountID,
+		TakenAt:   header.TakenAt,
+		Positions: positions,
+	}, nil
+}
@@ -0,0 +1,101 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// PriceRetention controls how the price history is downsampled as it ages, so that
+// daily fetches do not accumulate indefinitely in asset_prices
+type PriceRetention struct {
+	// DailyMonths keeps every price for this many months; older prices are reduced to
+	// the last one of each week (0 keeps every price)
+	DailyMonths int
+	// WeeklyMonths keeps weekly prices for this many months; older prices are reduced to
+	// the last one of each month (0 keeps weekly prices)
+	WeeklyMonths int
+}
+
+// Enabled reports whether the retention removes any price
+func (r PriceRetention) Enabled() bool {
+	return r.DailyMonths > 0 || r.WeeklyMonths > 0
+}
+
+// cutoffs returns the dates before which prices are reduced to weekly and to monthly
+// points. A zero time disables the corresponding step.
+func (r PriceRetention) cutoffs(now time.Time) (time.Time, time.Time) {
+	var weeklyBefore, monthlyBefore time.Time
+	if r.DailyMonths > 0 {
+		weeklyBefore = now.AddDate(0, -r.DailyMonths, 0)
+	}
+	if r.WeeklyMonths > 0 {
+		monthlyBefore = now.AddDate(0, -r.WeeklyMonths, 0)
+	}
+	return weeklyBefore, monthlyBefore
+}
+
+// CompactionResult reports the prices removed by CompactAssetPrices
+type CompactionResult struct {
+	WeeklyRemoved  int64 `json:"weekly_removed"`
+	MonthlyRemoved int64 `json:"monthly_removed"`
+}
+
+// CompactAssetPrices downsamples the price history according to the retention: in each
+// period older than the cutoff, only the last price of the period is kept.
+func (db *DB) CompactAssetPrices(ctx context.Context, retention PriceRetention, now time.Time) (CompactionResult, error) {
+	var result CompactionResult
+	weeklyBefore, monthlyBefore := retention.cutoffs(now)
+
+	err := db.InTransaction(ctx, func(tx *sql.Tx) error {
+		result = CompactionResult{}
+
+		if !weeklyBefore.IsZero() {
+			removed, err := downsampleAssetPrices(ctx, tx, "week", weeklyBefore)
+			if err != nil {
+				return err
+			}
+			result.WeeklyRemoved = removed
+		}
+
+		if !monthlyBefore.IsZero() {
+			removed, err := downsampleAssetPrices(ctx, tx, "month", monthlyBefore)
+			if err != nil {
+				return err
+			}
+			result.MonthlyRemoved = removed
+		}
+
+		return nil
+	})
+	if err != nil {
+		return CompactionResult{}, err
+	}
+
+	return result, nil
+}
+
+// downsampleAssetPrices keeps the last price of each asset and period (a date_trunc field)
+// among the prices older than before, and deletes the others
+func downsampleAssetPrices(ctx context.Context, tx *sql.Tx, period string, before time.Time) (int64, error) {
+	query := fmt.Sprintf(`
+		DELETE FROM asset_prices p
+		USING (
+			SELECT id, ROW_NUMBER() OVER (
+				PARTITION BY isin, date_trunc('%s', timestamp)
+				ORDER BY timestamp DESC
+			) AS rn
+			FROM asset_prices
+			WHERE timestamp < $1
+		) old
+		WHERE p.id = old.id AND old.rn > 1
+	`, period)
+
+	res, err := tx.ExecContext(ctx, query, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to downsample prices to one per %s: %w", period, err)
+	}
+
+	return res.RowsAffected()
+}
@@ -0,0 +1,98 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+	"valhafin/internal/domain/models"
+)
+
+func TestPriceRetentionCutoffs(t *testing.T) {
+	now := time.Date(2024, 6, 15, 12, 0, 0, 0, time.UTC)
+
+	weeklyBefore, monthlyBefore := PriceRetention{DailyMonths: 6, WeeklyMonths: 24}.cutoffs(now)
+	if !weeklyBefore.Equal(time.Date(2023, 12, 15, 12, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected weekly cutoff %s", weeklyBefore)
+	}
+	if !monthlyBefore.Equal(time.Date(2022, 6, 15, 12, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected monthly cutoff %s", monthlyBefore)
+	}
+
+	weeklyBefore, monthlyBefore = PriceRetention{WeeklyMonths: 24}.cutoffs(now)
+	if !weeklyBefore.IsZero() || monthlyBefore.IsZero() {
+		t.Errorf("expected only the monthly step, got %s and %s", weeklyBefore, monthlyBefore)
+	}
+
+	if (PriceRetention{}).Enabled() {
+		t.Error("expected an empty retention to be disabled")
+	}
+}
+
+func TestAssetPricesRefetchAndCompaction(t *testing.T) {
+	db := NewTestDB(t)
+
+	isin := "IE00B4L5Y983"
+	if err := db.CreateAsset(&models.Asset{ISIN: isin, Name: "iShares Core MSCI World", Type: "etf", Currency: "EUR"}); err != nil {
+		t.Fatalf("Failed to create asset: %v", err)
+	}
+
+	// Daily prices fetched twice over overlapping ranges: Jan 1-20, then Jan 10-31
+	dailyPrices := func(from, to int, price float64) []models.AssetPrice {
+		var prices []models.AssetPrice
+		for day := from; day <= to; day++ {
+			prices = append(prices, models.AssetPrice{
+				ISIN:      isin,
+				Price:     price + float64(day),
+				Currency:  "EUR",
+				Timestamp: time.Date(2024, 1, day, 17, 30, 0, 0, time.UTC),
+			})
+		}
+		return prices
+	}
+	if err := db.CreateAssetPricesBatch(dailyPrices(1, 20, 100)); err != nil {
+		t.Fatalf("Failed to store prices: %v", err)
+	}
+	if err := db.CreateAssetPricesBatch(dailyPrices(10, 31, 200)); err != nil {
+		t.Fatalf("Failed to store prices: %v", err)
+	}
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	history, err := db.GetAssetPriceHistory(isin, start, end)
+	if err != nil {
+		t.Fatalf("Failed to get history: %v", err)
+	}
+	if len(history) != 31 {
+		t.Fatalf("expected one price per day (31) after overlapping fetches, got %d", len(history))
+	}
+	for _, price := range history {
+		if price.Timestamp.Day() >= 10 && price.Price != 200+float64(price.Timestamp.Day()) {
+			t.Errorf("expected the re-fetched price to replace the stored one on %s, got %v", price.Timestamp, price.Price)
+		}
+	}
+
+	// Everything is older than the daily retention: one price per week remains,
+	// then one per month once past the weekly retention
+	now := time.Date(2024, 8, 1, 0, 0, 0, 0, time.UTC)
+	result, err := db.CompactAssetPrices(context.Background(), PriceRetention{DailyMonths: 3}, now)
+	if err != nil {
+		t.Fatalf("Failed to compact prices: %v", err)
+	}
+	history, _ = db.GetAssetPriceHistory(isin, start, end)
+	// Jan 1 2024 is a Monday: weeks of Jan 1, 8, 15, 22 and 29
+	if len(history) != 5 || result.WeeklyRemoved != 26 {
+		t.Fatalf("expected 5 weekly prices (26 removed), got %d (%+v)", len(history), result)
+	}
+
+	result, err = db.CompactAssetPrices(context.Background(), PriceRetention{DailyMonths: 3, WeeklyMonths: 6}, now)
+	if err != nil {
+		t.Fatalf("Failed to compact prices: %v", err)
+	}
+	history, _ = db.GetAssetPriceHistory(isin, start, end)
+	if len(history) != 1 || result.MonthlyRemoved != 4 {
+		t.Fatalf("expected a single monthly price (4 removed), got %d (%+v)", len(history), result)
+	}
+	if !history[0].Timestamp.Equal(time.Date(2024, 1, 31, 17, 30, 0, 0, time.UTC)) {
+		t.Errorf("expected the last price of the month to be kept, got %s", history[0].Timestamp)
+	}
+}
@@ -1,7 +1,11 @@
 package database
 
 import (
+	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 	"valhafin/internal/domain/models"
 )
@@ -20,7 +24,7 @@ func (db *DB) CreateAsset(asset *models.Asset) error {
 		INSERT INTO assets (isin, name, symbol, type, currency, last_updated)
 		VALUES ($1, $2, $3, $4, $5, $6)
 		ON CONFLICT (isin) DO UPDATE
-		SET name = EXCLUDED.name,
+		SET name = CASE WHEN EXCLUDED.name = 'Unknown' THEN assets.name ELSE EXCLUDED.name END,
 		    symbol = EXCLUDED.symbol,
 		    type = EXCLUDED.type,
 		    currency = EXCLUDED.currency,
@@ -46,6 +50,14 @@ func (db *DB) CreateAsset(asset *models.Asset) error {
 
 // GetAssetByISIN retrieves an asset by its ISIN
 func (db *DB) GetAssetByISIN(isin string) (*models.Asset, error) {
+	return db.GetAssetByISINContext(context.Background(), isin)
+}
+
+// GetAssetByISINContext is like GetAssetByISIN but is canceled with ctx and bounded by the query timeout
+func (db *DB) GetAssetByISINContext(ctx context.Context, isin string) (*models.Asset, error) {
+	ctx, cancel := db.withQueryTimeout(ctx)
+	defer cancel()
+
 	var asset models.Asset
 
 	query := `
@@ -54,7 +66,7 @@ func (db *DB) GetAssetByISIN(isin string) (*models.Asset, error) {
 		WHERE isin = $1
 	`
 
-	err := db.Get(&asset, query, isin)
+	err := db.GetContext(ctx, &asset, query, isin)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get asset: %w", err)
 	}
@@ -62,6 +74,34 @@ func (db *DB) GetAssetByISIN(isin string) (*models.Asset, error) {
 	return &asset, nil
 }
 
+// GetAssetMetadata returns the metadata of a Trade Republic transaction on the asset,
+// which carries the symbol, name and exchanges used for symbol resolution.
+// It returns nil when no transaction on the asset has metadata.
+func (db *DB) GetAssetMetadata(isin string) (*models.TransactionMetadata, error) {
+	var metadataJSON *string
+
+	query := `
+		SELECT metadata
+		FROM transactions_traderepublic
+		WHERE isin = $1 AND metadata IS NOT NULL
+		LIMIT 1
+	`
+
+	err := db.Get(&metadataJSON, query, isin)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get asset metadata: %w", err)
+	}
+	if metadataJSON == nil {
+		return nil, nil
+	}
+
+	metadata := models.ParseTransactionMetadata(*metadataJSON)
+	return &metadata, nil
+}
+
 // GetAllAssets retrieves all assets
 func (db *DB) GetAllAssets() ([]models.Asset, error) {
 	var assets []models.Asset
@@ -80,6 +120,121 @@ func (db *DB) GetAllAssets() ([]models.Asset, error) {
 	return assets, nil
 }
 
+// AssetSearchResult is an asset matching a search, with whether it appears in the portfolio transactions
+type AssetSearchResult struct {
+	models.Asset
+	Held bool `json:"held" db:"held"`
+}
+
+// likeContains returns the LIKE pattern matching the values that contain search. The LIKE
+// wildcards of search (% and _) and the escape character are escaped, so the pattern must be
+// used with ESCAPE '\'.
+func likeContains(search string) string {
+	escaped := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(search)
+	return "%" + escaped + "%"
+}
+
+// SearchAssets searches assets by name, symbol or ISIN (case-insensitive partial match).
+// Assets that appear in non-deleted transactions come first.
+func (db *DB) SearchAssets(ctx context.Context, search string, limit int) ([]AssetSearchResult, error) {
+	ctx, cancel := db.withQueryTimeout(ctx)
+	defer cancel()
+
+	var held []string
+	for _, platform := range models.PlatformIDs() {
+		tableName, err := getTransactionTableName(platform)
+		if err != nil {
+			return nil, err
+		}
+		held = append(held, fmt.Sprintf(
+			"EXISTS (SELECT 1 FROM %s t WHERE t.isin = a.isin AND t.deleted IS NOT TRUE)",
+			tableName,
+		))
+	}
+
+	query := fmt.Sprintf(`
+		SELECT a.isin, a.name, a.symbol, a.symbol_verified, a.type, a.currency, a.last_updated,
+			(%s) AS held
+		FROM assets a
+		WHERE LOWER(a.name) LIKE LOWER($1) ESCAPE '\'
+			OR LOWER(a.symbol) LIKE LOWER($1) ESCAPE '\'
+			OR LOWER(a.isin) LIKE LOWER($1) ESCAPE '\'
+		ORDER BY held DESC, a.name
+		LIMIT $2
+	`, strings.Join(held, " OR "))
+
+	var results []AssetSearchResult
+	if err := db.SelectContext(ctx, &results, query, likeContains(search), limit); err != nil {
+		return nil, fmt.Errorf("failed to search assets: %w", err)
+	}
+
+	return results, nil
+}
+
+// TradedAsset is an asset appearing in transactions, with the quantity still held
+type TradedAsset struct {
+	ISIN     string  `json:"isin" db:"isin"`
+	Name     string  `json:"name" db:"name"`
+	Quantity float64 `json:"quantity" db:"quantity"`
+	Held     bool    `json:"held" db:"-"`
+}
+
+// tradedQuantityTolerance absorbs rounding of fractional shares when deciding whether a position is closed
+const tradedQuantityTolerance = 1e-6
+
+// GetTradedAssets returns the distinct assets appearing in the non-deleted transactions of the
+// accounts of filter (all accounts when AccountIDs is empty), closed positions included, sorted
+// by name. Hidden transactions are skipped unless filter.IncludeHidden is set; the other filter
+// fields are ignored. The quantity held is the sum of buys minus sells, as for positions.
+func (db *DB) GetTradedAssets(ctx context.Context, filter TransactionFilter) ([]TradedAsset, error) {
+	ctx, cancel := db.withQueryTimeout(ctx)
+	defer cancel()
+
+	var args []interface{}
+	var selects []string
+	for _, platform := range models.PlatformIDs() {
+		tableName, err := getTransactionTableName(platform)
+		if err != nil {
+			return nil, err
+		}
+
+		query := fmt.Sprintf(`
+			SELECT isin, transaction_type, quantity FROM %s
+			WHERE isin IS NOT NULL AND isin != ''
+			  AND (subtitle IS NULL OR subtitle != 'Échec du plan d''épargne')
+		`, tableName)
+		query += filter.deletedCondition("deleted") + filter.hiddenCondition("hidden")
+
+		var condition string
+		condition, args = filter.accountIDsCondition("account_id", args)
+		selects = append(selects, query+condition)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT t.isin, COALESCE(a.name, 'Unknown') AS name,
+			SUM(CASE t.transaction_type
+				WHEN 'buy' THEN t.quantity
+				WHEN 'sell' THEN -t.quantity
+				ELSE 0
+			END) AS quantity
+		FROM (%s) t
+		LEFT JOIN assets a ON a.isin = t.isin
+		GROUP BY t.isin, a.name
+		ORDER BY name, t.isin
+	`, strings.Join(selects, " UNION ALL "))
+
+	var assets []TradedAsset
+	if err := db.SelectContext(ctx, &assets, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to get traded assets: %w", err)
+	}
+
+	for i := range assets {
+		assets[i].Held = assets[i].Quantity > tradedQuantityTolerance
+	}
+
+	return assets, nil
+}
+
 // GetAssetsByType retrieves all assets of a specific type
 func (db *DB) GetAssetsByType(assetType string) ([]models.Asset, error) {
 	var assets []models.Asset
@@ -141,6 +296,50 @@ func (db *DB) UpdateAsset(asset *models.Asset) error {
 	return nil
 }
 
+// GetAssetsWithUnknownName returns the assets still named models.UnknownAssetName
+func (db *DB) GetAssetsWithUnknownName(ctx context.Context) ([]models.Asset, error) {
+	ctx, cancel := db.withQueryTimeout(ctx)
+	defer cancel()
+
+	var assets []models.Asset
+	query := `
+		SELECT isin, name, symbol, symbol_verified, type, currency, last_updated
+		FROM assets
+		WHERE name = $1
+		ORDER BY isin
+	`
+	if err := db.SelectContext(ctx, &assets, query, models.UnknownAssetName); err != nil {
+		return nil, fmt.Errorf("failed to get assets with unknown name: %w", err)
+	}
+	return assets, nil
+}
+
+// SetUnknownAssetName names an asset still named models.UnknownAssetName. A real name is
+// never replaced: updated is false when the asset already has one.
+func (db *DB) SetUnknownAssetName(ctx context.Context, isin, name string) (updated bool, err error) {
+	name = strings.TrimSpace(name)
+	if name == "" || name == models.UnknownAssetName {
+		return false, fmt.Errorf("invalid asset name %q", name)
+	}
+
+	ctx, cancel := db.withQueryTimeout(ctx)
+	defer cancel()
+
+	result, err := db.ExecContext(ctx, `
+		UPDATE assets
+		SET name = $1, last_updated = NOW()
+		WHERE isin = $2 AND name = $3
+	`, name, isin, models.UnknownAssetName)
+	if err != nil {
+		return false, fmt.Errorf("failed to update asset name: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return rows > 0, nil
+}
+
 // DeleteAsset deletes an asset
 func (db *DB) DeleteAsset(isin string) error {
 	query := `DELETE FROM assets WHERE isin = $1`
@@ -162,21 +361,165 @@ func (db *DB) DeleteAsset(isin string) error {
 	return nil
 }
 
-// CreateAssetPrice creates a new asset price record
+// ErrMergeAssetNotFound is returned when an asset to merge does not exist
+var ErrMergeAssetNotFound = errors.New("asset to merge not found")
+
+// AssetMergeResult reports what was moved by MergeAssets
+type AssetMergeResult struct {
+	FromISIN          string `json:"from_isin"`
+	ToISIN            string `json:"to_isin"`
+	TransactionsMoved int64  `json:"transactions_moved"`
+	PricesMoved       int64  `json:"prices_moved"`
+	DuplicatePrices   int64  `json:"duplicate_prices_removed"`
+	AlertsMoved       int64  `json:"alerts_moved"`
+}
+
+// MergeAssets moves everything recorded under fromISIN to toISIN and deletes the source asset,
+// in a single transaction. Prices already known for the target at the same timestamp are kept
+// and the source duplicates are dropped. Both assets must exist.
+func (db *DB) MergeAssets(ctx context.Context, fromISIN, toISIN string) (*AssetMergeResult, error) {
+	var result *AssetMergeResult
+
+	err := db.InTransaction(ctx, func(tx *sql.Tx) error {
+		result = &AssetMergeResult{FromISIN: fromISIN, ToISIN: toISIN}
+
+		for _, isin := range []string{fromISIN, toISIN} {
+			var exists bool
+			if err := tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM assets WHERE isin = $1)`, isin).Scan(&exists); err != nil {
+				return fmt.Errorf("failed to check asset %s: %w", isin, err)
+			}
+			if !exists {
+				return fmt.Errorf("%w: %s", ErrMergeAssetNotFound, isin)
+			}
+		}
+
+		for _, platform := range models.PlatformIDs() {
+			tableName, err := getTransactionTableName(platform)
+			if err != nil {
+				return err
+			}
+			moved, err := execRowsAffected(ctx, tx, fmt.Sprintf(`UPDATE %s SET isin = $2 WHERE isin = $1`, tableName), fromISIN, toISIN)
+			if err != nil {
+				return fmt.Errorf("failed to move %s transactions: %w", platform, err)
+			}
+			result.TransactionsMoved += moved
+		}
+
+		moved, err := execRowsAffected(ctx, tx, `
+			UPDATE asset_prices SET isin = $2
+			WHERE isin = $1
+			AND NOT EXISTS (
+				SELECT 1 FROM asset_prices target
+				WHERE target.isin = $2 AND target.timestamp::date = asset_prices.timestamp::date
+			)
+		`, fromISIN, toISIN)
+		if err != nil {
+			return fmt.Errorf("failed to move prices: %w", err)
+		}
+		result.PricesMoved = moved
+
+		// What is left of the source prices duplicates a target price
+		removed, err := execRowsAffected(ctx, tx, `DELETE FROM asset_prices WHERE isin = $1`, fromISIN)
+		if err != nil {
+			return fmt.Errorf("failed to remove duplicate prices: %w", err)
+		}
+		result.DuplicatePrices = removed
+
+		alerts, err := execRowsAffected(ctx, tx, `UPDATE alerts SET isin = $2 WHERE isin = $1`, fromISIN, toISIN)
+		if err != nil {
+			return fmt.Errorf("failed to move alerts: %w", err)
+		}
+		result.AlertsMoved = alerts
+
+		if _, err := tx.ExecContext(ctx, `DELETE FROM assets WHERE isin = $1`, fromISIN); err != nil {
+			return fmt.Errorf("failed to delete merged asset: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// AssetSymbolUpdate is a Yahoo Finance symbol to set on an asset
+type AssetSymbolUpdate struct {
+	ISIN           string `json:"isin"`
+	Symbol         string `json:"symbol"`
+	SymbolVerified bool   `json:"symbol_verified"`
+}
+
+// AssetSymbolUpdateResult reports the outcome of one symbol update
+type AssetSymbolUpdateResult struct {
+	ISIN    string `json:"isin"`
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+}
+
+// UpdateAssetSymbols sets the symbols of several assets in a single transaction. An unknown
+// ISIN is reported as a failed item and does not prevent the other updates; a database error
+// rolls back every update.
+func (db *DB) UpdateAssetSymbols(ctx context.Context, updates []AssetSymbolUpdate) ([]AssetSymbolUpdateResult, error) {
+	var results []AssetSymbolUpdateResult
+
+	err := db.InTransaction(ctx, func(tx *sql.Tx) error {
+		results = make([]AssetSymbolUpdateResult, 0, len(updates))
+		for _, update := range updates {
+			updated, err := execRowsAffected(ctx, tx, `
+				UPDATE assets
+				SET symbol = $1, symbol_verified = $2, last_updated = NOW()
+				WHERE isin = $3
+			`, update.Symbol, update.SymbolVerified, update.ISIN)
+			if err != nil {
+				return fmt.Errorf("failed to update symbol of %s: %w", update.ISIN, err)
+			}
+
+			result := AssetSymbolUpdateResult{ISIN: update.ISIN, Success: updated > 0}
+			if !result.Success {
+				result.Error = "asset not found"
+			}
+			results = append(results, result)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// execRowsAffected runs a statement in tx and returns the number of rows it affected
+func execRowsAffected(ctx context.Context, tx *sql.Tx, query string, args ...interface{}) (int64, error) {
+	res, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// upsertAssetPriceQuery stores a price as the price of its trading day: asset_prices keeps
+// one row per asset and day, which the last stored price of the day replaces
+const upsertAssetPriceQuery = `
+	INSERT INTO asset_prices (isin, price, currency, timestamp)
+	VALUES ($1, $2, $3, $4)
+	ON CONFLICT (isin, (timestamp::date)) DO UPDATE
+	SET price = EXCLUDED.price,
+	    currency = EXCLUDED.currency,
+	    timestamp = EXCLUDED.timestamp
+`
+
+// CreateAssetPrice stores a price as the price of its day: the row already stored for the
+// asset that day is updated rather than a row added, so that intraday refreshes of the
+// current price do not pile up. Prices of other days are kept as history.
 func (db *DB) CreateAssetPrice(price *models.AssetPrice) error {
 	// Validate price
 	if err := price.Validate(); err != nil {
 		return fmt.Errorf("validation failed: %w", err)
 	}
 
-	query := `
-		INSERT INTO asset_prices (isin, price, currency, timestamp)
-		VALUES ($1, $2, $3, $4)
-		ON CONFLICT (isin, timestamp) DO UPDATE
-		SET price = EXCLUDED.price,
-		    currency = EXCLUDED.currency
-		RETURNING id
-	`
+	query := upsertAssetPriceQuery + ` RETURNING id`
 
 	err := db.Get(&price.ID, query, price.ISIN, price.Price, price.Currency, price.Timestamp)
 	if err != nil {
@@ -192,21 +535,14 @@ func (db *DB) CreateAssetPricesBatch(prices []models.AssetPrice) error {
 		return nil
 	}
 
-	tx, err := db.Begin()
-	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
-	}
-	defer tx.Rollback()
-
-	query := `
-		INSERT INTO asset_prices (isin, price, currency, timestamp)
-		VALUES ($1, $2, $3, $4)
-		ON CONFLICT (isin, timestamp) DO UPDATE
-		SET price = EXCLUDED.price,
-		    currency = EXCLUDED.currency
-	`
+	return db.InTransaction(context.Background(), func(tx *sql.Tx) error {
+		return insertAssetPricesBatch(tx, prices)
+	})
+}
 
-	stmt, err := tx.Prepare(query)
+// insertAssetPricesBatch inserts the batch within tx (run again when the transaction is retried)
+func insertAssetPricesBatch(tx *sql.Tx, prices []models.AssetPrice) error {
+	stmt, err := tx.Prepare(upsertAssetPriceQuery)
 	if err != nil {
 		return fmt.Errorf("failed to prepare statement: %w", err)
 	}
@@ -223,10 +559,6 @@ func (db *DB) CreateAssetPricesBatch(prices []models.AssetPrice) error {
 		}
 	}
 
-	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
-	}
-
 	return nil
 }
 
@@ -269,6 +601,28 @@ func (db *DB) GetAssetPriceHistory(isin string, startDate, endDate time.Time) ([
 	return prices, nil
 }
 
+// GetRecentAssetPrices retrieves the last limit stored prices of an asset, most recent first
+func (db *DB) GetRecentAssetPrices(ctx context.Context, isin string, limit int) ([]models.AssetPrice, error) {
+	ctx, cancel := db.withQueryTimeout(ctx)
+	defer cancel()
+
+	prices := []models.AssetPrice{}
+
+	query := `
+		SELECT id, isin, price, currency, timestamp
+		FROM asset_prices
+		WHERE isin = $1
+		ORDER BY timestamp DESC
+		LIMIT $2
+	`
+
+	if err := db.SelectContext(ctx, &prices, query, isin, limit); err != nil {
+		return nil, fmt.Errorf("failed to get recent prices: %w", err)
+	}
+
+	return prices, nil
+}
+
 // GetAssetPriceAt retrieves the price of an asset at or before a specific time
 func (db *DB) GetAssetPriceAt(isin string, timestamp time.Time) (*models.AssetPrice, error) {
 	var price models.AssetPrice
@@ -0,0 +1,170 @@
+package database
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+	"valhafin/internal/domain/models"
+)
+
+func TestGetRecentAssetPrices(t *testing.T) {
+	db := NewTestDB(t)
+
+	isin := "US0378331005"
+	if err := db.CreateAsset(&models.Asset{ISIN: isin, Name: "Apple Inc.", Type: "stock", Currency: "USD"}); err != nil {
+		t.Fatalf("Failed to create asset: %v", err)
+	}
+
+	prices, err := db.GetRecentAssetPrices(context.Background(), isin, 30)
+	if err != nil || prices == nil || len(prices) != 0 {
+		t.Fatalf("expected an empty list without stored price, got %v (%v)", prices, err)
+	}
+
+	start := time.Date(2024, 3, 1, 17, 30, 0, 0, time.UTC)
+	for day := 0; day < 5; day++ {
+		price := &models.AssetPrice{ISIN: isin, Price: 170 + float64(day), Currency: "USD", Timestamp: start.AddDate(0, 0, day)}
+		if err := db.CreateAssetPrice(price); err != nil {
+			t.Fatalf("Failed to store price: %v", err)
+		}
+	}
+
+	prices, err = db.GetRecentAssetPrices(context.Background(), isin, 3)
+	if err != nil {
+		t.Fatalf("Failed to get recent prices: %v", err)
+	}
+	if len(prices) != 3 || prices[0].Price != 174 || prices[2].Price != 172 {
+		t.Errorf("expected the 3 most recent prices in descending order, got %+v", prices)
+	}
+}
+
+func TestCreateAssetPrice_UpdatesPriceOfTheDay(t *testing.T) {
+	db := NewTestDB(t)
+
+	isin := "IE00B4L5Y983"
+	if err := db.CreateAsset(&models.Asset{ISIN: isin, Name: "iShares Core MSCI World", Type: "etf", Currency: "EUR"}); err != nil {
+		t.Fatalf("Failed to create asset: %v", err)
+	}
+
+	yesterday := time.Date(2024, 3, 14, 17, 30, 0, 0, time.UTC)
+	morning := time.Date(2024, 3, 15, 9, 5, 0, 0, time.UTC)
+	afternoon := time.Date(2024, 3, 15, 15, 40, 0, 0, time.UTC)
+	for _, price := range []*models.AssetPrice{
+		{ISIN: isin, Price: 99, Currency: "EUR", Timestamp: yesterday},
+		{ISIN: isin, Price: 100, Currency: "EUR", Timestamp: morning},
+		{ISIN: isin, Price: 101.5, Currency: "EUR", Timestamp: afternoon},
+	} {
+		if err := db.CreateAssetPrice(price); err != nil {
+			t.Fatalf("Failed to store price: %v", err)
+		}
+		if price.ID == 0 {
+			t.Errorf("expected the stored row ID to be set for the price at %s", price.Timestamp)
+		}
+	}
+
+	history, err := db.GetAssetPriceHistory(isin, yesterday.Add(-time.Hour), afternoon.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("Failed to get history: %v", err)
+	}
+	if len(history) != 2 {
+		t.Fatalf("expected one row for each day, got %+v", history)
+	}
+	if history[0].Price != 99 {
+		t.Errorf("expected the price of the previous day to be kept, got %v", history[0].Price)
+	}
+	if history[1].Price != 101.5 || !history[1].Timestamp.Equal(afternoon) {
+		t.Errorf("expected the second fetch of the day to update the row, got %v at %s", history[1].Price, history[1].Timestamp)
+	}
+}
+
+func TestCreateAssetPrice_OneRowPerTradingDay(t *testing.T) {
+	db := NewTestDB(t)
+
+	isin := "IE00B4L5Y983"
+	if err := db.CreateAsset(&models.Asset{ISIN: isin, Name: "iShares Core MSCI World", Type: "etf", Currency: "EUR"}); err != nil {
+		t.Fatalf("Failed to create asset: %v", err)
+	}
+
+	// A history bar is stored at midnight of its trading day, then refreshed concurrently
+	day := time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)
+	if err := db.CreateAssetPricesBatch([]models.AssetPrice{{ISIN: isin, Price: 100, Currency: "EUR", Timestamp: day}}); err != nil {
+		t.Fatalf("Failed to store history: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for i := 1; i <= 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs <- db.CreateAssetPrice(&models.AssetPrice{ISIN: isin, Price: 100 + float64(i), Currency: "EUR", Timestamp: day.Add(time.Duration(i) * time.Hour)})
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("Failed to store a concurrent price: %v", err)
+		}
+	}
+
+	history, err := db.GetAssetPriceHistory(isin, day, day.AddDate(0, 0, 1))
+	if err != nil {
+		t.Fatalf("Failed to get history: %v", err)
+	}
+	if len(history) != 1 {
+		t.Fatalf("expected a single row for the day, got %+v", history)
+	}
+
+	// A batch holding several prices of the same day keeps the last one
+	if err := db.CreateAssetPricesBatch([]models.AssetPrice{
+		{ISIN: isin, Price: 110, Currency: "EUR", Timestamp: day},
+		{ISIN: isin, Price: 111, Currency: "EUR", Timestamp: day.Add(17 * time.Hour)},
+	}); err != nil {
+		t.Fatalf("Failed to store batch: %v", err)
+	}
+	history, err = db.GetAssetPriceHistory(isin, day, day.AddDate(0, 0, 1))
+	if err != nil {
+		t.Fatalf("Failed to get history: %v", err)
+	}
+	if len(history) != 1 || history[0].Price != 111 {
+		t.Errorf("expected the last price of the batch to be the price of the day, got %+v", history)
+	}
+}
+
+func TestLikeContains(t *testing.T) {
+	tests := map[string]string{
+		"apple":   "%apple%",
+		"100%":    `%100\%%`,
+		"MSCI_W":  `%MSCI\_W%`,
+		`C:\data`: `%C:\\data%`,
+	}
+	for search, expected := range tests {
+		if got := likeContains(search); got != expected {
+			t.Errorf("likeContains(%q) = %q, expected %q", search, got, expected)
+		}
+	}
+}
+
+func TestSearchAssets_MatchesWildcardsLiterally(t *testing.T) {
+	db := NewTestDB(t)
+
+	for _, asset := range []*models.Asset{
+		{ISIN: "IE00B4L5Y983", Name: "iShares Core MSCI World", Type: "etf", Currency: "EUR"},
+		{ISIN: "FR0010315770", Name: "Lyxor MSCI_World 100% Acc", Type: "etf", Currency: "EUR"},
+	} {
+		if err := db.CreateAsset(asset); err != nil {
+			t.Fatalf("Failed to create asset: %v", err)
+		}
+	}
+
+	for search, expected := range map[string]int{"msci": 2, "_": 1, "%": 1, "msci_world": 1} {
+		results, err := db.SearchAssets(context.Background(), search, 10)
+		if err != nil {
+			t.Fatalf("SearchAssets(%q) error = %v", search, err)
+		}
+		if len(results) != expected {
+			t.Errorf("SearchAssets(%q): expected %d results, got %+v", search, expected, results)
+		}
+	}
+}
@@ -0,0 +1,336 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// ErrCircuitOpen is returned without contacting the database while the circuit breaker is open
+var ErrCircuitOpen = errors.New("database unavailable: circuit breaker is open")
+
+// RetryPolicy controls how reads and explicit transactions are retried on transient errors
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts (1 disables retries)
+	MaxAttempts int
+	// InitialBackoff is the wait before the first retry, doubled after each attempt
+	InitialBackoff time.Duration
+	// MaxBackoff caps the wait between attempts
+	MaxBackoff time.Duration
+}
+
+// DefaultRetryPolicy retries transient errors twice with a short backoff
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    3,
+	InitialBackoff: 100 * time.Millisecond,
+	MaxBackoff:     2 * time.Second,
+}
+
+// backoff returns the wait before the given retry (1 for the first retry)
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	wait := p.InitialBackoff
+	for i := 1; i < retry; i++ {
+		wait *= 2
+		if p.MaxBackoff > 0 && wait >= p.MaxBackoff {
+			return p.MaxBackoff
+		}
+	}
+	if p.MaxBackoff > 0 && wait > p.MaxBackoff {
+		return p.MaxBackoff
+	}
+	return wait
+}
+
+// IsRetryable reports whether err is transient and the operation may succeed if retried:
+// lost connections, serialization failures, deadlocks and server restarts.
+// Constraint violations, syntax errors, missing rows and cancellations are permanent.
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, sql.ErrNoRows) || errors.Is(err, ErrCircuitOpen) ||
+		errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code.Class() {
+		case "08": // connection_exception
+			return true
+		case "40": // transaction_rollback: serialization_failure, deadlock_detected
+			return true
+		case "53": // insufficient_resources, e.g. too_many_connections
+			return pqErr.Code == "53300"
+		case "57": // operator_intervention: admin_shutdown, crash_shutdown, cannot_connect_now
+			return pqErr.Code == "57P01" || pqErr.Code == "57P02" || pqErr.Code == "57P03"
+		}
+		return false
+	}
+
+	return isConnectionError(err)
+}
+
+// isConnectionError reports whether err means the database could not be reached
+func isConnectionError(err error) bool {
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.EPIPE) {
+		return true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Code.Class() == "08" || pqErr.Code == "57P01" || pqErr.Code == "57P03"
+	}
+
+	message := err.Error()
+	return strings.Contains(message, "connection reset") || strings.Contains(message, "connection refused") ||
+		strings.Contains(message, "broken pipe")
+}
+
+// Circuit breaker states
+const (
+	CircuitClosed   = "closed"
+	CircuitOpen     = "open"
+	CircuitHalfOpen = "half_open"
+)
+
+// CircuitBreaker fails fast while the database is unreachable.
+// It opens after Threshold consecutive connection failures and lets a single
+// trial request through once Cooldown has elapsed.
+type CircuitBreaker struct {
+	Threshold int
+	Cooldown  time.Duration
+
+	mu       sync.Mutex
+	failures int
+	openedAt time.Time
+	trial    bool
+	// now is overridden in tests
+	now func() time.Time
+}
+
+// NewCircuitBreaker creates a circuit breaker (threshold <= 0 disables it)
+func NewCircuitBreaker(threshold int, cooldown time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{Threshold: threshold, Cooldown: cooldown}
+}
+
+// clock returns the current time
+func (b *CircuitBreaker) clock() time.Time {
+	if b.now != nil {
+		return b.now()
+	}
+	return time.Now()
+}
+
+// Allow returns ErrCircuitOpen when requests must not reach the database
+func (b *CircuitBreaker) Allow() error {
+	if b == nil || b.Threshold <= 0 {
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.failures < b.Threshold {
+		return nil
+	}
+	if b.clock().Sub(b.openedAt) < b.Cooldown || b.trial {
+		return ErrCircuitOpen
+	}
+
+	// Half-open: let one request probe the database
+	b.trial = true
+	return nil
+}
+
+// Record updates the breaker with the outcome of a request.
+// Only connection failures count; other errors prove the database is reachable.
+func (b *CircuitBreaker) Record(err error) {
+	if b == nil || b.Threshold <= 0 || errors.Is(err, ErrCircuitOpen) {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.trial = false
+	if err == nil || !isConnectionError(err) {
+		if b.failures >= b.Threshold {
+			log.Printf("INFO: Database reachable again, closing circuit breaker")
+		}
+		b.failures = 0
+		return
+	}
+
+	b.failures++
+	if b.failures >= b.Threshold {
+		if b.failures == b.Threshold {
+			log.Printf("WARNING: Database unreachable after %d failures, opening circuit breaker for %s", b.failures, b.Cooldown)
+		}
+		b.openedAt = b.clock()
+	}
+}
+
+// State returns the breaker state: closed, open or half_open
+func (b *CircuitBreaker) State() string {
+	if b == nil || b.Threshold <= 0 {
+		return CircuitClosed
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch {
+	case b.failures < b.Threshold:
+		return CircuitClosed
+	case b.trial || b.clock().Sub(b.openedAt) >= b.Cooldown:
+		return CircuitHalfOpen
+	default:
+		return CircuitOpen
+	}
+}
+
+// SetRetryPolicy changes the retry policy applied to reads and explicit transactions
+func (db *DB) SetRetryPolicy(policy RetryPolicy) {
+	db.retryPolicy = policy
+}
+
+// SetCircuitBreaker installs the circuit breaker consulted before each query (nil disables it)
+func (db *DB) SetCircuitBreaker(breaker *CircuitBreaker) {
+	db.breaker = breaker
+}
+
+// CircuitState returns the state of the database circuit breaker
+func (db *DB) CircuitState() string {
+	return db.breaker.State()
+}
+
+// retry runs op through the circuit breaker, retrying transient errors per the retry policy.
+// It must only wrap idempotent operations: reads or whole transactions.
+func (db *DB) retry(ctx context.Context, op func() error) error {
+	attempts := db.retryPolicy.MaxAttempts
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if err = db.breaker.Allow(); err != nil {
+			return err
+		}
+
+		err = op()
+		db.breaker.Record(err)
+		if err == nil || !IsRetryable(err) || attempt == attempts {
+			return err
+		}
+
+		wait := db.retryPolicy.backoff(attempt)
+		log.Printf("WARNING: Transient database error (attempt %d/%d), retrying in %s: %v", attempt, attempts, wait, err)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(wait):
+		}
+	}
+	return err
+}
+
+// guard runs a non-idempotent operation once through the circuit breaker
+func (db *DB) guard(op func() error) error {
+	if err := db.breaker.Allow(); err != nil {
+		return err
+	}
+	err := op()
+	db.breaker.Record(err)
+	return err
+}
+
+// Get is like sqlx.DB.Get but retries transient errors
+func (db *DB) Get(dest interface{}, query string, args ...interface{}) error {
+	return db.retry(context.Background(), func() error {
+		return db.DB.Get(dest, query, args...)
+	})
+}
+
+// GetContext is like sqlx.DB.GetContext but retries transient errors
+func (db *DB) GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	return db.retry(ctx, func() error {
+		return db.DB.GetContext(ctx, dest, query, args...)
+	})
+}
+
+// Select is like sqlx.DB.Select but retries transient errors
+func (db *DB) Select(dest interface{}, query string, args ...interface{}) error {
+	return db.retry(context.Background(), func() error {
+		return db.DB.Select(dest, query, args...)
+	})
+}
+
+// SelectContext is like sqlx.DB.SelectContext but retries transient errors
+func (db *DB) SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	return db.retry(ctx, func() error {
+		return db.DB.SelectContext(ctx, dest, query, args...)
+	})
+}
+
+// Exec runs a statement through the circuit breaker. Writes are never retried
+// outside a transaction since they may not be idempotent; use InTransaction instead.
+func (db *DB) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return db.ExecContext(context.Background(), query, args...)
+}
+
+// ExecContext is like Exec but is canceled with ctx
+func (db *DB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	var result sql.Result
+	err := db.guard(func() error {
+		var err error
+		result, err = db.DB.ExecContext(ctx, query, args...)
+		return err
+	})
+	return result, err
+}
+
+// InTransaction runs fn in a database transaction and commits it. When the
+// transaction fails with a transient error (e.g. serialization failure or lost
+// connection) it is rolled back and fn is run again in a new transaction.
+func (db *DB) InTransaction(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	return db.retry(ctx, func() error {
+		tx, err := db.DB.BeginTx(ctx, nil)
+		if err != nil {
+			return fmt.Errorf("failed to begin transaction: %w", err)
+		}
+		defer tx.Rollback()
+
+		if err := fn(tx); err != nil {
+			return err
+		}
+
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("failed to commit transaction: %w", err)
+		}
+		return nil
+	})
+}
+
+// Ready reports whether the database can serve requests. It fails fast while
+// the circuit breaker is open and feeds the ping result back into the breaker.
+func (db *DB) Ready(ctx context.Context) error {
+	return db.guard(func() error {
+		return db.DB.PingContext(ctx)
+	})
+}
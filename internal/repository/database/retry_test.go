@@ -0,0 +1,138 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"no rows", sql.ErrNoRows, false},
+		{"canceled", context.Canceled, false},
+		{"serialization failure", &pq.Error{Code: "40001"}, true},
+		{"deadlock", &pq.Error{Code: "40P01"}, true},
+		{"connection failure", &pq.Error{Code: "08006"}, true},
+		{"admin shutdown", &pq.Error{Code: "57P01"}, true},
+		{"unique violation", &pq.Error{Code: "23505"}, false},
+		{"syntax error", &pq.Error{Code: "42601"}, false},
+		{"wrapped connection reset", fmt.Errorf("failed to get asset: %w", syscall.ECONNRESET), true},
+		{"circuit open", ErrCircuitOpen, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsRetryable(tt.err); got != tt.want {
+				t.Errorf("IsRetryable(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetry(t *testing.T) {
+	db := &DB{retryPolicy: RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}}
+
+	// Transient errors are retried until success
+	calls := 0
+	err := db.retry(context.Background(), func() error {
+		calls++
+		if calls < 3 {
+			return &pq.Error{Code: "40001"}
+		}
+		return nil
+	})
+	if err != nil || calls != 3 {
+		t.Errorf("expected success after 3 attempts, got err=%v calls=%d", err, calls)
+	}
+
+	// Permanent errors are returned immediately
+	calls = 0
+	err = db.retry(context.Background(), func() error {
+		calls++
+		return &pq.Error{Code: "23505"}
+	})
+	if err == nil || calls != 1 {
+		t.Errorf("expected a single attempt for a constraint violation, got err=%v calls=%d", err, calls)
+	}
+
+	// Attempts are bounded
+	calls = 0
+	err = db.retry(context.Background(), func() error {
+		calls++
+		return syscall.ECONNRESET
+	})
+	if !errors.Is(err, syscall.ECONNRESET) || calls != 3 {
+		t.Errorf("expected 3 attempts then the last error, got err=%v calls=%d", err, calls)
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	breaker := NewCircuitBreaker(2, 30*time.Second)
+	breaker.now = func() time.Time { return now }
+
+	// Non-connection errors do not open the breaker
+	breaker.Record(&pq.Error{Code: "23505"})
+	breaker.Record(&pq.Error{Code: "23505"})
+	if breaker.State() != CircuitClosed {
+		t.Fatalf("expected closed breaker, got %s", breaker.State())
+	}
+
+	breaker.Record(syscall.ECONNREFUSED)
+	breaker.Record(syscall.ECONNREFUSED)
+	if breaker.State() != CircuitOpen {
+		t.Fatalf("expected open breaker after 2 connection failures, got %s", breaker.State())
+	}
+	if err := breaker.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("expected ErrCircuitOpen while open, got %v", err)
+	}
+
+	// After the cooldown a single trial request goes through
+	now = now.Add(31 * time.Second)
+	if err := breaker.Allow(); err != nil {
+		t.Fatalf("expected a trial request after cooldown, got %v", err)
+	}
+	if err := breaker.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("expected other requests to fail fast during the trial, got %v", err)
+	}
+
+	// A successful trial closes the breaker
+	breaker.Record(nil)
+	if breaker.State() != CircuitClosed {
+		t.Errorf("expected closed breaker after a successful trial, got %s", breaker.State())
+	}
+}
+
+func TestRetryFailsFastWhenCircuitOpen(t *testing.T) {
+	breaker := NewCircuitBreaker(1, time.Minute)
+	db := &DB{retryPolicy: RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}, breaker: breaker}
+
+	calls := 0
+	op := func() error {
+		calls++
+		return syscall.ECONNREFUSED
+	}
+
+	db.retry(context.Background(), op)
+	if calls != 1 {
+		t.Errorf("expected the breaker to stop retries once open, got %d calls", calls)
+	}
+
+	if err := db.retry(context.Background(), op); !errors.Is(err, ErrCircuitOpen) || calls != 1 {
+		t.Errorf("expected ErrCircuitOpen without calling the database, got err=%v calls=%d", err, calls)
+	}
+	if db.CircuitState() != CircuitOpen {
+		t.Errorf("expected open circuit state, got %s", db.CircuitState())
+	}
+}
@@ -0,0 +1,25 @@
+package database
+
+import (
+	"errors"
+	"testing"
+)
+
+// NewTestDB returns a database isolated in a schema dropped when the test ends.
+// It mirrors testutil.NewTestDB, which cannot be imported from this package.
+// The test is skipped when the database is not available.
+func NewTestDB(t testing.TB) *DB {
+	t.Helper()
+
+	db, drop, err := OpenTestSchema()
+	if errors.Is(err, ErrTestDatabaseUnavailable) {
+		t.Skipf("Skipping test: %v", err)
+		return nil
+	}
+	if err != nil {
+		t.Fatalf("Failed to open test database: %v", err)
+	}
+	t.Cleanup(drop)
+
+	return db
+}
@@ -0,0 +1,94 @@
+package database
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+)
+
+// TestDatabaseURLEnv overrides the connection URL of the integration test database
+const TestDatabaseURLEnv = "TEST_DATABASE_URL"
+
+// ErrTestDatabaseUnavailable is returned by OpenTestSchema when the test database cannot be reached
+var ErrTestDatabaseUnavailable = errors.New("test database not available")
+
+// TestConfig returns the configuration of the integration test database:
+// TEST_DATABASE_URL when set, the local valhafin_test database otherwise
+func TestConfig() (Config, error) {
+	if rawURL := os.Getenv(TestDatabaseURLEnv); rawURL != "" {
+		return ParseURL(rawURL)
+	}
+	return Config{
+		Host:     "localhost",
+		Port:     DefaultPort,
+		User:     "valhafin",
+		Password: "valhafin",
+		DBName:   "valhafin_test",
+		SSLMode:  DefaultSSLMode,
+	}, nil
+}
+
+// OpenTestSchema connects to the test database in a schema of its own and runs the
+// migrations in it. drop closes the connection and drops the schema with everything in it,
+// so that every test starts from an empty database and leaves nothing behind.
+// Tests use it through NewTestDB (see internal/testutil).
+func OpenTestSchema() (db *DB, drop func(), err error) {
+	cfg, err := TestConfig()
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid %s: %w", TestDatabaseURLEnv, err)
+	}
+
+	admin, err := Connect(cfg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrTestDatabaseUnavailable, err)
+	}
+
+	suffix := make([]byte, 8)
+	if _, err := rand.Read(suffix); err != nil {
+		admin.Close()
+		return nil, nil, fmt.Errorf("failed to generate test schema name: %w", err)
+	}
+	schema := "test_" + hex.EncodeToString(suffix)
+
+	if _, err := admin.Exec(fmt.Sprintf("CREATE SCHEMA %s", schema)); err != nil {
+		admin.Close()
+		return nil, nil, fmt.Errorf("failed to create test schema: %w", err)
+	}
+
+	// Extensions and built-in functions stay reachable through public
+	params := make(map[string]string, len(cfg.Params)+1)
+	for key, value := range cfg.Params {
+		params[key] = value
+	}
+	params["search_path"] = schema + ",public"
+	cfg.Params = params
+
+	db, err = Connect(cfg)
+	if err != nil {
+		dropTestSchema(admin, schema)
+		return nil, nil, fmt.Errorf("failed to connect to test schema: %w", err)
+	}
+
+	drop = func() {
+		db.Close()
+		dropTestSchema(admin, schema)
+	}
+
+	if err := db.RunMigrations(); err != nil {
+		drop()
+		return nil, nil, fmt.Errorf("failed to run migrations: %w", err)
+	}
+
+	return db, drop, nil
+}
+
+// dropTestSchema drops a test schema with everything in it and closes the admin connection
+func dropTestSchema(admin *DB, schema string) {
+	if _, err := admin.Exec(fmt.Sprintf("DROP SCHEMA IF EXISTS %s CASCADE", schema)); err != nil {
+		log.Printf("Warning: failed to drop test schema %s: %v", schema, err)
+	}
+	admin.Close()
+}
@@ -1,20 +1,131 @@
 package database
 
 import (
+	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
 	"valhafin/internal/domain/models"
 )
 
 // TransactionFilter holds filter parameters for querying transactions
 type TransactionFilter struct {
-	AccountID       string
+	AccountID string
+	// AccountIDs restricts the rows to a set of accounts (ignored when empty)
+	AccountIDs      []string
 	StartDate       string
 	EndDate         string
 	ISIN            string
 	TransactionType string
-	Page            int
-	Limit           int
+	// Tag restricts the rows to the transactions carrying this tag (ignored when empty)
+	Tag   string
+	Page  int
+	Limit int
+	// IncludeDeleted returns transactions flagged as deleted, which are excluded by default
+	IncludeDeleted bool
+	// IncludeHidden returns transactions flagged as hidden, which are excluded by default
+	IncludeHidden bool
+}
+
+// deletedCondition returns the SQL condition excluding deleted transactions unless the filter asks for them.
+// column is the (optionally table-qualified) deleted column.
+func (f TransactionFilter) deletedCondition(column string) string {
+	if f.IncludeDeleted {
+		return ""
+	}
+	return fmt.Sprintf(" AND %s IS NOT TRUE", column)
+}
+
+// hiddenCondition returns the SQL condition excluding hidden transactions unless the filter asks for them.
+// column is the (optionally table-qualified) hidden column.
+func (f TransactionFilter) hiddenCondition(column string) string {
+	if f.IncludeHidden {
+		return ""
+	}
+	return fmt.Sprintf(" AND %s IS NOT TRUE", column)
+}
+
+// accountIDsCondition returns the SQL condition restricting rows to AccountIDs, with
+// placeholders numbered after args, and the arguments extended with the account IDs
+func (f TransactionFilter) accountIDsCondition(column string, args []interface{}) (string, []interface{}) {
+	if len(f.AccountIDs) == 0 {
+		return "", args
+	}
+
+	placeholders := make([]string, len(f.AccountIDs))
+	for i, accountID := range f.AccountIDs {
+		args = append(args, accountID)
+		placeholders[i] = fmt.Sprintf("$%d", len(args))
+	}
+	return fmt.Sprintf(" AND %s IN (%s)", column, strings.Join(placeholders, ", ")), args
+}
+
+// listWhereClause builds the WHERE clause shared by the sorted listing queries and CountTransactions,
+// so that counts always match the rows that can be fetched. Columns use the t alias and the
+// query must LEFT JOIN assets a for the asset name search.
+func (f TransactionFilter) listWhereClause() (string, []interface{}, error) {
+	startDate, endDate, err := f.DateBounds()
+	if err != nil {
+		return "", nil, err
+	}
+
+	where := "WHERE (t.subtitle IS NULL OR t.subtitle != 'Échec du plan d''épargne')" + f.deletedCondition("t.deleted") + f.hiddenCondition("t.hidden")
+	args := []interface{}{}
+
+	if f.AccountID != "" {
+		args = append(args, f.AccountID)
+		where += fmt.Sprintf(" AND t.account_id = $%d", len(args))
+	}
+
+	condition, args := f.accountIDsCondition("t.account_id", args)
+	where += condition
+
+	if startDate != "" {
+		args = append(args, startDate)
+		where += fmt.Sprintf(" AND t.timestamp::timestamptz >= $%d::timestamptz", len(args))
+	}
+
+	if endDate != "" {
+		args = append(args, endDate)
+		where += fmt.Sprintf(" AND t.timestamp::timestamptz <= $%d::timestamptz", len(args))
+	}
+
+	if f.ISIN != "" {
+		args = append(args, "%"+f.ISIN+"%")
+		// Search by ISIN (case-insensitive partial match) OR asset name (case-insensitive partial match)
+		where += fmt.Sprintf(" AND (LOWER(t.isin) LIKE LOWER($%[1]d) OR LOWER(a.name) LIKE LOWER($%[1]d))", len(args))
+	}
+
+	if f.TransactionType != "" {
+		args = append(args, f.TransactionType)
+		where += fmt.Sprintf(" AND t.transaction_type = $%d", len(args))
+	}
+
+	if f.Tag != "" {
+		args = append(args, f.Tag)
+		where += fmt.Sprintf(" AND $%d = ANY(t.tags)", len(args))
+	}
+
+	return where, args, nil
+}
+
+// listOrderClause returns the ORDER BY clause of the sorted listing queries
+func listOrderClause(sortBy, sortOrder string) string {
+	direction := "DESC"
+	if sortOrder == "asc" {
+		direction = "ASC"
+	}
+
+	switch sortBy {
+	case "amount":
+		return " ORDER BY t.amount_value " + direction + ", t.id"
+	case "timestamp":
+		return " ORDER BY t.timestamp " + direction + ", t.id"
+	default:
+		return " ORDER BY t.timestamp DESC, t.id"
+	}
 }
 
 // CreateTransaction creates a new transaction in the appropriate platform table
@@ -24,49 +135,33 @@ func (db *DB) CreateTransaction(transaction *models.Transaction, platform string
 		return fmt.Errorf("validation failed: %w", err)
 	}
 
-	// Ensure the asset exists if ISIN is provided
-	// Convert empty ISIN to NULL for database
-	var isinValue interface{}
-	if transaction.ISIN != nil && *transaction.ISIN != "" {
-		isinValue = *transaction.ISIN
+	// Reject unknown platforms before anything is written
+	tableName, err := getTransactionTableName(platform)
+	if err != nil {
+		return err
+	}
 
-		// Extract symbol and name from metadata if available
-		var symbol *string
-		var assetName string = "Unknown"
-		if transaction.Metadata != nil && *transaction.Metadata != "" {
-			var metadata map[string]interface{}
-			if err := json.Unmarshal([]byte(*transaction.Metadata), &metadata); err == nil {
-				if symbolStr, ok := metadata["symbol"].(string); ok && symbolStr != "" {
-					symbol = &symbolStr
-				}
-				if nameStr, ok := metadata["name"].(string); ok && nameStr != "" {
-					assetName = nameStr
-				}
-			}
-		}
+	// Timeline transactions may only carry the number of shares in their details
+	transaction.InferQuantity()
 
-		// Fallback to transaction title if name not in metadata
-		if assetName == "Unknown" && transaction.Title != "" {
-			assetName = transaction.Title
-		}
+	// Malformed metadata is wrapped so that symbol resolution can still read it
+	transaction.NormalizeMetadata()
 
-		// Create asset if it doesn't exist, or update symbol and name if provided
-		_, err := db.Exec(`
-			INSERT INTO assets (isin, name, symbol, type, currency)
-			VALUES ($1, $2, $3, $4, $5)
-			ON CONFLICT (isin) DO UPDATE
-			SET symbol = COALESCE(EXCLUDED.symbol, assets.symbol),
-			    name = CASE WHEN assets.name = 'Unknown' THEN EXCLUDED.name ELSE assets.name END
-		`, *transaction.ISIN, assetName, symbol, "stock", "EUR")
-		if err != nil {
-			return fmt.Errorf("failed to create asset for ISIN %s: %w", *transaction.ISIN, err)
+	// Amounts are stored at the precision of their currency
+	transaction.NormalizeAmount()
+	transaction.NormalizeAnnotations()
+
+	// Ensure the asset exists if ISIN is provided, the same way as for a batch
+	// Convert empty ISIN to NULL for database
+	var isinValue interface{}
+	assetType, symbolKeyed := platformAssetType(platform)
+	if info, ok := transactionAsset(transaction, symbolKeyed); ok {
+		isinValue = info.isin
+		if err := upsertTransactionAsset(db, info, assetType); err != nil {
+			return err
 		}
-	} else {
-		isinValue = nil
 	}
 
-	tableName := getTransactionTableName(platform)
-
 	// Handle metadata - convert empty string to NULL for JSONB
 	var metadata *string
 	if transaction.Metadata != nil && *transaction.Metadata != "" {
@@ -79,10 +174,10 @@ func (db *DB) CreateTransaction(transaction *models.Transaction, platform string
 			amount_currency, amount_value, amount_fraction, status,
 			action_type, action_payload, cash_account_number, hidden, deleted,
 			actions, dividend_per_share, taxes, total, shares, share_price,
-			fees, amount, isin, quantity, transaction_type, metadata
+			fees, amount, isin, quantity, transaction_type, metadata, tags, note
 		) VALUES (
 			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16,
-			$17, $18, $19, $20, $21, $22, $23, $24, $25, $26, $27, $28
+			$17, $18, $19, $20, $21, $22, $23, $24, $25, $26, $27, $28, $29, $30
 		)
 		ON CONFLICT (id) DO UPDATE SET
 			shares = EXCLUDED.shares,
@@ -91,7 +186,7 @@ func (db *DB) CreateTransaction(transaction *models.Transaction, platform string
 			fees = EXCLUDED.fees
 	`, tableName)
 
-	_, err := db.Exec(
+	_, err = db.Exec(
 		query,
 		transaction.ID,
 		transaction.AccountID,
@@ -121,6 +216,8 @@ func (db *DB) CreateTransaction(transaction *models.Transaction, platform string
 		transaction.Quantity,
 		transaction.TransactionType,
 		metadata,
+		transaction.Tags,
+		transaction.Note,
 	)
 
 	if err != nil {
@@ -132,75 +229,213 @@ func (db *DB) CreateTransaction(transaction *models.Transaction, platform string
 
 // CreateTransactionsBatch creates multiple transactions in a single transaction
 func (db *DB) CreateTransactionsBatch(transactions []models.Transaction, platform string) error {
+	_, err := db.UpsertTransactionsBatch(transactions, platform)
+	return err
+}
+
+// UpsertTransactionsBatch creates or updates multiple transactions in a single transaction and
+// reports which ones were inserted, updated, or already stored with the same values (those are
+// not written again)
+func (db *DB) UpsertTransactionsBatch(transactions []models.Transaction, platform string) (*models.TransactionChanges, error) {
 	if len(transactions) == 0 {
-		return nil
+		return &models.TransactionChanges{}, nil
+	}
+	if err := ValidateTransactionPlatform(platform); err != nil {
+		return nil, err
 	}
 
-	tx, err := db.Begin()
+	var changes *models.TransactionChanges
+	err := db.InTransaction(context.Background(), func(tx *sql.Tx) error {
+		// Reset on every attempt: a retried transaction starts over
+		changes = &models.TransactionChanges{}
+		return insertTransactionsBatch(tx, transactions, platform, changes)
+	})
 	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
+		return nil, err
 	}
-	defer tx.Rollback()
+	return changes, nil
+}
 
-	// First, ensure all ISINs exist in the assets table
-	// Also extract symbols and names from transaction metadata
-	type assetInfo struct {
-		isin   string
-		name   string
-		symbol *string
+// UpsertTransactionsBatchPartial is like UpsertTransactionsBatch, but writes each transaction
+// within a savepoint: a transaction that fails validation or cannot be written is reported in
+// the returned failures and the others are committed. The error is only set when the batch as
+// a whole could not be written.
+func (db *DB) UpsertTransactionsBatchPartial(transactions []models.Transaction, platform string) (*models.TransactionChanges, []models.TransactionFailure, error) {
+	if len(transactions) == 0 {
+		return &models.TransactionChanges{}, []models.TransactionFailure{}, nil
+	}
+	if err := ValidateTransactionPlatform(platform); err != nil {
+		return nil, nil, err
 	}
-	assetsToCreate := make(map[string]assetInfo)
 
-	for _, transaction := range transactions {
-		if transaction.ISIN != nil && *transaction.ISIN != "" {
-			isin := *transaction.ISIN
-
-			// Extract symbol and name from metadata if available
-			var symbol *string
-			var assetName string = "Unknown"
-			if transaction.Metadata != nil && *transaction.Metadata != "" {
-				// Parse metadata JSON to extract symbol and name
-				var metadata map[string]interface{}
-				if err := json.Unmarshal([]byte(*transaction.Metadata), &metadata); err == nil {
-					if symbolStr, ok := metadata["symbol"].(string); ok && symbolStr != "" {
-						symbol = &symbolStr
-					}
-					if nameStr, ok := metadata["name"].(string); ok && nameStr != "" {
-						assetName = nameStr
-					}
-				}
-			}
+	var changes *models.TransactionChanges
+	var failures []models.TransactionFailure
+	err := db.InTransaction(context.Background(), func(tx *sql.Tx) error {
+		// Reset on every attempt: a retried transaction starts over
+		changes = &models.TransactionChanges{}
+		failures = []models.TransactionFailure{}
+		return writeTransactionsBatch(tx, transactions, platform, changes, &failures)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return changes, failures, nil
+}
 
-			// Fallback to transaction title if name not in metadata
-			if assetName == "Unknown" && transaction.Title != "" {
-				assetName = transaction.Title
-			}
+// insertTransactionsBatch inserts the batch within tx (run again when the transaction is retried)
+// and records the status of each transaction in changes
+func insertTransactionsBatch(tx *sql.Tx, transactions []models.Transaction, platform string, changes *models.TransactionChanges) error {
+	return writeTransactionsBatch(tx, transactions, platform, changes, nil)
+}
 
+// batchSavepoint is the savepoint isolating each write of a partial batch
+const batchSavepoint = "transaction_batch_row"
+
+// withSavepoint runs write within a savepoint of tx. When write fails, the savepoint is rolled
+// back so that tx can go on, and the failure is returned as rowErr. err is set when tx itself
+// can no longer be used, or when the failure is transient and the whole transaction should be
+// retried.
+func withSavepoint(tx *sql.Tx, write func() error) (rowErr error, err error) {
+	if _, err := tx.Exec("SAVEPOINT " + batchSavepoint); err != nil {
+		return nil, fmt.Errorf("failed to create savepoint: %w", err)
+	}
+	if rowErr := write(); rowErr != nil {
+		if IsRetryable(rowErr) {
+			return nil, rowErr
+		}
+		if _, err := tx.Exec("ROLLBACK TO SAVEPOINT " + batchSavepoint); err != nil {
+			return nil, fmt.Errorf("failed to roll back savepoint: %w", err)
+		}
+		return rowErr, nil
+	}
+	if _, err := tx.Exec("RELEASE SAVEPOINT " + batchSavepoint); err != nil {
+		return nil, fmt.Errorf("failed to release savepoint: %w", err)
+	}
+	return nil, nil
+}
+
+// transactionAssetInfo is the asset a transaction refers to, as created on import
+type transactionAssetInfo struct {
+	isin     string
+	name     string
+	symbol   *string
+	currency string
+}
+
+// execer is implemented by *DB and *sql.Tx
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// platformAssetType returns the type of the assets created for the transactions of platform,
+// and whether the platform carries a ticker in place of the ISIN (e.g. Binance)
+func platformAssetType(platform string) (assetType string, symbolKeyed bool) {
+	if p, ok := models.GetPlatform(platform); ok {
+		return p.AssetType, p.AssetKey == models.AssetKeySymbol
+	}
+	return "stock", false
+}
+
+// transactionAsset returns the asset of transaction, with the symbol and name found in its
+// metadata; ok is false when the transaction has no asset
+func transactionAsset(transaction *models.Transaction, symbolKeyed bool) (info transactionAssetInfo, ok bool) {
+	if transaction.ISIN == nil || *transaction.ISIN == "" {
+		return transactionAssetInfo{}, false
+	}
+	isin := *transaction.ISIN
+
+	// Extract symbol and name from metadata if available
+	var symbol *string
+	var assetName string = models.UnknownAssetName
+	metadata := transaction.ParsedMetadata()
+	if metadata.Symbol != "" {
+		symbol = &metadata.Symbol
+	} else if symbolKeyed {
+		symbol = &isin
+	}
+	if metadata.Name != "" {
+		assetName = metadata.Name
+	}
+
+	// Fallback to transaction title if name not in metadata
+	if assetName == models.UnknownAssetName && transaction.Title != "" {
+		assetName = transaction.Title
+	}
+
+	return transactionAssetInfo{isin: isin, name: assetName, symbol: symbol, currency: assetCurrency(transaction)}, true
+}
+
+// upsertTransactionAsset creates the asset, or updates its symbol and name if it already exists.
+// symbol_verified is reset when a symbol is provided so that resolveAssetSymbols processes it.
+func upsertTransactionAsset(exec execer, info transactionAssetInfo, assetType string) error {
+	_, err := exec.Exec(`
+		INSERT INTO assets (isin, name, symbol, type, currency, symbol_verified)
+		VALUES ($1, $2, $3, $4, $5, false)
+		ON CONFLICT (isin) DO UPDATE
+		SET symbol = COALESCE(EXCLUDED.symbol, assets.symbol),
+		    name = CASE WHEN assets.name = 'Unknown' THEN EXCLUDED.name ELSE assets.name END,
+		    symbol_verified = CASE WHEN EXCLUDED.symbol IS NOT NULL THEN false ELSE assets.symbol_verified END
+	`, info.isin, info.name, info.symbol, assetType, info.currency)
+	if err != nil {
+		return fmt.Errorf("failed to create asset for ISIN %s: %w", info.isin, err)
+	}
+	return nil
+}
+
+// writeTransactionsBatch writes the batch within tx. When failures is nil, the first failure
+// aborts the batch; otherwise every asset and transaction is written within a savepoint and
+// the transactions that could not be written are appended to failures. The transactions are
+// normalized on a copy: the caller's slice is left untouched.
+func writeTransactionsBatch(tx *sql.Tx, transactions []models.Transaction, platform string, changes *models.TransactionChanges, failures *[]models.TransactionFailure) error {
+	transactions = append([]models.Transaction(nil), transactions...)
+
+	// First, ensure all ISINs exist in the assets table
+	// Also extract symbols and names from transaction metadata
+	assetsToCreate := make(map[string]transactionAssetInfo)
+	assetType, symbolKeyed := platformAssetType(platform)
+
+	for i := range transactions {
+		// Malformed metadata is wrapped so that symbol resolution can still read it
+		transactions[i].NormalizeMetadata()
+		// Amounts are stored at the precision of their currency
+		transactions[i].NormalizeAmount()
+		transactions[i].NormalizeAnnotations()
+
+		if info, ok := transactionAsset(&transactions[i], symbolKeyed); ok {
 			// Store asset info (symbol and name will be updated if found in later transactions)
-			if existing, exists := assetsToCreate[isin]; !exists || (symbol != nil && existing.symbol == nil) {
-				assetsToCreate[isin] = assetInfo{isin: isin, name: assetName, symbol: symbol}
+			if existing, exists := assetsToCreate[info.isin]; !exists || (info.symbol != nil && existing.symbol == nil) {
+				assetsToCreate[info.isin] = info
 			}
 		}
 	}
 
 	// Create assets for ISINs that don't exist yet
+	failedAssets := make(map[string]error)
 	for _, info := range assetsToCreate {
-		// Try to insert the asset, or update symbol and name if it already exists
-		// Set symbol_verified to false so that resolveAssetSymbols can process it
-		_, err := tx.Exec(`
-			INSERT INTO assets (isin, name, symbol, type, currency, symbol_verified)
-			VALUES ($1, $2, $3, $4, $5, false)
-			ON CONFLICT (isin) DO UPDATE
-			SET symbol = COALESCE(EXCLUDED.symbol, assets.symbol),
-			    name = CASE WHEN assets.name = 'Unknown' THEN EXCLUDED.name ELSE assets.name END,
-			    symbol_verified = CASE WHEN EXCLUDED.symbol IS NOT NULL THEN false ELSE assets.symbol_verified END
-		`, info.isin, info.name, info.symbol, "stock", "EUR")
+		createAsset := func() error {
+			return upsertTransactionAsset(tx, info, assetType)
+		}
+
+		if failures == nil {
+			if err := createAsset(); err != nil {
+				return err
+			}
+			continue
+		}
+		// The transactions of an asset that cannot be created fail with it
+		assetErr, err := withSavepoint(tx, createAsset)
 		if err != nil {
-			return fmt.Errorf("failed to create asset for ISIN %s: %w", info.isin, err)
+			return err
+		}
+		if assetErr != nil {
+			failedAssets[info.isin] = assetErr
 		}
 	}
 
-	tableName := getTransactionTableName(platform)
+	tableName, err := getTransactionTableName(platform)
+	if err != nil {
+		return err
+	}
 
 	query := fmt.Sprintf(`
 		INSERT INTO %s (
@@ -208,16 +443,22 @@ func (db *DB) CreateTransactionsBatch(transactions []models.Transaction, platfor
 			amount_currency, amount_value, amount_fraction, status,
 			action_type, action_payload, cash_account_number, hidden, deleted,
 			actions, dividend_per_share, taxes, total, shares, share_price,
-			fees, amount, isin, quantity, transaction_type, metadata
+			fees, amount, isin, quantity, transaction_type, metadata, tags, note
 		) VALUES (
 			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16,
-			$17, $18, $19, $20, $21, $22, $23, $24, $25, $26, $27, $28
+			$17, $18, $19, $20, $21, $22, $23, $24, $25, $26, $27, $28, $29, $30
 		)
 		ON CONFLICT (id) DO UPDATE SET
 			shares = EXCLUDED.shares,
 			share_price = EXCLUDED.share_price,
 			quantity = EXCLUDED.quantity,
-			fees = EXCLUDED.fees
+			fees = EXCLUDED.fees,
+			-- A transaction stored without ISIN takes the one found by a later sync
+			isin = COALESCE(%[1]s.isin, EXCLUDED.isin)
+		WHERE (%[1]s.shares, %[1]s.share_price, %[1]s.quantity, %[1]s.fees, %[1]s.isin)
+			IS DISTINCT FROM (EXCLUDED.shares, EXCLUDED.share_price, EXCLUDED.quantity, EXCLUDED.fees,
+				COALESCE(%[1]s.isin, EXCLUDED.isin))
+		RETURNING (xmax = 0) AS inserted
 	`, tableName)
 
 	stmt, err := tx.Prepare(query)
@@ -228,8 +469,20 @@ func (db *DB) CreateTransactionsBatch(transactions []models.Transaction, platfor
 
 	for _, transaction := range transactions {
 		if err := transaction.Validate(); err != nil {
-			return fmt.Errorf("validation failed for transaction %s: %w", transaction.ID, err)
+			err = fmt.Errorf("validation failed for transaction %s: %w", transaction.ID, err)
+			if failures == nil {
+				return err
+			}
+			*failures = append(*failures, models.TransactionFailure{ID: transaction.ID, Error: err.Error()})
+			continue
 		}
+		if failures != nil && transaction.ISIN != nil {
+			if assetErr, failed := failedAssets[*transaction.ISIN]; failed {
+				*failures = append(*failures, models.TransactionFailure{ID: transaction.ID, Error: assetErr.Error()})
+				continue
+			}
+		}
+		transaction.InferQuantity()
 
 		// Handle metadata - convert empty string to NULL for JSONB
 		var metadata *string
@@ -245,44 +498,73 @@ func (db *DB) CreateTransactionsBatch(transactions []models.Transaction, platfor
 			isinValue = nil
 		}
 
-		_, err := stmt.Exec(
-			transaction.ID,
-			transaction.AccountID,
-			transaction.Timestamp,
-			transaction.Title,
-			transaction.Icon,
-			transaction.Avatar,
-			transaction.Subtitle,
-			transaction.AmountCurrency,
-			transaction.AmountValue,
-			transaction.AmountFraction,
-			transaction.Status,
-			transaction.ActionType,
-			transaction.ActionPayload,
-			transaction.CashAccountNumber,
-			transaction.Hidden,
-			transaction.Deleted,
-			transaction.Actions,
-			transaction.DividendPerShare,
-			transaction.Taxes,
-			transaction.Total,
-			transaction.Shares,
-			transaction.SharePrice,
-			transaction.Fees,
-			transaction.Amount,
-			isinValue, // Use isinValue instead of transaction.ISIN
-			transaction.Quantity,
-			transaction.TransactionType,
-			metadata,
-		)
+		// xmax is only set on rows updated by the conflict clause; no row is returned when
+		// the stored values are the same
+		var inserted bool
+		var unchanged bool
+		upsert := func() error {
+			err := stmt.QueryRow(
+				transaction.ID,
+				transaction.AccountID,
+				transaction.Timestamp,
+				transaction.Title,
+				transaction.Icon,
+				transaction.Avatar,
+				transaction.Subtitle,
+				transaction.AmountCurrency,
+				transaction.AmountValue,
+				transaction.AmountFraction,
+				transaction.Status,
+				transaction.ActionType,
+				transaction.ActionPayload,
+				transaction.CashAccountNumber,
+				transaction.Hidden,
+				transaction.Deleted,
+				transaction.Actions,
+				transaction.DividendPerShare,
+				transaction.Taxes,
+				transaction.Total,
+				transaction.Shares,
+				transaction.SharePrice,
+				transaction.Fees,
+				transaction.Amount,
+				isinValue, // Use isinValue instead of transaction.ISIN
+				transaction.Quantity,
+				transaction.TransactionType,
+				metadata,
+				transaction.Tags,
+				transaction.Note,
+			).Scan(&inserted)
+			unchanged = err == sql.ErrNoRows
+			if err != nil && !unchanged {
+				return fmt.Errorf("failed to insert transaction %s: %w", transaction.ID, err)
+			}
+			return nil
+		}
 
-		if err != nil {
-			return fmt.Errorf("failed to insert transaction %s: %w", transaction.ID, err)
+		if failures == nil {
+			if err := upsert(); err != nil {
+				return err
+			}
+		} else {
+			rowErr, err := withSavepoint(tx, upsert)
+			if err != nil {
+				return err
+			}
+			if rowErr != nil {
+				*failures = append(*failures, models.TransactionFailure{ID: transaction.ID, Error: rowErr.Error()})
+				continue
+			}
 		}
-	}
 
-	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
+		switch {
+		case unchanged:
+			changes.Record(transaction.ID, models.TransactionUnchanged)
+		case inserted:
+			changes.Record(transaction.ID, models.TransactionInserted)
+		default:
+			changes.Record(transaction.ID, models.TransactionUpdated)
+		}
 	}
 
 	return nil
@@ -290,7 +572,23 @@ func (db *DB) CreateTransactionsBatch(transactions []models.Transaction, platfor
 
 // GetTransactionsByAccount retrieves all transactions for a specific account
 func (db *DB) GetTransactionsByAccount(accountID string, platform string, filter TransactionFilter) ([]models.Transaction, error) {
-	tableName := getTransactionTableName(platform)
+	return db.GetTransactionsByAccountContext(context.Background(), accountID, platform, filter)
+}
+
+// GetTransactionsByAccountContext is like GetTransactionsByAccount but is canceled with ctx and bounded by the query timeout
+func (db *DB) GetTransactionsByAccountContext(ctx context.Context, accountID string, platform string, filter TransactionFilter) ([]models.Transaction, error) {
+	ctx, cancel := db.withQueryTimeout(ctx)
+	defer cancel()
+
+	tableName, err := getTransactionTableName(platform)
+	if err != nil {
+		return nil, err
+	}
+
+	startDate, endDate, err := filter.DateBounds()
+	if err != nil {
+		return nil, err
+	}
 
 	query := fmt.Sprintf(`
 		SELECT 
@@ -298,25 +596,26 @@ func (db *DB) GetTransactionsByAccount(accountID string, platform string, filter
 			amount_currency, amount_value, amount_fraction, status,
 			action_type, action_payload, cash_account_number, hidden, deleted,
 			actions, dividend_per_share, taxes, total, shares, share_price,
-			fees, amount, isin, quantity, transaction_type, metadata
+			fees, amount, isin, quantity, transaction_type, metadata, tags, note
 		FROM %s
 		WHERE account_id = $1 AND (subtitle IS NULL OR subtitle != 'Échec du plan d''épargne')
 	`, tableName)
+	query += filter.deletedCondition("deleted") + filter.hiddenCondition("hidden")
 
 	args := []interface{}{accountID}
 	argCount := 1
 
 	// Apply filters
-	if filter.StartDate != "" {
+	if startDate != "" {
 		argCount++
-		query += fmt.Sprintf(" AND timestamp >= $%d", argCount)
-		args = append(args, filter.StartDate)
+		query += fmt.Sprintf(" AND timestamp::timestamptz >= $%d::timestamptz", argCount)
+		args = append(args, startDate)
 	}
 
-	if filter.EndDate != "" {
+	if endDate != "" {
 		argCount++
-		query += fmt.Sprintf(" AND timestamp <= $%d", argCount)
-		args = append(args, filter.EndDate)
+		query += fmt.Sprintf(" AND timestamp::timestamptz <= $%d::timestamptz", argCount)
+		args = append(args, endDate)
 	}
 
 	if filter.ISIN != "" {
@@ -331,6 +630,12 @@ func (db *DB) GetTransactionsByAccount(accountID string, platform string, filter
 		args = append(args, filter.TransactionType)
 	}
 
+	if filter.Tag != "" {
+		argCount++
+		query += fmt.Sprintf(" AND $%d = ANY(tags)", argCount)
+		args = append(args, filter.Tag)
+	}
+
 	query += " ORDER BY timestamp DESC"
 
 	// Apply pagination
@@ -348,7 +653,7 @@ func (db *DB) GetTransactionsByAccount(accountID string, platform string, filter
 	}
 
 	var transactions []models.Transaction
-	err := db.Select(&transactions, query, args...)
+	err = db.SelectContext(ctx, &transactions, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get transactions: %w", err)
 	}
@@ -358,119 +663,62 @@ func (db *DB) GetTransactionsByAccount(accountID string, platform string, filter
 
 // GetTransactionsByAccountWithSort retrieves transactions for a specific account with custom sorting
 func (db *DB) GetTransactionsByAccountWithSort(accountID string, platform string, filter TransactionFilter, sortBy, sortOrder string) ([]models.Transaction, error) {
-	tableName := getTransactionTableName(platform)
-
-	query := fmt.Sprintf(`
-		SELECT 
-			t.id, t.account_id, t.timestamp, t.title, t.icon, t.avatar, t.subtitle,
-			t.amount_currency, t.amount_value, t.amount_fraction, t.status,
-			t.action_type, t.action_payload, t.cash_account_number, t.hidden, t.deleted,
-			t.actions, t.dividend_per_share, t.taxes, t.total, t.shares, t.share_price,
-			t.fees, t.amount, t.isin, t.quantity, t.transaction_type, t.metadata
-		FROM %s t
-		LEFT JOIN assets a ON t.isin = a.isin
-		WHERE t.account_id = $1 AND (t.subtitle IS NULL OR t.subtitle != 'Échec du plan d''épargne')
-	`, tableName)
-
-	args := []interface{}{accountID}
-	argCount := 1
-
-	// Apply filters
-	if filter.StartDate != "" {
-		argCount++
-		query += fmt.Sprintf(" AND t.timestamp >= $%d", argCount)
-		args = append(args, filter.StartDate)
-	}
-
-	if filter.EndDate != "" {
-		argCount++
-		query += fmt.Sprintf(" AND t.timestamp <= $%d", argCount)
-		args = append(args, filter.EndDate)
-	}
-
-	if filter.ISIN != "" {
-		argCount++
-		// Search by ISIN (case-insensitive partial match) OR asset name (case-insensitive partial match)
-		query += fmt.Sprintf(" AND (LOWER(t.isin) LIKE LOWER($%d) OR LOWER(a.name) LIKE LOWER($%d))", argCount, argCount)
-		args = append(args, "%"+filter.ISIN+"%")
-	}
+	return db.GetTransactionsByAccountWithSortContext(context.Background(), accountID, platform, filter, sortBy, sortOrder)
+}
 
-	if filter.TransactionType != "" {
-		argCount++
-		query += fmt.Sprintf(" AND t.transaction_type = $%d", argCount)
-		args = append(args, filter.TransactionType)
-	}
+// GetTransactionsByAccountWithSortContext is like GetTransactionsByAccountWithSort but is canceled with ctx and bounded by the query timeout
+func (db *DB) GetTransactionsByAccountWithSortContext(ctx context.Context, accountID string, platform string, filter TransactionFilter, sortBy, sortOrder string) ([]models.Transaction, error) {
+	filter.AccountID = accountID
+	return db.listTransactions(ctx, platform, filter, sortBy, sortOrder, true)
+}
 
-	// Apply sorting
-	if sortBy == "timestamp" {
-		if sortOrder == "asc" {
-			query += " ORDER BY t.timestamp ASC"
-		} else {
-			query += " ORDER BY t.timestamp DESC"
-		}
-	} else if sortBy == "amount" {
-		if sortOrder == "asc" {
-			query += " ORDER BY t.amount_value ASC"
-		} else {
-			query += " ORDER BY t.amount_value DESC"
-		}
-	} else {
-		// Default sort
-		query += " ORDER BY t.timestamp DESC"
-	}
+// GetAllTransactions retrieves all transactions across all accounts for a platform
+func (db *DB) GetAllTransactions(platform string, filter TransactionFilter) ([]models.Transaction, error) {
+	return db.GetAllTransactionsContext(context.Background(), platform, filter)
+}
 
-	// Apply pagination
-	if filter.Limit > 0 {
-		argCount++
-		query += fmt.Sprintf(" LIMIT $%d", argCount)
-		args = append(args, filter.Limit)
+// GetAllTransactionsContext is like GetAllTransactions but is canceled with ctx and bounded by the query timeout
+func (db *DB) GetAllTransactionsContext(ctx context.Context, platform string, filter TransactionFilter) ([]models.Transaction, error) {
+	ctx, cancel := db.withQueryTimeout(ctx)
+	defer cancel()
 
-		if filter.Page > 0 {
-			argCount++
-			offset := (filter.Page - 1) * filter.Limit
-			query += fmt.Sprintf(" OFFSET $%d", argCount)
-			args = append(args, offset)
-		}
+	tableName, err := getTransactionTableName(platform)
+	if err != nil {
+		return nil, err
 	}
 
-	var transactions []models.Transaction
-	err := db.Select(&transactions, query, args...)
+	startDate, endDate, err := filter.DateBounds()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get transactions: %w", err)
+		return nil, err
 	}
 
-	return transactions, nil
-}
-
-// GetAllTransactions retrieves all transactions across all accounts for a platform
-func (db *DB) GetAllTransactions(platform string, filter TransactionFilter) ([]models.Transaction, error) {
-	tableName := getTransactionTableName(platform)
-
 	query := fmt.Sprintf(`
 		SELECT 
 			id, account_id, timestamp, title, icon, avatar, subtitle,
 			amount_currency, amount_value, amount_fraction, status,
 			action_type, action_payload, cash_account_number, hidden, deleted,
 			actions, dividend_per_share, taxes, total, shares, share_price,
-			fees, amount, isin, quantity, transaction_type, metadata
+			fees, amount, isin, quantity, transaction_type, metadata, tags, note
 		FROM %s
 		WHERE (subtitle IS NULL OR subtitle != 'Échec du plan d''épargne')
 	`, tableName)
+	query += filter.deletedCondition("deleted") + filter.hiddenCondition("hidden")
 
-	args := []interface{}{}
-	argCount := 0
+	condition, args := filter.accountIDsCondition("account_id", []interface{}{})
+	query += condition
+	argCount := len(args)
 
 	// Apply filters
-	if filter.StartDate != "" {
+	if startDate != "" {
 		argCount++
-		query += fmt.Sprintf(" AND timestamp >= $%d", argCount)
-		args = append(args, filter.StartDate)
+		query += fmt.Sprintf(" AND timestamp::timestamptz >= $%d::timestamptz", argCount)
+		args = append(args, startDate)
 	}
 
-	if filter.EndDate != "" {
+	if endDate != "" {
 		argCount++
-		query += fmt.Sprintf(" AND timestamp <= $%d", argCount)
-		args = append(args, filter.EndDate)
+		query += fmt.Sprintf(" AND timestamp::timestamptz <= $%d::timestamptz", argCount)
+		args = append(args, endDate)
 	}
 
 	if filter.ISIN != "" {
@@ -485,6 +733,12 @@ func (db *DB) GetAllTransactions(platform string, filter TransactionFilter) ([]m
 		args = append(args, filter.TransactionType)
 	}
 
+	if filter.Tag != "" {
+		argCount++
+		query += fmt.Sprintf(" AND $%d = ANY(tags)", argCount)
+		args = append(args, filter.Tag)
+	}
+
 	query += " ORDER BY timestamp DESC"
 
 	// Apply pagination
@@ -502,7 +756,7 @@ func (db *DB) GetAllTransactions(platform string, filter TransactionFilter) ([]m
 	}
 
 	var transactions []models.Transaction
-	err := db.Select(&transactions, query, args...)
+	err = db.SelectContext(ctx, &transactions, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get transactions: %w", err)
 	}
@@ -512,7 +766,29 @@ func (db *DB) GetAllTransactions(platform string, filter TransactionFilter) ([]m
 
 // GetAllTransactionsWithSort retrieves all transactions across all accounts for a platform with custom sorting
 func (db *DB) GetAllTransactionsWithSort(platform string, filter TransactionFilter, sortBy, sortOrder string) ([]models.Transaction, error) {
-	tableName := getTransactionTableName(platform)
+	return db.GetAllTransactionsWithSortContext(context.Background(), platform, filter, sortBy, sortOrder)
+}
+
+// GetAllTransactionsWithSortContext is like GetAllTransactionsWithSort but is canceled with ctx and bounded by the query timeout
+func (db *DB) GetAllTransactionsWithSortContext(ctx context.Context, platform string, filter TransactionFilter, sortBy, sortOrder string) ([]models.Transaction, error) {
+	// Don't apply pagination here - let the handler do it for combined results
+	return db.listTransactions(ctx, platform, filter, sortBy, sortOrder, false)
+}
+
+// listTransactions runs a sorted listing query with the WHERE clause shared with CountTransactions
+func (db *DB) listTransactions(ctx context.Context, platform string, filter TransactionFilter, sortBy, sortOrder string, paginate bool) ([]models.Transaction, error) {
+	ctx, cancel := db.withQueryTimeout(ctx)
+	defer cancel()
+
+	tableName, err := getTransactionTableName(platform)
+	if err != nil {
+		return nil, err
+	}
+
+	where, args, err := filter.listWhereClause()
+	if err != nil {
+		return nil, err
+	}
 
 	query := fmt.Sprintf(`
 		SELECT 
@@ -520,62 +796,27 @@ func (db *DB) GetAllTransactionsWithSort(platform string, filter TransactionFilt
 			t.amount_currency, t.amount_value, t.amount_fraction, t.status,
 			t.action_type, t.action_payload, t.cash_account_number, t.hidden, t.deleted,
 			t.actions, t.dividend_per_share, t.taxes, t.total, t.shares, t.share_price,
-			t.fees, t.amount, t.isin, t.quantity, t.transaction_type, t.metadata
+			t.fees, t.amount, t.isin, t.quantity, t.transaction_type, t.metadata, t.tags, t.note
 		FROM %s t
 		LEFT JOIN assets a ON t.isin = a.isin
-		WHERE (t.subtitle IS NULL OR t.subtitle != 'Échec du plan d''épargne')
-	`, tableName)
-
-	args := []interface{}{}
-	argCount := 0
+		%s
+	`, tableName, where)
 
-	// Apply filters
-	if filter.StartDate != "" {
-		argCount++
-		query += fmt.Sprintf(" AND t.timestamp >= $%d", argCount)
-		args = append(args, filter.StartDate)
-	}
+	query += listOrderClause(sortBy, sortOrder)
 
-	if filter.EndDate != "" {
-		argCount++
-		query += fmt.Sprintf(" AND t.timestamp <= $%d", argCount)
-		args = append(args, filter.EndDate)
-	}
-
-	if filter.ISIN != "" {
-		argCount++
-		// Search by ISIN (case-insensitive partial match) OR asset name (case-insensitive partial match)
-		query += fmt.Sprintf(" AND (LOWER(t.isin) LIKE LOWER($%d) OR LOWER(a.name) LIKE LOWER($%d))", argCount, argCount)
-		args = append(args, "%"+filter.ISIN+"%")
-	}
-
-	if filter.TransactionType != "" {
-		argCount++
-		query += fmt.Sprintf(" AND t.transaction_type = $%d", argCount)
-		args = append(args, filter.TransactionType)
-	}
+	// Apply pagination
+	if paginate && filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
 
-	// Apply sorting
-	if sortBy == "timestamp" {
-		if sortOrder == "asc" {
-			query += " ORDER BY t.timestamp ASC"
-		} else {
-			query += " ORDER BY t.timestamp DESC"
-		}
-	} else if sortBy == "amount" {
-		if sortOrder == "asc" {
-			query += " ORDER BY t.amount_value ASC"
-		} else {
-			query += " ORDER BY t.amount_value DESC"
+		if filter.Page > 0 {
+			args = append(args, (filter.Page-1)*filter.Limit)
+			query += fmt.Sprintf(" OFFSET $%d", len(args))
 		}
-	} else {
-		// Default sort
-		query += " ORDER BY t.timestamp DESC"
 	}
 
-	// Don't apply pagination here - let the handler do it for combined results
 	var transactions []models.Transaction
-	err := db.Select(&transactions, query, args...)
+	err = db.SelectContext(ctx, &transactions, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get transactions: %w", err)
 	}
@@ -585,7 +826,18 @@ func (db *DB) GetAllTransactionsWithSort(platform string, filter TransactionFilt
 
 // GetTransactionByID retrieves a specific transaction by ID
 func (db *DB) GetTransactionByID(id string, platform string) (*models.Transaction, error) {
-	tableName := getTransactionTableName(platform)
+	return db.GetTransactionByIDContext(context.Background(), id, platform)
+}
+
+// GetTransactionByIDContext is like GetTransactionByID but is canceled with ctx and bounded by the query timeout
+func (db *DB) GetTransactionByIDContext(ctx context.Context, id string, platform string) (*models.Transaction, error) {
+	ctx, cancel := db.withQueryTimeout(ctx)
+	defer cancel()
+
+	tableName, err := getTransactionTableName(platform)
+	if err != nil {
+		return nil, err
+	}
 
 	query := fmt.Sprintf(`
 		SELECT 
@@ -593,13 +845,13 @@ func (db *DB) GetTransactionByID(id string, platform string) (*models.Transactio
 			amount_currency, amount_value, amount_fraction, status,
 			action_type, action_payload, cash_account_number, hidden, deleted,
 			actions, dividend_per_share, taxes, total, shares, share_price,
-			fees, amount, isin, quantity, transaction_type, metadata
+			fees, amount, isin, quantity, transaction_type, metadata, tags, note
 		FROM %s
 		WHERE id = $1
 	`, tableName)
 
 	var transaction models.Transaction
-	err := db.Get(&transaction, query, id)
+	err = db.GetContext(ctx, &transaction, query, id)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get transaction: %w", err)
 	}
@@ -607,68 +859,126 @@ func (db *DB) GetTransactionByID(id string, platform string) (*models.Transactio
 	return &transaction, nil
 }
 
-// UpdateTransaction updates an existing transaction
-func (db *DB) UpdateTransaction(transaction *models.Transaction, platform string) error {
-	// Validate transaction
-	if err := transaction.Validate(); err != nil {
-		return fmt.Errorf("validation failed: %w", err)
+// FindTransactionByID searches a transaction by ID across all platform tables
+// Returns the transaction and the platform of the table it was found in
+func (db *DB) FindTransactionByID(id string) (*models.Transaction, string, error) {
+	return db.FindTransactionByIDContext(context.Background(), id)
+}
+
+// FindTransactionByIDContext is like FindTransactionByID but is canceled with ctx
+func (db *DB) FindTransactionByIDContext(ctx context.Context, id string) (*models.Transaction, string, error) {
+	for _, platform := range models.PlatformIDs() {
+		transaction, err := db.GetTransactionByIDContext(ctx, id, platform)
+		if err == nil {
+			return transaction, platform, nil
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return nil, "", err
+		}
+	}
+
+	return nil, "", fmt.Errorf("failed to get transaction: %w", sql.ErrNoRows)
+}
+
+// UpdateTransaction applies update to the transaction with the given ID and returns the
+// updated transaction. Only the fields set in update are written: the others keep their
+// stored value. When update.AccountID is set, transactions of other accounts are not found.
+func (db *DB) UpdateTransaction(ctx context.Context, id string, platform string, update models.TransactionUpdate) (*models.Transaction, error) {
+	tableName, err := getTransactionTableName(platform)
+	if err != nil {
+		return nil, err
 	}
 
-	tableName := getTransactionTableName(platform)
+	existing, err := db.GetTransactionByIDContext(ctx, id, platform)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("transaction not found")
+		}
+		return nil, err
+	}
+	if update.AccountID != "" && existing.AccountID != update.AccountID {
+		return nil, fmt.Errorf("transaction not found")
+	}
 
-	// Handle ISIN - convert empty string to NULL
+	// Validate the transaction as it will be stored
+	merged := *existing
+	update.Apply(&merged)
+	if err := merged.Validate(); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+	merged.NormalizeAmount()
+
+	var amountValue *float64
+	if update.AmountValue != nil {
+		amountValue = &merged.AmountValue
+	}
 	var isinValue interface{}
-	if transaction.ISIN != nil && *transaction.ISIN != "" {
-		isinValue = *transaction.ISIN
-	} else {
-		isinValue = nil
+	if merged.ISIN != nil {
+		isinValue = *merged.ISIN
+	}
+	var tags interface{}
+	if update.Tags != nil {
+		tags = merged.Tags
+	}
+	var note *string
+	if update.Note != nil {
+		note = &merged.Note
 	}
 
+	// NULL parameters keep the stored value, so that concurrent changes of other fields are kept
 	query := fmt.Sprintf(`
 		UPDATE %s SET
-			title = $1,
-			subtitle = $2,
-			amount_value = $3,
-			amount_currency = $4,
-			fees = $5,
-			quantity = $6,
-			transaction_type = $7,
-			isin = $8
-		WHERE id = $9
+			title = COALESCE($1, title),
+			subtitle = COALESCE($2, subtitle),
+			amount_value = COALESCE($3, amount_value),
+			amount_currency = COALESCE($4, amount_currency),
+			fees = COALESCE($5, fees),
+			quantity = COALESCE($6, quantity),
+			transaction_type = COALESCE($7, transaction_type),
+			isin = CASE WHEN $8 THEN $9 ELSE isin END,
+			tags = COALESCE($10, tags),
+			note = COALESCE($11, note)
+		WHERE id = $12
 	`, tableName)
 
-	result, err := db.Exec(
-		query,
-		transaction.Title,
-		transaction.Subtitle,
-		transaction.AmountValue,
-		transaction.AmountCurrency,
-		transaction.Fees,
-		transaction.Quantity,
-		transaction.TransactionType,
+	ctx, cancel := db.withQueryTimeout(ctx)
+	defer cancel()
+
+	result, err := db.ExecContext(ctx, query,
+		update.Title,
+		update.Subtitle,
+		amountValue,
+		update.AmountCurrency,
+		update.Fees,
+		update.Quantity,
+		update.TransactionType,
+		update.ISIN != nil,
 		isinValue,
-		transaction.ID,
+		tags,
+		note,
+		id,
 	)
-
 	if err != nil {
-		return fmt.Errorf("failed to update transaction: %w", err)
+		return nil, fmt.Errorf("failed to update transaction: %w", err)
 	}
 
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
+		return nil, fmt.Errorf("failed to get rows affected: %w", err)
 	}
-
 	if rowsAffected == 0 {
-		return fmt.Errorf("transaction not found")
+		return nil, fmt.Errorf("transaction not found")
 	}
 
-	return nil
+	return db.GetTransactionByIDContext(ctx, id, platform)
 }
 
 // DeleteTransaction deletes a transaction
 func (db *DB) DeleteTransaction(id string, platform string) error {
-	tableName := getTransactionTableName(platform)
+	tableName, err := getTransactionTableName(platform)
+	if err != nil {
+		return err
+	}
 
 	query := fmt.Sprintf(`DELETE FROM %s WHERE id = $1`, tableName)
 
@@ -691,51 +1001,33 @@ func (db *DB) DeleteTransaction(id string, platform string) error {
 
 // CountTransactions counts transactions matching the filter
 func (db *DB) CountTransactions(platform string, filter TransactionFilter) (int, error) {
-	tableName := getTransactionTableName(platform)
-
-	query := fmt.Sprintf(`
-		SELECT COUNT(*) 
-		FROM %s t
-		LEFT JOIN assets a ON t.isin = a.isin
-		WHERE (t.subtitle IS NULL OR t.subtitle != 'Échec du plan d''épargne')
-	`, tableName)
-
-	args := []interface{}{}
-	argCount := 0
+	return db.CountTransactionsContext(context.Background(), platform, filter)
+}
 
-	if filter.AccountID != "" {
-		argCount++
-		query += fmt.Sprintf(" AND t.account_id = $%d", argCount)
-		args = append(args, filter.AccountID)
-	}
+// CountTransactionsContext is like CountTransactions but is canceled with ctx and bounded by the query timeout
+func (db *DB) CountTransactionsContext(ctx context.Context, platform string, filter TransactionFilter) (int, error) {
+	ctx, cancel := db.withQueryTimeout(ctx)
+	defer cancel()
 
-	if filter.StartDate != "" {
-		argCount++
-		query += fmt.Sprintf(" AND t.timestamp >= $%d", argCount)
-		args = append(args, filter.StartDate)
-	}
-
-	if filter.EndDate != "" {
-		argCount++
-		query += fmt.Sprintf(" AND t.timestamp <= $%d", argCount)
-		args = append(args, filter.EndDate)
+	tableName, err := getTransactionTableName(platform)
+	if err != nil {
+		return 0, err
 	}
 
-	if filter.ISIN != "" {
-		argCount++
-		// Search by ISIN (case-insensitive partial match) OR asset name (case-insensitive partial match)
-		query += fmt.Sprintf(" AND (LOWER(t.isin) LIKE LOWER($%d) OR LOWER(a.name) LIKE LOWER($%d))", argCount, argCount)
-		args = append(args, "%"+filter.ISIN+"%")
+	where, args, err := filter.listWhereClause()
+	if err != nil {
+		return 0, err
 	}
 
-	if filter.TransactionType != "" {
-		argCount++
-		query += fmt.Sprintf(" AND t.transaction_type = $%d", argCount)
-		args = append(args, filter.TransactionType)
-	}
+	query := fmt.Sprintf(`
+		SELECT COUNT(*) 
+		FROM %s t
+		LEFT JOIN assets a ON t.isin = a.isin
+		%s
+	`, tableName, where)
 
 	var count int
-	err := db.Get(&count, query, args...)
+	err = db.GetContext(ctx, &count, query, args...)
 	if err != nil {
 		return 0, fmt.Errorf("failed to count transactions: %w", err)
 	}
@@ -762,16 +1054,35 @@ func (db *DB) ImportTransactionsFromJSON(jsonData []byte, accountID string, plat
 	return len(transactions), nil
 }
 
-// getTransactionTableName returns the table name for a given platform
-func getTransactionTableName(platform string) string {
+// assetCurrency returns the currency of an asset created from a transaction:
+// the transaction currency, which defaults to the account currency on import
+func assetCurrency(transaction *models.Transaction) string {
+	if transaction.AmountCurrency == "" {
+		return models.DefaultCurrency
+	}
+	return transaction.AmountCurrency
+}
+
+// ErrUnsupportedPlatform is returned for a platform without a transactions table
+var ErrUnsupportedPlatform = errors.New("unsupported platform")
+
+// getTransactionTableName returns the table name for a given platform.
+// Unknown platforms are rejected rather than read from or written to another platform's table.
+func getTransactionTableName(platform string) (string, error) {
 	switch platform {
 	case "traderepublic":
-		return "transactions_traderepublic"
+		return "transactions_traderepublic", nil
 	case "binance":
-		return "transactions_binance"
+		return "transactions_binance", nil
 	case "boursedirect":
-		return "transactions_boursedirect"
+		return "transactions_boursedirect", nil
 	default:
-		return "transactions_traderepublic" // default fallback
+		return "", fmt.Errorf("%w: %q", ErrUnsupportedPlatform, platform)
 	}
 }
+
+// ValidateTransactionPlatform returns ErrUnsupportedPlatform if the platform has no transactions table
+func ValidateTransactionPlatform(platform string) error {
+	_, err := getTransactionTableName(platform)
+	return err
+}
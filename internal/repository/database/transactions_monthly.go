@@ -0,0 +1,107 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// monthLayout is the layout of the months of MonthlyTransactions
+const monthLayout = "2006-01"
+
+// MonthlyTransactions aggregates the transactions of a month
+type MonthlyTransactions struct {
+	// Month is the month in the filter time zone, as YYYY-MM
+	Month string `json:"month" db:"month"`
+	Count int    `json:"count" db:"count"`
+	// NetCashFlow is deposits - withdrawals - buys + sells, whatever the sign of the stored amounts
+	NetCashFlow float64 `json:"net_cash_flow" db:"net_cash_flow"`
+}
+
+// GetMonthlyTransactions groups the transactions of the filter by month, in the filter time
+// zone, in ascending order. Months without transactions are not returned (see FillMonthGaps).
+func (db *DB) GetMonthlyTransactions(ctx context.Context, platform string, filter TransactionFilter) ([]MonthlyTransactions, error) {
+	ctx, cancel := db.withQueryTimeout(ctx)
+	defer cancel()
+
+	tableName, err := getTransactionTableName(platform)
+	if err != nil {
+		return nil, err
+	}
+
+	where, args, err := filter.listWhereClause()
+	if err != nil {
+		return nil, err
+	}
+
+	// Timestamps are stored as RFC3339 strings
+	args = append(args, filterLocation.String())
+	query := fmt.Sprintf(`
+		SELECT
+			to_char(date_trunc('month', t.timestamp::timestamptz AT TIME ZONE $%d), 'YYYY-MM') AS month,
+			COUNT(*) AS count,
+			COALESCE(SUM(CASE t.transaction_type
+				WHEN 'deposit' THEN ABS(t.amount_value)
+				WHEN 'withdrawal' THEN -ABS(t.amount_value)
+				WHEN 'buy' THEN -ABS(t.amount_value)
+				WHEN 'sell' THEN ABS(t.amount_value)
+				ELSE 0
+			END), 0) AS net_cash_flow
+		FROM %s t
+		LEFT JOIN assets a ON t.isin = a.isin
+		%s
+		GROUP BY 1
+		ORDER BY 1
+	`, len(args), tableName, where)
+
+	var months []MonthlyTransactions
+	if err := db.SelectContext(ctx, &months, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to group transactions by month: %w", err)
+	}
+	return months, nil
+}
+
+// FillMonthGaps returns the months from the first to the last one with a zero entry for each
+// month without transactions. from and to (ignored when zero) extend the range to their month.
+func FillMonthGaps(months []MonthlyTransactions, from, to time.Time) ([]MonthlyTransactions, error) {
+	byMonth := make(map[string]MonthlyTransactions, len(months))
+	var first, last time.Time
+	for _, month := range months {
+		start, err := time.Parse(monthLayout, month.Month)
+		if err != nil {
+			return nil, fmt.Errorf("invalid month %q: %w", month.Month, err)
+		}
+		byMonth[month.Month] = month
+		if first.IsZero() || start.Before(first) {
+			first = start
+		}
+		if last.IsZero() || start.After(last) {
+			last = start
+		}
+	}
+
+	if !from.IsZero() {
+		if start := time.Date(from.Year(), from.Month(), 1, 0, 0, 0, 0, time.UTC); first.IsZero() || start.Before(first) {
+			first = start
+		}
+	}
+	if !to.IsZero() {
+		if start := time.Date(to.Year(), to.Month(), 1, 0, 0, 0, 0, time.UTC); last.IsZero() || start.After(last) {
+			last = start
+		}
+	}
+	if first.IsZero() || last.IsZero() {
+		return []MonthlyTransactions{}, nil
+	}
+
+	filled := []MonthlyTransactions{}
+	for month := first; !month.After(last); month = month.AddDate(0, 1, 0) {
+		key := month.Format(monthLayout)
+		entry, ok := byMonth[key]
+		if !ok {
+			entry = MonthlyTransactions{Month: key}
+		}
+		filled = append(filled, entry)
+	}
+	return filled, nil
+}
@@ -0,0 +1,52 @@
+package database
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestFillMonthGaps(t *testing.T) {
+	months := []MonthlyTransactions{
+		{Month: "2023-11", Count: 2, NetCashFlow: 100},
+		{Month: "2024-02", Count: 1, NetCashFlow: -50},
+	}
+
+	tests := []struct {
+		name     string
+		from, to time.Time
+		want     []string
+	}{
+		{"between first and last", time.Time{}, time.Time{}, []string{"2023-11", "2023-12", "2024-01", "2024-02"}},
+		{"extended to the bounds", time.Date(2023, 10, 15, 0, 0, 0, 0, time.UTC), time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+			[]string{"2023-10", "2023-11", "2023-12", "2024-01", "2024-02", "2024-03"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filled, err := FillMonthGaps(months, tt.from, tt.to)
+			if err != nil {
+				t.Fatalf("FillMonthGaps() error = %v", err)
+			}
+			var got []string
+			for _, month := range filled {
+				got = append(got, month.Month)
+				if month.Month == "2023-12" && (month.Count != 0 || month.NetCashFlow != 0) {
+					t.Errorf("expected a zero gap month, got %+v", month)
+				}
+				if month.Month == "2023-11" && month.Count != 2 {
+					t.Errorf("expected the existing month to be kept, got %+v", month)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected months %v, got %v", tt.want, got)
+			}
+		})
+	}
+
+	if filled, err := FillMonthGaps(nil, time.Time{}, time.Time{}); err != nil || filled == nil || len(filled) != 0 {
+		t.Errorf("expected an empty slice without months nor bounds, got %v, %v", filled, err)
+	}
+	if _, err := FillMonthGaps([]MonthlyTransactions{{Month: "2024/01"}}, time.Time{}, time.Time{}); err == nil {
+		t.Error("expected an error for an invalid month")
+	}
+}
@@ -0,0 +1,165 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"valhafin/internal/domain/models"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// Errors returned by MoveTransactions
+var (
+	// ErrMoveAccountNotFound is returned when the source or the target account does not exist
+	ErrMoveAccountNotFound = errors.New("account not found")
+	// ErrMoveTransactionNotFound is returned when a transaction is not found in the source account
+	ErrMoveTransactionNotFound = errors.New("transaction not found in the source account")
+	// ErrMoveIncompatiblePlatforms is returned when the transactions cannot be stored in the
+	// target platform table (e.g. symbol keyed crypto transactions moved to an ISIN keyed broker)
+	ErrMoveIncompatiblePlatforms = errors.New("transactions cannot be moved between these platforms")
+	// ErrMoveConflict is returned when a moved transaction ID already exists in the target table
+	ErrMoveConflict = errors.New("transaction already exists on the target platform")
+)
+
+// TransactionMoveResult reports what was moved by MoveTransactions
+type TransactionMoveResult struct {
+	FromAccountID string `json:"from_account_id"`
+	ToAccountID   string `json:"to_account_id"`
+	FromPlatform  string `json:"from_platform"`
+	ToPlatform    string `json:"to_platform"`
+	Moved         int    `json:"moved"`
+}
+
+// transactionColumns lists the columns copied when a transaction changes platform table;
+// account_id and updated_at are set by the move
+const transactionColumns = `id, timestamp, title, icon, avatar, subtitle,
+	amount_currency, amount_value, amount_fraction, status,
+	action_type, action_payload, cash_account_number, hidden, deleted,
+	actions, dividend_per_share, taxes, total, shares, share_price,
+	fees, amount, isin, quantity, transaction_type, metadata, tags, note`
+
+// MoveTransactions attaches the given transactions of fromAccountID to toAccountID, in a single
+// transaction. When the accounts are on different platforms the rows are copied to the target
+// platform table and removed from the source one; they are validated again and both platforms
+// must identify assets the same way. Nothing is moved if any transaction is missing.
+func (db *DB) MoveTransactions(ctx context.Context, ids []string, fromAccountID, toAccountID string) (*TransactionMoveResult, error) {
+	var result *TransactionMoveResult
+
+	err := db.InTransaction(ctx, func(tx *sql.Tx) error {
+		result = &TransactionMoveResult{FromAccountID: fromAccountID, ToAccountID: toAccountID}
+
+		for _, account := range []struct {
+			id       string
+			platform *string
+		}{{fromAccountID, &result.FromPlatform}, {toAccountID, &result.ToPlatform}} {
+			err := tx.QueryRowContext(ctx, `SELECT platform FROM accounts WHERE id = $1`, account.id).Scan(account.platform)
+			if errors.Is(err, sql.ErrNoRows) {
+				return fmt.Errorf("%w: %s", ErrMoveAccountNotFound, account.id)
+			}
+			if err != nil {
+				return fmt.Errorf("failed to get account %s: %w", account.id, err)
+			}
+		}
+
+		fromTable, err := getTransactionTableName(result.FromPlatform)
+		if err != nil {
+			return err
+		}
+		toTable, err := getTransactionTableName(result.ToPlatform)
+		if err != nil {
+			return err
+		}
+
+		if fromTable == toTable {
+			moved, err := execRowsAffected(ctx, tx, fmt.Sprintf(`
+				UPDATE %s SET account_id = $1
+				WHERE account_id = $2 AND id = ANY($3)
+			`, fromTable), toAccountID, fromAccountID, pq.Array(ids))
+			if err != nil {
+				return fmt.Errorf("failed to move transactions: %w", err)
+			}
+			if int(moved) != len(ids) {
+				return fmt.Errorf("%w: %d of %d found", ErrMoveTransactionNotFound, moved, len(ids))
+			}
+			result.Moved = int(moved)
+			return nil
+		}
+
+		moved, err := moveTransactionsAcrossPlatforms(ctx, tx, ids, fromAccountID, toAccountID, result.FromPlatform, result.ToPlatform)
+		if err != nil {
+			return err
+		}
+		result.Moved = moved
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// moveTransactionsAcrossPlatforms copies the transactions to the target platform table and
+// deletes them from the source one, within tx
+func moveTransactionsAcrossPlatforms(ctx context.Context, tx *sql.Tx, ids []string, fromAccountID, toAccountID, fromPlatform, toPlatform string) (int, error) {
+	from, _ := models.GetPlatform(fromPlatform)
+	to, _ := models.GetPlatform(toPlatform)
+	if from.AssetKey != to.AssetKey {
+		return 0, fmt.Errorf("%w: %s identifies assets by %s, %s by %s", ErrMoveIncompatiblePlatforms, fromPlatform, from.AssetKey, toPlatform, to.AssetKey)
+	}
+
+	fromTable, _ := getTransactionTableName(fromPlatform)
+	toTable, _ := getTransactionTableName(toPlatform)
+
+	rows, err := tx.QueryContext(ctx, fmt.Sprintf(`
+		SELECT account_id, %s
+		FROM %s
+		WHERE account_id = $1 AND id = ANY($2)
+		FOR UPDATE
+	`, transactionColumns, fromTable), fromAccountID, pq.Array(ids))
+	if err != nil {
+		return 0, fmt.Errorf("failed to read transactions: %w", err)
+	}
+	var transactions []models.Transaction
+	if err := sqlx.StructScan(rows, &transactions); err != nil {
+		return 0, fmt.Errorf("failed to read transactions: %w", err)
+	}
+	if len(transactions) != len(ids) {
+		return 0, fmt.Errorf("%w: %d of %d found", ErrMoveTransactionNotFound, len(transactions), len(ids))
+	}
+
+	// Validate the transactions as they will be stored on the target platform
+	for _, transaction := range transactions {
+		transaction.AccountID = toAccountID
+		if err := transaction.Validate(); err != nil {
+			return 0, fmt.Errorf("%w: transaction %s: %v", ErrMoveIncompatiblePlatforms, transaction.ID, err)
+		}
+	}
+
+	var existing int
+	if err := tx.QueryRowContext(ctx, fmt.Sprintf(`SELECT COUNT(*) FROM %s WHERE id = ANY($1)`, toTable), pq.Array(ids)).Scan(&existing); err != nil {
+		return 0, fmt.Errorf("failed to check target transactions: %w", err)
+	}
+	if existing > 0 {
+		return 0, fmt.Errorf("%w: %d of %d", ErrMoveConflict, existing, len(ids))
+	}
+
+	inserted, err := execRowsAffected(ctx, tx, fmt.Sprintf(`
+		INSERT INTO %s (account_id, %s)
+		SELECT $1, %s
+		FROM %s
+		WHERE account_id = $2 AND id = ANY($3)
+	`, toTable, transactionColumns, transactionColumns, fromTable), toAccountID, fromAccountID, pq.Array(ids))
+	if err != nil {
+		return 0, fmt.Errorf("failed to copy transactions: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf(`DELETE FROM %s WHERE account_id = $1 AND id = ANY($2)`, fromTable), fromAccountID, pq.Array(ids)); err != nil {
+		return 0, fmt.Errorf("failed to remove moved transactions: %w", err)
+	}
+
+	return int(inserted), nil
+}
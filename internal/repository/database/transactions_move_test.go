@@ -0,0 +1,118 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+	"valhafin/internal/domain/models"
+)
+
+// createMoveFixture creates an account of the platform with n transactions
+func createMoveFixture(t *testing.T, db *DB, platform, prefix string, n int) (*models.Account, []string) {
+	t.Helper()
+
+	account := &models.Account{Name: "Move " + prefix, Platform: platform, Credentials: "encrypted"}
+	if err := db.CreateAccount(account); err != nil {
+		t.Fatalf("Failed to create account: %v", err)
+	}
+
+	isin := "US0378331005"
+	ids := make([]string, n)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("%s-%d", prefix, i)
+		tx := &models.Transaction{
+			ID:              ids[i],
+			AccountID:       account.ID,
+			Timestamp:       time.Date(2024, 1, i+1, 10, 0, 0, 0, time.UTC).Format(time.RFC3339),
+			Title:           "Apple",
+			AmountCurrency:  "EUR",
+			AmountValue:     -150,
+			TransactionType: "buy",
+			ISIN:            &isin,
+			Quantity:        1,
+			Tags:            models.Tags{"moved"},
+		}
+		if err := db.CreateTransaction(tx, platform); err != nil {
+			t.Fatalf("Failed to create transaction: %v", err)
+		}
+	}
+	return account, ids
+}
+
+func TestMoveTransactions_SamePlatform(t *testing.T) {
+	db := NewTestDB(t)
+	ctx := context.Background()
+
+	from, ids := createMoveFixture(t, db, "traderepublic", "tx-move-same", 3)
+	to, _ := createMoveFixture(t, db, "traderepublic", "tx-move-same-target", 0)
+
+	// An unknown transaction aborts the whole move
+	if _, err := db.MoveTransactions(ctx, []string{ids[0], "tx-unknown"}, from.ID, to.ID); !errors.Is(err, ErrMoveTransactionNotFound) {
+		t.Fatalf("expected ErrMoveTransactionNotFound, got %v", err)
+	}
+	if moved, _ := db.GetTransactionByID(ids[0], "traderepublic"); moved == nil || moved.AccountID != from.ID {
+		t.Fatal("expected nothing to be moved after a failed move")
+	}
+
+	result, err := db.MoveTransactions(ctx, ids[:2], from.ID, to.ID)
+	if err != nil {
+		t.Fatalf("MoveTransactions() error = %v", err)
+	}
+	if result.Moved != 2 || result.FromPlatform != "traderepublic" || result.ToPlatform != "traderepublic" {
+		t.Errorf("unexpected result: %+v", result)
+	}
+
+	for i, id := range ids {
+		transaction, err := db.GetTransactionByID(id, "traderepublic")
+		if err != nil {
+			t.Fatalf("Failed to get transaction: %v", err)
+		}
+		expected := to.ID
+		if i == 2 {
+			expected = from.ID
+		}
+		if transaction.AccountID != expected {
+			t.Errorf("transaction %s: expected account %s, got %s", id, expected, transaction.AccountID)
+		}
+	}
+}
+
+func TestMoveTransactions_CrossPlatform(t *testing.T) {
+	db := NewTestDB(t)
+	ctx := context.Background()
+
+	from, ids := createMoveFixture(t, db, "traderepublic", "tx-move-cross", 2)
+	to, _ := createMoveFixture(t, db, "boursedirect", "tx-move-cross-target", 0)
+
+	result, err := db.MoveTransactions(ctx, ids, from.ID, to.ID)
+	if err != nil {
+		t.Fatalf("MoveTransactions() error = %v", err)
+	}
+	if result.Moved != 2 || result.ToPlatform != "boursedirect" {
+		t.Errorf("unexpected result: %+v", result)
+	}
+
+	for _, id := range ids {
+		if _, err := db.GetTransactionByID(id, "traderepublic"); err == nil {
+			t.Errorf("transaction %s should have left the source platform table", id)
+		}
+		moved, err := db.GetTransactionByID(id, "boursedirect")
+		if err != nil {
+			t.Fatalf("Failed to get moved transaction: %v", err)
+		}
+		if moved.AccountID != to.ID || moved.ISIN == nil || *moved.ISIN != "US0378331005" || moved.Quantity != 1 || len(moved.Tags) != 1 {
+			t.Errorf("expected the transaction to be copied as is, got %+v", moved)
+		}
+	}
+
+	// Crypto accounts identify assets by symbol: ISIN keyed transactions cannot go there
+	crypto, _ := createMoveFixture(t, db, "binance", "tx-move-crypto", 0)
+	if _, err := db.MoveTransactions(ctx, ids, to.ID, crypto.ID); !errors.Is(err, ErrMoveIncompatiblePlatforms) {
+		t.Errorf("expected ErrMoveIncompatiblePlatforms, got %v", err)
+	}
+	if _, err := db.MoveTransactions(ctx, ids, to.ID, "00000000-0000-0000-0000-000000000000"); !errors.Is(err, ErrMoveAccountNotFound) {
+		t.Errorf("expected ErrMoveAccountNotFound, got %v", err)
+	}
+}
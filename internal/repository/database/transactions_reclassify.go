@@ -0,0 +1,126 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"valhafin/internal/domain/models"
+)
+
+// reclassifyPlatform is the only platform whose types are inferred from keywords: the other
+// platforms carry explicit types that the Trade Republic keywords would only corrupt
+const reclassifyPlatform = "traderepublic"
+
+// ErrReclassifyUnsupported is returned when reclassifying the transactions of another platform
+var ErrReclassifyUnsupported = errors.New("only Trade Republic transactions can be reclassified")
+
+// ReclassifyOptions selects the transactions reclassified by ReclassifyTransactions
+type ReclassifyOptions struct {
+	Platform string
+	// AccountID limits the reclassification to one account of the platform when set
+	AccountID string
+	// DryRun reports the changes without writing them
+	DryRun bool
+}
+
+// ReclassifyResult reports the outcome of ReclassifyTransactions
+type ReclassifyResult struct {
+	Platform  string `json:"platform"`
+	AccountID string `json:"account_id,omitempty"`
+	DryRun    bool   `json:"dry_run"`
+	Examined  int    `json:"examined"`
+	// Updated counts the rows whose type changed, or would change in a dry run
+	Updated int `json:"updated"`
+	// Changes counts the updated rows by "old->new" type
+	Changes map[string]int `json:"changes"`
+}
+
+// reclassifyCandidate holds the fields the type is inferred from
+type reclassifyCandidate struct {
+	ID              string
+	Title           string
+	Subtitle        string
+	Icon            string
+	TransactionType string
+}
+
+// ReclassifyTransactions infers again the type of the stored Trade Republic transactions (of
+// one account when opts.AccountID is set) with the current keyword mappings and updates the
+// rows whose type changed, in a single transaction. Only a keyword match changes a type: rows
+// for which no keyword matches keep their type, so that explicit types (e.g. a fee or a
+// withdrawal from a CSV import) are never guessed again from the amount. Running it twice
+// changes nothing, and opts.DryRun only reports the changes.
+func (db *DB) ReclassifyTransactions(ctx context.Context, opts ReclassifyOptions) (ReclassifyResult, error) {
+	tableName, err := getTransactionTableName(opts.Platform)
+	if err != nil {
+		return ReclassifyResult{}, err
+	}
+	if opts.Platform != reclassifyPlatform {
+		return ReclassifyResult{}, fmt.Errorf("%w: %q", ErrReclassifyUnsupported, opts.Platform)
+	}
+
+	var result ReclassifyResult
+	err = db.InTransaction(ctx, func(tx *sql.Tx) error {
+		result = ReclassifyResult{Platform: opts.Platform, AccountID: opts.AccountID, DryRun: opts.DryRun, Changes: make(map[string]int)}
+
+		rows, err := tx.QueryContext(ctx, fmt.Sprintf(`
+			SELECT id, COALESCE(title, ''), COALESCE(subtitle, ''), COALESCE(icon, ''), COALESCE(transaction_type, '')
+			FROM %s
+			WHERE ($1 = '' OR account_id::text = $1)
+			FOR UPDATE
+		`, tableName), opts.AccountID)
+		if err != nil {
+			return fmt.Errorf("failed to read transactions: %w", err)
+		}
+
+		var candidates []reclassifyCandidate
+		for rows.Next() {
+			var c reclassifyCandidate
+			if err := rows.Scan(&c.ID, &c.Title, &c.Subtitle, &c.Icon, &c.TransactionType); err != nil {
+				rows.Close()
+				return fmt.Errorf("failed to scan transaction: %w", err)
+			}
+			candidates = append(candidates, c)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("failed to read transactions: %w", err)
+		}
+
+		result.Examined = len(candidates)
+		for _, c := range candidates {
+			inferred, changed := reclassifiedType(c)
+			if !changed {
+				continue
+			}
+			result.Updated++
+			result.Changes[c.TransactionType+"->"+inferred]++
+			if opts.DryRun {
+				continue
+			}
+
+			query := fmt.Sprintf(`UPDATE %s SET transaction_type = $1 WHERE id = $2`, tableName)
+			if _, err := tx.ExecContext(ctx, query, inferred, c.ID); err != nil {
+				return fmt.Errorf("failed to update transaction %s: %w", c.ID, err)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return ReclassifyResult{}, err
+	}
+
+	return result, nil
+}
+
+// reclassifiedType returns the type matched by the keywords of the candidate and whether it
+// replaces the stored one
+func reclassifiedType(c reclassifyCandidate) (string, bool) {
+	inferred := models.KeywordTransactionType(c.Icon, c.Title, c.Subtitle)
+	if inferred == "" {
+		return "", false
+	}
+	return inferred, inferred != c.TransactionType
+}
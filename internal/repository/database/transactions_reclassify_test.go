@@ -0,0 +1,95 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"valhafin/internal/domain/models"
+)
+
+func TestReclassifiedType(t *testing.T) {
+	tests := []struct {
+		name      string
+		candidate reclassifyCandidate
+		want      string
+		changed   bool
+	}{
+		{"mistyped buy", reclassifyCandidate{Title: "Apple", Subtitle: "Ordre de vente", TransactionType: "buy"}, "sell", true},
+		{"already typed", reclassifyCandidate{Title: "Intérêts", TransactionType: "interest"}, "interest", false},
+		{"no rule keeps the stored type", reclassifyCandidate{Title: "?", TransactionType: "deposit"}, "", false},
+		{"fee is not guessed as a buy", reclassifyCandidate{Title: "Frais de garde", TransactionType: "fee"}, "fee", false},
+		{"withdrawal without keyword is kept", reclassifyCandidate{Title: "Jean Dupont", TransactionType: "withdrawal"}, "", false},
+		{"untyped row without keyword", reclassifyCandidate{Title: "?"}, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, changed := reclassifiedType(tt.candidate)
+			if changed != tt.changed || (changed && got != tt.want) {
+				t.Errorf("reclassifiedType() = %q, %v, want %q, %v", got, changed, tt.want, tt.changed)
+			}
+		})
+	}
+}
+
+func TestReclassifyTransactions(t *testing.T) {
+	db := NewTestDB(t)
+
+	account := &models.Account{Name: "Reclassify", Platform: "traderepublic", Credentials: "encrypted"}
+	if err := db.CreateAccount(account); err != nil {
+		t.Fatalf("Failed to create account: %v", err)
+	}
+
+	for i, txType := range []string{"buy", "sell"} {
+		tx := &models.Transaction{
+			ID:              []string{"tx-reclassify-1", "tx-reclassify-2"}[i],
+			AccountID:       account.ID,
+			Timestamp:       time.Now().Format(time.RFC3339),
+			Title:           "Apple",
+			Subtitle:        "Ordre de vente",
+			AmountCurrency:  "EUR",
+			AmountValue:     150,
+			TransactionType: txType,
+		}
+		if err := db.CreateTransaction(tx, "traderepublic"); err != nil {
+			t.Fatalf("Failed to create transaction: %v", err)
+		}
+	}
+
+	// A dry run reports the change without writing it
+	result, err := db.ReclassifyTransactions(context.Background(), ReclassifyOptions{Platform: "traderepublic", AccountID: account.ID, DryRun: true})
+	if err != nil {
+		t.Fatalf("ReclassifyTransactions() error = %v", err)
+	}
+	if !result.DryRun || result.Updated != 1 {
+		t.Errorf("expected the dry run to report one change, got %+v", result)
+	}
+	if stored, _ := db.GetTransactionByID("tx-reclassify-1", "traderepublic"); stored == nil || stored.TransactionType != "buy" {
+		t.Error("expected the dry run to leave the transaction unchanged")
+	}
+
+	result, err = db.ReclassifyTransactions(context.Background(), ReclassifyOptions{Platform: "traderepublic", AccountID: account.ID})
+	if err != nil {
+		t.Fatalf("ReclassifyTransactions() error = %v", err)
+	}
+	if result.Examined != 2 || result.Updated != 1 || result.Changes["buy->sell"] != 1 {
+		t.Errorf("expected the mistyped buy to become a sell, got %+v", result)
+	}
+
+	// A second run finds nothing to change
+	result, err = db.ReclassifyTransactions(context.Background(), ReclassifyOptions{Platform: "traderepublic", AccountID: account.ID})
+	if err != nil {
+		t.Fatalf("ReclassifyTransactions() error = %v", err)
+	}
+	if result.Updated != 0 {
+		t.Errorf("expected no change on the second run, got %+v", result)
+	}
+
+	if _, err := db.ReclassifyTransactions(context.Background(), ReclassifyOptions{Platform: "kraken"}); err == nil {
+		t.Error("expected an unknown platform to be rejected")
+	}
+	if _, err := db.ReclassifyTransactions(context.Background(), ReclassifyOptions{Platform: "boursedirect"}); !errors.Is(err, ErrReclassifyUnsupported) {
+		t.Errorf("expected ErrReclassifyUnsupported for another platform, got %v", err)
+	}
+}
@@ -0,0 +1,120 @@
+package database
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"valhafin/internal/domain/models"
+)
+
+// ErrInvalidCursor is returned for a stream cursor that was not produced by the stream
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// TransactionCursor is a position in the transactions stream. Rows are ordered by the ID of
+// the database transaction that last wrote them (change_xid), then by row ID. Unlike
+// updated_at, which is the start time of the writing transaction, this order can be held
+// back until every earlier writer has committed, so a slow commit is never skipped.
+// Since keeps the starting date requested by the client for the following pages.
+type TransactionCursor struct {
+	ChangeXID uint64
+	ID        string
+	Since     time.Time
+}
+
+// Encode returns the opaque representation of the cursor sent to clients
+func (c TransactionCursor) Encode() string {
+	raw := strconv.FormatUint(c.ChangeXID, 10) + "|" + c.Since.UTC().Format(time.RFC3339Nano) + "|" + c.ID
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// ParseTransactionCursor decodes a cursor returned by Encode. Cursors of the former
+// timestamp based format are rejected: clients restart from a since date.
+func ParseTransactionCursor(value string) (TransactionCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return TransactionCursor{}, ErrInvalidCursor
+	}
+
+	parts := strings.SplitN(string(raw), "|", 3)
+	if len(parts) != 3 {
+		return TransactionCursor{}, ErrInvalidCursor
+	}
+
+	changeXID, err := strconv.ParseUint(parts[0], 10, 64)
+	if err != nil {
+		return TransactionCursor{}, ErrInvalidCursor
+	}
+	since, err := time.Parse(time.RFC3339Nano, parts[1])
+	if err != nil {
+		return TransactionCursor{}, ErrInvalidCursor
+	}
+
+	return TransactionCursor{ChangeXID: changeXID, ID: parts[2], Since: since}, nil
+}
+
+// GetTransactionsChangedSince returns up to limit transactions of all platforms inserted or
+// modified after the cursor, oldest change first. Soft-deleted rows are included so that
+// clients learn about deletions. Each table is read with a keyset query on (change_xid, id)
+// backed by an index, so the cost does not grow with the position in the stream. Rows written
+// by transactions at or after the oldest one still running are held back: they are returned
+// once that transaction ends, so they can never fall behind a cursor already handed out.
+func (db *DB) GetTransactionsChangedSince(ctx context.Context, cursor TransactionCursor, accountIDs []string, limit int) ([]models.Transaction, error) {
+	ctx, cancel := db.withQueryTimeout(ctx)
+	defer cancel()
+
+	filter := TransactionFilter{AccountIDs: accountIDs}
+
+	var changed []models.Transaction
+	for _, platform := range models.PlatformIDs() {
+		tableName, err := getTransactionTableName(platform)
+		if err != nil {
+			return nil, err
+		}
+
+		args := []interface{}{strconv.FormatUint(cursor.ChangeXID, 10), cursor.ID, cursor.Since}
+		query := fmt.Sprintf(`
+			SELECT
+				id, account_id, timestamp, title, icon, avatar, subtitle,
+				amount_currency, amount_value, amount_fraction, status,
+				action_type, action_payload, cash_account_number, hidden, deleted,
+				actions, dividend_per_share, taxes, total, shares, share_price,
+				fees, amount, isin, quantity, transaction_type, metadata, tags, note, updated_at, change_xid
+			FROM %s
+			WHERE (change_xid, id) > ($1::xid8, $2)
+			  AND change_xid < pg_snapshot_xmin(pg_current_snapshot())
+			  AND updated_at >= $3
+			  AND (subtitle IS NULL OR subtitle != 'Échec du plan d''épargne')
+		`, tableName)
+
+		condition, conditionArgs := filter.accountIDsCondition("account_id", args)
+		query += condition
+		args = conditionArgs
+
+		args = append(args, limit)
+		query += fmt.Sprintf(" ORDER BY change_xid, id LIMIT $%d", len(args))
+
+		var transactions []models.Transaction
+		if err := db.SelectContext(ctx, &transactions, query, args...); err != nil {
+			return nil, fmt.Errorf("failed to get changed transactions from %s: %w", platform, err)
+		}
+		changed = append(changed, transactions...)
+	}
+
+	// Merge the platforms in stream order and keep the first page
+	sort.Slice(changed, func(i, j int) bool {
+		if changed[i].ChangeXID != changed[j].ChangeXID {
+			return changed[i].ChangeXID < changed[j].ChangeXID
+		}
+		return changed[i].ID < changed[j].ID
+	})
+	if len(changed) > limit {
+		changed = changed[:limit]
+	}
+
+	return changed, nil
+}
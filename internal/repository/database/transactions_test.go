@@ -0,0 +1,302 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+	"valhafin/internal/domain/models"
+)
+
+func TestTransactionFilterDeletedCondition(t *testing.T) {
+	if got := (TransactionFilter{}).deletedCondition("t.deleted"); got != " AND t.deleted IS NOT TRUE" {
+		t.Errorf("default filter should exclude deleted transactions, got %q", got)
+	}
+	if got := (TransactionFilter{IncludeDeleted: true}).deletedCondition("deleted"); got != "" {
+		t.Errorf("IncludeDeleted should not add a condition, got %q", got)
+	}
+}
+
+func TestTransactionFilterHiddenCondition(t *testing.T) {
+	if got := (TransactionFilter{}).hiddenCondition("t.hidden"); got != " AND t.hidden IS NOT TRUE" {
+		t.Errorf("default filter should exclude hidden transactions, got %q", got)
+	}
+	if got := (TransactionFilter{IncludeHidden: true}).hiddenCondition("hidden"); got != "" {
+		t.Errorf("IncludeHidden should not add a condition, got %q", got)
+	}
+}
+
+func TestTransactionFilterAccountIDsCondition(t *testing.T) {
+	if condition, args := (TransactionFilter{}).accountIDsCondition("t.account_id", nil); condition != "" || len(args) != 0 {
+		t.Errorf("empty selection should not add a condition, got %q %v", condition, args)
+	}
+
+	filter := TransactionFilter{AccountIDs: []string{"a", "b"}}
+	condition, args := filter.accountIDsCondition("t.account_id", []interface{}{"first"})
+	if condition != " AND t.account_id IN ($2, $3)" {
+		t.Errorf("unexpected condition %q", condition)
+	}
+	if len(args) != 3 || args[1] != "a" || args[2] != "b" {
+		t.Errorf("unexpected args %v", args)
+	}
+}
+
+func TestGetTransactionTableName_RejectsUnknownPlatform(t *testing.T) {
+	for _, platform := range models.PlatformIDs() {
+		if _, err := getTransactionTableName(platform); err != nil {
+			t.Errorf("platform %s should be supported: %v", platform, err)
+		}
+	}
+
+	if _, err := getTransactionTableName("traderepubic"); !errors.Is(err, ErrUnsupportedPlatform) {
+		t.Errorf("expected ErrUnsupportedPlatform for a misspelled platform, got %v", err)
+	}
+
+	// Unknown platforms are rejected before the database is used
+	db := &DB{}
+	isin := "US0378331005"
+	transaction := &models.Transaction{
+		ID:             "tx-1",
+		AccountID:      "account-1",
+		Timestamp:      "2024-01-15T10:00:00Z",
+		ISIN:           &isin,
+		AmountValue:    -100,
+		AmountCurrency: "EUR",
+		Fees:           "0",
+	}
+	if err := db.CreateTransaction(transaction, "degiro"); !errors.Is(err, ErrUnsupportedPlatform) {
+		t.Errorf("CreateTransaction: expected ErrUnsupportedPlatform, got %v", err)
+	}
+	if err := db.CreateTransactionsBatch([]models.Transaction{*transaction}, "degiro"); !errors.Is(err, ErrUnsupportedPlatform) {
+		t.Errorf("CreateTransactionsBatch: expected ErrUnsupportedPlatform, got %v", err)
+	}
+	if _, err := db.CountTransactionsContext(context.Background(), "degiro", TransactionFilter{}); !errors.Is(err, ErrUnsupportedPlatform) {
+		t.Errorf("CountTransactions: expected ErrUnsupportedPlatform, got %v", err)
+	}
+	if _, err := db.GetTransactionByIDContext(context.Background(), "tx-1", "degiro"); !errors.Is(err, ErrUnsupportedPlatform) {
+		t.Errorf("GetTransactionByID: expected ErrUnsupportedPlatform, got %v", err)
+	}
+}
+
+func TestTransactionCursorRoundTrip(t *testing.T) {
+	cursor := TransactionCursor{ChangeXID: 123456, ID: "tx|with|pipes", Since: time.Date(2024, 3, 1, 10, 0, 0, 123456000, time.UTC)}
+
+	parsed, err := ParseTransactionCursor(cursor.Encode())
+	if err != nil {
+		t.Fatalf("ParseTransactionCursor() error = %v", err)
+	}
+	if parsed.ChangeXID != cursor.ChangeXID || parsed.ID != cursor.ID || !parsed.Since.Equal(cursor.Since) {
+		t.Errorf("round trip gave %+v, want %+v", parsed, cursor)
+	}
+
+	// The former "updated_at|id" cursors are rejected
+	legacy := base64.RawURLEncoding.EncodeToString([]byte("2024-03-01T10:00:00Z|tx-1"))
+	for _, value := range []string{"not base64!", "bm8tc2VwYXJhdG9y", "bm90LWEtZGF0ZXxpZA", legacy} {
+		if _, err := ParseTransactionCursor(value); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("ParseTransactionCursor(%q) error = %v, want ErrInvalidCursor", value, err)
+		}
+	}
+}
+
+func TestGetTransactionsChangedSince_WaitsForOpenTransactions(t *testing.T) {
+	db := NewTestDB(t)
+
+	account := &models.Account{Name: "Stream commit order", Platform: "traderepublic", Credentials: "encrypted"}
+	if err := db.CreateAccount(account); err != nil {
+		t.Fatalf("Failed to create account: %v", err)
+	}
+	ctx := context.Background()
+	insert := `INSERT INTO transactions_traderepublic (id, account_id, timestamp, title) VALUES ($1, $2, '2024-01-01T10:00:00Z', 'Stream')`
+
+	// A slow writer starts first and commits last
+	slow, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatalf("Failed to begin transaction: %v", err)
+	}
+	defer slow.Rollback()
+	if _, err := slow.ExecContext(ctx, insert, "tx-slow", account.ID); err != nil {
+		t.Fatalf("Failed to insert slow transaction: %v", err)
+	}
+	if _, err := db.ExecContext(ctx, insert, "tx-fast", account.ID); err != nil {
+		t.Fatalf("Failed to insert fast transaction: %v", err)
+	}
+
+	// The fast row is held back while the slow writer is running, so that a cursor handed
+	// out now cannot move past the slow row
+	changed, err := db.GetTransactionsChangedSince(ctx, TransactionCursor{}, []string{account.ID}, 10)
+	if err != nil {
+		t.Fatalf("GetTransactionsChangedSince() error = %v", err)
+	}
+	if len(changed) != 0 {
+		t.Fatalf("expected no transaction while a writer is running, got %d", len(changed))
+	}
+
+	if err := slow.Commit(); err != nil {
+		t.Fatalf("Failed to commit: %v", err)
+	}
+	changed, err = db.GetTransactionsChangedSince(ctx, TransactionCursor{}, []string{account.ID}, 10)
+	if err != nil {
+		t.Fatalf("GetTransactionsChangedSince() error = %v", err)
+	}
+	if len(changed) != 2 || changed[0].ID != "tx-slow" || changed[1].ID != "tx-fast" {
+		t.Errorf("expected the slow then the fast transaction, got %+v", changed)
+	}
+}
+
+func TestCreateTransaction_UsesPlatformAssetType(t *testing.T) {
+	db := NewTestDB(t)
+
+	account := &models.Account{Name: "Single crypto", Platform: "binance", Credentials: "encrypted"}
+	if err := db.CreateAccount(account); err != nil {
+		t.Fatalf("Failed to create account: %v", err)
+	}
+	symbol := "DOTTEST"
+	transaction := &models.Transaction{
+		ID:             "tx-single-crypto",
+		AccountID:      account.ID,
+		Timestamp:      "2024-01-15T10:00:00Z",
+		Title:          "Polkadot",
+		AmountValue:    -50,
+		AmountCurrency: "EUR",
+		ISIN:           &symbol,
+		Quantity:       10,
+	}
+	if err := db.CreateTransaction(transaction, "binance"); err != nil {
+		t.Fatalf("CreateTransaction() error = %v", err)
+	}
+
+	// Like a batch import, the asset is a crypto keyed by its symbol
+	asset, err := db.GetAssetByISIN(symbol)
+	if err != nil {
+		t.Fatalf("GetAssetByISIN() error = %v", err)
+	}
+	if asset.Type != "crypto" || asset.Symbol == nil || *asset.Symbol != symbol {
+		t.Errorf("expected a crypto asset with symbol %s, got type %s and symbol %v", symbol, asset.Type, asset.Symbol)
+	}
+}
+
+func TestUpsertTransactionsBatchPartial_PoisonedRow(t *testing.T) {
+	db := NewTestDB(t)
+
+	account := &models.Account{Name: "Partial batch", Platform: "traderepublic", Credentials: "encrypted"}
+	if err := db.CreateAccount(account); err != nil {
+		t.Fatalf("Failed to create account: %v", err)
+	}
+
+	transaction := func(id, title string) models.Transaction {
+		return models.Transaction{
+			ID:              id,
+			AccountID:       account.ID,
+			Timestamp:       "2024-01-15T10:00:00Z",
+			Title:           title,
+			AmountCurrency:  "EUR",
+			AmountValue:     100,
+			TransactionType: "deposit",
+		}
+	}
+	// The title exceeds its column: the row passes validation but cannot be inserted
+	poisoned := transaction("tx-partial-2", strings.Repeat("x", 300))
+	invalid := transaction("tx-partial-4", "Dépôt")
+	invalid.Timestamp = "15/01/2024"
+	batch := []models.Transaction{
+		transaction("tx-partial-1", "Dépôt"),
+		poisoned,
+		transaction("tx-partial-3", "Dépôt"),
+		invalid,
+	}
+
+	// The all-or-nothing batch rejects everything
+	if _, err := db.UpsertTransactionsBatch(batch[:3], account.Platform); err == nil {
+		t.Fatal("expected UpsertTransactionsBatch to fail on the poisoned row")
+	}
+	stored, err := db.GetTransactionsByAccount(account.ID, account.Platform, TransactionFilter{})
+	if err != nil {
+		t.Fatalf("GetTransactionsByAccount() error = %v", err)
+	}
+	if len(stored) != 0 {
+		t.Fatalf("expected no transaction after the failed batch, got %d", len(stored))
+	}
+
+	changes, failures, err := db.UpsertTransactionsBatchPartial(batch, account.Platform)
+	if err != nil {
+		t.Fatalf("UpsertTransactionsBatchPartial() error = %v", err)
+	}
+	if len(changes.Inserted) != 2 || changes.Status("tx-partial-1") != models.TransactionInserted || changes.Status("tx-partial-3") != models.TransactionInserted {
+		t.Errorf("expected the valid rows to be inserted, got %+v", changes)
+	}
+	if len(failures) != 2 || failures[0].ID != "tx-partial-2" || failures[1].ID != "tx-partial-4" || failures[0].Error == "" {
+		t.Fatalf("expected the poisoned and invalid rows to be reported, got %+v", failures)
+	}
+
+	stored, err = db.GetTransactionsByAccount(account.ID, account.Platform, TransactionFilter{})
+	if err != nil {
+		t.Fatalf("GetTransactionsByAccount() error = %v", err)
+	}
+	if len(stored) != 2 {
+		t.Errorf("expected the 2 valid transactions to be committed, got %d", len(stored))
+	}
+}
+
+func TestCreateTransactionsBatch_LeavesCallerSliceUntouched(t *testing.T) {
+	db := NewTestDB(t)
+
+	account := &models.Account{Name: "Untouched batch", Platform: "traderepublic", Credentials: "encrypted"}
+	if err := db.CreateAccount(account); err != nil {
+		t.Fatalf("Failed to create account: %v", err)
+	}
+
+	metadata := "not json"
+	batch := []models.Transaction{{
+		ID:              "tx-untouched-1",
+		AccountID:       account.ID,
+		Timestamp:       "2024-01-15T10:00:00Z",
+		Title:           "Dépôt",
+		AmountCurrency:  "EUR",
+		AmountValue:     100.005,
+		TransactionType: "deposit",
+		Metadata:        &metadata,
+		Tags:            models.Tags{" Savings ", "savings"},
+		Note:            "  monthly  ",
+	}}
+
+	if err := db.CreateTransactionsBatch(batch, account.Platform); err != nil {
+		t.Fatalf("CreateTransactionsBatch() error = %v", err)
+	}
+
+	got := batch[0]
+	if got.AmountValue != 100.005 || got.Metadata != &metadata || metadata != "not json" ||
+		len(got.Tags) != 2 || got.Tags[0] != " Savings " || got.Note != "  monthly  " {
+		t.Errorf("expected the caller's transaction to be left as is, got %+v", got)
+	}
+
+	stored, err := db.GetTransactionsByAccount(account.ID, account.Platform, TransactionFilter{})
+	if err != nil {
+		t.Fatalf("GetTransactionsByAccount() error = %v", err)
+	}
+	if len(stored) != 1 || len(stored[0].Tags) != 1 || stored[0].Tags[0] != "savings" || stored[0].Note != "monthly" {
+		t.Errorf("expected the stored transaction to be normalized, got %+v", stored)
+	}
+}
+
+func TestFindTransactionByID_SearchesEveryPlatform(t *testing.T) {
+	db := NewTestDB(t)
+
+	for _, platform := range models.PlatformIDs() {
+		account, ids := createMoveFixture(t, db, platform, "tx-find-"+platform, 1)
+
+		found, foundPlatform, err := db.FindTransactionByID(ids[0])
+		if err != nil {
+			t.Fatalf("%s: FindTransactionByID() error = %v", platform, err)
+		}
+		if found.ID != ids[0] || found.AccountID != account.ID || foundPlatform != platform {
+			t.Errorf("%s: found %s of account %s in %s", platform, found.ID, found.AccountID, foundPlatform)
+		}
+	}
+
+	if _, _, err := db.FindTransactionByID("tx-find-missing"); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("expected sql.ErrNoRows for an unknown transaction, got %v", err)
+	}
+}
@@ -0,0 +1,164 @@
+package alert
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"time"
+	"valhafin/internal/domain/models"
+	"valhafin/internal/service/performance"
+)
+
+// AlertStore defines the database operations needed to evaluate alerts
+type AlertStore interface {
+	GetEnabledAlerts() ([]models.Alert, error)
+	UpdateAlertTriggerState(alertID string, triggered bool, lastTriggeredAt *time.Time) error
+}
+
+// Service evaluates alert rules against the current portfolio
+type Service struct {
+	store       AlertStore
+	performance performance.Service
+	notifier    Notifier
+}
+
+// NewService creates a new alert service
+func NewService(store AlertStore, performanceService performance.Service, notifier Notifier) *Service {
+	return &Service{
+		store:       store,
+		performance: performanceService,
+		notifier:    notifier,
+	}
+}
+
+// EvaluateAlerts checks every enabled alert and notifies the ones whose condition just started to hold.
+// An alert fires once when its condition becomes true and is re-armed when the condition clears.
+func (s *Service) EvaluateAlerts() error {
+	alerts, err := s.store.GetEnabledAlerts()
+	if err != nil {
+		return fmt.Errorf("failed to get alerts: %w", err)
+	}
+
+	if len(alerts) == 0 {
+		return nil
+	}
+
+	var global *performance.Performance
+	fired := 0
+
+	for _, alert := range alerts {
+		value, triggered, err := s.evaluate(alert, &global)
+		if err != nil {
+			log.Printf("WARNING: Failed to evaluate alert %s: %v", alert.ID, err)
+			continue
+		}
+
+		switch {
+		case triggered && !alert.Triggered:
+			now := time.Now()
+			event := Event{
+				AlertID:     alert.ID,
+				Type:        alert.Type,
+				Threshold:   alert.Threshold,
+				Value:       value,
+				Message:     alertMessage(alert, value),
+				TriggeredAt: now,
+			}
+			if alert.ISIN != nil {
+				event.ISIN = *alert.ISIN
+			}
+
+			if err := s.notifier.Notify(event); err != nil {
+				// Keep the alert armed so it is retried on the next run
+				log.Printf("ERROR: Failed to notify alert %s: %v", alert.ID, err)
+				continue
+			}
+
+			if err := s.store.UpdateAlertTriggerState(alert.ID, true, &now); err != nil {
+				log.Printf("ERROR: Failed to update alert %s: %v", alert.ID, err)
+			}
+			fired++
+		case !triggered && alert.Triggered:
+			// Condition cleared: re-arm the alert
+			if err := s.store.UpdateAlertTriggerState(alert.ID, false, nil); err != nil {
+				log.Printf("ERROR: Failed to update alert %s: %v", alert.ID, err)
+			}
+		}
+	}
+
+	log.Printf("🔔 Evaluated %d alerts, %d fired", len(alerts), fired)
+	return nil
+}
+
+// evaluate returns the observed value for an alert and whether its condition holds
+// The global performance is computed lazily and shared between alerts of the same run
+func (s *Service) evaluate(alert models.Alert, global **performance.Performance) (float64, bool, error) {
+	getGlobal := func() (*performance.Performance, error) {
+		if *global == nil {
+			perf, err := s.performance.CalculateGlobalPerformance("all")
+			if err != nil {
+				return nil, err
+			}
+			*global = perf
+		}
+		return *global, nil
+	}
+
+	switch alert.Type {
+	case models.AlertTypeNegativeCash:
+		perf, err := getGlobal()
+		if err != nil {
+			return 0, false, err
+		}
+		return perf.CashBalance, perf.CashBalance < alert.Threshold, nil
+
+	case models.AlertTypeUnrealizedLoss:
+		var value, costBasis float64
+		if alert.ISIN != nil && *alert.ISIN != "" {
+			assetPerf, err := s.performance.CalculateAssetPerformance(*alert.ISIN, "all")
+			if err != nil {
+				return 0, false, err
+			}
+			if assetPerf.TotalQuantity <= 0 {
+				return 0, false, nil
+			}
+			value = assetPerf.TotalValue
+			costBasis = math.Abs(assetPerf.TotalInvested)
+		} else {
+			perf, err := getGlobal()
+			if err != nil {
+				return 0, false, err
+			}
+			costBasis = math.Abs(perf.TotalInvested)
+			value = costBasis + perf.UnrealizedGains
+		}
+
+		lossPct := unrealizedLossPct(value, costBasis)
+		return lossPct, lossPct >= alert.Threshold, nil
+	}
+
+	return 0, false, fmt.Errorf("unsupported alert type: %s", alert.Type)
+}
+
+// unrealizedLossPct returns the unrealized loss as a positive percentage of the cost basis
+func unrealizedLossPct(value, costBasis float64) float64 {
+	if costBasis <= 0 {
+		return 0
+	}
+	return (costBasis - value) / costBasis * 100
+}
+
+// alertMessage builds a human readable description of a fired alert
+func alertMessage(alert models.Alert, value float64) string {
+	switch alert.Type {
+	case models.AlertTypeNegativeCash:
+		return fmt.Sprintf("Cash balance %.2f is below %.2f", value, alert.Threshold)
+	case models.AlertTypeUnrealizedLoss:
+		target := "Portfolio"
+		if alert.ISIN != nil && *alert.ISIN != "" {
+			target = *alert.ISIN
+		}
+		return fmt.Sprintf("%s unrealized loss %.2f%% exceeds %.2f%%", target, value, alert.Threshold)
+	}
+	return fmt.Sprintf("Alert %s triggered", alert.ID)
+}
@@ -0,0 +1,215 @@
+package alert
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"valhafin/internal/domain/models"
+	"valhafin/internal/service/performance"
+)
+
+// mockAlertStore is an in-memory implementation of AlertStore
+type mockAlertStore struct {
+	alerts []models.Alert
+}
+
+func (m *mockAlertStore) GetEnabledAlerts() ([]models.Alert, error) {
+	enabled := []models.Alert{}
+	for _, alert := range m.alerts {
+		if alert.Enabled {
+			enabled = append(enabled, alert)
+		}
+	}
+	return enabled, nil
+}
+
+func (m *mockAlertStore) UpdateAlertTriggerState(alertID string, triggered bool, lastTriggeredAt *time.Time) error {
+	for i := range m.alerts {
+		if m.alerts[i].ID == alertID {
+			m.alerts[i].Triggered = triggered
+			if lastTriggeredAt != nil {
+				m.alerts[i].LastTriggeredAt = lastTriggeredAt
+			}
+			return nil
+		}
+	}
+	return errors.New("alert not found")
+}
+
+// mockPerformanceService returns fixed performance metrics
+type mockPerformanceService struct {
+	global *performance.Performance
+	assets map[string]*performance.AssetPerformance
+}
+
+func (m *mockPerformanceService) CalculateAccountPerformance(accountID string, period string) (*performance.Performance, error) {
+	return m.global, nil
+}
+
+func (m *mockPerformanceService) CalculateGlobalPerformance(period string) (*performance.Performance, error) {
+	return m.global, nil
+}
+
+func (m *mockPerformanceService) CalculateAssetPerformance(isin string, period string) (*performance.AssetPerformance, error) {
+	if perf, ok := m.assets[isin]; ok {
+		return perf, nil
+	}
+	return nil, errors.New("asset not found")
+}
+
+func (m *mockPerformanceService) CalculateAccountPerformanceContext(ctx context.Context, accountID string, period string, opts performance.Options) (*performance.Performance, error) {
+	return m.CalculateAccountPerformance(accountID, period)
+}
+
+func (m *mockPerformanceService) CalculateGlobalPerformanceContext(ctx context.Context, period string, opts performance.Options) (*performance.Performance, error) {
+	return m.CalculateGlobalPerformance(period)
+}
+
+func (m *mockPerformanceService) CalculateAssetPerformanceContext(ctx context.Context, isin string, period string, opts performance.Options) (*performance.AssetPerformance, error) {
+	return m.CalculateAssetPerformance(isin, period)
+}
+
+func (m *mockPerformanceService) CalculateAssetHoldingsHistoryContext(ctx context.Context, isin string, period string, opts performance.Options) (*performance.HoldingsHistory, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockPerformanceService) CalculateAccountPerformanceRangeContext(ctx context.Context, accountID string, startDate, endDate time.Time, opts performance.Options) (*performance.Performance, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockPerformanceService) CalculateGlobalPerformanceRangeContext(ctx context.Context, startDate, endDate time.Time, opts performance.Options) (*performance.Performance, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockPerformanceService) CalculateAssetPerformanceRangeContext(ctx context.Context, isin string, startDate, endDate time.Time, opts performance.Options) (*performance.AssetPerformance, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockPerformanceService) CalculateAssetHoldingsHistoryRangeContext(ctx context.Context, isin string, startDate, endDate time.Time, opts performance.Options) (*performance.HoldingsHistory, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockPerformanceService) CalculateAssetLotsContext(ctx context.Context, isin string, opts performance.Options) (*performance.AssetLots, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockPerformanceService) CalculateAccountCashFlowContext(ctx context.Context, accountID string, opts performance.Options) (*performance.CashFlowStatement, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockPerformanceService) CalculateAccountPerformanceAsOfContext(ctx context.Context, accountID string, period string, asOf time.Time, opts performance.Options) (*performance.Performance, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockPerformanceService) CalculateGlobalPerformanceAsOfContext(ctx context.Context, period string, asOf time.Time, opts performance.Options) (*performance.Performance, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockPerformanceService) HistoricalPrice(isin string, date time.Time) (float64, error) {
+	return 0, errors.New("not implemented")
+}
+
+// mockNotifier records notified events
+type mockNotifier struct {
+	events []Event
+	err    error
+}
+
+func (m *mockNotifier) Notify(event Event) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.events = append(m.events, event)
+	return nil
+}
+
+func TestEvaluateAlerts_NegativeCashDeduplication(t *testing.T) {
+	store := &mockAlertStore{alerts: []models.Alert{
+		{ID: "cash", Type: models.AlertTypeNegativeCash, Enabled: true},
+	}}
+	perf := &mockPerformanceService{global: &performance.Performance{CashBalance: -50}}
+	notifier := &mockNotifier{}
+	service := NewService(store, perf, notifier)
+
+	// First run fires, second run with the same condition does not
+	for i := 0; i < 2; i++ {
+		if err := service.EvaluateAlerts(); err != nil {
+			t.Fatalf("EvaluateAlerts() error = %v", err)
+		}
+	}
+	if len(notifier.events) != 1 {
+		t.Fatalf("expected 1 notification, got %d", len(notifier.events))
+	}
+	if !store.alerts[0].Triggered || store.alerts[0].LastTriggeredAt == nil {
+		t.Error("alert should be marked as triggered")
+	}
+
+	// Condition clears: alert is re-armed without notification
+	perf.global.CashBalance = 100
+	if err := service.EvaluateAlerts(); err != nil {
+		t.Fatalf("EvaluateAlerts() error = %v", err)
+	}
+	if store.alerts[0].Triggered {
+		t.Error("alert should be re-armed when the condition clears")
+	}
+
+	// Condition holds again: alert fires again
+	perf.global.CashBalance = -1
+	if err := service.EvaluateAlerts(); err != nil {
+		t.Fatalf("EvaluateAlerts() error = %v", err)
+	}
+	if len(notifier.events) != 2 {
+		t.Errorf("expected 2 notifications, got %d", len(notifier.events))
+	}
+}
+
+func TestEvaluateAlerts_UnrealizedLoss(t *testing.T) {
+	isin := "US0378331005"
+	store := &mockAlertStore{alerts: []models.Alert{
+		{ID: "asset", Type: models.AlertTypeUnrealizedLoss, ISIN: &isin, Threshold: 10, Enabled: true},
+		{ID: "portfolio", Type: models.AlertTypeUnrealizedLoss, Threshold: 10, Enabled: true},
+		{ID: "disabled", Type: models.AlertTypeUnrealizedLoss, ISIN: &isin, Threshold: 1, Enabled: false},
+	}}
+	perf := &mockPerformanceService{
+		// Portfolio: -5% (below threshold)
+		global: &performance.Performance{TotalInvested: 1000, UnrealizedGains: -50},
+		assets: map[string]*performance.AssetPerformance{
+			// Asset: 1000 invested (stored as negative buy amounts), now worth 800 (-20%)
+			isin: {ISIN: isin, TotalQuantity: 10, TotalInvested: -1000, TotalValue: 800},
+		},
+	}
+	notifier := &mockNotifier{}
+	service := NewService(store, perf, notifier)
+
+	if err := service.EvaluateAlerts(); err != nil {
+		t.Fatalf("EvaluateAlerts() error = %v", err)
+	}
+
+	if len(notifier.events) != 1 {
+		t.Fatalf("expected 1 notification, got %d", len(notifier.events))
+	}
+	event := notifier.events[0]
+	if event.AlertID != "asset" || event.ISIN != isin {
+		t.Errorf("unexpected event: %+v", event)
+	}
+	if event.Value != 20 {
+		t.Errorf("expected loss of 20%%, got %.2f%%", event.Value)
+	}
+}
+
+func TestEvaluateAlerts_NotifierFailureKeepsAlertArmed(t *testing.T) {
+	store := &mockAlertStore{alerts: []models.Alert{
+		{ID: "cash", Type: models.AlertTypeNegativeCash, Enabled: true},
+	}}
+	perf := &mockPerformanceService{global: &performance.Performance{CashBalance: -50}}
+	notifier := &mockNotifier{err: errors.New("webhook down")}
+	service := NewService(store, perf, notifier)
+
+	if err := service.EvaluateAlerts(); err != nil {
+		t.Fatalf("EvaluateAlerts() error = %v", err)
+	}
+	if store.alerts[0].Triggered {
+		t.Error("alert should stay armed when notification fails")
+	}
+}
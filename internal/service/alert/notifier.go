@@ -0,0 +1,68 @@
+package alert
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+// Event is the payload sent when an alert fires
+type Event struct {
+	AlertID     string    `json:"alert_id"`
+	Type        string    `json:"type"`
+	ISIN        string    `json:"isin,omitempty"`
+	Threshold   float64   `json:"threshold"`
+	Value       float64   `json:"value"`
+	Message     string    `json:"message"`
+	TriggeredAt time.Time `json:"triggered_at"`
+}
+
+// Notifier delivers alert events
+type Notifier interface {
+	Notify(event Event) error
+}
+
+// WebhookNotifier posts alert events as JSON to a webhook URL
+type WebhookNotifier struct {
+	url        string
+	httpClient *http.Client
+}
+
+// NewWebhookNotifier creates a notifier posting to the given URL
+// When url is empty, events are only logged
+func NewWebhookNotifier(url string) *WebhookNotifier {
+	return &WebhookNotifier{
+		url: url,
+		httpClient: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}
+}
+
+// Notify posts the event to the webhook
+func (n *WebhookNotifier) Notify(event Event) error {
+	if n.url == "" {
+		log.Printf("🔔 Alert %s (webhook not configured): %s", event.AlertID, event.Message)
+		return nil
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal alert event: %w", err)
+	}
+
+	resp, err := n.httpClient.Post(n.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to call webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+
+	return nil
+}
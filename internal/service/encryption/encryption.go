@@ -19,9 +19,21 @@ var (
 	ErrDecryptionFailed = errors.New("decryption failed")
 )
 
+const (
+	// formatVersion1 is the current ciphertext format: version byte + nonce + ciphertext + tag,
+	// with the version header bound to the ciphertext as associated data
+	formatVersion1 byte = 0x01
+)
+
+// associatedData returns the authenticated associated data for a format version
+func associatedData(version byte) []byte {
+	return []byte{'v', 'a', 'l', 'h', 'a', 'f', 'i', 'n', ':', version}
+}
+
 // EncryptionService provides AES-256-GCM encryption and decryption
 type EncryptionService struct {
-	key []byte
+	key          []byte
+	previousKeys [][]byte
 }
 
 // NewEncryptionService creates a new encryption service with the provided key
@@ -36,23 +48,34 @@ func NewEncryptionService(key []byte) (*EncryptionService, error) {
 	}, nil
 }
 
+// NewEncryptionServiceWithPreviousKeys creates an encryption service that encrypts with key
+// and can still decrypt data encrypted with any of the previous keys (key rotation)
+func NewEncryptionServiceWithPreviousKeys(key []byte, previousKeys [][]byte) (*EncryptionService, error) {
+	service, err := NewEncryptionService(key)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, previous := range previousKeys {
+		if len(previous) != 32 {
+			return nil, fmt.Errorf("%w: previous key %d has %d bytes", ErrInvalidKeySize, i, len(previous))
+		}
+		service.previousKeys = append(service.previousKeys, previous)
+	}
+
+	return service, nil
+}
+
 // Encrypt encrypts plaintext using AES-256-GCM
-// Returns base64-encoded string containing: nonce + ciphertext + tag
+// Returns base64-encoded string containing: version + nonce + ciphertext + tag
 func (s *EncryptionService) Encrypt(plaintext string) (string, error) {
 	if plaintext == "" {
 		return "", nil
 	}
 
-	// Create AES cipher block
-	block, err := aes.NewCipher(s.key)
-	if err != nil {
-		return "", fmt.Errorf("failed to create cipher: %w", err)
-	}
-
-	// Create GCM mode
-	gcm, err := cipher.NewGCM(block)
+	gcm, err := newGCM(s.key)
 	if err != nil {
-		return "", fmt.Errorf("failed to create GCM: %w", err)
+		return "", err
 	}
 
 	// Generate random nonce
@@ -62,8 +85,9 @@ func (s *EncryptionService) Encrypt(plaintext string) (string, error) {
 	}
 
 	// Encrypt and append authentication tag
-	// gcm.Seal appends the ciphertext and tag to nonce
-	ciphertext := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+	// gcm.Seal appends the ciphertext and tag to version + nonce
+	header := append([]byte{formatVersion1}, nonce...)
+	ciphertext := gcm.Seal(header, nonce, []byte(plaintext), associatedData(formatVersion1))
 
 	// Encode to base64 for safe storage
 	encoded := base64.StdEncoding.EncodeToString(ciphertext)
@@ -71,7 +95,7 @@ func (s *EncryptionService) Encrypt(plaintext string) (string, error) {
 	return encoded, nil
 }
 
-// Decrypt decrypts a base64-encoded ciphertext using AES-256-GCM
+// Decrypt decrypts a base64-encoded ciphertext using AES-256-GCM with the current key
 // Returns the original plaintext
 func (s *EncryptionService) Decrypt(ciphertext string) (string, error) {
 	if ciphertext == "" {
@@ -84,32 +108,96 @@ func (s *EncryptionService) Decrypt(ciphertext string) (string, error) {
 		return "", fmt.Errorf("failed to decode base64: %w", err)
 	}
 
-	// Create AES cipher block
-	block, err := aes.NewCipher(s.key)
+	plaintext, _, err := decryptWithKey(s.key, data)
 	if err != nil {
-		return "", fmt.Errorf("failed to create cipher: %w", err)
+		return "", err
 	}
 
-	// Create GCM mode
-	gcm, err := cipher.NewGCM(block)
+	return string(plaintext), nil
+}
+
+// DecryptWithFallback decrypts ciphertext with the current key, then with each previous key.
+// usedPreviousKey reports whether the data should be re-encrypted under the current key.
+func (s *EncryptionService) DecryptWithFallback(ciphertext string) (plaintext string, usedPreviousKey bool, err error) {
+	if ciphertext == "" {
+		return "", false, nil
+	}
+
+	data, err := base64.StdEncoding.DecodeString(ciphertext)
 	if err != nil {
-		return "", fmt.Errorf("failed to create GCM: %w", err)
+		return "", false, fmt.Errorf("failed to decode base64: %w", err)
+	}
+
+	result, legacy, err := decryptWithKey(s.key, data)
+	if err == nil {
+		// Legacy (unversioned) ciphertexts are also flagged for re-encryption
+		return string(result), legacy, nil
+	}
+
+	for _, previous := range s.previousKeys {
+		if result, _, prevErr := decryptWithKey(previous, data); prevErr == nil {
+			return string(result), true, nil
+		}
+	}
+
+	return "", false, err
+}
+
+// decryptWithKey decrypts raw ciphertext bytes with the given key.
+// Versioned ciphertexts are tried first, then the legacy nonce + ciphertext format;
+// legacy reports whether the legacy format matched.
+func decryptWithKey(key []byte, data []byte) (plaintext []byte, legacy bool, err error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, false, err
 	}
 
 	// Check minimum length (nonce + at least some data)
 	nonceSize := gcm.NonceSize()
 	if len(data) < nonceSize {
-		return "", ErrInvalidCiphertext
+		return nil, false, ErrInvalidCiphertext
+	}
+
+	if plaintext, err := openVersioned(gcm, data); err == nil {
+		return plaintext, false, nil
 	}
 
-	// Extract nonce and ciphertext
+	// Legacy format: nonce + ciphertext, no associated data
 	nonce, ciphertextBytes := data[:nonceSize], data[nonceSize:]
 
 	// Decrypt and verify authentication tag
-	plaintext, err := gcm.Open(nil, nonce, ciphertextBytes, nil)
+	plaintext, err = gcm.Open(nil, nonce, ciphertextBytes, nil)
 	if err != nil {
-		return "", fmt.Errorf("%w: %v", ErrDecryptionFailed, err)
+		return nil, false, fmt.Errorf("%w: %v", ErrDecryptionFailed, err)
 	}
 
-	return string(plaintext), nil
+	return plaintext, true, nil
+}
+
+// openVersioned decrypts a version + nonce + ciphertext payload
+func openVersioned(gcm cipher.AEAD, data []byte) ([]byte, error) {
+	nonceSize := gcm.NonceSize()
+	if len(data) < 1+nonceSize || data[0] != formatVersion1 {
+		return nil, ErrInvalidCiphertext
+	}
+
+	nonce, ciphertextBytes := data[1:1+nonceSize], data[1+nonceSize:]
+	return gcm.Open(nil, nonce, ciphertextBytes, associatedData(data[0]))
+}
+
+// newGCM creates an AES-256-GCM cipher for the given key
+func newGCM(key []byte) (cipher.AEAD, error) {
+	// Create AES cipher block
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+
+	// Create GCM mode
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GCM: %w", err)
+	}
+
+	return gcm, nil
 }
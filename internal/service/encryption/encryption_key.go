@@ -35,6 +35,28 @@ func LoadEncryptionKeyFromEnv() ([]byte, error) {
 	return key, nil
 }
 
+// ParseKey decodes a 32-byte encryption key given either as a 64-character hex string
+// or as a raw 32-character string
+func ParseKey(keyStr string) ([]byte, error) {
+	if keyStr == "" {
+		return nil, fmt.Errorf("encryption key is not set (set ENCRYPTION_KEY environment variable)")
+	}
+
+	// Try to decode as hex
+	key, err := hex.DecodeString(keyStr)
+	if err == nil && len(key) == 32 {
+		return key, nil
+	}
+
+	// If not hex, use the string directly (must be 32 bytes)
+	keyBytes := []byte(keyStr)
+	if len(keyBytes) != 32 {
+		return nil, fmt.Errorf("encryption key must be exactly 32 bytes (got %d bytes)", len(keyBytes))
+	}
+
+	return keyBytes, nil
+}
+
 // GenerateEncryptionKey generates a new random 32-byte encryption key
 // Returns the key as a hex-encoded string suitable for environment variables
 func GenerateEncryptionKey() (string, error) {
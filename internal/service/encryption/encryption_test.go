@@ -2,6 +2,7 @@ package encryption
 
 import (
 	"crypto/rand"
+	"encoding/base64"
 	"strings"
 	"testing"
 
@@ -355,3 +356,165 @@ func TestProperty_RoundTripEncryptionDecryption(t *testing.T) {
 
 	properties.TestingRun(t)
 }
+
+// TestEncryptVersionedFormat tests that new ciphertexts carry the format version byte
+func TestEncryptVersionedFormat(t *testing.T) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+
+	service, err := NewEncryptionService(key)
+	if err != nil {
+		t.Fatalf("failed to create service: %v", err)
+	}
+
+	encrypted, err := service.Encrypt("secret")
+	if err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+
+	data, err := base64.StdEncoding.DecodeString(encrypted)
+	if err != nil {
+		t.Fatalf("failed to decode ciphertext: %v", err)
+	}
+	if data[0] != formatVersion1 {
+		t.Errorf("version byte = %#x, want %#x", data[0], formatVersion1)
+	}
+
+	// Tampering with the version header must break authentication
+	data[0] = 0x02
+	if _, err := service.Decrypt(base64.StdEncoding.EncodeToString(data)); err == nil {
+		t.Error("Decrypt() with tampered version should fail, got nil error")
+	}
+}
+
+// TestDecryptLegacyFormat tests that ciphertexts without version byte still decrypt
+func TestDecryptLegacyFormat(t *testing.T) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+
+	service, err := NewEncryptionService(key)
+	if err != nil {
+		t.Fatalf("failed to create service: %v", err)
+	}
+
+	legacy := legacyEncrypt(t, key, "legacy secret")
+
+	decrypted, err := service.Decrypt(legacy)
+	if err != nil {
+		t.Fatalf("Decrypt() error = %v", err)
+	}
+	if decrypted != "legacy secret" {
+		t.Errorf("Decrypt() = %q, want %q", decrypted, "legacy secret")
+	}
+
+	_, usedPrevious, err := service.DecryptWithFallback(legacy)
+	if err != nil {
+		t.Fatalf("DecryptWithFallback() error = %v", err)
+	}
+	if !usedPrevious {
+		t.Error("DecryptWithFallback() should flag legacy ciphertext for re-encryption")
+	}
+}
+
+// TestDecryptWithFallback tests decryption with previous keys during rotation
+func TestDecryptWithFallback(t *testing.T) {
+	oldKey := make([]byte, 32)
+	newKey := make([]byte, 32)
+	if _, err := rand.Read(oldKey); err != nil {
+		t.Fatalf("failed to generate old key: %v", err)
+	}
+	if _, err := rand.Read(newKey); err != nil {
+		t.Fatalf("failed to generate new key: %v", err)
+	}
+
+	oldService, err := NewEncryptionService(oldKey)
+	if err != nil {
+		t.Fatalf("failed to create old service: %v", err)
+	}
+	rotated, err := NewEncryptionServiceWithPreviousKeys(newKey, [][]byte{oldKey})
+	if err != nil {
+		t.Fatalf("failed to create rotated service: %v", err)
+	}
+
+	oldCiphertext, err := oldService.Encrypt("credentials")
+	if err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+
+	// Plain Decrypt only uses the current key
+	if _, err := rotated.Decrypt(oldCiphertext); err == nil {
+		t.Error("Decrypt() with new key should fail on old ciphertext")
+	}
+
+	plaintext, usedPrevious, err := rotated.DecryptWithFallback(oldCiphertext)
+	if err != nil {
+		t.Fatalf("DecryptWithFallback() error = %v", err)
+	}
+	if plaintext != "credentials" || !usedPrevious {
+		t.Errorf("DecryptWithFallback() = (%q, %v), want (%q, true)", plaintext, usedPrevious, "credentials")
+	}
+
+	newCiphertext, err := rotated.Encrypt("credentials")
+	if err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+	_, usedPrevious, err = rotated.DecryptWithFallback(newCiphertext)
+	if err != nil {
+		t.Fatalf("DecryptWithFallback() error = %v", err)
+	}
+	if usedPrevious {
+		t.Error("DecryptWithFallback() should not flag current-key ciphertext")
+	}
+
+	unrelated := make([]byte, 32)
+	if _, err := rand.Read(unrelated); err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	other, _ := NewEncryptionService(unrelated)
+	if _, _, err := other.DecryptWithFallback(oldCiphertext); err == nil {
+		t.Error("DecryptWithFallback() with unknown key should fail")
+	}
+
+	if _, err := NewEncryptionServiceWithPreviousKeys(newKey, [][]byte{make([]byte, 16)}); err == nil {
+		t.Error("NewEncryptionServiceWithPreviousKeys() should reject invalid previous key size")
+	}
+}
+
+// legacyEncrypt produces a ciphertext in the pre-versioning format (nonce + ciphertext, no AAD)
+func legacyEncrypt(t *testing.T, key []byte, plaintext string) string {
+	t.Helper()
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		t.Fatalf("failed to create GCM: %v", err)
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		t.Fatalf("failed to generate nonce: %v", err)
+	}
+	return base64.StdEncoding.EncodeToString(gcm.Seal(nonce, nonce, []byte(plaintext), nil))
+}
+
+func TestParseKey(t *testing.T) {
+	hexKey := strings.Repeat("ab", 32)
+	key, err := ParseKey(hexKey)
+	if err != nil || len(key) != 32 || key[0] != 0xab {
+		t.Errorf("Expected hex key to decode to 32 bytes, got %v (%v)", key, err)
+	}
+
+	rawKey := strings.Repeat("k", 32)
+	key, err = ParseKey(rawKey)
+	if err != nil || string(key) != rawKey {
+		t.Errorf("Expected raw key to be used as is, got %v (%v)", key, err)
+	}
+
+	for _, invalid := range []string{"", "short", strings.Repeat("ab", 20)} {
+		if _, err := ParseKey(invalid); err == nil {
+			t.Errorf("Expected key %q to be rejected", invalid)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package encryption
+
+import (
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// KDFParams are the Argon2id parameters of a key derivation version.
+// The parameters of a published version must never change, otherwise keys derived
+// from the same passphrase and salt would differ and stored credentials could not be decrypted.
+type KDFParams struct {
+	Time    uint32 // Number of passes over the memory
+	Memory  uint32 // Memory in KiB
+	Threads uint8  // Degree of parallelism
+}
+
+// CurrentKDFVersion is the key derivation version used when none is configured
+const CurrentKDFVersion = 1
+
+// MinSaltLength is the minimum salt length accepted for key derivation
+const MinSaltLength = 16
+
+// kdfVersions lists the supported key derivation versions
+var kdfVersions = map[int]KDFParams{
+	// Version 1: Argon2id, 3 passes, 64 MiB, 4 threads (RFC 9106 second recommended option)
+	1: {Time: 3, Memory: 64 * 1024, Threads: 4},
+}
+
+var (
+	// ErrPassphraseNotSet is returned when deriving a key from an empty passphrase
+	ErrPassphraseNotSet = errors.New("encryption passphrase is empty")
+	// ErrSaltTooShort is returned when the salt is shorter than MinSaltLength
+	ErrSaltTooShort = fmt.Errorf("encryption salt must be at least %d bytes", MinSaltLength)
+)
+
+// IsKDFVersion reports whether version is a supported key derivation version
+func IsKDFVersion(version int) bool {
+	_, ok := kdfVersions[version]
+	return ok
+}
+
+// DeriveKeyFromPassphrase derives a 32-byte encryption key from a passphrase with Argon2id.
+// The same passphrase, salt and version always produce the same key.
+func DeriveKeyFromPassphrase(passphrase, salt string, version int) ([]byte, error) {
+	if passphrase == "" {
+		return nil, ErrPassphraseNotSet
+	}
+	if len(salt) < MinSaltLength {
+		return nil, ErrSaltTooShort
+	}
+
+	params, ok := kdfVersions[version]
+	if !ok {
+		return nil, fmt.Errorf("unsupported key derivation version %d", version)
+	}
+
+	return argon2.IDKey([]byte(passphrase), []byte(salt), params.Time, params.Memory, params.Threads, 32), nil
+}
@@ -0,0 +1,61 @@
+package encryption
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"testing"
+)
+
+func TestDeriveKeyFromPassphrase(t *testing.T) {
+	salt := "valhafin-test-salt"
+
+	key, err := DeriveKeyFromPassphrase("correct horse battery staple", salt, CurrentKDFVersion)
+	if err != nil {
+		t.Fatalf("DeriveKeyFromPassphrase() error = %v", err)
+	}
+	if len(key) != 32 {
+		t.Fatalf("expected a 32-byte key, got %d bytes", len(key))
+	}
+
+	// Version 1 parameters are fixed: this value must never change
+	const want = "92b53b6532f555c64b618c276ad7c71b107b5ed5204d7b6d67ad7e2a3385ead5"
+	if got := hex.EncodeToString(key); got != want {
+		t.Errorf("version 1 key = %s, want %s", got, want)
+	}
+
+	again, _ := DeriveKeyFromPassphrase("correct horse battery staple", salt, CurrentKDFVersion)
+	if !bytes.Equal(key, again) {
+		t.Error("derivation is not reproducible")
+	}
+
+	other, _ := DeriveKeyFromPassphrase("correct horse battery staple", salt+"2", CurrentKDFVersion)
+	if bytes.Equal(key, other) {
+		t.Error("different salts must produce different keys")
+	}
+
+	// The derived key is usable by the encryption service
+	service, err := NewEncryptionService(key)
+	if err != nil {
+		t.Fatalf("NewEncryptionService() error = %v", err)
+	}
+	encrypted, err := service.Encrypt("secret")
+	if err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+	if decrypted, err := service.Decrypt(encrypted); err != nil || decrypted != "secret" {
+		t.Errorf("Decrypt() = %q, %v", decrypted, err)
+	}
+}
+
+func TestDeriveKeyFromPassphrase_Errors(t *testing.T) {
+	if _, err := DeriveKeyFromPassphrase("", "valhafin-test-salt", CurrentKDFVersion); !errors.Is(err, ErrPassphraseNotSet) {
+		t.Errorf("empty passphrase: error = %v, want ErrPassphraseNotSet", err)
+	}
+	if _, err := DeriveKeyFromPassphrase("passphrase", "short", CurrentKDFVersion); !errors.Is(err, ErrSaltTooShort) {
+		t.Errorf("short salt: error = %v, want ErrSaltTooShort", err)
+	}
+	if _, err := DeriveKeyFromPassphrase("passphrase", "valhafin-test-salt", 99); err == nil {
+		t.Error("unknown version: expected an error")
+	}
+}
@@ -0,0 +1,138 @@
+package encryption
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"unicode"
+)
+
+// MinPassphraseEntropyBits is the minimum estimated entropy of a passphrase used to
+// derive the encryption key
+const MinPassphraseEntropyBits = 60
+
+// ErrWeakKey is returned for an encryption key or passphrase that is trivial to guess
+var ErrWeakKey = errors.New("encryption key is weak")
+
+// placeholderKeys are example values found in documentation and tutorials, compared
+// case-insensitively with the configured key or passphrase
+var placeholderKeys = map[string]bool{
+	"your_32_byte_encryption_key_here":                                 true,
+	"your_32_byte_hex_encryption_key_here":                             true,
+	"12345678901234567890123456789012":                                 true,
+	"0123456789abcdef0123456789abcdef":                                 true,
+	"abcdefghijklmnopqrstuvwxyz012345":                                 true,
+	"0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef": true,
+	"changeme":             true,
+	"password":             true,
+	"secret":               true,
+	"your_passphrase_here": true,
+}
+
+// CheckKeyStrength returns an ErrWeakKey error when the key given as ENCRYPTION_KEY is a
+// known placeholder, repeats a short pattern (all zero, all the same byte...) or counts
+// up byte by byte. Random keys from GenerateEncryptionKey always pass.
+func CheckKeyStrength(keyStr string) error {
+	if placeholderKeys[strings.ToLower(strings.TrimSpace(keyStr))] {
+		return fmt.Errorf("%w: it is a known placeholder", ErrWeakKey)
+	}
+	key, err := ParseKey(keyStr)
+	if err != nil {
+		return err
+	}
+	if period := shortestPeriod(key); period <= 8 {
+		return fmt.Errorf("%w: it repeats a pattern of %d byte(s)", ErrWeakKey, period)
+	}
+	if isSequence(key) {
+		return fmt.Errorf("%w: its bytes follow each other", ErrWeakKey)
+	}
+	return nil
+}
+
+// CheckPassphraseStrength returns an ErrWeakKey error when the passphrase is a known
+// placeholder, repeats a short pattern or is estimated below MinPassphraseEntropyBits
+func CheckPassphraseStrength(passphrase string) error {
+	if placeholderKeys[strings.ToLower(strings.TrimSpace(passphrase))] {
+		return fmt.Errorf("%w: the passphrase is a known placeholder", ErrWeakKey)
+	}
+	if period := shortestPeriod([]byte(passphrase)); period < len(passphrase) && period <= 8 {
+		return fmt.Errorf("%w: the passphrase repeats a pattern of %d character(s)", ErrWeakKey, period)
+	}
+	if bits := PassphraseEntropy(passphrase); bits < MinPassphraseEntropyBits {
+		return fmt.Errorf("%w: the passphrase has about %.0f bits of entropy, at least %d are required (use a longer passphrase)",
+			ErrWeakKey, bits, MinPassphraseEntropyBits)
+	}
+	return nil
+}
+
+// PassphraseEntropy estimates the entropy of a passphrase in bits from its length and the
+// character classes it uses (lowercase, uppercase, digits, symbols, other characters)
+func PassphraseEntropy(passphrase string) float64 {
+	var lower, upper, digit, symbol, other bool
+	length := 0
+	for _, r := range passphrase {
+		length++
+		switch {
+		case r >= 'a' && r <= 'z':
+			lower = true
+		case r >= 'A' && r <= 'Z':
+			upper = true
+		case r >= '0' && r <= '9':
+			digit = true
+		case r < unicode.MaxASCII:
+			symbol = true
+		default:
+			other = true
+		}
+	}
+
+	alphabet := 0
+	for _, class := range []struct {
+		used bool
+		size int
+	}{{lower, 26}, {upper, 26}, {digit, 10}, {symbol, 33}, {other, 100}} {
+		if class.used {
+			alphabet += class.size
+		}
+	}
+	if alphabet == 0 {
+		return 0
+	}
+	return float64(length) * math.Log2(float64(alphabet))
+}
+
+// shortestPeriod returns the length of the shortest pattern that data repeats, len(data)
+// when it repeats none
+func shortestPeriod(data []byte) int {
+	for period := 1; period < len(data); period++ {
+		repeats := true
+		for i := period; i < len(data); i++ {
+			if data[i] != data[i-period] {
+				repeats = false
+				break
+			}
+		}
+		if repeats {
+			return period
+		}
+	}
+	return len(data)
+}
+
+// isSequence reports whether every byte of data is the previous one plus or minus one
+func isSequence(data []byte) bool {
+	if len(data) < 2 {
+		return false
+	}
+	step := int(data[1]) - int(data[0])
+	if step != 1 && step != -1 {
+		return false
+	}
+	for i := 2; i < len(data); i++ {
+		if int(data[i])-int(data[i-1]) != step {
+			return false
+		}
+	}
+	return true
+}
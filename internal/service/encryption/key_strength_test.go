@@ -0,0 +1,55 @@
+package encryption
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCheckKeyStrength(t *testing.T) {
+	weak := map[string]string{
+		"all zero hex":        strings.Repeat("00", 32),
+		"same byte hex":       strings.Repeat("ab", 32),
+		"same byte raw":       strings.Repeat("k", 32),
+		"short pattern":       strings.Repeat("deadbeef", 8),
+		"counting bytes":      "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f",
+		"counting characters": "abcdefghijklmnopqrstuvwxyz{|}~\x7f\x80",
+		"digits placeholder":  "12345678901234567890123456789012",
+		"hex placeholder":     "0123456789ABCDEF0123456789ABCDEF0123456789ABCDEF0123456789ABCDEF",
+		"env placeholder":     "your_32_byte_encryption_key_here",
+	}
+	for name, key := range weak {
+		t.Run(name, func(t *testing.T) {
+			if err := CheckKeyStrength(key); !errors.Is(err, ErrWeakKey) {
+				t.Errorf("Expected %q to be rejected as weak, got %v", key, err)
+			}
+		})
+	}
+
+	generated, err := GenerateEncryptionKey()
+	if err != nil {
+		t.Fatalf("GenerateEncryptionKey() error = %v", err)
+	}
+	for _, key := range []string{generated, "q7Vf2LxR9mTz4WcP8kHb1NsY6dGj3QeA"} {
+		if err := CheckKeyStrength(key); err != nil {
+			t.Errorf("Expected %q to be accepted, got %v", key, err)
+		}
+	}
+
+	if err := CheckKeyStrength("short"); err == nil || errors.Is(err, ErrWeakKey) {
+		t.Errorf("Expected an invalid key error, got %v", err)
+	}
+}
+
+func TestCheckPassphraseStrength(t *testing.T) {
+	for _, passphrase := range []string{"password", "ChangeMe", "hunter22", strings.Repeat("ab", 20)} {
+		if err := CheckPassphraseStrength(passphrase); !errors.Is(err, ErrWeakKey) {
+			t.Errorf("Expected passphrase %q to be rejected, got %v", passphrase, err)
+		}
+	}
+	for _, passphrase := range []string{"correct horse battery staple", "Ma-Clé-Très-Secrète-2024!"} {
+		if err := CheckPassphraseStrength(passphrase); err != nil {
+			t.Errorf("Expected passphrase %q to be accepted, got %v", passphrase, err)
+		}
+	}
+}
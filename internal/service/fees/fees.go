@@ -1,9 +1,9 @@
 package fees
 
 import (
+	"context"
 	"fmt"
-	"strconv"
-	"strings"
+	"math"
 	"time"
 	"valhafin/internal/domain/models"
 	"valhafin/internal/repository/database"
@@ -13,17 +13,36 @@ import (
 type Service interface {
 	CalculateAccountFees(accountID string, startDate, endDate string) (*FeesMetrics, error)
 	CalculateGlobalFees(startDate, endDate string) (*FeesMetrics, error)
+	// The Context variants keep the transactions flagged as hidden when includeHidden is set
+	CalculateAccountFeesContext(ctx context.Context, accountID string, startDate, endDate string, includeHidden bool) (*FeesMetrics, error)
+	CalculateGlobalFeesContext(ctx context.Context, startDate, endDate string, includeHidden bool) (*FeesMetrics, error)
 }
 
-// FeesMetrics represents aggregated fee metrics
+// FeesMetrics represents aggregated fee metrics.
+// TotalFees, AverageFees and FeesByType only cover the explicit commission (the Fees field);
+// Breakdown adds taxes and implicit costs to give the total cost of the transactions.
 type FeesMetrics struct {
 	TotalFees        float64              `json:"total_fees"`
 	AverageFees      float64              `json:"average_fees"`
 	TransactionCount int                  `json:"transaction_count"`
 	FeesByType       map[string]float64   `json:"fees_by_type"`
+	Breakdown        CostBreakdown        `json:"breakdown"`
 	TimeSeries       []FeeTimeSeriesPoint `json:"time_series"`
 }
 
+// CostBreakdown splits the total cost of transactions by nature
+type CostBreakdown struct {
+	// Commission is the explicit broker fee (Fees field)
+	Commission float64 `json:"commission"`
+	// Taxes are the taxes withheld or paid (Taxes field), e.g. on dividends or financial transactions
+	Taxes float64 `json:"taxes"`
+	// ImplicitCosts is the part of a trade total that neither the shares value nor the
+	// commission and taxes explain (spread, FX margin), when the trade details are available
+	ImplicitCosts float64 `json:"implicit_costs"`
+	// TotalCosts is the sum of the three
+	TotalCosts float64 `json:"total_costs"`
+}
+
 // FeeTimeSeriesPoint represents a point in the fee evolution chart
 type FeeTimeSeriesPoint struct {
 	Date string  `json:"date"`
@@ -44,34 +63,28 @@ func NewFeesService(db *database.DB) Service {
 
 // CalculateAccountFees calculates fee metrics for a specific account
 func (s *feesService) CalculateAccountFees(accountID string, startDate, endDate string) (*FeesMetrics, error) {
+	return s.CalculateAccountFeesContext(context.Background(), accountID, startDate, endDate, false)
+}
+
+// CalculateAccountFeesContext is like CalculateAccountFees but database reads are canceled with ctx,
+// and hidden transactions are counted when includeHidden is set
+func (s *feesService) CalculateAccountFeesContext(ctx context.Context, accountID string, startDate, endDate string, includeHidden bool) (*FeesMetrics, error) {
 	// Get account to determine platform
-	account, err := s.db.GetAccountByID(accountID)
+	account, err := s.db.GetAccountByIDContext(ctx, accountID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get account: %w", err)
 	}
 
-	// Normalize dates for proper timestamp comparison
-	normalizedStartDate := startDate
-	normalizedEndDate := endDate
-
-	// If endDate is provided, add time component to include the entire day
-	if endDate != "" {
-		// Parse the date and add 23:59:59 to include the entire day
-		if t, err := time.Parse("2006-01-02", endDate); err == nil {
-			// Add 1 day and subtract 1 second to get 23:59:59 of the end date
-			normalizedEndDate = t.AddDate(0, 0, 1).Add(-time.Second).Format(time.RFC3339)
-		}
-	}
-
-	// Build filter
+	// Build filter (YYYY-MM-DD bounds are normalized to whole days by the repository)
 	filter := database.TransactionFilter{
-		AccountID: accountID,
-		StartDate: normalizedStartDate,
-		EndDate:   normalizedEndDate,
+		AccountID:     accountID,
+		StartDate:     startDate,
+		EndDate:       endDate,
+		IncludeHidden: includeHidden,
 	}
 
 	// Get all transactions for the account
-	transactions, err := s.db.GetTransactionsByAccount(accountID, account.Platform, filter)
+	transactions, err := s.db.GetTransactionsByAccountContext(ctx, accountID, account.Platform, filter)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get transactions: %w", err)
 	}
@@ -81,36 +94,30 @@ func (s *feesService) CalculateAccountFees(accountID string, startDate, endDate
 
 // CalculateGlobalFees calculates fee metrics across all accounts
 func (s *feesService) CalculateGlobalFees(startDate, endDate string) (*FeesMetrics, error) {
+	return s.CalculateGlobalFeesContext(context.Background(), startDate, endDate, false)
+}
+
+// CalculateGlobalFeesContext is like CalculateGlobalFees but database reads are canceled with ctx,
+// and hidden transactions are counted when includeHidden is set
+func (s *feesService) CalculateGlobalFeesContext(ctx context.Context, startDate, endDate string, includeHidden bool) (*FeesMetrics, error) {
 	// Get all accounts
-	accounts, err := s.db.GetAllAccounts()
+	accounts, err := s.db.GetAllAccountsContext(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get accounts: %w", err)
 	}
 
-	// Normalize dates for proper timestamp comparison
-	normalizedStartDate := startDate
-	normalizedEndDate := endDate
-
-	// If endDate is provided, add time component to include the entire day
-	if endDate != "" {
-		// Parse the date and add 23:59:59 to include the entire day
-		if t, err := time.Parse("2006-01-02", endDate); err == nil {
-			// Add 1 day and subtract 1 second to get 23:59:59 of the end date
-			normalizedEndDate = t.AddDate(0, 0, 1).Add(-time.Second).Format(time.RFC3339)
-		}
-	}
-
 	// Collect all transactions from all accounts
 	allTransactions := []models.Transaction{}
 
 	for _, account := range accounts {
 		filter := database.TransactionFilter{
-			AccountID: account.ID,
-			StartDate: normalizedStartDate,
-			EndDate:   normalizedEndDate,
+			AccountID:     account.ID,
+			StartDate:     startDate,
+			EndDate:       endDate,
+			IncludeHidden: includeHidden,
 		}
 
-		transactions, err := s.db.GetTransactionsByAccount(account.ID, account.Platform, filter)
+		transactions, err := s.db.GetTransactionsByAccountContext(ctx, account.ID, account.Platform, filter)
 		if err != nil {
 			// Log error but continue with other accounts
 			continue
@@ -122,7 +129,9 @@ func (s *feesService) CalculateGlobalFees(startDate, endDate string) (*FeesMetri
 	return s.calculateFeesFromTransactions(allTransactions)
 }
 
-// calculateFeesFromTransactions calculates fee metrics from a list of transactions
+// calculateFeesFromTransactions calculates fee metrics from a list of transactions. The amounts
+// are read as stored, like the performance calculation does: the CSV import of an account with
+// a decimal style already stores its fees and taxes as plain numbers.
 func (s *feesService) calculateFeesFromTransactions(transactions []models.Transaction) (*FeesMetrics, error) {
 	metrics := &FeesMetrics{
 		TotalFees:        0,
@@ -143,6 +152,11 @@ func (s *feesService) calculateFeesFromTransactions(transactions []models.Transa
 	for _, tx := range transactions {
 		// Parse fees from the Fees field (format: "X,XX €" or "X.XX €")
 		feeValue := parseFeeValue(tx.Fees)
+		taxValue := parseFeeValue(tx.Taxes)
+
+		metrics.Breakdown.Commission += feeValue
+		metrics.Breakdown.Taxes += taxValue
+		metrics.Breakdown.ImplicitCosts += implicitCost(tx, feeValue, taxValue)
 
 		if feeValue > 0 {
 			metrics.TotalFees += feeValue
@@ -163,6 +177,8 @@ func (s *feesService) calculateFeesFromTransactions(transactions []models.Transa
 		}
 	}
 
+	metrics.Breakdown.TotalCosts = metrics.Breakdown.Commission + metrics.Breakdown.Taxes + metrics.Breakdown.ImplicitCosts
+
 	// Calculate average fees
 	if metrics.TransactionCount > 0 {
 		metrics.AverageFees = metrics.TotalFees / float64(metrics.TransactionCount)
@@ -182,35 +198,57 @@ func (s *feesService) calculateFeesFromTransactions(transactions []models.Transa
 	return metrics, nil
 }
 
-// parseFeeValue parses a fee string (e.g., "1,00 €" or "1.50 €") to a float64
+// parseFeeValue parses a fee or tax string (e.g., "1,00 €" or "1.50 €") to a positive float64.
+// Unparseable values count as zero.
 func parseFeeValue(feeStr string) float64 {
-	if feeStr == "" {
+	value, err := models.ParseMoney(feeStr)
+	if err != nil {
 		return 0
 	}
 
-	// Remove currency symbols and whitespace
-	feeStr = strings.TrimSpace(feeStr)
-	feeStr = strings.ReplaceAll(feeStr, "€", "")
-	feeStr = strings.ReplaceAll(feeStr, "$", "")
-	feeStr = strings.ReplaceAll(feeStr, "USD", "")
-	feeStr = strings.ReplaceAll(feeStr, "EUR", "")
-	feeStr = strings.TrimSpace(feeStr)
+	// Return absolute value (fees should be positive)
+	return math.Abs(value)
+}
 
-	// Replace comma with dot for parsing
-	feeStr = strings.ReplaceAll(feeStr, ",", ".")
+// implicitCostTolerance ignores differences that only come from rounding the displayed amounts
+const implicitCostTolerance = 0.01
+
+// implicitCost returns the cost of a trade that is not shown as fees or taxes: what was paid
+// above the shares value on a buy, or received below it on a sell. It is only available when
+// the trade details (shares, share price and total) are known, and zero otherwise.
+func implicitCost(tx models.Transaction, fees, taxes float64) float64 {
+	if tx.TransactionType != models.TransactionTypeBuy && tx.TransactionType != models.TransactionTypeSell {
+		return 0
+	}
+	if tx.Shares == "" || tx.SharePrice == "" || tx.Total == "" {
+		return 0
+	}
 
-	// Parse to float
-	value, err := strconv.ParseFloat(feeStr, 64)
+	shares, err := models.ParseMoney(tx.Shares)
+	if err != nil {
+		return 0
+	}
+	sharePrice, err := models.ParseMoney(tx.SharePrice)
+	if err != nil {
+		return 0
+	}
+	total, err := models.ParseMoney(tx.Total)
 	if err != nil {
 		return 0
 	}
 
-	// Return absolute value (fees should be positive)
-	if value < 0 {
-		return -value
+	sharesValue := math.Abs(shares) * math.Abs(sharePrice)
+	var cost float64
+	if tx.TransactionType == models.TransactionTypeBuy {
+		cost = math.Abs(total) - sharesValue - fees - taxes
+	} else {
+		cost = sharesValue - math.Abs(total) - fees - taxes
 	}
 
-	return value
+	if cost <= implicitCostTolerance {
+		return 0
+	}
+	return cost
 }
 
 // extractDate extracts the date part (YYYY-MM-DD) from a timestamp
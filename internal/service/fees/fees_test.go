@@ -1,11 +1,13 @@
 package fees
 
 import (
+	"context"
 	"fmt"
 	"testing"
 	"time"
 	"valhafin/internal/domain/models"
 	"valhafin/internal/repository/database"
+	"valhafin/internal/testutil"
 
 	"github.com/leanovate/gopter"
 	"github.com/leanovate/gopter/gen"
@@ -17,60 +19,9 @@ func stringPtr(s string) *string {
 	return &s
 }
 
-// setupTestDB creates a test database for testing
+// setupTestDB creates a test database isolated in its own schema
 func setupTestDB(t *testing.T) *database.DB {
-	cfg := database.Config{
-		Host:     "localhost",
-		Port:     5432,
-		User:     "valhafin",
-		Password: "valhafin",
-		DBName:   "valhafin_test",
-		SSLMode:  "disable",
-	}
-
-	db, err := database.Connect(cfg)
-	if err != nil {
-		t.Skipf("Skipping test: database not available: %v", err)
-		return nil
-	}
-
-	// Run migrations
-	if err := db.RunMigrations(); err != nil {
-		t.Fatalf("Failed to run migrations: %v", err)
-	}
-
-	return db
-}
-
-// cleanupTestDB cleans up test data
-func cleanupTestDB(t *testing.T, db *database.DB) {
-	if db == nil {
-		return
-	}
-
-	// Clean up test accounts and transactions
-	_, err := db.Exec("DELETE FROM accounts WHERE name LIKE 'Test%'")
-	if err != nil {
-		t.Logf("Warning: failed to clean up test accounts: %v", err)
-	}
-
-	// Clean up transactions from all platform tables
-	// Use a subquery to find account_ids that match test accounts
-	platforms := []string{"traderepublic", "binance", "boursedirect"}
-	for _, platform := range platforms {
-		tableName := fmt.Sprintf("transactions_%s", platform)
-		query := fmt.Sprintf(`
-			DELETE FROM %s 
-			WHERE account_id IN (
-				SELECT id FROM accounts WHERE name LIKE 'Test%%'
-			)
-		`, tableName)
-		_, err := db.Exec(query)
-		if err != nil {
-			// Silently ignore errors during cleanup
-			_ = err
-		}
-	}
+	return testutil.NewTestDB(t)
 }
 
 // **Propriété 17: Agrégation des frais**
@@ -85,7 +36,6 @@ func TestProperty_FeesAggregation(t *testing.T) {
 		t.Skip("Database not available")
 		return
 	}
-	defer cleanupTestDB(t, db)
 
 	service := NewFeesService(db)
 
@@ -251,7 +201,6 @@ func TestProperty_GlobalFeesAggregation(t *testing.T) {
 		t.Skip("Database not available")
 		return
 	}
-	defer cleanupTestDB(t, db)
 
 	service := NewFeesService(db)
 
@@ -376,6 +325,142 @@ func TestProperty_GlobalFeesAggregation(t *testing.T) {
 	properties.TestingRun(t)
 }
 
+// TestFeesExcludeDeletedTransactions checks that transactions flagged as deleted do not count in the fees totals
+func TestFeesExcludeDeletedTransactions(t *testing.T) {
+	db := setupTestDB(t)
+	if db == nil {
+		t.Skip("Database not available")
+		return
+	}
+
+	service := NewFeesService(db)
+
+	account := &models.Account{
+		Name:        "Test Deleted Fees Account",
+		Platform:    "traderepublic",
+		Credentials: "encrypted_test_credentials",
+	}
+	if err := db.CreateAccount(account); err != nil {
+		t.Fatalf("Failed to create account: %v", err)
+	}
+	defer db.DeleteAccount(account.ID)
+
+	_, err := db.Exec(`
+		INSERT INTO assets (isin, name, symbol, type, currency, last_updated)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (isin) DO NOTHING
+	`, "TEST123456", "Test Asset", "TEST", "stock", "EUR", time.Now())
+	if err != nil {
+		t.Fatalf("Failed to create asset: %v", err)
+	}
+
+	for i, deleted := range []bool{false, true} {
+		tx := models.Transaction{
+			ID:              fmt.Sprintf("tx-deleted-%d-%d", time.Now().UnixNano(), i),
+			AccountID:       account.ID,
+			Timestamp:       time.Now().Format(time.RFC3339),
+			Title:           fmt.Sprintf("Transaction %d", i),
+			AmountValue:     -100.0,
+			AmountCurrency:  "EUR",
+			Fees:            "1.00 €",
+			TransactionType: "buy",
+			ISIN:            stringPtr("TEST123456"),
+			Quantity:        1.0,
+			Deleted:         deleted,
+			Metadata:        stringPtr("{}"),
+		}
+		if err := db.CreateTransaction(&tx, "traderepublic"); err != nil {
+			t.Fatalf("Failed to create transaction: %v", err)
+		}
+	}
+
+	metrics, err := service.CalculateAccountFees(account.ID, "", "")
+	if err != nil {
+		t.Fatalf("Failed to calculate fees: %v", err)
+	}
+
+	if metrics.TransactionCount != 1 {
+		t.Errorf("TransactionCount = %d, want 1 (deleted transaction excluded)", metrics.TransactionCount)
+	}
+	if abs(metrics.TotalFees-1.0) > 0.01 {
+		t.Errorf("TotalFees = %.2f, want 1.00 (deleted transaction excluded)", metrics.TotalFees)
+	}
+
+	// The deleted transaction is still returned on request
+	all, err := db.GetTransactionsByAccount(account.ID, "traderepublic", database.TransactionFilter{IncludeDeleted: true})
+	if err != nil {
+		t.Fatalf("Failed to get transactions: %v", err)
+	}
+	if len(all) != 2 {
+		t.Errorf("expected 2 transactions with IncludeDeleted, got %d", len(all))
+	}
+}
+
+// TestFeesExcludeHiddenTransactions checks that hidden transactions only count in the fees when asked for
+func TestFeesExcludeHiddenTransactions(t *testing.T) {
+	db := setupTestDB(t)
+	if db == nil {
+		t.Skip("Database not available")
+		return
+	}
+
+	service := NewFeesService(db)
+
+	account := &models.Account{
+		Name:        "Test Hidden Fees Account",
+		Platform:    "traderepublic",
+		Credentials: "encrypted_test_credentials",
+	}
+	if err := db.CreateAccount(account); err != nil {
+		t.Fatalf("Failed to create account: %v", err)
+	}
+	defer db.DeleteAccount(account.ID)
+
+	for i, hidden := range []bool{false, true} {
+		tx := models.Transaction{
+			ID:              fmt.Sprintf("tx-hidden-%d-%d", time.Now().UnixNano(), i),
+			AccountID:       account.ID,
+			Timestamp:       time.Now().Format(time.RFC3339),
+			Title:           fmt.Sprintf("Transfer %d", i),
+			AmountValue:     -100.0,
+			AmountCurrency:  "EUR",
+			Fees:            "1.00 €",
+			TransactionType: "withdrawal",
+			Hidden:          hidden,
+			Metadata:        stringPtr("{}"),
+		}
+		if err := db.CreateTransaction(&tx, "traderepublic"); err != nil {
+			t.Fatalf("Failed to create transaction: %v", err)
+		}
+	}
+
+	metrics, err := service.CalculateAccountFees(account.ID, "", "")
+	if err != nil {
+		t.Fatalf("Failed to calculate fees: %v", err)
+	}
+	if metrics.TransactionCount != 1 {
+		t.Errorf("TransactionCount = %d, want 1 (hidden transaction excluded)", metrics.TransactionCount)
+	}
+
+	// include_hidden keeps them in the calculation
+	metrics, err = service.CalculateAccountFeesContext(context.Background(), account.ID, "", "", true)
+	if err != nil {
+		t.Fatalf("Failed to calculate fees: %v", err)
+	}
+	if metrics.TransactionCount != 2 || abs(metrics.TotalFees-2.0) > 0.01 {
+		t.Errorf("expected 2 transactions and 2.00 fees with hidden transactions, got %d and %.2f", metrics.TransactionCount, metrics.TotalFees)
+	}
+
+	// The list can still show the hidden transaction so that it can be unhidden
+	all, err := db.GetTransactionsByAccount(account.ID, "traderepublic", database.TransactionFilter{IncludeHidden: true})
+	if err != nil {
+		t.Fatalf("Failed to get transactions: %v", err)
+	}
+	if len(all) != 2 {
+		t.Errorf("expected 2 transactions with IncludeHidden, got %d", len(all))
+	}
+}
+
 // TestProperty_FeesFilteringByPeriod tests that fees are correctly filtered by date range
 func TestProperty_FeesFilteringByPeriod(t *testing.T) {
 	db := setupTestDB(t)
@@ -383,7 +468,6 @@ func TestProperty_FeesFilteringByPeriod(t *testing.T) {
 		t.Skip("Database not available")
 		return
 	}
-	defer cleanupTestDB(t, db)
 
 	service := NewFeesService(db)
 
@@ -522,6 +606,9 @@ func TestParseFeeValue(t *testing.T) {
 		{"negative value", "-1.50", 1.50}, // Should return absolute value
 		{"zero", "0", 0},
 		{"large value", "123.45", 123.45},
+		{"thousands separator", "1 234,50 €", 1234.50},
+		{"currency first", "€0.99", 0.99},
+		{"unparseable", "n/a", 0},
 	}
 
 	for _, tt := range tests {
@@ -534,6 +621,79 @@ func TestParseFeeValue(t *testing.T) {
 	}
 }
 
+func TestCalculateFees_StoredPlainNumbers(t *testing.T) {
+	// The CSV import of a comma account stores "1,50 €", "1.234,50" and "1,234" as plain numbers:
+	// they must be read back as written, whatever the account style
+	service := &feesService{}
+	transactions := []models.Transaction{
+		{ID: "a", AccountID: "eu-account", Timestamp: "2024-01-15T10:00:00Z", Fees: "1.5", Taxes: "0.3"},
+		{ID: "b", AccountID: "eu-account", Timestamp: "2024-01-15T10:00:00Z", Fees: "1234.5"},
+		{ID: "c", AccountID: "eu-account", Timestamp: "2024-01-15T10:00:00Z", Fees: "1.234"},
+	}
+
+	metrics, err := service.calculateFeesFromTransactions(transactions)
+	if err != nil {
+		t.Fatalf("calculateFeesFromTransactions failed: %v", err)
+	}
+	if abs(metrics.TotalFees-1237.234) > 0.0001 || abs(metrics.Breakdown.Taxes-0.3) > 0.0001 {
+		t.Errorf("expected the stored numbers to be read as written, got fees %v and taxes %v", metrics.TotalFees, metrics.Breakdown.Taxes)
+	}
+}
+
+func TestCalculateFees_TaxesCountedSeparately(t *testing.T) {
+	service := &feesService{}
+	transactions := []models.Transaction{
+		{ID: "buy", TransactionType: models.TransactionTypeBuy, Timestamp: "2024-01-15T10:00:00Z",
+			Fees: "1,00 €", Taxes: "0,30 €", Shares: "2", SharePrice: "50,00 €", Total: "-101,50 €"},
+		{ID: "dividend", TransactionType: models.TransactionTypeDividend, Timestamp: "2024-02-15T10:00:00Z",
+			Taxes: "-3,96 €"},
+		{ID: "sell", TransactionType: models.TransactionTypeSell, Timestamp: "2024-03-15T10:00:00Z",
+			Fees: "1,00 €", Shares: "1", SharePrice: "60,00 €", Total: "59,00 €"},
+	}
+
+	metrics, err := service.calculateFeesFromTransactions(transactions)
+	if err != nil {
+		t.Fatalf("calculateFeesFromTransactions failed: %v", err)
+	}
+
+	// Taxes must not leak into the commission figures
+	if abs(metrics.TotalFees-2.00) > 0.001 || metrics.TransactionCount != 2 {
+		t.Errorf("TotalFees = %.2f over %d transactions, want 2.00 over 2", metrics.TotalFees, metrics.TransactionCount)
+	}
+	if _, ok := metrics.FeesByType[models.TransactionTypeDividend]; ok {
+		t.Errorf("Dividend taxes counted as fees: %v", metrics.FeesByType)
+	}
+
+	breakdown := metrics.Breakdown
+	if abs(breakdown.Commission-2.00) > 0.001 {
+		t.Errorf("Commission = %.2f, want 2.00", breakdown.Commission)
+	}
+	if abs(breakdown.Taxes-4.26) > 0.001 {
+		t.Errorf("Taxes = %.2f, want 4.26", breakdown.Taxes)
+	}
+	// The buy total is 0.20 above the shares value, fees and taxes; the sell matches exactly
+	if abs(breakdown.ImplicitCosts-0.20) > 0.001 {
+		t.Errorf("ImplicitCosts = %.2f, want 0.20", breakdown.ImplicitCosts)
+	}
+	if abs(breakdown.TotalCosts-6.46) > 0.001 {
+		t.Errorf("TotalCosts = %.2f, want 6.46", breakdown.TotalCosts)
+	}
+}
+
+func TestImplicitCost_UnavailableDetails(t *testing.T) {
+	tests := []models.Transaction{
+		{TransactionType: models.TransactionTypeBuy, Total: "-100,00 €"},
+		{TransactionType: models.TransactionTypeBuy, Shares: "2", SharePrice: "n/a", Total: "-100,00 €"},
+		{TransactionType: models.TransactionTypeDeposit, Shares: "2", SharePrice: "10", Total: "100"},
+	}
+
+	for _, tx := range tests {
+		if cost := implicitCost(tx, 0, 0); cost != 0 {
+			t.Errorf("implicitCost(%+v) = %.2f, want 0", tx, cost)
+		}
+	}
+}
+
 func TestExtractDate(t *testing.T) {
 	tests := []struct {
 		name      string
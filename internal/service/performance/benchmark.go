@@ -0,0 +1,73 @@
+package performance
+
+import (
+	"sort"
+	"time"
+	"valhafin/internal/domain/models"
+)
+
+// BenchmarkComparison holds the portfolio and benchmark series normalized to 100 at the start
+type BenchmarkComparison struct {
+	Benchmark string            `json:"benchmark"`
+	Portfolio []NormalizedPoint `json:"portfolio"`
+	Series    []NormalizedPoint `json:"series"`
+}
+
+// NormalizedPoint represents a point of a series rebased to 100
+type NormalizedPoint struct {
+	Date  time.Time `json:"date"`
+	Value float64   `json:"value"`
+}
+
+// CompareWithBenchmark builds series rebased to 100 at the first common date.
+// The portfolio is rebased on its value/invested ratio so that deposits do not count as
+// performance. Benchmark prices are aligned on the portfolio dates using the last known price.
+func CompareWithBenchmark(benchmark string, timeSeries []PerformancePoint, prices []models.AssetPrice) *BenchmarkComparison {
+	comparison := &BenchmarkComparison{
+		Benchmark: benchmark,
+		Portfolio: []NormalizedPoint{},
+		Series:    []NormalizedPoint{},
+	}
+
+	if len(timeSeries) == 0 || len(prices) == 0 {
+		return comparison
+	}
+
+	sortedPrices := make([]models.AssetPrice, len(prices))
+	copy(sortedPrices, prices)
+	sort.Slice(sortedPrices, func(i, j int) bool {
+		return sortedPrices[i].Timestamp.Before(sortedPrices[j].Timestamp)
+	})
+
+	var portfolioBase, benchmarkBase float64
+	priceIdx := -1
+
+	for _, point := range timeSeries {
+		// Advance to the last benchmark price at or before the point date
+		for priceIdx+1 < len(sortedPrices) && !sortedPrices[priceIdx+1].Timestamp.After(point.Date) {
+			priceIdx++
+		}
+		if priceIdx < 0 || point.Invested <= 0 || sortedPrices[priceIdx].Price <= 0 {
+			continue
+		}
+
+		ratio := point.Value / point.Invested
+		benchmarkPrice := sortedPrices[priceIdx].Price
+
+		if portfolioBase == 0 {
+			portfolioBase = ratio
+			benchmarkBase = benchmarkPrice
+		}
+
+		comparison.Portfolio = append(comparison.Portfolio, NormalizedPoint{
+			Date:  point.Date,
+			Value: ratio / portfolioBase * 100,
+		})
+		comparison.Series = append(comparison.Series, NormalizedPoint{
+			Date:  point.Date,
+			Value: benchmarkPrice / benchmarkBase * 100,
+		})
+	}
+
+	return comparison
+}
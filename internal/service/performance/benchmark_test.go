@@ -0,0 +1,59 @@
+package performance
+
+import (
+	"math"
+	"testing"
+	"time"
+	"valhafin/internal/domain/models"
+)
+
+func TestCompareWithBenchmark(t *testing.T) {
+	day := func(d int) time.Time {
+		return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC)
+	}
+
+	timeSeries := []PerformancePoint{
+		{Date: day(1), Value: 0, Invested: 0},       // Nothing invested yet: skipped
+		{Date: day(2), Value: 1000, Invested: 1000}, // Base
+		{Date: day(3), Value: 2200, Invested: 2000}, // Deposit + 10% gain
+		{Date: day(5), Value: 1800, Invested: 2000}, // -10%
+	}
+
+	prices := []models.AssetPrice{
+		{Price: 60, Timestamp: day(4)},
+		{Price: 50, Timestamp: day(2)},
+		{Price: 40, Timestamp: day(1)},
+	}
+
+	comparison := CompareWithBenchmark("^GSPC", timeSeries, prices)
+
+	if comparison.Benchmark != "^GSPC" {
+		t.Errorf("Benchmark = %q, want %q", comparison.Benchmark, "^GSPC")
+	}
+	if len(comparison.Portfolio) != 3 || len(comparison.Series) != 3 {
+		t.Fatalf("expected 3 aligned points, got %d portfolio / %d benchmark", len(comparison.Portfolio), len(comparison.Series))
+	}
+
+	wantPortfolio := []float64{100, 110, 90}
+	// Day 3 uses the day 2 price (forward fill), day 5 uses the day 4 price
+	wantBenchmark := []float64{100, 100, 120}
+
+	for i := range wantPortfolio {
+		if math.Abs(comparison.Portfolio[i].Value-wantPortfolio[i]) > 1e-9 {
+			t.Errorf("Portfolio[%d] = %.4f, want %.4f", i, comparison.Portfolio[i].Value, wantPortfolio[i])
+		}
+		if math.Abs(comparison.Series[i].Value-wantBenchmark[i]) > 1e-9 {
+			t.Errorf("Series[%d] = %.4f, want %.4f", i, comparison.Series[i].Value, wantBenchmark[i])
+		}
+		if !comparison.Portfolio[i].Date.Equal(comparison.Series[i].Date) {
+			t.Errorf("point %d dates are not aligned", i)
+		}
+	}
+}
+
+func TestCompareWithBenchmark_Empty(t *testing.T) {
+	comparison := CompareWithBenchmark("^GSPC", nil, nil)
+	if comparison.Portfolio == nil || comparison.Series == nil {
+		t.Error("series should be empty slices, not nil")
+	}
+}
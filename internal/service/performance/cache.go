@@ -0,0 +1,168 @@
+package performance
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// globalScope is the cache scope of the portfolio performance
+const globalScope = "global"
+
+// cacheKey identifies a cached performance result
+type cacheKey struct {
+	// scope is globalScope or the account ID
+	scope string
+	// period, or the start and end of an explicit range, and the valuation date (zero for now)
+	period        string
+	start, end    time.Time
+	asOf          time.Time
+	includeHidden bool
+	interval      string
+}
+
+// cachedPerformance is a performance result and its expiry
+type cachedPerformance struct {
+	performance *Performance
+	expiresAt   time.Time
+}
+
+// CachedService keeps the portfolio and account performance results for a short time, so that
+// a dashboard refreshing often does not replay every transaction on each request. The other
+// calculations are delegated to the wrapped service as is. Expired results are evicted when
+// a new one is stored, and every result by Invalidate and InvalidateAll when transactions or
+// prices change.
+type CachedService struct {
+	Service
+	ttl time.Duration
+	now func() time.Time
+
+	mu      sync.Mutex
+	entries map[cacheKey]cachedPerformance
+	// generation changes on every invalidation, so that a result computed from data that
+	// changed in the meantime is not stored
+	generation uint64
+}
+
+// NewCachedService wraps service with a performance cache keeping results for ttl
+func NewCachedService(service Service, ttl time.Duration) *CachedService {
+	return &CachedService{
+		Service: service,
+		ttl:     ttl,
+		now:     time.Now,
+		entries: make(map[cacheKey]cachedPerformance),
+	}
+}
+
+// Invalidate drops the cached results of an account and of the portfolio, after its
+// transactions changed. It is a no-op on a nil cache.
+func (c *CachedService) Invalidate(accountID string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+	for key := range c.entries {
+		if key.scope == accountID || key.scope == globalScope {
+			delete(c.entries, key)
+		}
+	}
+}
+
+// InvalidateAll drops every cached result, after prices changed or several accounts were
+// synchronized. It is a no-op on a nil cache.
+func (c *CachedService) InvalidateAll() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+	c.entries = make(map[cacheKey]cachedPerformance)
+}
+
+// cached returns the result stored under key or computes and stores it. The result is a copy
+// that callers may modify.
+func (c *CachedService) cached(key cacheKey, opts Options, compute func() (*Performance, error)) (*Performance, error) {
+	key.includeHidden = opts.IncludeHidden
+	key.interval = opts.interval()
+
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	generation := c.generation
+	c.mu.Unlock()
+	if ok && c.now().Before(entry.expiresAt) {
+		result := *entry.performance
+		return &result, nil
+	}
+
+	perf, err := compute()
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	if c.generation == generation {
+		now := c.now()
+		c.evictExpired(now)
+		stored := *perf
+		c.entries[key] = cachedPerformance{performance: &stored, expiresAt: now.Add(c.ttl)}
+	}
+	c.mu.Unlock()
+	return perf, nil
+}
+
+// evictExpired drops the results expired at now, so that periods, ranges and valuation
+// dates requested once do not stay in memory. The caller must hold c.mu.
+func (c *CachedService) evictExpired(now time.Time) {
+	for key, entry := range c.entries {
+		if !now.Before(entry.expiresAt) {
+			delete(c.entries, key)
+		}
+	}
+}
+
+// CalculateAccountPerformanceContext returns the cached performance of an account over a period
+func (c *CachedService) CalculateAccountPerformanceContext(ctx context.Context, accountID string, period string, opts Options) (*Performance, error) {
+	return c.cached(cacheKey{scope: accountID, period: period}, opts, func() (*Performance, error) {
+		return c.Service.CalculateAccountPerformanceContext(ctx, accountID, period, opts)
+	})
+}
+
+// CalculateGlobalPerformanceContext returns the cached portfolio performance over a period
+func (c *CachedService) CalculateGlobalPerformanceContext(ctx context.Context, period string, opts Options) (*Performance, error) {
+	return c.cached(cacheKey{scope: globalScope, period: period}, opts, func() (*Performance, error) {
+		return c.Service.CalculateGlobalPerformanceContext(ctx, period, opts)
+	})
+}
+
+// CalculateAccountPerformanceRangeContext returns the cached performance of an account between two dates
+func (c *CachedService) CalculateAccountPerformanceRangeContext(ctx context.Context, accountID string, startDate, endDate time.Time, opts Options) (*Performance, error) {
+	return c.cached(cacheKey{scope: accountID, start: startDate, end: endDate}, opts, func() (*Performance, error) {
+		return c.Service.CalculateAccountPerformanceRangeContext(ctx, accountID, startDate, endDate, opts)
+	})
+}
+
+// CalculateGlobalPerformanceRangeContext returns the cached portfolio performance between two dates
+func (c *CachedService) CalculateGlobalPerformanceRangeContext(ctx context.Context, startDate, endDate time.Time, opts Options) (*Performance, error) {
+	return c.cached(cacheKey{scope: globalScope, start: startDate, end: endDate}, opts, func() (*Performance, error) {
+		return c.Service.CalculateGlobalPerformanceRangeContext(ctx, startDate, endDate, opts)
+	})
+}
+
+// CalculateAccountPerformanceAsOfContext returns the cached performance of an account valued at asOf
+func (c *CachedService) CalculateAccountPerformanceAsOfContext(ctx context.Context, accountID string, period string, asOf time.Time, opts Options) (*Performance, error) {
+	return c.cached(cacheKey{scope: accountID, period: period, asOf: asOf}, opts, func() (*Performance, error) {
+		return c.Service.CalculateAccountPerformanceAsOfContext(ctx, accountID, period, asOf, opts)
+	})
+}
+
+// CalculateGlobalPerformanceAsOfContext returns the cached portfolio performance valued at asOf
+func (c *CachedService) CalculateGlobalPerformanceAsOfContext(ctx context.Context, period string, asOf time.Time, opts Options) (*Performance, error) {
+	return c.cached(cacheKey{scope: globalScope, period: period, asOf: asOf}, opts, func() (*Performance, error) {
+		return c.Service.CalculateGlobalPerformanceAsOfContext(ctx, period, asOf, opts)
+	})
+}
@@ -0,0 +1,181 @@
+package performance
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+	"valhafin/internal/domain/models"
+)
+
+// countingService calculates the performance of fixed transactions and counts the calculations
+type countingService struct {
+	Service
+	service      *PerformanceService
+	transactions []models.Transaction
+	calls        int
+	// during is called while a calculation runs
+	during func()
+}
+
+func (s *countingService) calculate() (*Performance, error) {
+	s.calls++
+	if s.during != nil {
+		s.during()
+	}
+	startDate, endDate := calculateDateRange("1y")
+	return s.service.calculatePerformance(s.transactions, startDate, endDate)
+}
+
+func (s *countingService) CalculateGlobalPerformanceContext(ctx context.Context, period string, opts Options) (*Performance, error) {
+	return s.calculate()
+}
+
+func (s *countingService) CalculateAccountPerformanceContext(ctx context.Context, accountID string, period string, opts Options) (*Performance, error) {
+	return s.calculate()
+}
+
+// newCountingService returns a service over n buys and sells of a few assets
+func newCountingService(n int) *countingService {
+	transactions := make([]models.Transaction, 0, n+1)
+	start := time.Now().AddDate(-1, 0, 0)
+	transactions = append(transactions, models.Transaction{ID: "deposit", Timestamp: start.Format(time.RFC3339), TransactionType: "deposit", AmountValue: 1e6})
+	for i := 0; i < n; i++ {
+		isin := fmt.Sprintf("US%010d", i%10)
+		tx := models.Transaction{
+			ID:              fmt.Sprintf("tx-%d", i),
+			Timestamp:       start.Add(time.Duration(i) * time.Hour).Format(time.RFC3339),
+			TransactionType: "buy",
+			AmountValue:     -100,
+			Quantity:        1,
+			Fees:            "1,00 €",
+			ISIN:            &isin,
+		}
+		if i%4 == 3 {
+			tx.TransactionType, tx.AmountValue = "sell", 110
+		}
+		transactions = append(transactions, tx)
+	}
+	return &countingService{service: &PerformanceService{PriceService: NewMockPriceService()}, transactions: transactions}
+}
+
+func TestCachedService_ReusesResultsUntilInvalidated(t *testing.T) {
+	inner := newCountingService(20)
+	cache := NewCachedService(inner, time.Minute)
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	first, err := cache.CalculateGlobalPerformanceContext(ctx, "1y", Options{})
+	if err != nil {
+		t.Fatalf("CalculateGlobalPerformanceContext() error = %v", err)
+	}
+	first.Benchmark = &BenchmarkComparison{Benchmark: "^GSPC"}
+	second, _ := cache.CalculateGlobalPerformanceContext(ctx, "1y", Options{})
+	if inner.calls != 1 {
+		t.Fatalf("expected the second request to be served from the cache, got %d calculations", inner.calls)
+	}
+	if second.Benchmark != nil || second.TotalValue != first.TotalValue {
+		t.Error("expected each request to get its own copy of the cached result")
+	}
+
+	// Other periods, scopes, sampling intervals and the hidden transactions option have their own results
+	cache.CalculateGlobalPerformanceContext(ctx, "1m", Options{})
+	cache.CalculateGlobalPerformanceContext(ctx, "1y", Options{IncludeHidden: true})
+	cache.CalculateGlobalPerformanceContext(ctx, "1y", Options{Interval: IntervalMonthly})
+	cache.CalculateAccountPerformanceContext(ctx, "acc-1", "1y", Options{})
+	cache.CalculateAccountPerformanceContext(ctx, "acc-2", "1y", Options{})
+	if inner.calls != 6 {
+		t.Fatalf("expected 6 calculations, got %d", inner.calls)
+	}
+
+	// A change in acc-1 drops its results and the portfolio ones, not those of acc-2
+	cache.Invalidate("acc-1")
+	cache.CalculateAccountPerformanceContext(ctx, "acc-2", "1y", Options{})
+	if inner.calls != 6 {
+		t.Errorf("expected acc-2 to stay cached, got %d calculations", inner.calls)
+	}
+	cache.CalculateAccountPerformanceContext(ctx, "acc-1", "1y", Options{})
+	cache.CalculateGlobalPerformanceContext(ctx, "1y", Options{})
+	if inner.calls != 8 {
+		t.Errorf("expected acc-1 and the portfolio to be calculated again, got %d calculations", inner.calls)
+	}
+
+	// Expired results are calculated again
+	now = now.Add(2 * time.Minute)
+	cache.CalculateAccountPerformanceContext(ctx, "acc-2", "1y", Options{})
+	if inner.calls != 9 {
+		t.Errorf("expected the expired result to be calculated again, got %d calculations", inner.calls)
+	}
+
+	cache.InvalidateAll()
+	cache.CalculateAccountPerformanceContext(ctx, "acc-2", "1y", Options{})
+	if inner.calls != 10 {
+		t.Errorf("expected InvalidateAll to drop every result, got %d calculations", inner.calls)
+	}
+}
+
+func TestCachedService_EvictsExpiredResults(t *testing.T) {
+	inner := newCountingService(5)
+	cache := NewCachedService(inner, time.Minute)
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	for _, period := range []string{"1m", "3m", "1y"} {
+		cache.CalculateGlobalPerformanceContext(ctx, period, Options{})
+	}
+	if len(cache.entries) != 3 {
+		t.Fatalf("expected 3 cached results, got %d", len(cache.entries))
+	}
+
+	// Storing a result after the others expired drops them
+	now = now.Add(2 * time.Minute)
+	cache.CalculateAccountPerformanceContext(ctx, "acc-1", "1y", Options{})
+	if len(cache.entries) != 1 {
+		t.Errorf("expected only the new result to stay cached, got %d results", len(cache.entries))
+	}
+	if _, ok := cache.entries[cacheKey{scope: "acc-1", period: "1y", interval: Options{}.interval()}]; !ok {
+		t.Error("expected the new result to be cached")
+	}
+}
+
+func TestCachedService_SkipsResultsOfChangedData(t *testing.T) {
+	inner := newCountingService(5)
+	cache := NewCachedService(inner, time.Minute)
+	// Transactions arrive while the performance is calculated
+	inner.during = func() { cache.Invalidate("acc-1") }
+
+	cache.CalculateGlobalPerformanceContext(context.Background(), "1y", Options{})
+	inner.during = nil
+	cache.CalculateGlobalPerformanceContext(context.Background(), "1y", Options{})
+	if inner.calls != 2 {
+		t.Errorf("expected the result calculated before the change not to be cached, got %d calculations", inner.calls)
+	}
+
+	var nilCache *CachedService
+	nilCache.Invalidate("acc-1")
+	nilCache.InvalidateAll()
+}
+
+// BenchmarkGlobalPerformance compares repeated dashboard requests with and without the cache
+func BenchmarkGlobalPerformance(b *testing.B) {
+	ctx := context.Background()
+	for _, bench := range []struct {
+		name    string
+		service func(inner Service) Service
+	}{
+		{"uncached", func(inner Service) Service { return inner }},
+		{"cached", func(inner Service) Service { return NewCachedService(inner, time.Minute) }},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			service := bench.service(newCountingService(500))
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := service.CalculateGlobalPerformanceContext(ctx, "1y", Options{}); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
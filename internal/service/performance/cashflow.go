@@ -0,0 +1,87 @@
+package performance
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+	"valhafin/internal/domain/models"
+	"valhafin/internal/repository/database"
+)
+
+// CashFlowEntry is the effect of a transaction on the cash balance
+type CashFlowEntry struct {
+	TransactionID string    `json:"transaction_id"`
+	Date          time.Time `json:"date"`
+	Type          string    `json:"type"`
+	// Amount is the cash moved by the transaction, fees included: positive for deposits,
+	// sales, interests and dividends, negative for withdrawals, buys and fees
+	Amount float64 `json:"amount"`
+	// RunningBalance is the cash balance after the transaction
+	RunningBalance float64 `json:"running_balance"`
+}
+
+// CashFlowStatement is the chronological cash ledger of an account
+type CashFlowStatement struct {
+	AccountID string          `json:"account_id"`
+	Currency  string          `json:"currency"`
+	Entries   []CashFlowEntry `json:"entries"`
+	// Balance is the running balance after the last entry, the cash balance of the account
+	Balance float64 `json:"balance"`
+}
+
+// CashLedger replays the transactions in chronological order and returns their effect on the
+// cash balance, with the same signs as calculateCashBalance. Transactions without effect on
+// the cash (e.g. a buy without asset) are left out.
+func CashLedger(transactions []models.Transaction) []CashFlowEntry {
+	sortedTxs := make([]models.Transaction, len(transactions))
+	copy(sortedTxs, transactions)
+	sort.SliceStable(sortedTxs, func(i, j int) bool {
+		ti, _ := time.Parse(time.RFC3339, sortedTxs[i].Timestamp)
+		tj, _ := time.Parse(time.RFC3339, sortedTxs[j].Timestamp)
+		return ti.Before(tj)
+	})
+
+	embedded := embeddedFees(sortedTxs)
+	entries := []CashFlowEntry{}
+	var balance float64
+	for _, tx := range sortedTxs {
+		amount := cashEffect(tx, embedded)
+		if amount == 0 {
+			continue
+		}
+		balance += amount
+		date, _ := time.Parse(time.RFC3339, tx.Timestamp)
+		entries = append(entries, CashFlowEntry{
+			TransactionID:  tx.ID,
+			Date:           date,
+			Type:           tx.TransactionType,
+			Amount:         amount,
+			RunningBalance: balance,
+		})
+	}
+	return entries
+}
+
+// CalculateAccountCashFlowContext returns the cash ledger of an account over all its transactions
+func (s *PerformanceService) CalculateAccountCashFlowContext(ctx context.Context, accountID string, opts Options) (*CashFlowStatement, error) {
+	account, err := s.DB.GetAccountByIDContext(ctx, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account: %w", err)
+	}
+
+	transactions, err := s.DB.GetTransactionsByAccountContext(ctx, accountID, account.Platform, database.TransactionFilter{IncludeHidden: opts.IncludeHidden})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transactions: %w", err)
+	}
+
+	statement := &CashFlowStatement{
+		AccountID: account.ID,
+		Currency:  account.CurrencyOrDefault(),
+		Entries:   CashLedger(transactions),
+	}
+	if n := len(statement.Entries); n > 0 {
+		statement.Balance = statement.Entries[n-1].RunningBalance
+	}
+	return statement, nil
+}
@@ -0,0 +1,51 @@
+package performance
+
+import (
+	"testing"
+	"valhafin/internal/domain/models"
+)
+
+func TestCashLedger_MatchesCashBalance(t *testing.T) {
+	service := &PerformanceService{}
+	transactions := []models.Transaction{
+		// Listed out of order: the ledger follows the transaction dates
+		{ID: "sell", Timestamp: "2024-03-01T10:00:00Z", TransactionType: "sell", AmountValue: 600, Quantity: 5, Fees: "1,00 €", ISIN: stringPtr("IE00B4L5Y983")},
+		{ID: "deposit", Timestamp: "2024-01-01T10:00:00Z", TransactionType: "deposit", AmountValue: 2000},
+		{ID: "buy", Timestamp: "2024-01-02T10:00:00Z", TransactionType: "buy", AmountValue: -1000, Quantity: 10, Fees: "1,00 €", ISIN: stringPtr("IE00B4L5Y983")},
+		// Fee of the buy listed again as a transaction: not counted twice
+		{ID: "buy-fee", Timestamp: "2024-01-02T10:00:00Z", TransactionType: "fee", AmountValue: -1},
+		{ID: "interest", Timestamp: "2024-02-01T10:00:00Z", TransactionType: "interest", AmountValue: 3.21},
+		{ID: "custody-fee", Timestamp: "2024-02-15T10:00:00Z", TransactionType: "fee", AmountValue: -4.99},
+		{ID: "withdrawal", Timestamp: "2024-04-01T10:00:00Z", TransactionType: "withdrawal", AmountValue: -500},
+		// No cash effect
+		{ID: "buy-no-asset", Timestamp: "2024-04-02T10:00:00Z", TransactionType: "buy", AmountValue: -10},
+	}
+
+	entries := CashLedger(transactions)
+
+	expected := []struct {
+		id      string
+		amount  float64
+		balance float64
+	}{
+		{"deposit", 2000, 2000},
+		{"buy", -1001, 999},
+		{"interest", 3.21, 1002.21},
+		{"custody-fee", -4.99, 997.22},
+		{"sell", 599, 1596.22},
+		{"withdrawal", -500, 1096.22},
+	}
+	if len(entries) != len(expected) {
+		t.Fatalf("expected %d entries, got %+v", len(expected), entries)
+	}
+	for i, want := range expected {
+		entry := entries[i]
+		if entry.TransactionID != want.id || !floatEquals(entry.Amount, want.amount, 0.001) || !floatEquals(entry.RunningBalance, want.balance, 0.001) {
+			t.Errorf("entry %d: expected %s %v (balance %v), got %+v", i, want.id, want.amount, want.balance, entry)
+		}
+	}
+
+	if cash := service.calculateCashBalance(transactions); !floatEquals(entries[len(entries)-1].RunningBalance, cash, 1e-9) {
+		t.Errorf("expected the final running balance to match the cash balance %v, got %v", cash, entries[len(entries)-1].RunningBalance)
+	}
+}
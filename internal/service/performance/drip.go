@@ -0,0 +1,59 @@
+package performance
+
+import (
+	"math"
+	"sort"
+	"time"
+	"valhafin/internal/domain/models"
+)
+
+// dripMaxDelay is the maximum delay between a dividend and its reinvestment
+const dripMaxDelay = 24 * time.Hour
+
+// DetectDRIP finds dividend reinvestments: a dividend immediately followed (within a day) by a
+// buy of the same ISIN for the same amount. Returns the IDs of both transactions of each pair:
+// the dividend is not received as cash and the shares it bought enter the position at zero cost.
+func DetectDRIP(transactions []models.Transaction) map[string]bool {
+	drip := make(map[string]bool)
+
+	// Group transactions by ISIN in chronological order
+	byISIN := make(map[string][]models.Transaction)
+	for _, tx := range transactions {
+		if tx.ISIN == nil || *tx.ISIN == "" {
+			continue
+		}
+		byISIN[*tx.ISIN] = append(byISIN[*tx.ISIN], tx)
+	}
+
+	for _, txs := range byISIN {
+		sort.SliceStable(txs, func(i, j int) bool {
+			return txs[i].Timestamp < txs[j].Timestamp
+		})
+
+		for i := 0; i+1 < len(txs); i++ {
+			dividend, next := txs[i], txs[i+1]
+			if dividend.TransactionType != "dividend" || next.TransactionType != "buy" {
+				continue
+			}
+
+			dividendTime, err1 := time.Parse(time.RFC3339, dividend.Timestamp)
+			buyTime, err2 := time.Parse(time.RFC3339, next.Timestamp)
+			if err1 != nil || err2 != nil || buyTime.Sub(dividendTime) > dripMaxDelay {
+				continue
+			}
+
+			// Amounts match within a cent or 1% (rounding of fractional shares)
+			dividendAmount := math.Abs(dividend.ExactAmount())
+			buyAmount := math.Abs(next.ExactAmount())
+			if dividendAmount == 0 || math.Abs(buyAmount-dividendAmount) > math.Max(0.01, dividendAmount*0.01) {
+				continue
+			}
+
+			drip[dividend.ID] = true
+			drip[next.ID] = true
+			i++ // The buy cannot start another pair
+		}
+	}
+
+	return drip
+}
@@ -0,0 +1,100 @@
+package performance
+
+import (
+	"testing"
+	"time"
+	"valhafin/internal/domain/models"
+)
+
+func TestDetectDRIP(t *testing.T) {
+	isin := "IE00B4L5Y983"
+	other := "US0378331005"
+
+	transactions := []models.Transaction{
+		// Regular buy
+		{ID: "buy-1", ISIN: &isin, TransactionType: "buy", Timestamp: "2024-01-10T10:00:00Z", AmountValue: -1000, Quantity: 10},
+		// Dividend reinvested a few minutes later for the same amount
+		{ID: "div-1", ISIN: &isin, TransactionType: "dividend", Timestamp: "2024-03-15T08:00:00Z", AmountValue: 12.34},
+		{ID: "drip-1", ISIN: &isin, TransactionType: "buy", Timestamp: "2024-03-15T08:05:00Z", AmountValue: -12.34, Quantity: 0.12},
+		// Dividend followed by an unrelated, larger buy
+		{ID: "div-2", ISIN: &isin, TransactionType: "dividend", Timestamp: "2024-06-15T08:00:00Z", AmountValue: 13.00},
+		{ID: "buy-2", ISIN: &isin, TransactionType: "buy", Timestamp: "2024-06-15T09:00:00Z", AmountValue: -500, Quantity: 5},
+		// Dividend whose matching buy comes too late
+		{ID: "div-3", ISIN: &isin, TransactionType: "dividend", Timestamp: "2024-09-15T08:00:00Z", AmountValue: 14.00},
+		{ID: "buy-3", ISIN: &isin, TransactionType: "buy", Timestamp: "2024-09-18T08:00:00Z", AmountValue: -14.00, Quantity: 0.13},
+		// Dividend on another asset with a same-amount buy of the first asset
+		{ID: "div-4", ISIN: &other, TransactionType: "dividend", Timestamp: "2024-12-15T08:00:00Z", AmountValue: 5.00},
+		{ID: "buy-4", ISIN: &isin, TransactionType: "buy", Timestamp: "2024-12-15T08:01:00Z", AmountValue: -5.00, Quantity: 0.05},
+		// Reinvestment listed before its dividend (descending order from the database) with rounding
+		{ID: "drip-5", ISIN: &other, TransactionType: "buy", Timestamp: "2025-03-15T08:01:00Z", AmountValue: -7.99, Quantity: 0.04},
+		{ID: "div-5", ISIN: &other, TransactionType: "dividend", Timestamp: "2025-03-15T08:00:00Z", AmountValue: 8.00},
+	}
+
+	drip := DetectDRIP(transactions)
+
+	want := map[string]bool{"div-1": true, "drip-1": true, "div-5": true, "drip-5": true}
+	if len(drip) != len(want) {
+		t.Fatalf("DetectDRIP() = %v, want %v", drip, want)
+	}
+	for id := range want {
+		if !drip[id] {
+			t.Errorf("expected %s to be detected as part of a reinvestment", id)
+		}
+	}
+}
+
+func TestDetectDRIP_NoDividends(t *testing.T) {
+	isin := "IE00B4L5Y983"
+	transactions := []models.Transaction{
+		{ID: "buy-1", ISIN: &isin, TransactionType: "buy", Timestamp: "2024-01-10T10:00:00Z", AmountValue: -100, Quantity: 1},
+		{ID: "deposit", TransactionType: "deposit", Timestamp: "2024-01-09T10:00:00Z", AmountValue: 100},
+	}
+
+	if drip := DetectDRIP(transactions); len(drip) != 0 {
+		t.Errorf("DetectDRIP() = %v, want no reinvestment", drip)
+	}
+}
+
+// TestCalculatePerformance_DRIPNotCountedTwice tests that a reinvested dividend adds shares
+// without adding to the invested capital or to the dividend income
+func TestCalculatePerformance_DRIPNotCountedTwice(t *testing.T) {
+	isin := "IE00B4L5Y983"
+	mockPriceService := NewMockPriceService()
+	mockPriceService.SetPrice(isin, 110)
+	service := &PerformanceService{PriceService: mockPriceService}
+
+	transactions := []models.Transaction{
+		{ID: "tx1", Timestamp: "2024-01-01T10:00:00Z", TransactionType: "deposit", AmountValue: 2000},
+		{ID: "tx2", Timestamp: "2024-01-02T10:00:00Z", TransactionType: "buy", AmountValue: -1000, Quantity: 10, ISIN: &isin},
+	}
+	withDRIP := append(append([]models.Transaction{}, transactions...),
+		models.Transaction{ID: "div", Timestamp: "2024-03-15T08:00:00Z", TransactionType: "dividend", AmountValue: 22, ISIN: &isin},
+		models.Transaction{ID: "drip", Timestamp: "2024-03-15T08:05:00Z", TransactionType: "buy", AmountValue: -22, Quantity: 0.2, ISIN: &isin},
+	)
+
+	startDate := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	endDate := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	before, err := service.calculatePerformance(transactions, startDate, endDate)
+	if err != nil {
+		t.Fatalf("calculatePerformance failed: %v", err)
+	}
+	after, err := service.calculatePerformance(withDRIP, startDate, endDate)
+	if err != nil {
+		t.Fatalf("calculatePerformance failed: %v", err)
+	}
+
+	if after.TotalInvested != before.TotalInvested {
+		t.Errorf("reinvestment changed invested capital: %v vs %v", after.TotalInvested, before.TotalInvested)
+	}
+	if after.DividendIncome != before.DividendIncome || after.RealizedGains != before.RealizedGains {
+		t.Errorf("reinvested dividend counted as income: dividends %v vs %v, realized %v vs %v",
+			after.DividendIncome, before.DividendIncome, after.RealizedGains, before.RealizedGains)
+	}
+	if !floatEquals(after.CashBalance, before.CashBalance, 0.001) {
+		t.Errorf("reinvestment moved cash: %v vs %v", after.CashBalance, before.CashBalance)
+	}
+	// The 0.2 shares bought with the dividend are worth 22 more
+	if !floatEquals(after.TotalValue, before.TotalValue+22, 0.001) {
+		t.Errorf("expected the reinvested shares in the total value (%v), got %v", before.TotalValue+22, after.TotalValue)
+	}
+}
@@ -0,0 +1,168 @@
+package performance
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+	"valhafin/internal/domain/models"
+	"valhafin/internal/repository/database"
+)
+
+// Lot is an open purchase lot: what remains of a buy once the sales of its account have
+// consumed the oldest lots first (FIFO)
+type Lot struct {
+	TransactionID string    `json:"transaction_id"`
+	AccountID     string    `json:"account_id"`
+	Date          time.Time `json:"date"`
+	// Quantity is the quantity still held from the buy, out of BoughtQuantity
+	Quantity       float64 `json:"quantity"`
+	BoughtQuantity float64 `json:"bought_quantity"`
+	UnitCost       float64 `json:"unit_cost"`
+	// Cost is the cost of the quantity still held
+	Cost              float64 `json:"cost"`
+	CurrentValue      float64 `json:"current_value"`
+	UnrealizedGain    float64 `json:"unrealized_gain"`
+	UnrealizedGainPct float64 `json:"unrealized_gain_pct"`
+}
+
+// AssetLots lists the open purchase lots of an asset valued at its current price
+type AssetLots struct {
+	ISIN         string  `json:"isin"`
+	Name         string  `json:"name"`
+	CurrentPrice float64 `json:"current_price"`
+	Lots         []Lot   `json:"lots"`
+}
+
+// OpenLots replays the buys, sells and corporate actions of an asset and returns the open lots, oldest first.
+// Each account has its own lots: a sale consumes the oldest lots of its account, and a sale
+// exceeding them is ignored beyond the lots held.
+func OpenLots(transactions []models.Transaction) []Lot {
+	sortedTxs := make([]models.Transaction, len(transactions))
+	copy(sortedTxs, transactions)
+	sort.SliceStable(sortedTxs, func(i, j int) bool {
+		ti, _ := time.Parse(time.RFC3339, sortedTxs[i].Timestamp)
+		tj, _ := time.Parse(time.RFC3339, sortedTxs[j].Timestamp)
+		return ti.Before(tj)
+	})
+
+	lotsByAccount := make(map[string][]Lot)
+	for _, tx := range sortedTxs {
+		switch tx.TransactionType {
+		case "buy":
+			if tx.Quantity <= 0 {
+				continue
+			}
+			date, _ := time.Parse(time.RFC3339, tx.Timestamp)
+			// The cost of the purchase, as a positive value like in calculateAssetPerformance
+			cost := tx.ExactAmount()
+			if cost < 0 {
+				cost = -cost
+			}
+			lotsByAccount[tx.AccountID] = append(lotsByAccount[tx.AccountID], Lot{
+				TransactionID:  tx.ID,
+				AccountID:      tx.AccountID,
+				Date:           date,
+				Quantity:       tx.Quantity,
+				BoughtQuantity: tx.Quantity,
+				UnitCost:       cost / tx.Quantity,
+				Cost:           cost,
+			})
+		case "sell":
+			lotsByAccount[tx.AccountID] = sellFIFO(lotsByAccount[tx.AccountID], tx.Quantity)
+		case models.TransactionTypeCorporateAction:
+			// Shares received enter as a lot at zero cost, shares given up leave the oldest lots
+			delta := tx.CorporateActionQuantity()
+			if delta < 0 {
+				lotsByAccount[tx.AccountID] = sellFIFO(lotsByAccount[tx.AccountID], -delta)
+			} else if delta > 0 {
+				date, _ := time.Parse(time.RFC3339, tx.Timestamp)
+				lotsByAccount[tx.AccountID] = append(lotsByAccount[tx.AccountID], Lot{
+					TransactionID:  tx.ID,
+					AccountID:      tx.AccountID,
+					Date:           date,
+					Quantity:       delta,
+					BoughtQuantity: delta,
+				})
+			}
+		}
+	}
+
+	lots := []Lot{}
+	for _, accountLots := range lotsByAccount {
+		lots = append(lots, accountLots...)
+	}
+	sort.SliceStable(lots, func(i, j int) bool {
+		if !lots[i].Date.Equal(lots[j].Date) {
+			return lots[i].Date.Before(lots[j].Date)
+		}
+		return lots[i].TransactionID < lots[j].TransactionID
+	})
+	return lots
+}
+
+// sellFIFO removes sold shares from the oldest lots and returns the lots still open
+func sellFIFO(lots []Lot, sold float64) []Lot {
+	for sold > QuantityTolerance && len(lots) > 0 {
+		lot := &lots[0]
+		if lot.Quantity-sold > QuantityTolerance {
+			lot.Quantity -= sold
+			lot.Cost = lot.UnitCost * lot.Quantity
+			return lots
+		}
+		sold -= lot.Quantity
+		lots = lots[1:]
+	}
+	return lots
+}
+
+// ValueLots sets the current value and the unrealized gain of the lots at currentPrice
+func ValueLots(lots []Lot, currentPrice float64) {
+	for i := range lots {
+		lot := &lots[i]
+		lot.CurrentValue = lot.Quantity * currentPrice
+		lot.UnrealizedGain = lot.CurrentValue - lot.Cost
+		lot.UnrealizedGainPct = 0
+		if lot.Cost > 0 {
+			lot.UnrealizedGainPct = lot.UnrealizedGain / lot.Cost * 100
+		}
+	}
+}
+
+// CalculateAssetLotsContext returns the open purchase lots of an asset across all accounts,
+// valued at its current price
+func (s *PerformanceService) CalculateAssetLotsContext(ctx context.Context, isin string, opts Options) (*AssetLots, error) {
+	asset, err := s.DB.GetAssetByISINContext(ctx, isin)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get asset: %w", err)
+	}
+
+	currentPrice, err := s.PriceService.GetCurrentPrice(isin)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current price: %w", err)
+	}
+
+	accounts, err := s.DB.GetAllAccountsContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get accounts: %w", err)
+	}
+
+	var assetTransactions []models.Transaction
+	for _, account := range accounts {
+		transactions, err := s.DB.GetTransactionsByAccountContext(ctx, account.ID, account.Platform, database.TransactionFilter{ISIN: isin, IncludeHidden: opts.IncludeHidden})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get transactions for account %s: %w", account.ID, err)
+		}
+		assetTransactions = append(assetTransactions, transactions...)
+	}
+
+	lots := OpenLots(assetTransactions)
+	ValueLots(lots, currentPrice.Price)
+
+	return &AssetLots{
+		ISIN:         asset.ISIN,
+		Name:         asset.Name,
+		CurrentPrice: currentPrice.Price,
+		Lots:         lots,
+	}, nil
+}
@@ -0,0 +1,82 @@
+package performance
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+	"valhafin/internal/domain/models"
+)
+
+func lotTransaction(id, accountID, timestamp, txType string, quantity, amount float64) models.Transaction {
+	return models.Transaction{
+		ID:              id,
+		AccountID:       accountID,
+		Timestamp:       timestamp,
+		TransactionType: txType,
+		Quantity:        quantity,
+		AmountValue:     amount,
+		AmountCurrency:  "EUR",
+	}
+}
+
+func TestOpenLots_FIFO(t *testing.T) {
+	transactions := []models.Transaction{
+		// Listed out of order: lots follow the transaction dates
+		lotTransaction("buy-3", "acc-1", "2024-03-01T10:00:00Z", "buy", 10, -1200),
+		lotTransaction("buy-1", "acc-1", "2024-01-01T10:00:00Z", "buy", 10, -1000),
+		lotTransaction("buy-2", "acc-1", "2024-02-01T10:00:00Z", "buy", 5, -550),
+		lotTransaction("sell-1", "acc-1", "2024-04-01T10:00:00Z", "sell", 12, 1500),
+		// Sales of another account do not consume these lots
+		lotTransaction("buy-other", "acc-2", "2024-01-15T10:00:00Z", "buy", 2, -180),
+	}
+
+	lots := OpenLots(transactions)
+	if len(lots) != 3 {
+		t.Fatalf("expected 3 open lots, got %+v", lots)
+	}
+
+	expected := []struct {
+		id       string
+		quantity float64
+		cost     float64
+	}{
+		{"buy-other", 2, 180},
+		{"buy-2", 3, 330}, // 2 of the 5 shares were sold with the first lot
+		{"buy-3", 10, 1200},
+	}
+	for i, want := range expected {
+		lot := lots[i]
+		if lot.TransactionID != want.id || math.Abs(lot.Quantity-want.quantity) > 1e-9 || math.Abs(lot.Cost-want.cost) > 1e-9 {
+			t.Errorf("lot %d: expected %s with %v shares costing %v, got %+v", i, want.id, want.quantity, want.cost, lot)
+		}
+	}
+	if lots[1].BoughtQuantity != 5 || math.Abs(lots[1].UnitCost-110) > 1e-9 {
+		t.Errorf("expected the bought quantity and unit cost of the partially sold lot, got %+v", lots[1])
+	}
+
+	ValueLots(lots, 100)
+	if math.Abs(lots[1].CurrentValue-300) > 1e-9 || math.Abs(lots[1].UnrealizedGain+30) > 1e-9 {
+		t.Errorf("expected a 30 loss on the lot bought at 110, got %+v", lots[1])
+	}
+	if math.Abs(lots[0].UnrealizedGainPct-(200.0-180.0)/180.0*100) > 1e-9 {
+		t.Errorf("unexpected gain percentage %v", lots[0].UnrealizedGainPct)
+	}
+}
+
+func TestOpenLots_FullySold(t *testing.T) {
+	lots := OpenLots([]models.Transaction{
+		lotTransaction("buy-1", "acc-1", "2024-01-01T10:00:00Z", "buy", 0.1, -10),
+		lotTransaction("buy-2", "acc-1", "2024-01-02T10:00:00Z", "buy", 0.2, -20),
+		lotTransaction("sell-1", "acc-1", "2024-01-03T10:00:00Z", "sell", 0.3, 35),
+		// An oversold position has no lot left
+		lotTransaction("sell-2", "acc-1", "2024-01-04T10:00:00Z", "sell", 1, 100),
+	})
+	if len(lots) != 0 {
+		t.Errorf("expected no open lot, got %+v", lots)
+	}
+
+	data, _ := json.Marshal(AssetLots{ISIN: "US0378331005", Lots: lots})
+	if string(data) != `{"isin":"US0378331005","name":"","current_price":0,"lots":[]}` {
+		t.Errorf("expected an empty list of lots, got %s", data)
+	}
+}
@@ -1,7 +1,12 @@
 package performance
 
 import (
+	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"math"
+	"sort"
 	"time"
 	"valhafin/internal/domain/models"
 	"valhafin/internal/repository/database"
@@ -13,6 +18,40 @@ type Service interface {
 	CalculateAccountPerformance(accountID string, period string) (*Performance, error)
 	CalculateGlobalPerformance(period string) (*Performance, error)
 	CalculateAssetPerformance(isin string, period string) (*AssetPerformance, error)
+	// The Context variants take the options of the request
+	CalculateAccountPerformanceContext(ctx context.Context, accountID string, period string, opts Options) (*Performance, error)
+	CalculateGlobalPerformanceContext(ctx context.Context, period string, opts Options) (*Performance, error)
+	CalculateAssetPerformanceContext(ctx context.Context, isin string, period string, opts Options) (*AssetPerformance, error)
+	CalculateAssetHoldingsHistoryContext(ctx context.Context, isin string, period string, opts Options) (*HoldingsHistory, error)
+	// The Range variants take an explicit date range instead of a period
+	CalculateAccountPerformanceRangeContext(ctx context.Context, accountID string, startDate, endDate time.Time, opts Options) (*Performance, error)
+	CalculateGlobalPerformanceRangeContext(ctx context.Context, startDate, endDate time.Time, opts Options) (*Performance, error)
+	CalculateAssetPerformanceRangeContext(ctx context.Context, isin string, startDate, endDate time.Time, opts Options) (*AssetPerformance, error)
+	CalculateAssetHoldingsHistoryRangeContext(ctx context.Context, isin string, startDate, endDate time.Time, opts Options) (*HoldingsHistory, error)
+	CalculateAssetLotsContext(ctx context.Context, isin string, opts Options) (*AssetLots, error)
+	CalculateAccountCashFlowContext(ctx context.Context, accountID string, opts Options) (*CashFlowStatement, error)
+	// The AsOf variants value the portfolio at a past date instead of now
+	CalculateAccountPerformanceAsOfContext(ctx context.Context, accountID string, period string, asOf time.Time, opts Options) (*Performance, error)
+	CalculateGlobalPerformanceAsOfContext(ctx context.Context, period string, asOf time.Time, opts Options) (*Performance, error)
+	// HistoricalPrice returns the last price stored for an asset at a date, or an error when
+	// none is stored (the current price is never substituted)
+	HistoricalPrice(isin string, date time.Time) (float64, error)
+}
+
+// Options are the options of a calculation requested by a client
+type Options struct {
+	// IncludeHidden keeps the transactions flagged as hidden, which are excluded by default
+	IncludeHidden bool
+	// Interval is the sampling interval of the performance time series, IntervalAuto when empty
+	Interval string
+}
+
+// interval returns the sampling interval of the options, IntervalAuto by default
+func (o Options) interval() string {
+	if o.Interval == "" {
+		return IntervalAuto
+	}
+	return o.Interval
 }
 
 // PerformanceService implements the Service interface
@@ -38,7 +77,51 @@ type Performance struct {
 	RealizedGains   float64            `json:"realized_gains"`
 	UnrealizedGains float64            `json:"unrealized_gains"`
 	PerformancePct  float64            `json:"performance_pct"`
+	TotalDeposits   float64            `json:"total_deposits"`  // Deposits net of withdrawals
+	DividendIncome  float64            `json:"dividend_income"` // Dividends, part of the investment return
+	TotalInterest   float64            `json:"total_interest"`  // Interest paid on cash, not an investment return
+	CashOnly        bool               `json:"cash_only"`       // No security was ever traded (e.g. a savings account)
 	TimeSeries      []PerformancePoint `json:"time_series"`
+	// UnpricedAssets lists the ISINs of the held assets without price, valued at their
+	// invested amount (so without gain or loss), usually because their symbol is not resolved
+	UnpricedAssets []string `json:"unpriced_assets"`
+	// Benchmark is only set when a benchmark comparison is requested
+	Benchmark *BenchmarkComparison `json:"benchmark,omitempty"`
+}
+
+// AccountSummary is a compact overview of an account, meaningful for
+// cash-only accounts as well as securities accounts
+type AccountSummary struct {
+	AccountID      string  `json:"account_id"`
+	Name           string  `json:"name"`
+	Platform       string  `json:"platform"`
+	CashOnly       bool    `json:"cash_only"`
+	CashBalance    float64 `json:"cash_balance"`
+	AssetsValue    float64 `json:"assets_value"`
+	TotalValue     float64 `json:"total_value"` // Assets value plus cash balance
+	TotalInvested  float64 `json:"total_invested"`
+	TotalDeposits  float64 `json:"total_deposits"`
+	TotalInterest  float64 `json:"total_interest"`
+	TotalFees      float64 `json:"total_fees"`
+	PerformancePct float64 `json:"performance_pct"`
+}
+
+// Summarize builds the summary of an account from its all-time performance
+func Summarize(account *models.Account, perf *Performance) AccountSummary {
+	return AccountSummary{
+		AccountID:      account.ID,
+		Name:           account.Name,
+		Platform:       account.Platform,
+		CashOnly:       perf.CashOnly,
+		CashBalance:    perf.CashBalance,
+		AssetsValue:    perf.TotalValue,
+		TotalValue:     perf.TotalValue + perf.CashBalance,
+		TotalInvested:  perf.TotalInvested,
+		TotalDeposits:  perf.TotalDeposits,
+		TotalInterest:  perf.TotalInterest,
+		TotalFees:      perf.TotalFees,
+		PerformancePct: perf.PerformancePct,
+	}
 }
 
 // PerformancePoint represents a point in the performance time series
@@ -48,6 +131,22 @@ type PerformancePoint struct {
 	Invested float64   `json:"invested"` // Amount invested in assets (cost basis)
 }
 
+// HoldingsPoint represents the quantity of an asset held at a point in time
+type HoldingsPoint struct {
+	Date     time.Time `json:"date"`
+	Quantity float64   `json:"quantity"`
+
+	// invested is the remaining cost basis, used by the value time series
+	invested float64
+}
+
+// HoldingsHistory represents the quantity held of an asset over a period
+type HoldingsHistory struct {
+	ISIN     string          `json:"isin"`
+	Period   string          `json:"period"`
+	Holdings []HoldingsPoint `json:"holdings"`
+}
+
 // AssetPerformance represents performance metrics for a specific asset
 type AssetPerformance struct {
 	ISIN            string             `json:"isin"`
@@ -65,50 +164,96 @@ type AssetPerformance struct {
 
 // CalculateAccountPerformance calculates performance for a specific account
 func (s *PerformanceService) CalculateAccountPerformance(accountID string, period string) (*Performance, error) {
+	return s.CalculateAccountPerformanceContext(context.Background(), accountID, period, Options{})
+}
+
+// CalculateAccountPerformanceContext is like CalculateAccountPerformance but database reads are canceled with ctx
+func (s *PerformanceService) CalculateAccountPerformanceContext(ctx context.Context, accountID string, period string, opts Options) (*Performance, error) {
+	startDate, endDate := calculateDateRange(period)
+	return s.CalculateAccountPerformanceRangeContext(ctx, accountID, startDate, endDate, opts)
+}
+
+// CalculateAccountPerformanceRangeContext calculates the performance of an account between two dates
+func (s *PerformanceService) CalculateAccountPerformanceRangeContext(ctx context.Context, accountID string, startDate, endDate time.Time, opts Options) (*Performance, error) {
+	return s.accountPerformance(ctx, accountID, startDate, endDate, nil, opts)
+}
+
+// CalculateAccountPerformanceAsOfContext calculates the performance of an account over the
+// period ending at asOf, replaying the transactions up to asOf and valuing the holdings at
+// the prices of that date
+func (s *PerformanceService) CalculateAccountPerformanceAsOfContext(ctx context.Context, accountID string, period string, asOf time.Time, opts Options) (*Performance, error) {
+	startDate, endDate := dateRangeAt(period, asOf)
+	return s.accountPerformance(ctx, accountID, startDate, endDate, &endDate, opts)
+}
+
+// accountPerformance calculates the performance of an account between two dates, valued at
+// the current prices or, when valuedAt is set, at the prices of that date
+func (s *PerformanceService) accountPerformance(ctx context.Context, accountID string, startDate, endDate time.Time, valuedAt *time.Time, opts Options) (*Performance, error) {
 	// Get account to determine platform
-	account, err := s.DB.GetAccountByID(accountID)
+	account, err := s.DB.GetAccountByIDContext(ctx, accountID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get account: %w", err)
 	}
 
-	// Calculate date range based on period
-	startDate, endDate := calculateDateRange(period)
-
 	// Get transactions for the account
 	filter := database.TransactionFilter{
-		StartDate: startDate.Format(time.RFC3339),
-		EndDate:   endDate.Format(time.RFC3339),
+		StartDate:     startDate.Format(time.RFC3339),
+		EndDate:       endDate.Format(time.RFC3339),
+		IncludeHidden: opts.IncludeHidden,
 	}
 
-	transactions, err := s.DB.GetTransactionsByAccount(accountID, account.Platform, filter)
+	transactions, err := s.DB.GetTransactionsByAccountContext(ctx, accountID, account.Platform, filter)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get transactions: %w", err)
 	}
 
 	// Calculate performance
-	return s.calculatePerformance(transactions, startDate, endDate)
+	return s.calculatePerformanceAt(transactions, startDate, endDate, valuedAt, opts.interval())
 }
 
 // CalculateGlobalPerformance calculates performance across all accounts
 func (s *PerformanceService) CalculateGlobalPerformance(period string) (*Performance, error) {
+	return s.CalculateGlobalPerformanceContext(context.Background(), period, Options{})
+}
+
+// CalculateGlobalPerformanceContext is like CalculateGlobalPerformance but database reads are canceled with ctx
+func (s *PerformanceService) CalculateGlobalPerformanceContext(ctx context.Context, period string, opts Options) (*Performance, error) {
+	startDate, endDate := calculateDateRange(period)
+	return s.CalculateGlobalPerformanceRangeContext(ctx, startDate, endDate, opts)
+}
+
+// CalculateGlobalPerformanceRangeContext calculates the performance across all accounts between two dates
+func (s *PerformanceService) CalculateGlobalPerformanceRangeContext(ctx context.Context, startDate, endDate time.Time, opts Options) (*Performance, error) {
+	return s.globalPerformance(ctx, startDate, endDate, nil, opts)
+}
+
+// CalculateGlobalPerformanceAsOfContext calculates the performance across all accounts over
+// the period ending at asOf, valued at the prices of that date. The cash balance only counts
+// the transactions up to asOf.
+func (s *PerformanceService) CalculateGlobalPerformanceAsOfContext(ctx context.Context, period string, asOf time.Time, opts Options) (*Performance, error) {
+	startDate, endDate := dateRangeAt(period, asOf)
+	return s.globalPerformance(ctx, startDate, endDate, &endDate, opts)
+}
+
+// globalPerformance calculates the performance across all accounts between two dates, valued
+// at the current prices or, when valuedAt is set, at the prices of that date
+func (s *PerformanceService) globalPerformance(ctx context.Context, startDate, endDate time.Time, valuedAt *time.Time, opts Options) (*Performance, error) {
 	// Get all accounts
-	accounts, err := s.DB.GetAllAccounts()
+	accounts, err := s.DB.GetAllAccountsContext(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get accounts: %w", err)
 	}
 
-	// Calculate date range based on period
-	startDate, endDate := calculateDateRange(period)
-
 	// Collect filtered transactions (for period-specific metrics)
 	var filteredTransactions []models.Transaction
 	for _, account := range accounts {
 		filter := database.TransactionFilter{
-			StartDate: startDate.Format(time.RFC3339),
-			EndDate:   endDate.Format(time.RFC3339),
+			StartDate:     startDate.Format(time.RFC3339),
+			EndDate:       endDate.Format(time.RFC3339),
+			IncludeHidden: opts.IncludeHidden,
 		}
 
-		transactions, err := s.DB.GetTransactionsByAccount(account.ID, account.Platform, filter)
+		transactions, err := s.DB.GetTransactionsByAccountContext(ctx, account.ID, account.Platform, filter)
 		if err != nil {
 			return nil, fmt.Errorf("failed to get transactions for account %s: %w", account.ID, err)
 		}
@@ -120,10 +265,14 @@ func (s *PerformanceService) CalculateGlobalPerformance(period string) (*Perform
 	var allTransactions []models.Transaction
 	for _, account := range accounts {
 		filter := database.TransactionFilter{
-			Limit: 10000, // Get all transactions
+			Limit:         10000, // Get all transactions
+			IncludeHidden: opts.IncludeHidden,
+		}
+		if valuedAt != nil {
+			filter.EndDate = valuedAt.Format(time.RFC3339)
 		}
 
-		transactions, err := s.DB.GetTransactionsByAccount(account.ID, account.Platform, filter)
+		transactions, err := s.DB.GetTransactionsByAccountContext(ctx, account.ID, account.Platform, filter)
 		if err != nil {
 			return nil, fmt.Errorf("failed to get transactions for account %s: %w", account.ID, err)
 		}
@@ -132,7 +281,7 @@ func (s *PerformanceService) CalculateGlobalPerformance(period string) (*Perform
 	}
 
 	// Calculate performance with filtered transactions
-	performance, err := s.calculatePerformance(filteredTransactions, startDate, endDate)
+	performance, err := s.calculatePerformanceAt(filteredTransactions, startDate, endDate, valuedAt, opts.interval())
 	if err != nil {
 		return nil, err
 	}
@@ -146,8 +295,19 @@ func (s *PerformanceService) CalculateGlobalPerformance(period string) (*Perform
 
 // CalculateAssetPerformance calculates performance for a specific asset
 func (s *PerformanceService) CalculateAssetPerformance(isin string, period string) (*AssetPerformance, error) {
+	return s.CalculateAssetPerformanceContext(context.Background(), isin, period, Options{})
+}
+
+// CalculateAssetPerformanceContext is like CalculateAssetPerformance but database reads are canceled with ctx
+func (s *PerformanceService) CalculateAssetPerformanceContext(ctx context.Context, isin string, period string, opts Options) (*AssetPerformance, error) {
+	startDate, endDate := calculateDateRange(period)
+	return s.CalculateAssetPerformanceRangeContext(ctx, isin, startDate, endDate, opts)
+}
+
+// CalculateAssetPerformanceRangeContext calculates the performance of an asset between two dates
+func (s *PerformanceService) CalculateAssetPerformanceRangeContext(ctx context.Context, isin string, startDate, endDate time.Time, opts Options) (*AssetPerformance, error) {
 	// Get asset information
-	asset, err := s.DB.GetAssetByISIN(isin)
+	asset, err := s.DB.GetAssetByISINContext(ctx, isin)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get asset: %w", err)
 	}
@@ -158,11 +318,8 @@ func (s *PerformanceService) CalculateAssetPerformance(isin string, period strin
 		return nil, fmt.Errorf("failed to get current price: %w", err)
 	}
 
-	// Calculate date range based on period
-	startDate, endDate := calculateDateRange(period)
-
 	// Get all transactions for this asset across all accounts
-	accounts, err := s.DB.GetAllAccounts()
+	accounts, err := s.DB.GetAllAccountsContext(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get accounts: %w", err)
 	}
@@ -170,12 +327,13 @@ func (s *PerformanceService) CalculateAssetPerformance(isin string, period strin
 	var assetTransactions []models.Transaction
 	for _, account := range accounts {
 		filter := database.TransactionFilter{
-			ISIN:      isin,
-			StartDate: startDate.Format(time.RFC3339),
-			EndDate:   endDate.Format(time.RFC3339),
+			ISIN:          isin,
+			StartDate:     startDate.Format(time.RFC3339),
+			EndDate:       endDate.Format(time.RFC3339),
+			IncludeHidden: opts.IncludeHidden,
 		}
 
-		transactions, err := s.DB.GetTransactionsByAccount(account.ID, account.Platform, filter)
+		transactions, err := s.DB.GetTransactionsByAccountContext(ctx, account.ID, account.Platform, filter)
 		if err != nil {
 			return nil, fmt.Errorf("failed to get transactions for account %s: %w", account.ID, err)
 		}
@@ -187,15 +345,75 @@ func (s *PerformanceService) CalculateAssetPerformance(isin string, period strin
 	return s.calculateAssetPerformance(asset, assetTransactions, currentPrice.Price, startDate, endDate)
 }
 
+// CalculateAssetHoldingsHistoryContext returns the quantity held of an asset over the period
+// Transactions before the period are replayed so the first point reflects the opening position
+func (s *PerformanceService) CalculateAssetHoldingsHistoryContext(ctx context.Context, isin string, period string, opts Options) (*HoldingsHistory, error) {
+	startDate, endDate := calculateDateRange(period)
+	history, err := s.CalculateAssetHoldingsHistoryRangeContext(ctx, isin, startDate, endDate, opts)
+	if err != nil {
+		return nil, err
+	}
+	history.Period = period
+	return history, nil
+}
+
+// CalculateAssetHoldingsHistoryRangeContext returns the quantity held of an asset between two
+// dates; the period of the result is PeriodCustom
+func (s *PerformanceService) CalculateAssetHoldingsHistoryRangeContext(ctx context.Context, isin string, startDate, endDate time.Time, opts Options) (*HoldingsHistory, error) {
+	// Make sure the asset exists
+	if _, err := s.DB.GetAssetByISINContext(ctx, isin); err != nil {
+		return nil, fmt.Errorf("failed to get asset: %w", err)
+	}
+
+	accounts, err := s.DB.GetAllAccountsContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get accounts: %w", err)
+	}
+
+	var assetTransactions []models.Transaction
+	for _, account := range accounts {
+		filter := database.TransactionFilter{
+			ISIN:          isin,
+			EndDate:       endDate.Format(time.RFC3339),
+			IncludeHidden: opts.IncludeHidden,
+		}
+
+		transactions, err := s.DB.GetTransactionsByAccountContext(ctx, account.ID, account.Platform, filter)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get transactions for account %s: %w", account.ID, err)
+		}
+
+		assetTransactions = append(assetTransactions, transactions...)
+	}
+
+	return &HoldingsHistory{
+		ISIN:     isin,
+		Period:   PeriodCustom,
+		Holdings: ReplayAssetHoldings(assetTransactions, startDate, endDate),
+	}, nil
+}
+
 // calculatePerformance performs the actual performance calculation
 func (s *PerformanceService) calculatePerformance(transactions []models.Transaction, startDate, endDate time.Time) (*Performance, error) {
+	return s.calculatePerformanceAt(transactions, startDate, endDate, nil, IntervalAuto)
+}
+
+// calculatePerformanceAt is like calculatePerformance, but values the holdings with the
+// historical prices of valuedAt when it is set, as the time series does, and samples the
+// time series at interval
+func (s *PerformanceService) calculatePerformanceAt(transactions []models.Transaction, startDate, endDate time.Time, valuedAt *time.Time, interval string) (*Performance, error) {
 	// Group transactions by asset (ISIN)
 	assetHoldings := make(map[string]*assetHolding)
 	var totalFees float64
 	var totalInvested float64 // Total amount invested (all buys, including sold positions)
 	var totalDeposits float64
-	var totalInterests float64
-	var totalSales float64 // Total amount from sales
+	var totalInterests float64 // Interest and dividends, both credited to cash
+	var cashIncome float64     // Interest on cash (savings), kept out of the investment return
+	var dividendIncome float64 // Dividends, part of the investment return
+	var totalSales float64     // Total amount from sales
+	var corporateCash float64  // Cash received (or paid) through corporate actions
+	embedded := embeddedFees(transactions)
+	drip := DetectDRIP(transactions)
 
 	for _, tx := range transactions {
 		// Parse fees from the Fees field
@@ -205,20 +423,31 @@ func (s *PerformanceService) calculatePerformance(transactions []models.Transact
 		// Handle different transaction types
 		switch tx.TransactionType {
 		case "deposit":
-			totalDeposits += tx.AmountValue
+			totalDeposits += tx.ExactAmount()
 			continue
 		case "withdrawal":
-			totalDeposits += tx.AmountValue // AmountValue is negative for withdrawals
+			totalDeposits += tx.ExactAmount() // The amount is negative for withdrawals
 			continue
 		case "interest":
-			totalInterests += tx.AmountValue
+			totalInterests += tx.ExactAmount()
+			cashIncome += tx.ExactAmount()
 			continue
 		case "fee":
+			// Standalone fees reduce cash like embedded ones
+			totalFees += standaloneFee(tx, embedded)
 			continue
 		case "dividend":
+			if drip[tx.ID] {
+				// Reinvested right away: the return is in the shares bought at zero cost
+				continue
+			}
 			// Dividends are added to interests
-			totalInterests += tx.AmountValue
+			totalInterests += tx.ExactAmount()
+			dividendIncome += tx.ExactAmount()
 			continue
+		case models.TransactionTypeCorporateAction:
+			// The cash is part of the investment return; the shares, if any, are handled below
+			corporateCash += tx.ExactAmount()
 		}
 
 		// Skip if no ISIN
@@ -243,34 +472,34 @@ func (s *PerformanceService) calculatePerformance(transactions []models.Transact
 		switch tx.TransactionType {
 		case "buy":
 			holding.Quantity += tx.Quantity
-			// AmountValue represents the cost of the purchase (positive value)
-			investedAmount := tx.AmountValue
+			// The amount represents the cost of the purchase (positive value)
+			investedAmount := tx.ExactAmount()
 			if investedAmount < 0 {
 				investedAmount = -investedAmount // Handle negative values if they exist
 			}
+			if drip[tx.ID] {
+				investedAmount = 0 // Paid with the dividend
+			}
 			holding.Invested += investedAmount
 			// Add to total invested (all buys, even if later sold)
 			totalInvested += investedAmount
 		case "sell":
 			// Track total sales amount (positive value)
-			saleAmount := tx.AmountValue
+			saleAmount := tx.ExactAmount()
 			if saleAmount < 0 {
 				saleAmount = -saleAmount // Handle negative values if they exist
 			}
 			totalSales += saleAmount
-			// Calculate realized gain/loss
-			avgCost := 0.0
-			if holding.Quantity > 0 {
-				avgCost = holding.Invested / holding.Quantity
-			}
-			holding.Quantity -= tx.Quantity
-			holding.Invested -= avgCost * tx.Quantity
+			SellAtAverageCost(&holding.Quantity, &holding.Invested, tx.Quantity)
+		case models.TransactionTypeCorporateAction:
+			ApplyQuantityDelta(&holding.Quantity, &holding.Invested, tx.CorporateActionQuantity())
 		}
 	}
 
 	// Calculate current value of holdings (assets only, no cash)
 	var assetsValue float64
 	var currentInvested float64 // Amount currently invested (still in holdings)
+	unpricedAssets := []string{}
 	for isin, holding := range assetHoldings {
 		if holding.Quantity <= 0 {
 			continue
@@ -279,20 +508,23 @@ func (s *PerformanceService) calculatePerformance(transactions []models.Transact
 		// Add to current invested amount
 		currentInvested += holding.Invested
 
-		// Get current price
-		currentPrice, err := s.PriceService.GetCurrentPrice(isin)
+		// Get current price, or the price at the valuation date
+		price, err := s.valuationPrice(isin, valuedAt)
 		if err != nil {
-			// If price not available, use invested value as fallback
+			// If price not available, use invested value as fallback and report the asset
 			assetsValue += holding.Invested
+			unpricedAssets = append(unpricedAssets, isin)
 			continue
 		}
 
-		assetsValue += holding.Quantity * currentPrice.Price
+		assetsValue += holding.Quantity * price
 	}
 
+	sort.Strings(unpricedAssets)
+
 	// Calculate cash balance: deposits - buys + sells + interests - fees
 	// This represents the actual cash remaining in the account
-	cashBalance := totalDeposits - totalInvested + totalSales + totalInterests - totalFees
+	cashBalance := totalDeposits - totalInvested + totalSales + totalInterests + corporateCash - totalFees
 
 	// Total value = current value of assets only (no cash)
 	totalValue := assetsValue
@@ -302,22 +534,32 @@ func (s *PerformanceService) calculatePerformance(transactions []models.Transact
 
 	// Calculate performance percentage based on current investment
 	// Formula: performance % = ((current_value - total_invested - total_fees) / total_invested) × 100
+	// Deposits and savings interest are cash, not investment return: they only
+	// affect the cash balance. Cash-only accounts have nothing invested and stay at 0
 	performancePct := 0.0
 	if currentInvested > 0 {
-		performancePct = ((assetsValue - currentInvested - totalFees) / currentInvested) * 100
+		performancePct = finiteOrZero(((assetsValue - currentInvested - totalFees) / currentInvested) * 100)
 	}
 
 	// Generate time series
-	timeSeries := s.generateTimeSeries(transactions, assetHoldings, startDate, endDate)
+	timeSeries, err := s.generateTimeSeries(transactions, assetHoldings, startDate, endDate, interval)
+	if err != nil {
+		return nil, err
+	}
 
 	return &Performance{
 		TotalValue:      totalValue,
 		TotalInvested:   currentInvested, // Amount currently invested in open positions
 		CashBalance:     cashBalance,
 		TotalFees:       totalFees,
-		RealizedGains:   totalSales + totalInterests - totalFees, // Realized gains from sales + interests - fees
+		RealizedGains:   totalSales + dividendIncome + corporateCash - totalFees, // Realized gains from sales + dividends + corporate actions - fees, savings interest excluded
 		UnrealizedGains: unrealizedGains,
 		PerformancePct:  performancePct,
+		TotalDeposits:   totalDeposits,
+		DividendIncome:  dividendIncome,
+		TotalInterest:   cashIncome,
+		CashOnly:        len(assetHoldings) == 0,
+		UnpricedAssets:  unpricedAssets,
 		TimeSeries:      timeSeries,
 	}, nil
 }
@@ -328,6 +570,7 @@ func (s *PerformanceService) calculateAssetPerformance(asset *models.Asset, tran
 	var totalInvested float64
 	var totalFees float64
 	var realizedGains float64
+	drip := DetectDRIP(transactions)
 
 	for _, tx := range transactions {
 		fees := parseFees(tx.Fees)
@@ -336,17 +579,29 @@ func (s *PerformanceService) calculateAssetPerformance(asset *models.Asset, tran
 		switch tx.TransactionType {
 		case "buy":
 			totalQuantity += tx.Quantity
-			totalInvested += tx.AmountValue
+			// The cost of the purchase, as a positive value like in calculatePerformance
+			investedAmount := tx.ExactAmount()
+			if investedAmount < 0 {
+				investedAmount = -investedAmount
+			}
+			if drip[tx.ID] {
+				investedAmount = 0
+			}
+			totalInvested += investedAmount
 		case "sell":
-			avgCost := 0.0
-			if totalQuantity > 0 {
-				avgCost = totalInvested / totalQuantity
+			// The sale proceeds, as a positive value whatever the platform sign convention
+			saleAmount := tx.ExactAmount()
+			if saleAmount < 0 {
+				saleAmount = -saleAmount
 			}
-			realizedGains += tx.AmountValue - (avgCost * tx.Quantity)
-			totalQuantity -= tx.Quantity
-			totalInvested -= avgCost * tx.Quantity
+			realizedGains += saleAmount - SellAtAverageCost(&totalQuantity, &totalInvested, tx.Quantity)
 		case "dividend":
-			realizedGains += tx.AmountValue
+			if !drip[tx.ID] {
+				realizedGains += tx.ExactAmount()
+			}
+		case models.TransactionTypeCorporateAction:
+			realizedGains += tx.ExactAmount()
+			ApplyQuantityDelta(&totalQuantity, &totalInvested, tx.CorporateActionQuantity())
 		}
 	}
 
@@ -392,9 +647,48 @@ type assetHolding struct {
 	Invested float64
 }
 
+// QuantityTolerance is the remaining quantity below which a position is considered fully
+// sold, absorbing the float residue of a position sold in several parts
+const QuantityTolerance = 1e-9
+
+// SellAtAverageCost removes sold shares from a position at its average cost and returns the
+// cost of the shares sold. A position fully sold (or oversold) is reset to zero so that the
+// float residue of the cost basis does not carry over to a later re-entry.
+func SellAtAverageCost(quantity, invested *float64, sold float64) float64 {
+	held := *quantity
+	avgCost := 0.0
+	if held > 0 {
+		avgCost = *invested / held
+	}
+	*quantity -= sold
+	*invested -= avgCost * sold
+	if held > 0 && *quantity <= QuantityTolerance {
+		*quantity = 0
+		*invested = 0
+	}
+	return avgCost * sold
+}
+
+// ApplyQuantityDelta applies the shares of a corporate action to a position: shares received
+// (spin-off, bonus shares) enter at zero cost, shares given up leave at the average cost
+func ApplyQuantityDelta(quantity, invested *float64, delta float64) {
+	if delta >= 0 {
+		*quantity += delta
+		return
+	}
+	SellAtAverageCost(quantity, invested, -delta)
+}
+
+// PeriodCustom is the period reported for results computed over an explicit date range
+const PeriodCustom = "custom"
+
 // calculateDateRange converts a period string to start and end dates
 func calculateDateRange(period string) (time.Time, time.Time) {
-	endDate := time.Now()
+	return dateRangeAt(period, time.Now())
+}
+
+// dateRangeAt returns the start and end dates of the period ending at endDate
+func dateRangeAt(period string, endDate time.Time) (time.Time, time.Time) {
 	var startDate time.Time
 
 	switch period {
@@ -414,16 +708,60 @@ func calculateDateRange(period string) (time.Time, time.Time) {
 	return startDate, endDate
 }
 
-// parseFees extracts fee amount from the Fees string field
+// parseFees extracts fee amount from the Fees string field ("1,23 €" or "1.23")
 func parseFees(feesStr string) float64 {
-	// The Fees field might be in format like "1,23 €" or "1.23"
-	// For now, we'll try to parse it as a float
-	// In production, this would need more robust parsing
-	var fees float64
-	fmt.Sscanf(feesStr, "%f", &fees)
+	fees, err := models.ParseMoney(feesStr)
+	if err != nil {
+		return 0
+	}
 	return fees
 }
 
+// embeddedFeeKey identifies a fee by the time it was charged and its amount in cents
+func embeddedFeeKey(timestamp string, fee float64) string {
+	return fmt.Sprintf("%s|%.2f", timestamp, math.Abs(fee))
+}
+
+// embeddedFees counts the fees carried by the Fees field of buys and sells, by embeddedFeeKey
+func embeddedFees(transactions []models.Transaction) map[string]int {
+	embedded := make(map[string]int)
+	for _, tx := range transactions {
+		if tx.TransactionType != "buy" && tx.TransactionType != "sell" {
+			continue
+		}
+		if fee := parseFees(tx.Fees); fee != 0 {
+			embedded[embeddedFeeKey(tx.Timestamp, fee)]++
+		}
+	}
+	return embedded
+}
+
+// standaloneFee returns the cash spent on a standalone fee transaction. Some imports list the
+// fee of a trade both in the trade Fees field and as a separate fee transaction at the same
+// time: such a fee is already counted with the trade and is not counted again. A fee
+// transaction with its own Fees field is counted through that field.
+func standaloneFee(tx models.Transaction, embedded map[string]int) float64 {
+	fee := math.Abs(tx.ExactAmount())
+	if fee == 0 || parseFees(tx.Fees) != 0 {
+		return 0
+	}
+
+	key := embeddedFeeKey(tx.Timestamp, fee)
+	if embedded[key] > 0 {
+		embedded[key]--
+		return 0
+	}
+	return fee
+}
+
+// finiteOrZero replaces NaN and infinite results (e.g. from a zero denominator) with 0
+func finiteOrZero(value float64) float64 {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return 0
+	}
+	return value
+}
+
 // calculateRemainingInvestment calculates the total invested amount still in holdings
 func calculateRemainingInvestment(holdings map[string]*assetHolding) float64 {
 	var total float64
@@ -438,55 +776,45 @@ func calculateRemainingInvestment(holdings map[string]*assetHolding) float64 {
 // calculateCashBalance calculates the current cash balance using all transactions
 // Cash = deposits - buys + sells + interests - fees
 func (s *PerformanceService) calculateCashBalance(transactions []models.Transaction) float64 {
-	var totalDeposits float64
-	var totalInvested float64 // Total amount spent on buys
-	var totalSales float64    // Total amount received from sells
-	var totalInterests float64
-	var totalFees float64
+	var balance float64
+	embedded := embeddedFees(transactions)
 
 	for _, tx := range transactions {
-		// Parse fees
-		fees := parseFees(tx.Fees)
-		totalFees += fees
+		balance += cashEffect(tx, embedded)
+	}
 
-		// Handle different transaction types
-		switch tx.TransactionType {
-		case "deposit":
-			totalDeposits += tx.AmountValue
-		case "withdrawal":
-			totalDeposits += tx.AmountValue // AmountValue is negative for withdrawals
-		case "interest":
-			totalInterests += tx.AmountValue
-		case "dividend":
-			totalInterests += tx.AmountValue
-		case "buy":
-			if tx.ISIN != nil && *tx.ISIN != "" {
-				investedAmount := tx.AmountValue
-				if investedAmount < 0 {
-					investedAmount = -investedAmount
-				}
-				totalInvested += investedAmount
-			}
-		case "sell":
-			if tx.ISIN != nil && *tx.ISIN != "" {
-				saleAmount := tx.AmountValue
-				if saleAmount < 0 {
-					saleAmount = -saleAmount
-				}
-				totalSales += saleAmount
-			}
+	return balance
+}
+
+// cashEffect returns the cash moved by a transaction, its fees included: deposits, sells,
+// interests and dividends add cash, buys and fees remove it, withdrawals carry a negative amount
+func cashEffect(tx models.Transaction, embedded map[string]int) float64 {
+	effect := -parseFees(tx.Fees)
+
+	switch tx.TransactionType {
+	case "fee":
+		effect -= standaloneFee(tx, embedded)
+	case "deposit", "withdrawal", "interest", "dividend", models.TransactionTypeCorporateAction:
+		// The amount is negative for withdrawals and corporate actions paid in cash
+		effect += tx.ExactAmount()
+	case "buy":
+		if tx.ISIN != nil && *tx.ISIN != "" {
+			effect -= math.Abs(tx.ExactAmount())
+		}
+	case "sell":
+		if tx.ISIN != nil && *tx.ISIN != "" {
+			effect += math.Abs(tx.ExactAmount())
 		}
 	}
 
-	// Cash balance = deposits - buys + sells + interests - fees
-	return totalDeposits - totalInvested + totalSales + totalInterests - totalFees
+	return effect
 }
 
 // generateTimeSeries generates a time series of portfolio values using historical prices
 // This creates a Trade Republic-style performance chart with weekly data points
-func (s *PerformanceService) generateTimeSeries(transactions []models.Transaction, holdings map[string]*assetHolding, startDate, endDate time.Time) []PerformancePoint {
+func (s *PerformanceService) generateTimeSeries(transactions []models.Transaction, holdings map[string]*assetHolding, startDate, endDate time.Time, interval string) ([]PerformancePoint, error) {
 	if len(transactions) == 0 {
-		return []PerformancePoint{}
+		return []PerformancePoint{}, nil
 	}
 
 	// Sort transactions by timestamp
@@ -515,35 +843,14 @@ func (s *PerformanceService) generateTimeSeries(transactions []models.Transactio
 		startDate = firstTxTime
 	}
 
-	// Generate weekly time points from start to end
-	var timePoints []time.Time
-	currentPoint := startDate
-
-	// Determine interval based on period length
-	daysDiff := endDate.Sub(startDate).Hours() / 24
-	var interval time.Duration
-
-	if daysDiff <= 7 {
-		interval = 24 * time.Hour // Daily for 1 week
-	} else if daysDiff <= 30 {
-		interval = 24 * time.Hour // Daily for 1 month
-	} else if daysDiff <= 90 {
-		interval = 3 * 24 * time.Hour // Every 3 days for 3 months
-	} else {
-		interval = 7 * 24 * time.Hour // Weekly for longer periods
-	}
-
-	for currentPoint.Before(endDate) || currentPoint.Equal(endDate) {
-		timePoints = append(timePoints, currentPoint)
-		currentPoint = currentPoint.Add(interval)
-	}
-
-	// Always add the end date as the last point
-	if len(timePoints) == 0 || !timePoints[len(timePoints)-1].Equal(endDate) {
-		timePoints = append(timePoints, endDate)
+	// Sampling dates from start to end
+	timePoints, err := samplingDates(startDate, endDate, interval)
+	if err != nil {
+		return nil, err
 	}
 
 	// Build time series by replaying transactions and using historical prices
+	drip := DetectDRIP(transactions)
 	var timeSeries []PerformancePoint
 	currentHoldings := make(map[string]*assetHolding)
 	txIndex := 0
@@ -568,10 +875,13 @@ func (s *PerformanceService) generateTimeSeries(transactions []models.Transactio
 					}
 					currentHoldings[isin].Quantity += tx.Quantity
 					// Track cost basis
-					investedAmount := tx.AmountValue
+					investedAmount := tx.ExactAmount()
 					if investedAmount < 0 {
 						investedAmount = -investedAmount
 					}
+					if drip[tx.ID] {
+						investedAmount = 0
+					}
 					currentHoldings[isin].Invested += investedAmount
 				}
 			case "sell":
@@ -579,13 +889,16 @@ func (s *PerformanceService) generateTimeSeries(transactions []models.Transactio
 					isin := *tx.ISIN
 					if holding, exists := currentHoldings[isin]; exists {
 						// Reduce cost basis proportionally
-						avgCost := 0.0
-						if holding.Quantity > 0 {
-							avgCost = holding.Invested / holding.Quantity
-						}
-						holding.Quantity -= tx.Quantity
-						holding.Invested -= avgCost * tx.Quantity
+						SellAtAverageCost(&holding.Quantity, &holding.Invested, tx.Quantity)
+					}
+				}
+			case models.TransactionTypeCorporateAction:
+				if delta := tx.CorporateActionQuantity(); delta != 0 && tx.ISIN != nil && *tx.ISIN != "" {
+					isin := *tx.ISIN
+					if _, exists := currentHoldings[isin]; !exists {
+						currentHoldings[isin] = &assetHolding{ISIN: isin}
 					}
+					ApplyQuantityDelta(&currentHoldings[isin].Quantity, &currentHoldings[isin].Invested, delta)
 				}
 			}
 
@@ -622,46 +935,79 @@ func (s *PerformanceService) generateTimeSeries(transactions []models.Transactio
 		})
 	}
 
-	return timeSeries
+	return timeSeries, nil
 }
 
-// getHistoricalPrice retrieves the historical price for an asset at a specific date
-// It looks for the closest price in the database
-func (s *PerformanceService) getHistoricalPrice(isin string, date time.Time) (float64, error) {
-	// Check if DB is available (for tests)
+// valuationPrice returns the current price of an asset, or its stored price at valuedAt when set
+// to a past day. A past valuation never uses the current price: without a stored price the asset
+// is unpriced. A valuation today uses the current price, so that it matches the default output.
+func (s *PerformanceService) valuationPrice(isin string, valuedAt *time.Time) (float64, error) {
+	if valuedAt != nil && !isToday(*valuedAt) {
+		return s.storedPrice(isin, *valuedAt)
+	}
+	currentPrice, err := s.PriceService.GetCurrentPrice(isin)
+	if err != nil {
+		return 0, err
+	}
+	return currentPrice.Price, nil
+}
+
+// isToday reports whether t falls on the current day, in the time zone of t
+func isToday(t time.Time) bool {
+	now := time.Now().In(t.Location())
+	y1, m1, d1 := t.Date()
+	y2, m2, d2 := now.Date()
+	return y1 == y2 && m1 == m2 && d1 == d2
+}
+
+// HistoricalPrice returns the last price stored for an asset at date
+func (s *PerformanceService) HistoricalPrice(isin string, date time.Time) (float64, error) {
+	return s.storedPrice(isin, date)
+}
+
+// storedPrice returns the closest price stored in the database at or before date. Prices are
+// stored at their trading day, so the comparison is made on the date: an instant late in the
+// day must still select that day's close.
+func (s *PerformanceService) storedPrice(isin string, date time.Time) (float64, error) {
 	if s.DB == nil {
-		// Fallback to current price service
-		currentPrice, err := s.PriceService.GetCurrentPrice(isin)
-		if err != nil {
-			return 0, fmt.Errorf("no price available for %s at %s", isin, date.Format("2006-01-02"))
-		}
-		return currentPrice.Price, nil
+		return 0, fmt.Errorf("no price stored for %s at %s", isin, date.Format("2006-01-02"))
 	}
 
-	// Query for the closest price to the given date
 	query := `
 		SELECT price 
 		FROM asset_prices 
 		WHERE isin = $1 
-		AND timestamp <= $2
+		AND timestamp < $2::date + 1
 		ORDER BY timestamp DESC
 		LIMIT 1
 	`
 
 	var price float64
-	err := s.DB.Get(&price, query, isin, date)
-	if err != nil {
-		// If no historical price found, try to get current price as fallback
-		currentPrice, err := s.PriceService.GetCurrentPrice(isin)
-		if err != nil {
-			return 0, fmt.Errorf("no price available for %s at %s", isin, date.Format("2006-01-02"))
+	if err := s.DB.Get(&price, query, isin, date.Format("2006-01-02")); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, fmt.Errorf("no price stored for %s at %s", isin, date.Format("2006-01-02"))
 		}
-		return currentPrice.Price, nil
+		return 0, fmt.Errorf("failed to get price of %s at %s: %w", isin, date.Format("2006-01-02"), err)
 	}
 
 	return price, nil
 }
 
+// getHistoricalPrice retrieves the historical price for an asset at a specific date, for the
+// time series: the stored price when there is one, the current price otherwise
+func (s *PerformanceService) getHistoricalPrice(isin string, date time.Time) (float64, error) {
+	price, err := s.storedPrice(isin, date)
+	if err == nil {
+		return price, nil
+	}
+
+	currentPrice, err := s.PriceService.GetCurrentPrice(isin)
+	if err != nil {
+		return 0, fmt.Errorf("no price available for %s at %s", isin, date.Format("2006-01-02"))
+	}
+	return currentPrice.Price, nil
+}
+
 // generateAssetTimeSeries generates a time series for a specific asset
 // This replays transactions and uses historical prices to show asset value evolution
 func (s *PerformanceService) generateAssetTimeSeries(isin string, transactions []models.Transaction, startDate, endDate time.Time) ([]PerformancePoint, error) {
@@ -669,20 +1015,47 @@ func (s *PerformanceService) generateAssetTimeSeries(isin string, transactions [
 		return []PerformancePoint{}, nil
 	}
 
-	// Sort transactions by timestamp
-	sortedTxs := make([]models.Transaction, len(transactions))
-	copy(sortedTxs, transactions)
+	holdings := ReplayAssetHoldings(transactions, startDate, endDate)
 
-	for i := 0; i < len(sortedTxs); i++ {
-		for j := i + 1; j < len(sortedTxs); j++ {
-			ti, _ := time.Parse(time.RFC3339, sortedTxs[i].Timestamp)
-			tj, _ := time.Parse(time.RFC3339, sortedTxs[j].Timestamp)
-			if ti.After(tj) {
-				sortedTxs[i], sortedTxs[j] = sortedTxs[j], sortedTxs[i]
-			}
+	// Build time series
+	var timeSeries []PerformancePoint
+	for _, point := range holdings {
+		// Get historical price for this date
+		price, err := s.getHistoricalPrice(isin, point.Date)
+		if err != nil {
+			continue
 		}
+
+		// Calculate value
+		value := point.Quantity * price
+
+		timeSeries = append(timeSeries, PerformancePoint{
+			Date:     point.Date,
+			Value:    value,
+			Invested: point.invested,
+		})
+	}
+
+	return timeSeries, nil
+}
+
+// ReplayAssetHoldings replays the transactions of a single asset and returns the quantity
+// held at each time point between startDate and endDate, without any price lookup
+// Transactions before startDate are included in the first point
+func ReplayAssetHoldings(transactions []models.Transaction, startDate, endDate time.Time) []HoldingsPoint {
+	if len(transactions) == 0 {
+		return []HoldingsPoint{}
 	}
 
+	// Sort transactions by timestamp
+	sortedTxs := make([]models.Transaction, len(transactions))
+	copy(sortedTxs, transactions)
+	sort.SliceStable(sortedTxs, func(i, j int) bool {
+		ti, _ := time.Parse(time.RFC3339, sortedTxs[i].Timestamp)
+		tj, _ := time.Parse(time.RFC3339, sortedTxs[j].Timestamp)
+		return ti.Before(tj)
+	})
+
 	// Determine the actual start date (first transaction date)
 	firstTxTime, err := time.Parse(time.RFC3339, sortedTxs[0].Timestamp)
 	if err != nil {
@@ -693,36 +1066,12 @@ func (s *PerformanceService) generateAssetTimeSeries(isin string, transactions [
 		startDate = firstTxTime
 	}
 
-	// Generate time points
-	var timePoints []time.Time
-	currentPoint := startDate
-
-	daysDiff := endDate.Sub(startDate).Hours() / 24
-	var interval time.Duration
-
-	if daysDiff <= 7 {
-		interval = 24 * time.Hour
-	} else if daysDiff <= 30 {
-		interval = 24 * time.Hour
-	} else if daysDiff <= 90 {
-		interval = 3 * 24 * time.Hour
-	} else {
-		interval = 7 * 24 * time.Hour
-	}
+	timePoints := assetTimePoints(startDate, endDate)
 
-	for currentPoint.Before(endDate) || currentPoint.Equal(endDate) {
-		timePoints = append(timePoints, currentPoint)
-		currentPoint = currentPoint.Add(interval)
-	}
-
-	if len(timePoints) == 0 || !timePoints[len(timePoints)-1].Equal(endDate) {
-		timePoints = append(timePoints, endDate)
-	}
-
-	// Build time series
-	var timeSeries []PerformancePoint
+	var points []HoldingsPoint
 	var currentQuantity float64
 	var totalInvested float64
+	drip := DetectDRIP(transactions)
 	txIndex := 0
 
 	for _, timePoint := range timePoints {
@@ -738,39 +1087,37 @@ func (s *PerformanceService) generateAssetTimeSeries(isin string, transactions [
 			switch tx.TransactionType {
 			case "buy":
 				currentQuantity += tx.Quantity
-				investedAmount := tx.AmountValue
+				investedAmount := tx.ExactAmount()
 				if investedAmount < 0 {
 					investedAmount = -investedAmount
 				}
+				if drip[tx.ID] {
+					investedAmount = 0
+				}
 				totalInvested += investedAmount
 			case "sell":
 				// Reduce cost basis proportionally
-				avgCost := 0.0
-				if currentQuantity > 0 {
-					avgCost = totalInvested / currentQuantity
-				}
-				currentQuantity -= tx.Quantity
-				totalInvested -= avgCost * tx.Quantity
+				SellAtAverageCost(&currentQuantity, &totalInvested, tx.Quantity)
+			case models.TransactionTypeCorporateAction:
+				ApplyQuantityDelta(&currentQuantity, &totalInvested, tx.CorporateActionQuantity())
 			}
 
 			txIndex++
 		}
 
-		// Get historical price for this date
-		price, err := s.getHistoricalPrice(isin, timePoint)
-		if err != nil {
-			continue
-		}
-
-		// Calculate value
-		value := currentQuantity * price
-
-		timeSeries = append(timeSeries, PerformancePoint{
+		points = append(points, HoldingsPoint{
 			Date:     timePoint,
-			Value:    value,
-			Invested: totalInvested,
+			Quantity: currentQuantity,
+			invested: totalInvested,
 		})
 	}
 
-	return timeSeries, nil
+	return points
+}
+
+// assetTimePoints generates the sampling dates of an asset time series
+func assetTimePoints(startDate, endDate time.Time) []time.Time {
+	// The automatic interval is not bounded and never fails
+	timePoints, _ := samplingDates(startDate, endDate, IntervalAuto)
+	return timePoints
 }
@@ -1,9 +1,14 @@
 package performance
 
 import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
 	"testing"
 	"time"
 	"valhafin/internal/domain/models"
+	"valhafin/internal/testutil"
 
 	"github.com/leanovate/gopter"
 	"github.com/leanovate/gopter/gen"
@@ -94,6 +99,55 @@ func TestCalculateDateRange(t *testing.T) {
 	}
 }
 
+// TestReplayAssetHoldings tests the quantity replay used by the holdings history
+func TestReplayAssetHoldings(t *testing.T) {
+	isin := "US0378331005"
+	transactions := []models.Transaction{
+		// Out of order on purpose, replay must sort by timestamp
+		{ID: "tx-3", Timestamp: "2024-01-20T10:00:00Z", ISIN: &isin, TransactionType: "sell", Quantity: 4, AmountValue: 500},
+		{ID: "tx-1", Timestamp: "2023-12-01T10:00:00Z", ISIN: &isin, TransactionType: "buy", Quantity: 10, AmountValue: -1000},
+		{ID: "tx-2", Timestamp: "2024-01-10T10:00:00Z", ISIN: &isin, TransactionType: "buy", Quantity: 2.5, AmountValue: -300},
+		{ID: "tx-4", Timestamp: "2024-01-12T10:00:00Z", ISIN: &isin, TransactionType: "dividend", AmountValue: 3},
+	}
+
+	startDate := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	endDate := time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)
+
+	points := ReplayAssetHoldings(transactions, startDate, endDate)
+	if len(points) == 0 {
+		t.Fatal("Expected holdings points")
+	}
+
+	quantityAt := func(date time.Time) float64 {
+		var quantity float64
+		for _, p := range points {
+			if p.Date.After(date) {
+				break
+			}
+			quantity = p.Quantity
+		}
+		return quantity
+	}
+
+	// Buy before the period is part of the opening position
+	if got := quantityAt(startDate); got != 10 {
+		t.Errorf("Quantity at start = %v, want 10", got)
+	}
+	if got := quantityAt(time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)); got != 12.5 {
+		t.Errorf("Quantity on 2024-01-15 = %v, want 12.5", got)
+	}
+	if got := points[len(points)-1].Quantity; got != 8.5 {
+		t.Errorf("Final quantity = %v, want 8.5", got)
+	}
+	if !points[len(points)-1].Date.Equal(endDate) {
+		t.Errorf("Last point should be the end date, got %v", points[len(points)-1].Date)
+	}
+
+	if empty := ReplayAssetHoldings(nil, startDate, endDate); len(empty) != 0 {
+		t.Errorf("Expected no points without transactions, got %d", len(empty))
+	}
+}
+
 // TestParseFees tests the fee parsing function
 func TestParseFees(t *testing.T) {
 	tests := []struct {
@@ -105,6 +159,7 @@ func TestParseFees(t *testing.T) {
 		{"zero", "0", 0},
 		{"empty", "", 0},
 		{"with currency", "1.23 €", 1.23},
+		{"decimal comma", "1,50 €", 1.50},
 	}
 
 	for _, tt := range tests {
@@ -117,6 +172,375 @@ func TestParseFees(t *testing.T) {
 	}
 }
 
+// TestCalculatePerformance_CashOnlyAccount tests an account with only deposits and interest
+func TestCalculatePerformance_CashOnlyAccount(t *testing.T) {
+	service := &PerformanceService{PriceService: NewMockPriceService()}
+
+	transactions := []models.Transaction{
+		{ID: "tx1", Timestamp: "2024-01-01T10:00:00Z", TransactionType: "deposit", AmountValue: 1000},
+		{ID: "tx2", Timestamp: "2024-02-01T10:00:00Z", TransactionType: "interest", AmountValue: 2.5},
+		{ID: "tx3", Timestamp: "2024-03-01T10:00:00Z", TransactionType: "deposit", AmountValue: 500},
+		{ID: "tx4", Timestamp: "2024-03-15T10:00:00Z", TransactionType: "withdrawal", AmountValue: -200},
+		{ID: "tx5", Timestamp: "2024-04-01T10:00:00Z", TransactionType: "interest", AmountValue: 3.1},
+	}
+
+	startDate := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	endDate := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	perf, err := service.calculatePerformance(transactions, startDate, endDate)
+	if err != nil {
+		t.Fatalf("calculatePerformance failed: %v", err)
+	}
+
+	if !floatEquals(perf.CashBalance, 1305.6, 0.001) {
+		t.Errorf("expected cash balance 1305.6, got %v", perf.CashBalance)
+	}
+	if !floatEquals(perf.TotalInterest, 5.6, 0.001) {
+		t.Errorf("expected cash income 5.6, got %v", perf.TotalInterest)
+	}
+	if perf.TotalDeposits != 1300 {
+		t.Errorf("expected total deposits 1300, got %v", perf.TotalDeposits)
+	}
+	if perf.PerformancePct != 0 || perf.TotalInvested != 0 || perf.TotalValue != 0 {
+		t.Errorf("expected no invested amount and 0%% performance, got %+v", perf)
+	}
+	if !perf.CashOnly {
+		t.Error("expected the account to be reported as cash only")
+	}
+	for _, point := range perf.TimeSeries {
+		if math.IsNaN(point.Value) || math.IsNaN(point.Invested) {
+			t.Fatalf("unexpected NaN in time series at %v", point.Date)
+		}
+	}
+
+	summary := Summarize(&models.Account{ID: "acc1", Name: "Livret", Platform: "traderepublic"}, perf)
+	if !floatEquals(summary.TotalValue, 1305.6, 0.001) {
+		t.Errorf("expected summary total value to be the cash balance, got %v", summary.TotalValue)
+	}
+
+	// NaN would make encoding fail
+	if _, err := json.Marshal(summary); err != nil {
+		t.Errorf("failed to encode summary: %v", err)
+	}
+	if _, err := json.Marshal(perf); err != nil {
+		t.Errorf("failed to encode performance: %v", err)
+	}
+}
+
+// TestCashBalance_StandaloneFees tests that standalone fee transactions reduce cash without
+// counting again a fee already carried by a trade Fees field
+func TestCashBalance_StandaloneFees(t *testing.T) {
+	mockPriceService := NewMockPriceService()
+	mockPriceService.SetPrice("IE00B4L5Y983", 100)
+	service := &PerformanceService{PriceService: mockPriceService}
+
+	transactions := []models.Transaction{
+		{ID: "tx1", Timestamp: "2024-01-01T10:00:00Z", TransactionType: "deposit", AmountValue: 2000},
+		// Embedded fee, listed again as a fee transaction at the same time
+		{ID: "tx2", Timestamp: "2024-01-02T10:00:00Z", TransactionType: "buy", AmountValue: -1000, Quantity: 10, Fees: "1,00 €", ISIN: stringPtr("IE00B4L5Y983")},
+		{ID: "tx3", Timestamp: "2024-01-02T10:00:00Z", TransactionType: "fee", AmountValue: -1},
+		// Standalone fees only
+		{ID: "tx4", Timestamp: "2024-02-01T10:00:00Z", TransactionType: "fee", AmountValue: -4.99},
+		{ID: "tx5", Timestamp: "2024-03-01T10:00:00Z", TransactionType: "fee", AmountValue: -1},
+	}
+
+	// 2000 - 1000 - 1 (embedded) - 4.99 - 1 (standalone)
+	want := 993.01
+	if cash := service.calculateCashBalance(transactions); !floatEquals(cash, want, 0.001) {
+		t.Errorf("expected cash balance %v, got %v", want, cash)
+	}
+
+	startDate := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	endDate := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	perf, err := service.calculatePerformance(transactions, startDate, endDate)
+	if err != nil {
+		t.Fatalf("calculatePerformance failed: %v", err)
+	}
+	if !floatEquals(perf.CashBalance, want, 0.001) {
+		t.Errorf("expected performance cash balance %v, got %v", want, perf.CashBalance)
+	}
+	if !floatEquals(perf.TotalFees, 6.99, 0.001) {
+		t.Errorf("expected total fees 6.99, got %v", perf.TotalFees)
+	}
+
+	// Without the embedded fee, the matching fee transaction is a standalone fee
+	transactions[1].Fees = ""
+	if cash := service.calculateCashBalance(transactions); !floatEquals(cash, want, 0.001) {
+		t.Errorf("expected cash balance %v with the fee only as a transaction, got %v", want, cash)
+	}
+}
+
+// TestCorporateAction_AffectsCashAndHoldings tests that the cash of a corporate action
+// reaches the cash balance and that its shares change the holdings
+func TestCorporateAction_AffectsCashAndHoldings(t *testing.T) {
+	mockPriceService := NewMockPriceService()
+	mockPriceService.SetPrice("IE00B4L5Y983", 100)
+	service := &PerformanceService{PriceService: mockPriceService}
+
+	transactions := []models.Transaction{
+		{ID: "tx1", Timestamp: "2024-01-01T10:00:00Z", TransactionType: "deposit", AmountValue: 2000},
+		{ID: "tx2", Timestamp: "2024-01-02T10:00:00Z", TransactionType: "buy", AmountValue: -1000, Quantity: 10, ISIN: stringPtr("IE00B4L5Y983")},
+		// Rights sold by the broker on behalf of the holder
+		{ID: "tx3", Timestamp: "2024-02-01T10:00:00Z", TransactionType: models.TransactionTypeCorporateAction, AmountValue: 42.5, ISIN: stringPtr("IE00B4L5Y983")},
+		// Bonus shares without cash
+		{ID: "tx4", Timestamp: "2024-03-01T10:00:00Z", TransactionType: models.TransactionTypeCorporateAction, ISIN: stringPtr("IE00B4L5Y983"), Metadata: stringPtr(`{"quantity_delta":2}`)},
+	}
+
+	want := 1042.5
+	if cash := service.calculateCashBalance(transactions); !floatEquals(cash, want, 0.001) {
+		t.Errorf("expected cash balance %v, got %v", want, cash)
+	}
+
+	startDate := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	endDate := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	perf, err := service.calculatePerformance(transactions, startDate, endDate)
+	if err != nil {
+		t.Fatalf("calculatePerformance failed: %v", err)
+	}
+	if !floatEquals(perf.CashBalance, want, 0.001) {
+		t.Errorf("expected performance cash balance %v, got %v", want, perf.CashBalance)
+	}
+	if !floatEquals(perf.RealizedGains, 42.5, 0.001) {
+		t.Errorf("expected the corporate action cash in the realized gains, got %v", perf.RealizedGains)
+	}
+	// 12 shares at 100, the bonus shares entering at zero cost
+	if !floatEquals(perf.TotalValue, 1200, 0.001) || !floatEquals(perf.TotalInvested, 1000, 0.001) {
+		t.Errorf("expected 12 shares worth 1200 for 1000 invested, got %v for %v", perf.TotalValue, perf.TotalInvested)
+	}
+}
+
+// unresolvedPriceService has no current price for one asset, like an asset whose symbol is not resolved
+type unresolvedPriceService struct {
+	*MockPriceService
+	unresolved string
+}
+
+func (s unresolvedPriceService) GetCurrentPrice(isin string) (*models.AssetPrice, error) {
+	if isin == s.unresolved {
+		return nil, fmt.Errorf("no symbol for %s", isin)
+	}
+	return s.MockPriceService.GetCurrentPrice(isin)
+}
+
+// TestCalculatePerformance_ReportsUnpricedAssets tests that an asset valued at its invested
+// amount for lack of price is reported
+func TestCalculatePerformance_ReportsUnpricedAssets(t *testing.T) {
+	mockPriceService := NewMockPriceService()
+	mockPriceService.SetPrice("IE00B4L5Y983", 110)
+	service := &PerformanceService{PriceService: unresolvedPriceService{MockPriceService: mockPriceService, unresolved: "DE000UNKNOWN1"}}
+
+	transactions := []models.Transaction{
+		{ID: "tx1", Timestamp: "2024-01-01T10:00:00Z", TransactionType: "deposit", AmountValue: 2000},
+		{ID: "tx2", Timestamp: "2024-01-02T10:00:00Z", TransactionType: "buy", AmountValue: -1000, Quantity: 10, ISIN: stringPtr("IE00B4L5Y983")},
+		{ID: "tx3", Timestamp: "2024-01-03T10:00:00Z", TransactionType: "buy", AmountValue: -500, Quantity: 5, ISIN: stringPtr("DE000UNKNOWN1")},
+	}
+
+	startDate := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	endDate := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	perf, err := service.calculatePerformance(transactions, startDate, endDate)
+	if err != nil {
+		t.Fatalf("calculatePerformance failed: %v", err)
+	}
+	if len(perf.UnpricedAssets) != 1 || perf.UnpricedAssets[0] != "DE000UNKNOWN1" {
+		t.Errorf("expected DE000UNKNOWN1 to be reported as unpriced, got %v", perf.UnpricedAssets)
+	}
+	// 10 × 110 for the priced asset, the invested 500 for the other one
+	if !floatEquals(perf.TotalValue, 1600, 0.001) {
+		t.Errorf("expected a total value of 1600, got %v", perf.TotalValue)
+	}
+
+	// Every asset priced: an empty list rather than null
+	service.PriceService = mockPriceService
+	perf, err = service.calculatePerformance(transactions, startDate, endDate)
+	if err != nil {
+		t.Fatalf("calculatePerformance failed: %v", err)
+	}
+	if perf.UnpricedAssets == nil || len(perf.UnpricedAssets) != 0 {
+		t.Errorf("expected no unpriced asset, got %v", perf.UnpricedAssets)
+	}
+}
+
+// TestCalculatePerformance_SeparatesCashIncome tests that savings interest is cash income while dividends are investment return
+func TestCalculatePerformance_SeparatesCashIncome(t *testing.T) {
+	mockPriceService := NewMockPriceService()
+	mockPriceService.SetPrice("IE00B4L5Y983", 110)
+	service := &PerformanceService{PriceService: mockPriceService}
+
+	transactions := []models.Transaction{
+		{ID: "tx1", Timestamp: "2024-01-01T10:00:00Z", TransactionType: "deposit", AmountValue: 2000},
+		{ID: "tx2", Timestamp: "2024-01-02T10:00:00Z", TransactionType: "buy", AmountValue: 1000, Quantity: 10, ISIN: stringPtr("IE00B4L5Y983")},
+		{ID: "tx3", Timestamp: "2024-03-01T10:00:00Z", TransactionType: "dividend", AmountValue: 20, ISIN: stringPtr("IE00B4L5Y983")},
+		{ID: "tx4", Timestamp: "2024-04-01T10:00:00Z", TransactionType: "interest", AmountValue: 150},
+	}
+
+	startDate := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	endDate := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	perf, err := service.calculatePerformance(transactions, startDate, endDate)
+	if err != nil {
+		t.Fatalf("calculatePerformance failed: %v", err)
+	}
+
+	if perf.DividendIncome != 20 {
+		t.Errorf("expected dividend income 20, got %v", perf.DividendIncome)
+	}
+	if perf.TotalInterest != 150 {
+		t.Errorf("expected total interest 150, got %v", perf.TotalInterest)
+	}
+	if perf.RealizedGains != 20 {
+		t.Errorf("expected realized gains to include dividends but not interest, got %v", perf.RealizedGains)
+	}
+	if !floatEquals(perf.CashBalance, 1170, 0.001) {
+		t.Errorf("expected interest and dividends in the cash balance (1170), got %v", perf.CashBalance)
+	}
+
+	// 10 shares bought 1000 and worth 1100: counted as a gain, the 150 of interest would
+	// give 25% instead of 10%
+	if !floatEquals(perf.PerformancePct, 10, 0.001) {
+		t.Errorf("expected 10%% performance, got %v", perf.PerformancePct)
+	}
+}
+
+// TestCalculatePerformance_InterestIsNotReturn tests that interest moves neither the gains nor
+// the performance percentage, with or without securities
+func TestCalculatePerformance_InterestIsNotReturn(t *testing.T) {
+	isin := "IE00B4L5Y983"
+	mockPriceService := NewMockPriceService()
+	mockPriceService.SetPrice(isin, 90)
+	service := &PerformanceService{PriceService: mockPriceService}
+
+	tests := []struct {
+		name         string
+		transactions []models.Transaction
+		wantPct      float64
+	}{
+		{
+			// 50 of interest on 1000 of deposits would read as 5%
+			name: "cash only",
+			transactions: []models.Transaction{
+				{ID: "tx1", Timestamp: "2024-01-01T10:00:00Z", TransactionType: "deposit", AmountValue: 1000},
+				{ID: "tx2", Timestamp: "2024-02-01T10:00:00Z", TransactionType: "interest", AmountValue: 50},
+			},
+			wantPct: 0,
+		},
+		{
+			// A 10% loss that 200 of interest on 1000 invested would turn into a 10% gain
+			name: "losing position",
+			transactions: []models.Transaction{
+				{ID: "tx1", Timestamp: "2024-01-01T10:00:00Z", TransactionType: "deposit", AmountValue: 2000},
+				{ID: "tx2", Timestamp: "2024-01-02T10:00:00Z", TransactionType: "buy", AmountValue: -1000, Quantity: 10, ISIN: &isin},
+				{ID: "tx3", Timestamp: "2024-02-01T10:00:00Z", TransactionType: "interest", AmountValue: 200},
+			},
+			wantPct: -10,
+		},
+	}
+
+	startDate := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	endDate := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			perf, err := service.calculatePerformance(tt.transactions, startDate, endDate)
+			if err != nil {
+				t.Fatalf("calculatePerformance failed: %v", err)
+			}
+			if !floatEquals(perf.PerformancePct, tt.wantPct, 0.001) {
+				t.Errorf("PerformancePct = %v, want %v", perf.PerformancePct, tt.wantPct)
+			}
+			if perf.RealizedGains != 0 {
+				t.Errorf("RealizedGains = %v, want 0", perf.RealizedGains)
+			}
+		})
+	}
+}
+
+func TestCalculateAssetPerformance_RealizedGainsMatchAccount(t *testing.T) {
+	isin := "US0378331005"
+	mockPriceService := NewMockPriceService()
+	mockPriceService.SetPrice(isin, 150)
+	service := &PerformanceService{PriceService: mockPriceService}
+	startDate := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	endDate := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	// Platforms record buys and sells either as signed cash flows or as magnitudes
+	for _, tt := range []struct {
+		name       string
+		buyAmount  float64
+		sellAmount float64
+	}{
+		{"signed cash flows", -1000, 600},
+		{"negative sale", -1000, -600},
+		{"magnitudes", 1000, 600},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			transactions := []models.Transaction{
+				{ID: "tx1", Timestamp: "2024-01-02T10:00:00Z", TransactionType: "buy", AmountValue: tt.buyAmount, Quantity: 10, ISIN: stringPtr(isin)},
+				{ID: "tx2", Timestamp: "2024-03-01T10:00:00Z", TransactionType: "sell", AmountValue: tt.sellAmount, Quantity: 4, ISIN: stringPtr(isin)},
+			}
+
+			account, err := service.calculatePerformance(transactions, startDate, endDate)
+			if err != nil {
+				t.Fatalf("calculatePerformance failed: %v", err)
+			}
+			asset, err := service.calculateAssetPerformance(&models.Asset{ISIN: isin}, transactions, 150, startDate, endDate)
+			if err != nil {
+				t.Fatalf("calculateAssetPerformance failed: %v", err)
+			}
+
+			// The account reports the sale proceeds: the gain is the proceeds minus the
+			// cost of the sold shares, i.e. everything bought minus what is still invested
+			accountGain := account.RealizedGains - (1000 - account.TotalInvested)
+			if !floatEquals(asset.RealizedGains, 200, 0.001) || !floatEquals(asset.RealizedGains, accountGain, 0.001) {
+				t.Errorf("expected asset realized gains of 200 matching the account (%v), got %v", accountGain, asset.RealizedGains)
+			}
+			if !floatEquals(asset.TotalInvested, account.TotalInvested, 0.001) {
+				t.Errorf("expected the remaining investment to match the account (%v), got %v", account.TotalInvested, asset.TotalInvested)
+			}
+		})
+	}
+}
+
+func TestCalculatePerformance_ReentryAfterFullSale(t *testing.T) {
+	isin := "US0378331005"
+	service := &PerformanceService{PriceService: NewMockPriceService()}
+	startDate := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	endDate := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	// 0.1 + 0.2 leaves a float residue once 0.3 are sold
+	firstPosition := []models.Transaction{
+		{ID: "tx1", Timestamp: "2024-01-02T10:00:00Z", TransactionType: "buy", AmountValue: -10, Quantity: 0.1, ISIN: stringPtr(isin)},
+		{ID: "tx2", Timestamp: "2024-01-03T10:00:00Z", TransactionType: "buy", AmountValue: -20, Quantity: 0.2, ISIN: stringPtr(isin)},
+		{ID: "tx3", Timestamp: "2024-02-01T10:00:00Z", TransactionType: "sell", AmountValue: 45, Quantity: 0.3, ISIN: stringPtr(isin)},
+	}
+	soldOut, err := service.calculateAssetPerformance(&models.Asset{ISIN: isin}, firstPosition, 150, startDate, endDate)
+	if err != nil {
+		t.Fatalf("calculateAssetPerformance failed: %v", err)
+	}
+	if soldOut.TotalQuantity != 0 || soldOut.TotalInvested != 0 {
+		t.Errorf("expected a fully sold position to be reset, got quantity %v and invested %v", soldOut.TotalQuantity, soldOut.TotalInvested)
+	}
+
+	reentry := append(firstPosition, models.Transaction{
+		ID: "tx4", Timestamp: "2024-03-01T10:00:00Z", TransactionType: "buy", AmountValue: -300, Quantity: 2, ISIN: stringPtr(isin),
+	})
+	asset, err := service.calculateAssetPerformance(&models.Asset{ISIN: isin}, reentry, 150, startDate, endDate)
+	if err != nil {
+		t.Fatalf("calculateAssetPerformance failed: %v", err)
+	}
+	if asset.TotalQuantity != 2 || asset.TotalInvested/asset.TotalQuantity != 150 {
+		t.Errorf("expected the average cost of the second buy (150), got %v for %v shares", asset.TotalInvested/asset.TotalQuantity, asset.TotalQuantity)
+	}
+
+	account, err := service.calculatePerformance(reentry, startDate, endDate)
+	if err != nil {
+		t.Fatalf("calculatePerformance failed: %v", err)
+	}
+	if account.TotalInvested != 300 {
+		t.Errorf("expected only the second buy to remain invested, got %v", account.TotalInvested)
+	}
+
+	if points := ReplayAssetHoldings(firstPosition, startDate, endDate); points[len(points)-1].Quantity != 0 {
+		t.Errorf("expected no holdings after the sale, got %v", points[len(points)-1].Quantity)
+	}
+}
+
 // **Propriété 10: Calcul de performance avec prix actuels**
 // **Valide: Exigences 4.4, 4.6, 10.7**
 //
@@ -610,3 +1034,115 @@ func padInt(i, width int) string {
 	}
 	return s
 }
+
+// TestGetHistoricalPrice_DayBoundary checks that the close of the requested day is selected
+// even when the requested instant falls before midnight UTC (e.g. midnight in Paris)
+func TestGetHistoricalPrice_DayBoundary(t *testing.T) {
+	db := testutil.NewTestDB(t)
+
+	isin := "IE00B4L5Y983"
+	if err := db.CreateAsset(&models.Asset{ISIN: isin, Name: "iShares Core MSCI World", Type: "etf", Currency: "EUR"}); err != nil {
+		t.Fatalf("Failed to create asset: %v", err)
+	}
+	if err := db.CreateAssetPricesBatch([]models.AssetPrice{
+		{ISIN: isin, Price: 100, Currency: "EUR", Timestamp: time.Date(2024, 3, 14, 0, 0, 0, 0, time.UTC)},
+		{ISIN: isin, Price: 101, Currency: "EUR", Timestamp: time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)},
+	}); err != nil {
+		t.Fatalf("Failed to store prices: %v", err)
+	}
+
+	service := &PerformanceService{DB: db, PriceService: NewMockPriceService()}
+
+	paris, _ := time.LoadLocation("Europe/Paris")
+	for _, date := range []time.Time{
+		time.Date(2024, 3, 15, 0, 0, 0, 0, paris), // Mar 14 23:00 UTC
+		time.Date(2024, 3, 15, 23, 59, 0, 0, time.UTC),
+	} {
+		price, err := service.getHistoricalPrice(isin, date)
+		if err != nil {
+			t.Fatalf("getHistoricalPrice(%s) error = %v", date, err)
+		}
+		if price != 101 {
+			t.Errorf("getHistoricalPrice(%s) = %v, want the Mar 15 close (101)", date, price)
+		}
+	}
+}
+
+// TestCalculatePerformanceAt_AsOfTodayMatchesDefault checks that valuing the portfolio as of
+// now gives the same output as the default valuation at the current prices
+func TestCalculatePerformanceAt_AsOfTodayMatchesDefault(t *testing.T) {
+	service := &PerformanceService{PriceService: NewMockPriceService()}
+
+	transactions := []models.Transaction{
+		{ID: "tx1", Timestamp: "2024-01-01T10:00:00Z", TransactionType: "deposit", AmountValue: 2000},
+		{ID: "tx2", Timestamp: "2024-01-02T10:00:00Z", TransactionType: "buy", ISIN: stringPtr("US0378331005"), Quantity: 10, AmountValue: -900, Fees: "1"},
+		{ID: "tx3", Timestamp: "2024-03-01T10:00:00Z", TransactionType: "sell", ISIN: stringPtr("US0378331005"), Quantity: 4, AmountValue: 420},
+		{ID: "tx4", Timestamp: "2024-04-01T10:00:00Z", TransactionType: "dividend", ISIN: stringPtr("US0378331005"), AmountValue: 5},
+	}
+
+	startDate, endDate := calculateDateRange("all")
+	expected, err := service.calculatePerformance(transactions, startDate, endDate)
+	if err != nil {
+		t.Fatalf("calculatePerformance failed: %v", err)
+	}
+	asOfToday, err := service.calculatePerformanceAt(transactions, startDate, endDate, &endDate, IntervalAuto)
+	if err != nil {
+		t.Fatalf("calculatePerformanceAt failed: %v", err)
+	}
+
+	if !reflect.DeepEqual(expected, asOfToday) {
+		t.Errorf("expected as_of today to match the default output\ndefault: %+v\nas_of:   %+v", expected, asOfToday)
+	}
+}
+
+// TestCalculatePerformanceAt_AsOfUsesStoredClose checks that a past valuation uses the close
+// stored for that date, and lists an asset without stored price as unpriced instead of
+// valuing it at today's price
+func TestCalculatePerformanceAt_AsOfUsesStoredClose(t *testing.T) {
+	db := testutil.NewTestDB(t)
+
+	priced, unpriced := "US0378331005", "US5949181045"
+	for _, isin := range []string{priced, unpriced} {
+		if err := db.CreateAsset(&models.Asset{ISIN: isin, Name: isin, Type: "stock", Currency: "EUR"}); err != nil {
+			t.Fatalf("Failed to create asset: %v", err)
+		}
+	}
+	if err := db.CreateAssetPricesBatch([]models.AssetPrice{
+		{ISIN: priced, Price: 80, Currency: "EUR", Timestamp: time.Date(2024, 2, 28, 0, 0, 0, 0, time.UTC)},
+		{ISIN: priced, Price: 95, Currency: "EUR", Timestamp: time.Date(2024, 3, 29, 0, 0, 0, 0, time.UTC)},
+	}); err != nil {
+		t.Fatalf("Failed to store prices: %v", err)
+	}
+
+	// The mock would value both assets at 100
+	service := &PerformanceService{DB: db, PriceService: NewMockPriceService()}
+
+	transactions := []models.Transaction{
+		{ID: "tx1", Timestamp: "2024-01-01T10:00:00Z", TransactionType: "deposit", AmountValue: 2000},
+		{ID: "tx2", Timestamp: "2024-01-02T10:00:00Z", TransactionType: "buy", ISIN: stringPtr(priced), Quantity: 10, AmountValue: -900},
+		{ID: "tx3", Timestamp: "2024-01-03T10:00:00Z", TransactionType: "buy", ISIN: stringPtr(unpriced), Quantity: 2, AmountValue: -500},
+	}
+
+	asOf := time.Date(2024, 3, 15, 23, 59, 59, 0, time.UTC)
+	startDate, endDate := dateRangeAt("1y", asOf)
+	performance, err := service.calculatePerformanceAt(transactions, startDate, endDate, &asOf, IntervalAuto)
+	if err != nil {
+		t.Fatalf("calculatePerformanceAt failed: %v", err)
+	}
+
+	// 10 shares at the Feb 28 close and the unpriced asset at its invested amount
+	if want := 10*80.0 + 500; math.Abs(performance.TotalValue-want) > 0.01 {
+		t.Errorf("TotalValue = %v, want %v", performance.TotalValue, want)
+	}
+	if !reflect.DeepEqual(performance.UnpricedAssets, []string{unpriced}) {
+		t.Errorf("UnpricedAssets = %v, want [%s]", performance.UnpricedAssets, unpriced)
+	}
+}
+
+func TestDateRangeAt(t *testing.T) {
+	asOf := time.Date(2024, 6, 30, 23, 59, 59, 0, time.UTC)
+	startDate, endDate := dateRangeAt("3m", asOf)
+	if !endDate.Equal(asOf) || !startDate.Equal(time.Date(2024, 3, 30, 23, 59, 59, 0, time.UTC)) {
+		t.Errorf("expected the 3 months ending at as_of, got %s - %s", startDate, endDate)
+	}
+}
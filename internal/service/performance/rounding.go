@@ -0,0 +1,156 @@
+package performance
+
+import (
+	"encoding/json"
+	"math"
+)
+
+// RoundingPolicy defines how many decimals are kept when performance metrics are serialized
+// Computations always run in full precision, rounding only happens on output
+// A negative value disables rounding for that kind of field
+type RoundingPolicy struct {
+	// CurrencyDecimals applies to amounts (values, invested, fees, gains)
+	CurrencyDecimals int
+	// PriceDecimals applies to unit prices
+	PriceDecimals int
+	// PercentDecimals applies to percentages
+	PercentDecimals int
+}
+
+// DefaultRoundingPolicy rounds amounts and percentages to cents and prices to 4 decimals
+var DefaultRoundingPolicy = RoundingPolicy{
+	CurrencyDecimals: 2,
+	PriceDecimals:    4,
+	PercentDecimals:  2,
+}
+
+// roundingPolicy is the policy used by MarshalJSON
+var roundingPolicy = DefaultRoundingPolicy
+
+// SetRoundingPolicy changes the rounding applied on serialization
+// It must be called at startup, before any response is encoded
+func SetRoundingPolicy(policy RoundingPolicy) {
+	roundingPolicy = policy
+}
+
+// roundTo rounds value to the given number of decimals (negative keeps full precision)
+func roundTo(value float64, decimals int) float64 {
+	if decimals < 0 || math.IsNaN(value) || math.IsInf(value, 0) {
+		return value
+	}
+	factor := math.Pow(10, float64(decimals))
+	rounded := math.Round(value*factor) / factor
+	if rounded == 0 {
+		// Avoid emitting -0
+		return 0
+	}
+	return rounded
+}
+
+// MarshalJSON serializes the performance with monetary fields rounded
+func (p Performance) MarshalJSON() ([]byte, error) {
+	type performanceJSON Performance
+	out := performanceJSON(p)
+
+	out.TotalValue = roundTo(p.TotalValue, roundingPolicy.CurrencyDecimals)
+	out.TotalInvested = roundTo(p.TotalInvested, roundingPolicy.CurrencyDecimals)
+	out.CashBalance = roundTo(p.CashBalance, roundingPolicy.CurrencyDecimals)
+	out.TotalFees = roundTo(p.TotalFees, roundingPolicy.CurrencyDecimals)
+	out.RealizedGains = roundTo(p.RealizedGains, roundingPolicy.CurrencyDecimals)
+	out.UnrealizedGains = roundTo(p.UnrealizedGains, roundingPolicy.CurrencyDecimals)
+	out.PerformancePct = roundTo(p.PerformancePct, roundingPolicy.PercentDecimals)
+	out.TotalDeposits = roundTo(p.TotalDeposits, roundingPolicy.CurrencyDecimals)
+	out.DividendIncome = roundTo(p.DividendIncome, roundingPolicy.CurrencyDecimals)
+	out.TotalInterest = roundTo(p.TotalInterest, roundingPolicy.CurrencyDecimals)
+
+	return json.Marshal(out)
+}
+
+// MarshalJSON serializes the asset performance with monetary fields rounded
+func (p AssetPerformance) MarshalJSON() ([]byte, error) {
+	type assetPerformanceJSON AssetPerformance
+	out := assetPerformanceJSON(p)
+
+	out.CurrentPrice = roundTo(p.CurrentPrice, roundingPolicy.PriceDecimals)
+	out.TotalValue = roundTo(p.TotalValue, roundingPolicy.CurrencyDecimals)
+	out.TotalInvested = roundTo(p.TotalInvested, roundingPolicy.CurrencyDecimals)
+	out.TotalFees = roundTo(p.TotalFees, roundingPolicy.CurrencyDecimals)
+	out.RealizedGains = roundTo(p.RealizedGains, roundingPolicy.CurrencyDecimals)
+	out.UnrealizedGains = roundTo(p.UnrealizedGains, roundingPolicy.CurrencyDecimals)
+	out.PerformancePct = roundTo(p.PerformancePct, roundingPolicy.PercentDecimals)
+
+	return json.Marshal(out)
+}
+
+// MarshalJSON serializes the lot with monetary fields rounded
+func (l Lot) MarshalJSON() ([]byte, error) {
+	type lotJSON Lot
+	out := lotJSON(l)
+
+	out.UnitCost = roundTo(l.UnitCost, roundingPolicy.PriceDecimals)
+	out.Cost = roundTo(l.Cost, roundingPolicy.CurrencyDecimals)
+	out.CurrentValue = roundTo(l.CurrentValue, roundingPolicy.CurrencyDecimals)
+	out.UnrealizedGain = roundTo(l.UnrealizedGain, roundingPolicy.CurrencyDecimals)
+	out.UnrealizedGainPct = roundTo(l.UnrealizedGainPct, roundingPolicy.PercentDecimals)
+
+	return json.Marshal(out)
+}
+
+// MarshalJSON serializes the asset lots with the current price rounded
+func (a AssetLots) MarshalJSON() ([]byte, error) {
+	type assetLotsJSON AssetLots
+	out := assetLotsJSON(a)
+
+	out.CurrentPrice = roundTo(a.CurrentPrice, roundingPolicy.PriceDecimals)
+
+	return json.Marshal(out)
+}
+
+// MarshalJSON serializes the cash flow entry with amounts rounded
+func (e CashFlowEntry) MarshalJSON() ([]byte, error) {
+	type cashFlowEntryJSON CashFlowEntry
+	out := cashFlowEntryJSON(e)
+
+	out.Amount = roundTo(e.Amount, roundingPolicy.CurrencyDecimals)
+	out.RunningBalance = roundTo(e.RunningBalance, roundingPolicy.CurrencyDecimals)
+
+	return json.Marshal(out)
+}
+
+// MarshalJSON serializes the cash flow statement with the balance rounded
+func (s CashFlowStatement) MarshalJSON() ([]byte, error) {
+	type cashFlowStatementJSON CashFlowStatement
+	out := cashFlowStatementJSON(s)
+
+	out.Balance = roundTo(s.Balance, roundingPolicy.CurrencyDecimals)
+
+	return json.Marshal(out)
+}
+
+// MarshalJSON serializes the time series point with amounts rounded
+func (p PerformancePoint) MarshalJSON() ([]byte, error) {
+	type performancePointJSON PerformancePoint
+	out := performancePointJSON(p)
+
+	out.Value = roundTo(p.Value, roundingPolicy.CurrencyDecimals)
+	out.Invested = roundTo(p.Invested, roundingPolicy.CurrencyDecimals)
+
+	return json.Marshal(out)
+}
+
+// MarshalJSON serializes the account summary with amounts rounded
+func (s AccountSummary) MarshalJSON() ([]byte, error) {
+	type accountSummaryJSON AccountSummary
+	out := accountSummaryJSON(s)
+
+	out.CashBalance = roundTo(s.CashBalance, roundingPolicy.CurrencyDecimals)
+	out.AssetsValue = roundTo(s.AssetsValue, roundingPolicy.CurrencyDecimals)
+	out.TotalValue = roundTo(s.TotalValue, roundingPolicy.CurrencyDecimals)
+	out.TotalInvested = roundTo(s.TotalInvested, roundingPolicy.CurrencyDecimals)
+	out.TotalDeposits = roundTo(s.TotalDeposits, roundingPolicy.CurrencyDecimals)
+	out.TotalInterest = roundTo(s.TotalInterest, roundingPolicy.CurrencyDecimals)
+	out.TotalFees = roundTo(s.TotalFees, roundingPolicy.CurrencyDecimals)
+	out.PerformancePct = roundTo(s.PerformancePct, roundingPolicy.PercentDecimals)
+
+	return json.Marshal(out)
+}
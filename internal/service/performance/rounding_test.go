@@ -0,0 +1,137 @@
+package performance
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRoundTo(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    float64
+		decimals int
+		expected float64
+	}{
+		{"float noise", 12.340000000000001, 2, 12.34},
+		{"round half up", 1.005000001, 2, 1.01},
+		{"negative amount", -3.14159, 2, -3.14},
+		{"negative zero", -0.001, 2, 0},
+		{"price precision", 0.123456, 4, 0.1235},
+		{"rounding disabled", 1.23456789, -1, 1.23456789},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := roundTo(tt.value, tt.decimals); got != tt.expected {
+				t.Errorf("roundTo(%v, %d) = %v, want %v", tt.value, tt.decimals, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestPerformance_MarshalJSONRoundsMonetaryFields(t *testing.T) {
+	perf := &Performance{
+		TotalValue:      1234.5678,
+		TotalInvested:   1000.004,
+		CashBalance:     12.340000000000001,
+		TotalFees:       0.1 + 0.2,
+		RealizedGains:   -5.555,
+		UnrealizedGains: 234.5638,
+		PerformancePct:  23.456789,
+		TimeSeries: []PerformancePoint{
+			{Date: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), Value: 100.129, Invested: 99.999},
+		},
+	}
+
+	data, err := json.Marshal(perf)
+	if err != nil {
+		t.Fatalf("Failed to marshal performance: %v", err)
+	}
+
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Failed to unmarshal performance: %v", err)
+	}
+
+	expected := map[string]float64{
+		"total_value":      1234.57,
+		"total_invested":   1000,
+		"cash_balance":     12.34,
+		"total_fees":       0.3,
+		"realized_gains":   -5.56,
+		"unrealized_gains": 234.56,
+		"performance_pct":  23.46,
+	}
+	for field, want := range expected {
+		if got := decoded[field].(float64); got != want {
+			t.Errorf("%s = %v, want %v", field, got, want)
+		}
+	}
+
+	point := decoded["time_series"].([]interface{})[0].(map[string]interface{})
+	if point["value"].(float64) != 100.13 || point["invested"].(float64) != 100 {
+		t.Errorf("Time series point not rounded: %v", point)
+	}
+	if !strings.Contains(string(data), `"date":"2024-01-01T00:00:00Z"`) {
+		t.Errorf("Date should be preserved, got %s", data)
+	}
+
+	// Internal values keep full precision
+	if perf.CashBalance != 12.340000000000001 {
+		t.Errorf("Serialization must not modify the struct, got %v", perf.CashBalance)
+	}
+}
+
+func TestAssetPerformance_MarshalJSONRoundsMonetaryFields(t *testing.T) {
+	perf := AssetPerformance{
+		ISIN:          "US0378331005",
+		CurrentPrice:  185.123456,
+		TotalQuantity: 0.123456789,
+		TotalValue:    22.854321,
+		TotalInvested: 20.001,
+	}
+
+	data, err := json.Marshal(perf)
+	if err != nil {
+		t.Fatalf("Failed to marshal asset performance: %v", err)
+	}
+
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Failed to unmarshal asset performance: %v", err)
+	}
+
+	if got := decoded["current_price"].(float64); got != 185.1235 {
+		t.Errorf("current_price = %v, want 185.1235", got)
+	}
+	if got := decoded["total_value"].(float64); got != 22.85 {
+		t.Errorf("total_value = %v, want 22.85", got)
+	}
+	if got := decoded["total_invested"].(float64); got != 20 {
+		t.Errorf("total_invested = %v, want 20", got)
+	}
+	// Quantities are not monetary and keep full precision
+	if got := decoded["total_quantity"].(float64); got != 0.123456789 {
+		t.Errorf("total_quantity = %v, want 0.123456789", got)
+	}
+}
+
+func TestSetRoundingPolicy(t *testing.T) {
+	defer SetRoundingPolicy(DefaultRoundingPolicy)
+
+	SetRoundingPolicy(RoundingPolicy{CurrencyDecimals: 0, PriceDecimals: 4, PercentDecimals: 1})
+
+	data, err := json.Marshal(Performance{TotalValue: 1234.56, PerformancePct: 12.34})
+	if err != nil {
+		t.Fatalf("Failed to marshal performance: %v", err)
+	}
+
+	if !strings.Contains(string(data), `"total_value":1235`) {
+		t.Errorf("Expected total_value rounded to units, got %s", data)
+	}
+	if !strings.Contains(string(data), `"performance_pct":12.3`) {
+		t.Errorf("Expected performance_pct rounded to 1 decimal, got %s", data)
+	}
+}
@@ -0,0 +1,108 @@
+package performance
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Sampling intervals of the performance time series
+const (
+	// IntervalAuto picks the interval from the length of the period: daily up to a month,
+	// every 3 days up to 3 months, weekly beyond
+	IntervalAuto    = "auto"
+	IntervalDaily   = "daily"
+	IntervalWeekly  = "weekly"
+	IntervalMonthly = "monthly"
+)
+
+// MaxTimeSeriesPoints bounds the points of a time series sampled at a requested interval:
+// each point values every holding at its historical price. Daily points over more than about
+// 5 years exceed it. The automatic interval is not bounded, being weekly at worst.
+const MaxTimeSeriesPoints = 2000
+
+// ErrTooManyPoints is returned when the sampling interval would produce more than
+// MaxTimeSeriesPoints points over the period
+var ErrTooManyPoints = errors.New("too many time series points")
+
+// IsSamplingInterval reports whether value is a supported sampling interval
+func IsSamplingInterval(value string) bool {
+	switch value {
+	case IntervalAuto, IntervalDaily, IntervalWeekly, IntervalMonthly:
+		return true
+	}
+	return false
+}
+
+// samplingDates returns the dates of a time series from startDate to endDate at interval,
+// endDate always being the last one. It fails with ErrTooManyPoints when a requested interval
+// exceeds MaxTimeSeriesPoints, end date included.
+func samplingDates(startDate, endDate time.Time, interval string) ([]time.Time, error) {
+	next := func(i int) time.Time {
+		return addMonthsClamped(startDate, i)
+	}
+	if interval != IntervalMonthly {
+		step := autoSamplingStep(startDate, endDate)
+		switch interval {
+		case IntervalDaily:
+			step = 24 * time.Hour
+		case IntervalWeekly:
+			step = 7 * 24 * time.Hour
+		}
+		next = func(i int) time.Time {
+			return startDate.Add(time.Duration(i) * step)
+		}
+	}
+
+	tooManyPoints := func() error {
+		return fmt.Errorf("%w: the %s interval exceeds %d points from %s to %s, use a longer interval or a shorter period",
+			ErrTooManyPoints, interval, MaxTimeSeriesPoints, startDate.Format("2006-01-02"), endDate.Format("2006-01-02"))
+	}
+
+	var dates []time.Time
+	for i := 0; ; i++ {
+		point := next(i)
+		if point.After(endDate) {
+			break
+		}
+		if interval != IntervalAuto && len(dates) >= MaxTimeSeriesPoints {
+			return nil, tooManyPoints()
+		}
+		dates = append(dates, point)
+	}
+
+	// Always add the end date as the last point
+	if len(dates) == 0 || !dates[len(dates)-1].Equal(endDate) {
+		if interval != IntervalAuto && len(dates) >= MaxTimeSeriesPoints {
+			return nil, tooManyPoints()
+		}
+		dates = append(dates, endDate)
+	}
+	return dates, nil
+}
+
+// addMonthsClamped returns date moved by months, on the same day of the month or on the last
+// day of a shorter month: from January 31st, the next month is February 28th or 29th instead
+// of early March as with AddDate
+func addMonthsClamped(date time.Time, months int) time.Time {
+	year, month, day := date.Date()
+	firstOfMonth := time.Date(year, month+time.Month(months), 1, 0, 0, 0, 0, date.Location())
+	if lastDay := firstOfMonth.AddDate(0, 1, -1).Day(); day > lastDay {
+		day = lastDay
+	}
+	hour, minute, sec := date.Clock()
+	return time.Date(firstOfMonth.Year(), firstOfMonth.Month(), day, hour, minute, sec, date.Nanosecond(), date.Location())
+}
+
+// autoSamplingStep returns the interval of IntervalAuto for a period
+func autoSamplingStep(startDate, endDate time.Time) time.Duration {
+	daysDiff := endDate.Sub(startDate).Hours() / 24
+	switch {
+	case daysDiff <= 30:
+		return 24 * time.Hour // Daily up to a month
+	case daysDiff <= 90:
+		return 3 * 24 * time.Hour // Every 3 days up to 3 months
+	default:
+		return 7 * 24 * time.Hour // Weekly for longer periods
+	}
+}
@@ -0,0 +1,111 @@
+package performance
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSamplingDates(t *testing.T) {
+	end := time.Date(2024, 6, 30, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		start    time.Time
+		interval string
+		points   int
+		step     time.Duration // between the first two points, 0 for months
+	}{
+		{"auto daily up to a month", end.AddDate(0, 0, -30), IntervalAuto, 31, 24 * time.Hour},
+		{"auto every 3 days up to 3 months", end.AddDate(0, 0, -90), IntervalAuto, 31, 3 * 24 * time.Hour},
+		{"auto weekly beyond", end.AddDate(-1, 0, 0), IntervalAuto, 54, 7 * 24 * time.Hour},
+		{"daily over a leap year", end.AddDate(-1, 0, 0), IntervalDaily, 367, 24 * time.Hour},
+		{"weekly over a month", end.AddDate(0, 0, -28), IntervalWeekly, 5, 7 * 24 * time.Hour},
+		{"monthly over a year", end.AddDate(-1, 0, 0), IntervalMonthly, 13, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dates, err := samplingDates(tt.start, end, tt.interval)
+			if err != nil {
+				t.Fatalf("samplingDates() error = %v", err)
+			}
+			if len(dates) != tt.points {
+				t.Fatalf("expected %d points, got %d", tt.points, len(dates))
+			}
+			if !dates[0].Equal(tt.start) || !dates[len(dates)-1].Equal(end) {
+				t.Errorf("expected points from %s to %s, got %s to %s", tt.start, end, dates[0], dates[len(dates)-1])
+			}
+			if tt.step != 0 && dates[1].Sub(dates[0]) != tt.step {
+				t.Errorf("expected a step of %s, got %s", tt.step, dates[1].Sub(dates[0]))
+			}
+			if tt.step == 0 && dates[1] != tt.start.AddDate(0, 1, 0) {
+				t.Errorf("expected monthly points, got %s after %s", dates[1], dates[0])
+			}
+		})
+	}
+}
+
+func TestSamplingDates_MonthlyClampsToMonthEnd(t *testing.T) {
+	start := time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2024, 5, 15, 0, 0, 0, 0, time.UTC)
+
+	dates, err := samplingDates(start, end, IntervalMonthly)
+	if err != nil {
+		t.Fatalf("samplingDates() error = %v", err)
+	}
+	expected := []time.Time{
+		start,
+		time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC),
+		time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC),
+		time.Date(2024, 4, 30, 0, 0, 0, 0, time.UTC),
+		end,
+	}
+	if len(dates) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, dates)
+	}
+	for i := range expected {
+		if !dates[i].Equal(expected[i]) {
+			t.Errorf("point %d = %s, want %s", i, dates[i].Format("2006-01-02"), expected[i].Format("2006-01-02"))
+		}
+	}
+}
+
+func TestSamplingDates_TooManyPoints(t *testing.T) {
+	end := time.Date(2024, 6, 30, 0, 0, 0, 0, time.UTC)
+	start := end.AddDate(-10, 0, 0)
+
+	if _, err := samplingDates(start, end, IntervalDaily); !errors.Is(err, ErrTooManyPoints) {
+		t.Errorf("expected ErrTooManyPoints for 10 years of daily points, got %v", err)
+	}
+	for _, interval := range []string{IntervalWeekly, IntervalMonthly, IntervalAuto} {
+		if _, err := samplingDates(start, end, interval); err != nil {
+			t.Errorf("%s over 10 years: unexpected error %v", interval, err)
+		}
+	}
+	// The end point counts in the limit
+	exact := end.AddDate(0, 0, -(MaxTimeSeriesPoints - 1))
+	if dates, err := samplingDates(exact, end, IntervalDaily); err != nil || len(dates) != MaxTimeSeriesPoints {
+		t.Errorf("expected exactly %d daily points, got %d (%v)", MaxTimeSeriesPoints, len(dates), err)
+	}
+	if _, err := samplingDates(exact.Add(-12*time.Hour), end, IntervalDaily); !errors.Is(err, ErrTooManyPoints) {
+		t.Errorf("expected ErrTooManyPoints when the end point is one over the limit, got %v", err)
+	}
+	// The automatic interval is never refused
+	if _, err := samplingDates(end.AddDate(-60, 0, 0), end, IntervalAuto); err != nil {
+		t.Errorf("expected the automatic interval not to be bounded, got %v", err)
+	}
+}
+
+func TestSamplingInterval(t *testing.T) {
+	if interval := (Options{}).interval(); interval != IntervalAuto {
+		t.Errorf("expected auto by default, got %q", interval)
+	}
+	if interval := (Options{Interval: IntervalMonthly}).interval(); interval != IntervalMonthly {
+		t.Errorf("expected monthly, got %q", interval)
+	}
+	for value, valid := range map[string]bool{"auto": true, "daily": true, "weekly": true, "monthly": true, "hourly": false, "": false} {
+		if IsSamplingInterval(value) != valid {
+			t.Errorf("IsSamplingInterval(%q) = %v, want %v", value, !valid, valid)
+		}
+	}
+}
@@ -0,0 +1,287 @@
+package price
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"valhafin/internal/domain/models"
+	"valhafin/internal/repository/database"
+)
+
+// AlphaVantageRequestsPerMinute is the request rate allowed by the Alpha Vantage free tier
+const AlphaVantageRequestsPerMinute = 5
+
+// alphaVantageBaseURL is the Alpha Vantage query endpoint
+const alphaVantageBaseURL = "https://www.alphavantage.co/query"
+
+// AlphaVantageService retrieves prices from Alpha Vantage using the symbol of the assets.
+// Alpha Vantage does not report the quote currency: prices are stored in the asset currency,
+// or in the currency inferred from the symbol when the asset has none.
+type AlphaVantageService struct {
+	db          *database.DB
+	apiKey      string
+	baseURL     string
+	httpClient  *http.Client
+	rateLimiter *RateLimiter
+	// storedFallback returns the last stored price when Alpha Vantage has none
+	storedFallback bool
+}
+
+// NewAlphaVantageService creates a new Alpha Vantage price service
+func NewAlphaVantageService(db *database.DB, apiKey string) *AlphaVantageService {
+	return &AlphaVantageService{
+		db:      db,
+		apiKey:  apiKey,
+		baseURL: alphaVantageBaseURL,
+		httpClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+		rateLimiter:    NewRateLimiter(AlphaVantageRequestsPerMinute),
+		storedFallback: true,
+	}
+}
+
+// SetRateLimit sets the maximum number of Alpha Vantage requests per minute (<= 0 disables limiting)
+func (s *AlphaVantageService) SetRateLimit(requestsPerMinute int) {
+	s.rateLimiter.SetRate(requestsPerMinute)
+}
+
+// SetStoredPriceFallback enables or disables the fallback of GetCurrentPrice on the last stored
+// price. When disabled, the provider error is returned as is.
+func (s *AlphaVantageService) SetStoredPriceFallback(enabled bool) {
+	s.storedFallback = enabled
+}
+
+// alphaVantageSuffixes maps the exchange suffixes of Yahoo Finance symbols, stored on the
+// assets, to the ones of Alpha Vantage
+var alphaVantageSuffixes = map[string]string{
+	".PA": ".PAR",
+	".DE": ".DEX",
+	".L":  ".LON",
+	".TO": ".TRT",
+	".V":  ".TRV",
+	".BO": ".BSE",
+	".SS": ".SHH",
+	".SZ": ".SHZ",
+}
+
+// alphaVantageSymbol returns the Alpha Vantage symbol of a Yahoo Finance symbol
+// (e.g. MC.PA becomes MC.PAR); symbols without a known suffix are kept
+func alphaVantageSymbol(symbol string) string {
+	dot := strings.LastIndex(symbol, ".")
+	if dot < 0 {
+		return symbol
+	}
+	if suffix, ok := alphaVantageSuffixes[strings.ToUpper(symbol[dot:])]; ok {
+		return symbol[:dot] + suffix
+	}
+	return symbol
+}
+
+// GetCurrentPrice retrieves the current price for an asset by ISIN, falling back to
+// the last stored price when Alpha Vantage has none (unless disabled)
+func (s *AlphaVantageService) GetCurrentPrice(isin string) (*models.AssetPrice, error) {
+	asset, err := s.db.GetAssetByISIN(isin)
+	if err != nil {
+		return nil, fmt.Errorf("asset not found: %w", err)
+	}
+	if asset.Symbol == nil || *asset.Symbol == "" {
+		return nil, fmt.Errorf("no symbol found for asset %s", isin)
+	}
+
+	body, err := s.query(url.Values{"function": {"GLOBAL_QUOTE"}, "symbol": {alphaVantageSymbol(*asset.Symbol)}})
+	if err == nil {
+		var value float64
+		if value, err = parseGlobalQuote(body); err == nil {
+			price := &models.AssetPrice{ISIN: isin, Price: value, Currency: alphaVantageCurrency(asset), Timestamp: time.Now()}
+			if err := s.db.CreateAssetPrice(price); err != nil {
+				return nil, fmt.Errorf("failed to store price: %w", err)
+			}
+			return price, nil
+		}
+	}
+
+	log.Printf("WARNING: Alpha Vantage price unavailable for %s: %v", isin, err)
+	if s.storedFallback {
+		if lastPrice, dbErr := s.db.GetLatestAssetPrice(isin); dbErr == nil {
+			return lastPrice, nil
+		}
+	}
+	return nil, fmt.Errorf("failed to fetch price: %w", err)
+}
+
+// GetPriceHistory retrieves daily prices for an asset within a date range and stores them
+func (s *AlphaVantageService) GetPriceHistory(isin string, startDate, endDate time.Time) ([]models.AssetPrice, error) {
+	asset, err := s.db.GetAssetByISIN(isin)
+	if err != nil {
+		return nil, fmt.Errorf("asset not found: %w", err)
+	}
+	if asset.Symbol == nil || *asset.Symbol == "" {
+		return nil, fmt.Errorf("no symbol found for asset %s", isin)
+	}
+
+	body, err := s.query(url.Values{"function": {"TIME_SERIES_DAILY"}, "symbol": {alphaVantageSymbol(*asset.Symbol)}, "outputsize": {"full"}})
+	if err != nil {
+		return nil, err
+	}
+	prices, err := parseDailySeries(body, isin, alphaVantageCurrency(asset), startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(prices) > 0 {
+		if err := s.db.CreateAssetPricesBatch(prices); err != nil {
+			return nil, fmt.Errorf("failed to store prices: %w", err)
+		}
+	}
+	return prices, nil
+}
+
+// alphaVantageCurrency returns the currency of the Alpha Vantage prices of an asset: its stored
+// currency, else the one inferred from its symbol, else USD (listings without suffix)
+func alphaVantageCurrency(asset *models.Asset) string {
+	if asset.Currency != "" {
+		return asset.Currency
+	}
+	symbol := ""
+	if asset.Symbol != nil {
+		symbol = *asset.Symbol
+	}
+	return quoteCurrency("", symbol, "USD")
+}
+
+// UpdateAllPrices updates prices for all assets having a symbol
+func (s *AlphaVantageService) UpdateAllPrices() error {
+	assets, err := s.db.GetAllAssets()
+	if err != nil {
+		return fmt.Errorf("failed to get assets: %w", err)
+	}
+
+	var errors []error
+	successCount := 0
+	for _, asset := range assets {
+		if asset.Symbol == nil || *asset.Symbol == "" {
+			continue
+		}
+		if err := s.UpdateAssetPrice(asset.ISIN); err != nil {
+			errors = append(errors, fmt.Errorf("failed to update %s: %w", asset.ISIN, err))
+		} else {
+			successCount++
+		}
+	}
+
+	if len(errors) > 0 && successCount == 0 {
+		return fmt.Errorf("failed to update all prices: %d errors", len(errors))
+	}
+	return nil
+}
+
+// UpdateAssetPrice updates the price for a specific asset
+func (s *AlphaVantageService) UpdateAssetPrice(isin string) error {
+	_, err := s.GetCurrentPrice(isin)
+	return err
+}
+
+// query sends a request to Alpha Vantage and returns the response body
+func (s *AlphaVantageService) query(params url.Values) ([]byte, error) {
+	params.Set("apikey", s.apiKey)
+
+	s.rateLimiter.Wait()
+	resp, err := s.httpClient.Get(s.baseURL + "?" + params.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch from Alpha Vantage: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Alpha Vantage returned status %d: %s", resp.StatusCode, string(body))
+	}
+	return body, nil
+}
+
+// alphaVantageMessages are the fields Alpha Vantage answers with instead of data,
+// with a 200 status, on errors and when the quota is exceeded
+type alphaVantageMessages struct {
+	ErrorMessage string `json:"Error Message"`
+	Note         string `json:"Note"`
+	Information  string `json:"Information"`
+}
+
+func (m alphaVantageMessages) err() error {
+	switch {
+	case m.ErrorMessage != "":
+		return fmt.Errorf("Alpha Vantage error: %s", m.ErrorMessage)
+	case m.Note != "":
+		return fmt.Errorf("Alpha Vantage limit reached: %s", m.Note)
+	case m.Information != "":
+		return fmt.Errorf("Alpha Vantage limit reached: %s", m.Information)
+	}
+	return nil
+}
+
+// parseGlobalQuote extracts the price of a GLOBAL_QUOTE response
+func parseGlobalQuote(body []byte) (float64, error) {
+	var response struct {
+		alphaVantageMessages
+		Quote struct {
+			Price string `json:"05. price"`
+		} `json:"Global Quote"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return 0, fmt.Errorf("failed to parse response: %w", err)
+	}
+	if err := response.err(); err != nil {
+		return 0, err
+	}
+
+	price, err := strconv.ParseFloat(response.Quote.Price, 64)
+	if err != nil || price <= 0 {
+		return 0, fmt.Errorf("no price data available")
+	}
+	return price, nil
+}
+
+// parseDailySeries extracts the closing prices of a TIME_SERIES_DAILY response between
+// startDate and endDate (inclusive), in chronological order
+func parseDailySeries(body []byte, isin, currency string, startDate, endDate time.Time) ([]models.AssetPrice, error) {
+	var response struct {
+		alphaVantageMessages
+		Series map[string]struct {
+			Close string `json:"4. close"`
+		} `json:"Time Series (Daily)"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	if err := response.err(); err != nil {
+		return nil, err
+	}
+
+	from := startDate.UTC().Truncate(24 * time.Hour)
+	prices := []models.AssetPrice{}
+	for date, values := range response.Series {
+		day, err := time.Parse("2006-01-02", date)
+		if err != nil || day.Before(from) || day.After(endDate) {
+			continue
+		}
+		value, err := strconv.ParseFloat(values.Close, 64)
+		if err != nil || value <= 0 {
+			continue
+		}
+		prices = append(prices, models.AssetPrice{ISIN: isin, Price: value, Currency: currency, Timestamp: day})
+	}
+
+	sort.Slice(prices, func(i, j int) bool { return prices[i].Timestamp.Before(prices[j].Timestamp) })
+	return prices, nil
+}
@@ -0,0 +1,68 @@
+package price
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseGlobalQuote(t *testing.T) {
+	price, err := parseGlobalQuote([]byte(`{"Global Quote":{"01. symbol":"IBM","05. price":"182.3400","07. latest trading day":"2024-06-14"}}`))
+	if err != nil || price != 182.34 {
+		t.Fatalf("expected 182.34, got %v (%v)", price, err)
+	}
+
+	if _, err := parseGlobalQuote([]byte(`{"Global Quote":{}}`)); err == nil {
+		t.Error("expected an error for an unknown symbol")
+	}
+
+	_, err = parseGlobalQuote([]byte(`{"Note":"Thank you for using Alpha Vantage! Our standard API call frequency is 5 calls per minute."}`))
+	if err == nil || !strings.Contains(err.Error(), "limit reached") {
+		t.Errorf("expected a rate limit error, got %v", err)
+	}
+}
+
+func TestAlphaVantageSymbol(t *testing.T) {
+	tests := map[string]string{
+		"MC.PA":   "MC.PAR",
+		"SAP.DE":  "SAP.DEX",
+		"VOD.L":   "VOD.LON",
+		"SHOP.TO": "SHOP.TRT",
+		"AAPL":    "AAPL",
+		"BRK.B":   "BRK.B",
+	}
+	for symbol, expected := range tests {
+		if got := alphaVantageSymbol(symbol); got != expected {
+			t.Errorf("alphaVantageSymbol(%q) = %q, want %q", symbol, got, expected)
+		}
+	}
+}
+
+func TestParseDailySeries(t *testing.T) {
+	body := []byte(`{"Meta Data":{"2. Symbol":"IBM"},"Time Series (Daily)":{
+		"2024-06-14":{"1. open":"180.00","4. close":"182.34"},
+		"2024-06-13":{"1. open":"179.00","4. close":"181.00"},
+		"2024-06-12":{"1. open":"178.00","4. close":"180.50"},
+		"2024-05-31":{"1. open":"170.00","4. close":"171.00"}
+	}}`)
+
+	start := time.Date(2024, 6, 12, 15, 0, 0, 0, time.UTC)
+	end := time.Date(2024, 6, 14, 0, 0, 0, 0, time.UTC)
+	prices, err := parseDailySeries(body, "US4592001014", "USD", start, end)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(prices) != 3 {
+		t.Fatalf("expected 3 prices in range, got %d: %v", len(prices), prices)
+	}
+	if prices[0].Price != 180.50 || prices[2].Price != 182.34 {
+		t.Errorf("expected prices in chronological order, got %v", prices)
+	}
+	if prices[0].ISIN != "US4592001014" || prices[0].Currency != "USD" {
+		t.Errorf("unexpected price fields: %+v", prices[0])
+	}
+
+	if _, err := parseDailySeries([]byte(`{"Error Message":"Invalid API call."}`), "", "", start, end); err == nil {
+		t.Error("expected an error for an error response")
+	}
+}
@@ -0,0 +1,128 @@
+package price
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"valhafin/internal/domain/models"
+)
+
+// CompositeService asks each provider in turn and returns the first successful answer
+type CompositeService struct {
+	providers []Service
+}
+
+// NewCompositeService creates a service falling back from one provider to the next, in order
+func NewCompositeService(providers ...Service) *CompositeService {
+	return &CompositeService{providers: providers}
+}
+
+// GetCurrentPrice returns the price of the first provider that has one
+func (s *CompositeService) GetCurrentPrice(isin string) (*models.AssetPrice, error) {
+	var errs []error
+	for _, provider := range s.providers {
+		price, err := provider.GetCurrentPrice(isin)
+		if err == nil {
+			return price, nil
+		}
+		errs = append(errs, err)
+	}
+	return nil, fmt.Errorf("no provider returned a price for %s: %w", isin, errors.Join(errs...))
+}
+
+// GetPriceHistory returns the history of the first provider that has a non-empty one
+func (s *CompositeService) GetPriceHistory(isin string, startDate, endDate time.Time) ([]models.AssetPrice, error) {
+	var errs []error
+	prices := []models.AssetPrice{}
+	for _, provider := range s.providers {
+		history, err := provider.GetPriceHistory(isin, startDate, endDate)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if len(history) > 0 {
+			return history, nil
+		}
+		prices = history
+	}
+	if len(errs) == len(s.providers) {
+		return nil, fmt.Errorf("no provider returned a price history for %s: %w", isin, errors.Join(errs...))
+	}
+	return prices, nil
+}
+
+// UpdateAllPrices updates the prices with the first provider that succeeds
+func (s *CompositeService) UpdateAllPrices() error {
+	var errs []error
+	for _, provider := range s.providers {
+		err := provider.UpdateAllPrices()
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, err)
+	}
+	return fmt.Errorf("no provider updated the prices: %w", errors.Join(errs...))
+}
+
+// UpdateAssetPrice updates the price of an asset with the first provider that succeeds
+func (s *CompositeService) UpdateAssetPrice(isin string) error {
+	var errs []error
+	for _, provider := range s.providers {
+		err := provider.UpdateAssetPrice(isin)
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, err)
+	}
+	return fmt.Errorf("no provider updated the price of %s: %w", isin, errors.Join(errs...))
+}
+
+// ProviderHealth reports the health of the first provider that can be probed
+func (s *CompositeService) ProviderHealth(ctx context.Context) ProviderHealth {
+	for _, provider := range s.providers {
+		if probed, ok := provider.(interface {
+			ProviderHealth(ctx context.Context) ProviderHealth
+		}); ok {
+			return probed.ProviderHealth(ctx)
+		}
+	}
+	return ProviderHealth{Status: ProviderUp, CheckedAt: time.Now()}
+}
+
+// InvalidateCache drops cached prices from every provider having a cache
+func (s *CompositeService) InvalidateCache(isin string) int {
+	removed := 0
+	for _, provider := range s.providers {
+		if cached, ok := provider.(interface{ InvalidateCache(isin string) int }); ok {
+			removed += cached.InvalidateCache(isin)
+		}
+	}
+	return removed
+}
+
+// CacheStats returns the statistics of the first provider having a cache
+func (s *CompositeService) CacheStats() CacheStats {
+	for _, provider := range s.providers {
+		if cached, ok := provider.(interface{ CacheStats() CacheStats }); ok {
+			return cached.CacheStats()
+		}
+	}
+	return CacheStats{}
+}
+
+// YahooService returns the Yahoo Finance service behind s, which provides symbol search,
+// backfills and interval histories on top of Service
+func YahooService(s Service) (*YahooFinanceService, bool) {
+	switch service := s.(type) {
+	case *YahooFinanceService:
+		return service, true
+	case *CompositeService:
+		for _, provider := range service.providers {
+			if yahoo, ok := YahooService(provider); ok {
+				return yahoo, true
+			}
+		}
+	}
+	return nil, false
+}
@@ -2,16 +2,29 @@ package price
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"sync"
 	"time"
 )
 
-// CurrencyConverter handles currency conversion
+// ErrNoHistoricalRates is returned by rate providers that only know the latest rates
+var ErrNoHistoricalRates = errors.New("historical exchange rates are not available")
+
+// RateProvider supplies the exchange rates used by the CurrencyConverter
+type RateProvider interface {
+	// LatestRate returns the latest rate converting one unit of from into to
+	LatestRate(from, to string) (float64, error)
+	// RateAt returns the rate converting one unit of from into to on the day of at,
+	// or ErrNoHistoricalRates when the provider only knows the latest rates
+	RateAt(from, to string, at time.Time) (float64, error)
+}
+
+// CurrencyConverter handles currency conversion with the rates of a RateProvider
 type CurrencyConverter struct {
-	client *http.Client
-	cache  *ExchangeRateCache
+	provider RateProvider
+	cache    *ExchangeRateCache
 }
 
 // ExchangeRateCache caches exchange rates
@@ -22,12 +35,15 @@ type ExchangeRateCache struct {
 	lastUpdate time.Time
 }
 
-// NewCurrencyConverter creates a new currency converter
+// NewCurrencyConverter creates a new currency converter using exchangerate-api.com
 func NewCurrencyConverter() *CurrencyConverter {
+	return NewCurrencyConverterWithProvider(NewExchangeRateAPIProvider())
+}
+
+// NewCurrencyConverterWithProvider creates a new currency converter using provider
+func NewCurrencyConverterWithProvider(provider RateProvider) *CurrencyConverter {
 	return &CurrencyConverter{
-		client: &http.Client{
-			Timeout: 10 * time.Second,
-		},
+		provider: provider,
 		cache: &ExchangeRateCache{
 			rates: make(map[string]float64),
 			ttl:   1 * time.Hour, // Cache rates for 1 hour
@@ -35,7 +51,7 @@ func NewCurrencyConverter() *CurrencyConverter {
 	}
 }
 
-// Convert converts an amount from one currency to another
+// Convert converts an amount from one currency to another at the latest rate
 func (c *CurrencyConverter) Convert(amount float64, from, to string) (float64, error) {
 	if from == to {
 		return amount, nil
@@ -49,39 +65,36 @@ func (c *CurrencyConverter) Convert(amount float64, from, to string) (float64, e
 	return amount * rate, nil
 }
 
-// GetExchangeRate gets the exchange rate from one currency to another
-func (c *CurrencyConverter) GetExchangeRate(from, to string) (float64, error) {
-	key := fmt.Sprintf("%s_%s", from, to)
-
-	// Check cache
-	if rate := c.cache.Get(key); rate > 0 {
-		return rate, nil
+// ConvertAt converts an amount from one currency to another at the rate of the day of at
+func (c *CurrencyConverter) ConvertAt(amount float64, from, to string, at time.Time) (float64, error) {
+	if from == to {
+		return amount, nil
 	}
 
-	// Fetch from API (using exchangerate-api.com free tier)
-	url := fmt.Sprintf("https://api.exchangerate-api.com/v4/latest/%s", from)
-
-	resp, err := c.client.Get(url)
+	rate, err := c.GetExchangeRateAt(from, to, at)
 	if err != nil {
-		return 0, fmt.Errorf("failed to fetch exchange rate: %w", err)
+		return 0, err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return 0, fmt.Errorf("exchange rate API returned status %d", resp.StatusCode)
-	}
+	return amount * rate, nil
+}
 
-	var result struct {
-		Rates map[string]float64 `json:"rates"`
+// GetExchangeRate gets the latest exchange rate from one currency to another
+func (c *CurrencyConverter) GetExchangeRate(from, to string) (float64, error) {
+	if from == to {
+		return 1, nil
 	}
 
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return 0, fmt.Errorf("failed to parse exchange rate response: %w", err)
+	key := fmt.Sprintf("%s_%s", from, to)
+
+	// Check cache
+	if rate := c.cache.Get(key); rate > 0 {
+		return rate, nil
 	}
 
-	rate, ok := result.Rates[to]
-	if !ok {
-		return 0, fmt.Errorf("exchange rate not found for %s to %s", from, to)
+	rate, err := c.provider.LatestRate(from, to)
+	if err != nil {
+		return 0, err
 	}
 
 	// Cache the rate
@@ -90,6 +103,20 @@ func (c *CurrencyConverter) GetExchangeRate(from, to string) (float64, error) {
 	return rate, nil
 }
 
+// GetExchangeRateAt gets the exchange rate from one currency to another on the day of at.
+// The latest rate is used when the provider has no historical rates.
+func (c *CurrencyConverter) GetExchangeRateAt(from, to string, at time.Time) (float64, error) {
+	if from == to {
+		return 1, nil
+	}
+
+	rate, err := c.provider.RateAt(from, to, at)
+	if errors.Is(err, ErrNoHistoricalRates) {
+		return c.GetExchangeRate(from, to)
+	}
+	return rate, err
+}
+
 // Get retrieves a rate from cache if not expired
 func (c *ExchangeRateCache) Get(key string) float64 {
 	c.mu.RLock()
@@ -110,3 +137,52 @@ func (c *ExchangeRateCache) Set(key string, rate float64) {
 	c.rates[key] = rate
 	c.lastUpdate = time.Now()
 }
+
+// ExchangeRateAPIProvider fetches the latest rates from the exchangerate-api.com free tier
+type ExchangeRateAPIProvider struct {
+	client  *http.Client
+	baseURL string
+}
+
+// NewExchangeRateAPIProvider creates a new exchangerate-api.com rate provider
+func NewExchangeRateAPIProvider() *ExchangeRateAPIProvider {
+	return &ExchangeRateAPIProvider{
+		client: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+		baseURL: "https://api.exchangerate-api.com/v4/latest/",
+	}
+}
+
+// LatestRate fetches the latest rate from one currency to another
+func (p *ExchangeRateAPIProvider) LatestRate(from, to string) (float64, error) {
+	resp, err := p.client.Get(p.baseURL + from)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch exchange rate: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("exchange rate API returned status %d", resp.StatusCode)
+	}
+
+	var result struct {
+		Rates map[string]float64 `json:"rates"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return 0, fmt.Errorf("failed to parse exchange rate response: %w", err)
+	}
+
+	rate, ok := result.Rates[to]
+	if !ok {
+		return 0, fmt.Errorf("exchange rate not found for %s to %s", from, to)
+	}
+
+	return rate, nil
+}
+
+// RateAt returns ErrNoHistoricalRates: the free tier only serves the latest rates
+func (p *ExchangeRateAPIProvider) RateAt(from, to string, at time.Time) (float64, error) {
+	return 0, ErrNoHistoricalRates
+}
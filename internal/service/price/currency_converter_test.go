@@ -0,0 +1,149 @@
+package price
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+// fakeRateProvider answers with fixed rates and counts the calls
+type fakeRateProvider struct {
+	latest      map[string]float64
+	historical  map[string]float64 // keyed by "FROM_TO_2006-01-02", nil for no history
+	latestCalls int
+	atCalls     int
+}
+
+func (p *fakeRateProvider) LatestRate(from, to string) (float64, error) {
+	p.latestCalls++
+	rate, ok := p.latest[from+"_"+to]
+	if !ok {
+		return 0, errors.New("rate not found")
+	}
+	return rate, nil
+}
+
+func (p *fakeRateProvider) RateAt(from, to string, at time.Time) (float64, error) {
+	p.atCalls++
+	if p.historical == nil {
+		return 0, ErrNoHistoricalRates
+	}
+	rate, ok := p.historical[from+"_"+to+"_"+at.Format("2006-01-02")]
+	if !ok {
+		return 0, errors.New("rate not found")
+	}
+	return rate, nil
+}
+
+func TestCurrencyConverter_DelegatesToProvider(t *testing.T) {
+	provider := &fakeRateProvider{latest: map[string]float64{"USD_EUR": 0.9}}
+	converter := NewCurrencyConverterWithProvider(provider)
+
+	amount, err := converter.Convert(100, "USD", "EUR")
+	if err != nil || math.Abs(amount-90) > 1e-9 {
+		t.Fatalf("Convert() = %v, %v; expected 90", amount, err)
+	}
+	if _, err := converter.GetExchangeRate("USD", "EUR"); err != nil {
+		t.Fatalf("GetExchangeRate() error = %v", err)
+	}
+	if provider.latestCalls != 1 {
+		t.Errorf("expected the latest rate to be cached, got %d provider calls", provider.latestCalls)
+	}
+
+	if amount, _ := converter.Convert(100, "EUR", "EUR"); amount != 100 || provider.latestCalls != 1 {
+		t.Errorf("expected same-currency conversion without provider call, got %v", amount)
+	}
+	if _, err := converter.Convert(100, "USD", "JPY"); err == nil {
+		t.Error("expected the provider error to be returned")
+	}
+}
+
+func TestCurrencyConverter_ConvertAt(t *testing.T) {
+	day := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	provider := &fakeRateProvider{
+		latest:     map[string]float64{"USD_EUR": 0.9},
+		historical: map[string]float64{"USD_EUR_2024-01-15": 0.8},
+	}
+	converter := NewCurrencyConverterWithProvider(provider)
+
+	amount, err := converter.ConvertAt(100, "USD", "EUR", day)
+	if err != nil || math.Abs(amount-80) > 1e-9 {
+		t.Fatalf("ConvertAt() = %v, %v; expected 80", amount, err)
+	}
+	if provider.atCalls != 1 || provider.latestCalls != 0 {
+		t.Errorf("expected the historical rate only, got %d historical and %d latest calls", provider.atCalls, provider.latestCalls)
+	}
+	if _, err := converter.ConvertAt(100, "USD", "EUR", day.AddDate(0, 0, 1)); err == nil {
+		t.Error("expected an error for a day without rate")
+	}
+
+	// Providers without history fall back to the latest rate
+	provider.historical = nil
+	amount, err = converter.ConvertAt(100, "USD", "EUR", day)
+	if err != nil || math.Abs(amount-90) > 1e-9 {
+		t.Errorf("ConvertAt() without history = %v, %v; expected 90", amount, err)
+	}
+}
+
+func TestParseChartData_ConvertsAtDailyRates(t *testing.T) {
+	first, second := 100.0, 200.0
+	chart := YahooChartResult{
+		Meta: YahooMeta{Currency: "USD", ExchangeTimezoneName: "America/New_York"},
+		Timestamp: []int{
+			int(time.Date(2024, 1, 15, 14, 30, 0, 0, time.UTC).Unix()),
+			int(time.Date(2024, 1, 16, 14, 30, 0, 0, time.UTC).Unix()),
+		},
+		Indicators: YahooIndicators{Quote: []YahooQuote{{Close: []*float64{&first, &second}}}},
+	}
+	provider := &fakeRateProvider{
+		latest:     map[string]float64{"USD_EUR": 0.9},
+		historical: map[string]float64{"USD_EUR_2024-01-15": 0.8},
+	}
+	service := &YahooFinanceService{}
+	service.SetCurrencyConverter(NewCurrencyConverterWithProvider(provider))
+
+	prices, err := service.parseChartData(context.Background(), chart, "US0378331005", "EUR")
+	if err != nil {
+		t.Fatalf("parseChartData() error = %v", err)
+	}
+	if len(prices) != 2 {
+		t.Fatalf("expected 2 prices, got %d", len(prices))
+	}
+	if math.Abs(prices[0].Price-80) > 1e-9 || prices[0].Currency != "EUR" {
+		t.Errorf("expected the Jan 15 close at the rate of the day, got %v %s", prices[0].Price, prices[0].Currency)
+	}
+	if math.Abs(prices[1].Price-180) > 1e-9 {
+		t.Errorf("expected the latest rate for a day without rate, got %v", prices[1].Price)
+	}
+}
+
+func TestParseChartData_SkipsZeroCloses(t *testing.T) {
+	body := `{"chart":{"result":[{
+		"meta":{"currency":"EUR","symbol":"IWDA.AS","exchangeTimezoneName":"Europe/Amsterdam"},
+		"timestamp":[1705309200,1705395600,1705482000,1705568400],
+		"indicators":{"quote":[{"close":[80.5,null,0,81.2]}]}
+	}],"error":null}}`
+	var response YahooChartResponse
+	if err := json.Unmarshal([]byte(body), &response); err != nil {
+		t.Fatalf("failed to decode chart: %v", err)
+	}
+
+	service := &YahooFinanceService{}
+	service.SetCurrencyConverter(NewCurrencyConverterWithProvider(&fakeRateProvider{}))
+
+	prices, err := service.parseChartData(context.Background(), response.Chart.Result[0], "IE00B4L5Y983", "EUR")
+	if err != nil {
+		t.Fatalf("parseChartData() error = %v", err)
+	}
+	if len(prices) != 2 || prices[0].Price != 80.5 || prices[1].Price != 81.2 {
+		t.Fatalf("expected the null and zero closes to be skipped, got %+v", prices)
+	}
+	for _, p := range prices {
+		if err := p.Validate(); err != nil {
+			t.Errorf("expected only storable prices, got %+v: %v", p, err)
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package price
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ecbHistoryURL is the ECB archive of every daily reference rate since 1999
+const ecbHistoryURL = "https://www.ecb.europa.eu/stats/eurofxref/eurofxref-hist.zip"
+
+// ecbRefreshInterval is how long downloaded ECB rates are used before downloading them
+// again (the ECB publishes once per working day)
+const ecbRefreshInterval = 12 * time.Hour
+
+// ecbDay holds the reference rates published by the ECB for a day, in units per euro
+type ecbDay struct {
+	date  time.Time
+	rates map[string]float64
+}
+
+// ECBProvider uses the daily reference rates of the European Central Bank. The rates are
+// published against the euro on working days: other pairs are crossed through the euro and
+// days without publication use the previous one. The rates are downloaded, or read from a
+// CSV or ZIP file in the ECB format for offline use.
+type ECBProvider struct {
+	client *http.Client
+	url    string
+	file   string
+	now    func() time.Time
+
+	mu       sync.Mutex
+	days     []ecbDay // ascending by date
+	loadedAt time.Time
+}
+
+// NewECBProvider creates an ECB rate provider downloading the rates
+func NewECBProvider() *ECBProvider {
+	return &ECBProvider{
+		client: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+		url: ecbHistoryURL,
+		now: time.Now,
+	}
+}
+
+// NewECBFileProvider creates an ECB rate provider reading the rates from file
+// (eurofxref-hist.csv, or the eurofxref-hist.zip archive)
+func NewECBFileProvider(file string) *ECBProvider {
+	provider := NewECBProvider()
+	provider.file = file
+	return provider
+}
+
+// LatestRate returns the rate of the last published day
+func (p *ECBProvider) LatestRate(from, to string) (float64, error) {
+	return p.RateAt(from, to, p.now())
+}
+
+// RateAt returns the rate published on the day of at, or on the last working day before it
+func (p *ECBProvider) RateAt(from, to string, at time.Time) (float64, error) {
+	days, err := p.load()
+	if err != nil {
+		return 0, err
+	}
+	return ecbRate(days, from, to, at)
+}
+
+// load returns the rates, reading them on first use and downloading them again once stale
+func (p *ECBProvider) load() ([]ecbDay, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.days != nil && (p.file != "" || p.now().Sub(p.loadedAt) < ecbRefreshInterval) {
+		return p.days, nil
+	}
+
+	days, err := p.read()
+	if err != nil {
+		if p.days != nil {
+			log.Printf("WARNING: Failed to refresh ECB exchange rates, using rates loaded at %s: %v", p.loadedAt.Format(time.RFC3339), err)
+			p.loadedAt = p.now()
+			return p.days, nil
+		}
+		return nil, err
+	}
+
+	p.days = days
+	p.loadedAt = p.now()
+	return days, nil
+}
+
+// read reads the rates from the configured file or downloads them
+func (p *ECBProvider) read() ([]ecbDay, error) {
+	var data []byte
+	if p.file != "" {
+		content, err := os.ReadFile(p.file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read ECB rates file: %w", err)
+		}
+		data = content
+	} else {
+		resp, err := p.client.Get(p.url)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch ECB exchange rates: %w", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("ECB returned status %d", resp.StatusCode)
+		}
+		if data, err = io.ReadAll(resp.Body); err != nil {
+			return nil, fmt.Errorf("failed to read ECB exchange rates: %w", err)
+		}
+	}
+
+	// The history is distributed as a ZIP archive holding a single CSV file
+	if bytes.HasPrefix(data, []byte("PK")) {
+		archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return nil, fmt.Errorf("invalid ECB rates archive: %w", err)
+		}
+		if len(archive.File) == 0 {
+			return nil, fmt.Errorf("ECB rates archive is empty")
+		}
+		file, err := archive.File[0].Open()
+		if err != nil {
+			return nil, fmt.Errorf("invalid ECB rates archive: %w", err)
+		}
+		defer file.Close()
+		return parseECBRates(file)
+	}
+	return parseECBRates(bytes.NewReader(data))
+}
+
+// parseECBRates parses the ECB rates CSV: a Date column followed by one column per
+// currency, in units per euro. Rates published as N/A are skipped.
+func parseECBRates(r io.Reader) ([]ecbDay, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ECB rates: %w", err)
+	}
+	if len(records) < 2 || len(records[0]) < 2 || strings.TrimSpace(records[0][0]) != "Date" {
+		return nil, fmt.Errorf("failed to parse ECB rates: expected a Date column followed by currencies")
+	}
+
+	currencies := make([]string, len(records[0]))
+	for i, column := range records[0] {
+		currencies[i] = strings.TrimSpace(column)
+	}
+
+	days := make([]ecbDay, 0, len(records)-1)
+	for line, record := range records[1:] {
+		date, err := time.Parse("2006-01-02", strings.TrimSpace(record[0]))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse ECB rates: line %d: invalid date %q", line+2, record[0])
+		}
+		day := ecbDay{date: date, rates: map[string]float64{"EUR": 1}}
+		for i := 1; i < len(record) && i < len(currencies); i++ {
+			if currencies[i] == "" {
+				continue
+			}
+			rate, err := strconv.ParseFloat(strings.TrimSpace(record[i]), 64)
+			if err != nil || rate <= 0 {
+				continue
+			}
+			day.rates[currencies[i]] = rate
+		}
+		days = append(days, day)
+	}
+
+	sort.Slice(days, func(i, j int) bool { return days[i].date.Before(days[j].date) })
+	return days, nil
+}
+
+// ecbRate returns the rate converting from into to on the last day published at or before at
+func ecbRate(days []ecbDay, from, to string, at time.Time) (float64, error) {
+	day := time.Date(at.Year(), at.Month(), at.Day(), 0, 0, 0, 0, time.UTC)
+	i := sort.Search(len(days), func(i int) bool { return days[i].date.After(day) }) - 1
+	if i < 0 {
+		return 0, fmt.Errorf("no ECB exchange rate before %s", day.Format("2006-01-02"))
+	}
+
+	fromRate, ok := days[i].rates[from]
+	if !ok {
+		return 0, fmt.Errorf("ECB exchange rate not found for %s on %s", from, days[i].date.Format("2006-01-02"))
+	}
+	toRate, ok := days[i].rates[to]
+	if !ok {
+		return 0, fmt.Errorf("ECB exchange rate not found for %s on %s", to, days[i].date.Format("2006-01-02"))
+	}
+	return toRate / fromRate, nil
+}
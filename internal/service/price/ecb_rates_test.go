@@ -0,0 +1,110 @@
+package price
+
+import (
+	"archive/zip"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const ecbRatesCSV = `Date,USD,JPY,GBP,
+2024-01-16,1.0882,160.89,0.8601,
+2024-01-15,1.0945,160.35,N/A,
+2024-01-12,1.0942,159.16,0.8595,
+`
+
+func TestParseECBRates(t *testing.T) {
+	days, err := parseECBRates(strings.NewReader(ecbRatesCSV))
+	if err != nil {
+		t.Fatalf("parseECBRates() error = %v", err)
+	}
+	if len(days) != 3 || !days[0].date.Equal(time.Date(2024, 1, 12, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("expected 3 days in ascending order, got %+v", days)
+	}
+	if _, ok := days[1].rates["GBP"]; ok {
+		t.Error("expected N/A rates to be skipped")
+	}
+
+	if _, err := parseECBRates(strings.NewReader("isin,price\nUS0378331005,1\n")); err == nil {
+		t.Error("expected an error for a file without Date column")
+	}
+}
+
+func TestECBRate(t *testing.T) {
+	days, _ := parseECBRates(strings.NewReader(ecbRatesCSV))
+
+	tests := []struct {
+		name     string
+		from, to string
+		at       time.Time
+		expected float64
+	}{
+		{"euro to currency", "EUR", "USD", time.Date(2024, 1, 15, 18, 0, 0, 0, time.UTC), 1.0945},
+		{"currency to euro", "USD", "EUR", time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC), 1 / 1.0945},
+		{"cross rate", "USD", "JPY", time.Date(2024, 1, 16, 0, 0, 0, 0, time.UTC), 160.89 / 1.0882},
+		{"weekend uses friday", "EUR", "USD", time.Date(2024, 1, 14, 0, 0, 0, 0, time.UTC), 1.0942},
+		{"after last day", "EUR", "GBP", time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), 0.8601},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rate, err := ecbRate(days, tt.from, tt.to, tt.at)
+			if err != nil {
+				t.Fatalf("ecbRate() error = %v", err)
+			}
+			if math.Abs(rate-tt.expected) > 1e-9 {
+				t.Errorf("expected %v, got %v", tt.expected, rate)
+			}
+		})
+	}
+
+	if _, err := ecbRate(days, "EUR", "USD", time.Date(2024, 1, 11, 0, 0, 0, 0, time.UTC)); err == nil {
+		t.Error("expected an error before the first published day")
+	}
+	if _, err := ecbRate(days, "EUR", "GBP", time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)); err == nil {
+		t.Error("expected an error for a rate not published that day")
+	}
+	if _, err := ecbRate(days, "EUR", "XYZ", time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)); err == nil {
+		t.Error("expected an error for an unknown currency")
+	}
+}
+
+func TestECBFileProvider(t *testing.T) {
+	dir := t.TempDir()
+
+	csvFile := filepath.Join(dir, "eurofxref-hist.csv")
+	if err := os.WriteFile(csvFile, []byte(ecbRatesCSV), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	zipFile := filepath.Join(dir, "eurofxref-hist.zip")
+	out, err := os.Create(zipFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	archive := zip.NewWriter(out)
+	entry, _ := archive.Create("eurofxref-hist.csv")
+	entry.Write([]byte(ecbRatesCSV))
+	archive.Close()
+	out.Close()
+
+	for _, file := range []string{csvFile, zipFile} {
+		provider := NewECBFileProvider(file)
+		provider.now = func() time.Time { return time.Date(2024, 1, 20, 0, 0, 0, 0, time.UTC) }
+
+		rate, err := provider.LatestRate("EUR", "USD")
+		if err != nil || math.Abs(rate-1.0882) > 1e-9 {
+			t.Errorf("%s: LatestRate() = %v, %v; expected 1.0882", filepath.Base(file), rate, err)
+		}
+		rate, err = provider.RateAt("EUR", "USD", time.Date(2024, 1, 12, 0, 0, 0, 0, time.UTC))
+		if err != nil || math.Abs(rate-1.0942) > 1e-9 {
+			t.Errorf("%s: RateAt() = %v, %v; expected 1.0942", filepath.Base(file), rate, err)
+		}
+	}
+
+	if _, err := NewECBFileProvider(filepath.Join(dir, "missing.csv")).LatestRate("EUR", "USD"); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+}
@@ -0,0 +1,67 @@
+package price
+
+import (
+	"fmt"
+	"valhafin/internal/config"
+	"valhafin/internal/repository/database"
+)
+
+// NewService creates the price service selected by cfg.Provider (Yahoo Finance when empty)
+func NewService(cfg config.PriceConfig, db *database.DB) (Service, error) {
+	rates, err := NewRateProvider(cfg)
+	if err != nil {
+		return nil, err
+	}
+	converter := NewCurrencyConverterWithProvider(rates)
+
+	switch cfg.Provider {
+	case config.PriceProviderYahoo, "":
+		return newYahooService(cfg, db, converter), nil
+	case config.PriceProviderAlphaVantage:
+		if cfg.AlphaVantageKey == "" {
+			return nil, fmt.Errorf("price provider %q requires an Alpha Vantage API key (ALPHA_VANTAGE_API_KEY)", cfg.Provider)
+		}
+		return NewAlphaVantageService(db, cfg.AlphaVantageKey), nil
+	case config.PriceProviderComposite:
+		// Each provider returns its error so that the next one is asked; the stored
+		// prices come last
+		yahoo := newYahooService(cfg, db, converter)
+		yahoo.SetStoredPriceFallback(false)
+		providers := []Service{yahoo}
+		if cfg.AlphaVantageKey != "" {
+			alphaVantage := NewAlphaVantageService(db, cfg.AlphaVantageKey)
+			alphaVantage.SetStoredPriceFallback(false)
+			providers = append(providers, alphaVantage)
+		}
+		providers = append(providers, NewStaticService(db))
+		return NewCompositeService(providers...), nil
+	case config.PriceProviderStatic:
+		return NewStaticService(db), nil
+	}
+	return nil, fmt.Errorf("unknown price provider %q", cfg.Provider)
+}
+
+// NewRateProvider creates the exchange rate provider selected by cfg.ExchangeRateProvider
+// (exchangerate-api.com when empty)
+func NewRateProvider(cfg config.PriceConfig) (RateProvider, error) {
+	switch cfg.ExchangeRateProvider {
+	case config.ExchangeRateProviderAPI, "":
+		return NewExchangeRateAPIProvider(), nil
+	case config.ExchangeRateProviderECB:
+		if cfg.ECBRatesFile != "" {
+			return NewECBFileProvider(cfg.ECBRatesFile), nil
+		}
+		return NewECBProvider(), nil
+	}
+	return nil, fmt.Errorf("unknown exchange rate provider %q", cfg.ExchangeRateProvider)
+}
+
+// newYahooService creates a Yahoo Finance service applying the configured rate limit
+// and currency converter
+func newYahooService(cfg config.PriceConfig, db *database.DB, converter *CurrencyConverter) *YahooFinanceService {
+	service := NewYahooFinanceService(db)
+	service.SetRateLimit(cfg.RequestsPerMinute)
+	service.SetCurrencyConverter(converter)
+	service.SetNameBackfill(cfg.BackfillAssetNames)
+	return service
+}
@@ -0,0 +1,138 @@
+package price
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+	"valhafin/internal/config"
+	"valhafin/internal/domain/models"
+)
+
+func TestNewService_SelectsProvider(t *testing.T) {
+	tests := []struct {
+		cfg      config.PriceConfig
+		expected string
+	}{
+		{config.PriceConfig{}, "*price.YahooFinanceService"},
+		{config.PriceConfig{Provider: config.PriceProviderYahoo}, "*price.YahooFinanceService"},
+		{config.PriceConfig{Provider: config.PriceProviderAlphaVantage, AlphaVantageKey: "demo"}, "*price.AlphaVantageService"},
+		{config.PriceConfig{Provider: config.PriceProviderComposite}, "*price.CompositeService"},
+		{config.PriceConfig{Provider: config.PriceProviderStatic}, "*price.StaticService"},
+	}
+
+	for _, tt := range tests {
+		service, err := NewService(tt.cfg, nil)
+		if err != nil {
+			t.Fatalf("unexpected error for %q: %v", tt.cfg.Provider, err)
+		}
+		if got := fmt.Sprintf("%T", service); got != tt.expected {
+			t.Errorf("provider %q: expected %s, got %s", tt.cfg.Provider, tt.expected, got)
+		}
+	}
+
+	composite, _ := NewService(config.PriceConfig{Provider: config.PriceProviderComposite, AlphaVantageKey: "demo"}, nil)
+	if providers := composite.(*CompositeService).providers; len(providers) != 3 {
+		t.Errorf("expected Yahoo, Alpha Vantage and stored prices in the composite, got %d providers", len(providers))
+	}
+	yahoo, ok := YahooService(composite)
+	if !ok {
+		t.Fatal("expected the Yahoo Finance service to be found behind the composite")
+	}
+	// Behind the composite, the providers return their errors so that the next one is asked
+	if yahoo.storedFallback || composite.(*CompositeService).providers[1].(*AlphaVantageService).storedFallback {
+		t.Error("expected the stored price fallback to be left to the composite")
+	}
+}
+
+func TestNewService_Errors(t *testing.T) {
+	_, err := NewService(config.PriceConfig{Provider: config.PriceProviderAlphaVantage}, nil)
+	if err == nil || !strings.Contains(err.Error(), "ALPHA_VANTAGE_API_KEY") {
+		t.Errorf("expected a missing key error, got %v", err)
+	}
+
+	if _, err := NewService(config.PriceConfig{Provider: "bloomberg"}, nil); err == nil {
+		t.Error("expected an error for an unknown provider")
+	}
+
+	if _, err := NewService(config.PriceConfig{ExchangeRateProvider: "oanda"}, nil); err == nil {
+		t.Error("expected an error for an unknown exchange rate provider")
+	}
+}
+
+func TestNewRateProvider_SelectsProvider(t *testing.T) {
+	tests := []struct {
+		cfg      config.PriceConfig
+		expected string
+	}{
+		{config.PriceConfig{}, "*price.ExchangeRateAPIProvider"},
+		{config.PriceConfig{ExchangeRateProvider: config.ExchangeRateProviderAPI}, "*price.ExchangeRateAPIProvider"},
+		{config.PriceConfig{ExchangeRateProvider: config.ExchangeRateProviderECB}, "*price.ECBProvider"},
+	}
+
+	for _, tt := range tests {
+		provider, err := NewRateProvider(tt.cfg)
+		if err != nil {
+			t.Fatalf("unexpected error for %q: %v", tt.cfg.ExchangeRateProvider, err)
+		}
+		if got := fmt.Sprintf("%T", provider); got != tt.expected {
+			t.Errorf("provider %q: expected %s, got %s", tt.cfg.ExchangeRateProvider, tt.expected, got)
+		}
+	}
+
+	provider, _ := NewRateProvider(config.PriceConfig{ExchangeRateProvider: config.ExchangeRateProviderECB, ECBRatesFile: "rates.csv"})
+	if ecb := provider.(*ECBProvider); ecb.file != "rates.csv" {
+		t.Errorf("expected the ECB provider to read rates.csv, got %q", ecb.file)
+	}
+}
+
+// stubService answers with a fixed price or error
+type stubService struct {
+	price *models.AssetPrice
+	err   error
+	calls int
+}
+
+func (s *stubService) GetCurrentPrice(isin string) (*models.AssetPrice, error) {
+	s.calls++
+	return s.price, s.err
+}
+
+func (s *stubService) GetPriceHistory(isin string, startDate, endDate time.Time) ([]models.AssetPrice, error) {
+	s.calls++
+	if s.err != nil {
+		return nil, s.err
+	}
+	return []models.AssetPrice{*s.price}, nil
+}
+
+func (s *stubService) UpdateAllPrices() error             { return s.err }
+func (s *stubService) UpdateAssetPrice(isin string) error { return s.err }
+
+func TestCompositeService_FallsBack(t *testing.T) {
+	failing := &stubService{err: errors.New("provider down")}
+	working := &stubService{price: &models.AssetPrice{ISIN: "US0378331005", Price: 189.5, Currency: "USD"}}
+	unused := &stubService{price: &models.AssetPrice{ISIN: "US0378331005", Price: 1, Currency: "USD"}}
+	service := NewCompositeService(failing, working, unused)
+
+	price, err := service.GetCurrentPrice("US0378331005")
+	if err != nil || price.Price != 189.5 {
+		t.Fatalf("expected the second provider's price, got %v (%v)", price, err)
+	}
+	if unused.calls != 0 {
+		t.Error("expected the providers after a successful one not to be called")
+	}
+	if err := service.UpdateAllPrices(); err != nil {
+		t.Errorf("expected the update to succeed with the second provider, got %v", err)
+	}
+
+	service = NewCompositeService(failing, &stubService{err: errors.New("quota exceeded")})
+	_, err = service.GetCurrentPrice("US0378331005")
+	if err == nil || !strings.Contains(err.Error(), "provider down") || !strings.Contains(err.Error(), "quota exceeded") {
+		t.Errorf("expected every provider error to be reported, got %v", err)
+	}
+	if _, err := service.GetPriceHistory("US0378331005", time.Now().AddDate(0, -1, 0), time.Now()); err == nil {
+		t.Error("expected a history error when every provider fails")
+	}
+}
@@ -0,0 +1,119 @@
+package price
+
+import (
+	"sort"
+	"time"
+	"valhafin/internal/domain/models"
+)
+
+// History intervals accepted by GetPriceHistoryWithInterval
+const (
+	// HistoryIntervalAuto lets the service pick the interval from the length of the range
+	// (daily up to a month, weekly beyond)
+	HistoryIntervalAuto = "auto"
+	// HistoryIntervalDaily requests daily prices whatever the length of the range
+	HistoryIntervalDaily = "1d"
+)
+
+// IsHistoryInterval reports whether interval is a supported history interval
+func IsHistoryInterval(interval string) bool {
+	return interval == HistoryIntervalAuto || interval == HistoryIntervalDaily
+}
+
+// maxDailyGapDays is the longest gap between two daily prices that is still considered
+// complete: a long weekend followed by a public holiday
+const maxDailyGapDays = 5
+
+// hasDailyCoverage reports whether stored prices already cover a range with daily
+// granularity, so that it does not need to be fetched again
+func hasDailyCoverage(prices []models.AssetPrice, startDate, endDate time.Time) bool {
+	if len(prices) == 0 {
+		return false
+	}
+
+	maxGap := time.Duration(maxDailyGapDays) * 24 * time.Hour
+
+	sorted := sortedByTimestamp(prices)
+	if sorted[0].Timestamp.Sub(startDate) > maxGap {
+		return false
+	}
+	if endDate.Sub(sorted[len(sorted)-1].Timestamp) > maxGap {
+		return false
+	}
+	for i := 1; i < len(sorted); i++ {
+		if sorted[i].Timestamp.Sub(sorted[i-1].Timestamp) > maxGap {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ForwardFillDaily returns one price per calendar day from the first known price to
+// endDate: days without a price (weekends, holidays, missing data) carry the last known
+// price and are flagged as filled. Days before the first known price are left out since
+// there is nothing to carry forward.
+func ForwardFillDaily(prices []models.AssetPrice, endDate time.Time) []models.AssetPrice {
+	if len(prices) == 0 {
+		return prices
+	}
+
+	sorted := sortedByTimestamp(prices)
+	lastDay := truncateToDay(endDate)
+
+	filled := make([]models.AssetPrice, 0, len(sorted))
+	for i, price := range sorted {
+		// Keep a single price per day: the latest one
+		if i+1 < len(sorted) && truncateToDay(sorted[i+1].Timestamp).Equal(truncateToDay(price.Timestamp)) {
+			continue
+		}
+		filled = append(filled, price)
+
+		next := lastDay.AddDate(0, 0, 1)
+		if i+1 < len(sorted) {
+			next = truncateToDay(sorted[i+1].Timestamp)
+		}
+		for day := 1; truncateToDay(price.Timestamp).AddDate(0, 0, day).Before(next); day++ {
+			carried := price
+			carried.ID = 0
+			carried.Timestamp = price.Timestamp.AddDate(0, 0, day)
+			carried.Filled = true
+			filled = append(filled, carried)
+		}
+	}
+
+	return filled
+}
+
+// sortedByTimestamp returns a copy of prices ordered from the oldest to the newest
+func sortedByTimestamp(prices []models.AssetPrice) []models.AssetPrice {
+	sorted := make([]models.AssetPrice, len(prices))
+	copy(sorted, prices)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Timestamp.Before(sorted[j].Timestamp)
+	})
+	return sorted
+}
+
+// truncateToDay returns midnight of the day of t, in the location of t
+func truncateToDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// TradingDay returns the trading date of a Yahoo Finance bar as midnight UTC. Bars are
+// timestamped at the session open, which falls on the previous UTC day for exchanges ahead
+// of UTC (e.g. Sydney), so the date is taken in the exchange time zone.
+func TradingDay(timestamp int64, meta YahooMeta) time.Time {
+	local := time.Unix(timestamp, 0).In(meta.location())
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// location returns the exchange time zone, from its name or else from its UTC offset
+func (m YahooMeta) location() *time.Location {
+	if m.ExchangeTimezoneName != "" {
+		if loc, err := time.LoadLocation(m.ExchangeTimezoneName); err == nil {
+			return loc
+		}
+	}
+	return time.FixedZone("", m.GmtOffset)
+}
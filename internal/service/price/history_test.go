@@ -0,0 +1,172 @@
+package price
+
+import (
+	"context"
+	"testing"
+	"time"
+	"valhafin/internal/domain/models"
+)
+
+func historyPrice(day string, value float64) models.AssetPrice {
+	timestamp, _ := time.Parse("2006-01-02 15:04", day+" 17:30")
+	return models.AssetPrice{ISIN: "IE00B4L5Y983", Price: value, Currency: "EUR", Timestamp: timestamp}
+}
+
+func TestForwardFillDaily(t *testing.T) {
+	// Friday and Tuesday quotes, the Monday is a holiday
+	prices := []models.AssetPrice{
+		historyPrice("2024-01-09", 102),
+		historyPrice("2024-01-05", 100),
+	}
+	endDate := time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)
+
+	filled := ForwardFillDaily(prices, endDate)
+
+	expected := []struct {
+		day    string
+		price  float64
+		filled bool
+	}{
+		{"2024-01-05", 100, false},
+		{"2024-01-06", 100, true},
+		{"2024-01-07", 100, true},
+		{"2024-01-08", 100, true},
+		{"2024-01-09", 102, false},
+		{"2024-01-10", 102, true},
+	}
+
+	if len(filled) != len(expected) {
+		t.Fatalf("Expected %d prices, got %d", len(expected), len(filled))
+	}
+	for i, want := range expected {
+		got := filled[i]
+		if got.Timestamp.Format("2006-01-02") != want.day || got.Price != want.price || got.Filled != want.filled {
+			t.Errorf("Price %d: expected %s %.0f filled=%v, got %s %.0f filled=%v",
+				i, want.day, want.price, want.filled, got.Timestamp.Format("2006-01-02"), got.Price, got.Filled)
+		}
+	}
+}
+
+func TestForwardFillDailyKeepsLatestPriceOfADay(t *testing.T) {
+	morning := historyPrice("2024-01-05", 100)
+	morning.Timestamp = morning.Timestamp.Add(-8 * time.Hour)
+	prices := []models.AssetPrice{morning, historyPrice("2024-01-05", 101)}
+
+	filled := ForwardFillDaily(prices, time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC))
+
+	if len(filled) != 1 || filled[0].Price != 101 {
+		t.Errorf("Expected the closing price only, got %+v", filled)
+	}
+}
+
+func TestForwardFillDailyEmpty(t *testing.T) {
+	if filled := ForwardFillDaily(nil, time.Now()); len(filled) != 0 {
+		t.Errorf("Expected no price, got %d", len(filled))
+	}
+}
+
+func TestHasDailyCoverage(t *testing.T) {
+	startDate := time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC)
+	endDate := time.Date(2024, 1, 12, 0, 0, 0, 0, time.UTC)
+
+	daily := []models.AssetPrice{
+		historyPrice("2024-01-05", 100),
+		historyPrice("2024-01-08", 101),
+		historyPrice("2024-01-09", 102),
+		historyPrice("2024-01-10", 103),
+		historyPrice("2024-01-11", 104),
+	}
+	if !hasDailyCoverage(daily, startDate, endDate) {
+		t.Error("Expected daily prices with a weekend gap to cover the range")
+	}
+
+	weekly := []models.AssetPrice{
+		historyPrice("2024-01-05", 100),
+		historyPrice("2024-01-12", 101),
+	}
+	if hasDailyCoverage(weekly, startDate, endDate) {
+		t.Error("Expected weekly prices not to cover the range")
+	}
+
+	recentOnly := []models.AssetPrice{historyPrice("2024-01-11", 104)}
+	if hasDailyCoverage(recentOnly, startDate, endDate) {
+		t.Error("Expected prices missing the start of the range not to cover it")
+	}
+
+	if hasDailyCoverage(nil, startDate, endDate) {
+		t.Error("Expected no price not to cover the range")
+	}
+}
+
+func TestIsHistoryInterval(t *testing.T) {
+	for _, interval := range []string{HistoryIntervalAuto, HistoryIntervalDaily} {
+		if !IsHistoryInterval(interval) {
+			t.Errorf("Expected %q to be a history interval", interval)
+		}
+	}
+	if IsHistoryInterval("1wk") {
+		t.Error("Expected 1wk not to be a history interval")
+	}
+}
+
+func TestTradingDay(t *testing.T) {
+	tests := []struct {
+		name      string
+		timestamp time.Time
+		meta      YahooMeta
+		want      string
+	}{
+		{
+			// ASX opens at 10:00 in Sydney, still the previous day in UTC
+			name:      "exchange ahead of UTC",
+			timestamp: time.Date(2024, 3, 14, 23, 0, 0, 0, time.UTC),
+			meta:      YahooMeta{ExchangeTimezoneName: "Australia/Sydney", GmtOffset: 39600},
+			want:      "2024-03-15",
+		},
+		{
+			// NYSE closes at 16:00 in New York, already the next day in Paris
+			name:      "exchange behind UTC",
+			timestamp: time.Date(2024, 3, 15, 20, 0, 0, 0, time.UTC),
+			meta:      YahooMeta{ExchangeTimezoneName: "America/New_York", GmtOffset: -14400},
+			want:      "2024-03-15",
+		},
+		{
+			name:      "offset only",
+			timestamp: time.Date(2024, 3, 14, 23, 0, 0, 0, time.UTC),
+			meta:      YahooMeta{GmtOffset: 39600},
+			want:      "2024-03-15",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			day := TradingDay(tt.timestamp.Unix(), tt.meta)
+			if day.Format("2006-01-02") != tt.want || day.Location() != time.UTC || day.Hour() != 0 {
+				t.Errorf("TradingDay() = %s, want %s at midnight UTC", day, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseChartData_StoresTradingDays(t *testing.T) {
+	first, second := 50.0, 51.0
+	chart := YahooChartResult{
+		Meta: YahooMeta{Currency: "AUD", ExchangeTimezoneName: "Australia/Sydney"},
+		Timestamp: []int{
+			int(time.Date(2024, 3, 13, 23, 0, 0, 0, time.UTC).Unix()),
+			int(time.Date(2024, 3, 14, 23, 0, 0, 0, time.UTC).Unix()),
+		},
+		Indicators: YahooIndicators{Quote: []YahooQuote{{Close: []*float64{&first, &second}}}},
+	}
+
+	prices, err := (&YahooFinanceService{}).parseChartData(context.Background(), chart, "AU000000BHP4", "AUD")
+	if err != nil {
+		t.Fatalf("parseChartData() error = %v", err)
+	}
+	if len(prices) != 2 {
+		t.Fatalf("expected 2 prices, got %d", len(prices))
+	}
+	if !prices[1].Timestamp.Equal(time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)) || prices[1].Price != second {
+		t.Errorf("expected the Mar 15 close at its trading day, got %v at %s", prices[1].Price, prices[1].Timestamp)
+	}
+}
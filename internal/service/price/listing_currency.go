@@ -0,0 +1,62 @@
+package price
+
+import "strings"
+
+// symbolSuffixCurrencies maps Yahoo Finance symbol suffixes to the currency of the listing
+var symbolSuffixCurrencies = map[string]string{
+	".DE": "EUR",
+	".F":  "EUR",
+	".SG": "EUR",
+	".PA": "EUR",
+	".AS": "EUR",
+	".BR": "EUR",
+	".LS": "EUR",
+	".MI": "EUR",
+	".MC": "EUR",
+	".VI": "EUR",
+	".IR": "EUR",
+	".HE": "EUR",
+	".L":  "GBP",
+	".SW": "CHF",
+	".TO": "CAD",
+	".T":  "JPY",
+	".HK": "HKD",
+	".AX": "AUD",
+	".ST": "SEK",
+	".CO": "DKK",
+	".OL": "NOK",
+}
+
+// symbolCurrency infers the currency of a listing from its symbol: the suffix gives the
+// exchange, crypto pairs (BTC-USD) their quote currency and symbols without suffix are US
+// listings. It returns "" when the suffix is unknown.
+func symbolCurrency(symbol string) string {
+	symbol = strings.ToUpper(strings.TrimSpace(symbol))
+	if symbol == "" {
+		return ""
+	}
+
+	if dash := strings.LastIndex(symbol, "-"); dash > 0 && !strings.Contains(symbol[dash:], ".") {
+		if quote := symbol[dash+1:]; len(quote) == 3 {
+			return quote
+		}
+	}
+
+	dot := strings.LastIndex(symbol, ".")
+	if dot <= 0 {
+		return "USD"
+	}
+	return symbolSuffixCurrencies[symbol[dot:]]
+}
+
+// quoteCurrency returns the currency of a quote: the one reported by the provider, else the
+// one inferred from the symbol, else the stored asset currency
+func quoteCurrency(reported, symbol, assetCurrency string) string {
+	if reported != "" {
+		return reported
+	}
+	if currency := symbolCurrency(symbol); currency != "" {
+		return currency
+	}
+	return assetCurrency
+}
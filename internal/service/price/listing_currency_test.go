@@ -0,0 +1,77 @@
+package price
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+	"valhafin/internal/domain/models"
+)
+
+func TestSymbolCurrency(t *testing.T) {
+	tests := map[string]string{
+		"SAP.DE":  "EUR",
+		"AIR.PA":  "EUR",
+		"ASML.AS": "EUR",
+		"VUSA.L":  "GBP",
+		"NESN.SW": "CHF",
+		"RY.TO":   "CAD",
+		"7203.T":  "JPY",
+		"AAPL":    "USD",
+		"BRK-B":   "USD",
+		"BTC-EUR": "EUR",
+		"XYZ.ZZ":  "",
+		"":        "",
+	}
+	for symbol, expected := range tests {
+		if currency := symbolCurrency(symbol); currency != expected {
+			t.Errorf("symbolCurrency(%q) = %q, expected %q", symbol, currency, expected)
+		}
+	}
+}
+
+func TestQuoteCurrency(t *testing.T) {
+	if currency := quoteCurrency("USD", "SAP.DE", "EUR"); currency != "USD" {
+		t.Errorf("expected the reported currency to win, got %s", currency)
+	}
+	if currency := quoteCurrency("", "VUSA.L", "EUR"); currency != "GBP" {
+		t.Errorf("expected the currency of the listing, got %s", currency)
+	}
+	if currency := quoteCurrency("", "XYZ.ZZ", "EUR"); currency != "EUR" {
+		t.Errorf("expected the asset currency for an unknown suffix, got %s", currency)
+	}
+}
+
+func TestAlphaVantageCurrency(t *testing.T) {
+	symbol := "NESN.SW"
+	if currency := alphaVantageCurrency(&models.Asset{Symbol: &symbol, Currency: "EUR"}); currency != "EUR" {
+		t.Errorf("expected the stored asset currency, got %s", currency)
+	}
+	if currency := alphaVantageCurrency(&models.Asset{Symbol: &symbol}); currency != "CHF" {
+		t.Errorf("expected the currency of the listing, got %s", currency)
+	}
+	unknown := "XYZ.ZZ"
+	if currency := alphaVantageCurrency(&models.Asset{Symbol: &unknown}); currency != "USD" {
+		t.Errorf("expected USD for an unknown listing, got %s", currency)
+	}
+}
+
+func TestParseChartData_InfersMissingCurrency(t *testing.T) {
+	last := 100.0
+	chart := YahooChartResult{
+		Meta:       YahooMeta{Symbol: "VUSA.L", ExchangeTimezoneName: "Europe/London"},
+		Timestamp:  []int{int(time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC).Unix())},
+		Indicators: YahooIndicators{Quote: []YahooQuote{{Close: []*float64{&last}}}},
+	}
+	provider := &fakeRateProvider{latest: map[string]float64{"GBP_EUR": 1.15}}
+	service := &YahooFinanceService{}
+	service.SetCurrencyConverter(NewCurrencyConverterWithProvider(provider))
+
+	prices, err := service.parseChartData(context.Background(), chart, "IE00B3XXRP09", "EUR")
+	if err != nil {
+		t.Fatalf("parseChartData() error = %v", err)
+	}
+	if len(prices) != 1 || math.Abs(prices[0].Price-115) > 1e-9 || prices[0].Currency != "EUR" {
+		t.Errorf("expected the London close converted from GBP, got %+v", prices)
+	}
+}
@@ -0,0 +1,182 @@
+package price
+
+import (
+	"strings"
+	"time"
+	_ "time/tzdata" // Exchange time zones must resolve even without system zoneinfo
+	"valhafin/internal/domain/models"
+)
+
+// Cache durations of current prices depending on market hours
+const (
+	// MarketOpenPriceTTL is how long a price is cached while its market trades
+	MarketOpenPriceTTL = 5 * time.Minute
+	// MaxMarketClosedPriceTTL caps how long a price is cached while its market is closed
+	MaxMarketClosedPriceTTL = 12 * time.Hour
+)
+
+// Exchanges known to the market hours helper
+const (
+	ExchangeNYSE    = "NYSE"
+	ExchangeToronto = "TSX"
+	ExchangeLondon  = "LSE"
+	ExchangeParis   = "EURONEXT"
+	ExchangeXetra   = "XETRA"
+	ExchangeSwiss   = "SIX"
+	ExchangeTokyo   = "TSE"
+	ExchangeCrypto  = "CRYPTO"
+)
+
+// tradingSession describes the regular session of an exchange in its local time.
+// Holidays and lunch breaks are not taken into account.
+type tradingSession struct {
+	timezone string
+	open     time.Duration // Since local midnight
+	close    time.Duration
+	always   bool // Trades around the clock (crypto)
+}
+
+// tradingSessions lists the regular sessions by exchange
+var tradingSessions = map[string]tradingSession{
+	ExchangeNYSE:    {timezone: "America/New_York", open: 9*time.Hour + 30*time.Minute, close: 16 * time.Hour},
+	ExchangeToronto: {timezone: "America/Toronto", open: 9*time.Hour + 30*time.Minute, close: 16 * time.Hour},
+	ExchangeLondon:  {timezone: "Europe/London", open: 8 * time.Hour, close: 16*time.Hour + 30*time.Minute},
+	ExchangeParis:   {timezone: "Europe/Paris", open: 9 * time.Hour, close: 17*time.Hour + 30*time.Minute},
+	ExchangeXetra:   {timezone: "Europe/Berlin", open: 9 * time.Hour, close: 17*time.Hour + 30*time.Minute},
+	ExchangeSwiss:   {timezone: "Europe/Zurich", open: 9 * time.Hour, close: 17*time.Hour + 30*time.Minute},
+	ExchangeTokyo:   {timezone: "Asia/Tokyo", open: 9 * time.Hour, close: 15 * time.Hour},
+	ExchangeCrypto:  {always: true},
+}
+
+// symbolSuffixExchanges maps Yahoo Finance symbol suffixes to exchanges
+var symbolSuffixExchanges = map[string]string{
+	".TO": ExchangeToronto,
+	".L":  ExchangeLondon,
+	".PA": ExchangeParis,
+	".AS": ExchangeParis,
+	".BR": ExchangeParis,
+	".LS": ExchangeParis,
+	".MI": ExchangeParis,
+	".MC": ExchangeParis,
+	".DE": ExchangeXetra,
+	".F":  ExchangeXetra,
+	".SG": ExchangeXetra,
+	".SW": ExchangeSwiss,
+	".T":  ExchangeTokyo,
+}
+
+// currencyExchanges is the fallback exchange of an asset by its currency
+var currencyExchanges = map[string]string{
+	"USD": ExchangeNYSE,
+	"CAD": ExchangeToronto,
+	"GBP": ExchangeLondon,
+	"GBp": ExchangeLondon,
+	"GBX": ExchangeLondon,
+	"EUR": ExchangeXetra,
+	"CHF": ExchangeSwiss,
+	"JPY": ExchangeTokyo,
+}
+
+// exchangeFor returns the exchange of an asset from its Yahoo Finance symbol,
+// falling back to its currency. It returns "" when the exchange is unknown.
+func exchangeFor(symbol, currency string) string {
+	symbol = strings.ToUpper(strings.TrimSpace(symbol))
+
+	// Yahoo crypto pairs look like BTC-USD
+	if strings.HasSuffix(symbol, "-USD") || strings.HasSuffix(symbol, "-EUR") {
+		return ExchangeCrypto
+	}
+
+	if dot := strings.LastIndex(symbol, "."); dot > 0 {
+		if exchange, ok := symbolSuffixExchanges[symbol[dot:]]; ok {
+			return exchange
+		}
+	} else if symbol != "" && currency == "USD" {
+		// US listings have no suffix
+		return ExchangeNYSE
+	}
+
+	return currencyExchanges[currency]
+}
+
+// assetExchange returns the exchange of an asset
+func assetExchange(asset *models.Asset) string {
+	if asset.Type == "crypto" {
+		return ExchangeCrypto
+	}
+	symbol := ""
+	if asset.Symbol != nil {
+		symbol = *asset.Symbol
+	}
+	return exchangeFor(symbol, asset.Currency)
+}
+
+// isMarketOpen reports whether the exchange is in its regular session at now.
+// Unknown exchanges are considered open so that their prices stay fresh.
+func isMarketOpen(exchange string, now time.Time) bool {
+	session, ok := tradingSessions[exchange]
+	if !ok || session.always {
+		return true
+	}
+
+	local := now.In(session.location())
+	if local.Weekday() == time.Saturday || local.Weekday() == time.Sunday {
+		return false
+	}
+
+	clock := time.Duration(local.Hour())*time.Hour + time.Duration(local.Minute())*time.Minute +
+		time.Duration(local.Second())*time.Second
+	return clock >= session.open && clock < session.close
+}
+
+// nextMarketOpen returns the start of the next regular session of the exchange after now
+func nextMarketOpen(exchange string, now time.Time) time.Time {
+	session, ok := tradingSessions[exchange]
+	if !ok || session.always {
+		return now
+	}
+
+	local := now.In(session.location())
+	for i := 0; i <= 7; i++ {
+		open := atClock(local.AddDate(0, 0, i), session.open)
+		if open.Weekday() == time.Saturday || open.Weekday() == time.Sunday {
+			continue
+		}
+		if open.After(now) {
+			return open
+		}
+	}
+	return now
+}
+
+// priceTTL returns how long the current price of an asset may be cached: briefly
+// while its market trades, until the next session (capped) while it is closed
+func priceTTL(exchange string, now time.Time) time.Duration {
+	if isMarketOpen(exchange, now) {
+		return MarketOpenPriceTTL
+	}
+
+	ttl := nextMarketOpen(exchange, now).Sub(now)
+	if ttl < MarketOpenPriceTTL {
+		return MarketOpenPriceTTL
+	}
+	if ttl > MaxMarketClosedPriceTTL {
+		return MaxMarketClosedPriceTTL
+	}
+	return ttl
+}
+
+// location returns the time zone of the session, UTC if it cannot be loaded
+func (s tradingSession) location() *time.Location {
+	loc, err := time.LoadLocation(s.timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// atClock returns the time of day clock on the date of t, in the location of t
+func atClock(t time.Time, clock time.Duration) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, int(clock/time.Hour), int(clock%time.Hour/time.Minute), 0, 0, t.Location())
+}
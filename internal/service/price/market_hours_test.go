@@ -0,0 +1,86 @@
+package price
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIsMarketOpen(t *testing.T) {
+	// Wednesday 2024-07-10, summer time in Europe and the US
+	wednesday := func(hour, minute int) time.Time {
+		return time.Date(2024, 7, 10, hour, minute, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name     string
+		exchange string
+		now      time.Time
+		expected bool
+	}{
+		{"NYSE before open", ExchangeNYSE, wednesday(13, 29), false},   // 09:29 New York
+		{"NYSE at open", ExchangeNYSE, wednesday(13, 30), true},        // 09:30 New York
+		{"NYSE at close", ExchangeNYSE, wednesday(20, 0), false},       // 16:00 New York
+		{"Xetra morning", ExchangeXetra, wednesday(7, 0), true},        // 09:00 Berlin
+		{"Xetra after close", ExchangeXetra, wednesday(15, 30), false}, // 17:30 Berlin
+		{"London lunch", ExchangeLondon, wednesday(11, 0), true},       // 12:00 London
+		{"Tokyo evening", ExchangeTokyo, wednesday(7, 0), false},       // 16:00 Tokyo
+		{"Tokyo morning", ExchangeTokyo, wednesday(1, 0), true},        // 10:00 Tokyo
+		{"Paris on Saturday", ExchangeParis, time.Date(2024, 7, 13, 10, 0, 0, 0, time.UTC), false},
+		{"crypto on Sunday", ExchangeCrypto, time.Date(2024, 7, 14, 3, 0, 0, 0, time.UTC), true},
+		{"unknown exchange", "", wednesday(3, 0), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isMarketOpen(tt.exchange, tt.now); got != tt.expected {
+				t.Errorf("isMarketOpen(%s, %s) = %v, want %v", tt.exchange, tt.now, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestExchangeFor(t *testing.T) {
+	tests := []struct {
+		symbol   string
+		currency string
+		expected string
+	}{
+		{"AAPL", "USD", ExchangeNYSE},
+		{"AIR.PA", "EUR", ExchangeParis},
+		{"SAP.DE", "EUR", ExchangeXetra},
+		{"IGLN.L", "USD", ExchangeLondon},
+		{"7203.T", "JPY", ExchangeTokyo},
+		{"BTC-EUR", "EUR", ExchangeCrypto},
+		{"", "CHF", ExchangeSwiss},
+		{"XYZ", "SEK", ""},
+	}
+
+	for _, tt := range tests {
+		if got := exchangeFor(tt.symbol, tt.currency); got != tt.expected {
+			t.Errorf("exchangeFor(%q, %q) = %q, want %q", tt.symbol, tt.currency, got, tt.expected)
+		}
+	}
+}
+
+func TestPriceTTL(t *testing.T) {
+	// Open market: short TTL
+	if ttl := priceTTL(ExchangeXetra, time.Date(2024, 7, 10, 10, 0, 0, 0, time.UTC)); ttl != MarketOpenPriceTTL {
+		t.Errorf("open market TTL = %v, want %v", ttl, MarketOpenPriceTTL)
+	}
+
+	// Xetra closes at 17:30 Berlin (15:30 UTC) and opens at 09:00 (07:00 UTC): cache until the next open
+	if ttl := priceTTL(ExchangeXetra, time.Date(2024, 7, 10, 22, 0, 0, 0, time.UTC)); ttl != 9*time.Hour {
+		t.Errorf("closed market TTL = %v, want 9h", ttl)
+	}
+
+	// Over the weekend the TTL is capped
+	if ttl := priceTTL(ExchangeXetra, time.Date(2024, 7, 13, 12, 0, 0, 0, time.UTC)); ttl != MaxMarketClosedPriceTTL {
+		t.Errorf("weekend TTL = %v, want %v", ttl, MaxMarketClosedPriceTTL)
+	}
+
+	// Friday evening in New York: the next session is on Monday
+	friday := time.Date(2024, 7, 12, 21, 0, 0, 0, time.UTC)
+	if next := nextMarketOpen(ExchangeNYSE, friday); next.Weekday() != time.Monday || next.UTC().Hour() != 13 || next.UTC().Minute() != 30 {
+		t.Errorf("next NYSE open after Friday close = %s, want Monday 13:30 UTC", next.UTC())
+	}
+}
@@ -460,3 +460,181 @@ func TestProperty_FallbackToLastKnownPrice(t *testing.T) {
 
 	properties.TestingRun(t)
 }
+
+func TestBackfillChunks(t *testing.T) {
+	day := func(y int, m time.Month, d int) time.Time {
+		return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name      string
+		start     time.Time
+		end       time.Time
+		chunkDays int
+		want      []dateWindow
+	}{
+		{
+			name:      "single day",
+			start:     day(2024, 3, 1),
+			end:       day(2024, 3, 1),
+			chunkDays: 365,
+			want:      []dateWindow{{day(2024, 3, 1), day(2024, 3, 2)}},
+		},
+		{
+			name:      "range fits in one chunk",
+			start:     day(2024, 1, 1),
+			end:       day(2024, 1, 31),
+			chunkDays: 365,
+			want:      []dateWindow{{day(2024, 1, 1), day(2024, 2, 1)}},
+		},
+		{
+			name:      "range split with shorter last chunk",
+			start:     day(2024, 1, 1),
+			end:       day(2024, 1, 25),
+			chunkDays: 10,
+			want: []dateWindow{
+				{day(2024, 1, 1), day(2024, 1, 11)},
+				{day(2024, 1, 11), day(2024, 1, 21)},
+				{day(2024, 1, 21), day(2024, 1, 26)},
+			},
+		},
+		{
+			name:      "time of day is truncated",
+			start:     time.Date(2024, 1, 1, 15, 30, 0, 0, time.UTC),
+			end:       time.Date(2024, 1, 2, 9, 0, 0, 0, time.UTC),
+			chunkDays: 365,
+			want:      []dateWindow{{day(2024, 1, 1), day(2024, 1, 3)}},
+		},
+		{
+			name:      "start after end",
+			start:     day(2024, 2, 1),
+			end:       day(2024, 1, 1),
+			chunkDays: 365,
+			want:      nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := backfillChunks(tt.start, tt.end, tt.chunkDays)
+			if len(got) != len(tt.want) {
+				t.Fatalf("expected %d chunks, got %d: %v", len(tt.want), len(got), got)
+			}
+			for i := range got {
+				if !got[i].Start.Equal(tt.want[i].Start) || !got[i].End.Equal(tt.want[i].End) {
+					t.Errorf("chunk %d: expected [%v, %v), got [%v, %v)", i,
+						tt.want[i].Start, tt.want[i].End, got[i].Start, got[i].End)
+				}
+			}
+		})
+	}
+}
+
+func TestChunkSymbols(t *testing.T) {
+	symbols := []string{"A", "B", "C", "D", "E"}
+
+	chunks := chunkSymbols(symbols, 2)
+	if len(chunks) != 3 {
+		t.Fatalf("expected 3 chunks, got %d", len(chunks))
+	}
+	if len(chunks[0]) != 2 || len(chunks[1]) != 2 || len(chunks[2]) != 1 {
+		t.Errorf("unexpected chunk sizes: %v", chunks)
+	}
+	if chunks[2][0] != "E" {
+		t.Errorf("expected last chunk to contain E, got %v", chunks[2])
+	}
+
+	if chunks := chunkSymbols(nil, BatchQuoteSize); len(chunks) != 0 {
+		t.Errorf("expected no chunks for empty input, got %v", chunks)
+	}
+}
+
+func TestParseQuoteResponse(t *testing.T) {
+	body := []byte(`{"quoteResponse":{"result":[
+		{"symbol":"AAPL","currency":"USD","regularMarketPrice":189.5},
+		{"symbol":"IGLN.L","currency":"GBp","regularMarketPrice":3712},
+		{"symbol":"DELISTED","currency":"EUR"}
+	],"error":null}}`)
+
+	quotes, err := parseQuoteResponse(body)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(quotes) != 2 {
+		t.Fatalf("expected 2 quotes, got %d: %v", len(quotes), quotes)
+	}
+	if q := quotes["AAPL"]; q.Price != 189.5 || q.Currency != "USD" {
+		t.Errorf("unexpected AAPL quote: %+v", q)
+	}
+	if _, ok := quotes["DELISTED"]; ok {
+		t.Errorf("quote without price should be left out for the chart fallback")
+	}
+
+	if _, err := parseQuoteResponse([]byte(`{"quoteResponse":{"result":null,"error":{"code":"Unauthorized","description":"Invalid Crumb"}}}`)); err == nil {
+		t.Errorf("expected an error for an error response")
+	}
+}
+
+func TestPriceCacheInvalidateAndStats(t *testing.T) {
+	cache := &PriceCache{prices: make(map[string]*CachedPrice), ttl: time.Hour}
+	cache.Set("US0378331005", &models.AssetPrice{ISIN: "US0378331005", Price: 190})
+	cache.Set("IE00B4ND3602", &models.AssetPrice{ISIN: "IE00B4ND3602", Price: 45})
+
+	cache.Get("US0378331005")
+	cache.Get("FR0000120271")
+
+	stats := cache.Stats()
+	if stats.Size != 2 || stats.Hits != 1 || stats.Misses != 1 || stats.HitRate != 0.5 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+
+	if removed := cache.Invalidate("US0378331005"); removed != 1 {
+		t.Errorf("expected 1 entry removed, got %d", removed)
+	}
+	if cache.Get("US0378331005") != nil {
+		t.Error("invalidated ISIN should no longer be cached")
+	}
+	if removed := cache.Invalidate("US0378331005"); removed != 0 {
+		t.Errorf("expected nothing removed for a missing ISIN, got %d", removed)
+	}
+
+	if removed := cache.Invalidate(""); removed != 1 {
+		t.Errorf("expected the remaining entry to be cleared, got %d", removed)
+	}
+	if stats := cache.Stats(); stats.Size != 0 || stats.Misses != 2 {
+		t.Errorf("unexpected stats after clearing: %+v", stats)
+	}
+}
+
+func TestAssetNameFromResults(t *testing.T) {
+	results := []YahooSearchResult{
+		{Symbol: "IWDA.L", ShortName: "ISHARES CORE MSCI WORLD"},
+		{Symbol: "EUNL.DE", Name: "iShares Core MSCI World UCITS ETF USD (Acc)"},
+		{Symbol: "UNK.PA", Name: "Unknown"},
+		{Symbol: "IE00B4L5Y983.SG", Name: "iShares Core MSCI World UCITS ETF"},
+	}
+
+	tests := []struct {
+		name     string
+		results  []YahooSearchResult
+		isin     string
+		symbol   string
+		expected string
+	}{
+		{"matching symbol", results, "", "eunl.de", "iShares Core MSCI World UCITS ETF USD (Acc)"},
+		{"short name fallback", results, "", "IWDA.L", "ISHARES CORE MSCI WORLD"},
+		{"symbol preferred over ISIN listing", results, "IE00B4L5Y983", "EUNL.DE", "iShares Core MSCI World UCITS ETF USD (Acc)"},
+		{"ISIN listing", results, "IE00B4L5Y983", "", "iShares Core MSCI World UCITS ETF"},
+		{"unknown symbol", results, "", "XXX", ""},
+		{"unknown symbol and ISIN", results, "US0378331005", "AAPL", ""},
+		{"never returns Unknown", results[2:3], "", "UNK.PA", ""},
+		{"no results", nil, "IE00B4L5Y983", "EUNL.DE", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if name := assetNameFromResults(tt.results, tt.isin, tt.symbol); name != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, name)
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package price
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Price provider health states
+const (
+	// ProviderUp means the provider answered the probe normally
+	ProviderUp = "up"
+	// ProviderDegraded means the provider answered with an error (e.g. rate limiting)
+	ProviderDegraded = "degraded"
+	// ProviderDown means the provider could not be reached in time
+	ProviderDown = "down"
+)
+
+const (
+	// ProviderProbeTimeout bounds the probe request so that health checks stay fast
+	ProviderProbeTimeout = 3 * time.Second
+	// ProviderProbeTTL is how long a probe result is reused before probing again
+	ProviderProbeTTL = time.Minute
+)
+
+// ProviderStatusError is returned when the price provider answers with an error status
+type ProviderStatusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *ProviderStatusError) Error() string {
+	return fmt.Sprintf("Yahoo Finance API returned status %d: %s", e.StatusCode, e.Body)
+}
+
+// ProviderHealth is the result of a price provider probe
+type ProviderHealth struct {
+	Status    string    `json:"status"`
+	CheckedAt time.Time `json:"checked_at"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// ProviderProbe checks the price provider with a cheap request and caches the result,
+// so that frequent health checks do not add to the provider rate limits
+type ProviderProbe struct {
+	check   func(ctx context.Context) error
+	ttl     time.Duration
+	timeout time.Duration
+	now     func() time.Time
+
+	mu   sync.Mutex
+	last *ProviderHealth
+}
+
+// NewProviderProbe creates a probe running check at most once per ttl
+func NewProviderProbe(check func(ctx context.Context) error, ttl time.Duration) *ProviderProbe {
+	return &ProviderProbe{
+		check:   check,
+		ttl:     ttl,
+		timeout: ProviderProbeTimeout,
+		now:     time.Now,
+	}
+}
+
+// Health returns the cached probe result, probing the provider when it is stale
+func (p *ProviderProbe) Health(ctx context.Context) ProviderHealth {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.last != nil && p.now().Sub(p.last.CheckedAt) < p.ttl {
+		return *p.last
+	}
+
+	probeCtx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	health := ProviderHealth{Status: ProviderUp, CheckedAt: p.now()}
+	if err := p.check(probeCtx); err != nil {
+		health.Status = providerStatus(err)
+		health.Error = err.Error()
+	}
+
+	p.last = &health
+	return health
+}
+
+// providerStatus classifies a probe error: the provider is degraded when it answers
+// with an error status, down when it does not answer at all
+func providerStatus(err error) string {
+	var statusErr *ProviderStatusError
+	if errors.As(err, &statusErr) {
+		return ProviderDegraded
+	}
+	return ProviderDown
+}
@@ -0,0 +1,62 @@
+package price
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestProviderProbe_CachesResult(t *testing.T) {
+	calls := 0
+	var probeErr error
+	probe := NewProviderProbe(func(ctx context.Context) error {
+		calls++
+		return probeErr
+	}, time.Minute)
+
+	now := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	probe.now = func() time.Time { return now }
+
+	if health := probe.Health(context.Background()); health.Status != ProviderUp || health.Error != "" {
+		t.Fatalf("expected the provider to be up, got %+v", health)
+	}
+
+	// Within the TTL the provider is not probed again
+	probeErr = &ProviderStatusError{StatusCode: 429, Body: "Too Many Requests"}
+	now = now.Add(30 * time.Second)
+	if health := probe.Health(context.Background()); health.Status != ProviderUp || calls != 1 {
+		t.Fatalf("expected the cached result, got %+v after %d probes", health, calls)
+	}
+
+	now = now.Add(time.Minute)
+	health := probe.Health(context.Background())
+	if health.Status != ProviderDegraded || calls != 2 {
+		t.Fatalf("expected a rate limited provider to be degraded, got %+v after %d probes", health, calls)
+	}
+	if health.Error == "" || !health.CheckedAt.Equal(now) {
+		t.Errorf("expected the error and probe time to be reported, got %+v", health)
+	}
+}
+
+func TestProviderProbe_Timeout(t *testing.T) {
+	probe := NewProviderProbe(func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}, time.Minute)
+	probe.timeout = 10 * time.Millisecond
+
+	if health := probe.Health(context.Background()); health.Status != ProviderDown {
+		t.Errorf("expected an unresponsive provider to be down, got %+v", health)
+	}
+}
+
+func TestProviderStatus(t *testing.T) {
+	if status := providerStatus(errors.New("dial tcp: lookup query1.finance.yahoo.com: no such host")); status != ProviderDown {
+		t.Errorf("expected a network error to mean down, got %s", status)
+	}
+	wrapped := errors.Join(errors.New("search failed"), &ProviderStatusError{StatusCode: 503})
+	if status := providerStatus(wrapped); status != ProviderDegraded {
+		t.Errorf("expected an error status to mean degraded, got %s", status)
+	}
+}
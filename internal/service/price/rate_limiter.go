@@ -0,0 +1,64 @@
+package price
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultRequestsPerMinute is the default request rate towards a price provider
+const DefaultRequestsPerMinute = 600
+
+// RateLimiter spaces out requests to respect a provider's requests-per-minute quota
+type RateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+// NewRateLimiter creates a limiter allowing requestsPerMinute requests per minute
+// A value <= 0 disables limiting
+func NewRateLimiter(requestsPerMinute int) *RateLimiter {
+	limiter := &RateLimiter{}
+	limiter.SetRate(requestsPerMinute)
+	return limiter
+}
+
+// SetRate changes the allowed number of requests per minute
+func (l *RateLimiter) SetRate(requestsPerMinute int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if requestsPerMinute <= 0 {
+		l.interval = 0
+		return
+	}
+	l.interval = time.Minute / time.Duration(requestsPerMinute)
+}
+
+// Interval returns the minimum delay between two requests
+func (l *RateLimiter) Interval() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.interval
+}
+
+// Wait blocks until the next request is allowed
+func (l *RateLimiter) Wait() {
+	l.mu.Lock()
+	if l.interval == 0 {
+		l.mu.Unlock()
+		return
+	}
+
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	delay := l.next.Sub(now)
+	l.next = l.next.Add(l.interval)
+	l.mu.Unlock()
+
+	if delay > 0 {
+		time.Sleep(delay)
+	}
+}
@@ -0,0 +1,56 @@
+package price
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRateLimiter_Interval(t *testing.T) {
+	tests := []struct {
+		name     string
+		rpm      int
+		expected time.Duration
+	}{
+		{"default rate", DefaultRequestsPerMinute, 100 * time.Millisecond},
+		{"five per minute", 5, 12 * time.Second},
+		{"disabled", 0, 0},
+		{"negative disables", -1, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter := NewRateLimiter(tt.rpm)
+			if got := limiter.Interval(); got != tt.expected {
+				t.Errorf("Interval() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRateLimiter_WaitSpacesRequests(t *testing.T) {
+	limiter := NewRateLimiter(1200) // 50ms between requests
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		limiter.Wait()
+	}
+	elapsed := time.Since(start)
+
+	// First request is immediate, the next two wait one interval each
+	if elapsed < 100*time.Millisecond {
+		t.Errorf("Expected at least 100ms for 3 requests, got %v", elapsed)
+	}
+}
+
+func TestRateLimiter_DisabledDoesNotWait(t *testing.T) {
+	limiter := NewRateLimiter(0)
+
+	start := time.Now()
+	for i := 0; i < 100; i++ {
+		limiter.Wait()
+	}
+
+	if elapsed := time.Since(start); elapsed > 50*time.Millisecond {
+		t.Errorf("Disabled limiter should not wait, took %v", elapsed)
+	}
+}
@@ -0,0 +1,44 @@
+package price
+
+import (
+	"fmt"
+	"time"
+	"valhafin/internal/domain/models"
+	"valhafin/internal/repository/database"
+)
+
+// StaticService serves the prices already stored in the database and never calls a
+// provider, e.g. for offline use or tests
+type StaticService struct {
+	db *database.DB
+}
+
+// NewStaticService creates a price service reading stored prices only
+func NewStaticService(db *database.DB) *StaticService {
+	return &StaticService{db: db}
+}
+
+// GetCurrentPrice returns the last stored price of an asset
+func (s *StaticService) GetCurrentPrice(isin string) (*models.AssetPrice, error) {
+	price, err := s.db.GetLatestAssetPrice(isin)
+	if err != nil {
+		return nil, fmt.Errorf("no stored price for %s: %w", isin, err)
+	}
+	return price, nil
+}
+
+// GetPriceHistory returns the stored prices of an asset within a date range
+func (s *StaticService) GetPriceHistory(isin string, startDate, endDate time.Time) ([]models.AssetPrice, error) {
+	return s.db.GetAssetPriceHistory(isin, startDate, endDate)
+}
+
+// UpdateAllPrices does nothing: stored prices are only changed by imports
+func (s *StaticService) UpdateAllPrices() error {
+	return nil
+}
+
+// UpdateAssetPrice checks that a price is stored for the asset
+func (s *StaticService) UpdateAssetPrice(isin string) error {
+	_, err := s.GetCurrentPrice(isin)
+	return err
+}
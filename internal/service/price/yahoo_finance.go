@@ -1,16 +1,20 @@
 package price
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
+	"math"
 	"net/http"
 	"net/url"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 	"valhafin/internal/domain/models"
 	"valhafin/internal/repository/database"
+	"valhafin/internal/utils"
 )
 
 // PriceCache provides in-memory caching for asset prices
@@ -18,6 +22,8 @@ type PriceCache struct {
 	prices map[string]*CachedPrice
 	ttl    time.Duration
 	mu     sync.RWMutex
+	hits   atomic.Int64
+	misses atomic.Int64
 }
 
 // CachedPrice represents a cached price with expiration
@@ -26,6 +32,16 @@ type CachedPrice struct {
 	ExpiresAt time.Time
 }
 
+// CacheStats reports the content and effectiveness of the price cache
+type CacheStats struct {
+	Size       int     `json:"size"`    // Entries held, including expired ones not yet replaced
+	Expired    int     `json:"expired"` // Entries past their TTL
+	Hits       int64   `json:"hits"`
+	Misses     int64   `json:"misses"`
+	TTLSeconds int64   `json:"ttl_seconds"`
+	HitRate    float64 `json:"hit_rate"` // Hits over lookups, 0 without lookups
+}
+
 // Get retrieves a cached price if it exists and hasn't expired
 func (c *PriceCache) Get(isin string) *models.AssetPrice {
 	c.mu.RLock()
@@ -33,38 +49,95 @@ func (c *PriceCache) Get(isin string) *models.AssetPrice {
 
 	cached, exists := c.prices[isin]
 	if !exists {
+		c.misses.Add(1)
 		return nil
 	}
 
 	if time.Now().After(cached.ExpiresAt) {
+		c.misses.Add(1)
 		return nil
 	}
 
+	c.hits.Add(1)
 	return cached.Price
 }
 
-// Set stores a price in the cache
+// Set stores a price in the cache for the default TTL
 func (c *PriceCache) Set(isin string, price *models.AssetPrice) {
+	c.SetWithTTL(isin, price, c.ttl)
+}
+
+// SetWithTTL stores a price in the cache for the given duration
+func (c *PriceCache) SetWithTTL(isin string, price *models.AssetPrice, ttl time.Duration) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
 	c.prices[isin] = &CachedPrice{
 		Price:     price,
-		ExpiresAt: time.Now().Add(c.ttl),
+		ExpiresAt: time.Now().Add(ttl),
 	}
 }
 
+// Invalidate removes the entry of an ISIN, or every entry when isin is empty.
+// It returns the number of entries removed.
+func (c *PriceCache) Invalidate(isin string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if isin == "" {
+		removed := len(c.prices)
+		c.prices = make(map[string]*CachedPrice)
+		return removed
+	}
+
+	if _, exists := c.prices[isin]; !exists {
+		return 0
+	}
+	delete(c.prices, isin)
+	return 1
+}
+
+// Stats returns the cache size and hit/miss counters
+func (c *PriceCache) Stats() CacheStats {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	now := time.Now()
+	stats := CacheStats{
+		Size:       len(c.prices),
+		Hits:       c.hits.Load(),
+		Misses:     c.misses.Load(),
+		TTLSeconds: int64(c.ttl / time.Second),
+	}
+	for _, cached := range c.prices {
+		if now.After(cached.ExpiresAt) {
+			stats.Expired++
+		}
+	}
+	if lookups := stats.Hits + stats.Misses; lookups > 0 {
+		stats.HitRate = float64(stats.Hits) / float64(lookups)
+	}
+	return stats
+}
+
 // YahooFinanceService implements the Service interface using Yahoo Finance API
 type YahooFinanceService struct {
 	db                *database.DB
 	httpClient        *http.Client
 	cache             *PriceCache
 	currencyConverter *CurrencyConverter
+	rateLimiter       *RateLimiter
+	probe             *ProviderProbe
+	// nameBackfill names the assets still unknown when their symbol is resolved
+	nameBackfill bool
+	// storedFallback returns the last stored price when Yahoo Finance has none; disabled
+	// behind a composite, which asks the next providers before the stored prices
+	storedFallback bool
 }
 
 // NewYahooFinanceService creates a new Yahoo Finance price service
 func NewYahooFinanceService(db *database.DB) *YahooFinanceService {
-	return &YahooFinanceService{
+	service := &YahooFinanceService{
 		db: db,
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second,
@@ -74,30 +147,85 @@ func NewYahooFinanceService(db *database.DB) *YahooFinanceService {
 			ttl:    1 * time.Hour,
 		},
 		currencyConverter: NewCurrencyConverter(),
+		rateLimiter:       NewRateLimiter(DefaultRequestsPerMinute),
+		nameBackfill:      true,
+		storedFallback:    true,
 	}
+	service.probe = NewProviderProbe(service.probeProvider, ProviderProbeTTL)
+	return service
+}
+
+// SetCurrencyConverter sets the converter of the prices quoted in another currency than the asset
+func (s *YahooFinanceService) SetCurrencyConverter(converter *CurrencyConverter) {
+	s.currencyConverter = converter
+}
+
+// SetStoredPriceFallback enables or disables the fallback of GetCurrentPrice on the last stored
+// price. When disabled, the provider error is returned as is.
+func (s *YahooFinanceService) SetStoredPriceFallback(enabled bool) {
+	s.storedFallback = enabled
+}
+
+// SetNameBackfill enables or disables the naming of unknown assets during symbol resolution
+func (s *YahooFinanceService) SetNameBackfill(enabled bool) {
+	s.nameBackfill = enabled
+}
+
+// NameBackfill reports whether unknown assets are named during symbol resolution
+func (s *YahooFinanceService) NameBackfill() bool {
+	return s.nameBackfill
+}
+
+// ProviderHealth reports whether Yahoo Finance answers, probing it at most once per ProviderProbeTTL
+func (s *YahooFinanceService) ProviderHealth(ctx context.Context) ProviderHealth {
+	return s.probe.Health(ctx)
+}
+
+// probeProvider sends a cheap search request. It bypasses the rate limiter so that a
+// busy limiter does not make the provider look down.
+func (s *YahooFinanceService) probeProvider(ctx context.Context) error {
+	_, err := s.searchSymbol(ctx, "AAPL")
+	return err
+}
+
+// SetRateLimit sets the maximum number of Yahoo Finance requests per minute (<= 0 disables limiting)
+// The limit applies to price, history, search and validation requests
+func (s *YahooFinanceService) SetRateLimit(requestsPerMinute int) {
+	s.rateLimiter.SetRate(requestsPerMinute)
+}
+
+// InvalidateCache drops the cached price of an ISIN, or the whole cache when isin is empty.
+// It returns the number of entries removed.
+func (s *YahooFinanceService) InvalidateCache(isin string) int {
+	return s.cache.Invalidate(isin)
+}
+
+// CacheStats returns the price cache statistics
+func (s *YahooFinanceService) CacheStats() CacheStats {
+	return s.cache.Stats()
 }
 
 // GetCurrentPrice retrieves the current price for an asset by ISIN
 func (s *YahooFinanceService) GetCurrentPrice(isin string) (*models.AssetPrice, error) {
-	log.Printf("DEBUG: GetCurrentPrice for ISIN %s", isin)
+	// The Service interface carries no request context
+	ctx := context.Background()
+	utils.Logf(ctx, "DEBUG: GetCurrentPrice for ISIN %s", isin)
 
 	// Check cache first
 	if cachedPrice := s.cache.Get(isin); cachedPrice != nil {
-		log.Printf("DEBUG: Returning cached price for %s", isin)
+		utils.Logf(ctx, "DEBUG: Returning cached price for %s", isin)
 		return cachedPrice, nil
 	}
 
 	// Get asset from database to retrieve symbol
 	asset, err := s.db.GetAssetByISIN(isin)
 	if err != nil {
-		log.Printf("DEBUG: Asset not found in DB for %s", isin)
+		utils.Logf(ctx, "DEBUG: Asset not found in DB for %s", isin)
 		// Fallback: try to get last known price from database
-		lastPrice, dbErr := s.db.GetLatestAssetPrice(isin)
-		if dbErr == nil {
-			s.cache.Set(isin, lastPrice)
+		if lastPrice := s.lastStoredPrice(isin); lastPrice != nil {
 			return lastPrice, nil
 		}
-		return nil, fmt.Errorf("asset not found and no fallback available: %w", err)
+		return nil, fmt.Errorf("asset not found: %w", err)
 	}
 
 	// Get symbol from asset
@@ -110,29 +238,53 @@ func (s *YahooFinanceService) GetCurrentPrice(isin string) (*models.AssetPrice,
 		return nil, fmt.Errorf("no symbol found for asset %s", isin)
 	}
 
-	log.Printf("DEBUG: Asset found for %s, symbol: %s, currency: %s", isin, symbol, asset.Currency)
+	utils.Logf(ctx, "DEBUG: Asset found for %s, symbol: %s, currency: %s", isin, symbol, asset.Currency)
 
 	// Fetch price from Yahoo Finance
-	price, err := s.fetchAndStorePrice(isin, symbol, asset.Currency)
+	price, err := s.fetchAndStorePrice(ctx, isin, symbol, asset.Currency)
 	if err != nil {
-		log.Printf("DEBUG: Failed to fetch price for %s: %v", isin, err)
+		utils.Logf(ctx, "DEBUG: Failed to fetch price for %s: %v", isin, err)
 		// Fallback: try to get last known price from database
-		lastPrice, dbErr := s.db.GetLatestAssetPrice(isin)
-		if dbErr == nil {
-			s.cache.Set(isin, lastPrice)
+		if lastPrice := s.lastStoredPrice(isin); lastPrice != nil {
 			return lastPrice, nil
 		}
-		return nil, fmt.Errorf("failed to fetch price and no fallback available: %w", err)
+		return nil, fmt.Errorf("failed to fetch price: %w", err)
 	}
 
-	// Cache the new price
-	s.cache.Set(isin, price)
+	// Cache the new price: briefly while its market trades, until the next session otherwise
+	s.cache.SetWithTTL(isin, price, priceTTL(assetExchange(asset), time.Now()))
 
 	return price, nil
 }
 
+// lastStoredPrice returns the last stored price of an asset when the stored price fallback is
+// enabled, or nil
+func (s *YahooFinanceService) lastStoredPrice(isin string) *models.AssetPrice {
+	if !s.storedFallback {
+		return nil
+	}
+	lastPrice, err := s.db.GetLatestAssetPrice(isin)
+	if err != nil {
+		return nil
+	}
+	s.cache.Set(isin, lastPrice)
+	return lastPrice
+}
+
 // GetPriceHistory retrieves historical prices for an asset within a date range
 func (s *YahooFinanceService) GetPriceHistory(isin string, startDate, endDate time.Time) ([]models.AssetPrice, error) {
+	return s.GetPriceHistoryWithInterval(context.Background(), isin, startDate, endDate, HistoryIntervalAuto)
+}
+
+// GetPriceHistoryWithInterval retrieves historical prices for an asset within a date range.
+// With HistoryIntervalDaily, ranges longer than a single chart request allows are fetched
+// page by page so that daily granularity is kept; stored prices are reused when they
+// already cover the range day by day. Provider requests are canceled with ctx.
+func (s *YahooFinanceService) GetPriceHistoryWithInterval(ctx context.Context, isin string, startDate, endDate time.Time, interval string) ([]models.AssetPrice, error) {
+	if interval == HistoryIntervalDaily {
+		return s.getDailyPriceHistory(ctx, isin, startDate, endDate)
+	}
+
 	// First, try to get from database
 	prices, err := s.db.GetAssetPriceHistory(isin, startDate, endDate)
 	if err == nil && len(prices) > 0 {
@@ -154,7 +306,154 @@ func (s *YahooFinanceService) GetPriceHistory(isin string, startDate, endDate ti
 		return nil, fmt.Errorf("no symbol found for asset %s", isin)
 	}
 
-	// Determine range based on date difference
+	rangeStr, interval := historyRange(startDate, endDate)
+
+	historicalPrices, err := s.fetchHistoricalPrices(ctx, symbol, isin, asset.Currency, rangeStr, interval)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch historical prices: %w", err)
+	}
+
+	// Filter by date range
+	var filteredPrices []models.AssetPrice
+	for _, price := range historicalPrices {
+		if (price.Timestamp.Equal(startDate) || price.Timestamp.After(startDate)) &&
+			(price.Timestamp.Equal(endDate) || price.Timestamp.Before(endDate)) {
+			filteredPrices = append(filteredPrices, price)
+		}
+	}
+
+	// Store in database
+	if len(filteredPrices) > 0 {
+		if err := s.db.CreateAssetPricesBatch(filteredPrices); err != nil {
+			utils.Logf(ctx, "Warning: failed to store historical prices: %v", err)
+		}
+	}
+
+	return filteredPrices, nil
+}
+
+// getDailyPriceHistory returns the daily prices of an asset, fetching the range in pages
+// of BackfillChunkDays days when the database does not already cover it
+func (s *YahooFinanceService) getDailyPriceHistory(ctx context.Context, isin string, startDate, endDate time.Time) ([]models.AssetPrice, error) {
+	prices, err := s.db.GetAssetPriceHistory(isin, startDate, endDate)
+	if err == nil && hasDailyCoverage(prices, startDate, endDate) {
+		return prices, nil
+	}
+
+	asset, err := s.db.GetAssetByISIN(isin)
+	if err != nil {
+		return nil, fmt.Errorf("asset not found: %w", err)
+	}
+
+	if asset.Symbol == nil || *asset.Symbol == "" {
+		return nil, fmt.Errorf("no symbol found for asset %s", isin)
+	}
+	symbol := *asset.Symbol
+
+	var dailyPrices []models.AssetPrice
+	for _, chunk := range backfillChunks(startDate, endDate, BackfillChunkDays) {
+		historicalPrices, err := s.fetchHistoricalPricesBetween(ctx, symbol, isin, asset.Currency, chunk.Start, chunk.End)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch historical prices from %s to %s: %w",
+				chunk.Start.Format("2006-01-02"), chunk.End.Format("2006-01-02"), err)
+		}
+
+		for _, price := range historicalPrices {
+			if !price.Timestamp.Before(chunk.Start) && price.Timestamp.Before(chunk.End) &&
+				!price.Timestamp.Before(startDate) && !price.Timestamp.After(endDate) {
+				dailyPrices = append(dailyPrices, price)
+			}
+		}
+	}
+
+	if len(dailyPrices) > 0 {
+		if err := s.db.CreateAssetPricesBatch(dailyPrices); err != nil {
+			utils.Logf(ctx, "Warning: failed to store historical prices: %v", err)
+		}
+	}
+
+	return dailyPrices, nil
+}
+
+// BackfillPriceHistory fetches and stores daily prices for an asset across a date range.
+// The range is split into chunks so that each request stays within the provider limits.
+// It returns the number of price points stored and the number of chunks requested.
+func (s *YahooFinanceService) BackfillPriceHistory(ctx context.Context, isin string, startDate, endDate time.Time) (int, int, error) {
+	asset, err := s.db.GetAssetByISIN(isin)
+	if err != nil {
+		return 0, 0, fmt.Errorf("asset not found: %w", err)
+	}
+
+	if asset.Symbol == nil || *asset.Symbol == "" {
+		return 0, 0, fmt.Errorf("no symbol found for asset %s", isin)
+	}
+	symbol := *asset.Symbol
+
+	chunks := backfillChunks(startDate, endDate, BackfillChunkDays)
+	stored := 0
+
+	for _, chunk := range chunks {
+		historicalPrices, err := s.fetchHistoricalPricesBetween(ctx, symbol, isin, asset.Currency, chunk.Start, chunk.End)
+		if err != nil {
+			return stored, len(chunks), fmt.Errorf("failed to fetch prices from %s to %s: %w",
+				chunk.Start.Format("2006-01-02"), chunk.End.Format("2006-01-02"), err)
+		}
+
+		var chunkPrices []models.AssetPrice
+		for _, price := range historicalPrices {
+			if !price.Timestamp.Before(chunk.Start) && price.Timestamp.Before(chunk.End) {
+				chunkPrices = append(chunkPrices, price)
+			}
+		}
+
+		if len(chunkPrices) == 0 {
+			continue
+		}
+
+		if err := s.db.CreateAssetPricesBatch(chunkPrices); err != nil {
+			return stored, len(chunks), fmt.Errorf("failed to store prices: %w", err)
+		}
+		stored += len(chunkPrices)
+	}
+
+	return stored, len(chunks), nil
+}
+
+// BackfillChunkDays is the maximum number of days requested from Yahoo Finance at once
+// when backfilling daily prices
+const BackfillChunkDays = 365
+
+// dateWindow is a half-open time window [Start, End)
+type dateWindow struct {
+	Start time.Time
+	End   time.Time
+}
+
+// backfillChunks splits the days from startDate to endDate (inclusive) into
+// consecutive half-open windows of at most chunkDays days
+func backfillChunks(startDate, endDate time.Time, chunkDays int) []dateWindow {
+	if chunkDays <= 0 {
+		chunkDays = BackfillChunkDays
+	}
+
+	start := time.Date(startDate.Year(), startDate.Month(), startDate.Day(), 0, 0, 0, 0, startDate.Location())
+	end := time.Date(endDate.Year(), endDate.Month(), endDate.Day(), 0, 0, 0, 0, endDate.Location()).AddDate(0, 0, 1)
+
+	var chunks []dateWindow
+	for cursor := start; cursor.Before(end); {
+		next := cursor.AddDate(0, 0, chunkDays)
+		if next.After(end) {
+			next = end
+		}
+		chunks = append(chunks, dateWindow{Start: cursor, End: next})
+		cursor = next
+	}
+
+	return chunks
+}
+
+// historyRange picks the Yahoo Finance range and interval covering a date range
+func historyRange(startDate, endDate time.Time) (string, string) {
 	daysDiff := endDate.Sub(startDate).Hours() / 24
 	var rangeStr string
 	var interval string
@@ -185,48 +484,64 @@ func (s *YahooFinanceService) GetPriceHistory(isin string, startDate, endDate ti
 		interval = "1wk" // Weekly for max
 	}
 
-	historicalPrices, err := s.fetchHistoricalPrices(symbol, isin, asset.Currency, rangeStr, interval)
+	return rangeStr, interval
+}
+
+// GetSymbolPriceHistory fetches the price history of a Yahoo Finance symbol that is not
+// necessarily a tracked asset (e.g. an index such as ^GSPC). Prices are converted to EUR
+// and are not stored.
+func (s *YahooFinanceService) GetSymbolPriceHistory(ctx context.Context, symbol string, startDate, endDate time.Time) ([]models.AssetPrice, error) {
+	rangeStr, interval := historyRange(startDate, endDate)
+
+	historicalPrices, err := s.fetchHistoricalPrices(ctx, url.PathEscape(symbol), "", "EUR", rangeStr, interval)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch historical prices: %w", err)
 	}
 
-	// Filter by date range
 	var filteredPrices []models.AssetPrice
 	for _, price := range historicalPrices {
-		if (price.Timestamp.Equal(startDate) || price.Timestamp.After(startDate)) &&
-			(price.Timestamp.Equal(endDate) || price.Timestamp.Before(endDate)) {
+		if !price.Timestamp.Before(startDate) && !price.Timestamp.After(endDate) {
 			filteredPrices = append(filteredPrices, price)
 		}
 	}
 
-	// Store in database
-	if len(filteredPrices) > 0 {
-		if err := s.db.CreateAssetPricesBatch(filteredPrices); err != nil {
-			log.Printf("Warning: failed to store historical prices: %v", err)
-		}
-	}
-
 	return filteredPrices, nil
 }
 
-// UpdateAllPrices updates prices for all assets in the database
+// UpdateAllPrices updates prices for all assets in the database.
+// Current prices are fetched in batches from the quote endpoint; assets missing
+// from the batch response fall back to the per-symbol chart endpoint.
 func (s *YahooFinanceService) UpdateAllPrices() error {
+	// The Service interface carries no request context
+	ctx := context.Background()
+
 	assets, err := s.db.GetAllAssets()
 	if err != nil {
 		return fmt.Errorf("failed to get assets: %w", err)
 	}
 
+	// Only assets with a symbol and no fresh cached price need a quote
+	var symbols []string
+	for _, asset := range assets {
+		if asset.Symbol != nil && *asset.Symbol != "" && s.cache.Get(asset.ISIN) == nil {
+			symbols = append(symbols, *asset.Symbol)
+		}
+	}
+
+	quotes, err := s.fetchBatchPrices(ctx, symbols)
+	if err != nil {
+		utils.Logf(ctx, "WARNING: batch quote request failed, falling back to per-symbol requests: %v", err)
+	}
+
 	var errors []error
 	successCount := 0
 
 	for _, asset := range assets {
-		if err := s.UpdateAssetPrice(asset.ISIN); err != nil {
+		if err := s.updateAssetPriceFromQuotes(ctx, asset, quotes); err != nil {
 			errors = append(errors, fmt.Errorf("failed to update %s: %w", asset.ISIN, err))
 		} else {
 			successCount++
 		}
-		// Small delay to be respectful to Yahoo Finance
-		time.Sleep(100 * time.Millisecond)
 	}
 
 	if len(errors) > 0 && successCount == 0 {
@@ -236,6 +551,27 @@ func (s *YahooFinanceService) UpdateAllPrices() error {
 	return nil
 }
 
+// updateAssetPriceFromQuotes stores the batch quote for an asset when one was returned,
+// otherwise it falls back to UpdateAssetPrice
+func (s *YahooFinanceService) updateAssetPriceFromQuotes(ctx context.Context, asset models.Asset, quotes map[string]batchQuote) error {
+	if asset.Symbol == nil {
+		return s.UpdateAssetPrice(asset.ISIN)
+	}
+
+	quote, ok := quotes[*asset.Symbol]
+	if !ok {
+		return s.UpdateAssetPrice(asset.ISIN)
+	}
+
+	price, err := s.storePrice(ctx, asset.ISIN, quote.Price, quoteCurrency(quote.Currency, *asset.Symbol, asset.Currency), asset.Currency)
+	if err != nil {
+		return err
+	}
+
+	s.cache.SetWithTTL(asset.ISIN, price, priceTTL(assetExchange(&asset), time.Now()))
+	return nil
+}
+
 // UpdateAssetPrice updates the price for a specific asset
 func (s *YahooFinanceService) UpdateAssetPrice(isin string) error {
 	_, err := s.GetCurrentPrice(isin)
@@ -243,20 +579,25 @@ func (s *YahooFinanceService) UpdateAssetPrice(isin string) error {
 }
 
 // fetchAndStorePrice fetches the current price from Yahoo Finance and stores it
-func (s *YahooFinanceService) fetchAndStorePrice(isin, symbol, expectedCurrency string) (*models.AssetPrice, error) {
+func (s *YahooFinanceService) fetchAndStorePrice(ctx context.Context, isin, symbol, expectedCurrency string) (*models.AssetPrice, error) {
 	// Fetch from Yahoo Finance
 	price, currency, err := s.fetchPriceFromYahoo(symbol)
 	if err != nil {
 		return nil, err
 	}
 
+	return s.storePrice(ctx, isin, price, quoteCurrency(currency, symbol, expectedCurrency), expectedCurrency)
+}
+
+// storePrice converts a fetched price to the asset currency and stores it
+func (s *YahooFinanceService) storePrice(ctx context.Context, isin string, price float64, currency, expectedCurrency string) (*models.AssetPrice, error) {
 	// Convert currency if needed
 	if currency != expectedCurrency {
 		convertedPrice, err := s.currencyConverter.Convert(price, currency, expectedCurrency)
 		if err != nil {
-			log.Printf("Warning: failed to convert %s to %s for ISIN %s: %v", currency, expectedCurrency, isin, err)
+			utils.Logf(ctx, "Warning: failed to convert %s to %s for ISIN %s: %v", currency, expectedCurrency, isin, err)
 		} else {
-			log.Printf("Converted price for %s: %.2f %s -> %.2f %s", isin, price, currency, convertedPrice, expectedCurrency)
+			utils.Logf(ctx, "Converted price for %s: %.2f %s -> %.2f %s", isin, price, currency, convertedPrice, expectedCurrency)
 			price = convertedPrice
 			currency = expectedCurrency
 		}
@@ -290,6 +631,7 @@ func (s *YahooFinanceService) fetchPriceFromYahoo(symbol string) (float64, strin
 	// Add User-Agent to avoid rate limiting
 	req.Header.Set("User-Agent", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36")
 
+	s.rateLimiter.Wait()
 	resp, err := s.httpClient.Do(req)
 	if err != nil {
 		return 0, "", fmt.Errorf("failed to fetch from Yahoo Finance: %w", err)
@@ -329,17 +671,138 @@ func (s *YahooFinanceService) fetchPriceFromYahoo(symbol string) (float64, strin
 	return price, currency, nil
 }
 
+// BatchQuoteSize is the maximum number of symbols requested in a single quote call
+const BatchQuoteSize = 50
+
+// batchQuote is the current price of a symbol returned by the quote endpoint
+type batchQuote struct {
+	Price    float64
+	Currency string
+}
+
+// fetchBatchPrices fetches current prices for many symbols using the v7 quote endpoint.
+// Symbols are requested in chunks of BatchQuoteSize. Failed chunks are skipped so that
+// their symbols can fall back to per-symbol requests; an error is returned only when
+// every chunk failed.
+func (s *YahooFinanceService) fetchBatchPrices(ctx context.Context, symbols []string) (map[string]batchQuote, error) {
+	quotes := make(map[string]batchQuote)
+	chunks := chunkSymbols(symbols, BatchQuoteSize)
+
+	var lastErr error
+	failed := 0
+	for _, chunk := range chunks {
+		chunkQuotes, err := s.fetchQuoteChunk(chunk)
+		if err != nil {
+			utils.Logf(ctx, "WARNING: batch quote request for %d symbols failed: %v", len(chunk), err)
+			lastErr = err
+			failed++
+			continue
+		}
+		for symbol, quote := range chunkQuotes {
+			quotes[symbol] = quote
+		}
+	}
+
+	if failed > 0 && failed == len(chunks) {
+		return quotes, fmt.Errorf("all %d batch quote requests failed: %w", failed, lastErr)
+	}
+
+	return quotes, nil
+}
+
+// fetchQuoteChunk requests the quotes of a single chunk of symbols
+func (s *YahooFinanceService) fetchQuoteChunk(symbols []string) (map[string]batchQuote, error) {
+	apiURL := fmt.Sprintf("https://query1.finance.yahoo.com/v7/finance/quote?symbols=%s",
+		url.QueryEscape(strings.Join(symbols, ",")))
+
+	req, err := http.NewRequest("GET", apiURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	// Add User-Agent to avoid rate limiting
+	req.Header.Set("User-Agent", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36")
+
+	s.rateLimiter.Wait()
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch from Yahoo Finance: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Yahoo Finance returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	return parseQuoteResponse(body)
+}
+
+// parseQuoteResponse extracts the prices of a v7 quote response, keyed by symbol.
+// Quotes without a price are left out so that they fall back to the chart endpoint.
+func parseQuoteResponse(body []byte) (map[string]batchQuote, error) {
+	var result YahooQuoteResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if result.QuoteResponse.Error != nil {
+		return nil, fmt.Errorf("Yahoo Finance error: %s", result.QuoteResponse.Error.Description)
+	}
+
+	quotes := make(map[string]batchQuote, len(result.QuoteResponse.Result))
+	for _, quote := range result.QuoteResponse.Result {
+		if quote.Symbol == "" || quote.RegularMarketPrice <= 0 {
+			continue
+		}
+		quotes[quote.Symbol] = batchQuote{
+			Price:    quote.RegularMarketPrice,
+			Currency: quote.Currency,
+		}
+	}
+
+	return quotes, nil
+}
+
+// chunkSymbols splits symbols into consecutive chunks of at most size symbols
+func chunkSymbols(symbols []string, size int) [][]string {
+	var chunks [][]string
+	for start := 0; start < len(symbols); start += size {
+		end := start + size
+		if end > len(symbols) {
+			end = len(symbols)
+		}
+		chunks = append(chunks, symbols[start:end])
+	}
+	return chunks
+}
+
 // FetchHistoricalPrices fetches historical prices from Yahoo Finance with specific range and interval
 // This is a public wrapper for fetchHistoricalPrices to allow direct access from handlers
-func (s *YahooFinanceService) FetchHistoricalPrices(symbol, isin, expectedCurrency, rangeStr, interval string) ([]models.AssetPrice, error) {
-	return s.fetchHistoricalPrices(symbol, isin, expectedCurrency, rangeStr, interval)
+func (s *YahooFinanceService) FetchHistoricalPrices(ctx context.Context, symbol, isin, expectedCurrency, rangeStr, interval string) ([]models.AssetPrice, error) {
+	return s.fetchHistoricalPrices(ctx, symbol, isin, expectedCurrency, rangeStr, interval)
 }
 
 // fetchHistoricalPrices fetches historical prices from Yahoo Finance
-func (s *YahooFinanceService) fetchHistoricalPrices(symbol, isin, expectedCurrency, rangeStr, interval string) ([]models.AssetPrice, error) {
+func (s *YahooFinanceService) fetchHistoricalPrices(ctx context.Context, symbol, isin, expectedCurrency, rangeStr, interval string) ([]models.AssetPrice, error) {
 	url := fmt.Sprintf("https://query1.finance.yahoo.com/v8/finance/chart/%s?range=%s&interval=%s", symbol, rangeStr, interval)
+	return s.fetchChart(ctx, url, isin, expectedCurrency)
+}
 
-	req, err := http.NewRequest("GET", url, nil)
+// fetchHistoricalPricesBetween fetches daily historical prices for an absolute date window
+func (s *YahooFinanceService) fetchHistoricalPricesBetween(ctx context.Context, symbol, isin, expectedCurrency string, startDate, endDate time.Time) ([]models.AssetPrice, error) {
+	url := fmt.Sprintf("https://query1.finance.yahoo.com/v8/finance/chart/%s?period1=%d&period2=%d&interval=1d",
+		symbol, startDate.Unix(), endDate.Unix())
+	return s.fetchChart(ctx, url, isin, expectedCurrency)
+}
+
+// fetchChart calls the Yahoo Finance chart API, canceled with ctx, and parses the returned prices
+func (s *YahooFinanceService) fetchChart(ctx context.Context, url, isin, expectedCurrency string) ([]models.AssetPrice, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -347,6 +810,7 @@ func (s *YahooFinanceService) fetchHistoricalPrices(symbol, isin, expectedCurren
 	// Add User-Agent to avoid rate limiting
 	req.Header.Set("User-Agent", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36")
 
+	s.rateLimiter.Wait()
 	resp, err := s.httpClient.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch from Yahoo Finance: %w", err)
@@ -370,23 +834,27 @@ func (s *YahooFinanceService) fetchHistoricalPrices(symbol, isin, expectedCurren
 		return nil, fmt.Errorf("no data available")
 	}
 
-	return s.parseChartData(result.Chart.Result[0], isin, expectedCurrency)
+	return s.parseChartData(ctx, result.Chart.Result[0], isin, expectedCurrency)
 }
 
-// parseChartData parses Yahoo Finance chart data and converts currency
-func (s *YahooFinanceService) parseChartData(chartResult YahooChartResult, isin, expectedCurrency string) ([]models.AssetPrice, error) {
+// parseChartData parses Yahoo Finance chart data and converts currency.
+// Daily and weekly bars are stored at their trading day (see TradingDay).
+func (s *YahooFinanceService) parseChartData(ctx context.Context, chartResult YahooChartResult, isin, expectedCurrency string) ([]models.AssetPrice, error) {
 	var prices []models.AssetPrice
 
-	sourceCurrency := chartResult.Meta.Currency
+	sourceCurrency := quoteCurrency(chartResult.Meta.Currency, chartResult.Meta.Symbol, expectedCurrency)
 
-	// Get exchange rate once for all prices
+	// Get the latest exchange rate once; it is used for the days without a historical rate
 	exchangeRate := 1.0
+	convert := false
 	var err error
 	if sourceCurrency != expectedCurrency {
 		exchangeRate, err = s.currencyConverter.GetExchangeRate(sourceCurrency, expectedCurrency)
 		if err != nil {
-			log.Printf("Warning: failed to get exchange rate %s to %s: %v", sourceCurrency, expectedCurrency, err)
+			utils.Logf(ctx, "Warning: failed to get exchange rate %s to %s: %v", sourceCurrency, expectedCurrency, err)
 			exchangeRate = 1.0
+		} else {
+			convert = true
 		}
 	}
 
@@ -398,6 +866,9 @@ func (s *YahooFinanceService) parseChartData(chartResult YahooChartResult, isin,
 
 	closePrices := chartResult.Indicators.Quote[0].Close
 
+	// Sparse charts may report a zero close for a day without trading: storing it would
+	// value the asset at nothing, so such closes are skipped
+	skipped := 0
 	for i, timestamp := range timestamps {
 		if i >= len(closePrices) {
 			break
@@ -408,18 +879,34 @@ func (s *YahooFinanceService) parseChartData(chartResult YahooChartResult, isin,
 			continue
 		}
 
-		// Convert currency
-		finalPrice := *closePrice * exchangeRate
+		day := TradingDay(int64(timestamp), chartResult.Meta)
+
+		// Convert currency at the rate of the day when the provider has historical rates
+		rate := exchangeRate
+		if convert {
+			if dayRate, err := s.currencyConverter.GetExchangeRateAt(sourceCurrency, expectedCurrency, day); err == nil {
+				rate = dayRate
+			}
+		}
+		finalPrice := *closePrice * rate
 		finalCurrency := expectedCurrency
+		if !(finalPrice > 0) || math.IsInf(finalPrice, 0) {
+			skipped++
+			continue
+		}
 
 		prices = append(prices, models.AssetPrice{
 			ISIN:      isin,
 			Price:     finalPrice,
 			Currency:  finalCurrency,
-			Timestamp: time.Unix(int64(timestamp), 0),
+			Timestamp: day,
 		})
 	}
 
+	if skipped > 0 {
+		utils.Logf(ctx, "Warning: skipped %d non-positive closes for %s (%s)", skipped, isin, chartResult.Meta.Symbol)
+	}
+
 	return prices, nil
 }
 
@@ -449,6 +936,9 @@ type YahooMeta struct {
 	RegularMarketPrice float64 `json:"regularMarketPrice"`
 	ChartPreviousClose float64 `json:"chartPreviousClose"`
 	PreviousClose      float64 `json:"previousClose"`
+	// ExchangeTimezoneName (e.g. "Europe/Paris") and GmtOffset (seconds) locate the exchange
+	ExchangeTimezoneName string `json:"exchangeTimezoneName"`
+	GmtOffset            int    `json:"gmtoffset"`
 }
 
 type YahooIndicators struct {
@@ -463,6 +953,19 @@ type YahooQuote struct {
 	Volume []*int64   `json:"volume"`
 }
 
+type YahooQuoteResponse struct {
+	QuoteResponse struct {
+		Result []YahooQuoteResult `json:"result"`
+		Error  *YahooError        `json:"error"`
+	} `json:"quoteResponse"`
+}
+
+type YahooQuoteResult struct {
+	Symbol             string  `json:"symbol"`
+	Currency           string  `json:"currency"`
+	RegularMarketPrice float64 `json:"regularMarketPrice"`
+}
+
 // YahooSearchResult represents a search result from Yahoo Finance
 type YahooSearchResult struct {
 	Symbol    string  `json:"symbol"`
@@ -479,11 +982,17 @@ type YahooSearchResult struct {
 
 // SearchSymbol searches for symbols on Yahoo Finance
 func (s *YahooFinanceService) SearchSymbol(query string) ([]YahooSearchResult, error) {
+	s.rateLimiter.Wait()
+	return s.searchSymbol(context.Background(), query)
+}
+
+// searchSymbol sends a search request, canceled with ctx, without waiting for the rate limiter
+func (s *YahooFinanceService) searchSymbol(ctx context.Context, query string) ([]YahooSearchResult, error) {
 	// URL encode the query
 	encodedQuery := url.QueryEscape(query)
 	apiURL := fmt.Sprintf("https://query1.finance.yahoo.com/v1/finance/search?q=%s&quotesCount=15&newsCount=0", encodedQuery)
 
-	req, err := http.NewRequest("GET", apiURL, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -505,7 +1014,7 @@ func (s *YahooFinanceService) SearchSymbol(query string) ([]YahooSearchResult, e
 
 	// Check for non-200 status codes
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("Yahoo Finance API returned status %d: %s", resp.StatusCode, string(body))
+		return nil, &ProviderStatusError{StatusCode: resp.StatusCode, Body: string(body)}
 	}
 
 	// Parse the response
@@ -522,7 +1031,7 @@ func (s *YahooFinanceService) SearchSymbol(query string) ([]YahooSearchResult, e
 
 // ResolveSymbolWithExchange resolves a symbol to its full Yahoo Finance symbol with exchange suffix
 // Uses Trade Republic exchange information to select the best match
-func (s *YahooFinanceService) ResolveSymbolWithExchange(symbol string, trExchanges []string, assetName string) (string, bool, error) {
+func (s *YahooFinanceService) ResolveSymbolWithExchange(ctx context.Context, symbol string, trExchanges []string, assetName string) (string, bool, error) {
 	// Search for the symbol on Yahoo Finance
 	results, err := s.SearchSymbol(symbol)
 	if err != nil {
@@ -581,7 +1090,7 @@ func (s *YahooFinanceService) ResolveSymbolWithExchange(symbol string, trExchang
 					if result.Exchange == yahooExch {
 						// Validate that the symbol works
 						if s.validateSymbol(result.Symbol) {
-							log.Printf("INFO: Resolved %s to %s (matched EUR exchange %s)", symbol, result.Symbol, yahooExch)
+							utils.Logf(ctx, "INFO: Resolved %s to %s (matched EUR exchange %s)", symbol, result.Symbol, yahooExch)
 							return result.Symbol, true, nil
 						}
 					}
@@ -597,7 +1106,7 @@ func (s *YahooFinanceService) ResolveSymbolWithExchange(symbol string, trExchang
 					if result.Exchange == yahooExch {
 						// Validate that the symbol works
 						if s.validateSymbol(result.Symbol) {
-							log.Printf("INFO: Resolved %s to %s (matched exchange %s)", symbol, result.Symbol, yahooExch)
+							utils.Logf(ctx, "INFO: Resolved %s to %s (matched exchange %s)", symbol, result.Symbol, yahooExch)
 							return result.Symbol, true, nil
 						}
 					}
@@ -621,7 +1130,7 @@ func (s *YahooFinanceService) ResolveSymbolWithExchange(symbol string, trExchang
 	if bestResult != nil {
 		// Validate that the symbol works
 		if s.validateSymbol(bestResult.Symbol) {
-			log.Printf("INFO: Resolved %s to %s (priority-based)", symbol, bestResult.Symbol)
+			utils.Logf(ctx, "INFO: Resolved %s to %s (priority-based)", symbol, bestResult.Symbol)
 			return bestResult.Symbol, true, nil
 		}
 	}
@@ -638,20 +1147,78 @@ func (s *YahooFinanceService) ResolveSymbolWithExchange(symbol string, trExchang
 
 		// Validate that the symbol works
 		if s.validateSymbol(bestScore.Symbol) {
-			log.Printf("INFO: Resolved %s to %s (score-based)", symbol, bestScore.Symbol)
+			utils.Logf(ctx, "INFO: Resolved %s to %s (score-based)", symbol, bestScore.Symbol)
 			return bestScore.Symbol, true, nil
 		}
 	}
 
 	// If all methods fail, return the first result without validation
 	if len(results) > 0 {
-		log.Printf("WARNING: Could not validate symbol for %s, using first result %s", symbol, results[0].Symbol)
+		utils.Logf(ctx, "WARNING: Could not validate symbol for %s, using first result %s", symbol, results[0].Symbol)
 		return results[0].Symbol, false, nil
 	}
 
 	return "", false, fmt.Errorf("could not resolve symbol %s", symbol)
 }
 
+// LookupAssetName searches the name of an asset by ISIN, then by symbol. Only the search
+// results listing the asset symbol or ISIN are used; an empty name is returned when none
+// was found.
+func (s *YahooFinanceService) LookupAssetName(isin, symbol string) (string, error) {
+	var lastErr error
+	for _, query := range []string{isin, symbol} {
+		if query == "" {
+			continue
+		}
+		results, err := s.SearchSymbol(query)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if name := assetNameFromResults(results, isin, symbol); name != "" {
+			return name, nil
+		}
+	}
+	if lastErr != nil {
+		return "", fmt.Errorf("failed to search asset name: %w", lastErr)
+	}
+	return "", nil
+}
+
+// assetNameFromResults returns the long name (or short name) of the result of symbol, or of
+// a result listed under isin (e.g. IE00B4L5Y983.SG). Other results may be unrelated assets
+// matching the query text, so an empty name is returned when none of them matches.
+func assetNameFromResults(results []YahooSearchResult, isin, symbol string) string {
+	name := func(result YahooSearchResult) string {
+		for _, candidate := range []string{result.Name, result.ShortName} {
+			candidate = strings.TrimSpace(candidate)
+			if candidate != "" && candidate != models.UnknownAssetName {
+				return candidate
+			}
+		}
+		return ""
+	}
+
+	// The result of symbol is preferred over the listings of the ISIN
+	isSymbol := func(result YahooSearchResult) bool {
+		return symbol != "" && strings.EqualFold(result.Symbol, symbol)
+	}
+	isISINListing := func(result YahooSearchResult) bool {
+		ticker, _, _ := strings.Cut(result.Symbol, ".")
+		return isin != "" && strings.EqualFold(ticker, isin)
+	}
+	for _, matches := range []func(YahooSearchResult) bool{isSymbol, isISINListing} {
+		for _, result := range results {
+			if matches(result) {
+				if found := name(result); found != "" {
+					return found
+				}
+			}
+		}
+	}
+	return ""
+}
+
 // validateSymbol checks if a symbol exists and has price data on Yahoo Finance
 func (s *YahooFinanceService) validateSymbol(symbol string) bool {
 	apiURL := fmt.Sprintf("https://query1.finance.yahoo.com/v8/finance/chart/%s?range=1d&interval=1d", symbol)
@@ -663,6 +1230,7 @@ func (s *YahooFinanceService) validateSymbol(symbol string) bool {
 
 	req.Header.Set("User-Agent", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36")
 
+	s.rateLimiter.Wait()
 	resp, err := s.httpClient.Do(req)
 	if err != nil {
 		return false
@@ -11,7 +11,7 @@ import (
 
 // SyncService defines the interface for synchronization operations
 type SyncService interface {
-	SyncAccount(accountID string) (*types.SyncResult, error)
+	SyncAccount(accountID string) (types.SyncResult, error)
 	SyncAllAccounts() ([]types.SyncResult, error)
 }
 
@@ -30,6 +30,8 @@ type Scheduler struct {
 	wg           sync.WaitGroup
 	priceService price.Service
 	syncService  SyncService
+	// afterTask is called after every run of a task, successful or not
+	afterTask func(name string)
 }
 
 // NewScheduler creates a new scheduler instance
@@ -53,6 +55,12 @@ func (s *Scheduler) AddTask(name string, interval time.Duration, fn func() error
 	})
 }
 
+// OnTaskDone sets a function called after every run of a task, e.g. to drop results
+// cached from data a task may have changed. It must be called before Start.
+func (s *Scheduler) OnTaskDone(fn func(name string)) {
+	s.afterTask = fn
+}
+
 // Start begins executing all scheduled tasks
 func (s *Scheduler) Start() {
 	log.Println("📅 Scheduler starting...")
@@ -87,11 +95,7 @@ func (s *Scheduler) runTask(task Task) {
 	log.Printf("📅 Task '%s' scheduled to run every %s", task.Name, task.Interval)
 
 	// Run immediately on start
-	if err := task.Fn(); err != nil {
-		log.Printf("❌ Task '%s' failed: %v", task.Name, err)
-	} else {
-		log.Printf("✅ Task '%s' completed successfully", task.Name)
-	}
+	s.execute(task)
 
 	for {
 		select {
@@ -100,15 +104,23 @@ func (s *Scheduler) runTask(task Task) {
 			return
 		case <-ticker.C:
 			log.Printf("📅 Running task '%s'", task.Name)
-			if err := task.Fn(); err != nil {
-				log.Printf("❌ Task '%s' failed: %v", task.Name, err)
-			} else {
-				log.Printf("✅ Task '%s' completed successfully", task.Name)
-			}
+			s.execute(task)
 		}
 	}
 }
 
+// execute runs a task once and logs its outcome
+func (s *Scheduler) execute(task Task) {
+	if err := task.Fn(); err != nil {
+		log.Printf("❌ Task '%s' failed: %v", task.Name, err)
+	} else {
+		log.Printf("✅ Task '%s' completed successfully", task.Name)
+	}
+	if s.afterTask != nil {
+		s.afterTask(task.Name)
+	}
+}
+
 // addDefaultTasks adds the default scheduled tasks
 func (s *Scheduler) addDefaultTasks() {
 	// Task 1: Update asset prices once per day
@@ -135,7 +147,7 @@ func (s *Scheduler) addDefaultTasks() {
 		successCount := 0
 		failCount := 0
 		for _, result := range results {
-			if result.Error == "" {
+			if result.Status != types.SyncStatusFailed {
 				successCount++
 			} else {
 				failCount++
@@ -49,8 +49,8 @@ type mockSyncService struct {
 	mu                    sync.Mutex
 }
 
-func (m *mockSyncService) SyncAccount(accountID string) (*types.SyncResult, error) {
-	return &types.SyncResult{AccountID: accountID}, nil
+func (m *mockSyncService) SyncAccount(accountID string) (types.SyncResult, error) {
+	return types.SyncResult{AccountID: accountID, Status: types.SyncStatusSuccess}, nil
 }
 
 func (m *mockSyncService) SyncAllAccounts() ([]types.SyncResult, error) {
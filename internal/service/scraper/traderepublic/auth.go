@@ -2,14 +2,20 @@ package traderepublic
 
 import (
 	"bytes"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"strings"
+	"time"
 	"valhafin/internal/service/scraper/types"
 )
 
+// DefaultSessionTTL is how long a session token is considered valid when its expiry
+// cannot be read from the token
+const DefaultSessionTTL = 5 * time.Minute
+
 type loginResponse struct {
 	ProcessID          string `json:"processId"`
 	CountdownInSeconds int    `json:"countdownInSeconds"`
@@ -128,3 +134,20 @@ func (s *Scraper) Authenticate2FA(processID, code string) (string, error) {
 
 	return "", types.NewAuthError("traderepublic", "Session token not found in response", nil)
 }
+
+// SessionExpiry returns when a session token expires: the exp claim of the token, which is
+// a JWT, or now + DefaultSessionTTL when it cannot be read
+func SessionExpiry(sessionToken string, now time.Time) time.Time {
+	parts := strings.Split(sessionToken, ".")
+	if len(parts) == 3 {
+		if payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "=")); err == nil {
+			var claims struct {
+				Exp int64 `json:"exp"`
+			}
+			if json.Unmarshal(payload, &claims) == nil && claims.Exp > 0 {
+				return time.Unix(claims.Exp, 0)
+			}
+		}
+	}
+	return now.Add(DefaultSessionTTL)
+}
@@ -0,0 +1,25 @@
+package traderepublic
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+)
+
+func TestSessionExpiry(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	exp := now.Add(290 * time.Second)
+
+	token := "eyJhbGciOiJIUzI1NiJ9." +
+		base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"user","exp":1717243490}`)) +
+		".signature"
+	if expiry := SessionExpiry(token, now); !expiry.Equal(exp) {
+		t.Errorf("expected the exp claim %v, got %v", exp, expiry)
+	}
+
+	for _, token := range []string{"opaque-token", "a.not-base64!.c", "a." + base64.RawURLEncoding.EncodeToString([]byte(`{}`)) + ".c"} {
+		if expiry := SessionExpiry(token, now); !expiry.Equal(now.Add(DefaultSessionTTL)) {
+			t.Errorf("%s: expected the default lifetime, got %v", token, expiry)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package traderepublic
+
+import (
+	"fmt"
+)
+
+// Default PIN length range accepted by Trade Republic
+const (
+	DefaultPINMinLength = 4
+	DefaultPINMaxLength = 6
+)
+
+// pinMinLength and pinMaxLength bound the accepted PIN length
+var (
+	pinMinLength = DefaultPINMinLength
+	pinMaxLength = DefaultPINMaxLength
+)
+
+// SetPINLengthRange changes the accepted PIN length range
+// It must be called at startup, before credentials are validated
+func SetPINLengthRange(minLength, maxLength int) error {
+	if minLength < 1 || maxLength < minLength {
+		return fmt.Errorf("invalid PIN length range: %d-%d", minLength, maxLength)
+	}
+	pinMinLength = minLength
+	pinMaxLength = maxLength
+	return nil
+}
+
+// PINLengthRange returns the accepted PIN length range
+func PINLengthRange() (int, int) {
+	return pinMinLength, pinMaxLength
+}
+
+// ValidatePIN checks that the PIN is numeric and within the accepted length range
+func ValidatePIN(pin string) error {
+	for _, c := range pin {
+		if c < '0' || c > '9' {
+			return fmt.Errorf("PIN must contain only digits")
+		}
+	}
+
+	if len(pin) < pinMinLength || len(pin) > pinMaxLength {
+		if pinMinLength == pinMaxLength {
+			return fmt.Errorf("PIN must be %d digits", pinMinLength)
+		}
+		return fmt.Errorf("PIN must be %d to %d digits", pinMinLength, pinMaxLength)
+	}
+
+	return nil
+}
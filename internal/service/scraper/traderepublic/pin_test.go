@@ -0,0 +1,74 @@
+package traderepublic
+
+import "testing"
+
+func TestValidatePIN(t *testing.T) {
+	tests := []struct {
+		name    string
+		pin     string
+		wantErr bool
+	}{
+		{"4 digits", "1234", false},
+		{"5 digits", "12345", false},
+		{"6 digits", "123456", false},
+		{"too short", "123", true},
+		{"too long", "1234567", true},
+		{"alphanumeric", "12a4", true},
+		{"letters only", "abcdef", true},
+		{"empty", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidatePIN(tt.pin)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidatePIN(%q) error = %v, wantErr %v", tt.pin, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateCredentials_PINLengths(t *testing.T) {
+	scraper := NewScraper()
+
+	for _, pin := range []string{"1234", "12345", "123456"} {
+		err := scraper.ValidateCredentials(map[string]interface{}{
+			"phone_number": "+33612345678",
+			"pin":          pin,
+		})
+		if err != nil {
+			t.Errorf("PIN %q should be accepted, got %v", pin, err)
+		}
+	}
+
+	for _, pin := range []string{"123", "1234567", "12a4"} {
+		err := scraper.ValidateCredentials(map[string]interface{}{
+			"phone_number": "+33612345678",
+			"pin":          pin,
+		})
+		if err == nil {
+			t.Errorf("PIN %q should be rejected", pin)
+		}
+	}
+}
+
+func TestSetPINLengthRange(t *testing.T) {
+	defer SetPINLengthRange(DefaultPINMinLength, DefaultPINMaxLength)
+
+	if err := SetPINLengthRange(4, 4); err != nil {
+		t.Fatalf("SetPINLengthRange(4, 4) failed: %v", err)
+	}
+	if err := ValidatePIN("12345"); err == nil {
+		t.Error("5-digit PIN should be rejected when the range is 4-4")
+	}
+	if err := ValidatePIN("1234"); err != nil {
+		t.Errorf("4-digit PIN should be accepted: %v", err)
+	}
+
+	if err := SetPINLengthRange(6, 4); err == nil {
+		t.Error("Expected error for inverted range")
+	}
+	if err := SetPINLengthRange(0, 4); err == nil {
+		t.Error("Expected error for zero minimum")
+	}
+}
@@ -2,9 +2,12 @@ package traderepublic
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"regexp"
+	"sort"
 	"strings"
 	"time"
 	"valhafin/internal/domain/models"
@@ -80,9 +83,9 @@ func (s *Scraper) ValidateCredentials(credentials map[string]interface{}) error
 		return types.NewValidationError("traderepublic", "pin is required", nil)
 	}
 
-	// Validate PIN format (should be 4 digits)
-	if len(pin) != 4 {
-		return types.NewValidationError("traderepublic", "PIN must be 4 digits", nil)
+	// Validate PIN format (digits, configurable length)
+	if err := ValidatePIN(pin); err != nil {
+		return types.NewValidationError("traderepublic", err.Error(), nil)
 	}
 
 	// Validate phone number format (basic check)
@@ -131,6 +134,9 @@ func (s *Scraper) FetchTransactionsWithToken(sessionToken string, lastSync *time
 
 	// Fetch timeline transactions
 	timelineTransactions, err := wsClient.FetchTimeline()
+	if errors.Is(err, ErrSessionRejected) {
+		return nil, types.NewAuthError("traderepublic", "Session rejected", err)
+	}
 	if err != nil {
 		return nil, types.NewNetworkError("traderepublic", "Failed to fetch timeline transactions", err)
 	}
@@ -162,6 +168,30 @@ func (s *Scraper) FetchTransactionsWithToken(sessionToken string, lastSync *time
 	return transactions, nil
 }
 
+// FetchPortfolio fetches the current positions (ISIN, quantity, average price) of the
+// securities account using an authenticated session token. Unlike the positions replayed
+// from the timeline, they include the effect of corporate actions (splits, mergers...).
+func (s *Scraper) FetchPortfolio(sessionToken string) ([]models.BrokerPosition, error) {
+	wsClient, err := NewWebSocketClient(sessionToken)
+	if err != nil {
+		return nil, types.NewNetworkError("traderepublic", "Failed to connect to WebSocket", err)
+	}
+	defer wsClient.Close()
+
+	portfolio, err := wsClient.FetchPortfolio()
+	if err != nil {
+		return nil, types.NewNetworkError("traderepublic", "Failed to fetch portfolio", err)
+	}
+
+	positions, err := portfolio.BrokerPositions()
+	if err != nil {
+		return nil, types.NewParsingError("traderepublic", "Failed to parse portfolio", err)
+	}
+
+	log.Printf("DEBUG: Fetched %d portfolio positions", len(positions))
+	return positions, nil
+}
+
 // fetchAndStoreSymbols fetches instrument details for all unique ISINs and stores symbols
 func (s *Scraper) fetchAndStoreSymbols(transactions []models.Transaction, wsClient *WebSocketClient) error {
 	// Collect unique ISINs
@@ -274,8 +304,9 @@ func (s *Scraper) convertTimelineTransactions(timelineTransactions []TimelineTra
 			continue
 		}
 
-		// Extract amount value and currency
+		// Extract amount value, currency and precision
 		amountValue := 0.0
+		amountFraction := 0
 		amountCurrency := "EUR"
 		if tt.Amount != nil {
 			if val, ok := tt.Amount["value"].(float64); ok {
@@ -284,6 +315,9 @@ func (s *Scraper) convertTimelineTransactions(timelineTransactions []TimelineTra
 			if curr, ok := tt.Amount["currency"].(string); ok {
 				amountCurrency = curr
 			}
+			if digits, ok := tt.Amount["fractionDigits"].(float64); ok {
+				amountFraction = int(digits)
+			}
 		}
 
 		// Extract ISIN from icon path (format: "logos/IE00BM67HM91/v2")
@@ -302,12 +336,7 @@ func (s *Scraper) convertTimelineTransactions(timelineTransactions []TimelineTra
 
 		// If no ISIN from icon, try action payload
 		if isin == "" && tt.Action != nil {
-			if payload, ok := tt.Action["payload"].(string); ok {
-				// Only use if it looks like an ISIN (12 chars)
-				if len(payload) == 12 {
-					isin = payload
-				}
-			}
+			isin = isinFromPayload(tt.Action["payload"])
 		}
 
 		// Determine transaction type
@@ -326,6 +355,7 @@ func (s *Scraper) convertTimelineTransactions(timelineTransactions []TimelineTra
 			Subtitle:        tt.Subtitle,
 			ISIN:            isinPtr,
 			AmountValue:     amountValue,
+			AmountFraction:  amountFraction,
 			AmountCurrency:  amountCurrency,
 			Fees:            "0",
 			Quantity:        0,
@@ -349,97 +379,61 @@ func (s *Scraper) convertTimelineTransactions(timelineTransactions []TimelineTra
 	return transactions
 }
 
-// determineTransactionTypeFromIcon determines the transaction type from icon and title
-// determineTransactionTypeFromIcon determines the transaction type from icon, title, subtitle and amount
-func (s *Scraper) determineTransactionTypeFromIcon(icon, title, subtitle string, amountValue float64) string {
-	iconLower := strings.ToLower(icon)
-	titleLower := strings.ToLower(title)
-	subtitleLower := strings.ToLower(subtitle)
-
-	// Dividends - check subtitle for "dividende" or "dividend"
-	if strings.Contains(subtitleLower, "dividende en espèces") ||
-		strings.Contains(subtitleLower, "dividende") ||
-		strings.Contains(subtitleLower, "dividend") ||
-		strings.Contains(iconLower, "dividend") {
-		return "dividend"
-	}
-
-	// Interest
-	if strings.Contains(titleLower, "intérêts") ||
-		strings.Contains(titleLower, "intérêt") ||
-		strings.Contains(titleLower, "interest") {
-		return "interest"
-	}
-
-	// Buy transactions - negative amount means money going out (buying)
-	// Check subtitle for execution confirmation or if amount is negative with an ISIN
-	if strings.Contains(subtitleLower, "plan d'épargne exécuté") ||
-		strings.Contains(subtitleLower, "sparplan ausgeführt") ||
-		strings.Contains(subtitleLower, "ordre d'achat") ||
-		strings.Contains(subtitleLower, "échec du plan d'épargne") ||
-		strings.Contains(subtitleLower, "buy order") ||
-		strings.Contains(iconLower, "arrow-right") ||
-		strings.Contains(titleLower, "kauf") ||
-		strings.Contains(titleLower, "sparplan") {
-		return "buy"
-	}
-
-	// If amount is negative and title contains an asset name (not "intérêt", "versement", etc.)
-	// it's likely a buy transaction
-	if amountValue < 0 &&
-		!strings.Contains(titleLower, "intérêt") &&
-		!strings.Contains(titleLower, "versement") &&
-		!strings.Contains(titleLower, "dépôt") &&
-		titleLower != "" &&
-		// Check if it looks like an asset name (contains letters and possibly numbers)
-		len(titleLower) > 3 {
-		return "buy"
-	}
-
-	// Sell transactions
-	if strings.Contains(subtitleLower, "ordre de vente") ||
-		strings.Contains(subtitleLower, "sell order") ||
-		strings.Contains(iconLower, "arrow-left") ||
-		strings.Contains(titleLower, "verkauf") ||
-		strings.Contains(titleLower, "vente") {
-		return "sell"
-	}
-
-	// Deposits - positive amount with specific keywords or "terminé" subtitle
-	if strings.Contains(subtitleLower, "terminé") ||
-		strings.Contains(titleLower, "einzahlung") ||
-		strings.Contains(titleLower, "dépôt") ||
-		strings.Contains(titleLower, "versement") ||
-		strings.Contains(titleLower, "deposit") {
-		// But not if it's a dividend
-		if !strings.Contains(subtitleLower, "dividende") {
-			return "deposit"
+// isinCandidate matches what looks like an ISIN inside a longer text
+var isinCandidate = regexp.MustCompile(`[A-Z]{2}[A-Z0-9]{9}[0-9]`)
+
+// isinFromPayload returns the ISIN carried by a timeline action payload, empty when none is
+// found. The payload is an ISIN, a JSON object (decoded or as a string) with an isin key, or
+// a text containing an ISIN. Only ISINs with a valid check digit are returned.
+func isinFromPayload(payload interface{}) string {
+	switch v := payload.(type) {
+	case string:
+		v = strings.TrimSpace(v)
+		if models.IsValidISIN(v) {
+			return v
+		}
+		if strings.HasPrefix(v, "{") {
+			var decoded map[string]interface{}
+			if err := json.Unmarshal([]byte(v), &decoded); err == nil {
+				if isin := isinFromPayload(decoded); isin != "" {
+					return isin
+				}
+			}
+		}
+		for _, candidate := range isinCandidate.FindAllString(v, -1) {
+			if models.IsValidISIN(candidate) {
+				return candidate
+			}
+		}
+	case map[string]interface{}:
+		// An isin key wins over ISINs found in other values, searched in key order
+		keys := make([]string, 0, len(v))
+		for key, value := range v {
+			if s, ok := value.(string); ok && strings.EqualFold(key, "isin") && models.IsValidISIN(strings.TrimSpace(s)) {
+				return strings.TrimSpace(s)
+			}
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if isin := isinFromPayload(v[key]); isin != "" {
+				return isin
+			}
+		}
+	case []interface{}:
+		for _, value := range v {
+			if isin := isinFromPayload(value); isin != "" {
+				return isin
+			}
 		}
 	}
+	return ""
+}
 
-	// If amount is positive and title is a person's name (contains spaces and capital letters)
-	// it's likely a deposit
-	if amountValue > 0 &&
-		strings.Contains(title, " ") &&
-		title == strings.Title(strings.ToLower(title)) {
-		return "deposit"
-	}
-
-	// Withdrawals
-	if strings.Contains(titleLower, "auszahlung") ||
-		strings.Contains(titleLower, "retrait") ||
-		strings.Contains(titleLower, "withdrawal") {
-		return "withdrawal"
-	}
-
-	// Fees
-	if strings.Contains(titleLower, "gebühr") ||
-		strings.Contains(titleLower, "frais") ||
-		strings.Contains(titleLower, "fee") {
-		return "fee"
-	}
-
-	return "other"
+// determineTransactionTypeFromIcon determines the transaction type from icon, title, subtitle and amount
+// (see models.DetermineTransactionType)
+func (s *Scraper) determineTransactionTypeFromIcon(icon, title, subtitle string, amountValue float64) string {
+	return models.DetermineTransactionType(icon, title, subtitle, amountValue)
 }
 
 // enrichTransactionWithDetails fetches transaction details and enriches the transaction with shares, price, and fees
@@ -0,0 +1,108 @@
+package traderepublic
+
+import (
+	"testing"
+	"valhafin/internal/domain/models"
+)
+
+func TestDetermineTransactionTypeFromIcon(t *testing.T) {
+	scraper := NewScraper()
+
+	tests := []struct {
+		name     string
+		icon     string
+		title    string
+		subtitle string
+		amount   float64
+		expected string
+	}{
+		{"dividend", "logos/US0378331005/v2", "Apple", "Dividende en espèces", 1.23, "dividend"},
+		{"interest", "", "Intérêts", "", 2.5, "interest"},
+		{"savings plan", "", "Apple", "Plan d'épargne exécuté", -50, "buy"},
+		{"sell order", "", "Apple", "Ordre de vente", 120, "sell"},
+		{"deposit", "", "Einzahlung", "", 100, "deposit"},
+		{"withdrawal", "", "Retrait", "", 0, "withdrawal"},
+		{"unknown", "", "x", "", 0, "other"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := scraper.determineTransactionTypeFromIcon(tt.icon, tt.title, tt.subtitle, tt.amount)
+			if got != tt.expected {
+				t.Errorf("determineTransactionTypeFromIcon() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestDetermineTransactionTypeFromIcon_CustomMapping(t *testing.T) {
+	defer models.SetCustomTypeMappings(nil)
+
+	scraper := NewScraper()
+	if got := scraper.determineTransactionTypeFromIcon("", "Opname", "", 0); got != "other" {
+		t.Fatalf("Expected other before the custom mapping, got %q", got)
+	}
+
+	if err := models.SetCustomTypeMappings([]models.TypeMapping{{Keyword: "opname", Field: "title", Type: "withdrawal"}}); err != nil {
+		t.Fatalf("SetCustomTypeMappings failed: %v", err)
+	}
+
+	if got := scraper.determineTransactionTypeFromIcon("", "Opname", "", 0); got != "withdrawal" {
+		t.Errorf("Expected withdrawal with the custom mapping, got %q", got)
+	}
+}
+
+func TestIsinFromPayload(t *testing.T) {
+	tests := []struct {
+		name     string
+		payload  interface{}
+		expected string
+	}{
+		{"plain ISIN", "IE00B4L5Y983", "IE00B4L5Y983"},
+		{"JSON string", `{"type":"instrument","isin":"US0378331005"}`, "US0378331005"},
+		{"decoded JSON", map[string]interface{}{"ISIN": "DE0007164600", "id": "x"}, "DE0007164600"},
+		{"nested JSON", map[string]interface{}{"instrument": map[string]interface{}{"isin": "US0378331005"}}, "US0378331005"},
+		{"text with ISIN", "timeline/US0378331005/details", "US0378331005"},
+		{"invalid check digit", "US0378331006", ""},
+		{"12 characters but no ISIN", "abcdef123456", ""},
+		{"no payload", nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isinFromPayload(tt.payload); got != tt.expected {
+				t.Errorf("isinFromPayload() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestConvertTimelineTransactions_ISINFromPayload(t *testing.T) {
+	scraper := NewScraper()
+	transactions := scraper.convertTimelineTransactions([]TimelineTransaction{
+		{
+			ID:        "tx-1",
+			Timestamp: "2024-01-15T10:00:00.000+0000",
+			Title:     "Apple",
+			Subtitle:  "Dividende en espèces",
+			Amount:    map[string]interface{}{"value": 1.23, "currency": "EUR"},
+			Action:    map[string]interface{}{"type": "timelineDetail", "payload": `{"isin":"US0378331005"}`},
+		},
+		{
+			ID:        "tx-2",
+			Timestamp: "2024-01-16T10:00:00.000+0000",
+			Title:     "Intérêts",
+			Amount:    map[string]interface{}{"value": 2.5, "currency": "EUR"},
+			Action:    map[string]interface{}{"type": "timelineDetail", "payload": "f8a9c1d2-0000"},
+		},
+	}, nil)
+
+	if len(transactions) != 2 {
+		t.Fatalf("expected 2 transactions, got %d", len(transactions))
+	}
+	if transactions[0].ISIN == nil || *transactions[0].ISIN != "US0378331005" {
+		t.Errorf("expected the ISIN of the JSON payload, got %v", transactions[0].ISIN)
+	}
+	if transactions[1].ISIN != nil {
+		t.Errorf("expected no ISIN, got %q", *transactions[1].ISIN)
+	}
+}
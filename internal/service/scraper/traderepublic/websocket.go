@@ -2,15 +2,21 @@ package traderepublic
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"strconv"
 	"strings"
 	"time"
+	"valhafin/internal/domain/models"
 
 	"github.com/gorilla/websocket"
 )
 
+// ErrSessionRejected is returned when Trade Republic refuses the session token of a
+// subscription (expired or revoked session)
+var ErrSessionRejected = errors.New("session rejected by Trade Republic")
+
 // WebSocketClient handles WebSocket communication with Trade Republic
 type WebSocketClient struct {
 	conn         *websocket.Conn
@@ -128,6 +134,9 @@ func (c *WebSocketClient) FetchTimeline() ([]TimelineTransaction, error) {
 		if err != nil {
 			return nil, fmt.Errorf("failed to read response: %w", err)
 		}
+		if err := subscriptionError(string(message)); err != nil {
+			return nil, err
+		}
 
 		// Send unsubscribe
 		unsubMsg := fmt.Sprintf("unsub %d", c.messageID)
@@ -183,6 +192,35 @@ func (c *WebSocketClient) FetchTimeline() ([]TimelineTransaction, error) {
 	return allTransactions, nil
 }
 
+// subscriptionError returns the error reported by an error message ("<id> E <json>") answering
+// a subscription, or nil for other messages. Authentication errors wrap ErrSessionRejected.
+func subscriptionError(message string) error {
+	fields := strings.SplitN(message, " ", 3)
+	if len(fields) < 2 || fields[1] != "E" {
+		return nil
+	}
+
+	var response struct {
+		Errors []struct {
+			ErrorCode    string `json:"errorCode"`
+			ErrorMessage string `json:"errorMessage"`
+		} `json:"errors"`
+	}
+	if len(fields) == 3 {
+		_ = json.Unmarshal([]byte(fields[2]), &response)
+	}
+
+	for _, e := range response.Errors {
+		if e.ErrorCode == "AUTHENTICATION_ERROR" || e.ErrorCode == "UNAUTHORIZED" {
+			return fmt.Errorf("%w: %s", ErrSessionRejected, e.ErrorMessage)
+		}
+	}
+	if len(response.Errors) > 0 {
+		return fmt.Errorf("subscription failed: %s (%s)", response.Errors[0].ErrorMessage, response.Errors[0].ErrorCode)
+	}
+	return fmt.Errorf("subscription failed")
+}
+
 // min returns the minimum of two integers
 func min(a, b int) int {
 	if a < b {
@@ -487,3 +525,114 @@ func (c *WebSocketClient) FetchInstrumentDetails(isin string) (map[string]interf
 
 	return details, nil
 }
+
+// PortfolioPosition represents a position of the compactPortfolio response.
+// Trade Republic sends numbers as strings (e.g. "netSize": "2.5").
+type PortfolioPosition struct {
+	InstrumentID string      `json:"instrumentId"`
+	NetSize      interface{} `json:"netSize"`
+	AverageBuyIn interface{} `json:"averageBuyIn"`
+}
+
+// PortfolioResponse represents the response from compactPortfolio
+type PortfolioResponse struct {
+	Positions []PortfolioPosition `json:"positions"`
+}
+
+// FetchPortfolio fetches the current positions of the securities account
+func (c *WebSocketClient) FetchPortfolio() (*PortfolioResponse, error) {
+	c.messageID++
+
+	payload := map[string]interface{}{
+		"type":  "compactPortfolio",
+		"token": c.sessionToken,
+	}
+
+	payloadJSON, _ := json.Marshal(payload)
+	subMsg := fmt.Sprintf("sub %d %s", c.messageID, string(payloadJSON))
+
+	// Send subscription
+	if err := c.conn.WriteMessage(websocket.TextMessage, []byte(subMsg)); err != nil {
+		return nil, fmt.Errorf("failed to send subscription: %w", err)
+	}
+
+	// Read response
+	_, message, err := c.conn.ReadMessage()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	// Send unsubscribe
+	unsubMsg := fmt.Sprintf("unsub %d", c.messageID)
+	if err := c.conn.WriteMessage(websocket.TextMessage, []byte(unsubMsg)); err != nil {
+		return nil, fmt.Errorf("failed to send unsubscribe: %w", err)
+	}
+
+	// Read unsubscribe response
+	_, _, err = c.conn.ReadMessage()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read unsubscribe response: %w", err)
+	}
+
+	return parsePortfolioMessage(string(message))
+}
+
+// parsePortfolioMessage extracts the compactPortfolio JSON from a WebSocket message
+func parsePortfolioMessage(messageStr string) (*PortfolioResponse, error) {
+	startIndex := strings.Index(messageStr, "{")
+	endIndex := strings.LastIndex(messageStr, "}")
+
+	if startIndex == -1 || endIndex == -1 {
+		return nil, fmt.Errorf("no JSON found in message")
+	}
+
+	var portfolio PortfolioResponse
+	if err := json.Unmarshal([]byte(messageStr[startIndex:endIndex+1]), &portfolio); err != nil {
+		return nil, fmt.Errorf("failed to parse portfolio response: %w", err)
+	}
+
+	return &portfolio, nil
+}
+
+// portfolioNumber converts a compactPortfolio number, sent as a string or a number
+func portfolioNumber(value interface{}) (float64, error) {
+	switch v := value.(type) {
+	case nil:
+		return 0, nil
+	case float64:
+		return v, nil
+	case string:
+		if strings.TrimSpace(v) == "" {
+			return 0, nil
+		}
+		return strconv.ParseFloat(strings.TrimSpace(v), 64)
+	default:
+		return 0, fmt.Errorf("unexpected number type %T", value)
+	}
+}
+
+// BrokerPositions converts the portfolio to broker positions, skipping empty ones
+func (p *PortfolioResponse) BrokerPositions() ([]models.BrokerPosition, error) {
+	positions := make([]models.BrokerPosition, 0, len(p.Positions))
+	for _, position := range p.Positions {
+		quantity, err := portfolioNumber(position.NetSize)
+		if err != nil {
+			return nil, fmt.Errorf("invalid netSize for %s: %w", position.InstrumentID, err)
+		}
+		if quantity == 0 {
+			continue
+		}
+
+		averagePrice, err := portfolioNumber(position.AverageBuyIn)
+		if err != nil {
+			return nil, fmt.Errorf("invalid averageBuyIn for %s: %w", position.InstrumentID, err)
+		}
+
+		positions = append(positions, models.BrokerPosition{
+			ISIN:         position.InstrumentID,
+			Quantity:     quantity,
+			AveragePrice: averagePrice,
+		})
+	}
+	return positions, nil
+}
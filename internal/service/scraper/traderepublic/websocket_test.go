@@ -2,6 +2,7 @@ package traderepublic
 
 import (
 	"encoding/json"
+	"errors"
 	"testing"
 )
 
@@ -122,3 +123,66 @@ func TestExtractFeesFromDetail(t *testing.T) {
 
 	t.Logf("✓ Successfully extracted fees: %s", fees)
 }
+
+func TestParsePortfolioMessage(t *testing.T) {
+	message := `1 A {"positions":[` +
+		`{"instrumentId":"US0378331005","netSize":"2.5","averageBuyIn":"150.12"},` +
+		`{"instrumentId":"IE00B4L5Y983","netSize":10,"averageBuyIn":80},` +
+		`{"instrumentId":"DE0007164600","netSize":"0","averageBuyIn":"0"}]}`
+
+	portfolio, err := parsePortfolioMessage(message)
+	if err != nil {
+		t.Fatalf("parsePortfolioMessage() error = %v", err)
+	}
+
+	positions, err := portfolio.BrokerPositions()
+	if err != nil {
+		t.Fatalf("BrokerPositions() error = %v", err)
+	}
+	if len(positions) != 2 {
+		t.Fatalf("expected 2 positions (empty ones skipped), got %d: %+v", len(positions), positions)
+	}
+	if positions[0].ISIN != "US0378331005" || positions[0].Quantity != 2.5 || positions[0].AveragePrice != 150.12 {
+		t.Errorf("unexpected string-encoded position: %+v", positions[0])
+	}
+	if positions[1].Quantity != 10 || positions[1].AveragePrice != 80 {
+		t.Errorf("unexpected number-encoded position: %+v", positions[1])
+	}
+
+	invalid, err := parsePortfolioMessage(`1 A {"positions":[{"instrumentId":"US0378331005","netSize":"n/a"}]}`)
+	if err != nil {
+		t.Fatalf("parsePortfolioMessage() error = %v", err)
+	}
+	if _, err := invalid.BrokerPositions(); err == nil {
+		t.Error("expected an error for an invalid netSize")
+	}
+
+	if _, err := parsePortfolioMessage("1 E"); err == nil {
+		t.Error("expected an error for a message without JSON")
+	}
+}
+
+func TestSubscriptionError(t *testing.T) {
+	tests := []struct {
+		name     string
+		message  string
+		wantErr  bool
+		rejected bool
+	}{
+		{"answer", `1 A {"items":[]}`, false, false},
+		{"authentication error", `1 E {"errors":[{"errorCode":"AUTHENTICATION_ERROR","errorField":null,"errorMessage":"Unauthorized"}]}`, true, true},
+		{"other error", `1 E {"errors":[{"errorCode":"BAD_SUBSCRIPTION_TYPE","errorMessage":"Bad subscription type"}]}`, true, false},
+		{"error without payload", "1 E", true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := subscriptionError(tt.message)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("subscriptionError() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if errors.Is(err, ErrSessionRejected) != tt.rejected {
+				t.Errorf("expected ErrSessionRejected = %v, got %v", tt.rejected, err)
+			}
+		})
+	}
+}
@@ -19,17 +19,43 @@ type Scraper interface {
 	GetPlatformName() string
 }
 
+// SessionScraper is implemented by the scrapers of platforms requiring 2FA, which can fetch
+// transactions again with the session obtained by a previous authentication
+type SessionScraper interface {
+	Scraper
+
+	// FetchTransactionsWithToken retrieves transactions with an authenticated session token
+	FetchTransactionsWithToken(sessionToken string, lastSync *time.Time) ([]models.Transaction, error)
+}
+
+// Synchronization statuses
+const (
+	// SyncStatusSuccess means every fetched transaction was stored
+	SyncStatusSuccess = "success"
+	// SyncStatusPartial means the sync completed with warnings, e.g. transactions that could not be stored
+	SyncStatusPartial = "partial"
+	// SyncStatusFailed means the sync stopped before storing anything
+	SyncStatusFailed = "failed"
+	// SyncStatusSkipped means the account was not synchronized, e.g. during a bulk sync
+	// of an account requiring an interactive 2FA flow
+	SyncStatusSkipped = "skipped"
+)
+
 // SyncResult contains the result of a synchronization operation
 type SyncResult struct {
-	AccountID           string    `json:"account_id"`
-	Platform            string    `json:"platform"`
-	TransactionsFetched int       `json:"transactions_fetched"`
-	TransactionsStored  int       `json:"transactions_stored"`
-	SyncType            string    `json:"sync_type"` // "full" or "incremental"
-	StartTime           time.Time `json:"start_time"`
-	EndTime             time.Time `json:"end_time"`
-	Duration            string    `json:"duration"`
-	Error               string    `json:"error,omitempty"`
+	AccountID           string `json:"account_id"`
+	Platform            string `json:"platform"`
+	Status              string `json:"status"` // "success", "partial", "failed" or "skipped"
+	TransactionsFetched int    `json:"transactions_fetched"`
+	TransactionsAdded   int    `json:"transactions_added"`
+	// Changes reports which stored transactions were new, updated or unchanged
+	Changes   *models.TransactionChanges `json:"changes,omitempty"`
+	SyncType  string                     `json:"sync_type"` // "full" or "incremental"
+	StartTime time.Time                  `json:"start_time"`
+	EndTime   time.Time                  `json:"end_time"`
+	Duration  string                     `json:"duration"`
+	Warnings  []string                   `json:"warnings,omitempty"`
+	Error     string                     `json:"error,omitempty"`
 }
 
 // ScraperError represents an error that occurred during scraping
@@ -0,0 +1,173 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	gosync "sync"
+	"time"
+	"valhafin/internal/domain/models"
+	"valhafin/internal/service/scraper/types"
+	"valhafin/internal/utils"
+)
+
+const (
+	// BulkSyncWorkers bounds the accounts synchronized concurrently by a bulk sync
+	BulkSyncWorkers = 2
+	// BulkSyncInterval spaces the start of two account synchronizations so that a bulk
+	// sync does not hammer the platforms
+	BulkSyncInterval = time.Second
+)
+
+// Reasons reported for the accounts skipped by a bulk sync
+const (
+	skipReasonTwoFactor  = "requires an interactive 2FA synchronization"
+	skipReasonInProgress = "synchronization already in progress for this account"
+	skipReasonCanceled   = "bulk synchronization canceled before this account"
+)
+
+// BulkSyncOptions configures a bulk sync
+type BulkSyncOptions struct {
+	// Workers is the number of accounts synchronized concurrently (BulkSyncWorkers if <= 0)
+	Workers int
+	// Interval is the minimum delay between the start of two account synchronizations
+	Interval time.Duration
+}
+
+// BulkSyncSummary reports the outcome of a bulk sync
+type BulkSyncSummary struct {
+	Total     int                `json:"total"`
+	Succeeded int                `json:"succeeded"`
+	Failed    int                `json:"failed"`
+	Skipped   int                `json:"skipped"`
+	Canceled  bool               `json:"canceled"`
+	Results   []types.SyncResult `json:"results"`
+}
+
+// SyncAccountsContext synchronizes every account whose platform supports a non-interactive
+// sync, with a bounded number of concurrent synchronizations. A failure is isolated to its
+// account. Accounts requiring 2FA, or already being synchronized, are reported as skipped.
+// Once ctx is done no new synchronization starts; the ones in progress are finished and
+// the accounts not reached are reported as skipped.
+func (s *Service) SyncAccountsContext(ctx context.Context, opts BulkSyncOptions) (BulkSyncSummary, error) {
+	accounts, err := s.db.GetAllAccountsContext(ctx)
+	if err != nil {
+		return BulkSyncSummary{}, fmt.Errorf("failed to retrieve accounts: %w", err)
+	}
+
+	summary := runBulkSync(ctx, accounts, opts, func(accountID string) (types.SyncResult, error) {
+		return s.SyncAccountContext(ctx, accountID)
+	})
+	utils.Logf(ctx, "INFO: Bulk sync finished - Total: %d, Succeeded: %d, Failed: %d, Skipped: %d, Canceled: %v",
+		summary.Total, summary.Succeeded, summary.Failed, summary.Skipped, summary.Canceled)
+	return summary, nil
+}
+
+// runBulkSync synchronizes the accounts with syncAccount, keeping the results in the
+// order of the accounts
+func runBulkSync(ctx context.Context, accounts []models.Account, opts BulkSyncOptions, syncAccount func(accountID string) (types.SyncResult, error)) BulkSyncSummary {
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = BulkSyncWorkers
+	}
+
+	results := make([]types.SyncResult, len(accounts))
+	queue := make(chan int)
+	var wg gosync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range queue {
+				results[index] = syncIsolated(ctx, accounts[index], syncAccount)
+			}
+		}()
+	}
+
+	var ticker *time.Ticker
+	if opts.Interval > 0 {
+		ticker = time.NewTicker(opts.Interval)
+		defer ticker.Stop()
+	}
+
+	next := 0
+	started := false
+feed:
+	for ; next < len(accounts); next++ {
+		account := accounts[next]
+		if platform, ok := models.GetPlatform(account.Platform); ok && platform.RequiresTwoFactor {
+			results[next] = skippedResult(account, skipReasonTwoFactor)
+			continue
+		}
+
+		if started && ticker != nil {
+			select {
+			case <-ctx.Done():
+				break feed
+			case <-ticker.C:
+			}
+		}
+		select {
+		case <-ctx.Done():
+			break feed
+		case queue <- next:
+			started = true
+		}
+	}
+	close(queue)
+	wg.Wait()
+
+	summary := BulkSyncSummary{Total: len(accounts), Results: results}
+	for ; next < len(accounts); next++ {
+		summary.Canceled = true
+		results[next] = skippedResult(accounts[next], skipReasonCanceled)
+	}
+	for _, result := range results {
+		switch result.Status {
+		case types.SyncStatusSuccess, types.SyncStatusPartial:
+			summary.Succeeded++
+		case types.SyncStatusSkipped:
+			summary.Skipped++
+		default:
+			summary.Failed++
+		}
+	}
+	return summary
+}
+
+// syncIsolated synchronizes one account, turning a panic into a failed result so that it
+// does not stop the other accounts
+func syncIsolated(ctx context.Context, account models.Account, syncAccount func(accountID string) (types.SyncResult, error)) (result types.SyncResult) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			utils.Logf(ctx, "ERROR: Sync of account %s panicked: %v", account.ID, recovered)
+			result = types.SyncResult{
+				AccountID: account.ID,
+				Platform:  account.Platform,
+				Status:    types.SyncStatusFailed,
+				Error:     fmt.Sprintf("synchronization panicked: %v", recovered),
+				EndTime:   time.Now(),
+			}
+		}
+	}()
+
+	result, err := syncAccount(account.ID)
+	if errors.Is(err, ErrSyncInProgress) {
+		return skippedResult(account, skipReasonInProgress)
+	}
+	if err != nil {
+		utils.Logf(ctx, "WARNING: Failed to sync account %s: %v", account.ID, err)
+	}
+	result.Platform = account.Platform
+	return result
+}
+
+// skippedResult reports an account left out of a bulk sync
+func skippedResult(account models.Account, reason string) types.SyncResult {
+	return types.SyncResult{
+		AccountID: account.ID,
+		Platform:  account.Platform,
+		Status:    types.SyncStatusSkipped,
+		Error:     reason,
+	}
+}
@@ -0,0 +1,113 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	gosync "sync"
+	"sync/atomic"
+	"testing"
+	"time"
+	"valhafin/internal/domain/models"
+	"valhafin/internal/service/scraper/types"
+)
+
+func TestRunBulkSync_IsolatesAccounts(t *testing.T) {
+	accounts := []models.Account{
+		{ID: "ok", Platform: "binance"},
+		{ID: "tr", Platform: "traderepublic"},
+		{ID: "failing", Platform: "binance"},
+		{ID: "panicking", Platform: "boursedirect"},
+		{ID: "locked", Platform: "binance"},
+	}
+
+	var mu gosync.Mutex
+	synced := map[string]bool{}
+	summary := runBulkSync(context.Background(), accounts, BulkSyncOptions{Workers: 2}, func(accountID string) (types.SyncResult, error) {
+		mu.Lock()
+		synced[accountID] = true
+		mu.Unlock()
+
+		switch accountID {
+		case "failing":
+			return types.SyncResult{AccountID: accountID, Status: types.SyncStatusFailed, Error: "boom"}, errors.New("boom")
+		case "panicking":
+			panic("scraper bug")
+		case "locked":
+			return types.SyncResult{AccountID: accountID, Status: types.SyncStatusFailed}, ErrSyncInProgress
+		}
+		return types.SyncResult{AccountID: accountID, Status: types.SyncStatusSuccess}, nil
+	})
+
+	if synced["tr"] {
+		t.Error("2FA accounts must not be synchronized")
+	}
+	if summary.Total != 5 || summary.Succeeded != 1 || summary.Failed != 2 || summary.Skipped != 2 || summary.Canceled {
+		t.Errorf("unexpected summary: %+v", summary)
+	}
+
+	expected := []string{types.SyncStatusSuccess, types.SyncStatusSkipped, types.SyncStatusFailed, types.SyncStatusFailed, types.SyncStatusSkipped}
+	for i, result := range summary.Results {
+		if result.AccountID != accounts[i].ID || result.Status != expected[i] {
+			t.Errorf("result %d: expected %s %s, got %s %s", i, accounts[i].ID, expected[i], result.AccountID, result.Status)
+		}
+	}
+	if summary.Results[1].Error != skipReasonTwoFactor || summary.Results[3].Platform != "boursedirect" {
+		t.Errorf("unexpected results: %+v", summary.Results)
+	}
+}
+
+func TestRunBulkSync_BoundsConcurrency(t *testing.T) {
+	accounts := make([]models.Account, 10)
+	for i := range accounts {
+		accounts[i] = models.Account{ID: string(rune('a' + i)), Platform: "binance"}
+	}
+
+	var inFlight, maxInFlight int32
+	summary := runBulkSync(context.Background(), accounts, BulkSyncOptions{Workers: 3}, func(accountID string) (types.SyncResult, error) {
+		current := atomic.AddInt32(&inFlight, 1)
+		for {
+			max := atomic.LoadInt32(&maxInFlight)
+			if current <= max || atomic.CompareAndSwapInt32(&maxInFlight, max, current) {
+				break
+			}
+		}
+		time.Sleep(2 * time.Millisecond)
+		atomic.AddInt32(&inFlight, -1)
+		return types.SyncResult{AccountID: accountID, Status: types.SyncStatusSuccess}, nil
+	})
+
+	if maxInFlight > 3 {
+		t.Errorf("expected at most 3 concurrent syncs, got %d", maxInFlight)
+	}
+	if summary.Succeeded != 10 {
+		t.Errorf("expected 10 successes, got %+v", summary)
+	}
+}
+
+func TestRunBulkSync_StopsWhenCanceled(t *testing.T) {
+	accounts := make([]models.Account, 5)
+	for i := range accounts {
+		accounts[i] = models.Account{ID: string(rune('a' + i)), Platform: "binance"}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var calls int32
+	summary := runBulkSync(ctx, accounts, BulkSyncOptions{Workers: 1, Interval: time.Hour}, func(accountID string) (types.SyncResult, error) {
+		atomic.AddInt32(&calls, 1)
+		// Shutdown while the first account is synchronized
+		cancel()
+		return types.SyncResult{AccountID: accountID, Status: types.SyncStatusSuccess}, nil
+	})
+
+	if calls != 1 {
+		t.Errorf("expected a single sync before cancellation, got %d", calls)
+	}
+	if !summary.Canceled || summary.Succeeded != 1 || summary.Skipped != 4 {
+		t.Errorf("unexpected summary: %+v", summary)
+	}
+	if summary.Results[4].Error != skipReasonCanceled {
+		t.Errorf("expected the accounts not reached to be reported as canceled, got %+v", summary.Results[4])
+	}
+}
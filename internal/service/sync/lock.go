@@ -0,0 +1,51 @@
+package sync
+
+import (
+	"errors"
+	gosync "sync"
+)
+
+// ErrSyncInProgress is returned when a synchronization is already running for the account
+var ErrSyncInProgress = errors.New("synchronization already in progress for this account")
+
+// accountLocks tracks the accounts currently being synchronized
+type accountLocks struct {
+	mu       gosync.Mutex
+	inFlight map[string]struct{}
+}
+
+// tryLock marks the account as syncing, returning false if it already is
+func (l *accountLocks) tryLock(accountID string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.inFlight == nil {
+		l.inFlight = make(map[string]struct{})
+	}
+	if _, running := l.inFlight[accountID]; running {
+		return false
+	}
+	l.inFlight[accountID] = struct{}{}
+	return true
+}
+
+// unlock releases the account
+func (l *accountLocks) unlock(accountID string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.inFlight, accountID)
+}
+
+// LockAccount reserves the account for a synchronization
+// It returns ErrSyncInProgress if another synchronization holds the account,
+// otherwise a release function that must be called when the synchronization ends
+func (s *Service) LockAccount(accountID string) (func(), error) {
+	if !s.locks.tryLock(accountID) {
+		return nil, ErrSyncInProgress
+	}
+
+	var once gosync.Once
+	return func() {
+		once.Do(func() { s.locks.unlock(accountID) })
+	}, nil
+}
@@ -0,0 +1,92 @@
+package sync
+
+import (
+	"errors"
+	gosync "sync"
+	"testing"
+	"valhafin/internal/service/scraper/types"
+)
+
+func TestLockAccount_RejectsConcurrentSync(t *testing.T) {
+	service := NewService(nil, nil, nil)
+
+	release, err := service.LockAccount("account-1")
+	if err != nil {
+		t.Fatalf("First lock should succeed: %v", err)
+	}
+
+	// A second sync of the same account is rejected before touching the database
+	result, err := service.SyncAccount("account-1")
+	if !errors.Is(err, ErrSyncInProgress) {
+		t.Errorf("Expected ErrSyncInProgress, got %v", err)
+	}
+	if result.Status != types.SyncStatusFailed || result.Error == "" {
+		t.Errorf("Expected a failed result for a rejected sync, got %+v", result)
+	}
+
+	// Other accounts are not affected
+	releaseOther, err := service.LockAccount("account-2")
+	if err != nil {
+		t.Errorf("Lock on another account should succeed: %v", err)
+	} else {
+		releaseOther()
+	}
+
+	release()
+	release() // releasing twice is a no-op
+
+	release, err = service.LockAccount("account-1")
+	if err != nil {
+		t.Errorf("Lock should succeed after release: %v", err)
+	} else {
+		release()
+	}
+}
+
+func TestLockAccount_ConcurrentAttempts(t *testing.T) {
+	service := NewService(nil, nil, nil)
+
+	const attempts = 10
+	var wg gosync.WaitGroup
+	var mu gosync.Mutex
+	acquired := 0
+	rejected := 0
+	start := make(chan struct{})
+	done := make(chan struct{})
+
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+
+			release, err := service.LockAccount("account-1")
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if !errors.Is(err, ErrSyncInProgress) {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				rejected++
+				return
+			}
+			acquired++
+			// Hold the lock until every attempt has been made
+			go func() {
+				<-done
+				release()
+			}()
+		}()
+	}
+
+	close(start)
+	wg.Wait()
+	close(done)
+
+	if acquired != 1 {
+		t.Errorf("Expected exactly one sync to acquire the lock, got %d", acquired)
+	}
+	if rejected != attempts-1 {
+		t.Errorf("Expected %d rejected syncs, got %d", attempts-1, rejected)
+	}
+}
@@ -1,15 +1,23 @@
 package sync
 
 import (
+	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"time"
+	"valhafin/internal/domain/models"
 	"valhafin/internal/repository/database"
 	"valhafin/internal/service/encryption"
 	"valhafin/internal/service/scraper/types"
+	"valhafin/internal/utils"
 )
 
+// ErrUnsupportedPlatform is returned when no scraper exists for the account platform
+var ErrUnsupportedPlatform = errors.New("unsupported platform")
+
 // ScraperFactoryInterface defines the interface for scraper factories
 type ScraperFactoryInterface interface {
 	GetScraper(platform string) (types.Scraper, error)
@@ -20,6 +28,7 @@ type Service struct {
 	db             *database.DB
 	scraperFactory ScraperFactoryInterface
 	encryption     *encryption.EncryptionService
+	locks          accountLocks
 }
 
 // NewService creates a new synchronization service
@@ -31,121 +40,189 @@ func NewService(db *database.DB, scraperFactory ScraperFactoryInterface, encrypt
 	}
 }
 
-// SyncAccount synchronizes transactions for a specific account
-func (s *Service) SyncAccount(accountID string) (*types.SyncResult, error) {
+// SyncAccount synchronizes transactions for a specific account.
+// A result is always returned: its Status is SyncStatusFailed when err is not nil, and
+// SyncStatusPartial when the sync completed but some transactions could not be stored.
+// Concurrent synchronizations of the same account are rejected with ErrSyncInProgress
+func (s *Service) SyncAccount(accountID string) (types.SyncResult, error) {
+	return s.SyncAccountContext(context.Background(), accountID)
+}
+
+// SyncAccountContext is like SyncAccount, logging with the request ID carried by ctx.
+// The synchronization is not canceled with ctx: once started, it runs to completion.
+func (s *Service) SyncAccountContext(ctx context.Context, accountID string) (types.SyncResult, error) {
 	startTime := time.Now()
 
-	result := &types.SyncResult{
+	result := types.SyncResult{
 		AccountID: accountID,
 		StartTime: startTime,
 	}
 
-	// Get account from database
-	account, err := s.db.GetAccountByID(accountID)
-	if err != nil {
-		result.Error = fmt.Sprintf("Failed to retrieve account: %v", err)
+	// fail records a hard failure in the result
+	fail := func(err error) (types.SyncResult, error) {
+		result.Status = types.SyncStatusFailed
+		result.Error = err.Error()
 		result.EndTime = time.Now()
 		result.Duration = time.Since(startTime).String()
-		return result, fmt.Errorf("failed to retrieve account: %w", err)
+		return result, err
 	}
 
+	release, err := s.LockAccount(accountID)
+	if err != nil {
+		return fail(err)
+	}
+	defer release()
+
+	// Get account from database
+	account, err := s.db.GetAccountByID(accountID)
+	if err != nil {
+		return fail(fmt.Errorf("failed to retrieve account: %w", err))
+	}
 	result.Platform = account.Platform
 
 	// Decrypt credentials
-	credentialsJSON, err := s.encryption.Decrypt(account.Credentials)
+	credentialsJSON, _, err := s.encryption.DecryptWithFallback(account.Credentials)
 	if err != nil {
-		result.Error = fmt.Sprintf("Failed to decrypt credentials: %v", err)
-		result.EndTime = time.Now()
-		result.Duration = time.Since(startTime).String()
-		log.Printf("ERROR: Failed to decrypt credentials for account %s: %v", accountID, err)
-		return result, fmt.Errorf("failed to decrypt credentials: %w", err)
+		utils.Logf(ctx, "ERROR: Failed to decrypt credentials for account %s: %v", accountID, err)
+		return fail(fmt.Errorf("failed to decrypt credentials: %w", err))
 	}
 
 	// Parse credentials
 	var credentials map[string]interface{}
 	if err := json.Unmarshal([]byte(credentialsJSON), &credentials); err != nil {
-		result.Error = fmt.Sprintf("Failed to parse credentials: %v", err)
-		result.EndTime = time.Now()
-		result.Duration = time.Since(startTime).String()
-		log.Printf("ERROR: Failed to parse credentials for account %s: %v", accountID, err)
-		return result, fmt.Errorf("failed to parse credentials: %w", err)
+		utils.Logf(ctx, "ERROR: Failed to parse credentials for account %s: %v", accountID, err)
+		return fail(fmt.Errorf("failed to parse credentials: %w", err))
 	}
 
 	// Get appropriate scraper
 	platformScraper, err := s.scraperFactory.GetScraper(account.Platform)
 	if err != nil {
-		result.Error = fmt.Sprintf("Unsupported platform: %v", err)
-		result.EndTime = time.Now()
-		result.Duration = time.Since(startTime).String()
-		log.Printf("ERROR: Unsupported platform for account %s: %v", accountID, err)
-		return result, fmt.Errorf("unsupported platform: %w", err)
+		utils.Logf(ctx, "ERROR: Unsupported platform for account %s: %v", accountID, err)
+		return fail(fmt.Errorf("%w: %s", ErrUnsupportedPlatform, account.Platform))
 	}
 
 	// Determine sync type
 	syncType := "full"
-	if account.LastSync != nil {
+	lastSync := account.LastSync
+	if platform, ok := models.GetPlatform(account.Platform); ok && !platform.IncrementalSync {
+		// The scraper fetches the whole history anyway
+		lastSync = nil
+	}
+	if lastSync != nil {
 		syncType = "incremental"
 	}
 	result.SyncType = syncType
 
-	log.Printf("INFO: Starting %s sync for account %s (platform: %s)", syncType, accountID, account.Platform)
+	utils.Logf(ctx, "INFO: Starting %s sync for account %s (platform: %s)", syncType, accountID, account.Platform)
 
-	// Fetch transactions from platform
-	transactions, err := platformScraper.FetchTransactions(credentials, account.LastSync)
+	// Fetch transactions from platform, with the stored session when the platform requires 2FA
+	transactions, usedSession, err := s.fetchWithSession(ctx, account, platformScraper, lastSync)
+	if !usedSession {
+		transactions, err = platformScraper.FetchTransactions(credentials, lastSync)
+	}
 	if err != nil {
-		result.Error = fmt.Sprintf("Failed to fetch transactions: %v", err)
-		result.EndTime = time.Now()
-		result.Duration = time.Since(startTime).String()
-
 		// Log detailed error information
 		if scraperErr, ok := err.(*types.ScraperError); ok {
-			log.Printf("ERROR: Scraper error for account %s - Type: %s, Platform: %s, Message: %s, Retry: %v",
-				accountID, scraperErr.Type, scraperErr.Platform, scraperErr.Message, scraperErr.Retry)
+			utils.Logf(ctx, "ERROR: Scraper error for account %s - Type: %s, Platform: %s, Message: %s, Retry: %v",
+				accountID, scraperErr.Type, scraperErr.Platform, utils.RedactText(scraperErr.Message), scraperErr.Retry)
 		} else {
-			log.Printf("ERROR: Failed to fetch transactions for account %s: %v", accountID, err)
+			utils.Logf(ctx, "ERROR: Failed to fetch transactions for account %s: %s", accountID, utils.RedactText(err.Error()))
 		}
 
-		return result, fmt.Errorf("failed to fetch transactions: %w", err)
+		return fail(fmt.Errorf("failed to fetch transactions: %w", err))
 	}
 
 	result.TransactionsFetched = len(transactions)
-	log.Printf("INFO: Fetched %d transactions for account %s", len(transactions), accountID)
+	utils.Logf(ctx, "INFO: Fetched %d transactions for account %s", len(transactions), accountID)
 
-	// Set account ID for all transactions
-	for i := range transactions {
-		transactions[i].AccountID = accountID
+	// Invalid transactions are skipped with a warning instead of failing the whole batch
+	valid := make([]models.Transaction, 0, len(transactions))
+	for _, transaction := range transactions {
+		transaction.AccountID = accountID
+		if err := transaction.Validate(); err != nil {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("Transaction %s skipped: %v", transaction.ID, err))
+			continue
+		}
+		valid = append(valid, transaction)
 	}
 
 	// Store transactions in database
-	if len(transactions) > 0 {
-		if err := s.db.CreateTransactionsBatch(transactions, account.Platform); err != nil {
-			result.Error = fmt.Sprintf("Failed to store transactions: %v", err)
-			result.EndTime = time.Now()
-			result.Duration = time.Since(startTime).String()
-			log.Printf("ERROR: Failed to store transactions for account %s: %v", accountID, err)
-			return result, fmt.Errorf("failed to store transactions: %w", err)
+	if len(valid) > 0 {
+		changes, err := s.db.UpsertTransactionsBatch(valid, account.Platform)
+		if err != nil {
+			utils.Logf(ctx, "ERROR: Failed to store transactions for account %s: %v", accountID, err)
+			return fail(fmt.Errorf("failed to store transactions: %w", err))
 		}
-		result.TransactionsStored = len(transactions)
-		log.Printf("INFO: Stored %d transactions for account %s", len(transactions), accountID)
+		result.TransactionsAdded = len(valid)
+		result.Changes = changes
+		utils.Logf(ctx, "INFO: Stored %d transactions for account %s (%d new, %d updated, %d unchanged)",
+			len(valid), accountID, len(changes.Inserted), len(changes.Updated), len(changes.Unchanged))
 	}
 
 	// Update last sync timestamp
 	now := time.Now()
 	if err := s.db.UpdateAccountLastSync(accountID, now); err != nil {
 		// Log warning but don't fail the sync
-		log.Printf("WARNING: Failed to update last sync timestamp for account %s: %v", accountID, err)
+		utils.Logf(ctx, "WARNING: Failed to update last sync timestamp for account %s: %v", accountID, err)
+		result.Warnings = append(result.Warnings, fmt.Sprintf("Failed to update the last sync timestamp: %v", err))
 	}
 
+	result.Status = types.SyncStatusSuccess
+	if len(result.Warnings) > 0 {
+		result.Status = types.SyncStatusPartial
+	}
 	result.EndTime = time.Now()
 	result.Duration = time.Since(startTime).String()
 
-	log.Printf("INFO: Sync completed for account %s - Fetched: %d, Stored: %d, Duration: %s",
-		accountID, result.TransactionsFetched, result.TransactionsStored, result.Duration)
+	utils.Logf(ctx, "INFO: Sync completed for account %s - Status: %s, Fetched: %d, Stored: %d, Duration: %s",
+		accountID, result.Status, result.TransactionsFetched, result.TransactionsAdded, result.Duration)
 
 	return result, nil
 }
 
-// SyncAllAccounts synchronizes all accounts (skips Trade Republic for automatic sync)
+// fetchWithSession fetches the transactions of an account with the session stored after its
+// last two-factor authentication. used is false when the scraper cannot reuse sessions or the
+// account has no valid session; a session refused by the platform (authentication error) is
+// forgotten and used is false too, so that the caller falls back to the credentials and a new
+// 2FA. Other errors (e.g. network) are returned with used true and the session is kept.
+func (s *Service) fetchWithSession(ctx context.Context, account *models.Account, scraper types.Scraper, lastSync *time.Time) (transactions []models.Transaction, used bool, err error) {
+	sessionScraper, ok := scraper.(types.SessionScraper)
+	if !ok {
+		return nil, false, nil
+	}
+
+	session, err := s.db.GetAccountSession(ctx, account.ID)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			utils.Logf(ctx, "WARNING: Failed to get the session of account %s: %v", account.ID, err)
+		}
+		return nil, false, nil
+	}
+	if !session.ValidAt(time.Now()) {
+		utils.Logf(ctx, "INFO: Session of account %s expired at %s, 2FA required", account.ID, session.ExpiresAt.Format(time.RFC3339))
+		return nil, false, nil
+	}
+
+	token, _, err := s.encryption.DecryptWithFallback(session.Token)
+	if err != nil {
+		utils.Logf(ctx, "WARNING: Failed to decrypt the session of account %s: %v", account.ID, err)
+		return nil, false, nil
+	}
+
+	utils.Logf(ctx, "INFO: Reusing the session of account %s (valid until %s)", account.ID, session.ExpiresAt.Format(time.RFC3339))
+	transactions, err = sessionScraper.FetchTransactionsWithToken(token, lastSync)
+	var scraperErr *types.ScraperError
+	if errors.As(err, &scraperErr) && scraperErr.Type == "auth" {
+		utils.Logf(ctx, "WARNING: Session of account %s refused, 2FA required: %s", account.ID, utils.RedactText(err.Error()))
+		if err := s.db.DeleteAccountSession(ctx, account.ID); err != nil {
+			utils.Logf(ctx, "WARNING: %v", err)
+		}
+		return nil, false, nil
+	}
+	return transactions, true, err
+}
+
+// SyncAllAccounts synchronizes all accounts (skips platforms requiring 2FA for automatic sync)
 func (s *Service) SyncAllAccounts() ([]types.SyncResult, error) {
 	accounts, err := s.db.GetAllAccounts()
 	if err != nil {
@@ -155,9 +232,9 @@ func (s *Service) SyncAllAccounts() ([]types.SyncResult, error) {
 	results := make([]types.SyncResult, 0, len(accounts))
 
 	for _, account := range accounts {
-		// Skip Trade Republic accounts for automatic sync (requires 2FA)
-		if account.Platform == "traderepublic" {
-			log.Printf("INFO: Skipping automatic sync for Trade Republic account %s (requires 2FA)", account.ID)
+		// Skip accounts requiring 2FA (e.g. Trade Republic) for automatic sync
+		if platform, ok := models.GetPlatform(account.Platform); ok && platform.RequiresTwoFactor {
+			log.Printf("INFO: Skipping automatic sync for %s account %s (requires 2FA)", platform.Name, account.ID)
 			continue
 		}
 
@@ -166,9 +243,7 @@ func (s *Service) SyncAllAccounts() ([]types.SyncResult, error) {
 			// Continue with other accounts even if one fails
 			log.Printf("WARNING: Failed to sync account %s: %v", account.ID, err)
 		}
-		if result != nil {
-			results = append(results, *result)
-		}
+		results = append(results, result)
 	}
 
 	return results, nil
@@ -1,16 +1,18 @@
 package sync
 
 import (
+	"context"
 	"crypto/rand"
+	"database/sql"
 	"encoding/json"
 	"errors"
-	"fmt"
 	"testing"
 	"time"
 	"valhafin/internal/domain/models"
 	"valhafin/internal/repository/database"
 	"valhafin/internal/service/encryption"
 	"valhafin/internal/service/scraper/types"
+	"valhafin/internal/testutil"
 )
 
 // Helper function to create string pointers
@@ -18,60 +20,9 @@ func stringPtr(s string) *string {
 	return &s
 }
 
-// setupTestDB creates a test database connection
+// setupTestDB creates a test database isolated in its own schema
 func setupTestDB(t *testing.T) *database.DB {
-	cfg := database.Config{
-		Host:     "localhost",
-		Port:     5432,
-		User:     "valhafin",
-		Password: "valhafin",
-		DBName:   "valhafin_test",
-		SSLMode:  "disable",
-	}
-
-	db, err := database.Connect(cfg)
-	if err != nil {
-		t.Skipf("Skipping test: database not available: %v", err)
-		return nil
-	}
-
-	// Run migrations
-	if err := db.RunMigrations(); err != nil {
-		t.Fatalf("Failed to run migrations: %v", err)
-	}
-
-	return db
-}
-
-// cleanupTestDB cleans up test data
-func cleanupTestDB(t *testing.T, db *database.DB) {
-	if db == nil {
-		return
-	}
-
-	// Clean up test transactions first (due to foreign key constraints)
-	platforms := []string{"traderepublic", "binance", "boursedirect"}
-	for _, platform := range platforms {
-		tableName := "transactions_" + platform
-		query := fmt.Sprintf(`
-			DELETE FROM %s 
-			WHERE account_id IN (
-				SELECT id FROM accounts WHERE name LIKE 'Test%%'
-			)
-		`, tableName)
-		_, err := db.Exec(query)
-		if err != nil {
-			// Silently ignore errors during cleanup
-			_ = err
-		}
-	}
-
-	// Clean up test accounts
-	_, err := db.Exec("DELETE FROM accounts WHERE name LIKE 'Test%'")
-	if err != nil {
-		// Silently ignore errors during cleanup
-		_ = err
-	}
+	return testutil.NewTestDB(t)
 }
 
 // setupTestEncryption creates a test encryption service
@@ -167,7 +118,6 @@ func TestProperty4_FullInitialSync(t *testing.T) {
 	if db == nil {
 		return
 	}
-	defer cleanupTestDB(t, db)
 	defer db.Close()
 
 	encryptionService := setupTestEncryption(t)
@@ -253,8 +203,8 @@ func TestProperty4_FullInitialSync(t *testing.T) {
 				t.Errorf("Expected %d transactions fetched, got %d", numTransactions, result.TransactionsFetched)
 			}
 
-			if result.TransactionsStored != numTransactions {
-				t.Errorf("Expected %d transactions stored, got %d", numTransactions, result.TransactionsStored)
+			if result.TransactionsAdded != numTransactions {
+				t.Errorf("Expected %d transactions stored, got %d", numTransactions, result.TransactionsAdded)
 			}
 
 			// Verify transactions are stored in database
@@ -277,7 +227,6 @@ func TestProperty5_IncrementalSync(t *testing.T) {
 	if db == nil {
 		return
 	}
-	defer cleanupTestDB(t, db)
 	defer db.Close()
 
 	encryptionService := setupTestEncryption(t)
@@ -420,6 +369,172 @@ func TestProperty5_IncrementalSync(t *testing.T) {
 	}
 }
 
+func TestSyncAccount_ReportsChanges(t *testing.T) {
+	db := setupTestDB(t)
+	if db == nil {
+		return
+	}
+	defer db.Close()
+
+	encryptionService := setupTestEncryption(t)
+	credentialsJSON, _ := json.Marshal(map[string]interface{}{"phone_number": "+33612345678", "pin": "1234"})
+	encryptedCreds, _ := encryptionService.Encrypt(string(credentialsJSON))
+	account := &models.Account{Name: "Test Account Changes", Platform: "traderepublic", Credentials: encryptedCreds}
+	if err := db.CreateAccount(account); err != nil {
+		t.Fatalf("Failed to create account: %v", err)
+	}
+	defer db.DeleteAccount(account.ID)
+
+	transaction := func(id string, quantity float64) models.Transaction {
+		return models.Transaction{
+			ID:              id,
+			AccountID:       account.ID,
+			Timestamp:       time.Now().Add(-time.Hour).Format(time.RFC3339),
+			Title:           "Apple",
+			AmountCurrency:  "EUR",
+			AmountValue:     -100,
+			TransactionType: "buy",
+			ISIN:            stringPtr("US0378331005"),
+			Quantity:        quantity,
+		}
+	}
+	stored := []models.Transaction{transaction("tx-changes-same", 1), transaction("tx-changes-edited", 1)}
+	if err := db.CreateTransactionsBatch(stored, "traderepublic"); err != nil {
+		t.Fatalf("Failed to store transactions: %v", err)
+	}
+
+	mockFactory := newMockScraperFactory()
+	mockFactory.AddScraper("traderepublic", &mockScraper{
+		platform: "traderepublic",
+		transactions: []models.Transaction{
+			transaction("tx-changes-same", 1),
+			transaction("tx-changes-edited", 2),
+			transaction("tx-changes-new", 1),
+		},
+	})
+
+	result, err := NewService(db, mockFactory, encryptionService).SyncAccount(account.ID)
+	if err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if result.Changes == nil {
+		t.Fatal("Expected the changes in the sync result")
+	}
+	for id, expected := range map[string]string{
+		"tx-changes-same":   models.TransactionUnchanged,
+		"tx-changes-edited": models.TransactionUpdated,
+		"tx-changes-new":    models.TransactionInserted,
+	} {
+		if status := result.Changes.Status(id); status != expected {
+			t.Errorf("Expected %s to be %s, got %q", id, expected, status)
+		}
+	}
+	if result.TransactionsAdded != 3 {
+		t.Errorf("Expected 3 stored transactions, got %d", result.TransactionsAdded)
+	}
+}
+
+// mockSessionScraper requires 2FA for the credentials but accepts a session token; the
+// platform cannot be reached with unreachableToken
+type mockSessionScraper struct {
+	mockScraper
+	validToken       string
+	unreachableToken string
+	tokenCalls       int
+}
+
+func (m *mockSessionScraper) FetchTransactionsWithToken(sessionToken string, lastSync *time.Time) ([]models.Transaction, error) {
+	m.tokenCalls++
+	switch sessionToken {
+	case m.validToken:
+		return m.transactions, nil
+	case m.unreachableToken:
+		return nil, types.NewNetworkError(m.platform, "Mock platform unreachable", nil)
+	}
+	return nil, types.NewAuthError(m.platform, "Mock session refused", nil)
+}
+
+func TestSyncAccount_ReusesValidSession(t *testing.T) {
+	db := setupTestDB(t)
+	if db == nil {
+		return
+	}
+	defer db.Close()
+
+	encryptionService := setupTestEncryption(t)
+	credentialsJSON, _ := json.Marshal(map[string]interface{}{"phone_number": "+33612345678", "pin": "1234"})
+	encryptedCreds, _ := encryptionService.Encrypt(string(credentialsJSON))
+	account := &models.Account{Name: "Test Account Session", Platform: "traderepublic", Credentials: encryptedCreds}
+	if err := db.CreateAccount(account); err != nil {
+		t.Fatalf("Failed to create account: %v", err)
+	}
+	defer db.DeleteAccount(account.ID)
+
+	scraper := &mockSessionScraper{
+		mockScraper: mockScraper{
+			platform:    "traderepublic",
+			shouldError: true,
+			errorType:   "auth",
+			transactions: []models.Transaction{{
+				ID:              "tx-session-1",
+				Timestamp:       time.Now().Add(-time.Hour).Format(time.RFC3339),
+				Title:           "Apple",
+				AmountCurrency:  "EUR",
+				AmountValue:     -100,
+				TransactionType: "buy",
+				ISIN:            stringPtr("US0378331005"),
+				Quantity:        1,
+			}},
+		},
+		validToken:       "session-token",
+		unreachableToken: "unreachable-token",
+	}
+	mockFactory := newMockScraperFactory()
+	mockFactory.AddScraper("traderepublic", scraper)
+	service := NewService(db, mockFactory, encryptionService)
+
+	saveSession := func(token string, expiresAt time.Time) {
+		encrypted, _ := encryptionService.Encrypt(token)
+		session := &models.AccountSession{AccountID: account.ID, Token: encrypted, ExpiresAt: expiresAt.UTC()}
+		if err := db.SaveAccountSession(context.Background(), session); err != nil {
+			t.Fatalf("Failed to save session: %v", err)
+		}
+	}
+
+	// A valid session is reused without 2FA
+	saveSession("session-token", time.Now().Add(time.Hour))
+	result, err := service.SyncAccount(account.ID)
+	if err != nil || result.TransactionsAdded != 1 || scraper.tokenCalls != 1 {
+		t.Fatalf("Expected a sync with the stored session, got %+v, %v", result, err)
+	}
+
+	// An expired session is not used: 2FA is required again
+	saveSession("session-token", time.Now().Add(-time.Minute))
+	if _, err := service.SyncAccount(account.ID); err == nil || scraper.tokenCalls != 1 {
+		t.Errorf("Expected 2FA for an expired session, got %v after %d session calls", err, scraper.tokenCalls)
+	}
+
+	// A network failure fails the sync but keeps the session
+	saveSession("unreachable-token", time.Now().Add(time.Hour))
+	_, err = service.SyncAccount(account.ID)
+	var scraperErr *types.ScraperError
+	if !errors.As(err, &scraperErr) || scraperErr.Type != "network" {
+		t.Errorf("Expected the network error of the session fetch, got %v", err)
+	}
+	if _, err := db.GetAccountSession(context.Background(), account.ID); err != nil {
+		t.Errorf("Expected the session to be kept after a network error, got %v", err)
+	}
+
+	// A session refused by the platform is forgotten
+	saveSession("revoked-token", time.Now().Add(time.Hour))
+	if _, err := service.SyncAccount(account.ID); err == nil {
+		t.Error("Expected 2FA for a refused session")
+	}
+	if _, err := db.GetAccountSession(context.Background(), account.ID); err != sql.ErrNoRows {
+		t.Errorf("Expected the refused session to be deleted, got %v", err)
+	}
+}
+
 // **Propriété 6: Gestion d'erreur de synchronisation**
 // **Valide: Exigences 2.5**
 func TestProperty6_SyncErrorHandling(t *testing.T) {
@@ -427,7 +542,6 @@ func TestProperty6_SyncErrorHandling(t *testing.T) {
 	if db == nil {
 		return
 	}
-	defer cleanupTestDB(t, db)
 	defer db.Close()
 
 	encryptionService := setupTestEncryption(t)
@@ -475,11 +589,11 @@ func TestProperty6_SyncErrorHandling(t *testing.T) {
 			}
 
 			// Verify result contains error information
-			if result == nil {
-				t.Error("Expected result with error info, got nil")
+			if result.Status != types.SyncStatusFailed {
+				t.Errorf("Expected failed status, got %q", result.Status)
 			}
 
-			if result != nil && result.Error == "" {
+			if result.Error == "" {
 				t.Error("Expected error message in result, got empty string")
 			}
 
@@ -496,3 +610,47 @@ func TestProperty6_SyncErrorHandling(t *testing.T) {
 		})
 	}
 }
+
+// TestSyncAccount_PartialResult checks that invalid transactions are skipped with a warning
+// and reported as a partial success instead of failing the whole sync
+func TestSyncAccount_PartialResult(t *testing.T) {
+	db := setupTestDB(t)
+	if db == nil {
+		return
+	}
+	defer db.Close()
+
+	encryptionService := setupTestEncryption(t)
+
+	now := time.Now().Format(time.RFC3339)
+	mockFactory := newMockScraperFactory()
+	mockFactory.AddScraper("binance", &mockScraper{
+		platform: "binance",
+		transactions: []models.Transaction{
+			{ID: "tx-partial-1", Timestamp: now, Title: "Deposit", AmountCurrency: "EUR", AmountValue: 100, TransactionType: "deposit", Metadata: stringPtr("{}")},
+			{ID: "tx-partial-2", Timestamp: "yesterday", Title: "Deposit", AmountCurrency: "EUR", AmountValue: 50, TransactionType: "deposit", Metadata: stringPtr("{}")},
+		},
+	})
+	syncService := NewService(db, mockFactory, encryptionService)
+
+	encryptedCreds, _ := encryptionService.Encrypt(`{"api_key":"key","api_secret":"secret"}`)
+	account := &models.Account{Name: "Test Account Partial", Platform: "binance", Credentials: encryptedCreds}
+	if err := db.CreateAccount(account); err != nil {
+		t.Fatalf("Failed to create account: %v", err)
+	}
+	defer db.DeleteAccount(account.ID)
+
+	result, err := syncService.SyncAccount(account.ID)
+	if err != nil {
+		t.Fatalf("Expected a partial success, got %v", err)
+	}
+	if result.Status != types.SyncStatusPartial {
+		t.Errorf("Expected partial status, got %q", result.Status)
+	}
+	if result.TransactionsFetched != 2 || result.TransactionsAdded != 1 {
+		t.Errorf("Expected 2 fetched and 1 added, got %d and %d", result.TransactionsFetched, result.TransactionsAdded)
+	}
+	if len(result.Warnings) != 1 {
+		t.Errorf("Expected one warning for the skipped transaction, got %v", result.Warnings)
+	}
+}
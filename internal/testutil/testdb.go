@@ -0,0 +1,28 @@
+// Package testutil provides helpers shared by the tests of several packages
+package testutil
+
+import (
+	"errors"
+	"testing"
+	"valhafin/internal/repository/database"
+)
+
+// NewTestDB connects to the test database in a schema of its own, runs the
+// migrations in it and drops it when the test ends. Every test therefore starts
+// from an empty database and leaves nothing behind, even when it fails.
+// The test is skipped when the database is not available.
+func NewTestDB(t testing.TB) *database.DB {
+	t.Helper()
+
+	db, drop, err := database.OpenTestSchema()
+	if errors.Is(err, database.ErrTestDatabaseUnavailable) {
+		t.Skipf("Skipping test: %v", err)
+		return nil
+	}
+	if err != nil {
+		t.Fatalf("Failed to open test database: %v", err)
+	}
+	t.Cleanup(drop)
+
+	return db
+}
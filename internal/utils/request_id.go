@@ -0,0 +1,52 @@
+package utils
+
+import (
+	"context"
+	"log"
+	"regexp"
+
+	"github.com/google/uuid"
+)
+
+// RequestIDHeader carries the ID correlating the logs of a request
+const RequestIDHeader = "X-Request-ID"
+
+// requestIDPattern bounds the request IDs accepted from clients, so that they cannot
+// inject anything in the logs
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+type requestIDKey struct{}
+
+// NewRequestID generates a request ID
+func NewRequestID() string {
+	return uuid.New().String()
+}
+
+// IsValidRequestID reports whether a request ID received from a client can be reused
+func IsValidRequestID(id string) bool {
+	return requestIDPattern.MatchString(id)
+}
+
+// WithRequestID returns a copy of ctx carrying the request ID
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the request ID carried by ctx, or an empty string
+func RequestID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// Logf logs like log.Printf, prefixing the message with the request ID carried by ctx
+// so that the logs of a request can be followed among the interleaved ones
+func Logf(ctx context.Context, format string, args ...interface{}) {
+	if id := RequestID(ctx); id != "" {
+		log.Printf("[request_id=%s] "+format, append([]interface{}{id}, args...)...)
+		return
+	}
+	log.Printf(format, args...)
+}
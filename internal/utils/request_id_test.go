@@ -0,0 +1,45 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestLogf_PrefixesRequestID(t *testing.T) {
+	var logBuf bytes.Buffer
+	log.SetOutput(&logBuf)
+	defer log.SetOutput(os.Stderr)
+
+	Logf(WithRequestID(context.Background(), "abc-123"), "INFO: Synced %d%% of account %s", 50, "acc")
+	Logf(context.Background(), "INFO: no request")
+
+	lines := strings.Split(strings.TrimSpace(logBuf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 log lines, got %q", logBuf.String())
+	}
+	if !strings.HasSuffix(lines[0], "[request_id=abc-123] INFO: Synced 50% of account acc") {
+		t.Errorf("unexpected log line: %q", lines[0])
+	}
+	if strings.Contains(lines[1], "request_id") || !strings.HasSuffix(lines[1], "INFO: no request") {
+		t.Errorf("unexpected log line without request ID: %q", lines[1])
+	}
+}
+
+func TestIsValidRequestID(t *testing.T) {
+	for id, expected := range map[string]bool{
+		"0b6f3c1e-8f4a-4b7e-9a51-2f1e0c3d4b5a": true,
+		"trace.42:span_7":                      true,
+		"":                                     false,
+		"with space":                           false,
+		"line\nbreak":                          false,
+		strings.Repeat("a", 129):               false,
+	} {
+		if IsValidRequestID(id) != expected {
+			t.Errorf("IsValidRequestID(%q) = %v, expected %v", id, !expected, expected)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package utils
+
+import (
+	"encoding/json"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// RedactedValue replaces sensitive values in logs
+const RedactedValue = "[REDACTED]"
+
+// defaultSensitiveLogKeys are always redacted from logged query strings, maps and messages
+var defaultSensitiveLogKeys = []string{"pin", "password", "api_secret", "code", "secret", "token", "session_token"}
+
+var (
+	sensitiveKeysMu  sync.RWMutex
+	sensitiveKeys    = keySet(defaultSensitiveLogKeys)
+	sensitivePattern = buildSensitivePattern(defaultSensitiveLogKeys)
+)
+
+// SetSensitiveLogKeys adds keys to redact on top of the defaults (case-insensitive)
+func SetSensitiveLogKeys(extra []string) {
+	keys := append([]string{}, defaultSensitiveLogKeys...)
+	for _, key := range extra {
+		if key = strings.TrimSpace(key); key != "" {
+			keys = append(keys, key)
+		}
+	}
+
+	sensitiveKeysMu.Lock()
+	defer sensitiveKeysMu.Unlock()
+	sensitiveKeys = keySet(keys)
+	sensitivePattern = buildSensitivePattern(keys)
+}
+
+// SensitiveLogKeys returns the keys currently redacted, sorted
+func SensitiveLogKeys() []string {
+	sensitiveKeysMu.RLock()
+	defer sensitiveKeysMu.RUnlock()
+
+	keys := make([]string, 0, len(sensitiveKeys))
+	for key := range sensitiveKeys {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// IsSensitiveLogKey reports whether values of the key must not be logged
+func IsSensitiveLogKey(key string) bool {
+	sensitiveKeysMu.RLock()
+	defer sensitiveKeysMu.RUnlock()
+	return sensitiveKeys[strings.ToLower(key)]
+}
+
+// RedactURI redacts sensitive parameters from the query string of a request URI
+func RedactURI(uri string) string {
+	path, query, found := strings.Cut(uri, "?")
+	if !found {
+		return uri
+	}
+	return path + "?" + RedactQuery(query)
+}
+
+// RedactQuery redacts the values of sensitive parameters in a raw query string, keeping the parameter order
+func RedactQuery(rawQuery string) string {
+	if rawQuery == "" {
+		return rawQuery
+	}
+
+	params := strings.Split(rawQuery, "&")
+	for i, param := range params {
+		key, _, hasValue := strings.Cut(param, "=")
+		name, err := url.QueryUnescape(key)
+		if err != nil {
+			name = key
+		}
+		if hasValue && IsSensitiveLogKey(name) {
+			params[i] = key + "=" + RedactedValue
+		}
+	}
+	return strings.Join(params, "&")
+}
+
+// RedactMap returns a copy of the map with the values of sensitive keys redacted, including nested maps and lists
+func RedactMap(m map[string]interface{}) map[string]interface{} {
+	redacted := make(map[string]interface{}, len(m))
+	for key, value := range m {
+		if IsSensitiveLogKey(key) {
+			redacted[key] = RedactedValue
+			continue
+		}
+		redacted[key] = redactValue(value)
+	}
+	return redacted
+}
+
+func redactValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return RedactMap(v)
+	case []interface{}:
+		items := make([]interface{}, len(v))
+		for i, item := range v {
+			items[i] = redactValue(item)
+		}
+		return items
+	default:
+		return value
+	}
+}
+
+// RedactJSON returns a JSON body with sensitive fields redacted, suitable for logging.
+// Bodies that are not JSON are redacted as free text.
+func RedactJSON(body []byte) string {
+	var payload interface{}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return RedactText(string(body))
+	}
+
+	encoded, err := json.Marshal(redactValue(payload))
+	if err != nil {
+		return RedactedValue
+	}
+	return string(encoded)
+}
+
+// RedactText redacts "key=value", "key: value" and "\"key\":\"value\"" pairs of sensitive keys
+// in a free-form message such as an error
+func RedactText(text string) string {
+	sensitiveKeysMu.RLock()
+	pattern := sensitivePattern
+	sensitiveKeysMu.RUnlock()
+
+	return pattern.ReplaceAllString(text, "${1}"+RedactedValue)
+}
+
+func keySet(keys []string) map[string]bool {
+	set := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		set[strings.ToLower(key)] = true
+	}
+	return set
+}
+
+// buildSensitivePattern matches a sensitive key followed by = or : and captures the prefix before the value
+func buildSensitivePattern(keys []string) *regexp.Regexp {
+	quoted := make([]string, len(keys))
+	for i, key := range keys {
+		quoted[i] = regexp.QuoteMeta(key)
+	}
+	return regexp.MustCompile(`(?i)(\b(?:` + strings.Join(quoted, "|") + `)"?\s*[:=]\s*"?)[^"&\s,;}\]]+`)
+}
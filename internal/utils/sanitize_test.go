@@ -0,0 +1,84 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRedactURI(t *testing.T) {
+	tests := map[string]string{
+		"/api/accounts":                           "/api/accounts",
+		"/api/sync?pin=1234&page=2":               "/api/sync?pin=[REDACTED]&page=2",
+		"/api/sync?page=2&Password=hunter2&code=": "/api/sync?page=2&Password=[REDACTED]&code=[REDACTED]",
+		"/api/sync?api_secret=abc%20def&q=world":  "/api/sync?api_secret=[REDACTED]&q=world",
+		"/api/search?q=code":                      "/api/search?q=code",
+	}
+
+	for uri, want := range tests {
+		if got := RedactURI(uri); got != want {
+			t.Errorf("RedactURI(%q) = %q, want %q", uri, got, want)
+		}
+	}
+}
+
+func TestRedactMap(t *testing.T) {
+	input := map[string]interface{}{
+		"name": "Trade Republic",
+		"credentials": map[string]interface{}{
+			"phone_number": "+33612345678",
+			"PIN":          "4821",
+		},
+		"keys": []interface{}{map[string]interface{}{"api_secret": "s3cr3t"}},
+	}
+
+	redacted := RedactMap(input)
+
+	credentials := redacted["credentials"].(map[string]interface{})
+	if credentials["PIN"] != RedactedValue {
+		t.Errorf("nested PIN not redacted: %v", credentials["PIN"])
+	}
+	if credentials["phone_number"] != "+33612345678" {
+		t.Errorf("non sensitive value changed: %v", credentials["phone_number"])
+	}
+	keys := redacted["keys"].([]interface{})
+	if keys[0].(map[string]interface{})["api_secret"] != RedactedValue {
+		t.Errorf("api_secret in list not redacted: %v", keys[0])
+	}
+	if input["credentials"].(map[string]interface{})["PIN"] != "4821" {
+		t.Error("RedactMap must not modify its input")
+	}
+}
+
+func TestRedactJSONAndText(t *testing.T) {
+	body := []byte(`{"process_id":"abc","code":"998877","credentials":{"pin":"4821"}}`)
+	if got := RedactJSON(body); strings.Contains(got, "998877") || strings.Contains(got, "4821") || !strings.Contains(got, "abc") {
+		t.Errorf("RedactJSON() = %s", got)
+	}
+
+	messages := []string{
+		`login failed: pin=4821 rejected`,
+		`invalid payload map[code:998877 pin:4821]`,
+		`request {"pin": "4821", "password":"hunter2"}`,
+	}
+	for _, message := range messages {
+		got := RedactText(message)
+		for _, secret := range []string{"4821", "998877", "hunter2"} {
+			if strings.Contains(got, secret) {
+				t.Errorf("RedactText(%q) = %q leaks %s", message, got, secret)
+			}
+		}
+	}
+}
+
+func TestSetSensitiveLogKeys(t *testing.T) {
+	defer SetSensitiveLogKeys(nil)
+
+	SetSensitiveLogKeys([]string{"Phone_Number"})
+
+	if !IsSensitiveLogKey("phone_number") || !IsSensitiveLogKey("pin") {
+		t.Errorf("expected custom and default keys, got %v", SensitiveLogKeys())
+	}
+	if got := RedactQuery("phone_number=%2B33612345678"); got != "phone_number=[REDACTED]" {
+		t.Errorf("RedactQuery() = %q", got)
+	}
+}